@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,15 +21,6 @@ import (
 )
 
 func main() {
-	// Set timezone to local timezone (Jakarta/GMT+7) to avoid conversion issues
-	// Get the local timezone for Jakarta
-	local, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		fmt.Printf("Warning: Could not load Asia/Jakarta timezone: %v\n", err)
-		local = time.Local
-	}
-	time.Local = local
-
 	// Load configuration
 	cfg := config.Load()
 
@@ -57,20 +47,137 @@ func main() {
 		logger.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Times are stored in UTC; clients without a timezone use the agency's
+	agencyLocation, err := time.LoadLocation(cfg.AgencyTimezone)
+	if err != nil {
+		logger.Fatalf("Invalid agency timezone %q: %v", cfg.AgencyTimezone, err)
+	}
+
 	// Initialize repositories
 	scheduleRepo := repositories.NewScheduleRepository(db)
 	visitRepo := repositories.NewVisitRepository(db)
 	taskRepo := repositories.NewTaskRepository(db)
+	segmentRepo := repositories.NewScheduleSegmentRepository(db)
+	visitSegmentRepo := repositories.NewVisitSegmentRepository(db)
+	caregiverRepo := repositories.NewScheduleCaregiverRepository(db)
 	clientRepo := repositories.NewClientRepository(db)
+	availabilityRepo := repositories.NewAvailabilityRepository(db)
+	timeOffRepo := repositories.NewTimeOffRepository(db)
+	skillRepo := repositories.NewSkillRepository(db)
+	serviceTypeRepo := repositories.NewServiceTypeRepository(db)
+	profileRepo := repositories.NewCaregiverProfileRepository(db)
+	preferenceRepo := repositories.NewClientPreferenceRepository(db)
+	openShiftRepo := repositories.NewOpenShiftRepository(db)
+	swapRepo := repositories.NewSwapRequestRepository(db)
+	shiftTransitionRepo := repositories.NewShiftTransitionRepository(db)
+	travelRepo := repositories.NewTravelSegmentRepository(db)
+	trackRepo := repositories.NewLocationTrackRepository(db)
+	clockEventRepo := repositories.NewClockEventRepository(db)
+	visitReviewRepo := repositories.NewVisitReviewRepository(db)
+	verificationTokenRepo := repositories.NewClientVerificationTokenRepository(db)
+	telephonyPINRepo := repositories.NewTelephonyPINRepository(db)
+	correctionRepo := repositories.NewVisitCorrectionRepository(db)
+	geoPlaceRepo := repositories.NewGeoPlaceRepository(db)
+	taskTemplateRepo := repositories.NewTaskTemplateRepository(db)
+	carePlanRepo := repositories.NewCarePlanRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
 
 	// Initialize services
-	scheduleService := services.NewScheduleService(scheduleRepo, visitRepo, taskRepo, logger)
-	visitService := services.NewVisitService(visitRepo, logger)
-	taskService := services.NewTaskService(taskRepo, logger)
-	clientService := services.NewClientService(clientRepo, logger)
+	scheduleService := services.NewScheduleService(services.ScheduleRepositories{
+		Schedules:     scheduleRepo,
+		Visits:        visitRepo,
+		Tasks:         taskRepo,
+		Segments:      segmentRepo,
+		VisitSegments: visitSegmentRepo,
+		Caregivers:    caregiverRepo,
+	}, agencyLocation, logger)
+	visitService := services.NewVisitService(visitRepo, visitSegmentRepo, logger)
+	taskService := services.NewTaskService(taskRepo, caregiverRepo, logger)
+	geocoderService := services.NewGeocoderService(geoPlaceRepo, clientRepo, visitRepo, float64(cfg.GeofenceRadiusMeters), float64(cfg.GeocodeMaxDistanceMeters), logger)
+	clientService := services.NewClientService(clientRepo, addressGeocoder(cfg.Geocoder, geocoderService), logger)
+	availabilityService := services.NewAvailabilityService(availabilityRepo, timeOffRepo, scheduleRepo, agencyLocation, logger)
+	skillService := services.NewSkillService(skillRepo, serviceTypeRepo, logger)
+	matchingService := services.NewMatchingService(scheduleRepo, caregiverRepo, clientRepo, profileRepo, preferenceRepo, availabilityRepo, availabilityService, skillService, agencyLocation, logger)
+	shiftBoardService := services.NewShiftBoardService(openShiftRepo, swapRepo, shiftTransitionRepo, scheduleRepo, scheduleService, logger)
+	routeService := services.NewRouteService(scheduleRepo, services.StraightLineMatrix{SpeedKmh: float64(cfg.RouteAverageSpeedKmh)}, agencyLocation, logger)
+	mileageService := services.NewMileageService(scheduleRepo, visitRepo, travelRepo, agencyLocation, logger)
+	trackingService := services.NewTrackingService(trackRepo, scheduleRepo, visitRepo, float64(cfg.GeofenceRadiusMeters), logger)
+	anomalyService := services.NewAnomalyService(clockEventRepo, visitReviewRepo, scheduleRepo, float64(cfg.MaxTravelSpeedKmh), cfg.VisitReviewRiskThreshold, logger)
+	verificationService := services.NewVerificationService(verificationTokenRepo, clientRepo, cfg.VerificationCodeSkewSteps, logger)
+	telephonyService := services.NewTelephonyService(telephonyPINRepo, clientRepo, scheduleService, logger)
+	correctionService := services.NewVisitCorrectionService(correctionRepo, scheduleRepo, visitRepo, scheduleService, logger)
+	carePlanService := services.NewCarePlanService(taskTemplateRepo, carePlanRepo, clientRepo, agencyLocation, logger)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyTTL, logger)
+
+	// Caregivers can only be booked when they are available, qualified and not
+	// blocked by the client
+	scheduleService.OnAssign(availabilityService.ValidateAssignment)
+	scheduleService.OnAssign(skillService.ValidateAssignment)
+	scheduleService.OnAssign(matchingService.ValidateAssignment)
+
+	// Travel between visits is measured at each clock-in and shown on timesheets
+	scheduleService.OnClockIn(mileageService.RecordClockIn)
+	scheduleService.UseMileage(mileageService)
+
+	// Location pings sent during visits are summarized on each visit
+	scheduleService.UseVisitTracks(trackingService)
+
+	// Clock-in and clock-out locations are scored for spoofing and high-risk
+	// visits queued for review
+	scheduleService.OnClockEvent(anomalyService.EvaluateClockEvent)
+	scheduleService.UseVisitRisks(anomalyService)
+
+	// Clock-in and clock-out locations outside the client geofence are
+	// resolved to the nearest address of the offline place dataset
+	scheduleService.OnClockEvent(geocoderService.ResolveClockEvent)
+
+	// Caregivers without a GPS fix verify visits with the code on the
+	// client's QR card or NFC tag
+	scheduleService.UseVisitVerifier(verificationService)
+
+	// New schedules take their tasks from the client's care plan
+	scheduleService.UseTaskPlanner(carePlanService)
+
+	// Required tasks left not completed must give a reason code of the
+	// schedule's service type before the caregiver can clock out
+	scheduleService.UseReasonCodes(skillService)
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go idempotencyService.RunCleanup(jobsCtx, cfg.IdempotencyCleanupInterval)
+	go skillService.RunExpiryReport(jobsCtx, cfg.CertificationReportInterval, cfg.CertificationExpiryDays)
+	go trackingService.RunRetention(jobsCtx, cfg.LocationPurgeInterval, cfg.LocationRetention)
+	go scheduleService.RunMissedSweep(jobsCtx, cfg.MissedSweepInterval)
+	if cfg.GeocoderDataset != "" {
+		go func() {
+			if _, err := geocoderService.ImportFile(cfg.GeocoderDataset, cfg.GeocoderDatasetFormat); err != nil {
+				logger.WithError(err).Error("Failed to import geocoder dataset")
+			}
+		}()
+	}
 
 	// Initialize handlers
-	handler := handlers.NewHandler(scheduleService, visitService, taskService, clientService, logger)
+	handler := handlers.NewHandler(handlers.Services{
+		Schedule:     scheduleService,
+		Visit:        visitService,
+		Task:         taskService,
+		Client:       clientService,
+		Availability: availabilityService,
+		Skill:        skillService,
+		Matching:     matchingService,
+		ShiftBoard:   shiftBoardService,
+		Route:        routeService,
+		Mileage:      mileageService,
+		Tracking:     trackingService,
+		Anomaly:      anomalyService,
+		Verification: verificationService,
+		Telephony:    telephonyService,
+		Correction:   correctionService,
+		Geocoder:     geocoderService,
+		CarePlan:     carePlanService,
+		Idempotency:  idempotencyService,
+	}, agencyLocation, logger)
 
 	// Setup router
 	router := handler.SetupRoutes()
@@ -105,3 +212,13 @@ func main() {
 
 	logger.Info("Server exited")
 }
+
+// addressGeocoder returns the geocoder client addresses are placed with: the
+// offline place dataset, or with "stub" a geocoder that places no address so
+// that clients must be given their coordinates
+func addressGeocoder(name string, offline *services.GeocoderService) services.AddressGeocoder {
+	if name == "stub" {
+		return services.StubGeocoder{}
+	}
+	return offline
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config holds all configuration for the application
@@ -10,6 +12,54 @@ type Config struct {
 	Port        string
 	DatabaseURL string
 	LogLevel    string
+
+	// AgencyTimezone is the IANA timezone used for clients without their own.
+	// It defaults to Asia/Jakarta, the zone the whole service ran in before
+	// clients had timezones, so their day boundaries stay where they were.
+	AgencyTimezone string
+
+	// IdempotencyTTL is how long Idempotency-Key responses are kept for replay
+	IdempotencyTTL time.Duration
+	// IdempotencyCleanupInterval is how often expired keys are purged
+	IdempotencyCleanupInterval time.Duration
+
+	// CertificationExpiryDays is how far ahead the expiry report looks
+	CertificationExpiryDays int
+	// CertificationReportInterval is how often expiring certifications are reported
+	CertificationReportInterval time.Duration
+
+	// RouteAverageSpeedKmh turns straight-line distances into route travel times
+	RouteAverageSpeedKmh int
+
+	// GeofenceRadiusMeters is how far from the client a caregiver counts as at the visit
+	GeofenceRadiusMeters int
+	// LocationRetention is how long raw visit location pings are kept
+	LocationRetention time.Duration
+	// LocationPurgeInterval is how often expired location pings are purged
+	LocationPurgeInterval time.Duration
+
+	// MissedSweepInterval is how often ended schedules nobody clocked in to are marked missed
+	MissedSweepInterval time.Duration
+
+	// MaxTravelSpeedKmh is the fastest plausible travel between two clock events
+	MaxTravelSpeedKmh int
+	// VisitReviewRiskThreshold is the spoofing risk score from which visits are queued for review
+	VisitReviewRiskThreshold int
+
+	// VerificationCodeSkewSteps is how many 30 second steps a client verification
+	// code may be behind or ahead of the server clock
+	VerificationCodeSkewSteps int
+
+	// GeocoderDataset is a GeoNames dump or place CSV file imported for
+	// reverse geocoding at startup; empty imports nothing
+	GeocoderDataset string
+	// GeocoderDatasetFormat is geonames or csv; empty guesses from the file extension
+	GeocoderDatasetFormat string
+	// Geocoder places client addresses: offline uses the imported place
+	// dataset, stub places none so that clients need coordinates
+	Geocoder string
+	// GeocodeMaxDistanceMeters is how far the nearest place may be from a location it describes
+	GeocodeMaxDistanceMeters int
 }
 
 // Load loads configuration from environment variables with defaults
@@ -19,6 +69,32 @@ func Load() *Config {
 		Port:        getEnv("PORT", "8080"),
 		DatabaseURL: getEnv("DATABASE_URL", "caregiver_shift_tracker.db"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
+
+		AgencyTimezone: getEnv("AGENCY_TIMEZONE", "Asia/Jakarta"),
+
+		IdempotencyTTL:             getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyCleanupInterval: getDurationEnv("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+
+		CertificationExpiryDays:     getIntEnv("CERTIFICATION_EXPIRY_DAYS", 30),
+		CertificationReportInterval: getDurationEnv("CERTIFICATION_REPORT_INTERVAL", 24*time.Hour),
+
+		RouteAverageSpeedKmh: getIntEnv("ROUTE_AVERAGE_SPEED_KMH", 40),
+
+		GeofenceRadiusMeters:  getIntEnv("GEOFENCE_RADIUS_METERS", 150),
+		LocationRetention:     getDurationEnv("LOCATION_RETENTION", 30*24*time.Hour),
+		LocationPurgeInterval: getDurationEnv("LOCATION_PURGE_INTERVAL", time.Hour),
+
+		MissedSweepInterval: getDurationEnv("MISSED_SWEEP_INTERVAL", 5*time.Minute),
+
+		MaxTravelSpeedKmh:        getIntEnv("MAX_TRAVEL_SPEED_KMH", 120),
+		VisitReviewRiskThreshold: getIntEnv("VISIT_REVIEW_RISK_THRESHOLD", 50),
+
+		VerificationCodeSkewSteps: getIntEnv("VERIFICATION_CODE_SKEW_STEPS", 1),
+
+		GeocoderDataset:          getEnv("GEOCODER_DATASET", ""),
+		GeocoderDatasetFormat:    getEnv("GEOCODER_DATASET_FORMAT", ""),
+		Geocoder:                 getEnv("GEOCODER", "offline"),
+		GeocodeMaxDistanceMeters: getIntEnv("GEOCODE_MAX_DISTANCE_METERS", 5000),
 	}
 }
 
@@ -29,3 +105,23 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getDurationEnv gets a duration environment variable (e.g. "24h") with a fallback value
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// getIntEnv gets a positive integer environment variable with a fallback value
+func getIntEnv(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil && i > 0 {
+			return i
+		}
+	}
+	return fallback
+}
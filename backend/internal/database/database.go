@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
@@ -113,9 +115,34 @@ func Migrate(db *sql.DB) error {
 	migrations := []string{
 		createClientsTable,
 		createSchedulesTable,
+		createScheduleCaregiversTable,
 		createVisitsTable,
 		createTasksTable,
-		insertSampleData,
+		createScheduleSegmentsTable,
+		createVisitSegmentsTable,
+		createIdempotencyKeysTable,
+		createCaregiverPreferencesTable,
+		createCaregiverAvailabilityTable,
+		createTimeOffRequestsTable,
+		createCaregiverSkillsTable,
+		createServiceTypesTable,
+		createCaregiverProfilesTable,
+		createClientPreferencesTable,
+		createOpenShiftsTable,
+		createSwapRequestsTable,
+		createShiftTransitionsTable,
+		createTravelSegmentsTable,
+		createVisitLocationPointsTable,
+		createVisitTracksTable,
+		createClockEventsTable,
+		createVisitReviewsTable,
+		createClientVerificationTokensTable,
+		createCaregiverTelephonyPinsTable,
+		createVisitCorrectionsTable,
+		createGeoPlacesTable,
+		createClientsIndexTable,
+		createTaskTemplatesTable,
+		createCarePlansTable,
 	}
 
 	for i, migration := range migrations {
@@ -124,9 +151,261 @@ func Migrate(db *sql.DB) error {
 		}
 	}
 
+	if err := upgradeSchema(db); err != nil {
+		return fmt.Errorf("failed to upgrade schema: %w", err)
+	}
+
+	// Indexes are created after upgradeSchema since table rebuilds drop them
+	if _, err := db.Exec(createIndexes); err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+
+	if _, err := db.Exec(insertSampleData); err != nil {
+		return fmt.Errorf("failed to insert sample data: %w", err)
+	}
+
+	return nil
+}
+
+// upgradeSchema brings tables created by earlier versions up to date
+func upgradeSchema(db *sql.DB) error {
+	if err := addColumnIfNotExists(db, "visits", "location_status", "TEXT DEFAULT 'confirmed'"); err != nil {
+		return err
+	}
+
+	// IANA timezone of the client; empty means the agency default
+	if err := addColumnIfNotExists(db, "clients", "timezone", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Row versions used for optimistic concurrency control
+	for _, table := range []string{"clients", "schedules", "visits", "tasks"} {
+		if err := addColumnIfNotExists(db, table, "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+			return err
+		}
+	}
+
+	// SQLite cannot alter CHECK constraints, so the schedules table is rebuilt
+	// when it predates the cancelled status
+	if err := rebuildTableUnless(db, "schedules", "'cancelled'", createSchedulesTable); err != nil {
+		return err
+	}
+
+	// Minutes a visit may move around its booked start when planning routes
+	if err := addColumnIfNotExists(db, "schedules", "flex_minutes", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Team visits record one visit per caregiver, so the visits table is
+	// rebuilt to replace its unique schedule_id with a per-caregiver key
+	if err := rebuildTableUnless(db, "visits", "UNIQUE (schedule_id, caregiver_id)", createVisitsTable); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "visit_segments", "caregiver_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "tasks", "caregiver_id", "INTEGER"); err != nil {
+		return err
+	}
+	if _, err := db.Exec(backfillScheduleCaregivers); err != nil {
+		return fmt.Errorf("failed to backfill schedule caregivers: %w", err)
+	}
+
+	// How presence was verified at each clock event: GPS, a client
+	// verification code or both
+	for _, column := range []string{"start_verification_method", "end_verification_method"} {
+		if err := addColumnIfNotExists(db, "visits", column, "TEXT"); err != nil {
+			return err
+		}
+	}
+	if err := addColumnIfNotExists(db, "clock_events", "verification_method", "TEXT NOT NULL DEFAULT 'gps'"); err != nil {
+		return err
+	}
+
+	// Clock-in and clock-out as recorded, kept when an approved correction
+	// amends a visit's times
+	for _, column := range []string{"original_start_time", "original_end_time", "amended_at"} {
+		if err := addColumnIfNotExists(db, "visits", column, "DATETIME"); err != nil {
+			return err
+		}
+	}
+	if err := addColumnIfNotExists(db, "visits", "amendment_reason", "TEXT"); err != nil {
+		return err
+	}
+
+	// How client coordinates were found from their address
+	if err := addColumnIfNotExists(db, "clients", "geocode_match", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "clients", "geocode_confidence", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "clients", "geocoded_at", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Addresses of clock-in and clock-out locations outside the client geofence
+	for _, column := range []string{"start_address", "end_address"} {
+		if err := addColumnIfNotExists(db, "visits", column, "TEXT"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.Exec(backfillClientsIndex); err != nil {
+		return fmt.Errorf("failed to backfill clients index: %w", err)
+	}
+
+	// Care plan version a schedule's tasks were created from, and the
+	// template each task came from
+	if err := addColumnIfNotExists(db, "schedules", "care_plan_id", "INTEGER"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "tasks", "template_id", "INTEGER"); err != nil {
+		return err
+	}
+
+	// Required tasks and the reason codes that excuse them at clock-out
+	if err := addColumnIfNotExists(db, "tasks", "required", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "care_plan_items", "required", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "service_types", "reason_codes", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(db, "idempotency_keys", "response_headers", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// tableColumns returns the column names of a table in definition order
+func tableColumns(ctx context.Context, q queryer, table string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, rows.Err()
+}
+
+// addColumnIfNotExists adds a column to an existing table unless it is already present
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	columns, err := tableColumns(context.Background(), db, table)
+	if err != nil {
+		return err
+	}
+	for _, existing := range columns {
+		if existing == column {
+			return nil
+		}
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+
 	return nil
 }
 
+// rebuildTableUnless recreates a table from createSQL, copying its data, unless
+// the stored table definition already contains marker
+func rebuildTableUnless(db *sql.DB, table, marker, createSQL string) error {
+	var current string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read definition of %s: %w", table, err)
+	}
+	if strings.Contains(current, marker) {
+		return nil
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Foreign keys must be off so dropping the old table does not cascade
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin rebuild of %s: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	newTable := table + "_new"
+	create := strings.Replace(createSQL, "EXISTS "+table+" (", "EXISTS "+newTable+" (", 1)
+	if _, err := tx.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create %s: %w", newTable, err)
+	}
+
+	columns, err := sharedColumns(ctx, tx, table, newTable)
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", newTable, columns, columns, table),
+		fmt.Sprintf("DROP TABLE %s", table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", newTable, table),
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to rebuild %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// sharedColumns returns the comma separated columns present in both tables
+func sharedColumns(ctx context.Context, tx *sql.Tx, from, to string) (string, error) {
+	fromColumns, err := tableColumns(ctx, tx, from)
+	if err != nil {
+		return "", err
+	}
+	toColumns, err := tableColumns(ctx, tx, to)
+	if err != nil {
+		return "", err
+	}
+
+	existing := make(map[string]bool, len(fromColumns))
+	for _, column := range fromColumns {
+		existing[column] = true
+	}
+
+	var shared []string
+	for _, column := range toColumns {
+		if existing[column] {
+			shared = append(shared, column)
+		}
+	}
+
+	return strings.Join(shared, ", "), nil
+}
+
 const createClientsTable = `
 CREATE TABLE IF NOT EXISTS clients (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -140,9 +419,14 @@ CREATE TABLE IF NOT EXISTS clients (
     latitude REAL DEFAULT 0,
     longitude REAL DEFAULT 0,
     notes TEXT,
+    timezone TEXT NOT NULL DEFAULT '',
     is_active BOOLEAN DEFAULT 1,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    geocode_match TEXT NOT NULL DEFAULT '',
+    geocode_confidence REAL NOT NULL DEFAULT 0,
+    geocoded_at DATETIME
 );`
 
 const createSchedulesTable = `
@@ -153,27 +437,53 @@ CREATE TABLE IF NOT EXISTS schedules (
     caregiver_id INTEGER NOT NULL,
     start_time DATETIME NOT NULL,
     end_time DATETIME NOT NULL,
-    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'in_progress', 'completed', 'missed')),
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'in_progress', 'completed', 'missed', 'cancelled')),
     notes TEXT,
+    flex_minutes INTEGER NOT NULL DEFAULT 0,
+    care_plan_id INTEGER,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (client_id) REFERENCES clients(id)
 );`
 
+const createScheduleCaregiversTable = `
+CREATE TABLE IF NOT EXISTS schedule_caregivers (
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('lead', 'member')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schedule_id, caregiver_id),
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_schedule_caregivers_caregiver_id ON schedule_caregivers(caregiver_id, schedule_id);`
+
 const createVisitsTable = `
 CREATE TABLE IF NOT EXISTS visits (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    schedule_id INTEGER NOT NULL UNIQUE,
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL DEFAULT 0,
     start_time DATETIME,
     end_time DATETIME,
     start_latitude REAL,
     start_longitude REAL,
     end_latitude REAL,
     end_longitude REAL,
+    location_status TEXT DEFAULT 'confirmed',
     status TEXT NOT NULL DEFAULT 'not_started' CHECK (status IN ('not_started', 'in_progress', 'completed')),
     notes TEXT,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    start_verification_method TEXT,
+    end_verification_method TEXT,
+    original_start_time DATETIME,
+    original_end_time DATETIME,
+    amended_at DATETIME,
+    amendment_reason TEXT,
+    start_address TEXT,
+    end_address TEXT,
+    UNIQUE (schedule_id, caregiver_id),
     FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
 );`
 
@@ -181,16 +491,467 @@ const createTasksTable = `
 CREATE TABLE IF NOT EXISTS tasks (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER,
+    template_id INTEGER,
     title TEXT NOT NULL,
     description TEXT,
     status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'not_completed')),
     reason TEXT,
+    required INTEGER NOT NULL DEFAULT 0,
     completed_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);`
+
+const createScheduleSegmentsTable = `
+CREATE TABLE IF NOT EXISTS schedule_segments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('sleep', 'break')),
+    start_time DATETIME NOT NULL,
+    end_time DATETIME NOT NULL,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_schedule_segments_schedule_id ON schedule_segments(schedule_id, start_time);`
+
+const createVisitSegmentsTable = `
+CREATE TABLE IF NOT EXISTS visit_segments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL DEFAULT 0,
+    type TEXT NOT NULL CHECK (type IN ('work', 'break')),
+    start_time DATETIME NOT NULL,
+    end_time DATETIME,
+    start_latitude REAL,
+    start_longitude REAL,
+    end_latitude REAL,
+    end_longitude REAL,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_visit_segments_schedule_id ON visit_segments(schedule_id, start_time);`
+
+const createIdempotencyKeysTable = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER,
+    response_body BLOB,
+    response_headers TEXT NOT NULL DEFAULT '{}', -- JSON object of the replayed headers
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);`
+
+const createCaregiverPreferencesTable = `
+CREATE TABLE IF NOT EXISTS caregiver_preferences (
+    caregiver_id INTEGER PRIMARY KEY,
+    timezone TEXT NOT NULL DEFAULT '',
+    max_weekly_hours REAL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createCaregiverAvailabilityTable = `
+CREATE TABLE IF NOT EXISTS caregiver_availability (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    caregiver_id INTEGER NOT NULL,
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time TEXT NOT NULL,
+    end_time TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (caregiver_id) REFERENCES caregiver_preferences(caregiver_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_caregiver_availability_caregiver_id ON caregiver_availability(caregiver_id, weekday);`
+
+const createTimeOffRequestsTable = `
+CREATE TABLE IF NOT EXISTS time_off_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    caregiver_id INTEGER NOT NULL,
+    start_time DATETIME NOT NULL,
+    end_time DATETIME NOT NULL,
+    reason TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    review_notes TEXT,
+    reviewed_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_time_off_requests_caregiver_id ON time_off_requests(caregiver_id, status, start_time);`
+
+const createCaregiverSkillsTable = `
+CREATE TABLE IF NOT EXISTS caregiver_skills (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    caregiver_id INTEGER NOT NULL,
+    skill TEXT NOT NULL,
+    certification_number TEXT,
+    issued_at DATETIME,
+    expires_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (caregiver_id, skill)
+);
+CREATE INDEX IF NOT EXISTS idx_caregiver_skills_expires_at ON caregiver_skills(expires_at);`
+
+const createServiceTypesTable = `
+CREATE TABLE IF NOT EXISTS service_types (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    description TEXT,
+    reason_codes TEXT NOT NULL DEFAULT '', -- Comma-separated, empty for the defaults
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS service_type_skills (
+    service_type_id INTEGER NOT NULL,
+    skill TEXT NOT NULL,
+    PRIMARY KEY (service_type_id, skill),
+    FOREIGN KEY (service_type_id) REFERENCES service_types(id) ON DELETE CASCADE
+);`
+
+const createCaregiverProfilesTable = `
+CREATE TABLE IF NOT EXISTS caregiver_profiles (
+    caregiver_id INTEGER PRIMARY KEY,
+    gender TEXT NOT NULL DEFAULT '',
+    languages TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createClientPreferencesTable = `
+CREATE TABLE IF NOT EXISTS client_preferences (
+    client_id INTEGER PRIMARY KEY,
+    preferred_gender TEXT NOT NULL DEFAULT '',
+    languages TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS client_blocked_caregivers (
+    client_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    reason TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (client_id, caregiver_id),
+    FOREIGN KEY (client_id) REFERENCES client_preferences(client_id) ON DELETE CASCADE
+);`
+
+const createOpenShiftsTable = `
+CREATE TABLE IF NOT EXISTS open_shifts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    released_by INTEGER NOT NULL,
+    reason TEXT,
+    claim_mode TEXT NOT NULL DEFAULT 'first_come' CHECK (claim_mode IN ('first_come', 'approval')),
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'filled', 'cancelled')),
+    claimed_by INTEGER,
+    claimed_at DATETIME,
+    review_notes TEXT,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_open_shifts_status ON open_shifts(status, schedule_id);`
+
+const createSwapRequestsTable = `
+CREATE TABLE IF NOT EXISTS swap_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    requester_id INTEGER NOT NULL,
+    target_caregiver_id INTEGER NOT NULL,
+    target_schedule_id INTEGER,
+    notes TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected', 'cancelled')),
+    response_notes TEXT,
+    responded_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_swap_requests_requester_id ON swap_requests(requester_id, status);
+CREATE INDEX IF NOT EXISTS idx_swap_requests_target_caregiver_id ON swap_requests(target_caregiver_id, status);
+CREATE INDEX IF NOT EXISTS idx_swap_requests_schedule_id ON swap_requests(schedule_id, status);`
+
+const createShiftTransitionsTable = `
+CREATE TABLE IF NOT EXISTS shift_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL CHECK (entity IN ('open_shift', 'swap_request')),
+    entity_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    from_status TEXT NOT NULL DEFAULT '',
+    to_status TEXT NOT NULL,
+    caregiver_id INTEGER,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_shift_transitions_entity ON shift_transitions(entity, entity_id, id);`
+
+const createTravelSegmentsTable = `
+CREATE TABLE IF NOT EXISTS travel_segments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    caregiver_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    from_schedule_id INTEGER NOT NULL,
+    to_schedule_id INTEGER NOT NULL,
+    from_latitude REAL NOT NULL,
+    from_longitude REAL NOT NULL,
+    to_latitude REAL NOT NULL,
+    to_longitude REAL NOT NULL,
+    departed_at DATETIME NOT NULL,
+    arrived_at DATETIME NOT NULL,
+    distance_km REAL NOT NULL DEFAULT 0,
+    travel_minutes REAL NOT NULL DEFAULT 0,
+    adjusted_km REAL,
+    adjustment_reason TEXT,
+    adjusted_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (caregiver_id, from_schedule_id, to_schedule_id),
+    FOREIGN KEY (from_schedule_id) REFERENCES schedules(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_travel_segments_caregiver_date ON travel_segments(caregiver_id, date);`
+
+const createVisitLocationPointsTable = `
+CREATE TABLE IF NOT EXISTS visit_location_points (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    accuracy REAL,
+    recorded_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (schedule_id, caregiver_id, recorded_at),
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_visit_location_points_recorded_at ON visit_location_points(recorded_at);`
+
+const createVisitTracksTable = `
+CREATE TABLE IF NOT EXISTS visit_tracks (
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    point_count INTEGER NOT NULL DEFAULT 0,
+    first_recorded_at DATETIME,
+    last_recorded_at DATETIME,
+    geofence_known BOOLEAN NOT NULL DEFAULT 0,
+    geofence_radius_meters REAL NOT NULL DEFAULT 0,
+    inside_minutes REAL NOT NULL DEFAULT 0,
+    outside_minutes REAL NOT NULL DEFAULT 0,
+    points_outside INTEGER NOT NULL DEFAULT 0,
+    max_distance_meters REAL NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schedule_id, caregiver_id),
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);`
+
+const createClockEventsTable = `
+CREATE TABLE IF NOT EXISTS clock_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    client_id INTEGER NOT NULL,
+    event TEXT NOT NULL CHECK (event IN ('clock_in', 'clock_out')),
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    accuracy REAL,
+    mock_location BOOLEAN NOT NULL DEFAULT 0,
+    verification_method TEXT NOT NULL DEFAULT 'gps',
+    recorded_at DATETIME NOT NULL,
+    risk_score INTEGER NOT NULL DEFAULT 0,
+    signals TEXT NOT NULL DEFAULT '[]',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_clock_events_caregiver ON clock_events(caregiver_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_clock_events_schedule ON clock_events(schedule_id);
+CREATE INDEX IF NOT EXISTS idx_clock_events_location ON clock_events(latitude, longitude);`
+
+const createVisitReviewsTable = `
+CREATE TABLE IF NOT EXISTS visit_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    risk_score INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'cleared', 'confirmed')),
+    resolution_notes TEXT,
+    reviewed_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (schedule_id, caregiver_id),
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_visit_reviews_status ON visit_reviews(status);`
+
+const createClientVerificationTokensTable = `
+CREATE TABLE IF NOT EXISTS client_verification_tokens (
+    client_id INTEGER PRIMARY KEY,
+    secret TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);`
+
+const createCaregiverTelephonyPinsTable = `
+CREATE TABLE IF NOT EXISTS caregiver_telephony_pins (
+    caregiver_id INTEGER PRIMARY KEY,
+    pin_hash TEXT NOT NULL,
+    salt TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createVisitCorrectionsTable = `
+CREATE TABLE IF NOT EXISTS visit_corrections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    caregiver_id INTEGER NOT NULL,
+    visit_id INTEGER NOT NULL,
+    proposed_start_time DATETIME,
+    proposed_end_time DATETIME,
+    recorded_start_time DATETIME,
+    recorded_end_time DATETIME,
+    reason_code TEXT NOT NULL,
+    notes TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    review_notes TEXT,
+    reviewed_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_visit_corrections_status ON visit_corrections(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_visit_corrections_schedule_id ON visit_corrections(schedule_id);`
+
+const createGeoPlacesTable = `
+CREATE TABLE IF NOT EXISTS geo_places (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    street TEXT NOT NULL DEFAULT '',
+    locality TEXT NOT NULL DEFAULT '',
+    region TEXT NOT NULL DEFAULT '',
+    postcode TEXT NOT NULL DEFAULT '',
+    country_code TEXT NOT NULL DEFAULT '',
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    UNIQUE (source, source_id)
+);
+CREATE INDEX IF NOT EXISTS idx_geo_places_postcode ON geo_places(postcode);
+CREATE INDEX IF NOT EXISTS idx_geo_places_locality ON geo_places(locality COLLATE NOCASE);
+-- Spatial index of the places, kept in step with geo_places by triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS geo_places_index USING rtree(id, min_lat, max_lat, min_lon, max_lon);
+CREATE TRIGGER IF NOT EXISTS geo_places_index_insert AFTER INSERT ON geo_places BEGIN
+    INSERT INTO geo_places_index (id, min_lat, max_lat, min_lon, max_lon)
+    VALUES (new.id, new.latitude, new.latitude, new.longitude, new.longitude);
+END;
+CREATE TRIGGER IF NOT EXISTS geo_places_index_update AFTER UPDATE OF latitude, longitude ON geo_places BEGIN
+    UPDATE geo_places_index SET min_lat = new.latitude, max_lat = new.latitude, min_lon = new.longitude, max_lon = new.longitude
+    WHERE id = new.id;
+END;
+CREATE TRIGGER IF NOT EXISTS geo_places_index_delete AFTER DELETE ON geo_places BEGIN
+    DELETE FROM geo_places_index WHERE id = old.id;
+END;
+CREATE TABLE IF NOT EXISTS geo_place_sources (
+    source TEXT PRIMARY KEY,
+    format TEXT NOT NULL,
+    file_size INTEGER NOT NULL,
+    file_modified_at DATETIME NOT NULL,
+    imported INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    imported_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createClientsIndexTable = `
+-- Spatial index of located clients, kept in step with clients by triggers;
+-- clients at 0,0 have no location and are left out
+CREATE VIRTUAL TABLE IF NOT EXISTS clients_index USING rtree(id, min_lat, max_lat, min_lon, max_lon);
+CREATE TRIGGER IF NOT EXISTS clients_index_insert AFTER INSERT ON clients
+WHEN new.latitude != 0 OR new.longitude != 0 BEGIN
+    INSERT INTO clients_index (id, min_lat, max_lat, min_lon, max_lon)
+    VALUES (new.id, new.latitude, new.latitude, new.longitude, new.longitude);
+END;
+CREATE TRIGGER IF NOT EXISTS clients_index_update AFTER UPDATE OF latitude, longitude ON clients BEGIN
+    DELETE FROM clients_index WHERE id = old.id;
+    INSERT INTO clients_index (id, min_lat, max_lat, min_lon, max_lon)
+    SELECT new.id, new.latitude, new.latitude, new.longitude, new.longitude
+    WHERE new.latitude != 0 OR new.longitude != 0;
+END;
+CREATE TRIGGER IF NOT EXISTS clients_index_delete AFTER DELETE ON clients BEGIN
+    DELETE FROM clients_index WHERE id = old.id;
+END;`
+
+const createTaskTemplatesTable = `
+CREATE TABLE IF NOT EXISTS task_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    instructions TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );`
 
+const createCarePlansTable = `
+CREATE TABLE IF NOT EXISTS care_plans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (client_id, version),
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS care_plan_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    care_plan_id INTEGER NOT NULL,
+    template_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    frequency TEXT NOT NULL CHECK (frequency IN ('every_visit', 'weekly', 'days')),
+    weekdays TEXT NOT NULL DEFAULT '', -- Comma-separated, 0 is Sunday
+    instructions TEXT NOT NULL DEFAULT '',
+    required INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (care_plan_id) REFERENCES care_plans(id) ON DELETE CASCADE,
+    FOREIGN KEY (template_id) REFERENCES task_templates(id)
+);
+CREATE INDEX IF NOT EXISTS idx_care_plan_items_care_plan_id ON care_plan_items(care_plan_id, position);`
+
+// backfillClientsIndex adds clients located before the spatial index existed
+const backfillClientsIndex = `
+INSERT INTO clients_index (id, min_lat, max_lat, min_lon, max_lon)
+SELECT id, latitude, latitude, longitude, longitude FROM clients
+WHERE (latitude != 0 OR longitude != 0) AND id NOT IN (SELECT id FROM clients_index);`
+
+// backfillScheduleCaregivers makes every schedule's caregiver the lead of its
+// team and attributes visits recorded before team visits to that caregiver
+const backfillScheduleCaregivers = `
+INSERT OR IGNORE INTO schedule_caregivers (schedule_id, caregiver_id, role)
+SELECT id, caregiver_id, 'lead' FROM schedules;
+UPDATE visits SET caregiver_id = (SELECT caregiver_id FROM schedules WHERE schedules.id = visits.schedule_id)
+WHERE caregiver_id = 0;
+UPDATE visit_segments SET caregiver_id = (SELECT caregiver_id FROM schedules WHERE schedules.id = visit_segments.schedule_id)
+WHERE caregiver_id = 0;`
+
+// createIndexes backs the schedule list filters and the task lookups they use
+const createIndexes = `
+CREATE INDEX IF NOT EXISTS idx_schedules_start_time ON schedules(start_time);
+CREATE INDEX IF NOT EXISTS idx_schedules_caregiver_end_time ON schedules(caregiver_id, end_time);
+CREATE INDEX IF NOT EXISTS idx_schedules_caregiver_start_time ON schedules(caregiver_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_schedules_client_start_time ON schedules(client_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_schedules_status_start_time ON schedules(status, start_time);
+CREATE INDEX IF NOT EXISTS idx_visits_location_status ON visits(location_status, schedule_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_schedule_id_status ON tasks(schedule_id, status);`
+
 const insertSampleData = `
 -- Insert sample clients
 INSERT OR IGNORE INTO clients (id, name, email, phone, address, city, state, zip_code, latitude, longitude, notes, is_active) VALUES
@@ -206,12 +967,61 @@ INSERT OR IGNORE INTO schedules (id, client_id, service_name, caregiver_id, star
 (3, 103, 'Companionship Service', 1, datetime('now', '-2 hours'), datetime('now'), 'missed', 'Client was not home'),
 (4, 104, 'Personal Care Service', 1, datetime('now', '+1 day', '+2 hours'), datetime('now', '+1 day', '+4 hours'), 'scheduled', 'Tomorrow morning visit');
 
+-- Insert sample schedule teams; the first visit is a two-person transfer
+INSERT OR IGNORE INTO schedule_caregivers (schedule_id, caregiver_id, role) VALUES
+(1, 1, 'lead'),
+(1, 2, 'member'),
+(2, 1, 'lead'),
+(3, 1, 'lead'),
+(4, 1, 'lead');
+
 -- Insert sample visits
-INSERT OR IGNORE INTO visits (id, schedule_id, status) VALUES
-(1, 1, 'not_started'),
-(2, 2, 'not_started'),
-(3, 3, 'not_started'),
-(4, 4, 'not_started');
+INSERT OR IGNORE INTO visits (id, schedule_id, caregiver_id, status) VALUES
+(1, 1, 1, 'not_started'),
+(2, 2, 1, 'not_started'),
+(3, 3, 1, 'not_started'),
+(4, 4, 1, 'not_started');
+
+-- Insert sample service types; medication management needs a certified caregiver
+INSERT OR IGNORE INTO service_types (id, name, description, reason_codes) VALUES
+(1, 'Personal Care Service', 'Bathing, dressing and personal hygiene', ''),
+(2, 'Medication Management', 'Administering and reviewing prescribed medication', 'client_refused,client_unavailable,medication_unavailable,held_by_physician'),
+(3, 'Companionship Service', 'Social visits and light errands', ''),
+(4, 'Wound Care', 'Dressing changes and wound monitoring', '');
+
+INSERT OR IGNORE INTO service_type_skills (service_type_id, skill) VALUES
+(2, 'medication_management'),
+(4, 'wound_care');
+
+-- Insert sample caregiver certifications
+INSERT OR IGNORE INTO caregiver_skills (id, caregiver_id, skill, certification_number, issued_at, expires_at) VALUES
+(1, 1, 'medication_management', 'MED-2024-0117', datetime('now', '-1 year'), datetime('now', '+1 year')),
+(2, 2, 'wound_care', 'WC-2023-0542', datetime('now', '-2 years'), datetime('now', '+20 days'));
+
+-- Insert sample caregiver profiles and client preferences
+INSERT OR IGNORE INTO caregiver_profiles (caregiver_id, gender, languages) VALUES
+(1, 'female', 'en,es'),
+(2, 'male', 'en');
+
+INSERT OR IGNORE INTO client_preferences (client_id, preferred_gender, languages) VALUES
+(102, 'female', 'en'),
+(103, '', 'es');
+
+-- Insert sample task templates and a care plan for the first client
+INSERT OR IGNORE INTO task_templates (id, title, instructions) VALUES
+(1, 'Give medication', 'Administer medications as prescribed'),
+(2, 'Check vital signs', 'Take blood pressure and temperature'),
+(3, 'Assist with bathing', 'Help client with personal hygiene'),
+(4, 'Prepare lunch', 'Prepare and serve nutritious lunch'),
+(5, 'Light housekeeping', 'Tidy up living areas'),
+(6, 'Physical therapy exercises', 'Guide client through prescribed exercises'),
+(7, 'Medication review', 'Review medication schedule with client');
+INSERT OR IGNORE INTO care_plans (id, client_id, version, notes) VALUES
+(1, 101, 1, 'Morning routine');
+INSERT OR IGNORE INTO care_plan_items (id, care_plan_id, template_id, position, title, frequency, weekdays, instructions, required) VALUES
+(1, 1, 1, 1, 'Give medication', 'every_visit', '', 'Administer morning medications as prescribed', 1),
+(2, 1, 2, 2, 'Check vital signs', 'days', '1,3,5', 'Take blood pressure and temperature', 1),
+(3, 1, 5, 3, 'Light housekeeping', 'weekly', '', 'Tidy up living areas', 0);
 
 -- Insert sample tasks
 INSERT OR IGNORE INTO tasks (id, schedule_id, title, description, status) VALUES
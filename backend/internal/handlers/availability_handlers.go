@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getAvailableCaregivers lists the caregivers free for a proposed time window
+// @Summary Get available caregivers
+// @Description Get the caregivers who can work the whole of a proposed time window: within their availability windows, not on approved time off, not booked on another schedule and within their weekly hour limit
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param start query string true "Window start (RFC3339, or local date-time in the agency timezone)"
+// @Param end query string true "Window end (RFC3339, or local date-time in the agency timezone)"
+// @Success 200 {object} map[string]interface{} "success response with caregiver IDs"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/available [get]
+func (h *Handler) getAvailableCaregivers(c *gin.Context) {
+	start, err := parseTimeBound(c.Query("start"), h.location, false)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid start", err)
+		return
+	}
+	end, err := parseTimeBound(c.Query("end"), h.location, false)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid end", err)
+		return
+	}
+	if start == nil || end == nil {
+		h.errorResponse(c, http.StatusBadRequest, "start and end are required", fmt.Errorf("missing start or end"))
+		return
+	}
+
+	caregiverIDs, err := h.availabilityService.GetAvailableCaregivers(*start, *end)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid time window", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get available caregivers", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"caregiver_ids": caregiverIDs,
+		"count":         len(caregiverIDs),
+	})
+}
+
+// getCaregiverAvailability retrieves a caregiver's availability windows and preferences
+// @Summary Get caregiver availability
+// @Description Get a caregiver's recurring weekly availability windows, timezone and weekly hour limit. A caregiver without windows is available at any time
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Success 200 {object} map[string]interface{} "success response with availability"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/availability [get]
+func (h *Handler) getCaregiverAvailability(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	availability, err := h.availabilityService.GetAvailability(id)
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get availability", err)
+		return
+	}
+
+	h.successResponse(c, availability)
+}
+
+// setCaregiverAvailability replaces a caregiver's availability windows and preferences
+// @Summary Set caregiver availability
+// @Description Replace a caregiver's recurring weekly availability windows (weekday 0 is Sunday, times are HH:MM in the caregiver's timezone; a window ending at or before its start runs past midnight), timezone and weekly hour limit
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param availability body models.CaregiverAvailabilityRequest true "Availability windows and preferences"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with availability"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/availability [put]
+func (h *Handler) setCaregiverAvailability(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	var req models.CaregiverAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	availability, err := h.availabilityService.SetAvailability(id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid availability", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to set availability", err)
+		return
+	}
+
+	h.successResponse(c, availability)
+}
+
+// getCaregiverTimeOff lists a caregiver's time-off requests
+// @Summary Get caregiver time off
+// @Description Get a caregiver's time-off requests in every status, latest first
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Success 200 {object} map[string]interface{} "success response with time-off requests"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/time-off [get]
+func (h *Handler) getCaregiverTimeOff(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	requests, err := h.availabilityService.GetTimeOff(id)
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get time off", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"time_off": requests,
+		"count":    len(requests),
+	})
+}
+
+// requestTimeOff records a pending time-off request
+// @Summary Request time off
+// @Description Request time off for a caregiver. The request is pending until approved; only approved time off blocks bookings
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param request body models.TimeOffCreateRequest true "Time-off period"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with created time-off request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/time-off [post]
+func (h *Handler) requestTimeOff(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	var req models.TimeOffCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	request, err := h.availabilityService.RequestTimeOff(id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid time-off request", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to request time off", err)
+		return
+	}
+
+	h.setETag(c, request.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Time off requested successfully",
+		"data":    request,
+	})
+}
+
+// approveTimeOff approves a pending time-off request
+// @Summary Approve time off
+// @Description Approve a pending time-off request; the caregiver can no longer be booked during it
+// @Tags time-off
+// @Accept json
+// @Produce json
+// @Param id path int true "Time-off request ID"
+// @Param If-Match header string false "ETag of the time-off request version being reviewed"
+// @Param request body models.TimeOffReviewRequest false "Review notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated time-off request"
+// @Header 200 {string} ETag "New version of the time-off request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "time-off request not found"
+// @Failure 409 {object} map[string]interface{} "time-off request is not pending"
+// @Failure 412 {object} map[string]interface{} "time-off request was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/time-off/{id}/approve [post]
+func (h *Handler) approveTimeOff(c *gin.Context) {
+	h.reviewTimeOff(c, h.availabilityService.ApproveTimeOff, "approved")
+}
+
+// rejectTimeOff rejects a pending time-off request
+// @Summary Reject time off
+// @Description Reject a pending time-off request
+// @Tags time-off
+// @Accept json
+// @Produce json
+// @Param id path int true "Time-off request ID"
+// @Param If-Match header string false "ETag of the time-off request version being reviewed"
+// @Param request body models.TimeOffReviewRequest false "Review notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated time-off request"
+// @Header 200 {string} ETag "New version of the time-off request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "time-off request not found"
+// @Failure 409 {object} map[string]interface{} "time-off request is not pending"
+// @Failure 412 {object} map[string]interface{} "time-off request was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/time-off/{id}/reject [post]
+func (h *Handler) rejectTimeOff(c *gin.Context) {
+	h.reviewTimeOff(c, h.availabilityService.RejectTimeOff, "rejected")
+}
+
+// cancelTimeOff withdraws a pending or approved time-off request
+// @Summary Cancel time off
+// @Description Withdraw a pending or approved time-off request, making the caregiver bookable again
+// @Tags time-off
+// @Accept json
+// @Produce json
+// @Param id path int true "Time-off request ID"
+// @Param If-Match header string false "ETag of the time-off request version being cancelled"
+// @Param request body models.TimeOffReviewRequest false "Cancellation notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated time-off request"
+// @Header 200 {string} ETag "New version of the time-off request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "time-off request not found"
+// @Failure 409 {object} map[string]interface{} "time-off request was already rejected or cancelled"
+// @Failure 412 {object} map[string]interface{} "time-off request was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/time-off/{id}/cancel [post]
+func (h *Handler) cancelTimeOff(c *gin.Context) {
+	h.reviewTimeOff(c, h.availabilityService.CancelTimeOff, "cancelled")
+}
+
+// reviewTimeOff applies a review action to the time-off request in the URL.
+// The body with review notes is optional.
+func (h *Handler) reviewTimeOff(c *gin.Context, review func(int, *models.TimeOffReviewRequest) (*models.TimeOffRequest, error), outcome string) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid time-off request ID", err)
+		return
+	}
+
+	var req models.TimeOffReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	request, err := review(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Time-off request not found", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Time-off request cannot be "+outcome, err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Time-off request was modified by another request", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to review time-off request", err)
+		}
+		return
+	}
+
+	h.setETag(c, request.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Time off " + outcome + " successfully",
+		"data":    request,
+	})
+}
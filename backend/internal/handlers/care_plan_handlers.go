@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTaskTemplates lists the task templates
+// @Summary List task templates
+// @Description List the agency's task templates by title, including inactive ones
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success response with task templates"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/task-templates [get]
+func (h *Handler) getTaskTemplates(c *gin.Context) {
+	templates, err := h.carePlanService.GetTaskTemplates()
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get task templates", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"task_templates": templates,
+		"count":          len(templates),
+	})
+}
+
+// createTaskTemplate creates a task template
+// @Summary Create a task template
+// @Description Add a task to the agency's library that care plans can be composed of. Titles are unique regardless of case
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Param template body models.TaskTemplateRequest true "Task template"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with created task template"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/task-templates [post]
+func (h *Handler) createTaskTemplate(c *gin.Context) {
+	var req models.TaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	template, err := h.carePlanService.CreateTaskTemplate(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid task template", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to create task template", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Task template created successfully",
+		"data":    template,
+	})
+}
+
+// updateTaskTemplate updates a task template
+// @Summary Update a task template
+// @Description Rename, re-word or deactivate a task template. Saved care plans keep the title and instructions they were saved with; inactive templates cannot be added to new plan versions
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Param id path int true "Task template ID"
+// @Param template body models.TaskTemplateRequest true "Task template"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated task template"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "task template not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/task-templates/{id} [put]
+func (h *Handler) updateTaskTemplate(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid task template ID", err)
+		return
+	}
+
+	var req models.TaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	template, err := h.carePlanService.UpdateTaskTemplate(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Task template not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid task template", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to update task template", err)
+		}
+		return
+	}
+
+	h.successResponse(c, template)
+}
+
+// getClientCarePlan retrieves a client's current care plan
+// @Summary Get client care plan
+// @Description Get the current version of a client's care plan, which new schedules take their tasks from
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Success 200 {object} map[string]interface{} "success response with care plan"
+// @Header 200 {string} ETag "Current version of the care plan"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client or care plan not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/care-plan [get]
+func (h *Handler) getClientCarePlan(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	plan, err := h.carePlanService.GetCarePlan(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Care plan not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get care plan", err)
+		return
+	}
+
+	h.setETag(c, plan.Version)
+	h.successResponse(c, plan)
+}
+
+// reviseClientCarePlan saves a new version of a client's care plan
+// @Summary Revise client care plan
+// @Description Save a new version of a client's care plan from task templates, each done on every visit, on the first visit booked in each Monday to Sunday week, or on visits starting on given weekdays (0 is Sunday) in the client's timezone. Schedules created afterwards take their tasks from it; existing schedules keep the version they were created with. Tasks of required items block clock-out until completed, or not completed with a reason code. Send If-Match "0" to create the first version only if there is none
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Param plan body models.CarePlanRequest true "Care plan"
+// @Param If-Match header string false "Care plan version the revision is based on, from the ETag"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with the new care plan version"
+// @Header 201 {string} ETag "New version of the care plan"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 412 {object} map[string]interface{} "care plan was revised since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/care-plan [put]
+func (h *Handler) reviseClientCarePlan(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	var req models.CarePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	plan, err := h.carePlanService.ReviseCarePlan(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Client not found", err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Care plan was revised by another request", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid care plan", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to save care plan", err)
+		}
+		return
+	}
+
+	h.setETag(c, plan.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Care plan saved successfully",
+		"data":    plan,
+	})
+}
+
+// getClientCarePlanVersions lists the versions of a client's care plan
+// @Summary List client care plan versions
+// @Description List every version of a client's care plan, newest first
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Success 200 {object} map[string]interface{} "success response with care plan versions"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/care-plan/versions [get]
+func (h *Handler) getClientCarePlanVersions(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	plans, err := h.carePlanService.GetCarePlanVersions(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Client not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get care plan versions", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"care_plans": plans,
+		"count":      len(plans),
+	})
+}
+
+// getCarePlan retrieves a care plan version
+// @Summary Get a care plan version
+// @Description Get a care plan version by ID, such as the care_plan_id a schedule's tasks were created from
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Param id path int true "Care plan ID"
+// @Success 200 {object} map[string]interface{} "success response with care plan"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "care plan not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/care-plans/{id} [get]
+func (h *Handler) getCarePlan(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid care plan ID", err)
+		return
+	}
+
+	plan, err := h.carePlanService.GetCarePlanVersion(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Care plan not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get care plan", err)
+		return
+	}
+
+	h.successResponse(c, plan)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getCaregiverTimesheet retrieves a caregiver's hours per calendar day
+// @Summary Get caregiver timesheet
+// @Description Get a caregiver's shift hours per calendar day. Overnight and multi-day shifts are split at midnight in the client's timezone; sleep and break segments are reported separately from worked hours
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param from query string true "First day (YYYY-MM-DD)"
+// @Param to query string true "Last day, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "success response with timesheet"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/timesheet [get]
+func (h *Handler) getCaregiverTimesheet(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		h.errorResponse(c, http.StatusBadRequest, "from and to are required", nil)
+		return
+	}
+
+	timesheet, err := h.scheduleService.GetTimesheet(id, from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid timesheet range", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get timesheet", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"timesheet": timesheet,
+	})
+}
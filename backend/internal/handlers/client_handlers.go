@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,7 +12,7 @@ import (
 
 // getClients retrieves all clients with optional filtering
 // @Summary Get all clients
-// @Description Get all clients with optional filtering
+// @Description Get all clients with optional filtering. With near, clients are sorted by distance unless sorted otherwise.
 // @Tags clients
 // @Accept json
 // @Produce json
@@ -18,9 +20,13 @@ import (
 // @Param city query string false "Filter by city"
 // @Param state query string false "Filter by state"
 // @Param search query string false "Search by name, email, or phone"
-// @Param limit query int false "Limit number of results"
-// @Param offset query int false "Offset for pagination"
-// @Success 200 {object} map[string]interface{} "success response with clients list"
+// @Param near query string false "Only clients near a point given as latitude,longitude, with their distance_km"
+// @Param radius_km query number false "Search radius around near in kilometres (default 10, max 500)"
+// @Param cursor query string false "Cursor from the next_cursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param sort query string false "Sort field (id, name, city, state, created_at, and distance with near), prefix with - for descending" default(name)
+// @Param include_total query boolean false "Include the total number of matching clients"
+// @Success 200 {object} map[string]interface{} "success response with clients list and pagination"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/clients [get]
@@ -49,26 +55,41 @@ func (h *Handler) getClients(c *gin.Context) {
 		filter.Search = &search
 	}
 
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err != nil {
-			h.errorResponse(c, http.StatusBadRequest, "Invalid limit parameter", err)
+	if near := c.Query("near"); near != "" {
+		point, err := parseGeoPoint(near)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid near parameter", err)
 			return
-		} else {
-			filter.Limit = &limit
 		}
+		filter.Near = point
 	}
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err != nil {
-			h.errorResponse(c, http.StatusBadRequest, "Invalid offset parameter", err)
+	if radius := c.Query("radius_km"); radius != "" {
+		radiusKm, err := strconv.ParseFloat(radius, 64)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid radius_km parameter", err)
 			return
-		} else {
-			filter.Offset = &offset
 		}
+		filter.RadiusKm = radiusKm
+	}
+
+	if opts, err := h.parseListOptions(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	} else {
+		filter.ListOptions = opts
 	}
 
-	clients, err := h.clientService.GetAllClients(filter)
+	clients, page, err := h.clientService.GetAllClients(filter)
 	if err != nil {
+		if isListQueryError(err) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid pagination parameters", err)
+			return
+		}
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid nearby search", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to get clients", err)
 		return
 	}
@@ -80,6 +101,7 @@ func (h *Handler) getClients(c *gin.Context) {
 			"clients": clients,
 			"count":   len(clients),
 		},
+		"pagination": page,
 	})
 }
 
@@ -91,6 +113,7 @@ func (h *Handler) getClients(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Client ID"
 // @Success 200 {object} map[string]interface{} "success response with client details"
+// @Header 200 {string} ETag "Current version of the client"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "client not found"
 // @Failure 500 {object} map[string]interface{} "internal server error"
@@ -109,10 +132,13 @@ func (h *Handler) getClientByID(c *gin.Context) {
 	}
 
 	if client == nil {
-		h.errorResponse(c, http.StatusNotFound, "Client not found", nil)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
 		return
 	}
 
+	h.setETag(c, client.Version)
 	h.successResponse(c, gin.H{
 		"client": client,
 	})
@@ -120,11 +146,12 @@ func (h *Handler) getClientByID(c *gin.Context) {
 
 // createClient creates a new client
 // @Summary Create a new client
-// @Description Create a new client with the provided information
+// @Description Create a new client with the provided information. The address is normalized, and geocoded when latitude and longitude are omitted; geocode_match and geocode_confidence tell how precisely. Clients are never placed at 0,0: an address that cannot be geocoded must be given with its coordinates
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Param client body models.ClientCreateRequest true "Client creation data"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 201 {object} map[string]interface{} "success response with created client"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 500 {object} map[string]interface{} "internal server error"
@@ -138,6 +165,10 @@ func (h *Handler) createClient(c *gin.Context) {
 
 	client, err := h.clientService.CreateClient(&req)
 	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid client", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to create client", err)
 		return
 	}
@@ -153,15 +184,19 @@ func (h *Handler) createClient(c *gin.Context) {
 
 // updateClient updates an existing client
 // @Summary Update a client
-// @Description Update an existing client with the provided information
+// @Description Update an existing client with the provided information. A changed address is normalized and geocoded again unless latitude and longitude are given with it; 0,0 is rejected
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Param id path int true "Client ID"
+// @Param If-Match header string false "ETag of the client version being updated"
 // @Param client body models.ClientUpdateRequest true "Client update data"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 200 {object} map[string]interface{} "success response with updated client"
+// @Header 200 {string} ETag "New version of the client"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 412 {object} map[string]interface{} "client was modified since it was read"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/clients/{id} [put]
 func (h *Handler) updateClient(c *gin.Context) {
@@ -177,17 +212,83 @@ func (h *Handler) updateClient(c *gin.Context) {
 		return
 	}
 
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
 	client, err := h.clientService.UpdateClient(id, &req)
 	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.errorResponse(c, http.StatusPreconditionFailed, "Client was modified by another request", err)
+			return
+		}
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid client", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to update client", err)
 		return
 	}
 
 	if client == nil {
-		h.errorResponse(c, http.StatusNotFound, "Client not found", nil)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return
+	}
+
+	h.setETag(c, client.Version)
+	h.successResponse(c, gin.H{
+		"client": client,
+	})
+}
+
+// geocodeClient places a client again by its address
+// @Summary Geocode a client
+// @Description Geocode the client's current address again, e.g. after the place dataset was updated or for clients created before addresses were geocoded, replacing its coordinates even when they were given by hand
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Param If-Match header string false "ETag of the client version being geocoded"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with geocoded client"
+// @Header 200 {string} ETag "New version of the client"
+// @Failure 400 {object} map[string]interface{} "bad request or address could not be geocoded"
+// @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 412 {object} map[string]interface{} "client was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/geocode [post]
+func (h *Handler) geocodeClient(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	expectedVersion, err := h.parseIfMatch(c)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	client, err := h.clientService.GeocodeClient(id, expectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Client not found", err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Client was modified by another request", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Client address could not be geocoded", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to geocode client", err)
+		}
 		return
 	}
 
+	h.setETag(c, client.Version)
 	h.successResponse(c, gin.H{
 		"client": client,
 	})
@@ -200,6 +301,7 @@ func (h *Handler) updateClient(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Client ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "client not found"
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reverseGeocode returns the nearest known address of a location
+// @Summary Reverse geocode a location
+// @Description Get the address of the place nearest a location from the offline place dataset imported from GEOCODER_DATASET, a GeoNames dump or a CSV of addresses such as an OpenStreetMap extract. Clock-in and clock-out locations outside the client geofence are resolved the same way and stored on the visit as start_address and end_address
+// @Tags geocoding
+// @Accept json
+// @Produce json
+// @Param latitude query number true "Latitude"
+// @Param longitude query number true "Longitude"
+// @Success 200 {object} map[string]interface{} "success response with nearest address"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "no known place near the location"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/geocoding/reverse [get]
+func (h *Handler) reverseGeocode(c *gin.Context) {
+	latitude, err := strconv.ParseFloat(c.Query("latitude"), 64)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid latitude", fmt.Errorf("latitude must be a number"))
+		return
+	}
+	longitude, err := strconv.ParseFloat(c.Query("longitude"), 64)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid longitude", fmt.Errorf("longitude must be a number"))
+		return
+	}
+
+	geocode, err := h.geocoderService.ReverseGeocode(latitude, longitude)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid location", err)
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "No known address near the location", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to reverse geocode location", err)
+		}
+		return
+	}
+
+	h.successResponse(c, geocode)
+}
@@ -3,8 +3,13 @@ package handlers
 import (
 	"caregiver-shift-tracker/internal/middleware"
 	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -14,13 +19,26 @@ import (
 
 // ScheduleServiceInterface defines the interface for schedule service
 type ScheduleServiceInterface interface {
-	GetAllSchedules(filter *models.ScheduleFilter) ([]models.Schedule, error)
+	GetAllSchedules(filter *models.ScheduleFilter) ([]models.Schedule, *models.PageInfo, error)
 	GetScheduleByID(id int) (*models.Schedule, error)
+	CreateSchedule(req *models.ScheduleCreateRequest) (*models.Schedule, error)
+	ReassignSchedule(scheduleID int, req *models.ScheduleReassignRequest) (*models.Schedule, error)
 	GetTodaySchedules(caregiverID int) ([]models.Schedule, error)
 	GetScheduleStats(caregiverID int) (*models.ScheduleStats, error)
 	StartVisit(scheduleID int, req *models.VisitStartRequest) error
 	EndVisit(scheduleID int, req *models.VisitEndRequest) error
-	CancelVisit(scheduleID int) error
+	CancelVisit(scheduleID int, req *models.VisitCancelRequest) error
+	PauseVisit(scheduleID int, req *models.VisitPauseRequest) error
+	ResumeVisit(scheduleID int, req *models.VisitResumeRequest) error
+	GetScheduleSegments(scheduleID int) ([]models.ScheduleSegment, error)
+	AddScheduleSegment(scheduleID int, req *models.ScheduleSegmentRequest) (*models.ScheduleSegment, error)
+	DeleteScheduleSegment(scheduleID, segmentID int) error
+	GetScheduleCaregivers(scheduleID int) ([]models.ScheduleCaregiver, error)
+	AddScheduleCaregiver(scheduleID int, req *models.ScheduleCaregiverRequest) (*models.ScheduleCaregiver, error)
+	RemoveScheduleCaregiver(scheduleID, caregiverID int) error
+	GetTimesheet(caregiverID int, from, to string) (*models.Timesheet, error)
+	ExportPayroll(from, to string) (*models.PayrollExport, error)
+	ExportEVV(from, to string) (*models.EVVExport, error)
 }
 
 // VisitServiceInterface defines the interface for visit service
@@ -32,41 +50,215 @@ type VisitServiceInterface interface {
 type TaskServiceInterface interface {
 	GetTaskByID(id int) (*models.Task, error)
 	UpdateTaskStatus(id int, req *models.TaskUpdateRequest) (*models.Task, error)
+	AssignTask(id int, req *models.TaskAssignRequest) (*models.Task, error)
 }
 
 // ClientServiceInterface defines the interface for client service
 type ClientServiceInterface interface {
-	GetAllClients(filter *models.ClientFilter) ([]models.Client, error)
+	GetAllClients(filter *models.ClientFilter) ([]models.Client, *models.PageInfo, error)
 	GetClientByID(id int) (*models.Client, error)
 	CreateClient(req *models.ClientCreateRequest) (*models.Client, error)
 	UpdateClient(id int, req *models.ClientUpdateRequest) (*models.Client, error)
+	GeocodeClient(id int, expectedVersion *int) (*models.Client, error)
 	DeleteClient(id int) error
 	SearchClients(query string) ([]models.Client, error)
 }
 
+// AvailabilityServiceInterface defines the interface for caregiver availability service
+type AvailabilityServiceInterface interface {
+	GetAvailability(caregiverID int) (*models.CaregiverAvailability, error)
+	SetAvailability(caregiverID int, req *models.CaregiverAvailabilityRequest) (*models.CaregiverAvailability, error)
+	GetTimeOff(caregiverID int) ([]models.TimeOffRequest, error)
+	RequestTimeOff(caregiverID int, req *models.TimeOffCreateRequest) (*models.TimeOffRequest, error)
+	ApproveTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error)
+	RejectTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error)
+	CancelTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error)
+	GetAvailableCaregivers(start, end time.Time) ([]int, error)
+}
+
+// SkillServiceInterface defines the interface for caregiver skill and service type service
+type SkillServiceInterface interface {
+	GetCaregiverSkills(caregiverID int) ([]models.CaregiverSkill, error)
+	SetCaregiverSkill(caregiverID int, skill string, req *models.CaregiverSkillRequest) (*models.CaregiverSkill, error)
+	DeleteCaregiverSkill(caregiverID int, skill string) error
+	GetExpiringCertifications(days int) ([]models.CaregiverSkill, error)
+	GetServiceTypes() ([]models.ServiceType, error)
+	CreateServiceType(req *models.ServiceTypeRequest) (*models.ServiceType, error)
+	UpdateServiceType(id int, req *models.ServiceTypeRequest) (*models.ServiceType, error)
+}
+
+// MatchingServiceInterface defines the interface for caregiver matching service
+type MatchingServiceInterface interface {
+	GetCaregiverProfile(caregiverID int) (*models.CaregiverProfile, error)
+	SetCaregiverProfile(caregiverID int, req *models.CaregiverProfileRequest) (*models.CaregiverProfile, error)
+	GetClientPreferences(clientID int) (*models.ClientPreferences, error)
+	SetClientPreferences(clientID int, req *models.ClientPreferencesRequest) (*models.ClientPreferences, error)
+	GetCandidates(scheduleID int, opts models.CandidateOptions) ([]models.MatchCandidate, error)
+}
+
+// ShiftBoardServiceInterface defines the interface for open shift and swap request service
+type ShiftBoardServiceInterface interface {
+	ReleaseSchedule(scheduleID int, req *models.ScheduleReleaseRequest) (*models.OpenShift, error)
+	GetOpenShifts(filter *models.OpenShiftFilter) ([]models.OpenShift, error)
+	GetOpenShift(id int) (*models.OpenShift, error)
+	ClaimOpenShift(id int, req *models.OpenShiftClaimRequest) (*models.OpenShift, error)
+	ApproveOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error)
+	RejectOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error)
+	CancelOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error)
+	RequestSwap(req *models.SwapRequestCreateRequest) (*models.SwapRequest, error)
+	GetSwapRequests(caregiverID int) ([]models.SwapRequest, error)
+	GetSwapRequest(id int) (*models.SwapRequest, error)
+	AcceptSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error)
+	RejectSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error)
+	CancelSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error)
+}
+
+// RouteServiceInterface defines the interface for caregiver route planning service
+type RouteServiceInterface interface {
+	PlanRoute(caregiverID int, date string) (*models.RoutePlan, error)
+}
+
+// MileageServiceInterface defines the interface for caregiver travel and mileage service
+type MileageServiceInterface interface {
+	GetTravelLog(caregiverID int, from, to string) (*models.TravelLog, error)
+	RecalculateDay(caregiverID int, date string) (*models.TravelLog, error)
+	AdjustTravelSegment(id int, req *models.TravelAdjustmentRequest) (*models.TravelSegment, error)
+}
+
+// TrackingServiceInterface defines the interface for visit location tracking service
+type TrackingServiceInterface interface {
+	RecordLocations(scheduleID int, req *models.VisitLocationRequest) (*models.VisitTrack, error)
+	GetVisitTrack(scheduleID int, caregiverID *int) (*models.VisitTrack, error)
+}
+
+// AnomalyServiceInterface defines the interface for clock event anomaly detection service
+type AnomalyServiceInterface interface {
+	GetReviews(status string) ([]models.VisitReview, error)
+	GetReview(id int) (*models.VisitReview, error)
+	ResolveReview(id int, req *models.VisitReviewResolveRequest) (*models.VisitReview, error)
+}
+
+// VerificationServiceInterface defines the interface for client verification token service
+type VerificationServiceInterface interface {
+	IssueToken(clientID int) (*models.ClientVerificationToken, error)
+	GetToken(clientID int) (*models.ClientVerificationToken, error)
+	RevokeToken(clientID int) error
+}
+
+// TelephonyServiceInterface defines the interface for telephony verification service
+type TelephonyServiceInterface interface {
+	SetPIN(caregiverID int, req *models.TelephonyPINRequest) (*models.CaregiverTelephonyPIN, error)
+	HandleCall(call *models.TelephonyCall) (*models.TelephonyPrompt, error)
+}
+
+// VisitCorrectionServiceInterface defines the interface for visit correction service
+type VisitCorrectionServiceInterface interface {
+	RequestCorrection(scheduleID int, req *models.VisitCorrectionRequest) (*models.VisitCorrection, error)
+	GetCorrections(status string) ([]models.VisitCorrection, error)
+	GetScheduleCorrections(scheduleID int) ([]models.VisitCorrection, error)
+	GetCorrection(id int) (*models.VisitCorrection, error)
+	ApproveCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error)
+	RejectCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error)
+	CancelCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error)
+}
+
+// CarePlanServiceInterface defines the interface for task template and care plan service
+type CarePlanServiceInterface interface {
+	GetTaskTemplates() ([]models.TaskTemplate, error)
+	CreateTaskTemplate(req *models.TaskTemplateRequest) (*models.TaskTemplate, error)
+	UpdateTaskTemplate(id int, req *models.TaskTemplateRequest) (*models.TaskTemplate, error)
+	GetCarePlan(clientID int) (*models.CarePlan, error)
+	GetCarePlanVersions(clientID int) ([]models.CarePlan, error)
+	GetCarePlanVersion(id int) (*models.CarePlan, error)
+	ReviseCarePlan(clientID int, req *models.CarePlanRequest) (*models.CarePlan, error)
+}
+
+// GeocoderServiceInterface defines the interface for geocoder service
+type GeocoderServiceInterface interface {
+	ReverseGeocode(latitude, longitude float64) (*models.ReverseGeocode, error)
+}
+
+// IdempotencyServiceInterface defines the interface for idempotency key service
+type IdempotencyServiceInterface interface {
+	Begin(key, method, path string, body []byte) (*models.IdempotencyRecord, bool, error)
+	Complete(key string, statusCode int, headers map[string]string, body []byte) error
+	Release(key string) error
+}
+
 // Handler contains all HTTP handlers
 type Handler struct {
-	scheduleService ScheduleServiceInterface
-	visitService    VisitServiceInterface
-	taskService     TaskServiceInterface
-	clientService   ClientServiceInterface
-	logger          *logrus.Logger
-}
-
-// NewHandler creates a new handler
-func NewHandler(
-	scheduleService ScheduleServiceInterface,
-	visitService VisitServiceInterface,
-	taskService TaskServiceInterface,
-	clientService ClientServiceInterface,
-	logger *logrus.Logger,
-) *Handler {
+	scheduleService     ScheduleServiceInterface
+	visitService        VisitServiceInterface
+	taskService         TaskServiceInterface
+	clientService       ClientServiceInterface
+	availabilityService AvailabilityServiceInterface
+	skillService        SkillServiceInterface
+	matchingService     MatchingServiceInterface
+	shiftBoardService   ShiftBoardServiceInterface
+	routeService        RouteServiceInterface
+	mileageService      MileageServiceInterface
+	trackingService     TrackingServiceInterface
+	anomalyService      AnomalyServiceInterface
+	verificationService VerificationServiceInterface
+	telephonyService    TelephonyServiceInterface
+	correctionService   VisitCorrectionServiceInterface
+	geocoderService     GeocoderServiceInterface
+	carePlanService     CarePlanServiceInterface
+	idempotencyService  IdempotencyServiceInterface
+	location            *time.Location // Agency default timezone for date parameters
+	logger              *logrus.Logger
+}
+
+// Services are the services behind the handler's routes
+type Services struct {
+	Schedule     ScheduleServiceInterface
+	Visit        VisitServiceInterface
+	Task         TaskServiceInterface
+	Client       ClientServiceInterface
+	Availability AvailabilityServiceInterface
+	Skill        SkillServiceInterface
+	Matching     MatchingServiceInterface
+	ShiftBoard   ShiftBoardServiceInterface
+	Route        RouteServiceInterface
+	Mileage      MileageServiceInterface
+	Tracking     TrackingServiceInterface
+	Anomaly      AnomalyServiceInterface
+	Verification VerificationServiceInterface
+	Telephony    TelephonyServiceInterface
+	Correction   VisitCorrectionServiceInterface
+	Geocoder     GeocoderServiceInterface
+	CarePlan     CarePlanServiceInterface
+	Idempotency  IdempotencyServiceInterface // Idempotency-Key headers are ignored when nil
+}
+
+// NewHandler creates a new handler; location is the agency default timezone,
+// UTC when nil
+func NewHandler(services Services, location *time.Location, logger *logrus.Logger) *Handler {
+	if location == nil {
+		location = time.UTC
+	}
+
 	return &Handler{
-		scheduleService: scheduleService,
-		visitService:    visitService,
-		taskService:     taskService,
-		clientService:   clientService,
-		logger:          logger,
+		scheduleService:     services.Schedule,
+		visitService:        services.Visit,
+		taskService:         services.Task,
+		clientService:       services.Client,
+		availabilityService: services.Availability,
+		skillService:        services.Skill,
+		matchingService:     services.Matching,
+		shiftBoardService:   services.ShiftBoard,
+		routeService:        services.Route,
+		mileageService:      services.Mileage,
+		trackingService:     services.Tracking,
+		anomalyService:      services.Anomaly,
+		verificationService: services.Verification,
+		telephonyService:    services.Telephony,
+		correctionService:   services.Correction,
+		geocoderService:     services.Geocoder,
+		carePlanService:     services.CarePlan,
+		idempotencyService:  services.Idempotency,
+		location:            location,
+		logger:              logger,
 	}
 }
 
@@ -79,7 +271,7 @@ func (h *Handler) SetupRoutes() *gin.Engine {
 	router.Use(middleware.ErrorHandlingMiddleware(h.logger))
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.SecurityHeadersMiddleware())
-	router.Use(middleware.ValidationMiddleware())
+	router.Use(middleware.ValidationMiddleware(telephonyVoicePath))
 	router.Use(middleware.RateLimitMiddleware(h.logger))
 	router.Use(h.corsMiddleware())
 	router.Use(h.loggingMiddleware())
@@ -92,17 +284,156 @@ func (h *Handler) SetupRoutes() *gin.Engine {
 
 	// API routes
 	api := router.Group("/api/v1")
+	if h.idempotencyService != nil {
+		api.Use(h.idempotencyMiddleware())
+	}
 	{
 		// Schedule routes
 		schedules := api.Group("/schedules")
 		{
 			schedules.GET("", h.getSchedules)
+			schedules.POST("", h.createSchedule)
 			schedules.GET("/today", h.getTodaySchedules)
 			schedules.GET("/stats", h.getScheduleStats)
 			schedules.GET("/:id", h.getScheduleByID)
 			schedules.POST("/:id/start", h.startVisit)
 			schedules.POST("/:id/end", h.endVisit)
 			schedules.POST("/:id/cancel", h.cancelVisit)
+			schedules.POST("/:id/pause", h.pauseVisit)
+			schedules.POST("/:id/resume", h.resumeVisit)
+			schedules.GET("/:id/segments", h.getScheduleSegments)
+			schedules.POST("/:id/segments", h.addScheduleSegment)
+			schedules.DELETE("/:id/segments/:segmentId", h.deleteScheduleSegment)
+			schedules.GET("/:id/caregivers", h.getScheduleCaregivers)
+			schedules.POST("/:id/caregivers", h.addScheduleCaregiver)
+			schedules.DELETE("/:id/caregivers/:caregiverId", h.removeScheduleCaregiver)
+			schedules.PUT("/:id/caregiver", h.reassignSchedule)
+			schedules.GET("/:id/candidates", h.getScheduleCandidates)
+			schedules.POST("/:id/release", h.releaseSchedule)
+			schedules.POST("/:id/locations", h.recordVisitLocations)
+			schedules.GET("/:id/track", h.getVisitTrack)
+			schedules.GET("/:id/corrections", h.getScheduleCorrections)
+			schedules.POST("/:id/corrections", h.requestVisitCorrection)
+		}
+
+		// Caregiver routes
+		caregivers := api.Group("/caregivers")
+		{
+			caregivers.GET("/available", h.getAvailableCaregivers)
+			caregivers.GET("/certifications/expiring", h.getExpiringCertifications)
+			caregivers.GET("/:id/timesheet", h.getCaregiverTimesheet)
+			caregivers.GET("/:id/route", h.getCaregiverRoute)
+			caregivers.GET("/:id/travel", h.getCaregiverTravel)
+			caregivers.POST("/:id/travel/recalculate", h.recalculateCaregiverTravel)
+			caregivers.GET("/:id/availability", h.getCaregiverAvailability)
+			caregivers.PUT("/:id/availability", h.setCaregiverAvailability)
+			caregivers.GET("/:id/time-off", h.getCaregiverTimeOff)
+			caregivers.POST("/:id/time-off", h.requestTimeOff)
+			caregivers.GET("/:id/skills", h.getCaregiverSkills)
+			caregivers.PUT("/:id/skills/:skill", h.setCaregiverSkill)
+			caregivers.DELETE("/:id/skills/:skill", h.deleteCaregiverSkill)
+			caregivers.GET("/:id/profile", h.getCaregiverProfile)
+			caregivers.PUT("/:id/profile", h.setCaregiverProfile)
+			caregivers.GET("/:id/swap-requests", h.getCaregiverSwapRequests)
+			caregivers.PUT("/:id/telephony-pin", h.setCaregiverTelephonyPIN)
+		}
+
+		// Open shift board routes
+		openShifts := api.Group("/open-shifts")
+		{
+			openShifts.GET("", h.getOpenShifts)
+			openShifts.GET("/:id", h.getOpenShift)
+			openShifts.POST("/:id/claim", h.claimOpenShift)
+			openShifts.POST("/:id/approve", h.approveOpenShift)
+			openShifts.POST("/:id/reject", h.rejectOpenShift)
+			openShifts.POST("/:id/cancel", h.cancelOpenShift)
+		}
+
+		// Swap request routes
+		swapRequests := api.Group("/swap-requests")
+		{
+			swapRequests.POST("", h.requestSwap)
+			swapRequests.GET("/:id", h.getSwapRequest)
+			swapRequests.POST("/:id/accept", h.acceptSwap)
+			swapRequests.POST("/:id/reject", h.rejectSwap)
+			swapRequests.POST("/:id/cancel", h.cancelSwap)
+		}
+
+		// Travel segment routes
+		travelSegments := api.Group("/travel-segments")
+		{
+			travelSegments.PUT("/:id", h.adjustTravelSegment)
+		}
+
+		// Visit review routes
+		visitReviews := api.Group("/visit-reviews")
+		{
+			visitReviews.GET("", h.getVisitReviews)
+			visitReviews.GET("/:id", h.getVisitReview)
+			visitReviews.PUT("/:id", h.resolveVisitReview)
+		}
+
+		// Telephony routes
+		telephony := api.Group("/telephony")
+		{
+			telephony.POST("/voice", h.telephonyVoice)
+			telephony.POST("/simulate", h.simulateTelephonyCall)
+		}
+
+		// Visit correction routes
+		visitCorrections := api.Group("/visit-corrections")
+		{
+			visitCorrections.GET("", h.getVisitCorrections)
+			visitCorrections.GET("/:id", h.getVisitCorrection)
+			visitCorrections.POST("/:id/approve", h.approveVisitCorrection)
+			visitCorrections.POST("/:id/reject", h.rejectVisitCorrection)
+			visitCorrections.POST("/:id/cancel", h.cancelVisitCorrection)
+		}
+
+		// Payroll routes
+		payroll := api.Group("/payroll")
+		{
+			payroll.GET("/export", h.exportPayroll)
+		}
+
+		// EVV routes
+		evv := api.Group("/evv")
+		{
+			evv.GET("/export", h.exportEVV)
+		}
+
+		// Geocoding routes
+		geocoding := api.Group("/geocoding")
+		{
+			geocoding.GET("/reverse", h.reverseGeocode)
+		}
+
+		// Service type routes
+		serviceTypes := api.Group("/service-types")
+		{
+			serviceTypes.GET("", h.getServiceTypes)
+			serviceTypes.POST("", h.createServiceType)
+			serviceTypes.PUT("/:id", h.updateServiceType)
+		}
+
+		// Task template and care plan routes
+		taskTemplates := api.Group("/task-templates")
+		{
+			taskTemplates.GET("", h.getTaskTemplates)
+			taskTemplates.POST("", h.createTaskTemplate)
+			taskTemplates.PUT("/:id", h.updateTaskTemplate)
+		}
+		carePlans := api.Group("/care-plans")
+		{
+			carePlans.GET("/:id", h.getCarePlan)
+		}
+
+		// Time-off routes
+		timeOff := api.Group("/time-off")
+		{
+			timeOff.POST("/:id/approve", h.approveTimeOff)
+			timeOff.POST("/:id/reject", h.rejectTimeOff)
+			timeOff.POST("/:id/cancel", h.cancelTimeOff)
 		}
 
 		// Task routes
@@ -110,6 +441,7 @@ func (h *Handler) SetupRoutes() *gin.Engine {
 		{
 			tasks.GET("/:id", h.getTaskByID)
 			tasks.PUT("/:id", h.updateTaskStatus)
+			tasks.PUT("/:id/assignee", h.assignTask)
 		}
 
 		// Visit routes (for additional visit operations if needed)
@@ -127,6 +459,15 @@ func (h *Handler) SetupRoutes() *gin.Engine {
 			clients.POST("", h.createClient)
 			clients.PUT("/:id", h.updateClient)
 			clients.DELETE("/:id", h.deleteClient)
+			clients.POST("/:id/geocode", h.geocodeClient)
+			clients.GET("/:id/preferences", h.getClientPreferences)
+			clients.PUT("/:id/preferences", h.setClientPreferences)
+			clients.GET("/:id/verification-token", h.getClientVerificationToken)
+			clients.POST("/:id/verification-token", h.issueClientVerificationToken)
+			clients.DELETE("/:id/verification-token", h.revokeClientVerificationToken)
+			clients.GET("/:id/care-plan", h.getClientCarePlan)
+			clients.PUT("/:id/care-plan", h.reviseClientCarePlan)
+			clients.GET("/:id/care-plan/versions", h.getClientCarePlanVersions)
 		}
 	}
 
@@ -139,7 +480,8 @@ func (h *Handler) corsMiddleware() gin.HandlerFunc {
 		// Set CORS headers for all requests
 		c.Header("Access-Control-Allow-Origin", "http://localhost:8081")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-With, If-Match, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -197,6 +539,85 @@ func (h *Handler) parseIntQuery(c *gin.Context, param string) (*int, error) {
 	return &val, nil
 }
 
+// parseListOptions parses the cursor, limit, sort and include_total query parameters of list endpoints
+func (h *Handler) parseListOptions(c *gin.Context) (models.ListOptions, error) {
+	opts := models.ListOptions{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+
+	// Lists page by cursor; refuse offset rather than returning the first page again
+	if _, ok := c.GetQuery("offset"); ok {
+		return opts, fmt.Errorf("offset is not supported, pass the next_cursor of the previous page as cursor")
+	}
+
+	limit, err := h.parseIntQuery(c, "limit")
+	if err != nil {
+		return opts, fmt.Errorf("invalid limit: %w", err)
+	}
+	if limit != nil && *limit <= 0 {
+		return opts, fmt.Errorf("invalid limit: must be positive")
+	}
+	opts.Limit = limit
+
+	if includeTotal := c.Query("include_total"); includeTotal != "" {
+		if opts.IncludeTotal, err = strconv.ParseBool(includeTotal); err != nil {
+			return opts, fmt.Errorf("invalid include_total: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// parseGeoPoint parses a point given as "latitude,longitude"
+func parseGeoPoint(value string) (*models.GeoPoint, error) {
+	lat, lon, found := strings.Cut(value, ",")
+	if !found {
+		return nil, fmt.Errorf("expected latitude,longitude")
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude: %w", err)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude: %w", err)
+	}
+	return &models.GeoPoint{Latitude: latitude, Longitude: longitude}, nil
+}
+
+// isListQueryError reports whether a list error was caused by invalid pagination or sort parameters
+func isListQueryError(err error) bool {
+	return errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidSort)
+}
+
+// setETag sets the ETag header from an entity version
+func (h *Handler) setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// parseIfMatch parses the If-Match header into the expected entity version.
+// A missing header or "*" matches any version and yields nil.
+func (h *Handler) parseIfMatch(c *gin.Context) (*int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header %q", header)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header %q", header)
+	}
+
+	return &version, nil
+}
+
 // errorResponse sends an error response
 func (h *Handler) errorResponse(c *gin.Context, statusCode int, message string, err error) {
 	h.logger.WithError(err).WithFields(logrus.Fields{
@@ -218,3 +639,12 @@ func (h *Handler) successResponse(c *gin.Context, data interface{}) {
 		"data":    data,
 	})
 }
+
+// pageResponse sends a success response for a page of a list endpoint
+func (h *Handler) pageResponse(c *gin.Context, data interface{}, page *models.PageInfo) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       data,
+		"pagination": page,
+	})
+}
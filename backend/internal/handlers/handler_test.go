@@ -3,10 +3,13 @@ package handlers
 import (
 	"bytes"
 	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -19,9 +22,12 @@ type MockScheduleService struct {
 	mock.Mock
 }
 
-func (m *MockScheduleService) GetAllSchedules(filter *models.ScheduleFilter) ([]models.Schedule, error) {
+func (m *MockScheduleService) GetAllSchedules(filter *models.ScheduleFilter) ([]models.Schedule, *models.PageInfo, error) {
 	args := m.Called(filter)
-	return args.Get(0).([]models.Schedule), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]models.Schedule), args.Get(1).(*models.PageInfo), args.Error(2)
 }
 
 func (m *MockScheduleService) GetScheduleByID(id int) (*models.Schedule, error) {
@@ -32,6 +38,22 @@ func (m *MockScheduleService) GetScheduleByID(id int) (*models.Schedule, error)
 	return args.Get(0).(*models.Schedule), args.Error(1)
 }
 
+func (m *MockScheduleService) CreateSchedule(req *models.ScheduleCreateRequest) (*models.Schedule, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Schedule), args.Error(1)
+}
+
+func (m *MockScheduleService) ReassignSchedule(scheduleID int, req *models.ScheduleReassignRequest) (*models.Schedule, error) {
+	args := m.Called(scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Schedule), args.Error(1)
+}
+
 func (m *MockScheduleService) GetTodaySchedules(caregiverID int) ([]models.Schedule, error) {
 	args := m.Called(caregiverID)
 	return args.Get(0).([]models.Schedule), args.Error(1)
@@ -55,11 +77,87 @@ func (m *MockScheduleService) EndVisit(scheduleID int, req *models.VisitEndReque
 	return args.Error(0)
 }
 
-func (m *MockScheduleService) CancelVisit(scheduleID int) error {
+func (m *MockScheduleService) CancelVisit(scheduleID int, req *models.VisitCancelRequest) error {
+	args := m.Called(scheduleID, req)
+	return args.Error(0)
+}
+
+func (m *MockScheduleService) PauseVisit(scheduleID int, req *models.VisitPauseRequest) error {
+	args := m.Called(scheduleID, req)
+	return args.Error(0)
+}
+
+func (m *MockScheduleService) ResumeVisit(scheduleID int, req *models.VisitResumeRequest) error {
+	args := m.Called(scheduleID, req)
+	return args.Error(0)
+}
+
+func (m *MockScheduleService) GetScheduleSegments(scheduleID int) ([]models.ScheduleSegment, error) {
+	args := m.Called(scheduleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ScheduleSegment), args.Error(1)
+}
+
+func (m *MockScheduleService) AddScheduleSegment(scheduleID int, req *models.ScheduleSegmentRequest) (*models.ScheduleSegment, error) {
+	args := m.Called(scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduleSegment), args.Error(1)
+}
+
+func (m *MockScheduleService) DeleteScheduleSegment(scheduleID, segmentID int) error {
+	args := m.Called(scheduleID, segmentID)
+	return args.Error(0)
+}
+
+func (m *MockScheduleService) GetScheduleCaregivers(scheduleID int) ([]models.ScheduleCaregiver, error) {
 	args := m.Called(scheduleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ScheduleCaregiver), args.Error(1)
+}
+
+func (m *MockScheduleService) AddScheduleCaregiver(scheduleID int, req *models.ScheduleCaregiverRequest) (*models.ScheduleCaregiver, error) {
+	args := m.Called(scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduleCaregiver), args.Error(1)
+}
+
+func (m *MockScheduleService) RemoveScheduleCaregiver(scheduleID, caregiverID int) error {
+	args := m.Called(scheduleID, caregiverID)
 	return args.Error(0)
 }
 
+func (m *MockScheduleService) GetTimesheet(caregiverID int, from, to string) (*models.Timesheet, error) {
+	args := m.Called(caregiverID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Timesheet), args.Error(1)
+}
+
+func (m *MockScheduleService) ExportPayroll(from, to string) (*models.PayrollExport, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PayrollExport), args.Error(1)
+}
+
+func (m *MockScheduleService) ExportEVV(from, to string) (*models.EVVExport, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EVVExport), args.Error(1)
+}
+
 // MockVisitService is a mock implementation of VisitService
 type MockVisitService struct {
 	mock.Mock
@@ -94,175 +192,2407 @@ func (m *MockTaskService) UpdateTaskStatus(id int, req *models.TaskUpdateRequest
 	return args.Get(0).(*models.Task), args.Error(1)
 }
 
-// MockClientService is a mock implementation of ClientService
-type MockClientService struct {
+func (m *MockTaskService) AssignTask(id int, req *models.TaskAssignRequest) (*models.Task, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+// MockAvailabilityService is a mock implementation of AvailabilityService
+type MockAvailabilityService struct {
 	mock.Mock
 }
 
-func (m *MockClientService) GetAllClients(filter *models.ClientFilter) ([]models.Client, error) {
-	args := m.Called(filter)
-	return args.Get(0).([]models.Client), args.Error(1)
+func (m *MockAvailabilityService) GetAvailability(caregiverID int) (*models.CaregiverAvailability, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverAvailability), args.Error(1)
 }
 
-func (m *MockClientService) GetClientByID(id int) (*models.Client, error) {
-	args := m.Called(id)
+func (m *MockAvailabilityService) SetAvailability(caregiverID int, req *models.CaregiverAvailabilityRequest) (*models.CaregiverAvailability, error) {
+	args := m.Called(caregiverID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Client), args.Error(1)
+	return args.Get(0).(*models.CaregiverAvailability), args.Error(1)
 }
 
-func (m *MockClientService) CreateClient(req *models.ClientCreateRequest) (*models.Client, error) {
-	args := m.Called(req)
+func (m *MockAvailabilityService) GetTimeOff(caregiverID int) ([]models.TimeOffRequest, error) {
+	args := m.Called(caregiverID)
+	return args.Get(0).([]models.TimeOffRequest), args.Error(1)
+}
+
+func (m *MockAvailabilityService) RequestTimeOff(caregiverID int, req *models.TimeOffCreateRequest) (*models.TimeOffRequest, error) {
+	args := m.Called(caregiverID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Client), args.Error(1)
+	return args.Get(0).(*models.TimeOffRequest), args.Error(1)
 }
 
-func (m *MockClientService) UpdateClient(id int, req *models.ClientUpdateRequest) (*models.Client, error) {
+func (m *MockAvailabilityService) ApproveTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
 	args := m.Called(id, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Client), args.Error(1)
+	return args.Get(0).(*models.TimeOffRequest), args.Error(1)
 }
 
-func (m *MockClientService) DeleteClient(id int) error {
-	args := m.Called(id)
-	return args.Error(0)
+func (m *MockAvailabilityService) RejectTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeOffRequest), args.Error(1)
 }
 
-func (m *MockClientService) SearchClients(query string) ([]models.Client, error) {
-	args := m.Called(query)
-	return args.Get(0).([]models.Client), args.Error(1)
+func (m *MockAvailabilityService) CancelTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeOffRequest), args.Error(1)
 }
 
-func setupTestHandler() (*Handler, *MockScheduleService, *MockVisitService, *MockTaskService, *MockClientService) {
-	gin.SetMode(gin.TestMode)
+func (m *MockAvailabilityService) GetAvailableCaregivers(start, end time.Time) ([]int, error) {
+	args := m.Called(start, end)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
 
-	mockScheduleService := new(MockScheduleService)
-	mockVisitService := new(MockVisitService)
-	mockTaskService := new(MockTaskService)
-	mockClientService := new(MockClientService)
-	logger := logrus.New()
+// MockSkillService is a mock implementation of SkillService
+type MockSkillService struct {
+	mock.Mock
+}
 
-	handler := NewHandler(mockScheduleService, mockVisitService, mockTaskService, mockClientService, logger)
+func (m *MockSkillService) GetCaregiverSkills(caregiverID int) ([]models.CaregiverSkill, error) {
+	args := m.Called(caregiverID)
+	return args.Get(0).([]models.CaregiverSkill), args.Error(1)
+}
 
-	return handler, mockScheduleService, mockVisitService, mockTaskService, mockClientService
+func (m *MockSkillService) SetCaregiverSkill(caregiverID int, skill string, req *models.CaregiverSkillRequest) (*models.CaregiverSkill, error) {
+	args := m.Called(caregiverID, skill, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverSkill), args.Error(1)
 }
 
-func TestHandler_HealthCheck(t *testing.T) {
-	// Setup
-	handler, _, _, _, _ := setupTestHandler()
-	router := handler.SetupRoutes()
+func (m *MockSkillService) DeleteCaregiverSkill(caregiverID int, skill string) error {
+	args := m.Called(caregiverID, skill)
+	return args.Error(0)
+}
 
-	// Create request
-	req, _ := http.NewRequest("GET", "/health", nil)
-	w := httptest.NewRecorder()
+func (m *MockSkillService) GetExpiringCertifications(days int) ([]models.CaregiverSkill, error) {
+	args := m.Called(days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CaregiverSkill), args.Error(1)
+}
 
-	// Execute
-	router.ServeHTTP(w, req)
+func (m *MockSkillService) GetServiceTypes() ([]models.ServiceType, error) {
+	args := m.Called()
+	return args.Get(0).([]models.ServiceType), args.Error(1)
+}
 
-	// Assert
-	assert.Equal(t, http.StatusOK, w.Code)
+func (m *MockSkillService) CreateServiceType(req *models.ServiceTypeRequest) (*models.ServiceType, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ServiceType), args.Error(1)
+}
 
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
-	assert.Equal(t, "healthy", response["status"])
-	assert.Equal(t, "caregiver-shift-tracker", response["service"])
+func (m *MockSkillService) UpdateServiceType(id int, req *models.ServiceTypeRequest) (*models.ServiceType, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ServiceType), args.Error(1)
 }
 
-func TestHandler_GetScheduleByID(t *testing.T) {
-	// Setup
-	handler, mockScheduleService, _, _, _ := setupTestHandler()
-	router := handler.SetupRoutes()
+// MockMatchingService is a mock implementation of MatchingService
+type MockMatchingService struct {
+	mock.Mock
+}
 
-	// Test data
-	expectedSchedule := &models.Schedule{
-		ID:       1,
-		ClientID: 1,
-		Status:   "scheduled",
-		Client: &models.Client{
-			ID:   1,
-			Name: "John Doe",
-		},
+func (m *MockMatchingService) GetCaregiverProfile(caregiverID int) (*models.CaregiverProfile, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*models.CaregiverProfile), args.Error(1)
+}
 
-	// Mock expectations
-	mockScheduleService.On("GetScheduleByID", 1).Return(expectedSchedule, nil)
+func (m *MockMatchingService) SetCaregiverProfile(caregiverID int, req *models.CaregiverProfileRequest) (*models.CaregiverProfile, error) {
+	args := m.Called(caregiverID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverProfile), args.Error(1)
+}
 
-	// Create request
-	req, _ := http.NewRequest("GET", "/api/v1/schedules/1", nil)
-	w := httptest.NewRecorder()
+func (m *MockMatchingService) GetClientPreferences(clientID int) (*models.ClientPreferences, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientPreferences), args.Error(1)
+}
 
-	// Execute
-	router.ServeHTTP(w, req)
+func (m *MockMatchingService) SetClientPreferences(clientID int, req *models.ClientPreferencesRequest) (*models.ClientPreferences, error) {
+	args := m.Called(clientID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientPreferences), args.Error(1)
+}
 
-	// Assert
-	assert.Equal(t, http.StatusOK, w.Code)
+func (m *MockMatchingService) GetCandidates(scheduleID int, opts models.CandidateOptions) ([]models.MatchCandidate, error) {
+	args := m.Called(scheduleID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MatchCandidate), args.Error(1)
+}
 
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
-	assert.True(t, response["success"].(bool))
+// MockShiftBoardService is a mock implementation of ShiftBoardService
+type MockShiftBoardService struct {
+	mock.Mock
+}
 
-	data := response["data"].(map[string]interface{})
-	assert.Equal(t, float64(1), data["id"])
+func (m *MockShiftBoardService) GetOpenShifts(filter *models.OpenShiftFilter) ([]models.OpenShift, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OpenShift), args.Error(1)
+}
 
-	client := data["client"].(map[string]interface{})
-	assert.Equal(t, "John Doe", client["name"])
+func (m *MockShiftBoardService) GetSwapRequests(caregiverID int) ([]models.SwapRequest, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SwapRequest), args.Error(1)
+}
 
-	// Verify mock expectations
-	mockScheduleService.AssertExpectations(t)
+func (m *MockShiftBoardService) ReleaseSchedule(scheduleID int, req *models.ScheduleReleaseRequest) (*models.OpenShift, error) {
+	args := m.Called(scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
 }
 
-func TestHandler_GetScheduleByID_NotFound(t *testing.T) {
-	// Setup
-	handler, mockScheduleService, _, _, _ := setupTestHandler()
-	router := handler.SetupRoutes()
+func (m *MockShiftBoardService) GetOpenShift(id int) (*models.OpenShift, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
 
-	// Mock expectations
-	mockScheduleService.On("GetScheduleByID", 999).Return(nil, nil)
+func (m *MockShiftBoardService) ClaimOpenShift(id int, req *models.OpenShiftClaimRequest) (*models.OpenShift, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
 
-	// Create request
-	req, _ := http.NewRequest("GET", "/api/v1/schedules/999", nil)
-	w := httptest.NewRecorder()
+func (m *MockShiftBoardService) ApproveOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
 
-	// Execute
-	router.ServeHTTP(w, req)
+func (m *MockShiftBoardService) RejectOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
+
+func (m *MockShiftBoardService) CancelOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
+
+func (m *MockShiftBoardService) RequestSwap(req *models.SwapRequestCreateRequest) (*models.SwapRequest, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapRequest), args.Error(1)
+}
+
+func (m *MockShiftBoardService) GetSwapRequest(id int) (*models.SwapRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapRequest), args.Error(1)
+}
+
+func (m *MockShiftBoardService) AcceptSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapRequest), args.Error(1)
+}
+
+func (m *MockShiftBoardService) RejectSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapRequest), args.Error(1)
+}
+
+func (m *MockShiftBoardService) CancelSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapRequest), args.Error(1)
+}
+
+// MockRouteService is a mock implementation of RouteService
+type MockRouteService struct {
+	mock.Mock
+}
+
+func (m *MockRouteService) PlanRoute(caregiverID int, date string) (*models.RoutePlan, error) {
+	args := m.Called(caregiverID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RoutePlan), args.Error(1)
+}
+
+// MockMileageService is a mock implementation of MileageService
+type MockMileageService struct {
+	mock.Mock
+}
+
+func (m *MockMileageService) GetTravelLog(caregiverID int, from, to string) (*models.TravelLog, error) {
+	args := m.Called(caregiverID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TravelLog), args.Error(1)
+}
+
+func (m *MockMileageService) RecalculateDay(caregiverID int, date string) (*models.TravelLog, error) {
+	args := m.Called(caregiverID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TravelLog), args.Error(1)
+}
+
+func (m *MockMileageService) AdjustTravelSegment(id int, req *models.TravelAdjustmentRequest) (*models.TravelSegment, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TravelSegment), args.Error(1)
+}
+
+// MockTrackingService is a mock implementation of TrackingService
+type MockTrackingService struct {
+	mock.Mock
+}
+
+func (m *MockTrackingService) RecordLocations(scheduleID int, req *models.VisitLocationRequest) (*models.VisitTrack, error) {
+	args := m.Called(scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitTrack), args.Error(1)
+}
+
+func (m *MockTrackingService) GetVisitTrack(scheduleID int, caregiverID *int) (*models.VisitTrack, error) {
+	args := m.Called(scheduleID, caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitTrack), args.Error(1)
+}
+
+// MockAnomalyService is a mock implementation of AnomalyService
+type MockAnomalyService struct {
+	mock.Mock
+}
+
+func (m *MockAnomalyService) GetReviews(status string) ([]models.VisitReview, error) {
+	args := m.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.VisitReview), args.Error(1)
+}
+
+func (m *MockAnomalyService) GetReview(id int) (*models.VisitReview, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitReview), args.Error(1)
+}
+
+func (m *MockAnomalyService) ResolveReview(id int, req *models.VisitReviewResolveRequest) (*models.VisitReview, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitReview), args.Error(1)
+}
+
+// MockVerificationService is a mock implementation of VerificationService
+type MockVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockVerificationService) IssueToken(clientID int) (*models.ClientVerificationToken, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientVerificationToken), args.Error(1)
+}
+
+func (m *MockVerificationService) GetToken(clientID int) (*models.ClientVerificationToken, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientVerificationToken), args.Error(1)
+}
+
+func (m *MockVerificationService) RevokeToken(clientID int) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+
+// MockTelephonyService is a mock implementation of TelephonyService
+type MockTelephonyService struct {
+	mock.Mock
+}
+
+func (m *MockTelephonyService) SetPIN(caregiverID int, req *models.TelephonyPINRequest) (*models.CaregiverTelephonyPIN, error) {
+	args := m.Called(caregiverID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverTelephonyPIN), args.Error(1)
+}
+
+func (m *MockTelephonyService) HandleCall(call *models.TelephonyCall) (*models.TelephonyPrompt, error) {
+	args := m.Called(call)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TelephonyPrompt), args.Error(1)
+}
+
+// MockVisitCorrectionService is a mock implementation of VisitCorrectionService
+type MockVisitCorrectionService struct {
+	mock.Mock
+}
+
+func (m *MockVisitCorrectionService) RequestCorrection(scheduleID int, req *models.VisitCorrectionRequest) (*models.VisitCorrection, error) {
+	args := m.Called(scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionService) GetCorrections(status string) ([]models.VisitCorrection, error) {
+	args := m.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionService) GetScheduleCorrections(scheduleID int) ([]models.VisitCorrection, error) {
+	args := m.Called(scheduleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionService) GetCorrection(id int) (*models.VisitCorrection, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionService) ApproveCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionService) RejectCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionService) CancelCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitCorrection), args.Error(1)
+}
+
+// MockGeocoderService is a mock implementation of GeocoderService
+type MockGeocoderService struct {
+	mock.Mock
+}
+
+func (m *MockGeocoderService) ReverseGeocode(latitude, longitude float64) (*models.ReverseGeocode, error) {
+	args := m.Called(latitude, longitude)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReverseGeocode), args.Error(1)
+}
+
+// MockCarePlanService is a mock implementation of CarePlanService
+type MockCarePlanService struct {
+	mock.Mock
+}
+
+func (m *MockCarePlanService) GetTaskTemplates() ([]models.TaskTemplate, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockCarePlanService) CreateTaskTemplate(req *models.TaskTemplateRequest) (*models.TaskTemplate, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockCarePlanService) UpdateTaskTemplate(id int, req *models.TaskTemplateRequest) (*models.TaskTemplate, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockCarePlanService) GetCarePlan(clientID int) (*models.CarePlan, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CarePlan), args.Error(1)
+}
+
+func (m *MockCarePlanService) GetCarePlanVersions(clientID int) ([]models.CarePlan, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CarePlan), args.Error(1)
+}
+
+func (m *MockCarePlanService) GetCarePlanVersion(id int) (*models.CarePlan, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CarePlan), args.Error(1)
+}
+
+func (m *MockCarePlanService) ReviseCarePlan(clientID int, req *models.CarePlanRequest) (*models.CarePlan, error) {
+	args := m.Called(clientID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CarePlan), args.Error(1)
+}
+
+// MockClientService is a mock implementation of ClientService
+type MockClientService struct {
+	mock.Mock
+}
+
+func (m *MockClientService) GetAllClients(filter *models.ClientFilter) ([]models.Client, *models.PageInfo, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]models.Client), args.Get(1).(*models.PageInfo), args.Error(2)
+}
+
+func (m *MockClientService) GetClientByID(id int) (*models.Client, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+func (m *MockClientService) CreateClient(req *models.ClientCreateRequest) (*models.Client, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+func (m *MockClientService) UpdateClient(id int, req *models.ClientUpdateRequest) (*models.Client, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+func (m *MockClientService) GeocodeClient(id int, expectedVersion *int) (*models.Client, error) {
+	args := m.Called(id, expectedVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+func (m *MockClientService) DeleteClient(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockClientService) SearchClients(query string) ([]models.Client, error) {
+	args := m.Called(query)
+	return args.Get(0).([]models.Client), args.Error(1)
+}
+
+// newTestHandler returns a handler backed by the given services; tests set
+// only the mocks their routes use
+func newTestHandler(services Services) *Handler {
+	gin.SetMode(gin.TestMode)
+	return NewHandler(services, nil, logrus.New())
+}
+
+func setupTestHandler() (*Handler, *MockScheduleService, *MockVisitService, *MockTaskService, *MockClientService) {
+	mockScheduleService := new(MockScheduleService)
+	mockVisitService := new(MockVisitService)
+	mockTaskService := new(MockTaskService)
+	mockClientService := new(MockClientService)
+
+	handler := newTestHandler(Services{
+		Schedule: mockScheduleService,
+		Visit:    mockVisitService,
+		Task:     mockTaskService,
+		Client:   mockClientService,
+	})
+
+	return handler, mockScheduleService, mockVisitService, mockTaskService, mockClientService
+}
+
+func TestHandler_HealthCheck(t *testing.T) {
+	// Setup
+	handler, _, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "healthy", response["status"])
+	assert.Equal(t, "caregiver-shift-tracker", response["service"])
+}
+
+func TestHandler_GetScheduleByID(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Test data
+	expectedSchedule := &models.Schedule{
+		ID:       1,
+		ClientID: 1,
+		Status:   "scheduled",
+		Client: &models.Client{
+			ID:   1,
+			Name: "John Doe",
+		},
+	}
+
+	// Mock expectations
+	mockScheduleService.On("GetScheduleByID", 1).Return(expectedSchedule, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules/1", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response["success"].(bool))
+
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["id"])
+
+	client := data["client"].(map[string]interface{})
+	assert.Equal(t, "John Doe", client["name"])
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_GetScheduleByID_NotFound(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("GetScheduleByID", 999).Return(nil, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules/999", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Schedule not found", response["error"])
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_StartVisit(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Test data
+	requestBody := models.VisitStartRequest{
+		Latitude:  40.7128,
+		Longitude: -74.0060,
+	}
+
+	// Mock expectations
+	mockScheduleService.On("StartVisit", 1, &requestBody).Return(nil)
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response["success"].(bool))
+	assert.Equal(t, "Visit started successfully", response["message"])
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_UpdateTaskStatus(t *testing.T) {
+	// Setup
+	handler, _, _, mockTaskService, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Test data
+	requestBody := models.TaskUpdateRequest{
+		Status: "completed",
+	}
+
+	// Mock expectations
+	expectedTask := &models.Task{
+		ID:          1,
+		ScheduleID:  1,
+		Title:       "Give medication",
+		Status:      "completed",
+		Description: "Administer morning medications",
+	}
+	mockTaskService.On("UpdateTaskStatus", 1, &requestBody).Return(expectedTask, nil)
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response["success"].(bool))
+	assert.Equal(t, expectedTask.ID, int(response["data"].(map[string]interface{})["id"].(float64)))
+
+	// Verify mock expectations
+	mockTaskService.AssertExpectations(t)
+}
+
+func TestHandler_GetTodaySchedules(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Test data
+	expectedSchedules := []models.Schedule{
+		{
+			ID:       1,
+			ClientID: 1,
+			Status:   "scheduled",
+			Client: &models.Client{
+				ID:   1,
+				Name: "John Doe",
+			},
+		},
+		{
+			ID:       2,
+			ClientID: 2,
+			Status:   "completed",
+			Client: &models.Client{
+				ID:   2,
+				Name: "Jane Smith",
+			},
+		},
+	}
+
+	// Mock expectations
+	mockScheduleService.On("GetTodaySchedules", 1).Return(expectedSchedules, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules/today?caregiver_id=1", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response["success"].(bool))
+
+	data := response["data"].([]interface{})
+	assert.Len(t, data, 2)
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_EndVisit_InvalidTransition(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Test data
+	requestBody := models.VisitEndRequest{
+		Latitude:  40.7128,
+		Longitude: -74.0060,
+	}
+
+	// Mock expectations
+	mockScheduleService.On("EndVisit", 1, &requestBody).
+		Return(fmt.Errorf("%w: cannot end schedule in status missed", services.ErrInvalidTransition))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/end", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Visit cannot be ended", response["error"])
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_GetTaskByID_ETag(t *testing.T) {
+	// Setup
+	handler, _, _, mockTaskService, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockTaskService.On("GetTaskByID", 1).Return(&models.Task{ID: 1, ScheduleID: 1, Status: "pending", Version: 4}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/tasks/1", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+
+	// Verify mock expectations
+	mockTaskService.AssertExpectations(t)
+}
+
+func TestHandler_UpdateTaskStatus_PreconditionFailed(t *testing.T) {
+	// Setup
+	handler, _, _, mockTaskService, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockTaskService.On("UpdateTaskStatus", 1, mock.MatchedBy(func(req *models.TaskUpdateRequest) bool {
+		return req.ExpectedVersion != nil && *req.ExpectedVersion == 2
+	})).Return(nil, fmt.Errorf("task 1 is at version 3: %w", services.ErrVersionConflict))
+
+	// Create request
+	jsonBody, _ := json.Marshal(models.TaskUpdateRequest{Status: "completed"})
+	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// Verify mock expectations
+	mockTaskService.AssertExpectations(t)
+}
+
+// MockIdempotencyService is a mock implementation of IdempotencyService
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyService) Begin(key, method, path string, body []byte) (*models.IdempotencyRecord, bool, error) {
+	args := m.Called(key, method, path, body)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *MockIdempotencyService) Complete(key string, statusCode int, headers map[string]string, body []byte) error {
+	args := m.Called(key, statusCode, headers, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyService) Release(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func TestHandler_StartVisit_IdempotentReplay(t *testing.T) {
+	// Setup
+	mockScheduleService := new(MockScheduleService)
+	mockIdempotencyService := new(MockIdempotencyService)
+	handler := newTestHandler(Services{Schedule: mockScheduleService, Idempotency: mockIdempotencyService})
+	router := handler.SetupRoutes()
+
+	// Test data
+	jsonBody, _ := json.Marshal(models.VisitStartRequest{Latitude: 40.7128, Longitude: -74.0060})
+	status := http.StatusOK
+	stored := &models.IdempotencyRecord{
+		Key:          "start-1",
+		StatusCode:   &status,
+		ResponseBody: []byte(`{"success":true,"message":"Visit started successfully"}`),
+		ResponseHeaders: map[string]string{
+			"Content-Type": "application/json; charset=utf-8",
+			"ETag":         `"4"`,
+		},
+	}
+
+	// Mock expectations
+	mockIdempotencyService.On("Begin", "start-1", "POST", "/api/v1/schedules/1/start", jsonBody).Return(stored, true, nil)
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "start-1")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(stored.ResponseBody), w.Body.String())
+
+	// Verify mock expectations
+	mockIdempotencyService.AssertExpectations(t)
+	mockScheduleService.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything)
+}
+
+func TestHandler_StartVisit_IdempotencyKeyStored(t *testing.T) {
+	// Setup
+	mockScheduleService := new(MockScheduleService)
+	mockIdempotencyService := new(MockIdempotencyService)
+	handler := newTestHandler(Services{Schedule: mockScheduleService, Idempotency: mockIdempotencyService})
+	router := handler.SetupRoutes()
+
+	// Test data
+	requestBody := models.VisitStartRequest{Latitude: 40.7128, Longitude: -74.0060}
+	jsonBody, _ := json.Marshal(requestBody)
+
+	// Mock expectations
+	mockIdempotencyService.On("Begin", "start-1", "POST", "/api/v1/schedules/1/start", jsonBody).
+		Return(&models.IdempotencyRecord{Key: "start-1"}, false, nil)
+	mockScheduleService.On("StartVisit", 1, &requestBody).Return(nil)
+	mockIdempotencyService.On("Complete", "start-1", http.StatusOK, mock.MatchedBy(func(headers map[string]string) bool {
+		return headers["Content-Type"] == "application/json; charset=utf-8"
+	}), mock.Anything).Return(nil)
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "start-1")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+	mockIdempotencyService.AssertExpectations(t)
+}
+
+func TestHandler_StartVisit_IdempotencyKeyReleasedOnPanic(t *testing.T) {
+	// Setup
+	mockScheduleService := new(MockScheduleService)
+	mockIdempotencyService := new(MockIdempotencyService)
+	handler := newTestHandler(Services{Schedule: mockScheduleService, Idempotency: mockIdempotencyService})
+	router := handler.SetupRoutes()
+
+	// Test data
+	requestBody := models.VisitStartRequest{Latitude: 40.7128, Longitude: -74.0060}
+	jsonBody, _ := json.Marshal(requestBody)
+
+	// Mock expectations
+	mockIdempotencyService.On("Begin", "start-1", "POST", "/api/v1/schedules/1/start", jsonBody).
+		Return(&models.IdempotencyRecord{Key: "start-1"}, false, nil)
+	mockScheduleService.On("StartVisit", 1, &requestBody).Run(func(mock.Arguments) {
+		panic("boom")
+	}).Return(nil)
+	mockIdempotencyService.On("Release", "start-1").Return(nil)
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "start-1")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// Verify mock expectations
+	mockIdempotencyService.AssertExpectations(t)
+	mockIdempotencyService.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_StartVisit_IdempotencyKeyReused(t *testing.T) {
+	// Setup
+	mockScheduleService := new(MockScheduleService)
+	mockIdempotencyService := new(MockIdempotencyService)
+	handler := newTestHandler(Services{Schedule: mockScheduleService, Idempotency: mockIdempotencyService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockIdempotencyService.On("Begin", "start-1", "POST", "/api/v1/schedules/1/start", mock.Anything).
+		Return(nil, false, services.ErrIdempotencyKeyReused)
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBufferString(`{"latitude":1,"longitude":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "start-1")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Verify mock expectations
+	mockIdempotencyService.AssertExpectations(t)
+	mockScheduleService.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything)
+}
+
+func TestHandler_StartVisit_IdempotencyKeyQueryString(t *testing.T) {
+	// Setup
+	mockScheduleService := new(MockScheduleService)
+	mockIdempotencyService := new(MockIdempotencyService)
+	handler := newTestHandler(Services{Schedule: mockScheduleService, Idempotency: mockIdempotencyService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations: the query string is fingerprinted with the path
+	mockIdempotencyService.On("Begin", "start-1", "POST", "/api/v1/schedules/1/start?caregiver_id=2", mock.Anything).
+		Return(nil, false, services.ErrIdempotencyKeyReused)
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start?caregiver_id=2", bytes.NewBufferString(`{"latitude":1,"longitude":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "start-1")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Verify mock expectations
+	mockIdempotencyService.AssertExpectations(t)
+	mockScheduleService.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything)
+}
+
+func TestHandler_GetSchedules_Pagination(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Test data
+	schedules := []models.Schedule{{ID: 3, ClientID: 1, Status: "scheduled"}}
+	total := 7
+	page := &models.PageInfo{NextCursor: "abc", HasMore: true, Limit: 1, Total: &total}
+
+	// Mock expectations
+	mockScheduleService.On("GetAllSchedules", mock.MatchedBy(func(filter *models.ScheduleFilter) bool {
+		return filter.Cursor == "xyz" && *filter.Limit == 1 && filter.Sort == "-start_time" && filter.IncludeTotal
+	})).Return(schedules, page, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules?cursor=xyz&limit=1&sort=-start_time&include_total=true", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response["data"], 1)
+
+	pagination := response["pagination"].(map[string]interface{})
+	assert.Equal(t, "abc", pagination["next_cursor"])
+	assert.Equal(t, true, pagination["has_more"])
+	assert.Equal(t, float64(7), pagination["total"])
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_GetSchedules_InvalidSort(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("GetAllSchedules", mock.Anything).
+		Return(nil, nil, fmt.Errorf("failed to get schedules: %w: \"notes\"", services.ErrInvalidSort))
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules?sort=notes", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_GetSchedules_OffsetRejected(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules?offset=20", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "cursor")
+	mockScheduleService.AssertNotCalled(t, "GetAllSchedules", mock.Anything)
+}
+
+func TestHandler_GetClients_InvalidLimit(t *testing.T) {
+	// Setup
+	handler, _, _, _, mockClientService := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/clients?limit=0", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockClientService.AssertNotCalled(t, "GetAllClients", mock.Anything)
+}
+
+func TestHandler_GetClients_Near(t *testing.T) {
+	// Setup
+	handler, _, _, _, mockClientService := setupTestHandler()
+	router := handler.SetupRoutes()
+	distance := 2.5
+
+	// Mock expectations
+	mockClientService.On("GetAllClients", mock.MatchedBy(func(filter *models.ClientFilter) bool {
+		return filter.Near != nil && filter.Near.Latitude == 39.78 && filter.Near.Longitude == -89.65 && filter.RadiusKm == 5
+	})).Return([]models.Client{{ID: 5, DistanceKm: &distance}}, &models.PageInfo{Limit: models.DefaultPageSize}, nil)
+
+	// Execute
+	req, _ := http.NewRequest("GET", "/api/v1/clients?near=39.78,-89.65&radius_km=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	clients := response["data"].(map[string]interface{})["clients"].([]interface{})
+	assert.Equal(t, 2.5, clients[0].(map[string]interface{})["distance_km"])
+
+	// Malformed points are rejected before the service is called
+	for _, query := range []string{"near=39.78", "near=north,-89.65", "near=39.78,-89.65&radius_km=far"} {
+		req, _ = http.NewRequest("GET", "/api/v1/clients?"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockClientService.AssertNumberOfCalls(t, "GetAllClients", 1)
+}
+
+func TestHandler_CreateClient_NotGeocoded(t *testing.T) {
+	// Setup
+	handler, _, _, _, mockClientService := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockClientService.On("CreateClient", mock.AnythingOfType("*models.ClientCreateRequest")).
+		Return(nil, fmt.Errorf("%w: the address could not be geocoded", services.ErrValidation))
+
+	// Create request
+	jsonBody, _ := json.Marshal(models.ClientCreateRequest{Name: "Jane Doe", Address: "1 Nowhere Rd", City: "Springfield", State: "IL", ZipCode: "62799"})
+	req, _ := http.NewRequest("POST", "/api/v1/clients", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockClientService.AssertExpectations(t)
+}
+
+func TestHandler_GeocodeClient(t *testing.T) {
+	// Setup
+	handler, _, _, _, mockClientService := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockClientService.On("GeocodeClient", 7, mock.MatchedBy(func(version *int) bool {
+		return version != nil && *version == 2
+	})).Return(&models.Client{ID: 7, Latitude: 39.7751, Longitude: -89.672, GeocodeMatch: models.GeocodeMatchAddress,
+		GeocodeConfidence: 1, Version: 3}, nil)
+	mockClientService.On("GeocodeClient", 8, (*int)(nil)).
+		Return(nil, fmt.Errorf("%w: the address could not be geocoded", services.ErrValidation))
+	mockClientService.On("GeocodeClient", 9, (*int)(nil)).Return(nil, fmt.Errorf("client 9: %w", services.ErrNotFound))
+
+	// Execute
+	req, _ := http.NewRequest("POST", "/api/v1/clients/7/geocode", nil)
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	client := response["data"].(map[string]interface{})["client"].(map[string]interface{})
+	assert.Equal(t, "address", client["geocode_match"])
+
+	// The address cannot be placed
+	req, _ = http.NewRequest("POST", "/api/v1/clients/8/geocode", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Unknown client
+	req, _ = http.NewRequest("POST", "/api/v1/clients/9/geocode", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockClientService.AssertExpectations(t)
+}
+
+func TestHandler_Client_NotFound(t *testing.T) {
+	// Setup
+	handler, _, _, _, mockClientService := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockClientService.On("GetClientByID", 999).Return(nil, nil)
+	mockClientService.On("UpdateClient", 999, mock.AnythingOfType("*models.ClientUpdateRequest")).Return(nil, nil)
+
+	// Execute
+	req, _ := http.NewRequest("GET", "/api/v1/clients/999", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Client not found", response["error"])
+
+	// Updating an unknown client
+	req, _ = http.NewRequest("PUT", "/api/v1/clients/999", bytes.NewBufferString(`{"notes":"moved"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	mockClientService.AssertExpectations(t)
+}
+
+func TestHandler_GetSchedules_Filters(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	newYork, _ := time.LoadLocation("America/New_York")
+	expectedFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, newYork)
+	expectedTo := time.Date(2025, 2, 1, 0, 0, 0, 0, newYork)
+
+	// Mock expectations
+	mockScheduleService.On("GetAllSchedules", mock.MatchedBy(func(filter *models.ScheduleFilter) bool {
+		return *filter.ClientID == 102 &&
+			filter.From.Equal(expectedFrom) &&
+			filter.To.Equal(expectedTo) &&
+			assert.ObjectsAreEqual([]string{"missed", "cancelled"}, filter.Statuses) &&
+			*filter.ServiceName == "Personal Care" &&
+			*filter.HasIncompleteTasks
+	})).Return([]models.Schedule{}, &models.PageInfo{Limit: models.DefaultPageSize}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules?client_id=102&from=2025-01-01&to=2025-01-31&tz=America/New_York"+
+		"&status=missed,cancelled&service_name=Personal%20Care&has_incomplete_tasks=true", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_GetSchedules_InvalidTimezone(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules?from=2025-01-01&tz=Mars/Olympus", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockScheduleService.AssertNotCalled(t, "GetAllSchedules", mock.Anything)
+}
+
+func TestHandler_GetSchedules_DateInTimezone(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// The day of the fall back DST change is 25 hours long in New York
+	newYork, _ := time.LoadLocation("America/New_York")
+	expectedFrom := time.Date(2025, 11, 2, 0, 0, 0, 0, newYork)
+
+	// Mock expectations
+	mockScheduleService.On("GetAllSchedules", mock.MatchedBy(func(filter *models.ScheduleFilter) bool {
+		return filter.From == nil && filter.ActiveFrom.Equal(expectedFrom) && filter.ActiveTo.Sub(*filter.ActiveFrom) == 25*time.Hour
+	})).Return([]models.Schedule{}, &models.PageInfo{Limit: models.DefaultPageSize}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules?date=2025-11-02&tz=America/New_York", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_AddScheduleSegment_Invalid(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("AddScheduleSegment", 1, mock.AnythingOfType("*models.ScheduleSegmentRequest")).
+		Return(nil, fmt.Errorf("%w: segment must lie within the scheduled shift", services.ErrValidation))
+
+	// Create request
+	body := []byte(`{"type":"sleep","start_time":"2025-01-01T23:00:00Z","end_time":"2025-01-02T06:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/segments", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_StartVisit_CaregiverNotOnTeam(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	stranger := 9
+	requestBody := models.VisitStartRequest{Latitude: 40.7128, Longitude: -74.0060, CaregiverID: &stranger}
+
+	// Mock expectations
+	mockScheduleService.On("StartVisit", 1, &requestBody).
+		Return(fmt.Errorf("%w: caregiver 9 is not assigned to schedule 1", services.ErrValidation))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_CancelVisit_TeamMember(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("CancelVisit", 1, mock.MatchedBy(func(req *models.VisitCancelRequest) bool {
+		return req.CaregiverID != nil && *req.CaregiverID == 2
+	})).Return(nil)
+	mockScheduleService.On("CancelVisit", 3, &models.VisitCancelRequest{}).Return(nil)
+
+	// Execute
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/cancel", bytes.NewBufferString(`{"caregiver_id":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Without a body the lead aborts
+	req, _ = http.NewRequest("POST", "/api/v1/schedules/3/cancel", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_AddScheduleCaregiver(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	requestBody := models.ScheduleCaregiverRequest{CaregiverID: 2}
+
+	// Mock expectations
+	mockScheduleService.On("AddScheduleCaregiver", 1, &requestBody).
+		Return(&models.ScheduleCaregiver{ScheduleID: 1, CaregiverID: 2, Role: models.CaregiverRoleMember}, nil)
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/caregivers", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	caregiver := response["data"].(map[string]interface{})["caregiver"].(map[string]interface{})
+	assert.Equal(t, "member", caregiver["role"])
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_RemoveScheduleCaregiver_ClockedIn(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("RemoveScheduleCaregiver", 1, 2).
+		Return(fmt.Errorf("%w: caregiver 2 has already clocked in", services.ErrInvalidTransition))
+
+	// Create request
+	req, _ := http.NewRequest("DELETE", "/api/v1/schedules/1/caregivers/2", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_AssignTask(t *testing.T) {
+	// Setup
+	handler, _, _, mockTaskService, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	member := 2
+	version := 3
+
+	// Mock expectations
+	mockTaskService.On("AssignTask", 1, &models.TaskAssignRequest{CaregiverID: &member, ExpectedVersion: &version}).
+		Return(&models.Task{ID: 1, ScheduleID: 1, CaregiverID: &member, Version: 4}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1/assignee", bytes.NewBufferString(`{"caregiver_id":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	mockTaskService.AssertExpectations(t)
+}
+
+func TestHandler_GetCaregiverTimesheet(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	timesheet := &models.Timesheet{
+		CaregiverID: 1,
+		From:        "2025-01-01",
+		To:          "2025-01-02",
+		Days: []models.TimesheetDay{
+			{Date: "2025-01-01", TotalHours: 4, WorkedHours: 4},
+			{Date: "2025-01-02", TotalHours: 8, SleepHours: 6, WorkedHours: 2},
+		},
+		TotalHours:  12,
+		SleepHours:  6,
+		WorkedHours: 6,
+	}
+
+	// Mock expectations
+	mockScheduleService.On("GetTimesheet", 1, "2025-01-01", "2025-01-02").Return(timesheet, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/caregivers/1/timesheet?from=2025-01-01&to=2025-01-02", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})["timesheet"].(map[string]interface{})
+	assert.Equal(t, 6.0, data["worked_hours"])
+	assert.Len(t, data["days"], 2)
+
+	// Verify mock expectations
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_PauseVisit_AlreadyPaused(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	requestBody := models.VisitPauseRequest{Latitude: 40.7128, Longitude: -74.0060, Reason: "lunch"}
+
+	// Mock expectations
+	mockScheduleService.On("PauseVisit", 1, &requestBody).
+		Return(fmt.Errorf("%w: visit is already paused", services.ErrInvalidTransition))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/pause", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_ResumeVisit(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	requestBody := models.VisitResumeRequest{Latitude: 40.7128, Longitude: -74.0060}
+
+	// Mock expectations
+	mockScheduleService.On("ResumeVisit", 1, &requestBody).Return(nil)
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/resume", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_CreateSchedule_CaregiverUnavailable(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	requestBody := models.ScheduleCreateRequest{ClientID: 101, CaregiverID: 3, StartTime: start, EndTime: start.Add(2 * time.Hour)}
+
+	// Mock expectations
+	mockScheduleService.On("CreateSchedule", &requestBody).
+		Return(nil, fmt.Errorf("%w: caregiver 3 is on approved time off", services.ErrCaregiverUnavailable))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/schedules", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_ReassignSchedule(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	version := 2
+
+	// Mock expectations
+	mockScheduleService.On("ReassignSchedule", 1, &models.ScheduleReassignRequest{CaregiverID: 3, ExpectedVersion: &version}).
+		Return(&models.Schedule{ID: 1, CaregiverID: 3, Status: models.ScheduleStatusScheduled, Version: 3}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("PUT", "/api/v1/schedules/1/caregiver", bytes.NewBufferString(`{"caregiver_id": 3}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_GetAvailableCaregivers(t *testing.T) {
+	// Setup
+	mockAvailabilityService := new(MockAvailabilityService)
+	handler := newTestHandler(Services{Availability: mockAvailabilityService})
+	router := handler.SetupRoutes()
+
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC)
+
+	// Mock expectations
+	mockAvailabilityService.On("GetAvailableCaregivers", mock.MatchedBy(start.Equal), mock.MatchedBy(end.Equal)).
+		Return([]int{1, 3}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/caregivers/available?start=2025-01-06T09:00:00Z&end=2025-01-06T11:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(2), data["count"])
+
+	// A window without an end is rejected before reaching the service
+	req, _ = http.NewRequest("GET", "/api/v1/caregivers/available?start=2025-01-06T09:00:00Z", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAvailabilityService.AssertNumberOfCalls(t, "GetAvailableCaregivers", 1)
+}
+
+func TestHandler_SetCaregiverAvailability_Invalid(t *testing.T) {
+	// Setup
+	mockAvailabilityService := new(MockAvailabilityService)
+	handler := newTestHandler(Services{Availability: mockAvailabilityService})
+	router := handler.SetupRoutes()
+
+	requestBody := models.CaregiverAvailabilityRequest{
+		Windows: []models.AvailabilityWindow{{Weekday: 1, StartTime: "9am", EndTime: "13:00"}},
+	}
+
+	// Mock expectations
+	mockAvailabilityService.On("SetAvailability", 3, &requestBody).
+		Return(nil, fmt.Errorf("%w: window 0: invalid time of day", services.ErrValidation))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("PUT", "/api/v1/caregivers/3/availability", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAvailabilityService.AssertExpectations(t)
+}
+
+func TestHandler_ApproveTimeOff(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "approved", wantStatus: http.StatusOK},
+		{name: "not pending", err: fmt.Errorf("%w: cannot approve time off in status rejected", services.ErrInvalidTransition), wantStatus: http.StatusConflict},
+		{name: "stale version", err: services.ErrVersionConflict, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockAvailabilityService := new(MockAvailabilityService)
+			handler := newTestHandler(Services{Availability: mockAvailabilityService})
+			router := handler.SetupRoutes()
+			version := 1
+
+			// Mock expectations: the body is optional
+			call := mockAvailabilityService.On("ApproveTimeOff", 7, &models.TimeOffReviewRequest{ExpectedVersion: &version})
+			if tc.err != nil {
+				call.Return(nil, tc.err)
+			} else {
+				call.Return(&models.TimeOffRequest{ID: 7, CaregiverID: 3, Status: models.TimeOffStatusApproved, Version: 2}, nil)
+			}
+
+			// Create request
+			req, _ := http.NewRequest("POST", "/api/v1/time-off/7/approve", nil)
+			req.Header.Set("If-Match", `"1"`)
+			w := httptest.NewRecorder()
+
+			// Execute
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tc.wantStatus, w.Code)
+			if tc.err == nil {
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			}
+			mockAvailabilityService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ReassignSchedule_CaregiverUnqualified(t *testing.T) {
+	// Setup
+	mockScheduleService := new(MockScheduleService)
+	handler := newTestHandler(Services{Schedule: mockScheduleService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("ReassignSchedule", 1, &models.ScheduleReassignRequest{CaregiverID: 2}).
+		Return(nil, fmt.Errorf("%w: caregiver 2 has no valid certification for wound_care", services.ErrCaregiverUnqualified))
+
+	// Create request
+	req, _ := http.NewRequest("PUT", "/api/v1/schedules/1/caregiver", bytes.NewBufferString(`{"caregiver_id": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Caregiver is not qualified")
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_SetCaregiverSkill(t *testing.T) {
+	// Setup
+	mockSkillService := new(MockSkillService)
+	handler := newTestHandler(Services{Skill: mockSkillService})
+	router := handler.SetupRoutes()
+
+	expires := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	requestBody := models.CaregiverSkillRequest{CertificationNumber: "WC-1001", ExpiresAt: &expires}
+
+	// Mock expectations
+	mockSkillService.On("SetCaregiverSkill", 2, "wound_care", &requestBody).
+		Return(&models.CaregiverSkill{ID: 4, CaregiverID: 2, Skill: "wound_care", CertificationNumber: "WC-1001", ExpiresAt: &expires}, nil)
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("PUT", "/api/v1/caregivers/2/skills/wound_care", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSkillService.AssertExpectations(t)
+}
+
+func TestHandler_GetExpiringCertifications(t *testing.T) {
+	// Setup
+	mockSkillService := new(MockSkillService)
+	handler := newTestHandler(Services{Skill: mockSkillService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockSkillService.On("GetExpiringCertifications", 30).
+		Return([]models.CaregiverSkill{{ID: 2, CaregiverID: 2, Skill: "wound_care"}}, nil)
+	mockSkillService.On("GetExpiringCertifications", 0).
+		Return(nil, fmt.Errorf("%w: days must be positive", services.ErrValidation))
+
+	// Without days the default window is used
+	req, _ := http.NewRequest("GET", "/api/v1/caregivers/certifications/expiring", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["count"])
+
+	req, _ = http.NewRequest("GET", "/api/v1/caregivers/certifications/expiring?days=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSkillService.AssertExpectations(t)
+}
+
+func TestHandler_CreateServiceType_Duplicate(t *testing.T) {
+	// Setup
+	mockSkillService := new(MockSkillService)
+	handler := newTestHandler(Services{Skill: mockSkillService})
+	router := handler.SetupRoutes()
+
+	requestBody := models.ServiceTypeRequest{Name: "wound care", RequiredSkills: []string{"wound_care"}}
+
+	// Mock expectations
+	mockSkillService.On("CreateServiceType", &requestBody).
+		Return(nil, fmt.Errorf("%w: service type \"Wound Care\" already exists", services.ErrValidation))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/service-types", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSkillService.AssertExpectations(t)
+}
+
+func TestHandler_GetScheduleCandidates(t *testing.T) {
+	// Setup
+	mockMatchingService := new(MockMatchingService)
+	handler := newTestHandler(Services{Matching: mockMatchingService})
+	router := handler.SetupRoutes()
+
+	limit := 5
+	candidates := []models.MatchCandidate{{
+		CaregiverID: 2,
+		Eligible:    true,
+		Score:       86.7,
+		Factors: []models.MatchFactor{
+			{Name: "continuity", Weight: 30, Score: 0.6, Points: 18, Detail: "3 earlier visits to this client"},
+		},
+	}}
+
+	// Mock expectations
+	mockMatchingService.On("GetCandidates", 1, models.CandidateOptions{IncludeIneligible: true, Limit: &limit}).Return(candidates, nil)
+	mockMatchingService.On("GetCandidates", 3, models.CandidateOptions{}).
+		Return(nil, fmt.Errorf("%w: cannot match caregivers to a missed schedule", services.ErrInvalidTransition))
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules/1/candidates?include_ineligible=true&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["count"])
+
+	// Schedules that already took place cannot be matched
+	req, _ = http.NewRequest("GET", "/api/v1/schedules/3/candidates", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/schedules/1/candidates?include_ineligible=maybe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockMatchingService.AssertExpectations(t)
+}
+
+func TestHandler_SetClientPreferences_NotFound(t *testing.T) {
+	// Setup
+	mockMatchingService := new(MockMatchingService)
+	handler := newTestHandler(Services{Matching: mockMatchingService})
+	router := handler.SetupRoutes()
+
+	requestBody := models.ClientPreferencesRequest{PreferredGender: models.GenderFemale, Languages: []string{"es"}}
+
+	// Mock expectations
+	mockMatchingService.On("SetClientPreferences", 999, &requestBody).
+		Return(nil, fmt.Errorf("client 999: %w", services.ErrNotFound))
+
+	// Create request
+	jsonBody, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("PUT", "/api/v1/clients/999/preferences", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockMatchingService.AssertExpectations(t)
+}
+
+func TestHandler_ClaimOpenShift(t *testing.T) {
+	// Setup
+	mockShiftBoardService := new(MockShiftBoardService)
+	handler := newTestHandler(Services{ShiftBoard: mockShiftBoardService})
+	router := handler.SetupRoutes()
+
+	version := 1
+	claimedBy := 2
+	requestBody := models.OpenShiftClaimRequest{CaregiverID: 2, ExpectedVersion: &version}
+	filled := &models.OpenShift{ID: 5, ScheduleID: 1, ClaimMode: models.ClaimModeFirstCome,
+		Status: models.OpenShiftStatusFilled, ClaimedBy: &claimedBy, Version: 2}
+
+	// Mock expectations
+	mockShiftBoardService.On("ClaimOpenShift", 5, &requestBody).Return(filled, nil)
+	mockShiftBoardService.On("ClaimOpenShift", 6, &models.OpenShiftClaimRequest{CaregiverID: 3}).
+		Return(nil, fmt.Errorf("%w: caregiver 3 has no valid certification", services.ErrCaregiverUnqualified))
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/open-shifts/5/claim", bytes.NewBufferString(`{"caregiver_id":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, "filled", data["status"])
+
+	// Caregivers without the required skills cannot claim
+	req, _ = http.NewRequest("POST", "/api/v1/open-shifts/6/claim", bytes.NewBufferString(`{"caregiver_id":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockShiftBoardService.AssertExpectations(t)
+}
+
+func TestHandler_GetOpenShifts(t *testing.T) {
+	// Setup
+	mockShiftBoardService := new(MockShiftBoardService)
+	handler := newTestHandler(Services{ShiftBoard: mockShiftBoardService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockShiftBoardService.On("GetOpenShifts", mock.MatchedBy(func(filter *models.OpenShiftFilter) bool {
+		return filter.CaregiverID != nil && *filter.CaregiverID == 2 &&
+			assert.ObjectsAreEqual([]string{"open"}, filter.Statuses)
+	})).Return([]models.OpenShift{{ID: 5, Status: models.OpenShiftStatusOpen}}, nil)
+	mockShiftBoardService.On("GetOpenShifts", &models.OpenShiftFilter{Statuses: []string{"taken"}}).
+		Return(nil, fmt.Errorf("%w: unknown status", services.ErrInvalidFilter))
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/open-shifts?status=open&caregiver_id=2", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/open-shifts?status=taken", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockShiftBoardService.AssertExpectations(t)
+}
+
+func TestHandler_AcceptSwap_NotPending(t *testing.T) {
+	// Setup
+	mockShiftBoardService := new(MockShiftBoardService)
+	handler := newTestHandler(Services{ShiftBoard: mockShiftBoardService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockShiftBoardService.On("AcceptSwap", 9, &models.ShiftReviewRequest{}).
+		Return(nil, fmt.Errorf("%w: cannot accept swap request in status rejected", services.ErrInvalidTransition))
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/swap-requests/9/accept", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockShiftBoardService.AssertExpectations(t)
+}
+
+func TestHandler_GetCaregiverRoute(t *testing.T) {
+	// Setup
+	mockRouteService := new(MockRouteService)
+	handler := newTestHandler(Services{Route: mockRouteService})
+	router := handler.SetupRoutes()
+
+	plan := &models.RoutePlan{CaregiverID: 1, Date: "2025-01-06", Feasible: false, TotalDistanceKm: 20,
+		Stops:  []models.RouteStop{{Sequence: 1, ScheduleID: 1}, {Sequence: 2, ScheduleID: 2, LateMinutes: 30}},
+		Issues: []string{"schedule 2 cannot start by 10:00, 30 minutes late"}}
+
+	// Mock expectations
+	mockRouteService.On("PlanRoute", 1, "2025-01-06").Return(plan, nil)
+	mockRouteService.On("PlanRoute", 1, "tomorrow").
+		Return(nil, fmt.Errorf("%w: invalid date", services.ErrValidation))
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/caregivers/1/route?date=2025-01-06", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, false, data["feasible"])
+	assert.Len(t, data["stops"], 2)
+
+	req, _ = http.NewRequest("GET", "/api/v1/caregivers/1/route?date=tomorrow", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRouteService.AssertExpectations(t)
+}
+
+func TestHandler_AdjustTravelSegment(t *testing.T) {
+	// Setup
+	mockMileageService := new(MockMileageService)
+	handler := newTestHandler(Services{Mileage: mockMileageService})
+	router := handler.SetupRoutes()
+
+	km := 12.5
+	segment := &models.TravelSegment{ID: 7, CaregiverID: 1, DistanceKm: 9.8, AdjustedKm: &km,
+		AdjustmentReason: "Detour around road works", MileageKm: 12.5, Version: 3}
+
+	// Mock expectations
+	mockMileageService.On("AdjustTravelSegment", 7, mock.MatchedBy(func(req *models.TravelAdjustmentRequest) bool {
+		return *req.AdjustedKm == 12.5 && req.Reason == "Detour around road works" && *req.ExpectedVersion == 2
+	})).Return(segment, nil)
+	mockMileageService.On("AdjustTravelSegment", 7, mock.MatchedBy(func(req *models.TravelAdjustmentRequest) bool {
+		return *req.ExpectedVersion == 1
+	})).Return(nil, fmt.Errorf("travel segment 7 is at version 3: %w", services.ErrVersionConflict))
+
+	// Create request
+	body := `{"adjusted_km": 12.5, "reason": "Detour around road works"}`
+	req, _ := http.NewRequest("PUT", "/api/v1/travel-segments/7", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, 12.5, data["mileage_km"])
+
+	req, _ = http.NewRequest("PUT", "/api/v1/travel-segments/7", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	mockMileageService.AssertExpectations(t)
+}
+
+func TestHandler_ExportPayroll_CSV(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	export := &models.PayrollExport{From: "2025-01-06", To: "2025-01-07", WorkedHours: 12, MileageKm: 18.4,
+		Rows: []models.PayrollRow{
+			{CaregiverID: 1, Date: "2025-01-06", TotalHours: 8, WorkedHours: 7.5, BreakHours: 0.5, MileageKm: 18.4},
+			{CaregiverID: 2, Date: "2025-01-07", TotalHours: 4.5, WorkedHours: 4.5, Amended: true},
+		}}
+
+	// Mock expectations
+	mockScheduleService.On("ExportPayroll", "2025-01-06", "2025-01-07").Return(export, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/payroll/export?from=2025-01-06&to=2025-01-07&format=csv", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Equal(t, "caregiver_id,date,total_hours,sleep_hours,break_hours,worked_hours,mileage_km,amended\n"+
+		"1,2025-01-06,8.00,0.00,0.50,7.50,18.40,false\n"+
+		"2,2025-01-07,4.50,0.00,0.00,4.50,0.00,true\n", w.Body.String())
+
+	req, _ = http.NewRequest("GET", "/api/v1/payroll/export?from=2025-01-06&to=2025-01-07&format=xml", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_RecordVisitLocations(t *testing.T) {
+	// Setup
+	mockTrackingService := new(MockTrackingService)
+	handler := newTestHandler(Services{Tracking: mockTrackingService})
+	router := handler.SetupRoutes()
+
+	track := &models.VisitTrack{ScheduleID: 3, CaregiverID: 1, PointCount: 2, GeofenceKnown: true,
+		GeofenceRadiusMeters: 150, InsideMinutes: 5, PointsOutside: 1, MaxDistanceMeters: 1112}
+
+	// Mock expectations
+	mockTrackingService.On("RecordLocations", 3, mock.MatchedBy(func(req *models.VisitLocationRequest) bool {
+		return len(req.Points) == 2 && req.Points[1].Longitude == 0.01 && req.CaregiverID == nil
+	})).Return(track, nil).Once()
+	mockTrackingService.On("RecordLocations", 4, mock.Anything).
+		Return(nil, fmt.Errorf("%w: caregiver 1 is not clocked in", services.ErrInvalidTransition)).Once()
+
+	// Create request
+	body := `{"points": [{"latitude": 1, "longitude": 0, "recorded_at": "2025-01-06T09:00:00Z"},
+		{"latitude": 1, "longitude": 0.01, "accuracy": 8, "recorded_at": "2025-01-06T09:05:00Z"}]}`
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/3/locations", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, 1.0, data["points_outside"])
+
+	req, _ = http.NewRequest("POST", "/api/v1/schedules/4/locations", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockTrackingService.AssertExpectations(t)
+}
+
+func TestHandler_GetVisitTrack(t *testing.T) {
+	// Setup
+	mockTrackingService := new(MockTrackingService)
+	handler := newTestHandler(Services{Tracking: mockTrackingService})
+	router := handler.SetupRoutes()
+
+	track := &models.VisitTrack{ScheduleID: 3, CaregiverID: 2, PointCount: 1,
+		Points: []models.LocationPing{{Latitude: 1, Longitude: 0}}}
+
+	// Mock expectations
+	mockTrackingService.On("GetVisitTrack", 3, mock.MatchedBy(func(id *int) bool {
+		return id != nil && *id == 2
+	})).Return(track, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/schedules/3/track?caregiver_id=2", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	data := response["data"].(map[string]interface{})
+	assert.Len(t, data["points"], 1)
+
+	req, _ = http.NewRequest("GET", "/api/v1/schedules/3/track?caregiver_id=x", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockTrackingService.AssertExpectations(t)
+}
+
+func TestHandler_GetVisitReviews(t *testing.T) {
+	// Setup
+	mockAnomalyService := new(MockAnomalyService)
+	handler := newTestHandler(Services{Anomaly: mockAnomalyService})
+	router := handler.SetupRoutes()
+
+	reviews := []models.VisitReview{{ID: 5, ScheduleID: 3, CaregiverID: 1, RiskScore: 80, Status: models.VisitReviewPending,
+		Signals: []models.RiskSignal{{Code: models.RiskSignalMockLocation, Event: models.ClockEventIn, Points: 60}}}}
+
+	// Mock expectations
+	mockAnomalyService.On("GetReviews", models.VisitReviewPending).Return(reviews, nil)
+	mockAnomalyService.On("GetReviews", "open").Return(nil, fmt.Errorf("%w: unknown review status \"open\"", services.ErrValidation))
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/visit-reviews", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
 
 	// Assert
-	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code)
 
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "Schedule not found", response["error"])
+	data := response["data"].([]interface{})
+	assert.Len(t, data, 1)
+	signals := data[0].(map[string]interface{})["signals"].([]interface{})
+	assert.Equal(t, "mock_location", signals[0].(map[string]interface{})["code"])
 
-	// Verify mock expectations
-	mockScheduleService.AssertExpectations(t)
+	req, _ = http.NewRequest("GET", "/api/v1/visit-reviews?status=open", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAnomalyService.AssertExpectations(t)
 }
 
-func TestHandler_StartVisit(t *testing.T) {
+func TestHandler_ResolveVisitReview(t *testing.T) {
 	// Setup
-	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	mockAnomalyService := new(MockAnomalyService)
+	handler := newTestHandler(Services{Anomaly: mockAnomalyService})
 	router := handler.SetupRoutes()
 
-	// Test data
-	requestBody := models.VisitStartRequest{
-		Latitude:  40.7128,
-		Longitude: -74.0060,
-	}
+	reviewedAt := time.Now()
+	review := &models.VisitReview{ID: 5, ScheduleID: 3, CaregiverID: 1, RiskScore: 80, Status: models.VisitReviewConfirmed,
+		ResolutionNotes: "Caregiver was at home", ReviewedAt: &reviewedAt, Version: 3}
 
 	// Mock expectations
-	mockScheduleService.On("StartVisit", 1, &requestBody).Return(nil)
+	mockAnomalyService.On("ResolveReview", 5, mock.MatchedBy(func(req *models.VisitReviewResolveRequest) bool {
+		return req.Status == models.VisitReviewConfirmed && req.Notes == "Caregiver was at home" && *req.ExpectedVersion == 2
+	})).Return(review, nil).Once()
+	mockAnomalyService.On("ResolveReview", 5, mock.Anything).
+		Return(nil, fmt.Errorf("%w: visit review 5 is already confirmed", services.ErrInvalidTransition)).Once()
 
 	// Create request
-	jsonBody, _ := json.Marshal(requestBody)
-	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBuffer(jsonBody))
+	body := `{"status": "confirmed", "notes": "Caregiver was at home"}`
+	req, _ := http.NewRequest("PUT", "/api/v1/visit-reviews/5", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -270,91 +2600,278 @@ func TestHandler_StartVisit(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	req, _ = http.NewRequest("PUT", "/api/v1/visit-reviews/5", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockAnomalyService.AssertExpectations(t)
+}
+
+func TestHandler_IssueClientVerificationToken(t *testing.T) {
+	// Setup
+	mockVerificationService := new(MockVerificationService)
+	handler := newTestHandler(Services{Verification: mockVerificationService})
+	router := handler.SetupRoutes()
+
+	token := &models.ClientVerificationToken{ClientID: 4, Secret: "JBSWY3DPEHPK3PXP", Digits: 6, PeriodSeconds: 30,
+		ProvisioningURI: "otpauth://totp/Caregiver%20Shift%20Tracker:client-4?secret=JBSWY3DPEHPK3PXP"}
+
+	// Mock expectations
+	mockVerificationService.On("IssueToken", 4).Return(token, nil)
+	mockVerificationService.On("IssueToken", 9).Return(nil, fmt.Errorf("client 9: %w", services.ErrNotFound))
+	mockVerificationService.On("GetToken", 4).Return(&models.ClientVerificationToken{ClientID: 4, Digits: 6, PeriodSeconds: 30}, nil)
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/clients/4/verification-token", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
 
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.True(t, response["success"].(bool))
-	assert.Equal(t, "Visit started successfully", response["message"])
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", data["secret"])
 
-	// Verify mock expectations
+	req, _ = http.NewRequest("GET", "/api/v1/clients/4/verification-token", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	req, _ = http.NewRequest("POST", "/api/v1/clients/9/verification-token", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockVerificationService.AssertExpectations(t)
+}
+
+func TestHandler_StartVisit_VerificationCode(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("StartVisit", 1, mock.MatchedBy(func(req *models.VisitStartRequest) bool {
+		return req.VerificationCode == "123456" && req.Latitude == 0
+	})).Return(fmt.Errorf("%w: verification code is invalid or expired", services.ErrVerificationFailed))
+
+	// Create request
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/start", bytes.NewBufferString(`{"verification_code": "123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "could not be verified")
 	mockScheduleService.AssertExpectations(t)
 }
 
-func TestHandler_UpdateTaskStatus(t *testing.T) {
+func TestHandler_TelephonyVoice(t *testing.T) {
 	// Setup
-	handler, _, _, mockTaskService, _ := setupTestHandler()
+	mockTelephonyService := new(MockTelephonyService)
+	handler := newTestHandler(Services{Telephony: mockTelephonyService})
 	router := handler.SetupRoutes()
 
-	// Test data
-	requestBody := models.TaskUpdateRequest{
-		Status: "completed",
-	}
+	// Mock expectations
+	mockTelephonyService.On("HandleCall", &models.TelephonyCall{CallID: "CA1", From: "+15550100", Digits: "12", State: "caregiver"}).
+		Return(&models.TelephonyPrompt{Message: "Enter your PIN, then press pound.", Gather: true, State: "pin:12"}, nil)
+	mockTelephonyService.On("HandleCall", &models.TelephonyCall{CallID: "CA1", From: "+15550100", Digits: "4321", State: "pin:12"}).
+		Return(nil, fmt.Errorf("database is locked"))
+
+	// Create request: IVR providers post forms
+	req, _ := http.NewRequest("POST", "/api/v1/telephony/voice?state=caregiver", bytes.NewBufferString("CallSid=CA1&From=%2B15550100&Digits=12"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<Gather input="dtmf" finishOnKey="#" action="/api/v1/telephony/voice?state=pin%3A12" method="POST"><Say>Enter your PIN, then press pound.</Say></Gather>`)
+	assert.NotContains(t, w.Body.String(), "<Hangup>")
+
+	// A failed step still answers the call
+	req, _ = http.NewRequest("POST", "/api/v1/telephony/voice?state=pin%3A12", bytes.NewBufferString("CallSid=CA1&From=%2B15550100&Digits=4321"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<Say>"+telephonyUnavailable+"</Say><Hangup></Hangup>")
+	mockTelephonyService.AssertExpectations(t)
+}
+
+func TestHandler_SetCaregiverTelephonyPIN(t *testing.T) {
+	// Setup
+	mockTelephonyService := new(MockTelephonyService)
+	handler := newTestHandler(Services{Telephony: mockTelephonyService})
+	router := handler.SetupRoutes()
 
 	// Mock expectations
-	expectedTask := &models.Task{
-		ID:          1,
-		ScheduleID:  1,
-		Title:       "Give medication",
-		Status:      "completed",
-		Description: "Administer morning medications",
-	}
-	mockTaskService.On("UpdateTaskStatus", 1, &requestBody).Return(expectedTask, nil)
+	mockTelephonyService.On("SetPIN", 2, &models.TelephonyPINRequest{PIN: "4321"}).
+		Return(&models.CaregiverTelephonyPIN{CaregiverID: 2, PINHash: "hash", Salt: "salt"}, nil)
+	mockTelephonyService.On("SetPIN", 2, &models.TelephonyPINRequest{PIN: "12"}).
+		Return(nil, fmt.Errorf("%w: PIN must be 4 to 8 digits", services.ErrValidation))
 
 	// Create request
-	jsonBody, _ := json.Marshal(requestBody)
-	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1", bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequest("PUT", "/api/v1/caregivers/2/telephony-pin", bytes.NewBufferString(`{"pin": "4321"}`))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
 	// Execute
 	router.ServeHTTP(w, req)
 
-	// Assert
+	// Assert: the hash is never returned
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hash")
 
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
-	assert.True(t, response["success"].(bool))
-	assert.Equal(t, expectedTask.ID, int(response["data"].(map[string]interface{})["id"].(float64)))
+	req, _ = http.NewRequest("PUT", "/api/v1/caregivers/2/telephony-pin", bytes.NewBufferString(`{"pin": "12"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockTelephonyService.AssertExpectations(t)
+}
 
-	// Verify mock expectations
-	mockTaskService.AssertExpectations(t)
+func TestHandler_RequestVisitCorrection(t *testing.T) {
+	// Setup
+	mockCorrectionService := new(MockVisitCorrectionService)
+	handler := newTestHandler(Services{Correction: mockCorrectionService})
+	router := handler.SetupRoutes()
+
+	leftAt := time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC)
+	correction := &models.VisitCorrection{ID: 4, ScheduleID: 1, CaregiverID: 1, VisitID: 7, ProposedEndTime: &leftAt,
+		ReasonCode: models.CorrectionReasonForgotClockOut, Status: models.CorrectionStatusPending, Version: 1}
+
+	// Mock expectations
+	mockCorrectionService.On("RequestCorrection", 1, mock.MatchedBy(func(req *models.VisitCorrectionRequest) bool {
+		return req.EndTime.Equal(leftAt) && req.ReasonCode == models.CorrectionReasonForgotClockOut
+	})).Return(correction, nil).Once()
+	mockCorrectionService.On("RequestCorrection", 1, mock.Anything).
+		Return(nil, fmt.Errorf("%w: a correction is already pending", services.ErrInvalidTransition)).Once()
+
+	// Create request
+	body := `{"end_time": "2025-01-06T11:00:00Z", "reason_code": "forgot_clock_out"}`
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/corrections", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	req, _ = http.NewRequest("POST", "/api/v1/schedules/1/corrections", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockCorrectionService.AssertExpectations(t)
 }
 
-func TestHandler_GetTodaySchedules(t *testing.T) {
+func TestHandler_ApproveVisitCorrection(t *testing.T) {
+	// Setup
+	mockCorrectionService := new(MockVisitCorrectionService)
+	handler := newTestHandler(Services{Correction: mockCorrectionService})
+	router := handler.SetupRoutes()
+
+	reviewedAt := time.Now()
+	correction := &models.VisitCorrection{ID: 4, ScheduleID: 1, CaregiverID: 1, Status: models.CorrectionStatusApproved,
+		ReviewNotes: "Confirmed with the client", ReviewedAt: &reviewedAt, Version: 2}
+
+	// Mock expectations
+	mockCorrectionService.On("ApproveCorrection", 4, mock.MatchedBy(func(req *models.VisitCorrectionReviewRequest) bool {
+		return req.Notes == "Confirmed with the client" && *req.ExpectedVersion == 1
+	})).Return(correction, nil).Once()
+	mockCorrectionService.On("ApproveCorrection", 4, mock.Anything).
+		Return(nil, fmt.Errorf("%w: visit correction 4 is at version 2", services.ErrVersionConflict)).Once()
+
+	// Create request
+	body := `{"notes": "Confirmed with the client"}`
+	req, _ := http.NewRequest("POST", "/api/v1/visit-corrections/4/approve", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	req, _ = http.NewRequest("POST", "/api/v1/visit-corrections/4/approve", nil)
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	mockCorrectionService.AssertExpectations(t)
+}
+
+func TestHandler_ExportEVV_CSV(t *testing.T) {
 	// Setup
 	handler, mockScheduleService, _, _, _ := setupTestHandler()
 	router := handler.SetupRoutes()
 
-	// Test data
-	expectedSchedules := []models.Schedule{
-		{
-			ID:       1,
-			ClientID: 1,
-			Status:   "scheduled",
-			Client: &models.Client{
-				ID:   1,
-				Name: "John Doe",
-			},
-		},
-		{
-			ID:       2,
-			ClientID: 2,
-			Status:   "completed",
-			Client: &models.Client{
-				ID:   2,
-				Name: "Jane Smith",
-			},
-		},
-	}
+	scheduledStart := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	scheduledEnd := time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC)
+	recordedIn := time.Date(2025, 1, 6, 9, 5, 0, 0, time.UTC)
+	amendedAt := time.Date(2025, 1, 7, 8, 0, 0, 0, time.UTC)
+	export := &models.EVVExport{From: "2025-01-06", To: "2025-01-06", Visits: []models.EVVVisit{{
+		VisitID: 7, ScheduleID: 1, CaregiverID: 1, ClientID: 3, ServiceName: "Personal Care Service", Date: "2025-01-06",
+		ScheduledStartTime: scheduledStart, ScheduledEndTime: scheduledEnd, RecordedStartTime: &recordedIn,
+		StartTime: &scheduledStart, EndTime: &scheduledEnd, StartVerificationMethod: models.VerificationMethodGPS,
+		Amended: true, AmendmentReason: models.CorrectionReasonForgotClockOut, AmendedAt: &amendedAt,
+	}}}
 
 	// Mock expectations
-	mockScheduleService.On("GetTodaySchedules", 1).Return(expectedSchedules, nil)
+	mockScheduleService.On("ExportEVV", "2025-01-06", "2025-01-06").Return(export, nil)
 
 	// Create request
-	req, _ := http.NewRequest("GET", "/api/v1/schedules/today?caregiver_id=1", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/evv/export?from=2025-01-06&to=2025-01-06&format=csv", nil)
+	w := httptest.NewRecorder()
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert: the recorded clock-out stays empty
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Equal(t, "visit_id,schedule_id,caregiver_id,client_id,service_name,date,scheduled_start_time,scheduled_end_time,"+
+		"recorded_start_time,recorded_end_time,start_time,end_time,start_verification_method,end_verification_method,"+
+		"amended,amendment_reason,amended_at\n"+
+		"7,1,1,3,Personal Care Service,2025-01-06,2025-01-06T09:00:00Z,2025-01-06T11:00:00Z,2025-01-06T09:05:00Z,,"+
+		"2025-01-06T09:00:00Z,2025-01-06T11:00:00Z,gps,,true,forgot_clock_out,2025-01-07T08:00:00Z\n", w.Body.String())
+	mockScheduleService.AssertExpectations(t)
+}
+
+func TestHandler_ReverseGeocode(t *testing.T) {
+	// Setup
+	mockGeocoderService := new(MockGeocoderService)
+	handler := newTestHandler(Services{Geocoder: mockGeocoderService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockGeocoderService.On("ReverseGeocode", 39.7752, -89.6721).Return(&models.ReverseGeocode{Latitude: 39.7752, Longitude: -89.6721,
+		Address: "742 Evergreen Terrace, Springfield, IL 62704", DistanceMeters: 14}, nil)
+	mockGeocoderService.On("ReverseGeocode", 0.0, 0.0).
+		Return(nil, fmt.Errorf("no place within 5000 m of 0.000000,0.000000: %w", services.ErrNotFound))
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/api/v1/geocoding/reverse?latitude=39.7752&longitude=-89.6721", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -362,15 +2879,126 @@ func TestHandler_GetTodaySchedules(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "742 Evergreen Terrace")
+
+	req, _ = http.NewRequest("GET", "/api/v1/geocoding/reverse?latitude=0&longitude=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/geocoding/reverse?latitude=north", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockGeocoderService.AssertExpectations(t)
+}
 
+func TestHandler_CreateTaskTemplate(t *testing.T) {
+	// Setup
+	mockCarePlanService := new(MockCarePlanService)
+	handler := newTestHandler(Services{CarePlan: mockCarePlanService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockCarePlanService.On("CreateTaskTemplate", &models.TaskTemplateRequest{Title: "Wound care"}).
+		Return(&models.TaskTemplate{ID: 8, Title: "Wound care", IsActive: true}, nil)
+	mockCarePlanService.On("CreateTaskTemplate", &models.TaskTemplateRequest{Title: "Bathing"}).
+		Return(nil, fmt.Errorf("%w: task template \"Bathing\" already exists", services.ErrValidation))
+
+	// Execute
+	req, _ := http.NewRequest("POST", "/api/v1/task-templates", bytes.NewBufferString(`{"title":"Wound care"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// The title is taken
+	req, _ = http.NewRequest("POST", "/api/v1/task-templates", bytes.NewBufferString(`{"title":"Bathing"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockCarePlanService.AssertExpectations(t)
+}
+
+func TestHandler_ReviseClientCarePlan(t *testing.T) {
+	// Setup
+	mockCarePlanService := new(MockCarePlanService)
+	handler := newTestHandler(Services{CarePlan: mockCarePlanService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockCarePlanService.On("ReviseCarePlan", 101, mock.MatchedBy(func(req *models.CarePlanRequest) bool {
+		return req.ExpectedVersion != nil && *req.ExpectedVersion == 2 && len(req.Items) == 1
+	})).Return(&models.CarePlan{ID: 4, ClientID: 101, Version: 3}, nil)
+	mockCarePlanService.On("ReviseCarePlan", 101, mock.MatchedBy(func(req *models.CarePlanRequest) bool {
+		return req.ExpectedVersion != nil && *req.ExpectedVersion == 1
+	})).Return(nil, fmt.Errorf("care plan of client 101 is at version 2: %w", services.ErrVersionConflict))
+
+	body := `{"items":[{"template_id":1,"frequency":"every_visit"}]}`
+
+	// Execute
+	req, _ := http.NewRequest("PUT", "/api/v1/clients/101/care-plan", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	// The plan was revised since version 1 was read
+	req, _ = http.NewRequest("PUT", "/api/v1/clients/101/care-plan", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	mockCarePlanService.AssertExpectations(t)
+}
+
+func TestHandler_GetClientCarePlan_NotFound(t *testing.T) {
+	// Setup
+	mockCarePlanService := new(MockCarePlanService)
+	handler := newTestHandler(Services{CarePlan: mockCarePlanService})
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockCarePlanService.On("GetCarePlan", 102).Return(nil, fmt.Errorf("care plan of client 102: %w", services.ErrNotFound))
+
+	// Execute
+	req, _ := http.NewRequest("GET", "/api/v1/clients/102/care-plan", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockCarePlanService.AssertExpectations(t)
+}
+
+func TestHandler_EndVisit_RequiredTasksOpen(t *testing.T) {
+	// Setup
+	handler, mockScheduleService, _, _, _ := setupTestHandler()
+	router := handler.SetupRoutes()
+
+	// Mock expectations
+	mockScheduleService.On("EndVisit", 1, mock.AnythingOfType("*models.VisitEndRequest")).
+		Return(&services.IncompleteTasksError{TaskIDs: []int{3, 6}})
+
+	// Execute
+	req, _ := http.NewRequest("POST", "/api/v1/schedules/1/end", bytes.NewBufferString(`{"end_latitude":40.7128,"end_longitude":-74.006}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.True(t, response["success"].(bool))
-
-	data := response["data"].([]interface{})
-	assert.Len(t, data, 2)
-
-	// Verify mock expectations
+	assert.Equal(t, []interface{}{float64(3), float64(6)}, response["task_ids"])
 	mockScheduleService.AssertExpectations(t)
 }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxIdempotencyKeyLength limits the size of client supplied keys
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with an idempotent response
+// and sent again on replay; ETag keeps the If-Match flow working after a retry
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// responseRecorder captures the response body while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware makes mutating requests carrying an Idempotency-Key
+// header safe to retry: the first response is stored and replayed for retries
+func (h *Handler) idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid Idempotency-Key header",
+				fmt.Errorf("idempotency key must be at most %d characters", maxIdempotencyKeyLength))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Failed to read request body", err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The query string is part of the request, so it is part of the fingerprint
+		record, replay, err := h.idempotencyService.Begin(key, c.Request.Method, c.Request.URL.RequestURI(), body)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyReused):
+				h.errorResponse(c, http.StatusUnprocessableEntity, "Idempotency-Key was used for a different request", err)
+			case errors.Is(err, services.ErrIdempotencyInProgress):
+				h.errorResponse(c, http.StatusConflict, "Request with this Idempotency-Key is still being processed", err)
+			default:
+				h.errorResponse(c, http.StatusInternalServerError, "Failed to process Idempotency-Key", err)
+			}
+			c.Abort()
+			return
+		}
+
+		if replay {
+			for name, value := range record.ResponseHeaders {
+				c.Header(name, value)
+			}
+			contentType := record.ResponseHeaders["Content-Type"]
+			if contentType == "" {
+				contentType = "application/json; charset=utf-8"
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(*record.StatusCode, contentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// The recovery middleware turns a panic into a 500 without returning here;
+		// release the key first so retries are not locked out until it expires
+		defer func() {
+			if r := recover(); r != nil {
+				_ = h.idempotencyService.Release(key)
+				panic(r)
+			}
+		}()
+		c.Next()
+
+		// Server errors are not stored so the client can retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			_ = h.idempotencyService.Release(key)
+			return
+		}
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+
+		if err := h.idempotencyService.Complete(key, status, headers, recorder.body.Bytes()); err != nil {
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"idempotency_key": key,
+				"path":            c.Request.URL.Path,
+			}).Warn("Failed to store idempotent response")
+		}
+	}
+}
+
+// isMutatingMethod reports whether the HTTP method changes server state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
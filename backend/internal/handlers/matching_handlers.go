@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getScheduleCandidates ranks the caregivers who could take a schedule
+// @Summary Get schedule candidates
+// @Description Rank the caregivers who could take a scheduled visit, best first, with the factors behind each score: continuity of care with the client, travel from their previous visit that day, the client's language and gender preferences and how much of their week is booked. Caregivers who are unavailable, lack a required certification or were blocked by the client are not eligible. Caregivers already on the schedule's team are not listed
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param include_ineligible query boolean false "Also list caregivers who cannot take the schedule, with the reasons why"
+// @Param limit query int false "Maximum number of candidates"
+// @Success 200 {object} map[string]interface{} "success response with ranked candidates"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule is no longer scheduled"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/candidates [get]
+func (h *Handler) getScheduleCandidates(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var opts models.CandidateOptions
+	if includeStr := c.Query("include_ineligible"); includeStr != "" {
+		if opts.IncludeIneligible, err = strconv.ParseBool(includeStr); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid include_ineligible parameter", err)
+			return
+		}
+	}
+	if opts.Limit, err = h.parseIntQuery(c, "limit"); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid limit parameter", err)
+		return
+	}
+
+	candidates, err := h.matchingService.GetCandidates(id, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid parameters", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Schedule cannot be matched", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to get candidates", err)
+		}
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"candidates": candidates,
+		"count":      len(candidates),
+	})
+}
+
+// getCaregiverProfile retrieves a caregiver's profile
+// @Summary Get caregiver profile
+// @Description Get the gender and languages of a caregiver used to match them to client preferences
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Success 200 {object} map[string]interface{} "success response with profile"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/profile [get]
+func (h *Handler) getCaregiverProfile(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	profile, err := h.matchingService.GetCaregiverProfile(id)
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get profile", err)
+		return
+	}
+
+	h.successResponse(c, profile)
+}
+
+// setCaregiverProfile replaces a caregiver's profile
+// @Summary Set caregiver profile
+// @Description Replace the gender and languages (ISO 639 codes, e.g. en) of a caregiver
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param profile body models.CaregiverProfileRequest true "Caregiver profile"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with profile"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/profile [put]
+func (h *Handler) setCaregiverProfile(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	var req models.CaregiverProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	profile, err := h.matchingService.SetCaregiverProfile(id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid profile", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to set profile", err)
+		return
+	}
+
+	h.successResponse(c, profile)
+}
+
+// getClientPreferences retrieves a client's caregiver preferences
+// @Summary Get client preferences
+// @Description Get the caregiver gender and languages a client prefers and the caregivers they asked not to be sent
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Success 200 {object} map[string]interface{} "success response with preferences"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/preferences [get]
+func (h *Handler) getClientPreferences(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	preferences, err := h.matchingService.GetClientPreferences(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Client not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get preferences", err)
+		return
+	}
+
+	h.successResponse(c, preferences)
+}
+
+// setClientPreferences replaces a client's caregiver preferences
+// @Summary Set client preferences
+// @Description Replace the caregiver gender and languages (ISO 639 codes) a client prefers and the caregivers they asked not to be sent. Blocked caregivers are never offered as candidates for the client's schedules
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Param preferences body models.ClientPreferencesRequest true "Client preferences"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with preferences"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/preferences [put]
+func (h *Handler) setClientPreferences(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	var req models.ClientPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	preferences, err := h.matchingService.SetClientPreferences(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Client not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid preferences", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to set preferences", err)
+		}
+		return
+	}
+
+	h.successResponse(c, preferences)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// payrollCSVHeader is the header row of the payroll CSV export
+var payrollCSVHeader = []string{"caregiver_id", "date", "total_hours", "sleep_hours", "break_hours", "worked_hours", "mileage_km", "amended"}
+
+// getCaregiverTravel retrieves a caregiver's travel between visits
+// @Summary Get caregiver travel
+// @Description Get a caregiver's travel segments between visits over a date range. Each segment runs from one visit's clock-out to the next visit's clock-in on the same day; mileage_km is the measured distance or the coordinator's adjustment of it
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param from query string true "First day (YYYY-MM-DD)"
+// @Param to query string true "Last day, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "success response with travel log"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/travel [get]
+func (h *Handler) getCaregiverTravel(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		h.errorResponse(c, http.StatusBadRequest, "from and to are required", fmt.Errorf("missing from or to"))
+		return
+	}
+
+	log, err := h.mileageService.GetTravelLog(id, from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid travel range", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get travel", err)
+		return
+	}
+
+	h.successResponse(c, log)
+}
+
+// recalculateCaregiverTravel measures a caregiver's travel on a day again
+// @Summary Recalculate caregiver travel
+// @Description Measure a caregiver's travel on a day again from their clock-in and clock-out locations, e.g. after a visit was corrected. Travel is measured automatically at each clock-in; adjustments of segments measured again are kept
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param date query string true "Day (YYYY-MM-DD in the client's timezone)"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with travel log for the day"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/travel/recalculate [post]
+func (h *Handler) recalculateCaregiverTravel(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	date := c.Query("date")
+	if date == "" {
+		h.errorResponse(c, http.StatusBadRequest, "date is required", fmt.Errorf("missing date"))
+		return
+	}
+
+	log, err := h.mileageService.RecalculateDay(id, date)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid date", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to recalculate travel", err)
+		return
+	}
+
+	h.successResponse(c, log)
+}
+
+// adjustTravelSegment overrides the distance of a travel segment
+// @Summary Adjust travel segment
+// @Description Override the measured distance of a travel segment with the reason for it, e.g. a detour or a road closure. Send a null adjusted_km to restore the measured distance
+// @Tags travel
+// @Accept json
+// @Produce json
+// @Param id path int true "Travel segment ID"
+// @Param request body models.TravelAdjustmentRequest true "Adjusted distance and reason"
+// @Param If-Match header string false "ETag of the travel segment the change is based on"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with travel segment"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "travel segment not found"
+// @Failure 412 {object} map[string]interface{} "travel segment was modified by another request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/travel-segments/{id} [put]
+func (h *Handler) adjustTravelSegment(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid travel segment ID", err)
+		return
+	}
+
+	var req models.TravelAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	segment, err := h.mileageService.AdjustTravelSegment(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Travel segment not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid adjustment", err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Travel segment was modified by another request", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to adjust travel segment", err)
+		}
+		return
+	}
+
+	h.setETag(c, segment.Version)
+	h.successResponse(c, segment)
+}
+
+// exportPayroll exports the hours and mileage of every caregiver
+// @Summary Export payroll
+// @Description Export the hours and mileage of every caregiver who worked over a date range, one row per caregiver and day. Hours are split at midnight in the client's timezone as on timesheets. Rows counted from visit times corrected after approval are marked amended; the EVV export lists the recorded and corrected times. Use format=csv for a CSV file
+// @Tags payroll
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param from query string true "First day (YYYY-MM-DD)"
+// @Param to query string true "Last day, inclusive (YYYY-MM-DD)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{} "success response with payroll export"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/payroll/export [get]
+func (h *Handler) exportPayroll(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		h.errorResponse(c, http.StatusBadRequest, "from and to are required", fmt.Errorf("missing from or to"))
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		h.errorResponse(c, http.StatusBadRequest, "format must be 'json' or 'csv'", fmt.Errorf("unknown format %q", format))
+		return
+	}
+
+	export, err := h.scheduleService.ExportPayroll(from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid payroll range", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to export payroll", err)
+		return
+	}
+
+	if format == "json" {
+		h.successResponse(c, export)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=payroll_%s_%s.csv", from, to))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(payrollCSVHeader)
+	for _, row := range export.Rows {
+		_ = w.Write([]string{
+			strconv.Itoa(row.CaregiverID),
+			row.Date,
+			strconv.FormatFloat(row.TotalHours, 'f', 2, 64),
+			strconv.FormatFloat(row.SleepHours, 'f', 2, 64),
+			strconv.FormatFloat(row.BreakHours, 'f', 2, 64),
+			strconv.FormatFloat(row.WorkedHours, 'f', 2, 64),
+			strconv.FormatFloat(row.MileageKm, 'f', 2, 64),
+			strconv.FormatBool(row.Amended),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		h.logger.WithError(err).Error("Failed to write payroll CSV")
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getCaregiverRoute plans the order of a caregiver's visits for a day
+// @Summary Get caregiver route
+// @Description Plan the order a caregiver drives their scheduled visits on a day to minimise travel. Visits with flex_minutes may start that much before or after their booked start and are reordered; the others keep their booked start. Travel times are estimated between consecutive visits, and the day is flagged infeasible when a visit cannot be reached in time
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param date query string false "Day (YYYY-MM-DD in the agency timezone), today when omitted"
+// @Success 200 {object} map[string]interface{} "success response with route plan"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/route [get]
+func (h *Handler) getCaregiverRoute(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	plan, err := h.routeService.PlanRoute(id, c.Query("date"))
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid date", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to plan route", err)
+		return
+	}
+
+	h.successResponse(c, plan)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getScheduleCaregivers lists the caregivers assigned to a schedule
+// @Summary Get schedule caregivers
+// @Description Get the team of caregivers assigned to a schedule, lead first
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{} "success response with caregivers"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/caregivers [get]
+func (h *Handler) getScheduleCaregivers(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	caregivers, err := h.scheduleService.GetScheduleCaregivers(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get caregivers", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"caregivers": caregivers,
+		"count":      len(caregivers),
+	})
+}
+
+// addScheduleCaregiver adds a caregiver to a schedule's team
+// @Summary Add a schedule caregiver
+// @Description Add a caregiver to a schedule as a team member, e.g. for a two-person transfer. Each team member clocks in and out independently
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param caregiver body models.ScheduleCaregiverRequest true "Caregiver to add"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with added caregiver"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule has already finished or caregiver is not available"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/caregivers [post]
+func (h *Handler) addScheduleCaregiver(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.ScheduleCaregiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	caregiver, err := h.scheduleService.AddScheduleCaregiver(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Team cannot be changed", err)
+		case errors.Is(err, services.ErrCaregiverUnavailable):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not available", err)
+		case errors.Is(err, services.ErrCaregiverUnqualified):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not qualified", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to add caregiver", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Caregiver added successfully",
+		"data": gin.H{
+			"caregiver": caregiver,
+		},
+	})
+}
+
+// removeScheduleCaregiver removes a caregiver from a schedule's team
+// @Summary Remove a schedule caregiver
+// @Description Remove a team member from a schedule and unassign their tasks. The lead caregiver and caregivers who already clocked in cannot be removed
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param caregiverId path int true "Caregiver ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "caregiver not on schedule"
+// @Failure 409 {object} map[string]interface{} "caregiver has already clocked in"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/caregivers/{caregiverId} [delete]
+func (h *Handler) removeScheduleCaregiver(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	caregiverID, err := h.parseIntParam(c, "caregiverId")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	if err := h.scheduleService.RemoveScheduleCaregiver(id, caregiverID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Caregiver not found on schedule", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Caregiver cannot be removed", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Caregiver cannot be removed", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to remove caregiver", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Caregiver removed successfully",
+	})
+}
+
+// reassignSchedule hands a schedule to another lead caregiver
+// @Summary Reassign a schedule
+// @Description Hand a schedule that has not started to another lead caregiver, who must be available for it. The previous lead leaves the team and their tasks are unassigned
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param If-Match header string false "ETag of the schedule version being updated"
+// @Param request body models.ScheduleReassignRequest true "New lead caregiver"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated schedule"
+// @Header 200 {string} ETag "New version of the schedule"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule has started or caregiver is not available"
+// @Failure 412 {object} map[string]interface{} "schedule was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/caregiver [put]
+func (h *Handler) reassignSchedule(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.ScheduleReassignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	schedule, err := h.scheduleService.ReassignSchedule(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Schedule cannot be reassigned", err)
+		case errors.Is(err, services.ErrCaregiverUnavailable):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not available", err)
+		case errors.Is(err, services.ErrCaregiverUnqualified):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not qualified", err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Schedule was modified by another request", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to reassign schedule", err)
+		}
+		return
+	}
+
+	h.setETag(c, schedule.Version)
+	h.successResponse(c, schedule)
+}
@@ -2,7 +2,12 @@ package handlers
 
 import (
 	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,16 +15,25 @@ import (
 
 // getSchedules retrieves all schedules with optional filtering
 // @Summary Get all schedules
-// @Description Get all schedules with optional filtering by caregiver_id, date, status
+// @Description Get a page of schedules with optional filtering by caregiver, client, date range, statuses, service, visit location status and incomplete tasks
 // @Tags schedules
 // @Accept json
 // @Produce json
 // @Param caregiver_id query int false "Filter by caregiver ID"
-// @Param date query string false "Filter by date (YYYY-MM-DD format)"
-// @Param status query string false "Filter by status (scheduled, in_progress, completed, missed)"
-// @Param limit query int false "Limit number of results"
-// @Param offset query int false "Offset for pagination"
-// @Success 200 {object} map[string]interface{} "success response with schedules data"
+// @Param client_id query int false "Filter by client ID"
+// @Param date query string false "Schedules overlapping this day in tz, including overnight shifts (YYYY-MM-DD format)"
+// @Param from query string false "Schedules starting at or after this time (RFC3339, or YYYY-MM-DD for the start of the day)"
+// @Param to query string false "Schedules starting before this time (RFC3339, or YYYY-MM-DD to include the whole day)"
+// @Param tz query string false "IANA timezone for date and for from/to values without an offset, e.g. America/New_York; defaults to the agency timezone"
+// @Param status query []string false "Filter by one or more statuses (scheduled, in_progress, completed, missed, cancelled)" collectionFormat(csv)
+// @Param service_name query string false "Filter by service name (case-insensitive)"
+// @Param location_status query string false "Filter by visit location status (pending, confirmed)"
+// @Param has_incomplete_tasks query boolean false "Filter by whether the schedule has tasks that are not completed"
+// @Param cursor query string false "Cursor from the next_cursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param sort query string false "Sort field (id, start_time, end_time, status, service_name, created_at), prefix with - for descending" default(start_time)
+// @Param include_total query boolean false "Include the total number of matching schedules"
+// @Success 200 {object} map[string]interface{} "success response with schedules data and pagination"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/schedules [get]
@@ -34,41 +48,96 @@ func (h *Handler) getSchedules(c *gin.Context) {
 		filter.CaregiverID = caregiverID
 	}
 
+	if clientID, err := h.parseIntQuery(c, "client_id"); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client_id", err)
+		return
+	} else {
+		filter.ClientID = clientID
+	}
+
+	loc := h.location
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid tz", err)
+			return
+		}
+	}
+
+	// date selects every schedule that overlaps that day in tz, so overnight
+	// shifts show up on both days
 	if dateStr := c.Query("date"); dateStr != "" {
-		if date, err := time.Parse("2006-01-02", dateStr); err != nil {
+		if date, err := time.ParseInLocation("2006-01-02", dateStr, loc); err != nil {
 			h.errorResponse(c, http.StatusBadRequest, "Invalid date format, use YYYY-MM-DD", err)
 			return
 		} else {
-			filter.Date = &date
+			next := date.AddDate(0, 0, 1)
+			filter.ActiveFrom, filter.ActiveTo = &date, &next
 		}
 	}
 
-	if status := c.Query("status"); status != "" {
-		filter.Status = &status
+	if from, err := parseTimeBound(c.Query("from"), loc, false); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid from, use RFC3339 or YYYY-MM-DD", err)
+		return
+	} else if from != nil {
+		filter.From = from
 	}
 
-	if limit, err := h.parseIntQuery(c, "limit"); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid limit", err)
+	if to, err := parseTimeBound(c.Query("to"), loc, true); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid to, use RFC3339 or YYYY-MM-DD", err)
 		return
-	} else {
-		filter.Limit = limit
+	} else if to != nil {
+		filter.To = to
+	}
+
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	if serviceName := c.Query("service_name"); serviceName != "" {
+		filter.ServiceName = &serviceName
+	}
+
+	if locationStatus := c.Query("location_status"); locationStatus != "" {
+		filter.LocationStatus = &locationStatus
 	}
 
-	if offset, err := h.parseIntQuery(c, "offset"); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid offset", err)
+	if hasIncompleteStr := c.Query("has_incomplete_tasks"); hasIncompleteStr != "" {
+		if hasIncomplete, err := strconv.ParseBool(hasIncompleteStr); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid has_incomplete_tasks parameter", err)
+			return
+		} else {
+			filter.HasIncompleteTasks = &hasIncomplete
+		}
+	}
+
+	if opts, err := h.parseListOptions(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid pagination parameters", err)
 		return
 	} else {
-		filter.Offset = offset
+		filter.ListOptions = opts
 	}
 
 	// Get schedules
-	schedules, err := h.scheduleService.GetAllSchedules(filter)
+	schedules, page, err := h.scheduleService.GetAllSchedules(filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidFilter) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid filter", err)
+			return
+		}
+		if isListQueryError(err) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid pagination parameters", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to get schedules", err)
 		return
 	}
 
-	h.successResponse(c, schedules)
+	h.pageResponse(c, schedules, page)
 }
 
 // getTodaySchedules retrieves today's schedules for a caregiver
@@ -133,6 +202,7 @@ func (h *Handler) getScheduleStats(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Schedule ID"
 // @Success 200 {object} map[string]interface{} "success response with schedule details"
+// @Header 200 {string} ETag "Current version of the schedule"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "schedule not found"
 // @Failure 500 {object} map[string]interface{} "internal server error"
@@ -157,20 +227,67 @@ func (h *Handler) getScheduleByID(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, schedule.Version)
 	h.successResponse(c, schedule)
 }
 
+// createSchedule books a caregiver for a client visit
+// @Summary Create a schedule
+// @Description Book a caregiver for a client visit. The caregiver must be available: within their availability windows, not on approved time off, not booked elsewhere and within their weekly hour limit
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param schedule body models.ScheduleCreateRequest true "Schedule to create"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with created schedule"
+// @Header 201 {string} ETag "Version of the created schedule"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 409 {object} map[string]interface{} "caregiver is not available"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules [post]
+func (h *Handler) createSchedule(c *gin.Context) {
+	var req models.ScheduleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(&req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid schedule", err)
+		case errors.Is(err, services.ErrCaregiverUnavailable):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not available", err)
+		case errors.Is(err, services.ErrCaregiverUnqualified):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not qualified", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to create schedule", err)
+		}
+		return
+	}
+
+	h.setETag(c, schedule.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Schedule created successfully",
+		"data":    schedule,
+	})
+}
+
 // startVisit starts a visit for a schedule
 // @Summary Start a visit
-// @Description Start a visit for a specific schedule with geolocation
+// @Description Start a visit for a specific schedule with geolocation, the verification_code on the client's QR card or NFC tag, or both. On a team visit each caregiver clocks in with their caregiver_id; the schedule starts with the first one. The location is scored for the risk of spoofing from the device's accuracy and mock_location flag, and high-risk visits are queued for review
 // @Tags schedules
 // @Accept json
 // @Produce json
 // @Param id path int true "Schedule ID"
-// @Param request body models.VisitStartRequest true "Visit start request with geolocation"
+// @Param request body models.VisitStartRequest true "Visit start request with geolocation and/or verification code"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule status does not allow starting the visit"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/schedules/{id}/start [post]
 func (h *Handler) startVisit(c *gin.Context) {
@@ -193,6 +310,18 @@ func (h *Handler) startVisit(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Caregiver is not assigned to this schedule", err)
+			return
+		}
+		if errors.Is(err, services.ErrVerificationFailed) {
+			h.errorResponse(c, http.StatusBadRequest, "Presence at the client could not be verified", err)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransition) || errors.Is(err, services.ErrVersionConflict) {
+			h.errorResponse(c, http.StatusConflict, "Visit cannot be started", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to start visit", err)
 		return
 	}
@@ -205,15 +334,17 @@ func (h *Handler) startVisit(c *gin.Context) {
 
 // endVisit ends a visit for a schedule
 // @Summary End a visit
-// @Description End a visit for a specific schedule with geolocation
+// @Description End a visit for a specific schedule with geolocation, the verification_code on the client's QR card or NFC tag, or both. On a team visit each caregiver clocks out with their caregiver_id; the schedule completes when the last one does. The location is scored for the risk of spoofing as at clock-in. Clock-out is rejected with the task_ids of the caregiver's required tasks, and of the unassigned ones when they are the last to clock out, that are neither completed nor not completed with a reason code of the schedule's service type
 // @Tags schedules
 // @Accept json
 // @Produce json
 // @Param id path int true "Schedule ID"
-// @Param request body models.VisitEndRequest true "Visit end request with geolocation and optional notes"
+// @Param request body models.VisitEndRequest true "Visit end request with geolocation and/or verification code and optional notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule status does not allow ending the visit, or required tasks are not done"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/schedules/{id}/end [post]
 func (h *Handler) endVisit(c *gin.Context) {
@@ -236,6 +367,28 @@ func (h *Handler) endVisit(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Caregiver is not assigned to this schedule", err)
+			return
+		}
+		if errors.Is(err, services.ErrVerificationFailed) {
+			h.errorResponse(c, http.StatusBadRequest, "Presence at the client could not be verified", err)
+			return
+		}
+		var incomplete *services.IncompleteTasksError
+		if errors.As(err, &incomplete) {
+			h.logger.WithError(err).WithField("schedule_id", id).Warn("Clock-out with required tasks open")
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Required tasks are not done",
+				"details":  err.Error(),
+				"task_ids": incomplete.TaskIDs,
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransition) || errors.Is(err, services.ErrVersionConflict) {
+			h.errorResponse(c, http.StatusConflict, "Visit cannot be ended", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to end visit", err)
 		return
 	}
@@ -246,16 +399,19 @@ func (h *Handler) endVisit(c *gin.Context) {
 	})
 }
 
-// cancelVisit cancels an in-progress visit for a schedule
+// cancelVisit cancels a visit for a schedule
 // @Summary Cancel a visit
-// @Description Cancel an in-progress visit for a specific schedule
+// @Description Cancel a visit for a specific schedule. A scheduled visit is cancelled. On an in-progress visit only the caregiver's clock-in is undone; the schedule is reset to scheduled once no team member is clocked in, or completed when the others already clocked out
 // @Tags schedules
 // @Accept json
 // @Produce json
 // @Param id path int true "Schedule ID"
+// @Param visit body models.VisitCancelRequest false "Team member aborting the visit, defaults to the lead"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule status does not allow cancelling the visit"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/schedules/{id}/cancel [post]
 func (h *Handler) cancelVisit(c *gin.Context) {
@@ -266,17 +422,28 @@ func (h *Handler) cancelVisit(c *gin.Context) {
 		return
 	}
 
-	if err := h.scheduleService.CancelVisit(id); err != nil {
+	// The body is optional, the lead caregiver aborts without one
+	var req models.VisitCancelRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	if err := h.scheduleService.CancelVisit(id, &req); err != nil {
 		if err.Error() == "schedule not found" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Schedule not found",
 			})
 			return
 		}
-		if err.Error() == "visit not in progress" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Visit is not in progress",
-			})
+		if errors.Is(err, services.ErrInvalidTransition) || errors.Is(err, services.ErrVersionConflict) {
+			h.errorResponse(c, http.StatusConflict, "Visit cannot be cancelled", err)
+			return
+		}
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver", err)
 			return
 		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to cancel visit", err)
@@ -288,3 +455,121 @@ func (h *Handler) cancelVisit(c *gin.Context) {
 		"message": "Visit cancelled successfully",
 	})
 }
+
+// pauseVisit starts a break in an in-progress visit
+// @Summary Pause a visit
+// @Description Pause an in-progress visit for a break such as lunch or a pharmacy run. The break is recorded with its own timestamps and location and is not counted as worked time
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body models.VisitPauseRequest true "Location where the break starts and optional reason"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "visit is not in progress or already paused"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/pause [post]
+func (h *Handler) pauseVisit(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.VisitPauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.scheduleService.PauseVisit(id, &req); err != nil {
+		h.visitSegmentError(c, err, "Visit cannot be paused", "Failed to pause visit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Visit paused successfully",
+	})
+}
+
+// resumeVisit ends the break of a paused visit
+// @Summary Resume a visit
+// @Description Resume a paused visit, starting a new period of work at the given location
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body models.VisitResumeRequest true "Location where work resumes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "visit is not paused"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/resume [post]
+func (h *Handler) resumeVisit(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.VisitResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.scheduleService.ResumeVisit(id, &req); err != nil {
+		h.visitSegmentError(c, err, "Visit cannot be resumed", "Failed to resume visit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Visit resumed successfully",
+	})
+}
+
+// visitSegmentError maps pause and resume errors to responses
+func (h *Handler) visitSegmentError(c *gin.Context, err error, conflictMessage, failureMessage string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+	case errors.Is(err, services.ErrValidation):
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request", err)
+	case errors.Is(err, services.ErrInvalidTransition):
+		h.errorResponse(c, http.StatusConflict, conflictMessage, err)
+	default:
+		h.errorResponse(c, http.StatusInternalServerError, failureMessage, err)
+	}
+}
+
+// parseTimeBound parses a from/to query value. RFC3339 values carry their own
+// offset; other values are read in loc. A date-only upper bound includes the
+// whole day, so it resolves to midnight of the next day.
+func parseTimeBound(value string, loc *time.Location, upper bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, loc); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getScheduleSegments lists the sleep and break segments of a schedule
+// @Summary Get schedule segments
+// @Description Get the sleep and break segments planned within a schedule
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{} "success response with segments"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/segments [get]
+func (h *Handler) getScheduleSegments(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	segments, err := h.scheduleService.GetScheduleSegments(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get segments", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"segments": segments,
+		"count":    len(segments),
+	})
+}
+
+// addScheduleSegment adds a sleep or break segment to a schedule
+// @Summary Add a schedule segment
+// @Description Add a sleep or break segment within a long or overnight shift. Segment hours are excluded from worked hours
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param segment body models.ScheduleSegmentRequest true "Segment type and time range"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with created segment"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/segments [post]
+func (h *Handler) addScheduleSegment(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.ScheduleSegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	segment, err := h.scheduleService.AddScheduleSegment(id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+			return
+		}
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid segment", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to add segment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Segment added successfully",
+		"data": gin.H{
+			"segment": segment,
+		},
+	})
+}
+
+// deleteScheduleSegment removes a segment from a schedule
+// @Summary Delete a schedule segment
+// @Description Delete a sleep or break segment from a schedule
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param segmentId path int true "Segment ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "segment not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/segments/{segmentId} [delete]
+func (h *Handler) deleteScheduleSegment(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	segmentID, err := h.parseIntParam(c, "segmentId")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid segment ID", err)
+		return
+	}
+
+	if err := h.scheduleService.DeleteScheduleSegment(id, segmentID); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Segment not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to delete segment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Segment deleted successfully",
+	})
+}
@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shiftBoardError maps shift board service errors to responses
+func (h *Handler) shiftBoardError(c *gin.Context, err error, notFoundMessage, conflictMessage, failureMessage string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		h.errorResponse(c, http.StatusNotFound, notFoundMessage, err)
+	case errors.Is(err, services.ErrValidation):
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request", err)
+	case errors.Is(err, services.ErrInvalidTransition):
+		h.errorResponse(c, http.StatusConflict, conflictMessage, err)
+	case errors.Is(err, services.ErrCaregiverUnavailable):
+		h.errorResponse(c, http.StatusConflict, "Caregiver is not available", err)
+	case errors.Is(err, services.ErrCaregiverUnqualified):
+		h.errorResponse(c, http.StatusConflict, "Caregiver is not qualified", err)
+	case errors.Is(err, services.ErrVersionConflict):
+		h.errorResponse(c, http.StatusPreconditionFailed, "Modified by another request", err)
+	default:
+		h.errorResponse(c, http.StatusInternalServerError, failureMessage, err)
+	}
+}
+
+// bindShiftReview reads the optional review notes and If-Match header of an
+// open shift or swap request change
+func (h *Handler) bindShiftReview(c *gin.Context) (*models.ShiftReviewRequest, bool) {
+	var req models.ShiftReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return nil, false
+		}
+	}
+
+	var err error
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return nil, false
+	}
+	return &req, true
+}
+
+// releaseSchedule puts a schedule on the open shift board
+// @Summary Release schedule
+// @Description Release a scheduled visit to the open shift board for another caregiver to claim. With first_come the first eligible caregiver to claim takes the visit; with approval a coordinator approves the claim. The lead caregiver keeps the visit until the shift is filled
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body models.ScheduleReleaseRequest false "Reason and claim mode"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with open shift"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule is not scheduled, already released or part of a pending swap"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/release [post]
+func (h *Handler) releaseSchedule(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.ScheduleReleaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	shift, err := h.shiftBoardService.ReleaseSchedule(id, &req)
+	if err != nil {
+		h.shiftBoardError(c, err, "Schedule not found", "Schedule cannot be released", "Failed to release schedule")
+		return
+	}
+
+	h.setETag(c, shift.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Schedule released successfully",
+		"data":    shift,
+	})
+}
+
+// getOpenShifts lists the shifts on the open shift board
+// @Summary Get open shifts
+// @Description Get the shifts on the open shift board, oldest first. Open and claimed shifts are listed unless a status is given. With caregiver_id, only open shifts that caregiver did not release and could take are listed
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param status query string false "Comma-separated statuses (open, claimed, filled, cancelled)"
+// @Param caregiver_id query int false "Only shifts this caregiver is eligible to claim"
+// @Success 200 {object} map[string]interface{} "success response with open shifts"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/open-shifts [get]
+func (h *Handler) getOpenShifts(c *gin.Context) {
+	var filter models.OpenShiftFilter
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	var err error
+	if filter.CaregiverID, err = h.parseIntQuery(c, "caregiver_id"); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver_id parameter", err)
+		return
+	}
+
+	shifts, err := h.shiftBoardService.GetOpenShifts(&filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidFilter) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid filter", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get open shifts", err)
+		return
+	}
+
+	h.successResponse(c, shifts)
+}
+
+// getOpenShift retrieves an open shift
+// @Summary Get open shift
+// @Description Get an open shift with its schedule and the history of its status changes
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param id path int true "Open shift ID"
+// @Success 200 {object} map[string]interface{} "success response with open shift"
+// @Header 200 {string} ETag "Current version of the open shift"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "open shift not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/open-shifts/{id} [get]
+func (h *Handler) getOpenShift(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid open shift ID", err)
+		return
+	}
+
+	shift, err := h.shiftBoardService.GetOpenShift(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Open shift not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get open shift", err)
+		return
+	}
+
+	h.setETag(c, shift.Version)
+	h.successResponse(c, shift)
+}
+
+// claimOpenShift claims an open shift for a caregiver
+// @Summary Claim open shift
+// @Description Claim an open shift for a caregiver. The caregiver must be available, qualified and not blocked by the client. A first_come shift is reassigned to the caregiver straight away; an approval shift waits for a coordinator
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param id path int true "Open shift ID"
+// @Param If-Match header string false "ETag of the open shift version being claimed"
+// @Param request body models.OpenShiftClaimRequest true "Claiming caregiver"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated open shift"
+// @Header 200 {string} ETag "New version of the open shift"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "open shift not found"
+// @Failure 409 {object} map[string]interface{} "shift is not open or the caregiver cannot take it"
+// @Failure 412 {object} map[string]interface{} "open shift or schedule was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/open-shifts/{id}/claim [post]
+func (h *Handler) claimOpenShift(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid open shift ID", err)
+		return
+	}
+
+	var req models.OpenShiftClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	shift, err := h.shiftBoardService.ClaimOpenShift(id, &req)
+	if err != nil {
+		h.shiftBoardError(c, err, "Open shift not found", "Open shift cannot be claimed", "Failed to claim open shift")
+		return
+	}
+
+	h.setETag(c, shift.Version)
+	h.successResponse(c, shift)
+}
+
+// approveOpenShift approves a pending claim
+// @Summary Approve open shift claim
+// @Description Approve a caregiver's claim on an open shift and reassign the schedule to them
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param id path int true "Open shift ID"
+// @Param If-Match header string false "ETag of the open shift version being reviewed"
+// @Param request body models.ShiftReviewRequest false "Review notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated open shift"
+// @Header 200 {string} ETag "New version of the open shift"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "open shift not found"
+// @Failure 409 {object} map[string]interface{} "shift has no pending claim or the caregiver can no longer take it"
+// @Failure 412 {object} map[string]interface{} "open shift was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/open-shifts/{id}/approve [post]
+func (h *Handler) approveOpenShift(c *gin.Context) {
+	h.reviewOpenShift(c, h.shiftBoardService.ApproveOpenShift, "approved")
+}
+
+// rejectOpenShift rejects a pending claim
+// @Summary Reject open shift claim
+// @Description Reject a caregiver's claim on an open shift; the shift goes back on the board
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param id path int true "Open shift ID"
+// @Param If-Match header string false "ETag of the open shift version being reviewed"
+// @Param request body models.ShiftReviewRequest false "Review notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated open shift"
+// @Header 200 {string} ETag "New version of the open shift"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "open shift not found"
+// @Failure 409 {object} map[string]interface{} "shift has no pending claim"
+// @Failure 412 {object} map[string]interface{} "open shift was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/open-shifts/{id}/reject [post]
+func (h *Handler) rejectOpenShift(c *gin.Context) {
+	h.reviewOpenShift(c, h.shiftBoardService.RejectOpenShift, "rejected")
+}
+
+// cancelOpenShift takes a shift off the board
+// @Summary Cancel open shift
+// @Description Take an open or claimed shift off the board; the releasing caregiver keeps the schedule
+// @Tags open-shifts
+// @Accept json
+// @Produce json
+// @Param id path int true "Open shift ID"
+// @Param If-Match header string false "ETag of the open shift version being cancelled"
+// @Param request body models.ShiftReviewRequest false "Notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated open shift"
+// @Header 200 {string} ETag "New version of the open shift"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "open shift not found"
+// @Failure 409 {object} map[string]interface{} "shift is already filled or cancelled"
+// @Failure 412 {object} map[string]interface{} "open shift was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/open-shifts/{id}/cancel [post]
+func (h *Handler) cancelOpenShift(c *gin.Context) {
+	h.reviewOpenShift(c, h.shiftBoardService.CancelOpenShift, "cancelled")
+}
+
+// reviewOpenShift applies a coordinator decision to an open shift
+func (h *Handler) reviewOpenShift(c *gin.Context, review func(int, *models.ShiftReviewRequest) (*models.OpenShift, error), outcome string) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid open shift ID", err)
+		return
+	}
+
+	req, ok := h.bindShiftReview(c)
+	if !ok {
+		return
+	}
+
+	shift, err := review(id, req)
+	if err != nil {
+		h.shiftBoardError(c, err, "Open shift not found", "Open shift cannot be "+outcome, "Failed to review open shift")
+		return
+	}
+
+	h.setETag(c, shift.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Open shift " + outcome + " successfully",
+		"data":    shift,
+	})
+}
+
+// requestSwap asks another caregiver to swap shifts
+// @Summary Request shift swap
+// @Description Ask another caregiver to take over a schedule led by the requester, optionally in exchange for a schedule the target caregiver leads. Both caregivers must be able to take the other's schedule
+// @Tags swap-requests
+// @Accept json
+// @Produce json
+// @Param request body models.SwapRequestCreateRequest true "Schedules and target caregiver"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with swap request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "schedule cannot be swapped or a caregiver cannot take it"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/swap-requests [post]
+func (h *Handler) requestSwap(c *gin.Context) {
+	var req models.SwapRequestCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	request, err := h.shiftBoardService.RequestSwap(&req)
+	if err != nil {
+		h.shiftBoardError(c, err, "Schedule not found", "Schedule cannot be swapped", "Failed to request swap")
+		return
+	}
+
+	h.setETag(c, request.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Swap requested successfully",
+		"data":    request,
+	})
+}
+
+// getCaregiverSwapRequests lists a caregiver's swap requests
+// @Summary Get caregiver swap requests
+// @Description Get the swap requests a caregiver made or received, latest first
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Success 200 {object} map[string]interface{} "success response with swap requests"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/swap-requests [get]
+func (h *Handler) getCaregiverSwapRequests(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	requests, err := h.shiftBoardService.GetSwapRequests(id)
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get swap requests", err)
+		return
+	}
+
+	h.successResponse(c, requests)
+}
+
+// getSwapRequest retrieves a swap request
+// @Summary Get swap request
+// @Description Get a swap request with the history of its status changes
+// @Tags swap-requests
+// @Accept json
+// @Produce json
+// @Param id path int true "Swap request ID"
+// @Success 200 {object} map[string]interface{} "success response with swap request"
+// @Header 200 {string} ETag "Current version of the swap request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "swap request not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/swap-requests/{id} [get]
+func (h *Handler) getSwapRequest(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid swap request ID", err)
+		return
+	}
+
+	request, err := h.shiftBoardService.GetSwapRequest(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Swap request not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get swap request", err)
+		return
+	}
+
+	h.setETag(c, request.Version)
+	h.successResponse(c, request)
+}
+
+// acceptSwap accepts a swap request
+// @Summary Accept swap request
+// @Description Accept a pending swap request on behalf of the target caregiver and reassign the schedules. Both reassignments are checked before either takes effect, so schedules that overlap in time cannot be exchanged
+// @Tags swap-requests
+// @Accept json
+// @Produce json
+// @Param id path int true "Swap request ID"
+// @Param If-Match header string false "ETag of the swap request version being accepted"
+// @Param request body models.ShiftReviewRequest false "Response notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated swap request"
+// @Header 200 {string} ETag "New version of the swap request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "swap request not found"
+// @Failure 409 {object} map[string]interface{} "swap request is not pending or a caregiver can no longer take the schedule"
+// @Failure 412 {object} map[string]interface{} "swap request or schedule was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/swap-requests/{id}/accept [post]
+func (h *Handler) acceptSwap(c *gin.Context) {
+	h.respondToSwap(c, h.shiftBoardService.AcceptSwap, "accepted")
+}
+
+// rejectSwap rejects a swap request
+// @Summary Reject swap request
+// @Description Decline a pending swap request on behalf of the target caregiver
+// @Tags swap-requests
+// @Accept json
+// @Produce json
+// @Param id path int true "Swap request ID"
+// @Param If-Match header string false "ETag of the swap request version being rejected"
+// @Param request body models.ShiftReviewRequest false "Response notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated swap request"
+// @Header 200 {string} ETag "New version of the swap request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "swap request not found"
+// @Failure 409 {object} map[string]interface{} "swap request is not pending"
+// @Failure 412 {object} map[string]interface{} "swap request was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/swap-requests/{id}/reject [post]
+func (h *Handler) rejectSwap(c *gin.Context) {
+	h.respondToSwap(c, h.shiftBoardService.RejectSwap, "rejected")
+}
+
+// cancelSwap withdraws a swap request
+// @Summary Cancel swap request
+// @Description Withdraw a pending swap request on behalf of the requester
+// @Tags swap-requests
+// @Accept json
+// @Produce json
+// @Param id path int true "Swap request ID"
+// @Param If-Match header string false "ETag of the swap request version being cancelled"
+// @Param request body models.ShiftReviewRequest false "Notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated swap request"
+// @Header 200 {string} ETag "New version of the swap request"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "swap request not found"
+// @Failure 409 {object} map[string]interface{} "swap request is not pending"
+// @Failure 412 {object} map[string]interface{} "swap request was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/swap-requests/{id}/cancel [post]
+func (h *Handler) cancelSwap(c *gin.Context) {
+	h.respondToSwap(c, h.shiftBoardService.CancelSwap, "cancelled")
+}
+
+// respondToSwap applies a caregiver's response to a swap request
+func (h *Handler) respondToSwap(c *gin.Context, respond func(int, *models.ShiftReviewRequest) (*models.SwapRequest, error), outcome string) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid swap request ID", err)
+		return
+	}
+
+	req, ok := h.bindShiftReview(c)
+	if !ok {
+		return
+	}
+
+	request, err := respond(id, req)
+	if err != nil {
+		h.shiftBoardError(c, err, "Swap request not found", "Swap request cannot be "+outcome, "Failed to respond to swap request")
+		return
+	}
+
+	h.setETag(c, request.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Swap request " + outcome + " successfully",
+		"data":    request,
+	})
+}
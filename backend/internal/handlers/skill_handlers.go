@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultExpiryDays is how far ahead expiring certifications are listed when
+// no days parameter is given
+const defaultExpiryDays = 30
+
+// getCaregiverSkills lists a caregiver's skills and certifications
+// @Summary Get caregiver skills
+// @Description Get a caregiver's skills and certifications with their expiry dates
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Success 200 {object} map[string]interface{} "success response with skills"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/skills [get]
+func (h *Handler) getCaregiverSkills(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	skills, err := h.skillService.GetCaregiverSkills(id)
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get skills", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"skills": skills,
+		"count":  len(skills),
+	})
+}
+
+// setCaregiverSkill records or renews a caregiver's skill or certification
+// @Summary Set caregiver skill
+// @Description Record a skill or certification for a caregiver, replacing the certification details when the caregiver already holds the skill. Skill names are stored in lower case with underscores, e.g. wound_care
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param skill path string true "Skill name"
+// @Param certification body models.CaregiverSkillRequest false "Certification details"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with skill"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/skills/{skill} [put]
+func (h *Handler) setCaregiverSkill(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	var req models.CaregiverSkillRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	skill, err := h.skillService.SetCaregiverSkill(id, c.Param("skill"), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid skill", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to set skill", err)
+		return
+	}
+
+	h.successResponse(c, skill)
+}
+
+// deleteCaregiverSkill removes a skill from a caregiver
+// @Summary Delete caregiver skill
+// @Description Remove a skill or certification from a caregiver
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param skill path string true "Skill name"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "skill not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/skills/{skill} [delete]
+func (h *Handler) deleteCaregiverSkill(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	if err := h.skillService.DeleteCaregiverSkill(id, c.Param("skill")); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Skill not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to delete skill", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Skill deleted successfully",
+	})
+}
+
+// getExpiringCertifications lists certifications expiring soon
+// @Summary Get expiring certifications
+// @Description Get the caregiver certifications expiring within the next days, soonest first
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param days query int false "Days ahead to look (default 30)"
+// @Success 200 {object} map[string]interface{} "success response with certifications"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/certifications/expiring [get]
+func (h *Handler) getExpiringCertifications(c *gin.Context) {
+	days, err := h.parseIntQuery(c, "days")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid days", err)
+		return
+	}
+	if days == nil {
+		d := defaultExpiryDays
+		days = &d
+	}
+
+	skills, err := h.skillService.GetExpiringCertifications(*days)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid days", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get expiring certifications", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"certifications": skills,
+		"count":          len(skills),
+		"days":           *days,
+	})
+}
+
+// getServiceTypes lists the service types
+// @Summary Get service types
+// @Description Get all service types with the skills a caregiver needs to deliver them and the reason codes that excuse required tasks
+// @Tags service-types
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success response with service types"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/service-types [get]
+func (h *Handler) getServiceTypes(c *gin.Context) {
+	serviceTypes, err := h.skillService.GetServiceTypes()
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get service types", err)
+		return
+	}
+
+	h.successResponse(c, gin.H{
+		"service_types": serviceTypes,
+		"count":         len(serviceTypes),
+	})
+}
+
+// createServiceType creates a service type
+// @Summary Create a service type
+// @Description Create a service type. Schedules whose service name matches it can only be assigned to caregivers holding every required skill, certified until the end of the schedule. Required tasks of its schedules left not completed must give one of its reason_codes, or a default reason code when it has none
+// @Tags service-types
+// @Accept json
+// @Produce json
+// @Param serviceType body models.ServiceTypeRequest true "Service type"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with created service type"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/service-types [post]
+func (h *Handler) createServiceType(c *gin.Context) {
+	var req models.ServiceTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	serviceType, err := h.skillService.CreateServiceType(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid service type", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to create service type", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Service type created successfully",
+		"data":    serviceType,
+	})
+}
+
+// updateServiceType updates a service type
+// @Summary Update a service type
+// @Description Rename a service type and replace its required skills and reason codes. Existing assignments are not re-checked
+// @Tags service-types
+// @Accept json
+// @Produce json
+// @Param id path int true "Service type ID"
+// @Param serviceType body models.ServiceTypeRequest true "Service type"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated service type"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "service type not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/service-types/{id} [put]
+func (h *Handler) updateServiceType(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid service type ID", err)
+		return
+	}
+
+	var req models.ServiceTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	serviceType, err := h.skillService.UpdateServiceType(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Service type not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid service type", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to update service type", err)
+		}
+		return
+	}
+
+	h.successResponse(c, serviceType)
+}
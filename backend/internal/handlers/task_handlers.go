@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +17,7 @@ import (
 // @Produce json
 // @Param id path int true "Task ID"
 // @Success 200 {object} map[string]interface{} "success response with task details"
+// @Header 200 {string} ETag "Current version of the task"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "task not found"
 // @Failure 500 {object} map[string]interface{} "internal server error"
@@ -39,6 +42,7 @@ func (h *Handler) getTaskByID(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, task.Version)
 	h.successResponse(c, task)
 }
 
@@ -49,10 +53,14 @@ func (h *Handler) getTaskByID(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Task ID"
+// @Param If-Match header string false "ETag of the task version being updated"
 // @Param request body models.TaskUpdateRequest true "Task update request with status and optional reason"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
 // @Success 200 {object} map[string]interface{} "success response with updated task"
+// @Header 200 {string} ETag "New version of the task"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "task not found"
+// @Failure 412 {object} map[string]interface{} "task was modified since it was read"
 // @Failure 500 {object} map[string]interface{} "internal server error"
 // @Router /api/v1/tasks/{id} [put]
 func (h *Handler) updateTaskStatus(c *gin.Context) {
@@ -68,6 +76,11 @@ func (h *Handler) updateTaskStatus(c *gin.Context) {
 		return
 	}
 
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
 	updatedTask, err := h.taskService.UpdateTaskStatus(id, &req)
 	if err != nil {
 		if err.Error() == "task not found" {
@@ -76,9 +89,68 @@ func (h *Handler) updateTaskStatus(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.errorResponse(c, http.StatusPreconditionFailed, "Task was modified by another request", err)
+			return
+		}
 		h.errorResponse(c, http.StatusInternalServerError, "Failed to update task status", err)
 		return
 	}
 
+	h.setETag(c, updatedTask.Version)
 	h.successResponse(c, updatedTask)
 }
+
+// assignTask assigns a task to a caregiver on the schedule's team
+// @Summary Assign a task
+// @Description Assign a task to a caregiver on a team visit, or unassign it with a null caregiver_id
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param If-Match header string false "ETag of the task version being updated"
+// @Param request body models.TaskAssignRequest true "Caregiver to assign"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated task"
+// @Header 200 {string} ETag "New version of the task"
+// @Failure 400 {object} map[string]interface{} "caregiver is not assigned to the schedule"
+// @Failure 404 {object} map[string]interface{} "task not found"
+// @Failure 412 {object} map[string]interface{} "task was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/tasks/{id}/assignee [put]
+func (h *Handler) assignTask(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+
+	var req models.TaskAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	task, err := h.taskService.AssignTask(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Task not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid assignee", err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Task was modified by another request", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to assign task", err)
+		}
+		return
+	}
+
+	h.setETag(c, task.Version)
+	h.successResponse(c, task)
+}
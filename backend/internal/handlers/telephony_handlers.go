@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// telephonyVoicePath is the IVR provider webhook, which posts forms
+const telephonyVoicePath = "/api/v1/telephony/voice"
+
+// telephonyUnavailable is said when a call step fails
+const telephonyUnavailable = "Sorry, the caregiver line is not available. Please try again later. Goodbye."
+
+// voiceResponse is the TwiML-style XML document IVR providers play back
+type voiceResponse struct {
+	XMLName xml.Name     `xml:"Response"`
+	Gather  *voiceGather `xml:"Gather,omitempty"`
+	Say     string       `xml:"Say,omitempty"`
+	Hangup  *struct{}    `xml:"Hangup,omitempty"`
+}
+
+// voiceGather says a prompt and posts the keys pressed, ended by #, to Action
+type voiceGather struct {
+	Input       string `xml:"input,attr"`
+	FinishOnKey string `xml:"finishOnKey,attr"`
+	Action      string `xml:"action,attr"`
+	Method      string `xml:"method,attr"`
+	Say         string `xml:"Say"`
+}
+
+// setCaregiverTelephonyPIN sets a caregiver's telephony PIN
+// @Summary Set caregiver telephony PIN
+// @Description Set the PIN, 4 to 8 digits, a caregiver enters when clocking in or out by calling the telephony line from the client's phone. Only a salted hash of the PIN is stored
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Param id path int true "Caregiver ID"
+// @Param request body models.TelephonyPINRequest true "Telephony PIN"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/caregivers/{id}/telephony-pin [put]
+func (h *Handler) setCaregiverTelephonyPIN(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver ID", err)
+		return
+	}
+
+	var req models.TelephonyPINRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	pin, err := h.telephonyService.SetPIN(id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid PIN", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to set PIN", err)
+		return
+	}
+
+	h.successResponse(c, pin)
+}
+
+// telephonyVoice answers the IVR provider's voice webhook
+// @Summary Telephony voice webhook
+// @Description Webhook for IVR providers with TwiML-compatible voice callbacks. The provider posts the call's From caller ID, CallSid and the Digits pressed as a form; the response is a TwiML document. The caller ID is matched to a client's phone number, the caregiver enters their caregiver number and PIN, and is then clocked out of the visit to that client they are clocked in to, or else in to their next visit to the client today, with verification method telephony
+// @Tags telephony
+// @Accept x-www-form-urlencoded
+// @Produce xml
+// @Param From formData string true "Caller ID"
+// @Param CallSid formData string false "Provider call ID"
+// @Param Digits formData string false "Keys pressed in answer to the previous prompt"
+// @Param state query string false "Call state from the previous prompt's action URL"
+// @Success 200 {string} string "TwiML response"
+// @Router /api/v1/telephony/voice [post]
+func (h *Handler) telephonyVoice(c *gin.Context) {
+	call := &models.TelephonyCall{
+		CallID: c.PostForm("CallSid"),
+		From:   c.PostForm("From"),
+		Digits: c.PostForm("Digits"),
+		State:  c.Query("state"),
+	}
+
+	response := voiceResponse{Say: telephonyUnavailable, Hangup: &struct{}{}}
+	prompt, err := h.telephonyService.HandleCall(call)
+	if err != nil {
+		h.logger.WithError(err).WithField("call_id", call.CallID).Error("Telephony call failed")
+	} else if prompt.Gather {
+		response = voiceResponse{Gather: &voiceGather{
+			Input:       "dtmf",
+			FinishOnKey: "#",
+			Action:      telephonyVoicePath + "?state=" + url.QueryEscape(prompt.State),
+			Method:      http.MethodPost,
+			Say:         prompt.Message,
+		}}
+	} else {
+		response.Say = prompt.Message
+	}
+
+	c.XML(http.StatusOK, response)
+}
+
+// simulateTelephonyCall runs one step of a call without an IVR provider
+// @Summary Simulate a telephony call
+// @Description Run one step of a call to the telephony line as the voice webhook would, for local testing without an IVR provider. Start a call with from and no state, then send the digits pressed with the state returned by each prompt until gather is false
+// @Tags telephony
+// @Accept json
+// @Produce json
+// @Param request body models.TelephonyCall true "Call step"
+// @Success 200 {object} map[string]interface{} "success response with the next prompt"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/telephony/simulate [post]
+func (h *Handler) simulateTelephonyCall(c *gin.Context) {
+	var call models.TelephonyCall
+	if err := c.ShouldBindJSON(&call); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	prompt, err := h.telephonyService.HandleCall(&call)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid call state", err)
+			return
+		}
+		h.logger.WithError(err).WithFields(logrus.Fields{"call_id": call.CallID}).Error("Telephony call failed")
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to handle call", err)
+		return
+	}
+
+	h.successResponse(c, prompt)
+}
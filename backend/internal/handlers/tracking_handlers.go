@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordVisitLocations stores location pings sent during a visit
+// @Summary Record visit locations
+// @Description Record a batch of location pings sent by a caregiver's device while clocked in, e.g. every few minutes during a visit to a high-risk client. Pings already recorded for the same time are ignored, so a batch can be resent. Returns the visit's track summarized against the client's geofence
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body models.VisitLocationRequest true "Location pings; caregiver_id defaults to the lead caregiver"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with visit track"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "caregiver is not clocked in"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/locations [post]
+func (h *Handler) recordVisitLocations(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.VisitLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	track, err := h.trackingService.RecordLocations(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid location", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Caregiver is not clocked in", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to record locations", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Locations recorded successfully",
+		"data":    track,
+	})
+}
+
+// getVisitTrack retrieves the location track of a visit
+// @Summary Get visit track
+// @Description Get a caregiver's location track for a visit: the time spent inside and outside the client's geofence and the pings that have not yet been purged by the retention period
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param caregiver_id query int false "Caregiver ID, defaults to the lead caregiver"
+// @Success 200 {object} map[string]interface{} "success response with visit track"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule or track not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/track [get]
+func (h *Handler) getVisitTrack(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	caregiverID, err := h.parseIntQuery(c, "caregiver_id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid caregiver_id", err)
+		return
+	}
+
+	track, err := h.trackingService.GetVisitTrack(id, caregiverID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Visit track not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get visit track", err)
+		return
+	}
+
+	h.successResponse(c, track)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getClientVerificationToken retrieves a client's verification token
+// @Summary Get client verification token
+// @Description Get when a client's verification token was issued and its code parameters. The secret is only returned when the token is issued
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Success 200 {object} map[string]interface{} "success response with verification token"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client has no verification token"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/verification-token [get]
+func (h *Handler) getClientVerificationToken(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	token, err := h.verificationService.GetToken(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Verification token not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get verification token", err)
+		return
+	}
+
+	h.successResponse(c, token)
+}
+
+// issueClientVerificationToken issues a new verification token for a client
+// @Summary Issue client verification token
+// @Description Issue the secret behind the rotating code on a QR card or NFC tag in a client's home, replacing any earlier token. Codes are TOTP (RFC 6238, SHA-1, 30 second steps, 6 digits); the provisioning_uri can be written to the card or tag as is. Caregivers without a GPS fix send the code as verification_code when starting or ending a visit
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with verification token and secret"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/verification-token [post]
+func (h *Handler) issueClientVerificationToken(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	token, err := h.verificationService.IssueToken(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Client not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to issue verification token", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Verification token issued successfully",
+		"data":    token,
+	})
+}
+
+// revokeClientVerificationToken revokes a client's verification token
+// @Summary Revoke client verification token
+// @Description Revoke a client's verification token; visits to the client then have to be verified by location
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "client has no verification token"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/clients/{id}/verification-token [delete]
+func (h *Handler) revokeClientVerificationToken(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+
+	if err := h.verificationService.RevokeToken(id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Verification token not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to revoke verification token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Verification token revoked successfully",
+	})
+}
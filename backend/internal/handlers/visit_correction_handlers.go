@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// evvCSVHeader is the header row of the EVV CSV export
+var evvCSVHeader = []string{"visit_id", "schedule_id", "caregiver_id", "client_id", "service_name", "date",
+	"scheduled_start_time", "scheduled_end_time", "recorded_start_time", "recorded_end_time", "start_time", "end_time",
+	"start_verification_method", "end_verification_method", "amended", "amendment_reason", "amended_at"}
+
+// requestVisitCorrection records a caregiver's correction of their visit times
+// @Summary Request visit correction
+// @Description Ask to correct the clock-in, clock-out or both of a caregiver's visit on a schedule, e.g. after forgetting to clock out. The correction is pending until a supervisor approves it; the visit keeps its recorded times until then. Omitted times stay as recorded. Reason codes are forgot_clock_in, forgot_clock_out, device_issue, wrong_time and other
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body models.VisitCorrectionRequest true "Corrected times and reason"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 201 {object} map[string]interface{} "success response with created visit correction"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "schedule not found"
+// @Failure 409 {object} map[string]interface{} "caregiver has not clocked in or a correction is already pending"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/corrections [post]
+func (h *Handler) requestVisitCorrection(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	var req models.VisitCorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	correction, err := h.correctionService.RequestCorrection(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid visit correction", err)
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Schedule not found", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Visit cannot be corrected", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to request visit correction", err)
+		}
+		return
+	}
+
+	h.setETag(c, correction.Version)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Visit correction requested successfully",
+		"data":    correction,
+	})
+}
+
+// getScheduleCorrections lists the visit corrections requested on a schedule
+// @Summary Get schedule visit corrections
+// @Description Get the corrections requested for the visits on a schedule, oldest first
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{} "success response with visit corrections"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/schedules/{id}/corrections [get]
+func (h *Handler) getScheduleCorrections(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid schedule ID", err)
+		return
+	}
+
+	corrections, err := h.correctionService.GetScheduleCorrections(id)
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get visit corrections", err)
+		return
+	}
+
+	h.successResponse(c, corrections)
+}
+
+// getVisitCorrections lists visit corrections awaiting or after review
+// @Summary Get visit corrections
+// @Description Get the visit corrections in a status, oldest first. Pending corrections are listed unless a status is given
+// @Tags visit-corrections
+// @Accept json
+// @Produce json
+// @Param status query string false "pending (default), approved, rejected or cancelled"
+// @Success 200 {object} map[string]interface{} "success response with visit corrections"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-corrections [get]
+func (h *Handler) getVisitCorrections(c *gin.Context) {
+	corrections, err := h.correctionService.GetCorrections(c.DefaultQuery("status", models.CorrectionStatusPending))
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid status", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get visit corrections", err)
+		return
+	}
+
+	h.successResponse(c, corrections)
+}
+
+// getVisitCorrection retrieves a visit correction
+// @Summary Get visit correction
+// @Description Get a visit correction with the visit's times when it was requested and the proposed ones
+// @Tags visit-corrections
+// @Accept json
+// @Produce json
+// @Param id path int true "Visit correction ID"
+// @Success 200 {object} map[string]interface{} "success response with visit correction"
+// @Header 200 {string} ETag "Version of the visit correction"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "visit correction not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-corrections/{id} [get]
+func (h *Handler) getVisitCorrection(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid visit correction ID", err)
+		return
+	}
+
+	correction, err := h.correctionService.GetCorrection(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Visit correction not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get visit correction", err)
+		return
+	}
+
+	h.setETag(c, correction.Version)
+	h.successResponse(c, correction)
+}
+
+// approveVisitCorrection approves a pending visit correction
+// @Summary Approve visit correction
+// @Description Approve a pending visit correction. The visit's clock times are replaced by the corrected ones and the recorded times are kept as its original times; a corrected clock-out ends a visit still in progress. Timesheets, payroll and the EVV export use the corrected times and mark them as amended
+// @Tags visit-corrections
+// @Accept json
+// @Produce json
+// @Param id path int true "Visit correction ID"
+// @Param If-Match header string false "ETag of the visit correction version being reviewed"
+// @Param request body models.VisitCorrectionReviewRequest false "Review notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated visit correction"
+// @Header 200 {string} ETag "New version of the visit correction"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "visit correction not found"
+// @Failure 409 {object} map[string]interface{} "visit correction is not pending"
+// @Failure 412 {object} map[string]interface{} "visit correction was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-corrections/{id}/approve [post]
+func (h *Handler) approveVisitCorrection(c *gin.Context) {
+	h.reviewVisitCorrection(c, h.correctionService.ApproveCorrection, "approved")
+}
+
+// rejectVisitCorrection rejects a pending visit correction
+// @Summary Reject visit correction
+// @Description Reject a pending visit correction, leaving the visit as recorded
+// @Tags visit-corrections
+// @Accept json
+// @Produce json
+// @Param id path int true "Visit correction ID"
+// @Param If-Match header string false "ETag of the visit correction version being reviewed"
+// @Param request body models.VisitCorrectionReviewRequest false "Review notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated visit correction"
+// @Header 200 {string} ETag "New version of the visit correction"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "visit correction not found"
+// @Failure 409 {object} map[string]interface{} "visit correction is not pending"
+// @Failure 412 {object} map[string]interface{} "visit correction was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-corrections/{id}/reject [post]
+func (h *Handler) rejectVisitCorrection(c *gin.Context) {
+	h.reviewVisitCorrection(c, h.correctionService.RejectCorrection, "rejected")
+}
+
+// cancelVisitCorrection withdraws a pending visit correction
+// @Summary Cancel visit correction
+// @Description Withdraw a pending visit correction, e.g. to submit a new one
+// @Tags visit-corrections
+// @Accept json
+// @Produce json
+// @Param id path int true "Visit correction ID"
+// @Param If-Match header string false "ETag of the visit correction version being cancelled"
+// @Param request body models.VisitCorrectionReviewRequest false "Cancellation notes"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with updated visit correction"
+// @Header 200 {string} ETag "New version of the visit correction"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "visit correction not found"
+// @Failure 409 {object} map[string]interface{} "visit correction is not pending"
+// @Failure 412 {object} map[string]interface{} "visit correction was modified since it was read"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-corrections/{id}/cancel [post]
+func (h *Handler) cancelVisitCorrection(c *gin.Context) {
+	h.reviewVisitCorrection(c, h.correctionService.CancelCorrection, "cancelled")
+}
+
+// reviewVisitCorrection applies a review action to the visit correction in
+// the URL. The body with review notes is optional.
+func (h *Handler) reviewVisitCorrection(c *gin.Context, review func(int, *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error), outcome string) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid visit correction ID", err)
+		return
+	}
+
+	var req models.VisitCorrectionReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	correction, err := review(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Visit correction not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Visit correction cannot be "+outcome, err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Visit correction cannot be "+outcome, err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Visit correction was modified by another request", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to review visit correction", err)
+		}
+		return
+	}
+
+	h.setETag(c, correction.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Visit correction " + outcome + " successfully",
+		"data":    correction,
+	})
+}
+
+// exportEVV exports every visit for electronic visit verification
+// @Summary Export EVV visits
+// @Description Export every caregiver visit started over a date range for electronic visit verification, with the scheduled times, clock-in and clock-out locations and how presence was verified. recorded_start_time and recorded_end_time are the clock times as captured; start_time and end_time are the times billed, which differ from them on visits amended by an approved correction. Use format=csv for a CSV file
+// @Tags evv
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param from query string true "First day (YYYY-MM-DD)"
+// @Param to query string true "Last day, inclusive (YYYY-MM-DD)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{} "success response with EVV export"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/evv/export [get]
+func (h *Handler) exportEVV(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		h.errorResponse(c, http.StatusBadRequest, "from and to are required", fmt.Errorf("missing from or to"))
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		h.errorResponse(c, http.StatusBadRequest, "format must be 'json' or 'csv'", fmt.Errorf("unknown format %q", format))
+		return
+	}
+
+	export, err := h.scheduleService.ExportEVV(from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid EVV range", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to export EVV visits", err)
+		return
+	}
+
+	if format == "json" {
+		h.successResponse(c, export)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=evv_%s_%s.csv", from, to))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(evvCSVHeader)
+	for _, visit := range export.Visits {
+		_ = w.Write([]string{
+			strconv.Itoa(visit.VisitID),
+			strconv.Itoa(visit.ScheduleID),
+			strconv.Itoa(visit.CaregiverID),
+			strconv.Itoa(visit.ClientID),
+			visit.ServiceName,
+			visit.Date,
+			visit.ScheduledStartTime.UTC().Format(time.RFC3339),
+			visit.ScheduledEndTime.UTC().Format(time.RFC3339),
+			csvTime(visit.RecordedStartTime),
+			csvTime(visit.RecordedEndTime),
+			csvTime(visit.StartTime),
+			csvTime(visit.EndTime),
+			visit.StartVerificationMethod,
+			visit.EndVerificationMethod,
+			strconv.FormatBool(visit.Amended),
+			visit.AmendmentReason,
+			csvTime(visit.AmendedAt),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		h.logger.WithError(err).Error("Failed to write EVV CSV")
+	}
+}
+
+// csvTime formats an optional time for a CSV export, empty when it is nil
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -14,6 +14,7 @@ import (
 // @Produce json
 // @Param scheduleId path int true "Schedule ID"
 // @Success 200 {object} map[string]interface{} "success response with visit details"
+// @Header 200 {string} ETag "Current version of the visit"
 // @Failure 400 {object} map[string]interface{} "bad request"
 // @Failure 404 {object} map[string]interface{} "visit not found"
 // @Failure 500 {object} map[string]interface{} "internal server error"
@@ -38,5 +39,6 @@ func (h *Handler) getVisitByScheduleID(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, visit.Version)
 	h.successResponse(c, visit)
 }
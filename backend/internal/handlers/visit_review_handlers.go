@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getVisitReviews lists the visits queued for review as likely spoofed
+// @Summary Get visit reviews
+// @Description Get the visits queued for review because a clock-in or clock-out scored a high risk of a spoofed location, riskiest first. Pending reviews are listed unless a status is given. Each review lists the risk signals of the visit's clock events: mock location, impossible travel speed, coordinates repeated at other clients, the client's exact address coordinates, rounded coordinates and implausible or poor device accuracy
+// @Tags visit-reviews
+// @Accept json
+// @Produce json
+// @Param status query string false "pending (default), cleared or confirmed"
+// @Success 200 {object} map[string]interface{} "success response with visit reviews"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-reviews [get]
+func (h *Handler) getVisitReviews(c *gin.Context) {
+	reviews, err := h.anomalyService.GetReviews(c.DefaultQuery("status", models.VisitReviewPending))
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			h.errorResponse(c, http.StatusBadRequest, "Invalid status", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get visit reviews", err)
+		return
+	}
+
+	h.successResponse(c, reviews)
+}
+
+// getVisitReview retrieves a visit review
+// @Summary Get visit review
+// @Description Get a visit review with the risk signals of the visit's clock events
+// @Tags visit-reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Visit review ID"
+// @Success 200 {object} map[string]interface{} "success response with visit review"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "visit review not found"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-reviews/{id} [get]
+func (h *Handler) getVisitReview(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid visit review ID", err)
+		return
+	}
+
+	review, err := h.anomalyService.GetReview(id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.errorResponse(c, http.StatusNotFound, "Visit review not found", err)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "Failed to get visit review", err)
+		return
+	}
+
+	h.setETag(c, review.Version)
+	h.successResponse(c, review)
+}
+
+// resolveVisitReview records the decision on a visit review
+// @Summary Resolve visit review
+// @Description Resolve a pending visit review: cleared when the caregiver was at the client, confirmed when the location was spoofed, which requires notes. A resolved visit is queued again if a later clock event of it is high-risk
+// @Tags visit-reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Visit review ID"
+// @Param request body models.VisitReviewResolveRequest true "Decision and notes"
+// @Param If-Match header string false "ETag of the visit review the decision is based on"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry"
+// @Success 200 {object} map[string]interface{} "success response with visit review"
+// @Failure 400 {object} map[string]interface{} "bad request"
+// @Failure 404 {object} map[string]interface{} "visit review not found"
+// @Failure 409 {object} map[string]interface{} "visit review is already resolved"
+// @Failure 412 {object} map[string]interface{} "visit review was modified by another request"
+// @Failure 500 {object} map[string]interface{} "internal server error"
+// @Router /api/v1/visit-reviews/{id} [put]
+func (h *Handler) resolveVisitReview(c *gin.Context) {
+	id, err := h.parseIntParam(c, "id")
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid visit review ID", err)
+		return
+	}
+
+	var req models.VisitReviewResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.ExpectedVersion, err = h.parseIfMatch(c); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid If-Match header", err)
+		return
+	}
+
+	review, err := h.anomalyService.ResolveReview(id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.errorResponse(c, http.StatusNotFound, "Visit review not found", err)
+		case errors.Is(err, services.ErrValidation):
+			h.errorResponse(c, http.StatusBadRequest, "Invalid resolution", err)
+		case errors.Is(err, services.ErrInvalidTransition):
+			h.errorResponse(c, http.StatusConflict, "Visit review is already resolved", err)
+		case errors.Is(err, services.ErrVersionConflict):
+			h.errorResponse(c, http.StatusPreconditionFailed, "Visit review was modified by another request", err)
+		default:
+			h.errorResponse(c, http.StatusInternalServerError, "Failed to resolve visit review", err)
+		}
+		return
+	}
+
+	h.setETag(c, review.Version)
+	h.successResponse(c, review)
+}
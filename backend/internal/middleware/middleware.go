@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -78,10 +79,18 @@ func RateLimitMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 	}
 }
 
-// ValidationMiddleware validates request content type for POST/PUT requests
-func ValidationMiddleware() gin.HandlerFunc {
+// ValidationMiddleware validates request content type for POST/PUT requests.
+// Webhooks at formPaths, such as IVR provider callbacks, may also post forms.
+func ValidationMiddleware(formPaths ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if (c.Request.Method == "POST" || c.Request.Method == "PUT") && c.Request.ContentLength > 0 {
+			for _, path := range formPaths {
+				if c.Request.URL.Path == path && strings.HasPrefix(c.GetHeader("Content-Type"), "application/x-www-form-urlencoded") {
+					c.Next()
+					return
+				}
+			}
+
 			// Only validate content type if there's actual content in the request
 			contentType := c.GetHeader("Content-Type")
 			if contentType != "application/json" && contentType != "application/json; charset=utf-8" {
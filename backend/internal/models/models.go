@@ -4,6 +4,22 @@ import (
 	"time"
 )
 
+// Schedule statuses
+const (
+	ScheduleStatusScheduled  = "scheduled"
+	ScheduleStatusInProgress = "in_progress"
+	ScheduleStatusCompleted  = "completed"
+	ScheduleStatusMissed     = "missed"
+	ScheduleStatusCancelled  = "cancelled"
+)
+
+// Visit statuses
+const (
+	VisitStatusNotStarted = "not_started"
+	VisitStatusInProgress = "in_progress"
+	VisitStatusCompleted  = "completed"
+)
+
 // Schedule represents a caregiver's scheduled visit to a client
 type Schedule struct {
 	ID          int       `json:"id" db:"id"`
@@ -12,15 +28,450 @@ type Schedule struct {
 	CaregiverID int       `json:"caregiver_id" db:"caregiver_id" validate:"required"`
 	StartTime   time.Time `json:"start_time" db:"start_time" validate:"required"`
 	EndTime     time.Time `json:"end_time" db:"end_time" validate:"required"`
-	Status      string    `json:"status" db:"status" validate:"required,oneof=scheduled in_progress completed missed"`
+	Status      string    `json:"status" db:"status" validate:"required,oneof=scheduled in_progress completed missed cancelled"`
 	Notes       string    `json:"notes" db:"notes"`
+	FlexMinutes int       `json:"flex_minutes" db:"flex_minutes"` // The visit may start this much before or after start_time
+	CarePlanID  *int      `json:"care_plan_id" db:"care_plan_id"` // Care plan version the tasks were created from
+	Timezone    string    `json:"timezone" db:"-"`                // Zone the schedule's times are shown in
+	Version     int       `json:"version" db:"version"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 
 	// Related data
-	Client *Client `json:"client,omitempty" db:"-"`
-	Visit  *Visit  `json:"visit,omitempty" db:"-"`
-	Tasks  []Task  `json:"tasks,omitempty" db:"-"`
+	Client     *Client             `json:"client,omitempty" db:"-"`
+	Visit      *Visit              `json:"visit,omitempty" db:"-"`  // The lead caregiver's visit
+	Visits     []Visit             `json:"visits,omitempty" db:"-"` // One visit per caregiver who clocked in
+	Caregivers []ScheduleCaregiver `json:"caregivers,omitempty" db:"-"`
+	Tasks      []Task              `json:"tasks,omitempty" db:"-"`
+	Segments   []ScheduleSegment   `json:"segments,omitempty" db:"-"`
+}
+
+// Schedule caregiver roles
+const (
+	CaregiverRoleLead   = "lead"
+	CaregiverRoleMember = "member"
+)
+
+// ScheduleCaregiver assigns a caregiver to a schedule. The lead is the
+// schedule's CaregiverID; team visits such as two-person transfers add members.
+type ScheduleCaregiver struct {
+	ScheduleID  int       `json:"schedule_id" db:"schedule_id"`
+	CaregiverID int       `json:"caregiver_id" db:"caregiver_id" validate:"required"`
+	Role        string    `json:"role" db:"role" validate:"required,oneof=lead member"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Schedule segment types
+const (
+	SegmentTypeSleep = "sleep"
+	SegmentTypeBreak = "break"
+)
+
+// ScheduleSegment is a planned sleep or break period within a long shift
+type ScheduleSegment struct {
+	ID         int       `json:"id" db:"id"`
+	ScheduleID int       `json:"schedule_id" db:"schedule_id" validate:"required"`
+	Type       string    `json:"type" db:"type" validate:"required,oneof=sleep break"`
+	StartTime  time.Time `json:"start_time" db:"start_time" validate:"required"`
+	EndTime    time.Time `json:"end_time" db:"end_time" validate:"required"`
+	Notes      string    `json:"notes" db:"notes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// AvailabilityWindow is a recurring weekly period a caregiver can work, in
+// the caregiver's timezone. A window ending at or before its start time runs
+// past midnight into the next day; 00:00 to 00:00 covers the whole day.
+type AvailabilityWindow struct {
+	Weekday   int    `json:"weekday" db:"weekday" validate:"min=0,max=6"`    // 0 is Sunday
+	StartTime string `json:"start_time" db:"start_time" validate:"required"` // HH:MM
+	EndTime   string `json:"end_time" db:"end_time" validate:"required"`     // HH:MM
+}
+
+// CaregiverAvailability holds a caregiver's recurring availability and
+// working-hour preferences. A caregiver without windows is available at any time.
+type CaregiverAvailability struct {
+	CaregiverID    int                  `json:"caregiver_id" db:"caregiver_id"`
+	Timezone       string               `json:"timezone" db:"timezone"`                 // IANA name, empty for the agency default
+	MaxWeeklyHours *float64             `json:"max_weekly_hours" db:"max_weekly_hours"` // Nil for no limit
+	Windows        []AvailabilityWindow `json:"windows" db:"-"`
+	UpdatedAt      *time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// Time-off request statuses
+const (
+	TimeOffStatusPending   = "pending"
+	TimeOffStatusApproved  = "approved"
+	TimeOffStatusRejected  = "rejected"
+	TimeOffStatusCancelled = "cancelled"
+)
+
+// TimeOffRequest is a caregiver's request for leave. Approved time off makes
+// the caregiver unavailable for schedules overlapping it.
+type TimeOffRequest struct {
+	ID          int        `json:"id" db:"id"`
+	CaregiverID int        `json:"caregiver_id" db:"caregiver_id" validate:"required"`
+	StartTime   time.Time  `json:"start_time" db:"start_time" validate:"required"`
+	EndTime     time.Time  `json:"end_time" db:"end_time" validate:"required"`
+	Reason      string     `json:"reason" db:"reason"`
+	Status      string     `json:"status" db:"status" validate:"required,oneof=pending approved rejected cancelled"`
+	ReviewNotes string     `json:"review_notes" db:"review_notes"`
+	ReviewedAt  *time.Time `json:"reviewed_at" db:"reviewed_at"`
+	Version     int        `json:"version" db:"version"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Open shift claim modes
+const (
+	ClaimModeFirstCome = "first_come" // The first eligible caregiver to claim takes the shift
+	ClaimModeApproval  = "approval"   // A coordinator approves the claim
+)
+
+// Open shift statuses
+const (
+	OpenShiftStatusOpen      = "open"
+	OpenShiftStatusClaimed   = "claimed" // Awaiting coordinator approval
+	OpenShiftStatusFilled    = "filled"
+	OpenShiftStatusCancelled = "cancelled"
+)
+
+// OpenShift is a schedule released to the open shift board for another
+// caregiver to claim. The releasing caregiver keeps the schedule until the
+// shift is filled.
+type OpenShift struct {
+	ID          int               `json:"id" db:"id"`
+	ScheduleID  int               `json:"schedule_id" db:"schedule_id" validate:"required"`
+	ReleasedBy  int               `json:"released_by" db:"released_by"`
+	Reason      string            `json:"reason" db:"reason"`
+	ClaimMode   string            `json:"claim_mode" db:"claim_mode" validate:"required,oneof=first_come approval"`
+	Status      string            `json:"status" db:"status" validate:"required,oneof=open claimed filled cancelled"`
+	ClaimedBy   *int              `json:"claimed_by" db:"claimed_by"`
+	ClaimedAt   *time.Time        `json:"claimed_at" db:"claimed_at"`
+	ReviewNotes string            `json:"review_notes" db:"review_notes"`
+	Version     int               `json:"version" db:"version"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+	Schedule    *Schedule         `json:"schedule,omitempty" db:"-"`
+	History     []ShiftTransition `json:"history,omitempty" db:"-"`
+}
+
+// Swap request statuses
+const (
+	SwapStatusPending   = "pending"
+	SwapStatusAccepted  = "accepted"
+	SwapStatusRejected  = "rejected"
+	SwapStatusCancelled = "cancelled"
+)
+
+// SwapRequest asks another caregiver to take over a schedule, optionally in
+// exchange for one of theirs
+type SwapRequest struct {
+	ID                int               `json:"id" db:"id"`
+	ScheduleID        int               `json:"schedule_id" db:"schedule_id" validate:"required"`
+	RequesterID       int               `json:"requester_id" db:"requester_id"`
+	TargetCaregiverID int               `json:"target_caregiver_id" db:"target_caregiver_id" validate:"required"`
+	TargetScheduleID  *int              `json:"target_schedule_id" db:"target_schedule_id"` // Nil for a one-way handover
+	Notes             string            `json:"notes" db:"notes"`
+	Status            string            `json:"status" db:"status" validate:"required,oneof=pending accepted rejected cancelled"`
+	ResponseNotes     string            `json:"response_notes" db:"response_notes"`
+	RespondedAt       *time.Time        `json:"responded_at" db:"responded_at"`
+	Version           int               `json:"version" db:"version"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+	History           []ShiftTransition `json:"history,omitempty" db:"-"`
+}
+
+// Entities whose transitions are recorded in the shift transition log
+const (
+	ShiftEntityOpenShift   = "open_shift"
+	ShiftEntitySwapRequest = "swap_request"
+)
+
+// ShiftTransition records a status change of an open shift or swap request
+type ShiftTransition struct {
+	ID          int       `json:"id" db:"id"`
+	Entity      string    `json:"entity" db:"entity"`
+	EntityID    int       `json:"entity_id" db:"entity_id"`
+	Event       string    `json:"event" db:"event"`
+	FromStatus  string    `json:"from_status" db:"from_status"` // Empty when the entity was created
+	ToStatus    string    `json:"to_status" db:"to_status"`
+	CaregiverID *int      `json:"caregiver_id" db:"caregiver_id"` // Caregiver who acted, nil for coordinators
+	Notes       string    `json:"notes" db:"notes"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// CaregiverSkill is a skill or certification held by a caregiver, e.g.
+// "medication_management". A certification with an expiry date no longer
+// qualifies the caregiver once it has expired.
+type CaregiverSkill struct {
+	ID                  int        `json:"id" db:"id"`
+	CaregiverID         int        `json:"caregiver_id" db:"caregiver_id" validate:"required"`
+	Skill               string     `json:"skill" db:"skill" validate:"required"`
+	CertificationNumber string     `json:"certification_number" db:"certification_number"`
+	IssuedAt            *time.Time `json:"issued_at" db:"issued_at"`
+	ExpiresAt           *time.Time `json:"expires_at" db:"expires_at"` // Nil for skills that do not expire
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ValidAt reports whether the skill qualifies the caregiver at t
+func (s *CaregiverSkill) ValidAt(t time.Time) bool {
+	return s.ExpiresAt == nil || s.ExpiresAt.After(t)
+}
+
+// ServiceType is a kind of service schedules are booked for, matched to a
+// schedule's ServiceName without regard to case, and the skills a caregiver
+// needs to deliver it
+type ServiceType struct {
+	ID             int       `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name" validate:"required"`
+	Description    string    `json:"description" db:"description"`
+	RequiredSkills []string  `json:"required_skills" db:"-"`
+	ReasonCodes    []string  `json:"reason_codes" db:"reason_codes"` // Accepted reasons for not completing required tasks; empty for the defaults
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TaskTemplate is a task of the agency's library that care plans are composed
+// of, so that a task is named the same on every client's visits
+type TaskTemplate struct {
+	ID           int       `json:"id" db:"id"`
+	Title        string    `json:"title" db:"title" validate:"required"`
+	Instructions string    `json:"instructions" db:"instructions"` // Default instructions for care plans
+	IsActive     bool      `json:"is_active" db:"is_active"`       // Inactive templates cannot be added to care plans
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultTaskReasonCodes are the accepted reasons for not completing a
+// required task on schedules whose service type has no reason codes of its own
+var DefaultTaskReasonCodes = []string{
+	"client_refused",
+	"client_unavailable",
+	"supplies_unavailable",
+	"unsafe_conditions",
+}
+
+// How often a care plan item is done
+const (
+	CarePlanFrequencyEveryVisit = "every_visit"
+	CarePlanFrequencyWeekly     = "weekly" // On the first visit booked in each Monday to Sunday week
+	CarePlanFrequencyDays       = "days"   // On visits starting on the item's weekdays
+)
+
+// CarePlan is a version of the tasks a client's visits are set up with. A
+// version never changes: revising the plan adds a version, and schedules keep
+// the version their tasks were created from.
+type CarePlan struct {
+	ID        int            `json:"id" db:"id"`
+	ClientID  int            `json:"client_id" db:"client_id"`
+	Version   int            `json:"version" db:"version"` // Numbered from 1 for each client
+	Notes     string         `json:"notes" db:"notes"`
+	Items     []CarePlanItem `json:"items" db:"-"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// CarePlanItem is a task template in a care plan with how often it is done.
+// The template's title is copied when the plan is saved.
+type CarePlanItem struct {
+	ID           int    `json:"id" db:"id"`
+	CarePlanID   int    `json:"care_plan_id" db:"care_plan_id"`
+	TemplateID   int    `json:"template_id" db:"template_id" validate:"required"`
+	Title        string `json:"title" db:"title"`
+	Frequency    string `json:"frequency" db:"frequency" validate:"required,oneof=every_visit weekly days"`
+	Weekdays     []int  `json:"weekdays,omitempty" db:"weekdays"` // With the days frequency; 0 is Sunday
+	Instructions string `json:"instructions" db:"instructions"`
+	Required     bool   `json:"required" db:"required"` // Must be done, or excused with a reason code, before clock-out
+}
+
+// Genders used for caregiver profiles and client preferences
+const (
+	GenderFemale    = "female"
+	GenderMale      = "male"
+	GenderNonBinary = "non_binary"
+)
+
+// CaregiverProfile holds the caregiver attributes clients can state
+// preferences about. Languages are lower-case ISO 639 codes, e.g. "en".
+type CaregiverProfile struct {
+	CaregiverID int        `json:"caregiver_id" db:"caregiver_id"`
+	Gender      string     `json:"gender" db:"gender"`
+	Languages   []string   `json:"languages" db:"languages"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// ClientPreferences holds what a client prefers in a caregiver and the
+// caregivers they do not want to be sent again
+type ClientPreferences struct {
+	ClientID          int                `json:"client_id" db:"client_id"`
+	PreferredGender   string             `json:"preferred_gender" db:"preferred_gender"` // Empty for no preference
+	Languages         []string           `json:"languages" db:"languages"`               // Languages the client speaks
+	BlockedCaregivers []BlockedCaregiver `json:"blocked_caregivers" db:"-"`
+	UpdatedAt         *time.Time         `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// BlockedCaregiver is a caregiver a client asked not to be matched with
+type BlockedCaregiver struct {
+	CaregiverID int    `json:"caregiver_id" db:"caregiver_id" validate:"required"`
+	Reason      string `json:"reason" db:"reason"`
+}
+
+// MatchCandidate is a caregiver ranked for a schedule, with the factors
+// that make up their score
+type MatchCandidate struct {
+	CaregiverID int           `json:"caregiver_id"`
+	Eligible    bool          `json:"eligible"`
+	Score       float64       `json:"score"` // Sum of the factor points, out of 100
+	Factors     []MatchFactor `json:"factors"`
+	Exclusions  []string      `json:"exclusions,omitempty"` // Why an ineligible caregiver cannot take the schedule
+}
+
+// MatchFactor explains one part of a candidate's score
+type MatchFactor struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"` // Points available for the factor
+	Score  float64 `json:"score"`  // How well the candidate does, from 0 to 1
+	Points float64 `json:"points"` // Weight times score
+	Detail string  `json:"detail"`
+}
+
+// RoutePlan is the order a caregiver's visits for a day are best driven in,
+// with travel estimates between them. A day is infeasible when some visit
+// cannot be reached before its latest allowed start.
+type RoutePlan struct {
+	CaregiverID        int         `json:"caregiver_id"`
+	Date               string      `json:"date"`
+	Feasible           bool        `json:"feasible"`
+	TotalDistanceKm    float64     `json:"total_distance_km"`
+	TotalTravelMinutes float64     `json:"total_travel_minutes"`
+	BookedDistanceKm   float64     `json:"booked_distance_km"` // Driving the visits in booked order, for comparison
+	Stops              []RouteStop `json:"stops"`
+	Issues             []string    `json:"issues"`
+}
+
+// RouteStop is one visit on a route plan. Travel is from the previous stop.
+type RouteStop struct {
+	Sequence      int       `json:"sequence"`
+	ScheduleID    int       `json:"schedule_id"`
+	ClientID      int       `json:"client_id"`
+	ClientName    string    `json:"client_name"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	Flexible      bool      `json:"flexible"`
+	BookedStart   time.Time `json:"booked_start"`
+	WindowStart   time.Time `json:"window_start"` // Earliest allowed start
+	WindowEnd     time.Time `json:"window_end"`   // Latest allowed start
+	Arrival       time.Time `json:"arrival"`
+	PlannedStart  time.Time `json:"planned_start"`
+	PlannedEnd    time.Time `json:"planned_end"`
+	TravelKm      float64   `json:"travel_km"`
+	TravelMinutes float64   `json:"travel_minutes"`
+	WaitMinutes   float64   `json:"wait_minutes"`
+	LateMinutes   float64   `json:"late_minutes"` // Past the latest allowed start
+}
+
+// TravelSegment is a caregiver's drive from one visit's clock-out to the next
+// visit's clock-in on the same day. A coordinator may override the measured
+// distance, giving a reason; MileageKm is the distance that is paid.
+type TravelSegment struct {
+	ID               int        `json:"id" db:"id"`
+	CaregiverID      int        `json:"caregiver_id" db:"caregiver_id"`
+	Date             string     `json:"date" db:"date"` // YYYY-MM-DD of the clock-in in the client's timezone
+	FromScheduleID   int        `json:"from_schedule_id" db:"from_schedule_id"`
+	ToScheduleID     int        `json:"to_schedule_id" db:"to_schedule_id"`
+	FromLatitude     float64    `json:"from_latitude" db:"from_latitude"`
+	FromLongitude    float64    `json:"from_longitude" db:"from_longitude"`
+	ToLatitude       float64    `json:"to_latitude" db:"to_latitude"`
+	ToLongitude      float64    `json:"to_longitude" db:"to_longitude"`
+	DepartedAt       time.Time  `json:"departed_at" db:"departed_at"`
+	ArrivedAt        time.Time  `json:"arrived_at" db:"arrived_at"`
+	DistanceKm       float64    `json:"distance_km" db:"distance_km"`
+	TravelMinutes    float64    `json:"travel_minutes" db:"travel_minutes"`
+	AdjustedKm       *float64   `json:"adjusted_km" db:"adjusted_km"`
+	AdjustmentReason string     `json:"adjustment_reason" db:"adjustment_reason"`
+	AdjustedAt       *time.Time `json:"adjusted_at" db:"adjusted_at"`
+	MileageKm        float64    `json:"mileage_km" db:"-"`
+	Version          int        `json:"version" db:"version"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// TravelLog lists a caregiver's travel segments over a date range
+type TravelLog struct {
+	CaregiverID        int             `json:"caregiver_id"`
+	From               string          `json:"from"`
+	To                 string          `json:"to"`
+	Segments           []TravelSegment `json:"segments"`
+	DistanceKm         float64         `json:"distance_km"` // Measured between clock-out and clock-in
+	MileageKm          float64         `json:"mileage_km"`  // Paid, after adjustments
+	TotalTravelMinutes float64         `json:"total_travel_minutes"`
+}
+
+// TravelAdjustmentRequest represents the request to override the distance of a
+// travel segment. A nil adjusted_km removes the adjustment.
+type TravelAdjustmentRequest struct {
+	AdjustedKm *float64 `json:"adjusted_km" validate:"omitempty,min=0"`
+	Reason     string   `json:"reason"` // Required with adjusted_km
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// PayrollRow is one caregiver's hours and mileage on one day of a payroll export
+type PayrollRow struct {
+	CaregiverID int     `json:"caregiver_id"`
+	Date        string  `json:"date"`
+	TotalHours  float64 `json:"total_hours"`
+	SleepHours  float64 `json:"sleep_hours"`
+	BreakHours  float64 `json:"break_hours"`
+	WorkedHours float64 `json:"worked_hours"`
+	MileageKm   float64 `json:"mileage_km"`
+	Amended     bool    `json:"amended"` // Hours include a visit with corrected times
+}
+
+// PayrollExport lists the hours and mileage of every caregiver who worked
+// over a date range
+type PayrollExport struct {
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	Rows        []PayrollRow `json:"rows"`
+	WorkedHours float64      `json:"worked_hours"`
+	MileageKm   float64      `json:"mileage_km"`
+}
+
+// EVVVisit is one caregiver's visit in an EVV export. Recorded times are the
+// clock-in and clock-out as captured; start and end times are the ones billed,
+// which differ from them once a correction was approved.
+type EVVVisit struct {
+	VisitID                 int        `json:"visit_id"`
+	ScheduleID              int        `json:"schedule_id"`
+	CaregiverID             int        `json:"caregiver_id"`
+	ClientID                int        `json:"client_id"`
+	ServiceName             string     `json:"service_name"`
+	Date                    string     `json:"date"` // YYYY-MM-DD of the start in the client's timezone
+	ScheduledStartTime      time.Time  `json:"scheduled_start_time"`
+	ScheduledEndTime        time.Time  `json:"scheduled_end_time"`
+	RecordedStartTime       *time.Time `json:"recorded_start_time"`
+	RecordedEndTime         *time.Time `json:"recorded_end_time"`
+	StartTime               *time.Time `json:"start_time"`
+	EndTime                 *time.Time `json:"end_time"`
+	StartLatitude           *float64   `json:"start_latitude"`
+	StartLongitude          *float64   `json:"start_longitude"`
+	EndLatitude             *float64   `json:"end_latitude"`
+	EndLongitude            *float64   `json:"end_longitude"`
+	StartVerificationMethod string     `json:"start_verification_method"`
+	EndVerificationMethod   string     `json:"end_verification_method"`
+	Amended                 bool       `json:"amended"`
+	AmendmentReason         string     `json:"amendment_reason"`
+	AmendedAt               *time.Time `json:"amended_at"`
+}
+
+// EVVExport lists every visit started over a date range for electronic visit
+// verification reporting
+type EVVExport struct {
+	From   string     `json:"from"`
+	To     string     `json:"to"`
+	Visits []EVVVisit `json:"visits"`
 }
 
 // Client represents a client with their information and location
@@ -36,15 +487,54 @@ type Client struct {
 	Latitude  float64   `json:"latitude" db:"latitude"`
 	Longitude float64   `json:"longitude" db:"longitude"`
 	Notes     string    `json:"notes" db:"notes"`
+	Timezone  string    `json:"timezone" db:"timezone"` // IANA name, empty for the agency default
 	IsActive  bool      `json:"is_active" db:"is_active"`
+	Version   int       `json:"version" db:"version"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// How the coordinates were found: given with the client or geocoded from
+	// its address, and how sure the geocoder was from 0 to 1. Empty for
+	// clients created before addresses were geocoded.
+	GeocodeMatch      string     `json:"geocode_match,omitempty" db:"geocode_match" validate:"omitempty,oneof=manual address street postcode locality"`
+	GeocodeConfidence float64    `json:"geocode_confidence" db:"geocode_confidence"`
+	GeocodedAt        *time.Time `json:"geocoded_at,omitempty" db:"geocoded_at"`
+
+	// DistanceKm is the distance from the point of a nearby-client search
+	DistanceKm *float64 `json:"distance_km,omitempty" db:"-"`
+}
+
+// Geocode matches, from the most to the least precise
+const (
+	GeocodeMatchManual   = "manual"   // Coordinates given with the client
+	GeocodeMatchAddress  = "address"  // The house number on the street
+	GeocodeMatchStreet   = "street"   // The middle of the street
+	GeocodeMatchPostcode = "postcode" // The middle of the postcode area
+	GeocodeMatchLocality = "locality" // The city or town
+)
+
+// PostalAddress is the address of a client as geocoded
+type PostalAddress struct {
+	Street  string `json:"street"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	ZipCode string `json:"zip_code"`
+}
+
+// GeocodeResult is where a geocoder placed an address
+type GeocodeResult struct {
+	Address    PostalAddress `json:"address"` // Normalized, in the dataset's spelling when matched to an address
+	Latitude   float64       `json:"latitude"`
+	Longitude  float64       `json:"longitude"`
+	Match      string        `json:"match"`
+	Confidence float64       `json:"confidence"`
 }
 
 // Visit represents the actual visit log with timestamps and geolocation
 type Visit struct {
 	ID             int        `json:"id" db:"id"`
 	ScheduleID     int        `json:"schedule_id" db:"schedule_id" validate:"required"`
+	CaregiverID    int        `json:"caregiver_id" db:"caregiver_id" validate:"required"`
 	StartTime      *time.Time `json:"start_time" db:"start_time"`
 	EndTime        *time.Time `json:"end_time" db:"end_time"`
 	StartLatitude  *float64   `json:"start_latitude" db:"start_latitude"`
@@ -54,40 +544,636 @@ type Visit struct {
 	LocationStatus string     `json:"location_status" db:"location_status" validate:"oneof=pending confirmed"`
 	Status         string     `json:"status" db:"status" validate:"required,oneof=not_started in_progress completed"`
 	Notes          string     `json:"notes" db:"notes"`
+	Version        int        `json:"version" db:"version"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+
+	// How the caregiver's presence was verified at clock-in and clock-out
+	StartVerificationMethod string `json:"start_verification_method,omitempty" db:"start_verification_method" validate:"omitempty,oneof=gps token gps_and_token telephony"`
+	EndVerificationMethod   string `json:"end_verification_method,omitempty" db:"end_verification_method" validate:"omitempty,oneof=gps token gps_and_token telephony"`
+
+	// Work and break periods recorded through pause and resume, with the
+	// time worked and spent on breaks computed from them
+	Segments    []VisitSegment `json:"segments,omitempty" db:"-"`
+	WorkedHours float64        `json:"worked_hours" db:"-"`
+	BreakHours  float64        `json:"break_hours" db:"-"`
+	OnBreak     bool           `json:"on_break" db:"-"`
+
+	// Summary of the location pings posted during the visit, if any
+	Track *VisitTrack `json:"track,omitempty" db:"-"`
+
+	// Risk that the clock-in or clock-out location was spoofed
+	Risk *VisitRisk `json:"risk,omitempty" db:"-"`
+
+	// Clock-in and clock-out as recorded, kept once an approved correction
+	// amends the visit's times; AmendedAt is nil for visits never amended
+	OriginalStartTime *time.Time `json:"original_start_time,omitempty" db:"original_start_time"`
+	OriginalEndTime   *time.Time `json:"original_end_time,omitempty" db:"original_end_time"`
+	AmendedAt         *time.Time `json:"amended_at,omitempty" db:"amended_at"`
+	AmendmentReason   string     `json:"amendment_reason,omitempty" db:"amendment_reason"` // Reason code of the last approved correction
+
+	// Nearest known address of the clock-in and clock-out locations, resolved
+	// when they were outside the client geofence
+	StartAddress string `json:"start_address,omitempty" db:"start_address"`
+	EndAddress   string `json:"end_address,omitempty" db:"end_address"`
+}
+
+// Visit verification methods
+const (
+	VerificationMethodGPS         = "gps"
+	VerificationMethodToken       = "token"
+	VerificationMethodGPSAndToken = "gps_and_token"
+	VerificationMethodTelephony   = "telephony"
+)
+
+// Visit correction statuses
+const (
+	CorrectionStatusPending   = "pending"
+	CorrectionStatusApproved  = "approved"
+	CorrectionStatusRejected  = "rejected"
+	CorrectionStatusCancelled = "cancelled"
+)
+
+// Visit correction reason codes
+const (
+	CorrectionReasonForgotClockIn  = "forgot_clock_in"  // Clocked in after arriving
+	CorrectionReasonForgotClockOut = "forgot_clock_out" // Clocked out after leaving, or not at all
+	CorrectionReasonDeviceIssue    = "device_issue"
+	CorrectionReasonWrongTime      = "wrong_time"
+	CorrectionReasonOther          = "other"
+)
+
+// VisitCorrection is a caregiver's request to amend the clock-in or clock-out
+// of their visit, approved or rejected by a supervisor. The visit's times
+// when the request was made are kept alongside the proposed ones.
+type VisitCorrection struct {
+	ID                int        `json:"id" db:"id"`
+	ScheduleID        int        `json:"schedule_id" db:"schedule_id"`
+	CaregiverID       int        `json:"caregiver_id" db:"caregiver_id"`
+	VisitID           int        `json:"visit_id" db:"visit_id"`
+	ProposedStartTime *time.Time `json:"proposed_start_time" db:"proposed_start_time"` // Nil keeps the clock-in
+	ProposedEndTime   *time.Time `json:"proposed_end_time" db:"proposed_end_time"`     // Nil keeps the clock-out
+	RecordedStartTime *time.Time `json:"recorded_start_time" db:"recorded_start_time"`
+	RecordedEndTime   *time.Time `json:"recorded_end_time" db:"recorded_end_time"`
+	ReasonCode        string     `json:"reason_code" db:"reason_code" validate:"required,oneof=forgot_clock_in forgot_clock_out device_issue wrong_time other"`
+	Notes             string     `json:"notes" db:"notes"`
+	Status            string     `json:"status" db:"status" validate:"required,oneof=pending approved rejected cancelled"`
+	ReviewNotes       string     `json:"review_notes" db:"review_notes"`
+	ReviewedAt        *time.Time `json:"reviewed_at" db:"reviewed_at"`
+	Version           int        `json:"version" db:"version"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Visit segment types
+const (
+	VisitSegmentTypeWork  = "work"
+	VisitSegmentTypeBreak = "break"
+)
+
+// VisitSegment is a recorded period of work or a break within a visit. The
+// segment that is still running has no end time.
+type VisitSegment struct {
+	ID             int        `json:"id" db:"id"`
+	ScheduleID     int        `json:"schedule_id" db:"schedule_id" validate:"required"`
+	CaregiverID    int        `json:"caregiver_id" db:"caregiver_id" validate:"required"`
+	Type           string     `json:"type" db:"type" validate:"required,oneof=work break"`
+	StartTime      time.Time  `json:"start_time" db:"start_time"`
+	EndTime        *time.Time `json:"end_time" db:"end_time"`
+	StartLatitude  *float64   `json:"start_latitude" db:"start_latitude"`
+	StartLongitude *float64   `json:"start_longitude" db:"start_longitude"`
+	EndLatitude    *float64   `json:"end_latitude" db:"end_latitude"`
+	EndLongitude   *float64   `json:"end_longitude" db:"end_longitude"`
+	Notes          string     `json:"notes" db:"notes"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 }
 
 // Task represents a care activity that needs to be completed during a visit
 type Task struct {
 	ID          int        `json:"id" db:"id"`
 	ScheduleID  int        `json:"schedule_id" db:"schedule_id" validate:"required"`
+	CaregiverID *int       `json:"caregiver_id" db:"caregiver_id"`      // Assigned team member, nil for anyone on the schedule
+	TemplateID  *int       `json:"template_id" db:"template_id"`        // Task template of the care plan the task came from
 	Title       string     `json:"name" db:"title" validate:"required"` // Map title to name for frontend compatibility
 	Description string     `json:"description" db:"description"`
 	Status      string     `json:"status" db:"status" validate:"required,oneof=pending completed not_completed"`
-	Reason      string     `json:"reason" db:"reason"` // Required when status is "not_completed"
+	Reason      string     `json:"reason" db:"reason"`     // Required when status is "not_completed"
+	Required    bool       `json:"required" db:"required"` // Blocks clock-out until completed or not completed with a reason code
 	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	Version     int        `json:"version" db:"version"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
-// VisitStartRequest represents the request to start a visit
+// VisitStartRequest represents the request to start a visit. The caregiver's
+// presence is verified by a location, by the verification code shown in the
+// client's home, or by both; a location of 0, 0 counts as missing.
 type VisitStartRequest struct {
-	Latitude  float64 `json:"start_latitude" validate:"required,min=-90,max=90"`
-	Longitude float64 `json:"start_longitude" validate:"required,min=-180,max=180"`
+	Latitude         float64 `json:"start_latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude        float64 `json:"start_longitude" validate:"omitempty,min=-180,max=180"`
+	VerificationCode string  `json:"verification_code"` // From the client's QR card or NFC tag
+
+	// Device details used to detect spoofed locations
+	Accuracy     *float64 `json:"accuracy"`      // Metres, as reported by the device
+	MockLocation bool     `json:"mock_location"` // The device reported a mock location provider
+
+	// CaregiverID clocks in a member of a team visit; defaults to the lead
+	CaregiverID *int `json:"caregiver_id,omitempty"`
+
+	// CallerClientID is set by the telephony service to the client whose phone
+	// the caregiver called from; it cannot be sent in a request body
+	CallerClientID *int `json:"-"`
 }
 
-// VisitEndRequest represents the request to end a visit
+// VisitEndRequest represents the request to end a visit, verified like
+// VisitStartRequest by a location, a verification code or both
 type VisitEndRequest struct {
-	Latitude  float64 `json:"end_latitude" validate:"required,min=-90,max=90"`
-	Longitude float64 `json:"end_longitude" validate:"required,min=-180,max=180"`
-	Notes     string  `json:"notes"`
+	Latitude         float64 `json:"end_latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude        float64 `json:"end_longitude" validate:"omitempty,min=-180,max=180"`
+	VerificationCode string  `json:"verification_code"` // From the client's QR card or NFC tag
+	Notes            string  `json:"notes"`
+
+	// Device details used to detect spoofed locations
+	Accuracy     *float64 `json:"accuracy"`      // Metres, as reported by the device
+	MockLocation bool     `json:"mock_location"` // The device reported a mock location provider
+
+	// CaregiverID clocks out a member of a team visit; defaults to the lead
+	CaregiverID *int `json:"caregiver_id,omitempty"`
+
+	// CallerClientID is set by the telephony service to the client whose phone
+	// the caregiver called from; it cannot be sent in a request body
+	CallerClientID *int `json:"-"`
+}
+
+// LocationPing is a position a caregiver's device reported during a visit
+type LocationPing struct {
+	Latitude   float64   `json:"latitude" validate:"required,min=-90,max=90"`
+	Longitude  float64   `json:"longitude" validate:"required,min=-180,max=180"`
+	Accuracy   *float64  `json:"accuracy"` // Metres, as reported by the device
+	RecordedAt time.Time `json:"recorded_at" validate:"required"`
+}
+
+// VisitLocationRequest represents a batch of location pings posted during an
+// in-progress visit. Pings already stored for the same time are ignored, so a
+// batch can be retried.
+type VisitLocationRequest struct {
+	Points []LocationPing `json:"points" validate:"required,min=1,max=500,dive"`
+
+	// CaregiverID posts for a member of a team visit; defaults to the lead
+	CaregiverID *int `json:"caregiver_id,omitempty"`
+}
+
+// VisitTrack summarizes the location pings of one caregiver's visit against
+// the client's geofence. Time between two pings counts as inside or outside
+// the geofence by where the first of them was. The summary is kept after the
+// raw pings are purged.
+type VisitTrack struct {
+	ScheduleID           int            `json:"schedule_id" db:"schedule_id"`
+	CaregiverID          int            `json:"caregiver_id" db:"caregiver_id"`
+	PointCount           int            `json:"point_count" db:"point_count"`
+	FirstRecordedAt      *time.Time     `json:"first_recorded_at" db:"first_recorded_at"`
+	LastRecordedAt       *time.Time     `json:"last_recorded_at" db:"last_recorded_at"`
+	GeofenceKnown        bool           `json:"geofence_known" db:"geofence_known"` // False when the client has no coordinates
+	GeofenceRadiusMeters float64        `json:"geofence_radius_meters" db:"geofence_radius_meters"`
+	InsideMinutes        float64        `json:"inside_minutes" db:"inside_minutes"`
+	OutsideMinutes       float64        `json:"outside_minutes" db:"outside_minutes"`
+	PointsOutside        int            `json:"points_outside" db:"points_outside"`
+	MaxDistanceMeters    float64        `json:"max_distance_meters" db:"max_distance_meters"` // Furthest ping from the client
+	UpdatedAt            time.Time      `json:"updated_at" db:"updated_at"`
+	Points               []LocationPing `json:"points,omitempty" db:"-"` // Raw pings, until purged
+}
+
+// ClientVerificationToken is the secret behind the rotating verification code
+// on a QR card or NFC tag in a client's home. Codes are time-based one-time
+// passwords (RFC 6238: SHA-1, 30 second steps, 6 digits). The secret and its
+// provisioning URI are only returned when the token is issued.
+type ClientVerificationToken struct {
+	ClientID        int       `json:"client_id" db:"client_id"`
+	Secret          string    `json:"secret,omitempty" db:"secret"` // Base32
+	ProvisioningURI string    `json:"provisioning_uri,omitempty" db:"-"`
+	Digits          int       `json:"digits" db:"-"`
+	PeriodSeconds   int       `json:"period_seconds" db:"-"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// CaregiverTelephonyPIN is the PIN a caregiver enters when clocking in or out
+// by phone. Only a salted hash of it is stored.
+type CaregiverTelephonyPIN struct {
+	CaregiverID int       `json:"caregiver_id" db:"caregiver_id"`
+	PINHash     string    `json:"-" db:"pin_hash"`
+	Salt        string    `json:"-" db:"salt"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TelephonyPINRequest represents the request to set a caregiver's telephony PIN
+type TelephonyPINRequest struct {
+	PIN string `json:"pin" validate:"required"` // 4 to 8 digits
+}
+
+// TelephonyCall is one step of a call to the telephony line, normalized from
+// the IVR provider's webhook. State is the value returned with the previous
+// prompt and is empty when the call comes in.
+type TelephonyCall struct {
+	CallID string `json:"call_id"`
+	From   string `json:"from" validate:"required"` // Caller ID
+	Digits string `json:"digits"`                   // Keys pressed in answer to the previous prompt
+	State  string `json:"state"`
+}
+
+// TelephonyPrompt is what the telephony line says next. When Gather is set
+// the caller's keypad input, ended by #, is sent back with State; otherwise
+// the call ends.
+type TelephonyPrompt struct {
+	Message string `json:"message"`
+	Gather  bool   `json:"gather"`
+	State   string `json:"state,omitempty"`
+
+	// The clock event recorded by the call, if any
+	ScheduleID *int   `json:"schedule_id,omitempty"`
+	Event      string `json:"event,omitempty"`
+}
+
+// Clock event types
+const (
+	ClockEventIn  = "clock_in"
+	ClockEventOut = "clock_out"
+)
+
+// ClockEvent is a clock-in or clock-out with the location and device details
+// it was reported with, scored for the risk that the location was spoofed
+type ClockEvent struct {
+	ID           int          `json:"id" db:"id"`
+	ScheduleID   int          `json:"schedule_id" db:"schedule_id"`
+	CaregiverID  int          `json:"caregiver_id" db:"caregiver_id"`
+	ClientID     int          `json:"client_id" db:"client_id"`
+	Event        string       `json:"event" db:"event" validate:"required,oneof=clock_in clock_out"`
+	Latitude     float64      `json:"latitude" db:"latitude"`
+	Longitude    float64      `json:"longitude" db:"longitude"`
+	Accuracy     *float64     `json:"accuracy" db:"accuracy"`
+	MockLocation bool         `json:"mock_location" db:"mock_location"`
+	RecordedAt   time.Time    `json:"recorded_at" db:"recorded_at"`
+	RiskScore    int          `json:"risk_score" db:"risk_score"`
+	Signals      []RiskSignal `json:"signals" db:"signals"` // Stored as JSON
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+
+	// How presence was verified; events verified by a token alone or by
+	// telephony have no location
+	VerificationMethod string `json:"verification_method" db:"verification_method"`
+}
+
+// Risk signal codes
+const (
+	RiskSignalMockLocation           = "mock_location"
+	RiskSignalImpossibleTravel       = "impossible_travel"
+	RiskSignalRepeatedCoordinates    = "repeated_coordinates"
+	RiskSignalExactClientCoordinates = "exact_client_coordinates"
+	RiskSignalRoundedCoordinates     = "rounded_coordinates"
+	RiskSignalImplausibleAccuracy    = "implausible_accuracy"
+	RiskSignalPoorAccuracy           = "poor_accuracy"
+)
+
+// RiskSignal is one reason a clock event's location may have been spoofed
+type RiskSignal struct {
+	Code   string `json:"code"`
+	Event  string `json:"event"`  // Clock event the signal was raised on
+	Points int    `json:"points"` // Added to the risk score
+	Detail string `json:"detail"`
+}
+
+// VisitRisk summarizes the spoofing risk of one caregiver's visit. The score
+// is the highest of its clock events, from 0 to 100.
+type VisitRisk struct {
+	ScheduleID   int          `json:"schedule_id"`
+	CaregiverID  int          `json:"caregiver_id"`
+	Score        int          `json:"score"`
+	Signals      []RiskSignal `json:"signals"`
+	ReviewID     *int         `json:"review_id,omitempty"`
+	ReviewStatus string       `json:"review_status,omitempty"` // Empty when the visit was not queued for review
+}
+
+// Visit review statuses
+const (
+	VisitReviewPending   = "pending"
+	VisitReviewCleared   = "cleared"
+	VisitReviewConfirmed = "confirmed"
+)
+
+// VisitReview queues a high-risk visit for a coordinator to check whether the
+// caregiver was really at the client. It is queued again when a later clock
+// event of the visit is high-risk.
+type VisitReview struct {
+	ID              int          `json:"id" db:"id"`
+	ScheduleID      int          `json:"schedule_id" db:"schedule_id"`
+	CaregiverID     int          `json:"caregiver_id" db:"caregiver_id"`
+	RiskScore       int          `json:"risk_score" db:"risk_score"`
+	Status          string       `json:"status" db:"status" validate:"required,oneof=pending cleared confirmed"`
+	ResolutionNotes string       `json:"resolution_notes" db:"resolution_notes"`
+	ReviewedAt      *time.Time   `json:"reviewed_at" db:"reviewed_at"`
+	Version         int          `json:"version" db:"version"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
+	Signals         []RiskSignal `json:"signals,omitempty" db:"-"`
+}
+
+// Geographic place dataset formats
+const (
+	GeoPlaceFormatGeoNames = "geonames" // GeoNames tab-separated dump
+	GeoPlaceFormatCSV      = "csv"      // CSV with a header, e.g. converted from an OpenStreetMap extract
+)
+
+// GeoPlace is a place of the offline reverse geocoding dataset: a street
+// address, a point of interest or a populated place
+type GeoPlace struct {
+	ID          int     `json:"id" db:"id"`
+	Source      string  `json:"source" db:"source"`       // Dataset the place was imported from
+	SourceID    string  `json:"source_id" db:"source_id"` // ID of the place in its dataset
+	Name        string  `json:"name" db:"name"`
+	Street      string  `json:"street" db:"street"`
+	Locality    string  `json:"locality" db:"locality"`
+	Region      string  `json:"region" db:"region"`
+	Postcode    string  `json:"postcode" db:"postcode"`
+	CountryCode string  `json:"country_code" db:"country_code"`
+	Latitude    float64 `json:"latitude" db:"latitude"`
+	Longitude   float64 `json:"longitude" db:"longitude"`
+}
+
+// GeoPlaceSource records the import of a place dataset file, so that an
+// unchanged file is not imported again
+type GeoPlaceSource struct {
+	Source         string    `json:"source" db:"source"` // Name the places were imported under
+	Format         string    `json:"format" db:"format"`
+	FileSize       int64     `json:"file_size" db:"file_size"`
+	FileModifiedAt time.Time `json:"file_modified_at" db:"file_modified_at"`
+	Imported       int       `json:"imported" db:"imported"` // Places added or updated
+	Skipped        int       `json:"skipped" db:"skipped"`   // Lines without a usable location
+	ImportedAt     time.Time `json:"imported_at" db:"imported_at"`
+}
+
+// ReverseGeocode is the nearest known address of a location
+type ReverseGeocode struct {
+	Latitude       float64  `json:"latitude"`
+	Longitude      float64  `json:"longitude"`
+	Address        string   `json:"address"`
+	DistanceMeters float64  `json:"distance_meters"` // From the location to the place
+	Place          GeoPlace `json:"place"`
+}
+
+// VisitReviewResolveRequest represents the request to resolve a visit review:
+// cleared when the caregiver was at the client, confirmed when the location was spoofed
+type VisitReviewResolveRequest struct {
+	Status string `json:"status" validate:"required,oneof=cleared confirmed"`
+	Notes  string `json:"notes"`
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// VisitCancelRequest represents the optional request body to cancel a visit
+type VisitCancelRequest struct {
+	// CaregiverID aborts the visit of a member of a team visit; defaults to the lead
+	CaregiverID *int `json:"caregiver_id,omitempty"`
+}
+
+// VisitPauseRequest represents the request to pause a visit for a break
+type VisitPauseRequest struct {
+	Latitude  float64 `json:"latitude" validate:"required,min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"required,min=-180,max=180"`
+	Reason    string  `json:"reason"` // e.g. "lunch", "pharmacy run"
+
+	// CaregiverID pauses a member of a team visit; defaults to the lead
+	CaregiverID *int `json:"caregiver_id,omitempty"`
+}
+
+// VisitResumeRequest represents the request to resume a paused visit
+type VisitResumeRequest struct {
+	Latitude  float64 `json:"latitude" validate:"required,min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"required,min=-180,max=180"`
+
+	// CaregiverID resumes a member of a team visit; defaults to the lead
+	CaregiverID *int `json:"caregiver_id,omitempty"`
 }
 
 // TaskUpdateRequest represents the request to update a task
 type TaskUpdateRequest struct {
 	Status string `json:"status" validate:"required,oneof=completed not_completed"`
 	Reason string `json:"reason"` // Required when status is "not_completed"
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// TaskAssignRequest represents the request to assign a task to a team member
+type TaskAssignRequest struct {
+	CaregiverID *int `json:"caregiver_id"` // null unassigns the task
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// ScheduleCaregiverRequest represents the request to add a caregiver to a schedule's team
+type ScheduleCaregiverRequest struct {
+	CaregiverID int `json:"caregiver_id" validate:"required"`
+}
+
+// ScheduleCreateRequest represents the request to create a schedule
+type ScheduleCreateRequest struct {
+	ClientID    int       `json:"client_id" validate:"required"`
+	ServiceName string    `json:"service_name"`
+	CaregiverID int       `json:"caregiver_id" validate:"required"`
+	StartTime   time.Time `json:"start_time" validate:"required"`
+	EndTime     time.Time `json:"end_time" validate:"required"`
+	Notes       string    `json:"notes"`
+	FlexMinutes int       `json:"flex_minutes" validate:"min=0"` // Leave at 0 for a visit that must start on time
+}
+
+// ScheduleReassignRequest represents the request to hand a schedule to another lead caregiver
+type ScheduleReassignRequest struct {
+	CaregiverID int `json:"caregiver_id" validate:"required"`
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// CaregiverAvailabilityRequest replaces a caregiver's availability windows and preferences
+type CaregiverAvailabilityRequest struct {
+	Timezone       string               `json:"timezone"`
+	MaxWeeklyHours *float64             `json:"max_weekly_hours"`
+	Windows        []AvailabilityWindow `json:"windows"`
+}
+
+// TimeOffCreateRequest represents the request to ask for time off
+type TimeOffCreateRequest struct {
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+	Reason    string    `json:"reason"`
+}
+
+// TimeOffReviewRequest represents the request to approve, reject or cancel time off
+type TimeOffReviewRequest struct {
+	Notes string `json:"notes"`
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// VisitCorrectionRequest represents a caregiver's request to correct their
+// clock-in, clock-out or both
+type VisitCorrectionRequest struct {
+	CaregiverID *int       `json:"caregiver_id"` // Defaults to the schedule's lead caregiver
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	ReasonCode  string     `json:"reason_code" validate:"required,oneof=forgot_clock_in forgot_clock_out device_issue wrong_time other"`
+	Notes       string     `json:"notes"`
+}
+
+// VisitCorrectionReviewRequest represents the request to approve, reject or
+// cancel a visit correction
+type VisitCorrectionReviewRequest struct {
+	Notes string `json:"notes"`
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// ScheduleReleaseRequest represents the request to release a schedule to the open shift board
+type ScheduleReleaseRequest struct {
+	Reason    string `json:"reason"`
+	ClaimMode string `json:"claim_mode" validate:"omitempty,oneof=first_come approval"` // Defaults to first_come
+}
+
+// OpenShiftClaimRequest represents a caregiver's claim on an open shift
+type OpenShiftClaimRequest struct {
+	CaregiverID int `json:"caregiver_id" validate:"required"`
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// ShiftReviewRequest represents the request to approve, reject, accept or
+// cancel an open shift or swap request
+type ShiftReviewRequest struct {
+	Notes string `json:"notes"`
+
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// SwapRequestCreateRequest represents the request to ask another caregiver to swap shifts
+type SwapRequestCreateRequest struct {
+	ScheduleID        int    `json:"schedule_id" validate:"required"`
+	TargetCaregiverID int    `json:"target_caregiver_id" validate:"required"`
+	TargetScheduleID  *int   `json:"target_schedule_id"`
+	Notes             string `json:"notes"`
+}
+
+// OpenShiftFilter represents filters for listing open shifts
+type OpenShiftFilter struct {
+	Statuses    []string `json:"statuses"`     // Open and claimed shifts when empty
+	CaregiverID *int     `json:"caregiver_id"` // Only shifts this caregiver is eligible to claim
+}
+
+// CaregiverSkillRequest represents the request to record a caregiver's skill or certification
+type CaregiverSkillRequest struct {
+	CertificationNumber string     `json:"certification_number"`
+	IssuedAt            *time.Time `json:"issued_at"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+}
+
+// CaregiverProfileRequest represents the request to set a caregiver's profile
+type CaregiverProfileRequest struct {
+	Gender    string   `json:"gender" validate:"omitempty,oneof=female male non_binary"`
+	Languages []string `json:"languages"`
+}
+
+// ClientPreferencesRequest represents the request to set a client's caregiver preferences
+type ClientPreferencesRequest struct {
+	PreferredGender   string             `json:"preferred_gender" validate:"omitempty,oneof=female male non_binary"`
+	Languages         []string           `json:"languages"`
+	BlockedCaregivers []BlockedCaregiver `json:"blocked_caregivers"`
+}
+
+// CandidateOptions controls how schedule candidates are listed
+type CandidateOptions struct {
+	IncludeIneligible bool // Also list caregivers who cannot take the schedule
+	Limit             *int // Nil for every candidate
+}
+
+// ServiceTypeRequest represents the request to create or update a service type
+type ServiceTypeRequest struct {
+	Name           string   `json:"name" validate:"required"`
+	Description    string   `json:"description"`
+	RequiredSkills []string `json:"required_skills"`
+	ReasonCodes    []string `json:"reason_codes"` // Empty for the default reason codes
+}
+
+// TaskTemplateRequest represents the request to create or update a task template
+type TaskTemplateRequest struct {
+	Title        string `json:"title" validate:"required"`
+	Instructions string `json:"instructions"`
+	IsActive     *bool  `json:"is_active"` // Defaults to true
+}
+
+// CarePlanRequest represents the request to revise a client's care plan
+type CarePlanRequest struct {
+	Notes string                `json:"notes"`
+	Items []CarePlanItemRequest `json:"items"`
+
+	// ExpectedVersion is taken from the If-Match header; the revision is
+	// rejected when the client's plan has moved on to another version
+	ExpectedVersion *int `json:"-"`
+}
+
+// CarePlanItemRequest is a task template to add to a care plan
+type CarePlanItemRequest struct {
+	TemplateID   int    `json:"template_id" validate:"required"`
+	Frequency    string `json:"frequency" validate:"required,oneof=every_visit weekly days"`
+	Weekdays     []int  `json:"weekdays"`     // Required with the days frequency; 0 is Sunday
+	Instructions string `json:"instructions"` // Defaults to the template's instructions
+	Required     bool   `json:"required"`
+}
+
+// ScheduleSegmentRequest represents the request to add a sleep or break segment to a schedule
+type ScheduleSegmentRequest struct {
+	Type      string    `json:"type" validate:"required,oneof=sleep break"`
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+	Notes     string    `json:"notes"`
+}
+
+// DayHours is the part of a shift that falls on one calendar day
+type DayHours struct {
+	Date        string  `json:"date"` // YYYY-MM-DD in the client's timezone
+	ScheduleID  int     `json:"schedule_id"`
+	TotalHours  float64 `json:"total_hours"`  // Elapsed shift hours on the day
+	SleepHours  float64 `json:"sleep_hours"`  // Hours in sleep segments
+	BreakHours  float64 `json:"break_hours"`  // Hours in break segments
+	WorkedHours float64 `json:"worked_hours"` // Total less sleep and break hours
+	Amended     bool    `json:"amended"`      // Counted from corrected clock times
+}
+
+// TimesheetDay sums the shift hours of a caregiver on one calendar day
+type TimesheetDay struct {
+	Date        string     `json:"date"`
+	TotalHours  float64    `json:"total_hours"`
+	SleepHours  float64    `json:"sleep_hours"`
+	BreakHours  float64    `json:"break_hours"`
+	WorkedHours float64    `json:"worked_hours"`
+	MileageKm   float64    `json:"mileage_km"` // Travel between visits, after adjustments
+	Amended     bool       `json:"amended"`    // A shift is counted from corrected clock times
+	Shifts      []DayHours `json:"shifts"`
+}
+
+// Timesheet lists a caregiver's hours per calendar day over a date range
+type Timesheet struct {
+	CaregiverID int            `json:"caregiver_id"`
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	Days        []TimesheetDay `json:"days"`
+	TotalHours  float64        `json:"total_hours"`
+	SleepHours  float64        `json:"sleep_hours"`
+	BreakHours  float64        `json:"break_hours"`
+	WorkedHours float64        `json:"worked_hours"`
+	MileageKm   float64        `json:"mileage_km"`
 }
 
 // ScheduleStats represents statistics for the dashboard
@@ -100,11 +1186,17 @@ type ScheduleStats struct {
 
 // ScheduleFilter represents filters for schedule queries
 type ScheduleFilter struct {
-	CaregiverID *int       `json:"caregiver_id"`
-	Date        *time.Time `json:"date"`
-	Status      *string    `json:"status"`
-	Limit       *int       `json:"limit"`
-	Offset      *int       `json:"offset"`
+	CaregiverID        *int       `json:"caregiver_id"`
+	ClientID           *int       `json:"client_id"`
+	From               *time.Time `json:"from"`                 // Schedules starting at or after this time
+	To                 *time.Time `json:"to"`                   // Schedules starting before this time
+	ActiveFrom         *time.Time `json:"active_from"`          // Schedules ending after this time
+	ActiveTo           *time.Time `json:"active_to"`            // Schedules starting before this time
+	Statuses           []string   `json:"statuses"`             // Match any of the statuses
+	ServiceName        *string    `json:"service_name"`         // Case-insensitive match
+	LocationStatus     *string    `json:"location_status"`      // Location status of the schedule's visit
+	HasIncompleteTasks *bool      `json:"has_incomplete_tasks"` // Has tasks that are not completed
+	ListOptions
 }
 
 // ClientCreateRequest represents the request to create a new client
@@ -116,9 +1208,10 @@ type ClientCreateRequest struct {
 	City      string  `json:"city" validate:"required"`
 	State     string  `json:"state" validate:"required"`
 	ZipCode   string  `json:"zip_code" validate:"required"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`  // Omit with longitude to geocode the address
+	Longitude float64 `json:"longitude"` // Omit with latitude to geocode the address
 	Notes     string  `json:"notes"`
+	Timezone  string  `json:"timezone"`
 }
 
 // ClientUpdateRequest represents the request to update a client
@@ -133,7 +1226,12 @@ type ClientUpdateRequest struct {
 	Latitude  *float64 `json:"latitude"`
 	Longitude *float64 `json:"longitude"`
 	Notes     *string  `json:"notes"`
+	Timezone  *string  `json:"timezone"`
 	IsActive  *bool    `json:"is_active"`
+
+	// ExpectedVersion is taken from the If-Match header; the update is rejected
+	// when the stored client has moved on to another version
+	ExpectedVersion *int `json:"-"`
 }
 
 // ClientFilter represents filters for client queries
@@ -142,6 +1240,69 @@ type ClientFilter struct {
 	City     *string `json:"city"`
 	State    *string `json:"state"`
 	Search   *string `json:"search"` // Search by name, email, or phone
-	Limit    *int    `json:"limit"`
-	Offset   *int    `json:"offset"`
+
+	// Near limits the clients to those within RadiusKm of a point, nearest
+	// first unless sorted otherwise
+	Near     *GeoPoint `json:"near"`
+	RadiusKm float64   `json:"radius_km"`
+	ListOptions
+}
+
+// GeoPoint is a location given by latitude and longitude
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Search radius of nearby-client queries
+const (
+	DefaultNearRadiusKm = 10.0
+	MaxNearRadiusKm     = 500.0
+)
+
+// Page size limits for list endpoints
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ListOptions holds cursor pagination and sorting options shared by list queries
+type ListOptions struct {
+	Cursor       string `json:"cursor"`        // Opaque cursor from a previous page's next_cursor
+	Limit        *int   `json:"limit"`         // Page size; nil means no limit
+	Sort         string `json:"sort"`          // Sort field, prefixed with "-" for descending order
+	IncludeTotal bool   `json:"include_total"` // Also count all matching rows
+}
+
+// PageSize returns the requested page size, defaulted and capped at MaxPageSize
+func (o ListOptions) PageSize() int {
+	if o.Limit == nil || *o.Limit <= 0 {
+		return DefaultPageSize
+	}
+	if *o.Limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return *o.Limit
+}
+
+// PageInfo describes a page of list results
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Limit      int    `json:"limit"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// IdempotencyRecord stores the outcome of a mutating request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	Key          string `json:"key" db:"key"`
+	Method       string `json:"method" db:"method"`
+	Path         string `json:"path" db:"path"`
+	RequestHash  string `json:"request_hash" db:"request_hash"`
+	StatusCode   *int   `json:"status_code" db:"status_code"` // Nil while the request is still being processed
+	ResponseBody []byte `json:"-" db:"response_body"`
+	// ResponseHeaders holds the headers sent again on replay, such as ETag and Location
+	ResponseHeaders map[string]string `json:"-" db:"response_headers"` // Stored as JSON
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+	ExpiresAt       time.Time         `json:"expires_at" db:"expires_at"`
 }
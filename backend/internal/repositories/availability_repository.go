@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type availabilityRepository struct {
+	db *sql.DB
+}
+
+// NewAvailabilityRepository creates a new caregiver availability repository
+func NewAvailabilityRepository(db *sql.DB) AvailabilityRepository {
+	return &availabilityRepository{db: db}
+}
+
+// GetByCaregiverID retrieves a caregiver's preferences and availability windows
+func (r *availabilityRepository) GetByCaregiverID(caregiverID int) (*models.CaregiverAvailability, error) {
+	query := `
+		SELECT caregiver_id, timezone, max_weekly_hours, updated_at
+		FROM caregiver_preferences
+		WHERE caregiver_id = ?`
+
+	var a models.CaregiverAvailability
+	err := r.db.QueryRow(query, caregiverID).Scan(&a.CaregiverID, &a.Timezone, &a.MaxWeeklyHours, &a.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get caregiver preferences: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT weekday, start_time, end_time
+		FROM caregiver_availability
+		WHERE caregiver_id = ?
+		ORDER BY weekday ASC, start_time ASC`, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query caregiver availability: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w models.AvailabilityWindow
+		if err := rows.Scan(&w.Weekday, &w.StartTime, &w.EndTime); err != nil {
+			return nil, fmt.Errorf("failed to scan availability window: %w", err)
+		}
+		a.Windows = append(a.Windows, w)
+	}
+
+	return &a, nil
+}
+
+// Replace stores a caregiver's preferences and replaces all of their
+// availability windows
+func (r *availabilityRepository) Replace(availability *models.CaregiverAvailability) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO caregiver_preferences (caregiver_id, timezone, max_weekly_hours, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (caregiver_id) DO UPDATE SET
+		    timezone = excluded.timezone,
+		    max_weekly_hours = excluded.max_weekly_hours,
+		    updated_at = excluded.updated_at`,
+		availability.CaregiverID, availability.Timezone, availability.MaxWeeklyHours)
+	if err != nil {
+		return fmt.Errorf("failed to save caregiver preferences: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM caregiver_availability WHERE caregiver_id = ?", availability.CaregiverID); err != nil {
+		return fmt.Errorf("failed to clear caregiver availability: %w", err)
+	}
+	for _, w := range availability.Windows {
+		_, err := tx.Exec(`
+			INSERT INTO caregiver_availability (caregiver_id, weekday, start_time, end_time)
+			VALUES (?, ?, ?, ?)`, availability.CaregiverID, w.Weekday, w.StartTime, w.EndTime)
+		if err != nil {
+			return fmt.Errorf("failed to save availability window: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit caregiver availability: %w", err)
+	}
+
+	now := time.Now()
+	availability.UpdatedAt = &now
+	return nil
+}
+
+// GetCaregiverIDs lists every caregiver known to the agency: those who declared
+// availability, a profile or skills, and those assigned to a schedule
+func (r *availabilityRepository) GetCaregiverIDs() ([]int, error) {
+	query := `
+		SELECT caregiver_id FROM caregiver_preferences
+		UNION
+		SELECT caregiver_id FROM caregiver_profiles
+		UNION
+		SELECT caregiver_id FROM caregiver_skills
+		UNION
+		SELECT caregiver_id FROM schedule_caregivers
+		ORDER BY caregiver_id ASC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query caregivers: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan caregiver id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type carePlanRepository struct {
+	db *sql.DB
+}
+
+// NewCarePlanRepository creates a new care plan repository
+func NewCarePlanRepository(db *sql.DB) CarePlanRepository {
+	return &carePlanRepository{db: db}
+}
+
+// GetByID retrieves a care plan version with its items
+func (r *carePlanRepository) GetByID(id int) (*models.CarePlan, error) {
+	return r.get("SELECT id, client_id, version, notes, created_at FROM care_plans WHERE id = ?", id)
+}
+
+// GetCurrent retrieves the latest version of a client's care plan with its
+// items, or nil when the client has none
+func (r *carePlanRepository) GetCurrent(clientID int) (*models.CarePlan, error) {
+	return r.get(`
+		SELECT id, client_id, version, notes, created_at FROM care_plans
+		WHERE client_id = ? ORDER BY version DESC LIMIT 1`, clientID)
+}
+
+// get retrieves a single care plan version with its items
+func (r *carePlanRepository) get(query string, arg interface{}) (*models.CarePlan, error) {
+	var plan models.CarePlan
+	err := r.db.QueryRow(query, arg).Scan(&plan.ID, &plan.ClientID, &plan.Version, &plan.Notes, &plan.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get care plan: %w", err)
+	}
+
+	if plan.Items, err = r.items(plan.ID); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetVersions retrieves every version of a client's care plan, newest first,
+// with their items
+func (r *carePlanRepository) GetVersions(clientID int) ([]models.CarePlan, error) {
+	rows, err := r.db.Query(`
+		SELECT id, client_id, version, notes, created_at FROM care_plans
+		WHERE client_id = ? ORDER BY version DESC`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query care plans: %w", err)
+	}
+	defer rows.Close()
+
+	var plans []models.CarePlan
+	for rows.Next() {
+		var plan models.CarePlan
+		if err := rows.Scan(&plan.ID, &plan.ClientID, &plan.Version, &plan.Notes, &plan.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan care plan: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read care plans: %w", err)
+	}
+
+	for i := range plans {
+		if plans[i].Items, err = r.items(plans[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return plans, nil
+}
+
+// items retrieves the items of a care plan version in order
+func (r *carePlanRepository) items(carePlanID int) ([]models.CarePlanItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, care_plan_id, template_id, title, frequency, weekdays, instructions, required
+		FROM care_plan_items WHERE care_plan_id = ? ORDER BY position ASC`, carePlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query care plan items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.CarePlanItem{}
+	for rows.Next() {
+		var item models.CarePlanItem
+		var weekdays string
+		if err := rows.Scan(&item.ID, &item.CarePlanID, &item.TemplateID, &item.Title, &item.Frequency,
+			&weekdays, &item.Instructions, &item.Required); err != nil {
+			return nil, fmt.Errorf("failed to scan care plan item: %w", err)
+		}
+		for _, day := range splitList(weekdays) {
+			weekday, err := strconv.Atoi(day)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read weekday %q of care plan item %d: %w", day, item.ID, err)
+			}
+			item.Weekdays = append(item.Weekdays, weekday)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Create saves a new version of a client's care plan with its items. It
+// returns ErrVersionConflict when the client already has this version or a
+// later one.
+func (r *carePlanRepository) Create(plan *models.CarePlan) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO care_plans (client_id, version, notes)
+		SELECT ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM care_plans WHERE client_id = ? AND version >= ?)`,
+		plan.ClientID, plan.Version, plan.Notes, plan.ClientID, plan.Version)
+	if err != nil {
+		return fmt.Errorf("failed to create care plan: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to create version %d of client %d care plan: %w", plan.Version, plan.ClientID, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		weekdays := make([]string, len(item.Weekdays))
+		for j, weekday := range item.Weekdays {
+			weekdays[j] = strconv.Itoa(weekday)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO care_plan_items (care_plan_id, template_id, position, title, frequency, weekdays, instructions, required)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, item.TemplateID, i+1, item.Title, item.Frequency, joinList(weekdays), item.Instructions, item.Required)
+		if err != nil {
+			return fmt.Errorf("failed to create care plan item: %w", err)
+		}
+		itemID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		item.ID = int(itemID)
+		item.CarePlanID = int(id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit care plan: %w", err)
+	}
+
+	plan.ID = int(id)
+	plan.CreatedAt = time.Now()
+	return nil
+}
+
+// PlannedTemplates retrieves the templates of the tasks on a client's
+// schedules starting within [from, to), leaving out cancelled schedules
+func (r *carePlanRepository) PlannedTemplates(clientID int, from, to time.Time) ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT t.template_id
+		FROM tasks t
+		JOIN schedules s ON s.id = t.schedule_id
+		WHERE s.client_id = ? AND s.status != 'cancelled' AND s.start_time >= ? AND s.start_time < ?
+		  AND t.template_id IS NOT NULL`,
+		clientID, from.UTC().Format("2006-01-02 15:04:05"), to.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query planned templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templateIDs []int
+	for rows.Next() {
+		var templateID int
+		if err := rows.Scan(&templateID); err != nil {
+			return nil, fmt.Errorf("failed to scan planned template: %w", err)
+		}
+		templateIDs = append(templateIDs, templateID)
+	}
+
+	return templateIDs, nil
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type caregiverProfileRepository struct {
+	db *sql.DB
+}
+
+// NewCaregiverProfileRepository creates a new caregiver profile repository
+func NewCaregiverProfileRepository(db *sql.DB) CaregiverProfileRepository {
+	return &caregiverProfileRepository{db: db}
+}
+
+// GetByCaregiverID retrieves a caregiver's profile
+func (r *caregiverProfileRepository) GetByCaregiverID(caregiverID int) (*models.CaregiverProfile, error) {
+	query := `
+		SELECT caregiver_id, gender, languages, updated_at
+		FROM caregiver_profiles
+		WHERE caregiver_id = ?`
+
+	var p models.CaregiverProfile
+	var languages string
+	err := r.db.QueryRow(query, caregiverID).Scan(&p.CaregiverID, &p.Gender, &languages, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get caregiver profile: %w", err)
+	}
+	p.Languages = splitList(languages)
+
+	return &p, nil
+}
+
+// Replace stores a caregiver's profile, overwriting any earlier one
+func (r *caregiverProfileRepository) Replace(profile *models.CaregiverProfile) error {
+	_, err := r.db.Exec(`
+		INSERT INTO caregiver_profiles (caregiver_id, gender, languages, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (caregiver_id) DO UPDATE SET
+		    gender = excluded.gender,
+		    languages = excluded.languages,
+		    updated_at = excluded.updated_at`,
+		profile.CaregiverID, profile.Gender, joinList(profile.Languages))
+	if err != nil {
+		return fmt.Errorf("failed to save caregiver profile: %w", err)
+	}
+
+	now := time.Now()
+	profile.UpdatedAt = &now
+	return nil
+}
+
+// joinList stores a list of short codes in a single comma-separated column
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+// splitList reads a column written by joinList; an empty column is an empty list
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
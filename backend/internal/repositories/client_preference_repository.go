@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type clientPreferenceRepository struct {
+	db *sql.DB
+}
+
+// NewClientPreferenceRepository creates a new client preference repository
+func NewClientPreferenceRepository(db *sql.DB) ClientPreferenceRepository {
+	return &clientPreferenceRepository{db: db}
+}
+
+// GetByClientID retrieves a client's caregiver preferences and blocked caregivers
+func (r *clientPreferenceRepository) GetByClientID(clientID int) (*models.ClientPreferences, error) {
+	query := `
+		SELECT client_id, preferred_gender, languages, updated_at
+		FROM client_preferences
+		WHERE client_id = ?`
+
+	var p models.ClientPreferences
+	var languages string
+	err := r.db.QueryRow(query, clientID).Scan(&p.ClientID, &p.PreferredGender, &languages, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get client preferences: %w", err)
+	}
+	p.Languages = splitList(languages)
+
+	rows, err := r.db.Query(`
+		SELECT caregiver_id, reason
+		FROM client_blocked_caregivers
+		WHERE client_id = ?
+		ORDER BY caregiver_id ASC`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocked caregivers: %w", err)
+	}
+	defer rows.Close()
+
+	p.BlockedCaregivers = []models.BlockedCaregiver{}
+	for rows.Next() {
+		var b models.BlockedCaregiver
+		var reason sql.NullString
+		if err := rows.Scan(&b.CaregiverID, &reason); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked caregiver: %w", err)
+		}
+		b.Reason = reason.String
+		p.BlockedCaregivers = append(p.BlockedCaregivers, b)
+	}
+
+	return &p, nil
+}
+
+// Replace stores a client's caregiver preferences and replaces all of their
+// blocked caregivers
+func (r *clientPreferenceRepository) Replace(preferences *models.ClientPreferences) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO client_preferences (client_id, preferred_gender, languages, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (client_id) DO UPDATE SET
+		    preferred_gender = excluded.preferred_gender,
+		    languages = excluded.languages,
+		    updated_at = excluded.updated_at`,
+		preferences.ClientID, preferences.PreferredGender, joinList(preferences.Languages))
+	if err != nil {
+		return fmt.Errorf("failed to save client preferences: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM client_blocked_caregivers WHERE client_id = ?", preferences.ClientID); err != nil {
+		return fmt.Errorf("failed to clear blocked caregivers: %w", err)
+	}
+	for _, b := range preferences.BlockedCaregivers {
+		_, err := tx.Exec(`
+			INSERT INTO client_blocked_caregivers (client_id, caregiver_id, reason)
+			VALUES (?, ?, ?)`, preferences.ClientID, b.CaregiverID, b.Reason)
+		if err != nil {
+			return fmt.Errorf("failed to save blocked caregiver: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit client preferences: %w", err)
+	}
+
+	now := time.Now()
+	preferences.UpdatedAt = &now
+	return nil
+}
@@ -4,7 +4,7 @@ import (
 	"caregiver-shift-tracker/internal/models"
 	"database/sql"
 	"fmt"
-	"strings"
+	"strconv"
 	"time"
 )
 
@@ -17,81 +17,146 @@ func NewClientRepository(db *sql.DB) ClientRepository {
 	return &clientRepository{db: db}
 }
 
-// GetAll retrieves all clients with optional filtering
-func (r *clientRepository) GetAll(filter *models.ClientFilter) ([]models.Client, error) {
-	query := `
-		SELECT id, name, email, phone, address, city, state, zip_code, latitude, longitude, notes, is_active, created_at, updated_at
-		FROM clients 
-		WHERE 1=1`
+// clientSorts whitelists the fields clients can be sorted by
+var clientSorts = sortSpec{
+	fields: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"city":       "city",
+		"state":      "state",
+		"created_at": "created_at",
+	},
+	defaultField: "name",
+	idColumn:     "id",
+}
+
+// earthRadiusKm is the mean radius of the Earth used for client distances
+const earthRadiusKm = 6371.0
+
+// clientDistance returns the SQL expression of a client's great-circle
+// distance in kilometres from a point. It is rounded to the metre so that the
+// distance survives the round trip through a pagination cursor.
+func clientDistance(point models.GeoPoint) string {
+	lat := strconv.FormatFloat(point.Latitude, 'g', -1, 64)
+	lon := strconv.FormatFloat(point.Longitude, 'g', -1, 64)
+	return fmt.Sprintf("CAST(ROUND(2 * %g * asin(min(1, sqrt("+
+		"pow(sin(radians(latitude - %s) / 2), 2) + "+
+		"cos(radians(%s)) * cos(radians(latitude)) * pow(sin(radians(longitude - %s) / 2), 2)))), 3) AS REAL)",
+		earthRadiusKm, lat, lat, lon)
+}
+
+// GetAll retrieves clients with optional filtering, sorting and cursor pagination
+func (r *clientRepository) GetAll(filter *models.ClientFilter) ([]models.Client, *models.PageInfo, error) {
+	if filter == nil {
+		filter = &models.ClientFilter{}
+	}
+
+	sorts := clientSorts
+	distance := "NULL"
+	if filter.Near != nil {
+		distance = clientDistance(*filter.Near)
+		sorts = clientSorts.withField("distance", distance)
+	}
+
+	keys, err := sorts.keyset(filter.ListOptions)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	where := " WHERE 1=1"
 	var args []interface{}
 	argIndex := 1
 
-	if filter != nil {
-		if filter.IsActive != nil {
-			query += fmt.Sprintf(" AND is_active = ?%d", argIndex)
-			args = append(args, *filter.IsActive)
-			argIndex++
-		}
-
-		if filter.City != nil {
-			query += fmt.Sprintf(" AND LOWER(city) = LOWER(?%d)", argIndex)
-			args = append(args, *filter.City)
-			argIndex++
-		}
+	if filter.Near != nil {
+		// The spatial index narrows the clients down to a bounding box
+		latDelta, lonDelta := boundingBox(filter.Near.Latitude, filter.RadiusKm)
+		where += fmt.Sprintf(" AND id IN (SELECT id FROM clients_index WHERE min_lat <= ?%d AND max_lat >= ?%d AND min_lon <= ?%d AND max_lon >= ?%d)",
+			argIndex, argIndex+1, argIndex+2, argIndex+3)
+		args = append(args, filter.Near.Latitude+latDelta, filter.Near.Latitude-latDelta,
+			filter.Near.Longitude+lonDelta, filter.Near.Longitude-lonDelta)
+		argIndex += 4
+
+		where += fmt.Sprintf(" AND %s <= ?%d", distance, argIndex)
+		args = append(args, filter.RadiusKm)
+		argIndex++
+	}
 
-		if filter.State != nil {
-			query += fmt.Sprintf(" AND LOWER(state) = LOWER(?%d)", argIndex)
-			args = append(args, *filter.State)
-			argIndex++
-		}
+	if filter.IsActive != nil {
+		where += fmt.Sprintf(" AND is_active = ?%d", argIndex)
+		args = append(args, *filter.IsActive)
+		argIndex++
+	}
 
-		if filter.Search != nil {
-			query += fmt.Sprintf(" AND (LOWER(name) LIKE LOWER(?%d) OR LOWER(email) LIKE LOWER(?%d) OR phone LIKE ?%d)", argIndex, argIndex+1, argIndex+2)
-			searchTerm := "%" + *filter.Search + "%"
-			args = append(args, searchTerm, searchTerm, searchTerm)
-			argIndex += 3
-		}
+	if filter.City != nil {
+		where += fmt.Sprintf(" AND LOWER(city) = LOWER(?%d)", argIndex)
+		args = append(args, *filter.City)
+		argIndex++
 	}
 
-	query += " ORDER BY name ASC"
+	if filter.State != nil {
+		where += fmt.Sprintf(" AND LOWER(state) = LOWER(?%d)", argIndex)
+		args = append(args, *filter.State)
+		argIndex++
+	}
 
-	if filter != nil {
-		if filter.Limit != nil {
-			query += fmt.Sprintf(" LIMIT ?%d", argIndex)
-			args = append(args, *filter.Limit)
-			argIndex++
-		}
+	if filter.Search != nil {
+		where += fmt.Sprintf(" AND (LOWER(name) LIKE LOWER(?%d) OR LOWER(email) LIKE LOWER(?%d) OR phone LIKE ?%d)", argIndex, argIndex+1, argIndex+2)
+		searchTerm := "%" + *filter.Search + "%"
+		args = append(args, searchTerm, searchTerm, searchTerm)
+		argIndex += 3
+	}
 
-		if filter.Offset != nil {
-			query += fmt.Sprintf(" OFFSET ?%d", argIndex)
-			args = append(args, *filter.Offset)
-			argIndex++
+	page := &models.PageInfo{}
+	if filter.IncludeTotal {
+		total, err := r.count(where, args)
+		if err != nil {
+			return nil, nil, err
 		}
+		page.Total = &total
 	}
 
-	// Replace numbered placeholders with actual ? placeholders for SQLite
-	for i := len(args); i >= 1; i-- {
-		query = strings.ReplaceAll(query, fmt.Sprintf("?%d", i), "?")
+	query := `
+		SELECT id, name, email, phone, address, city, state, zip_code, latitude, longitude, notes, timezone, is_active, version, created_at, updated_at,
+		       geocode_match, geocode_confidence, geocoded_at, ` + distance + `,
+		       ` + keys.selectKey() + `
+		FROM clients` + where
+
+	condition, cursorArgs := keys.condition(argIndex)
+	query += condition
+	args = append(args, cursorArgs...)
+	argIndex += len(cursorArgs)
+
+	query += keys.orderBy()
+
+	// Fetch one extra row to find out whether there is a next page
+	if filter.Limit != nil {
+		query += fmt.Sprintf(" LIMIT ?%d", argIndex)
+		args = append(args, *filter.Limit+1)
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.Query(bindPlaceholders(query, len(args)), args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query clients: %w", err)
+		return nil, nil, fmt.Errorf("failed to query clients: %w", err)
 	}
 	defer rows.Close()
 
 	var clients []models.Client
+	var sortKeys []string
+	var ids []int
 	for rows.Next() {
 		var c models.Client
 		var email, phone, notes sql.NullString
+		var distanceKm sql.NullFloat64
+		var sortKey string
 
 		err := rows.Scan(
 			&c.ID, &c.Name, &email, &phone, &c.Address, &c.City, &c.State, &c.ZipCode,
-			&c.Latitude, &c.Longitude, &notes, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+			&c.Latitude, &c.Longitude, &notes, &c.Timezone, &c.IsActive, &c.Version, &c.CreatedAt, &c.UpdatedAt,
+			&c.GeocodeMatch, &c.GeocodeConfidence, &c.GeocodedAt, &distanceKm,
+			&sortKey,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan client: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan client: %w", err)
 		}
 
 		if email.Valid {
@@ -103,17 +168,41 @@ func (r *clientRepository) GetAll(filter *models.ClientFilter) ([]models.Client,
 		if notes.Valid {
 			c.Notes = notes.String
 		}
+		if distanceKm.Valid {
+			c.DistanceKm = &distanceKm.Float64
+		}
 
 		clients = append(clients, c)
+		sortKeys = append(sortKeys, sortKey)
+		ids = append(ids, c.ID)
+	}
+
+	if filter.Limit != nil {
+		keys.fillPage(page, *filter.Limit, sortKeys, ids)
+		if len(clients) > *filter.Limit {
+			clients = clients[:*filter.Limit]
+		}
 	}
 
-	return clients, nil
+	return clients, page, nil
+}
+
+// count returns the number of clients matching a WHERE clause built by GetAll
+func (r *clientRepository) count(where string, args []interface{}) (int, error) {
+	query := "SELECT COUNT(*) FROM clients" + where
+
+	var total int
+	if err := r.db.QueryRow(bindPlaceholders(query, len(args)), args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count clients: %w", err)
+	}
+	return total, nil
 }
 
 // GetByID retrieves a client by ID
 func (r *clientRepository) GetByID(id int) (*models.Client, error) {
 	query := `
-		SELECT id, name, email, phone, address, city, state, zip_code, latitude, longitude, notes, is_active, created_at, updated_at
+		SELECT id, name, email, phone, address, city, state, zip_code, latitude, longitude, notes, timezone, is_active, version, created_at, updated_at,
+		       geocode_match, geocode_confidence, geocoded_at
 		FROM clients 
 		WHERE id = ?`
 
@@ -122,7 +211,8 @@ func (r *clientRepository) GetByID(id int) (*models.Client, error) {
 
 	err := r.db.QueryRow(query, id).Scan(
 		&c.ID, &c.Name, &email, &phone, &c.Address, &c.City, &c.State, &c.ZipCode,
-		&c.Latitude, &c.Longitude, &notes, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+		&c.Latitude, &c.Longitude, &notes, &c.Timezone, &c.IsActive, &c.Version, &c.CreatedAt, &c.UpdatedAt,
+		&c.GeocodeMatch, &c.GeocodeConfidence, &c.GeocodedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -144,15 +234,51 @@ func (r *clientRepository) GetByID(id int) (*models.Client, error) {
 	return &c, nil
 }
 
+// phoneDigits strips the separators clients' phone numbers are written with,
+// leaving the digits to compare with a caller ID
+const phoneDigits = `REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(phone, ' ', ''), '-', ''), '(', ''), ')', ''), '.', ''), '+', '')`
+
+// GetByPhone retrieves the active client with a phone number, compared by its
+// digits only so that "+62 21-555 0101" matches a caller ID of +62215550101
+func (r *clientRepository) GetByPhone(phone string) (*models.Client, error) {
+	var digits []byte
+	for i := 0; i < len(phone); i++ {
+		if phone[i] >= '0' && phone[i] <= '9' {
+			digits = append(digits, phone[i])
+		}
+	}
+	if len(digits) == 0 {
+		return nil, nil
+	}
+
+	var id int
+	err := r.db.QueryRow(`
+		SELECT id
+		FROM clients
+		WHERE is_active = 1 AND phone IS NOT NULL AND `+phoneDigits+` = ?
+		ORDER BY id ASC
+		LIMIT 1`, string(digits)).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get client by phone: %w", err)
+	}
+
+	return r.GetByID(id)
+}
+
 // Create creates a new client
 func (r *clientRepository) Create(client *models.Client) error {
 	query := `
-		INSERT INTO clients (name, email, phone, address, city, state, zip_code, latitude, longitude, notes, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		INSERT INTO clients (name, email, phone, address, city, state, zip_code, latitude, longitude, notes, timezone, is_active,
+		                     geocode_match, geocode_confidence, geocoded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.db.Exec(query, client.Name, client.Email, client.Phone, client.Address,
 		client.City, client.State, client.ZipCode, client.Latitude, client.Longitude,
-		client.Notes, client.IsActive)
+		client.Notes, client.Timezone, client.IsActive,
+		client.GeocodeMatch, client.GeocodeConfidence, nullableTime(client.GeocodedAt))
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -163,6 +289,7 @@ func (r *clientRepository) Create(client *models.Client) error {
 	}
 
 	client.ID = int(id)
+	client.Version = 1
 	client.CreatedAt = time.Now()
 	client.UpdatedAt = time.Now()
 	return nil
@@ -173,16 +300,24 @@ func (r *clientRepository) Update(client *models.Client) error {
 	query := `
 		UPDATE clients 
 		SET name = ?, email = ?, phone = ?, address = ?, city = ?, state = ?, zip_code = ?, 
-		    latitude = ?, longitude = ?, notes = ?, is_active = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`
+		    latitude = ?, longitude = ?, notes = ?, timezone = ?, is_active = ?,
+		    geocode_match = ?, geocode_confidence = ?, geocoded_at = ?,
+		    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
 
-	_, err := r.db.Exec(query, client.Name, client.Email, client.Phone, client.Address,
+	result, err := r.db.Exec(query, client.Name, client.Email, client.Phone, client.Address,
 		client.City, client.State, client.ZipCode, client.Latitude, client.Longitude,
-		client.Notes, client.IsActive, client.ID)
+		client.Notes, client.Timezone, client.IsActive,
+		client.GeocodeMatch, client.GeocodeConfidence, nullableTime(client.GeocodedAt), client.ID, client.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update client: %w", err)
 	}
 
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update client %d: %w", client.ID, err)
+	}
+
+	client.Version++
 	client.UpdatedAt = time.Now()
 	return nil
 }
@@ -213,5 +348,6 @@ func (r *clientRepository) Search(query string) ([]models.Client, error) {
 	filter := &models.ClientFilter{
 		Search: &query,
 	}
-	return r.GetAll(filter)
+	clients, _, err := r.GetAll(filter)
+	return clients, err
 }
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type clientVerificationTokenRepository struct {
+	db *sql.DB
+}
+
+// NewClientVerificationTokenRepository creates a new client verification token repository
+func NewClientVerificationTokenRepository(db *sql.DB) ClientVerificationTokenRepository {
+	return &clientVerificationTokenRepository{db: db}
+}
+
+// GetByClientID retrieves the verification token of a client
+func (r *clientVerificationTokenRepository) GetByClientID(clientID int) (*models.ClientVerificationToken, error) {
+	query := `
+		SELECT client_id, secret, created_at
+		FROM client_verification_tokens
+		WHERE client_id = ?`
+
+	var t models.ClientVerificationToken
+	err := r.db.QueryRow(query, clientID).Scan(&t.ClientID, &t.Secret, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get client verification token: %w", err)
+	}
+
+	return &t, nil
+}
+
+// Replace stores a client's verification token, overwriting any earlier one
+func (r *clientVerificationTokenRepository) Replace(token *models.ClientVerificationToken) error {
+	_, err := r.db.Exec(`
+		INSERT INTO client_verification_tokens (client_id, secret, created_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (client_id) DO UPDATE SET
+		    secret = excluded.secret,
+		    created_at = excluded.created_at`,
+		token.ClientID, token.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to save client verification token: %w", err)
+	}
+
+	token.CreatedAt = time.Now()
+	return nil
+}
+
+// Delete removes a client's verification token
+func (r *clientVerificationTokenRepository) Delete(clientID int) error {
+	if _, err := r.db.Exec("DELETE FROM client_verification_tokens WHERE client_id = ?", clientID); err != nil {
+		return fmt.Errorf("failed to delete client verification token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type clockEventRepository struct {
+	db *sql.DB
+}
+
+// NewClockEventRepository creates a new clock event repository
+func NewClockEventRepository(db *sql.DB) ClockEventRepository {
+	return &clockEventRepository{db: db}
+}
+
+// clockEventColumns lists the columns read by scanClockEvent
+const clockEventColumns = `id, schedule_id, caregiver_id, client_id, event, latitude, longitude, accuracy, mock_location,
+		recorded_at, risk_score, signals, created_at, verification_method`
+
+// coordinatePrecision is the number of decimals at which two clock event
+// locations count as identical, about 10 cm
+const coordinatePrecision = 6
+
+// scanClockEvent reads a row selected with clockEventColumns
+func scanClockEvent(row rowScanner) (*models.ClockEvent, error) {
+	var e models.ClockEvent
+	var accuracy sql.NullFloat64
+	var signals string
+	if err := row.Scan(
+		&e.ID, &e.ScheduleID, &e.CaregiverID, &e.ClientID, &e.Event, &e.Latitude, &e.Longitude, &accuracy, &e.MockLocation,
+		&e.RecordedAt, &e.RiskScore, &signals, &e.CreatedAt, &e.VerificationMethod,
+	); err != nil {
+		return nil, err
+	}
+
+	if accuracy.Valid {
+		a := accuracy.Float64
+		e.Accuracy = &a
+	}
+	if err := json.Unmarshal([]byte(signals), &e.Signals); err != nil {
+		return nil, fmt.Errorf("invalid risk signals: %w", err)
+	}
+	return &e, nil
+}
+
+// GetByScheduleID retrieves the clock events of every caregiver on a schedule in the order they happened
+func (r *clockEventRepository) GetByScheduleID(scheduleID int) ([]models.ClockEvent, error) {
+	query := "SELECT " + clockEventColumns + `
+		FROM clock_events
+		WHERE schedule_id = ?
+		ORDER BY recorded_at ASC, id ASC`
+
+	rows, err := r.db.Query(query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clock events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.ClockEvent
+	for rows.Next() {
+		e, err := scanClockEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clock event: %w", err)
+		}
+		events = append(events, *e)
+	}
+
+	return events, nil
+}
+
+// GetLastByCaregiver retrieves a caregiver's latest clock event with a
+// location at or before a time
+func (r *clockEventRepository) GetLastByCaregiver(caregiverID int, before time.Time) (*models.ClockEvent, error) {
+	query := "SELECT " + clockEventColumns + `
+		FROM clock_events
+		WHERE caregiver_id = ? AND recorded_at <= ? AND verification_method NOT IN ('token', 'telephony')
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1`
+
+	e, err := scanClockEvent(r.db.QueryRow(query, caregiverID, before.UTC().Format("2006-01-02 15:04:05")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get clock event: %w", err)
+	}
+
+	return e, nil
+}
+
+// CountClientsAt counts the other clients with a clock event since a time at
+// the same coordinates, ignoring events without a location, compared to coordinatePrecision decimals
+func (r *clockEventRepository) CountClientsAt(latitude, longitude float64, excludeClientID int, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(DISTINCT client_id)
+		FROM clock_events
+		WHERE ROUND(latitude, ?) = ROUND(?, ?) AND ROUND(longitude, ?) = ROUND(?, ?)
+		  AND client_id != ? AND recorded_at >= ? AND verification_method NOT IN ('token', 'telephony')`
+
+	var count int
+	err := r.db.QueryRow(query, coordinatePrecision, latitude, coordinatePrecision, coordinatePrecision, longitude, coordinatePrecision,
+		excludeClientID, since.UTC().Format("2006-01-02 15:04:05")).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count clients at location: %w", err)
+	}
+
+	return count, nil
+}
+
+// Create stores a clock event with its risk assessment
+func (r *clockEventRepository) Create(event *models.ClockEvent) error {
+	signals, err := json.Marshal(event.Signals)
+	if err != nil {
+		return fmt.Errorf("failed to encode risk signals: %w", err)
+	}
+	if event.Signals == nil {
+		signals = []byte("[]")
+	}
+
+	method := event.VerificationMethod
+	if method == "" {
+		method = models.VerificationMethodGPS
+	}
+
+	query := `
+		INSERT INTO clock_events (schedule_id, caregiver_id, client_id, event, latitude, longitude, accuracy, mock_location,
+		    recorded_at, risk_score, signals, verification_method)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, event.ScheduleID, event.CaregiverID, event.ClientID, event.Event, event.Latitude, event.Longitude,
+		event.Accuracy, event.MockLocation, event.RecordedAt.UTC().Format("2006-01-02 15:04:05"), event.RiskScore, string(signals),
+		method)
+	if err != nil {
+		return fmt.Errorf("failed to create clock event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get clock event ID: %w", err)
+	}
+
+	event.ID = int(id)
+	event.VerificationMethod = method
+	event.CreatedAt = time.Now()
+	return nil
+}
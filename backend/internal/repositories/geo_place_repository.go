@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// kmPerDegree is the length of a degree of latitude, used to turn a search
+// radius into a bounding box for the spatial index
+const kmPerDegree = 111.32
+
+// boundingBox returns how far in degrees of latitude and longitude a search
+// radius around a latitude reaches
+func boundingBox(latitude, radiusKm float64) (latDelta, lonDelta float64) {
+	// Degrees of longitude shrink towards the poles
+	lonScale := math.Max(math.Cos(latitude*math.Pi/180), 0.01)
+	return radiusKm / kmPerDegree, math.Min(radiusKm/(kmPerDegree*lonScale), 180)
+}
+
+type geoPlaceRepository struct {
+	db *sql.DB
+}
+
+// NewGeoPlaceRepository creates a new geographic place repository
+func NewGeoPlaceRepository(db *sql.DB) GeoPlaceRepository {
+	return &geoPlaceRepository{db: db}
+}
+
+// Upsert stores places, replacing the ones already imported with the same
+// source and source ID
+func (r *geoPlaceRepository) Upsert(places []models.GeoPlace) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO geo_places (source, source_id, name, street, locality, region, postcode, country_code, latitude, longitude)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, source_id) DO UPDATE SET
+		    name = excluded.name,
+		    street = excluded.street,
+		    locality = excluded.locality,
+		    region = excluded.region,
+		    postcode = excluded.postcode,
+		    country_code = excluded.country_code,
+		    latitude = excluded.latitude,
+		    longitude = excluded.longitude`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare place insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range places {
+		if _, err := stmt.Exec(p.Source, p.SourceID, p.Name, p.Street, p.Locality, p.Region, p.Postcode, p.CountryCode,
+			p.Latitude, p.Longitude); err != nil {
+			return fmt.Errorf("failed to save place %s/%s: %w", p.Source, p.SourceID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit places: %w", err)
+	}
+	return nil
+}
+
+// FindNearest retrieves up to limit places in the bounding box of radiusKm
+// around a location, roughly nearest first. Callers measure the exact
+// distances.
+func (r *geoPlaceRepository) FindNearest(latitude, longitude, radiusKm float64, limit int) ([]models.GeoPlace, error) {
+	latDelta, lonDelta := boundingBox(latitude, radiusKm)
+	lonScale := math.Max(math.Cos(latitude*math.Pi/180), 0.01)
+
+	rows, err := r.db.Query(`
+		SELECT p.id, p.source, p.source_id, p.name, p.street, p.locality, p.region, p.postcode, p.country_code,
+		       p.latitude, p.longitude
+		FROM geo_places_index i
+		JOIN geo_places p ON p.id = i.id
+		WHERE i.min_lat <= ? AND i.max_lat >= ? AND i.min_lon <= ? AND i.max_lon >= ?
+		ORDER BY (p.latitude - ?) * (p.latitude - ?) + (p.longitude - ?) * (p.longitude - ?) * ? ASC
+		LIMIT ?`,
+		latitude+latDelta, latitude-latDelta, longitude+lonDelta, longitude-lonDelta,
+		latitude, latitude, longitude, longitude, lonScale*lonScale, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places: %w", err)
+	}
+	defer rows.Close()
+
+	var places []models.GeoPlace
+	for rows.Next() {
+		var p models.GeoPlace
+		if err := rows.Scan(&p.ID, &p.Source, &p.SourceID, &p.Name, &p.Street, &p.Locality, &p.Region, &p.Postcode,
+			&p.CountryCode, &p.Latitude, &p.Longitude); err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+		places = append(places, p)
+	}
+
+	return places, nil
+}
+
+// FindInArea retrieves up to limit places in a postcode or a locality, by
+// name, and whose street contains street when it is not empty
+func (r *geoPlaceRepository) FindInArea(locality, postcode, street string, limit int) ([]models.GeoPlace, error) {
+	rows, err := r.db.Query(`
+		SELECT id, source, source_id, name, street, locality, region, postcode, country_code, latitude, longitude
+		FROM geo_places
+		WHERE ((?1 != '' AND postcode = ?1) OR (?2 != '' AND locality = ?2 COLLATE NOCASE))
+		  AND (?3 = '' OR street LIKE '%' || ?3 || '%')
+		ORDER BY id ASC
+		LIMIT ?4`, postcode, locality, street, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places: %w", err)
+	}
+	defer rows.Close()
+
+	var places []models.GeoPlace
+	for rows.Next() {
+		var p models.GeoPlace
+		if err := rows.Scan(&p.ID, &p.Source, &p.SourceID, &p.Name, &p.Street, &p.Locality, &p.Region, &p.Postcode,
+			&p.CountryCode, &p.Latitude, &p.Longitude); err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+		places = append(places, p)
+	}
+
+	return places, nil
+}
+
+// GetSource retrieves the last import of a place dataset, nil when it was never imported
+func (r *geoPlaceRepository) GetSource(source string) (*models.GeoPlaceSource, error) {
+	var src models.GeoPlaceSource
+	err := r.db.QueryRow(`
+		SELECT source, format, file_size, file_modified_at, imported, skipped, imported_at
+		FROM geo_place_sources
+		WHERE source = ?`, source).
+		Scan(&src.Source, &src.Format, &src.FileSize, &src.FileModifiedAt, &src.Imported, &src.Skipped, &src.ImportedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get place source: %w", err)
+	}
+
+	return &src, nil
+}
+
+// SaveSource records the import of a place dataset, replacing the previous one
+func (r *geoPlaceRepository) SaveSource(src *models.GeoPlaceSource) error {
+	_, err := r.db.Exec(`
+		INSERT INTO geo_place_sources (source, format, file_size, file_modified_at, imported, skipped, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source) DO UPDATE SET
+		    format = excluded.format,
+		    file_size = excluded.file_size,
+		    file_modified_at = excluded.file_modified_at,
+		    imported = excluded.imported,
+		    skipped = excluded.skipped,
+		    imported_at = excluded.imported_at`,
+		src.Source, src.Format, src.FileSize, src.FileModifiedAt.UTC().Format("2006-01-02 15:04:05"),
+		src.Imported, src.Skipped, src.ImportedAt.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return fmt.Errorf("failed to save place source: %w", err)
+	}
+	return nil
+}
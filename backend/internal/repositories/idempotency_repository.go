@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Get retrieves a stored idempotency record by key
+func (r *idempotencyRepository) Get(key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT key, method, path, request_hash, status_code, response_body, response_headers, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = ?`
+
+	var rec models.IdempotencyRecord
+	var statusCode sql.NullInt64
+	var headers string
+	err := r.db.QueryRow(query, key).Scan(&rec.Key, &rec.Method, &rec.Path, &rec.RequestHash,
+		&statusCode, &rec.ResponseBody, &headers, &rec.CreatedAt, &rec.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		rec.StatusCode = &code
+	}
+	if err := json.Unmarshal([]byte(headers), &rec.ResponseHeaders); err != nil {
+		return nil, fmt.Errorf("invalid idempotent response headers: %w", err)
+	}
+
+	return &rec, nil
+}
+
+// Reserve stores a new in-flight record; it returns false when the key is already taken
+func (r *idempotencyRepository) Reserve(record *models.IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT OR IGNORE INTO idempotency_keys (key, method, path, request_hash, expires_at)
+		VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, record.Key, record.Method, record.Path, record.RequestHash,
+		record.ExpiresAt.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// Complete stores the response produced for a reserved key
+func (r *idempotencyRepository) Complete(key string, statusCode int, headers map[string]string, responseBody []byte) error {
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response headers: %w", err)
+	}
+	if headers == nil {
+		encoded = []byte("{}")
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = ?, response_headers = ?, response_body = ?
+		WHERE key = ?`
+
+	if _, err := r.db.Exec(query, statusCode, string(encoded), responseBody, key); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an idempotency key
+func (r *idempotencyRepository) Delete(key string) error {
+	if _, err := r.db.Exec("DELETE FROM idempotency_keys WHERE key = ?", key); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes all keys that expired before now and returns how many were removed
+func (r *idempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= ?", now.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
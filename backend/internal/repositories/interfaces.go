@@ -2,13 +2,26 @@ package repositories
 
 import (
 	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"time"
 )
 
+// ErrVersionConflict is returned by Update methods when the stored row no
+// longer has the version the caller read
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrInvalidCursor is returned by list methods when a pagination cursor is
+// malformed or was issued for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidSort is returned by list methods when sorting by a field that is
+// not whitelisted
+var ErrInvalidSort = errors.New("invalid sort field")
+
 // ScheduleRepository defines the interface for schedule data access
 type ScheduleRepository interface {
-	GetAll(filter *models.ScheduleFilter) ([]models.Schedule, error)
+	GetAll(filter *models.ScheduleFilter) ([]models.Schedule, *models.PageInfo, error)
 	GetByID(id int) (*models.Schedule, error)
-	GetToday(caregiverID int) ([]models.Schedule, error)
 	GetStats(caregiverID int) (*models.ScheduleStats, error)
 	Create(schedule *models.Schedule) error
 	Update(schedule *models.Schedule) error
@@ -18,11 +31,21 @@ type ScheduleRepository interface {
 // VisitRepository defines the interface for visit data access
 type VisitRepository interface {
 	GetByScheduleID(scheduleID int) (*models.Visit, error)
+	GetAllByScheduleID(scheduleID int) ([]models.Visit, error)
+	GetByScheduleAndCaregiver(scheduleID, caregiverID int) (*models.Visit, error)
 	Create(visit *models.Visit) error
 	Update(visit *models.Visit) error
-	StartVisit(scheduleID int, latitude, longitude float64) error
-	EndVisit(scheduleID int, latitude, longitude float64, notes string) error
-	CancelVisit(scheduleID int) error
+	StartVisit(scheduleID, caregiverID int, latitude, longitude float64, method string) error
+	EndVisit(scheduleID, caregiverID int, latitude, longitude float64, method, notes string) error
+	CancelVisit(scheduleID, caregiverID int) error
+	SetClockAddress(scheduleID, caregiverID int, event, address string) error
+}
+
+// ScheduleCaregiverRepository defines the interface for schedule team data access
+type ScheduleCaregiverRepository interface {
+	GetByScheduleID(scheduleID int) ([]models.ScheduleCaregiver, error)
+	Add(caregiver *models.ScheduleCaregiver) error
+	Remove(scheduleID, caregiverID int) error
 }
 
 // TaskRepository defines the interface for task data access
@@ -31,16 +54,196 @@ type TaskRepository interface {
 	GetByID(id int) (*models.Task, error)
 	Create(task *models.Task) error
 	Update(task *models.Task) error
-	UpdateStatus(id int, status, reason string) error
+	UpdateStatus(id int, status, reason string, version int) error
 	Delete(id int) error
 }
 
 // ClientRepository defines the interface for client data access
 type ClientRepository interface {
-	GetAll(filter *models.ClientFilter) ([]models.Client, error)
+	GetAll(filter *models.ClientFilter) ([]models.Client, *models.PageInfo, error)
 	GetByID(id int) (*models.Client, error)
 	Create(client *models.Client) error
 	Update(client *models.Client) error
 	Delete(id int) error
 	Search(query string) ([]models.Client, error)
+	GetByPhone(phone string) (*models.Client, error)
+}
+
+// ScheduleSegmentRepository defines the interface for schedule segment data access
+type ScheduleSegmentRepository interface {
+	GetByScheduleID(scheduleID int) ([]models.ScheduleSegment, error)
+	GetByID(id int) (*models.ScheduleSegment, error)
+	Create(segment *models.ScheduleSegment) error
+	Delete(id int) error
+}
+
+// VisitSegmentRepository defines the interface for recorded visit segment data access
+type VisitSegmentRepository interface {
+	GetByScheduleID(scheduleID int) ([]models.VisitSegment, error)
+	Create(segment *models.VisitSegment) error
+	Switch(scheduleID, caregiverID int, at time.Time, latitude, longitude float64, next *models.VisitSegment) error
+	Close(scheduleID, caregiverID int, at time.Time, latitude, longitude float64) error
+	DeleteByScheduleAndCaregiver(scheduleID, caregiverID int) error
+}
+
+// AvailabilityRepository defines the interface for caregiver availability data access
+type AvailabilityRepository interface {
+	GetByCaregiverID(caregiverID int) (*models.CaregiverAvailability, error)
+	Replace(availability *models.CaregiverAvailability) error
+	GetCaregiverIDs() ([]int, error)
+}
+
+// TimeOffRepository defines the interface for time-off request data access
+type TimeOffRepository interface {
+	GetByID(id int) (*models.TimeOffRequest, error)
+	GetByCaregiverID(caregiverID int) ([]models.TimeOffRequest, error)
+	GetOverlapping(caregiverID int, start, end time.Time, status string) ([]models.TimeOffRequest, error)
+	Create(request *models.TimeOffRequest) error
+	Update(request *models.TimeOffRequest) error
+}
+
+// SkillRepository defines the interface for caregiver skill data access
+type SkillRepository interface {
+	GetByCaregiverID(caregiverID int) ([]models.CaregiverSkill, error)
+	Upsert(skill *models.CaregiverSkill) error
+	Delete(caregiverID int, skill string) error
+	GetExpiring(from, to time.Time) ([]models.CaregiverSkill, error)
+}
+
+// ServiceTypeRepository defines the interface for service type data access
+type ServiceTypeRepository interface {
+	GetAll() ([]models.ServiceType, error)
+	GetByID(id int) (*models.ServiceType, error)
+	GetByName(name string) (*models.ServiceType, error)
+	Create(serviceType *models.ServiceType) error
+	Update(serviceType *models.ServiceType) error
+}
+
+// TaskTemplateRepository defines the interface for task template data access
+type TaskTemplateRepository interface {
+	GetAll() ([]models.TaskTemplate, error)
+	GetByID(id int) (*models.TaskTemplate, error)
+	GetByTitle(title string) (*models.TaskTemplate, error)
+	Create(template *models.TaskTemplate) error
+	Update(template *models.TaskTemplate) error
+}
+
+// CarePlanRepository defines the interface for care plan data access
+type CarePlanRepository interface {
+	GetByID(id int) (*models.CarePlan, error)
+	GetCurrent(clientID int) (*models.CarePlan, error)
+	GetVersions(clientID int) ([]models.CarePlan, error)
+	Create(plan *models.CarePlan) error
+	PlannedTemplates(clientID int, from, to time.Time) ([]int, error)
+}
+
+// CaregiverProfileRepository defines the interface for caregiver profile data access
+type CaregiverProfileRepository interface {
+	GetByCaregiverID(caregiverID int) (*models.CaregiverProfile, error)
+	Replace(profile *models.CaregiverProfile) error
+}
+
+// ClientPreferenceRepository defines the interface for client caregiver preference data access
+type ClientPreferenceRepository interface {
+	GetByClientID(clientID int) (*models.ClientPreferences, error)
+	Replace(preferences *models.ClientPreferences) error
+}
+
+// OpenShiftRepository defines the interface for open shift data access
+type OpenShiftRepository interface {
+	GetByID(id int) (*models.OpenShift, error)
+	GetByStatuses(statuses []string) ([]models.OpenShift, error)
+	GetActiveByScheduleID(scheduleID int) (*models.OpenShift, error)
+	Create(shift *models.OpenShift) error
+	Update(shift *models.OpenShift) error
+}
+
+// SwapRequestRepository defines the interface for shift swap request data access
+type SwapRequestRepository interface {
+	GetByID(id int) (*models.SwapRequest, error)
+	GetByCaregiverID(caregiverID int) ([]models.SwapRequest, error)
+	GetPendingByScheduleID(scheduleID int) ([]models.SwapRequest, error)
+	Create(request *models.SwapRequest) error
+	Update(request *models.SwapRequest) error
+}
+
+// ShiftTransitionRepository defines the interface for the open shift and swap request transition log
+type ShiftTransitionRepository interface {
+	GetByEntity(entity string, entityID int) ([]models.ShiftTransition, error)
+	Create(transition *models.ShiftTransition) error
+}
+
+// TravelSegmentRepository defines the interface for caregiver travel segment data access
+type TravelSegmentRepository interface {
+	GetByID(id int) (*models.TravelSegment, error)
+	GetByCaregiver(caregiverID int, from, to string) ([]models.TravelSegment, error)
+	Upsert(segment *models.TravelSegment) error
+	Update(segment *models.TravelSegment) error
+	Delete(id int) error
+}
+
+// LocationTrackRepository defines the interface for visit location ping and track data access
+type LocationTrackRepository interface {
+	AddPoints(scheduleID, caregiverID int, points []models.LocationPing) error
+	GetPoints(scheduleID, caregiverID int) ([]models.LocationPing, error)
+	DeletePointsBefore(cutoff time.Time) (int64, error)
+	GetTracks(scheduleID int) ([]models.VisitTrack, error)
+	SaveTrack(track *models.VisitTrack) error
+}
+
+// ClockEventRepository defines the interface for clock event data access
+type ClockEventRepository interface {
+	GetByScheduleID(scheduleID int) ([]models.ClockEvent, error)
+	GetLastByCaregiver(caregiverID int, before time.Time) (*models.ClockEvent, error)
+	CountClientsAt(latitude, longitude float64, excludeClientID int, since time.Time) (int, error)
+	Create(event *models.ClockEvent) error
+}
+
+// VisitReviewRepository defines the interface for high-risk visit review data access
+type VisitReviewRepository interface {
+	GetByID(id int) (*models.VisitReview, error)
+	GetByStatus(status string) ([]models.VisitReview, error)
+	GetByScheduleID(scheduleID int) ([]models.VisitReview, error)
+	Queue(review *models.VisitReview) error
+	Update(review *models.VisitReview) error
+}
+
+// ClientVerificationTokenRepository defines the interface for client verification token data access
+type ClientVerificationTokenRepository interface {
+	GetByClientID(clientID int) (*models.ClientVerificationToken, error)
+	Replace(token *models.ClientVerificationToken) error
+	Delete(clientID int) error
+}
+
+// TelephonyPINRepository defines the interface for caregiver telephony PIN data access
+type TelephonyPINRepository interface {
+	GetByCaregiverID(caregiverID int) (*models.CaregiverTelephonyPIN, error)
+	Replace(pin *models.CaregiverTelephonyPIN) error
+}
+
+// VisitCorrectionRepository defines the interface for visit correction data access
+type VisitCorrectionRepository interface {
+	GetByID(id int) (*models.VisitCorrection, error)
+	GetByStatus(status string) ([]models.VisitCorrection, error)
+	GetByScheduleID(scheduleID int) ([]models.VisitCorrection, error)
+	Create(correction *models.VisitCorrection) error
+	Update(correction *models.VisitCorrection) error
+}
+
+// GeoPlaceRepository defines the interface for the offline geocoding dataset
+type GeoPlaceRepository interface {
+	Upsert(places []models.GeoPlace) error
+	FindNearest(latitude, longitude, radiusKm float64, limit int) ([]models.GeoPlace, error)
+	FindInArea(locality, postcode, street string, limit int) ([]models.GeoPlace, error)
+	GetSource(source string) (*models.GeoPlaceSource, error)
+	SaveSource(source *models.GeoPlaceSource) error
+}
+
+// IdempotencyRepository defines the interface for idempotency key storage
+type IdempotencyRepository interface {
+	Get(key string) (*models.IdempotencyRecord, error)
+	Reserve(record *models.IdempotencyRecord) (bool, error)
+	Complete(key string, statusCode int, headers map[string]string, responseBody []byte) error
+	Delete(key string) error
+	DeleteExpired(now time.Time) (int64, error)
 }
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type locationTrackRepository struct {
+	db *sql.DB
+}
+
+// NewLocationTrackRepository creates a new visit location track repository
+func NewLocationTrackRepository(db *sql.DB) LocationTrackRepository {
+	return &locationTrackRepository{db: db}
+}
+
+// AddPoints stores the location pings of a caregiver's visit, ignoring pings
+// already stored for the same time
+func (r *locationTrackRepository) AddPoints(scheduleID, caregiverID int, points []models.LocationPing) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range points {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO visit_location_points (schedule_id, caregiver_id, latitude, longitude, accuracy, recorded_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			scheduleID, caregiverID, p.Latitude, p.Longitude, p.Accuracy, p.RecordedAt.UTC().Format("2006-01-02 15:04:05"))
+		if err != nil {
+			return fmt.Errorf("failed to save location ping: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit location pings: %w", err)
+	}
+	return nil
+}
+
+// GetPoints retrieves the stored location pings of a caregiver's visit in the order they were recorded
+func (r *locationTrackRepository) GetPoints(scheduleID, caregiverID int) ([]models.LocationPing, error) {
+	rows, err := r.db.Query(`
+		SELECT latitude, longitude, accuracy, recorded_at
+		FROM visit_location_points
+		WHERE schedule_id = ? AND caregiver_id = ?
+		ORDER BY recorded_at ASC`, scheduleID, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query location pings: %w", err)
+	}
+	defer rows.Close()
+
+	var points []models.LocationPing
+	for rows.Next() {
+		var p models.LocationPing
+		var accuracy sql.NullFloat64
+		if err := rows.Scan(&p.Latitude, &p.Longitude, &accuracy, &p.RecordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan location ping: %w", err)
+		}
+		if accuracy.Valid {
+			a := accuracy.Float64
+			p.Accuracy = &a
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// DeletePointsBefore removes the location pings recorded before cutoff and returns how many were removed
+func (r *locationTrackRepository) DeletePointsBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM visit_location_points WHERE recorded_at < ?", cutoff.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete location pings: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// GetTracks retrieves the track summaries of every caregiver's visit to a schedule
+func (r *locationTrackRepository) GetTracks(scheduleID int) ([]models.VisitTrack, error) {
+	rows, err := r.db.Query(`
+		SELECT schedule_id, caregiver_id, point_count, first_recorded_at, last_recorded_at, geofence_known,
+		       geofence_radius_meters, inside_minutes, outside_minutes, points_outside, max_distance_meters, updated_at
+		FROM visit_tracks
+		WHERE schedule_id = ?
+		ORDER BY caregiver_id ASC`, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit tracks: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []models.VisitTrack
+	for rows.Next() {
+		var t models.VisitTrack
+		if err := rows.Scan(&t.ScheduleID, &t.CaregiverID, &t.PointCount, &t.FirstRecordedAt, &t.LastRecordedAt, &t.GeofenceKnown,
+			&t.GeofenceRadiusMeters, &t.InsideMinutes, &t.OutsideMinutes, &t.PointsOutside, &t.MaxDistanceMeters, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan visit track: %w", err)
+		}
+		tracks = append(tracks, t)
+	}
+
+	return tracks, nil
+}
+
+// SaveTrack stores the track summary of a caregiver's visit, replacing the previous one
+func (r *locationTrackRepository) SaveTrack(track *models.VisitTrack) error {
+	_, err := r.db.Exec(`
+		INSERT INTO visit_tracks (schedule_id, caregiver_id, point_count, first_recorded_at, last_recorded_at, geofence_known,
+		    geofence_radius_meters, inside_minutes, outside_minutes, points_outside, max_distance_meters, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (schedule_id, caregiver_id) DO UPDATE SET
+		    point_count = excluded.point_count,
+		    first_recorded_at = excluded.first_recorded_at,
+		    last_recorded_at = excluded.last_recorded_at,
+		    geofence_known = excluded.geofence_known,
+		    geofence_radius_meters = excluded.geofence_radius_meters,
+		    inside_minutes = excluded.inside_minutes,
+		    outside_minutes = excluded.outside_minutes,
+		    points_outside = excluded.points_outside,
+		    max_distance_meters = excluded.max_distance_meters,
+		    updated_at = excluded.updated_at`,
+		track.ScheduleID, track.CaregiverID, track.PointCount, nullableTime(track.FirstRecordedAt), nullableTime(track.LastRecordedAt),
+		track.GeofenceKnown, track.GeofenceRadiusMeters, track.InsideMinutes, track.OutsideMinutes, track.PointsOutside, track.MaxDistanceMeters)
+	if err != nil {
+		return fmt.Errorf("failed to save visit track: %w", err)
+	}
+
+	track.UpdatedAt = time.Now()
+	return nil
+}
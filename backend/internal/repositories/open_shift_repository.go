@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type openShiftRepository struct {
+	db *sql.DB
+}
+
+// NewOpenShiftRepository creates a new open shift repository
+func NewOpenShiftRepository(db *sql.DB) OpenShiftRepository {
+	return &openShiftRepository{db: db}
+}
+
+// openShiftColumns lists the columns read by scanOpenShift
+const openShiftColumns = `id, schedule_id, released_by, reason, claim_mode, status, claimed_by, claimed_at, review_notes,
+		version, created_at, updated_at`
+
+// scanOpenShift reads a row selected with openShiftColumns
+func scanOpenShift(row rowScanner) (*models.OpenShift, error) {
+	var o models.OpenShift
+	var reason, notes sql.NullString
+	var claimedBy sql.NullInt64
+	if err := row.Scan(
+		&o.ID, &o.ScheduleID, &o.ReleasedBy, &reason, &o.ClaimMode, &o.Status, &claimedBy, &o.ClaimedAt, &notes,
+		&o.Version, &o.CreatedAt, &o.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	o.Reason = reason.String
+	o.ReviewNotes = notes.String
+	if claimedBy.Valid {
+		id := int(claimedBy.Int64)
+		o.ClaimedBy = &id
+	}
+	return &o, nil
+}
+
+// GetByID retrieves an open shift by ID
+func (r *openShiftRepository) GetByID(id int) (*models.OpenShift, error) {
+	query := "SELECT " + openShiftColumns + " FROM open_shifts WHERE id = ?"
+
+	o, err := scanOpenShift(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get open shift: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetByStatuses retrieves the open shifts in any of the statuses, oldest first
+func (r *openShiftRepository) GetByStatuses(statuses []string) ([]models.OpenShift, error) {
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args[i] = status
+	}
+
+	query := "SELECT " + openShiftColumns + `
+		FROM open_shifts
+		WHERE status IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY created_at ASC, id ASC`
+
+	return r.query(query, args...)
+}
+
+// GetActiveByScheduleID retrieves the open or claimed shift of a schedule
+func (r *openShiftRepository) GetActiveByScheduleID(scheduleID int) (*models.OpenShift, error) {
+	query := "SELECT " + openShiftColumns + `
+		FROM open_shifts
+		WHERE schedule_id = ? AND status IN ('open', 'claimed')
+		ORDER BY id DESC
+		LIMIT 1`
+
+	o, err := scanOpenShift(r.db.QueryRow(query, scheduleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get open shift: %w", err)
+	}
+
+	return o, nil
+}
+
+// query runs a select over openShiftColumns and scans every row
+func (r *openShiftRepository) query(query string, args ...interface{}) ([]models.OpenShift, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open shifts: %w", err)
+	}
+	defer rows.Close()
+
+	var shifts []models.OpenShift
+	for rows.Next() {
+		o, err := scanOpenShift(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan open shift: %w", err)
+		}
+		shifts = append(shifts, *o)
+	}
+
+	return shifts, nil
+}
+
+// Create creates a new open shift
+func (r *openShiftRepository) Create(shift *models.OpenShift) error {
+	query := `
+		INSERT INTO open_shifts (schedule_id, released_by, reason, claim_mode, status)
+		VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, shift.ScheduleID, shift.ReleasedBy, shift.Reason, shift.ClaimMode, shift.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create open shift: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	now := time.Now()
+	shift.ID = int(id)
+	shift.Version = 1
+	shift.CreatedAt = now
+	shift.UpdatedAt = now
+	return nil
+}
+
+// Update stores the claim and review of an open shift if it is still at the version the caller read
+func (r *openShiftRepository) Update(shift *models.OpenShift) error {
+	query := `
+		UPDATE open_shifts
+		SET status = ?, claimed_by = ?, claimed_at = ?, review_notes = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	result, err := r.db.Exec(query, shift.Status, shift.ClaimedBy, nullableTime(shift.ClaimedAt), shift.ReviewNotes, shift.ID, shift.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update open shift: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update open shift %d: %w", shift.ID, err)
+	}
+
+	shift.Version++
+	shift.UpdatedAt = time.Now()
+	return nil
+}
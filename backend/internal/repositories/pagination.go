@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// sortSpec whitelists the fields a list can be sorted by. Each field maps to a
+// non-null column; ties are broken by the id column so that keyset pagination
+// is stable.
+type sortSpec struct {
+	fields       map[string]string
+	defaultField string
+	idColumn     string
+}
+
+// withField returns a copy of the spec that can also be sorted by a computed
+// expression, sorted by it by default. The expression must be non-null and
+// have numeric affinity, such as a CAST to REAL, so that cursor values compare
+// as numbers.
+func (s sortSpec) withField(field, expr string) sortSpec {
+	fields := make(map[string]string, len(s.fields)+1)
+	for name, column := range s.fields {
+		fields[name] = column
+	}
+	fields[field] = expr
+	return sortSpec{fields: fields, defaultField: field, idColumn: s.idColumn}
+}
+
+// cursor is the decoded form of the opaque pagination cursor
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    int    `json:"i"`
+}
+
+// keyset holds the resolved sort order and position for a list query
+type keyset struct {
+	spec  sortSpec
+	field string
+	desc  bool
+	after *cursor
+}
+
+// keyset resolves the sort and cursor options of a list query
+func (s sortSpec) keyset(opts models.ListOptions) (*keyset, error) {
+	k := &keyset{spec: s, field: s.defaultField}
+
+	if opts.Sort != "" {
+		k.field = strings.TrimPrefix(opts.Sort, "-")
+		k.desc = strings.HasPrefix(opts.Sort, "-")
+		if _, ok := s.fields[k.field]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSort, k.field)
+		}
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != k.field || c.Desc != k.desc {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+		}
+		k.after = c
+	}
+
+	return k, nil
+}
+
+// expr returns the column of the sort field
+func (k *keyset) expr() string {
+	return k.spec.fields[k.field]
+}
+
+// selectKey returns the select expression reading the sort value of a row.
+// Values are read as text; SQLite applies the column's affinity when the
+// cursor value is compared against it.
+func (k *keyset) selectKey() string {
+	return fmt.Sprintf("CAST(%s AS TEXT)", k.expr())
+}
+
+// condition returns the WHERE condition selecting rows after the cursor, using
+// numbered placeholders starting at argIndex. It is empty on the first page.
+func (k *keyset) condition(argIndex int) (string, []interface{}) {
+	if k.after == nil {
+		return "", nil
+	}
+
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+	condition := fmt.Sprintf(" AND (%s %s ?%d OR (%s = ?%d AND %s %s ?%d))",
+		k.expr(), op, argIndex, k.expr(), argIndex+1, k.spec.idColumn, op, argIndex+2)
+	return condition, []interface{}{k.after.Value, k.after.Value, k.after.ID}
+}
+
+// orderBy returns the ORDER BY clause for the sort
+func (k *keyset) orderBy() string {
+	direction := "ASC"
+	if k.desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", k.expr(), direction, k.spec.idColumn, direction)
+}
+
+// nextCursor encodes a cursor pointing after the row with the given sort value and id
+func (k *keyset) nextCursor(value string, id int) string {
+	data, _ := json.Marshal(cursor{Sort: k.field, Desc: k.desc, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an opaque cursor string
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return &c, nil
+}
+
+// fillPage describes a page of a result fetched with one extra row.
+// sortKeys holds the sort value of each row, ids the row ids.
+func (k *keyset) fillPage(page *models.PageInfo, limit int, sortKeys []string, ids []int) {
+	page.Limit = limit
+	if len(ids) > limit {
+		page.HasMore = true
+		page.NextCursor = k.nextCursor(sortKeys[limit-1], ids[limit-1])
+	}
+}
+
+// bindPlaceholders replaces numbered ?N placeholders with plain ? placeholders for SQLite
+func bindPlaceholders(query string, argCount int) string {
+	for i := argCount; i >= 1; i-- {
+		query = strings.ReplaceAll(query, fmt.Sprintf("?%d", i), "?")
+	}
+	return query
+}
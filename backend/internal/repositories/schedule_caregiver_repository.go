@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type scheduleCaregiverRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleCaregiverRepository creates a new schedule caregiver repository
+func NewScheduleCaregiverRepository(db *sql.DB) ScheduleCaregiverRepository {
+	return &scheduleCaregiverRepository{db: db}
+}
+
+// GetByScheduleID retrieves the team of a schedule, lead first
+func (r *scheduleCaregiverRepository) GetByScheduleID(scheduleID int) ([]models.ScheduleCaregiver, error) {
+	query := `
+		SELECT schedule_id, caregiver_id, role, created_at
+		FROM schedule_caregivers
+		WHERE schedule_id = ?
+		ORDER BY role = 'lead' DESC, created_at ASC, caregiver_id ASC`
+
+	rows, err := r.db.Query(query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule caregivers: %w", err)
+	}
+	defer rows.Close()
+
+	var caregivers []models.ScheduleCaregiver
+	for rows.Next() {
+		var sc models.ScheduleCaregiver
+		if err := rows.Scan(&sc.ScheduleID, &sc.CaregiverID, &sc.Role, &sc.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule caregiver: %w", err)
+		}
+		caregivers = append(caregivers, sc)
+	}
+
+	return caregivers, nil
+}
+
+// Add assigns a caregiver to a schedule, leaving an existing assignment unchanged
+func (r *scheduleCaregiverRepository) Add(caregiver *models.ScheduleCaregiver) error {
+	query := `
+		INSERT OR IGNORE INTO schedule_caregivers (schedule_id, caregiver_id, role)
+		VALUES (?, ?, ?)`
+
+	if _, err := r.db.Exec(query, caregiver.ScheduleID, caregiver.CaregiverID, caregiver.Role); err != nil {
+		return fmt.Errorf("failed to add schedule caregiver: %w", err)
+	}
+
+	caregiver.CreatedAt = time.Now()
+	return nil
+}
+
+// Remove unassigns a caregiver from a schedule
+func (r *scheduleCaregiverRepository) Remove(scheduleID, caregiverID int) error {
+	query := "DELETE FROM schedule_caregivers WHERE schedule_id = ? AND caregiver_id = ?"
+	if _, err := r.db.Exec(query, scheduleID, caregiverID); err != nil {
+		return fmt.Errorf("failed to remove schedule caregiver: %w", err)
+	}
+	return nil
+}
+
+// setLead makes caregiverID the lead of a schedule's team, demoting the
+// previous lead to a member
+func setLead(db execer, scheduleID, caregiverID int) error {
+	statements := []string{
+		"UPDATE schedule_caregivers SET role = 'member' WHERE schedule_id = ? AND role = 'lead' AND caregiver_id != ?",
+		`INSERT INTO schedule_caregivers (schedule_id, caregiver_id, role) VALUES (?, ?, 'lead')
+		 ON CONFLICT (schedule_id, caregiver_id) DO UPDATE SET role = 'lead'`,
+	}
+	args := [][]interface{}{{scheduleID, caregiverID}, {scheduleID, caregiverID}}
+
+	for i, statement := range statements {
+		if _, err := db.Exec(statement, args[i]...); err != nil {
+			return fmt.Errorf("failed to set schedule lead: %w", err)
+		}
+	}
+	return nil
+}
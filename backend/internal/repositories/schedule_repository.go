@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
-	"time"
 )
 
 type scheduleRepository struct {
@@ -17,73 +16,144 @@ func NewScheduleRepository(db *sql.DB) ScheduleRepository {
 	return &scheduleRepository{db: db}
 }
 
-// GetAll retrieves all schedules with optional filtering
-func (r *scheduleRepository) GetAll(filter *models.ScheduleFilter) ([]models.Schedule, error) {
-	query := `
-		SELECT s.id, s.client_id, s.service_name, s.caregiver_id, s.start_time, s.end_time, s.status, s.notes, s.created_at, s.updated_at,
-		       c.id, c.name, c.email, c.phone, c.address, c.city, c.state, c.zip_code, c.latitude, c.longitude, c.notes, c.is_active, c.created_at, c.updated_at
-		FROM schedules s
-		LEFT JOIN clients c ON s.client_id = c.id
-		WHERE 1=1`
+// scheduleSorts whitelists the fields schedules can be sorted by
+var scheduleSorts = sortSpec{
+	fields: map[string]string{
+		"id":           "s.id",
+		"start_time":   "s.start_time",
+		"end_time":     "s.end_time",
+		"status":       "s.status",
+		"service_name": "s.service_name",
+		"created_at":   "s.created_at",
+	},
+	defaultField: "start_time",
+	idColumn:     "s.id",
+}
 
+// GetAll retrieves schedules with optional filtering, sorting and cursor pagination
+func (r *scheduleRepository) GetAll(filter *models.ScheduleFilter) ([]models.Schedule, *models.PageInfo, error) {
+	if filter == nil {
+		filter = &models.ScheduleFilter{}
+	}
+
+	keys, err := scheduleSorts.keyset(filter.ListOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	where := " WHERE 1=1"
 	args := []interface{}{}
 	argIndex := 1
 
-	if filter != nil {
-		if filter.CaregiverID != nil {
-			query += fmt.Sprintf(" AND s.caregiver_id = ?%d", argIndex)
-			args = append(args, *filter.CaregiverID)
-			argIndex++
-		}
-		if filter.Date != nil {
-			query += fmt.Sprintf(" AND DATE(s.start_time) = DATE(?%d)", argIndex)
-			args = append(args, filter.Date.UTC().Format("2006-01-02"))
+	if filter.CaregiverID != nil {
+		where += fmt.Sprintf(" AND s.id IN (SELECT schedule_id FROM schedule_caregivers WHERE caregiver_id = ?%d)", argIndex)
+		args = append(args, *filter.CaregiverID)
+		argIndex++
+	}
+	if filter.ClientID != nil {
+		where += fmt.Sprintf(" AND s.client_id = ?%d", argIndex)
+		args = append(args, *filter.ClientID)
+		argIndex++
+	}
+	if filter.From != nil {
+		where += fmt.Sprintf(" AND s.start_time >= ?%d", argIndex)
+		args = append(args, filter.From.UTC().Format("2006-01-02 15:04:05"))
+		argIndex++
+	}
+	if filter.To != nil {
+		where += fmt.Sprintf(" AND s.start_time < ?%d", argIndex)
+		args = append(args, filter.To.UTC().Format("2006-01-02 15:04:05"))
+		argIndex++
+	}
+	if filter.ActiveFrom != nil {
+		where += fmt.Sprintf(" AND s.end_time > ?%d", argIndex)
+		args = append(args, filter.ActiveFrom.UTC().Format("2006-01-02 15:04:05"))
+		argIndex++
+	}
+	if filter.ActiveTo != nil {
+		where += fmt.Sprintf(" AND s.start_time < ?%d", argIndex)
+		args = append(args, filter.ActiveTo.UTC().Format("2006-01-02 15:04:05"))
+		argIndex++
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = fmt.Sprintf("?%d", argIndex)
+			args = append(args, status)
 			argIndex++
 		}
-		if filter.Status != nil {
-			query += fmt.Sprintf(" AND s.status = ?%d", argIndex)
-			args = append(args, *filter.Status)
-			argIndex++
+		where += " AND s.status IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	if filter.ServiceName != nil {
+		where += fmt.Sprintf(" AND LOWER(s.service_name) = LOWER(?%d)", argIndex)
+		args = append(args, *filter.ServiceName)
+		argIndex++
+	}
+	if filter.LocationStatus != nil {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM visits v WHERE v.schedule_id = s.id AND v.location_status = ?%d)", argIndex)
+		args = append(args, *filter.LocationStatus)
+		argIndex++
+	}
+	if filter.HasIncompleteTasks != nil {
+		incomplete := "EXISTS (SELECT 1 FROM tasks t WHERE t.schedule_id = s.id AND t.status != 'completed')"
+		if *filter.HasIncompleteTasks {
+			where += " AND " + incomplete
+		} else {
+			where += " AND NOT " + incomplete
 		}
 	}
 
-	query += " ORDER BY s.start_time ASC"
-
-	if filter != nil {
-		if filter.Limit != nil {
-			query += fmt.Sprintf(" LIMIT ?%d", argIndex)
-			args = append(args, *filter.Limit)
-			argIndex++
-		}
-		if filter.Offset != nil {
-			query += fmt.Sprintf(" OFFSET ?%d", argIndex)
-			args = append(args, *filter.Offset)
+	page := &models.PageInfo{}
+	if filter.IncludeTotal {
+		total, err := r.count(where, args)
+		if err != nil {
+			return nil, nil, err
 		}
+		page.Total = &total
 	}
 
-	// Replace numbered placeholders with actual ? placeholders for SQLite
-	for i := len(args); i >= 1; i-- {
-		query = strings.ReplaceAll(query, fmt.Sprintf("?%d", i), "?")
+	query := `
+		SELECT s.id, s.client_id, s.service_name, s.caregiver_id, s.start_time, s.end_time, s.status, s.notes, s.flex_minutes, s.care_plan_id, s.version, s.created_at, s.updated_at,
+		       c.id, c.name, c.email, c.phone, c.address, c.city, c.state, c.zip_code, c.latitude, c.longitude, c.notes, c.timezone, c.is_active, c.version, c.created_at, c.updated_at,
+		       ` + keys.selectKey() + `
+		FROM schedules s
+		LEFT JOIN clients c ON s.client_id = c.id` + where
+
+	condition, cursorArgs := keys.condition(argIndex)
+	query += condition
+	args = append(args, cursorArgs...)
+	argIndex += len(cursorArgs)
+
+	query += keys.orderBy()
+
+	// Fetch one extra row to find out whether there is a next page
+	if filter.Limit != nil {
+		query += fmt.Sprintf(" LIMIT ?%d", argIndex)
+		args = append(args, *filter.Limit+1)
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.Query(bindPlaceholders(query, len(args)), args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query schedules: %w", err)
+		return nil, nil, fmt.Errorf("failed to query schedules: %w", err)
 	}
 	defer rows.Close()
 
 	var schedules []models.Schedule
+	var sortKeys []string
+	var ids []int
 	for rows.Next() {
 		var s models.Schedule
 		var c models.Client
-		var clientNotes, clientEmail, clientPhone sql.NullString
+		var clientNotes, clientEmail, clientPhone, clientTimezone sql.NullString
+		var sortKey string
 
 		err := rows.Scan(
-			&s.ID, &s.ClientID, &s.ServiceName, &s.CaregiverID, &s.StartTime, &s.EndTime, &s.Status, &s.Notes, &s.CreatedAt, &s.UpdatedAt,
-			&c.ID, &c.Name, &clientEmail, &clientPhone, &c.Address, &c.City, &c.State, &c.ZipCode, &c.Latitude, &c.Longitude, &clientNotes, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+			&s.ID, &s.ClientID, &s.ServiceName, &s.CaregiverID, &s.StartTime, &s.EndTime, &s.Status, &s.Notes, &s.FlexMinutes, &s.CarePlanID, &s.Version, &s.CreatedAt, &s.UpdatedAt,
+			&c.ID, &c.Name, &clientEmail, &clientPhone, &c.Address, &c.City, &c.State, &c.ZipCode, &c.Latitude, &c.Longitude, &clientNotes, &clientTimezone, &c.IsActive, &c.Version, &c.CreatedAt, &c.UpdatedAt,
+			&sortKey,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan schedule: %w", err)
 		}
 
 		// Handle nullable fields
@@ -96,31 +166,54 @@ func (r *scheduleRepository) GetAll(filter *models.ScheduleFilter) ([]models.Sch
 		if clientNotes.Valid {
 			c.Notes = clientNotes.String
 		}
+		if clientTimezone.Valid {
+			c.Timezone = clientTimezone.String
+		}
 
 		// Set client data
 		s.Client = &c
 		schedules = append(schedules, s)
+		sortKeys = append(sortKeys, sortKey)
+		ids = append(ids, s.ID)
+	}
+
+	if filter.Limit != nil {
+		keys.fillPage(page, *filter.Limit, sortKeys, ids)
+		if len(schedules) > *filter.Limit {
+			schedules = schedules[:*filter.Limit]
+		}
 	}
 
-	return schedules, nil
+	return schedules, page, nil
+}
+
+// count returns the number of schedules matching a WHERE clause built by GetAll
+func (r *scheduleRepository) count(where string, args []interface{}) (int, error) {
+	query := "SELECT COUNT(*) FROM schedules s" + where
+
+	var total int
+	if err := r.db.QueryRow(bindPlaceholders(query, len(args)), args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count schedules: %w", err)
+	}
+	return total, nil
 }
 
 // GetByID retrieves a schedule by ID
 func (r *scheduleRepository) GetByID(id int) (*models.Schedule, error) {
 	query := `
-		SELECT s.id, s.client_id, s.service_name, s.caregiver_id, s.start_time, s.end_time, s.status, s.notes, s.created_at, s.updated_at,
-		       c.id, c.name, c.email, c.phone, c.address, c.city, c.state, c.zip_code, c.latitude, c.longitude, c.notes, c.is_active, c.created_at, c.updated_at
+		SELECT s.id, s.client_id, s.service_name, s.caregiver_id, s.start_time, s.end_time, s.status, s.notes, s.flex_minutes, s.care_plan_id, s.version, s.created_at, s.updated_at,
+		       c.id, c.name, c.email, c.phone, c.address, c.city, c.state, c.zip_code, c.latitude, c.longitude, c.notes, c.timezone, c.is_active, c.version, c.created_at, c.updated_at
 		FROM schedules s
 		LEFT JOIN clients c ON s.client_id = c.id
 		WHERE s.id = ?`
 
 	var s models.Schedule
 	var c models.Client
-	var clientNotes, clientEmail, clientPhone sql.NullString
+	var clientNotes, clientEmail, clientPhone, clientTimezone sql.NullString
 
 	err := r.db.QueryRow(query, id).Scan(
-		&s.ID, &s.ClientID, &s.ServiceName, &s.CaregiverID, &s.StartTime, &s.EndTime, &s.Status, &s.Notes, &s.CreatedAt, &s.UpdatedAt,
-		&c.ID, &c.Name, &clientEmail, &clientPhone, &c.Address, &c.City, &c.State, &c.ZipCode, &c.Latitude, &c.Longitude, &clientNotes, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+		&s.ID, &s.ClientID, &s.ServiceName, &s.CaregiverID, &s.StartTime, &s.EndTime, &s.Status, &s.Notes, &s.FlexMinutes, &s.CarePlanID, &s.Version, &s.CreatedAt, &s.UpdatedAt,
+		&c.ID, &c.Name, &clientEmail, &clientPhone, &c.Address, &c.City, &c.State, &c.ZipCode, &c.Latitude, &c.Longitude, &clientNotes, &clientTimezone, &c.IsActive, &c.Version, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -139,35 +232,27 @@ func (r *scheduleRepository) GetByID(id int) (*models.Schedule, error) {
 	if clientNotes.Valid {
 		c.Notes = clientNotes.String
 	}
+	if clientTimezone.Valid {
+		c.Timezone = clientTimezone.String
+	}
 
 	// Set client data
 	s.Client = &c
 	return &s, nil
 }
 
-// GetToday retrieves today's schedules for a caregiver
-func (r *scheduleRepository) GetToday(caregiverID int) ([]models.Schedule, error) {
-	today := time.Now()
-	filter := &models.ScheduleFilter{
-		CaregiverID: &caregiverID,
-		Date:        &today,
-	}
-	return r.GetAll(filter)
-}
-
-// GetStats retrieves schedule statistics for a caregiver
+// GetStats retrieves the overall schedule totals for a caregiver. Per-day
+// counts depend on client timezones and are left to the service.
 func (r *scheduleRepository) GetStats(caregiverID int) (*models.ScheduleStats, error) {
 	query := `
 		SELECT 
 			COUNT(*) as total,
-			SUM(CASE WHEN status = 'missed' THEN 1 ELSE 0 END) as missed,
-			SUM(CASE WHEN status = 'scheduled' AND DATE(start_time) = DATE('now') THEN 1 ELSE 0 END) as upcoming,
-			SUM(CASE WHEN status = 'completed' AND DATE(start_time) = DATE('now') THEN 1 ELSE 0 END) as completed
+			COALESCE(SUM(CASE WHEN status = 'missed' THEN 1 ELSE 0 END), 0) as missed
 		FROM schedules 
-		WHERE caregiver_id = ?`
+		WHERE id IN (SELECT schedule_id FROM schedule_caregivers WHERE caregiver_id = ?)`
 
 	var stats models.ScheduleStats
-	err := r.db.QueryRow(query, caregiverID).Scan(&stats.Total, &stats.Missed, &stats.Upcoming, &stats.Completed)
+	err := r.db.QueryRow(query, caregiverID).Scan(&stats.Total, &stats.Missed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get schedule stats: %w", err)
 	}
@@ -175,18 +260,24 @@ func (r *scheduleRepository) GetStats(caregiverID int) (*models.ScheduleStats, e
 	return &stats, nil
 }
 
-// Create creates a new schedule
+// Create creates a new schedule with its tasks
 func (r *scheduleRepository) Create(schedule *models.Schedule) error {
 	// Format time in local timezone to avoid timezone conversion issues
 	startTimeFormatted := schedule.StartTime.UTC().Format("2006-01-02 15:04:05")
 	endTimeFormatted := schedule.EndTime.UTC().Format("2006-01-02 15:04:05")
 
 	query := `
-	INSERT INTO schedules (client_id, service_name, caregiver_id, start_time, end_time, status, notes)
-	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	INSERT INTO schedules (client_id, service_name, caregiver_id, start_time, end_time, status, notes, flex_minutes, care_plan_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.Exec(query, schedule.ClientID, schedule.ServiceName, schedule.CaregiverID,
-		startTimeFormatted, endTimeFormatted, schedule.Status, schedule.Notes)
+	result, err := tx.Exec(query, schedule.ClientID, schedule.ServiceName, schedule.CaregiverID,
+		startTimeFormatted, endTimeFormatted, schedule.Status, schedule.Notes, schedule.FlexMinutes, schedule.CarePlanID)
 	if err != nil {
 		return fmt.Errorf("failed to create schedule: %w", err)
 	}
@@ -196,7 +287,22 @@ func (r *scheduleRepository) Create(schedule *models.Schedule) error {
 		return fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
+	// The schedule's caregiver leads its team
+	if err := setLead(tx, int(id), schedule.CaregiverID); err != nil {
+		return err
+	}
+	for i := range schedule.Tasks {
+		schedule.Tasks[i].ScheduleID = int(id)
+		if err := insertTask(tx, &schedule.Tasks[i]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schedule: %w", err)
+	}
+
 	schedule.ID = int(id)
+	schedule.Version = 1
 	return nil
 }
 
@@ -209,15 +315,33 @@ func (r *scheduleRepository) Update(schedule *models.Schedule) error {
 	query := `
 		UPDATE schedules
 		SET client_id = ?, service_name = ?, caregiver_id = ?, start_time = ?, end_time = ?,
-		    status = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`
-	fmt.Println(query)
-	_, err := r.db.Exec(query, schedule.ClientID, schedule.ServiceName, schedule.CaregiverID,
-		startTimeFormatted, endTimeFormatted, schedule.Status, schedule.Notes, schedule.ID)
+		    status = ?, notes = ?, flex_minutes = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, schedule.ClientID, schedule.ServiceName, schedule.CaregiverID,
+		startTimeFormatted, endTimeFormatted, schedule.Status, schedule.Notes, schedule.FlexMinutes, schedule.ID, schedule.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update schedule: %w", err)
 	}
 
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update schedule %d: %w", schedule.ID, err)
+	}
+
+	if err := setLead(tx, schedule.ID, schedule.CaregiverID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schedule: %w", err)
+	}
+
+	schedule.Version++
 	return nil
 }
 
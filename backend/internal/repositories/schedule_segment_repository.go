@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type scheduleSegmentRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleSegmentRepository creates a new schedule segment repository
+func NewScheduleSegmentRepository(db *sql.DB) ScheduleSegmentRepository {
+	return &scheduleSegmentRepository{db: db}
+}
+
+// GetByScheduleID retrieves all segments of a schedule in chronological order
+func (r *scheduleSegmentRepository) GetByScheduleID(scheduleID int) ([]models.ScheduleSegment, error) {
+	query := `
+		SELECT id, schedule_id, type, start_time, end_time, notes, created_at
+		FROM schedule_segments
+		WHERE schedule_id = ?
+		ORDER BY start_time ASC`
+
+	rows, err := r.db.Query(query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []models.ScheduleSegment
+	for rows.Next() {
+		var seg models.ScheduleSegment
+		var notes sql.NullString
+		if err := rows.Scan(&seg.ID, &seg.ScheduleID, &seg.Type, &seg.StartTime, &seg.EndTime, &notes, &seg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule segment: %w", err)
+		}
+		if notes.Valid {
+			seg.Notes = notes.String
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+// GetByID retrieves a schedule segment by ID
+func (r *scheduleSegmentRepository) GetByID(id int) (*models.ScheduleSegment, error) {
+	query := `
+		SELECT id, schedule_id, type, start_time, end_time, notes, created_at
+		FROM schedule_segments
+		WHERE id = ?`
+
+	var seg models.ScheduleSegment
+	var notes sql.NullString
+	err := r.db.QueryRow(query, id).Scan(&seg.ID, &seg.ScheduleID, &seg.Type, &seg.StartTime, &seg.EndTime, &notes, &seg.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get schedule segment: %w", err)
+	}
+	if notes.Valid {
+		seg.Notes = notes.String
+	}
+
+	return &seg, nil
+}
+
+// Create creates a new schedule segment
+func (r *scheduleSegmentRepository) Create(segment *models.ScheduleSegment) error {
+	query := `
+		INSERT INTO schedule_segments (schedule_id, type, start_time, end_time, notes)
+		VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, segment.ScheduleID, segment.Type,
+		segment.StartTime.UTC().Format("2006-01-02 15:04:05"),
+		segment.EndTime.UTC().Format("2006-01-02 15:04:05"),
+		segment.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to create schedule segment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	segment.ID = int(id)
+	segment.CreatedAt = time.Now()
+	return nil
+}
+
+// Delete deletes a schedule segment
+func (r *scheduleSegmentRepository) Delete(id int) error {
+	if _, err := r.db.Exec("DELETE FROM schedule_segments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete schedule segment: %w", err)
+	}
+	return nil
+}
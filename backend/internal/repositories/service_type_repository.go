@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type serviceTypeRepository struct {
+	db *sql.DB
+}
+
+// NewServiceTypeRepository creates a new service type repository
+func NewServiceTypeRepository(db *sql.DB) ServiceTypeRepository {
+	return &serviceTypeRepository{db: db}
+}
+
+// GetAll retrieves all service types by name
+func (r *serviceTypeRepository) GetAll() ([]models.ServiceType, error) {
+	rows, err := r.db.Query("SELECT id, name, description, reason_codes, created_at, updated_at FROM service_types ORDER BY name ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service types: %w", err)
+	}
+	defer rows.Close()
+
+	var serviceTypes []models.ServiceType
+	for rows.Next() {
+		st, err := scanServiceType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service type: %w", err)
+		}
+		serviceTypes = append(serviceTypes, *st)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read service types: %w", err)
+	}
+
+	for i := range serviceTypes {
+		if serviceTypes[i].RequiredSkills, err = r.requiredSkills(serviceTypes[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return serviceTypes, nil
+}
+
+// GetByID retrieves a service type by ID
+func (r *serviceTypeRepository) GetByID(id int) (*models.ServiceType, error) {
+	return r.get("SELECT id, name, description, reason_codes, created_at, updated_at FROM service_types WHERE id = ?", id)
+}
+
+// GetByName retrieves a service type by name, ignoring case
+func (r *serviceTypeRepository) GetByName(name string) (*models.ServiceType, error) {
+	return r.get("SELECT id, name, description, reason_codes, created_at, updated_at FROM service_types WHERE name = ?", name)
+}
+
+// get retrieves a single service type with its required skills
+func (r *serviceTypeRepository) get(query string, arg interface{}) (*models.ServiceType, error) {
+	st, err := scanServiceType(r.db.QueryRow(query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get service type: %w", err)
+	}
+
+	if st.RequiredSkills, err = r.requiredSkills(st.ID); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// scanServiceType reads a service type row without its required skills
+func scanServiceType(row rowScanner) (*models.ServiceType, error) {
+	var st models.ServiceType
+	var description sql.NullString
+	var reasonCodes string
+	if err := row.Scan(&st.ID, &st.Name, &description, &reasonCodes, &st.CreatedAt, &st.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	st.Description = description.String
+	st.ReasonCodes = splitList(reasonCodes)
+	return &st, nil
+}
+
+// requiredSkills retrieves the skills a service type requires
+func (r *serviceTypeRepository) requiredSkills(serviceTypeID int) ([]string, error) {
+	rows, err := r.db.Query("SELECT skill FROM service_type_skills WHERE service_type_id = ? ORDER BY skill ASC", serviceTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query required skills: %w", err)
+	}
+	defer rows.Close()
+
+	skills := []string{}
+	for rows.Next() {
+		var skill string
+		if err := rows.Scan(&skill); err != nil {
+			return nil, fmt.Errorf("failed to scan required skill: %w", err)
+		}
+		skills = append(skills, skill)
+	}
+
+	return skills, nil
+}
+
+// Create creates a new service type with its required skills
+func (r *serviceTypeRepository) Create(serviceType *models.ServiceType) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO service_types (name, description, reason_codes) VALUES (?, ?, ?)",
+		serviceType.Name, serviceType.Description, joinList(serviceType.ReasonCodes))
+	if err != nil {
+		return fmt.Errorf("failed to create service type: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if err := replaceRequiredSkills(tx, int(id), serviceType.RequiredSkills); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit service type: %w", err)
+	}
+
+	now := time.Now()
+	serviceType.ID = int(id)
+	serviceType.CreatedAt = now
+	serviceType.UpdatedAt = now
+	return nil
+}
+
+// Update updates a service type and replaces its required skills
+func (r *serviceTypeRepository) Update(serviceType *models.ServiceType) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE service_types SET name = ?, description = ?, reason_codes = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		serviceType.Name, serviceType.Description, joinList(serviceType.ReasonCodes), serviceType.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update service type: %w", err)
+	}
+
+	if err := replaceRequiredSkills(tx, serviceType.ID, serviceType.RequiredSkills); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit service type: %w", err)
+	}
+
+	serviceType.UpdatedAt = time.Now()
+	return nil
+}
+
+// replaceRequiredSkills replaces the skills a service type requires
+func replaceRequiredSkills(db execer, serviceTypeID int, skills []string) error {
+	if _, err := db.Exec("DELETE FROM service_type_skills WHERE service_type_id = ?", serviceTypeID); err != nil {
+		return fmt.Errorf("failed to clear required skills: %w", err)
+	}
+	for _, skill := range skills {
+		if _, err := db.Exec("INSERT OR IGNORE INTO service_type_skills (service_type_id, skill) VALUES (?, ?)", serviceTypeID, skill); err != nil {
+			return fmt.Errorf("failed to save required skill: %w", err)
+		}
+	}
+	return nil
+}
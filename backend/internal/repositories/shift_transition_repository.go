@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type shiftTransitionRepository struct {
+	db *sql.DB
+}
+
+// NewShiftTransitionRepository creates a new shift transition log repository
+func NewShiftTransitionRepository(db *sql.DB) ShiftTransitionRepository {
+	return &shiftTransitionRepository{db: db}
+}
+
+// GetByEntity retrieves the transitions of an open shift or swap request in the order they happened
+func (r *shiftTransitionRepository) GetByEntity(entity string, entityID int) ([]models.ShiftTransition, error) {
+	query := `
+		SELECT id, entity, entity_id, event, from_status, to_status, caregiver_id, notes, created_at
+		FROM shift_transitions
+		WHERE entity = ? AND entity_id = ?
+		ORDER BY id ASC`
+
+	rows, err := r.db.Query(query, entity, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shift transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []models.ShiftTransition
+	for rows.Next() {
+		var t models.ShiftTransition
+		var caregiverID sql.NullInt64
+		var notes sql.NullString
+		if err := rows.Scan(&t.ID, &t.Entity, &t.EntityID, &t.Event, &t.FromStatus, &t.ToStatus, &caregiverID, &notes, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shift transition: %w", err)
+		}
+		if caregiverID.Valid {
+			id := int(caregiverID.Int64)
+			t.CaregiverID = &id
+		}
+		t.Notes = notes.String
+		transitions = append(transitions, t)
+	}
+
+	return transitions, nil
+}
+
+// Create records a transition
+func (r *shiftTransitionRepository) Create(transition *models.ShiftTransition) error {
+	query := `
+		INSERT INTO shift_transitions (entity, entity_id, event, from_status, to_status, caregiver_id, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	if transition.CreatedAt.IsZero() {
+		transition.CreatedAt = time.Now()
+	}
+	result, err := r.db.Exec(query, transition.Entity, transition.EntityID, transition.Event, transition.FromStatus,
+		transition.ToStatus, transition.CaregiverID, transition.Notes, transition.CreatedAt.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return fmt.Errorf("failed to create shift transition: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	transition.ID = int(id)
+	return nil
+}
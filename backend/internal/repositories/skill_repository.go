@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type skillRepository struct {
+	db *sql.DB
+}
+
+// NewSkillRepository creates a new caregiver skill repository
+func NewSkillRepository(db *sql.DB) SkillRepository {
+	return &skillRepository{db: db}
+}
+
+// skillColumns lists the columns read by scanSkill
+const skillColumns = "id, caregiver_id, skill, certification_number, issued_at, expires_at, created_at, updated_at"
+
+// scanSkill reads a row selected with skillColumns
+func scanSkill(row rowScanner) (*models.CaregiverSkill, error) {
+	var s models.CaregiverSkill
+	var number sql.NullString
+	if err := row.Scan(&s.ID, &s.CaregiverID, &s.Skill, &number, &s.IssuedAt, &s.ExpiresAt, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	s.CertificationNumber = number.String
+	return &s, nil
+}
+
+// GetByCaregiverID retrieves the skills of a caregiver in alphabetical order
+func (r *skillRepository) GetByCaregiverID(caregiverID int) ([]models.CaregiverSkill, error) {
+	query := "SELECT " + skillColumns + " FROM caregiver_skills WHERE caregiver_id = ? ORDER BY skill ASC"
+	return r.query(query, caregiverID)
+}
+
+// GetExpiring retrieves the certifications expiring from from up to to, soonest first
+func (r *skillRepository) GetExpiring(from, to time.Time) ([]models.CaregiverSkill, error) {
+	query := "SELECT " + skillColumns + `
+		FROM caregiver_skills
+		WHERE expires_at IS NOT NULL AND expires_at >= ? AND expires_at < ?
+		ORDER BY expires_at ASC, caregiver_id ASC`
+
+	return r.query(query, from.UTC().Format("2006-01-02 15:04:05"), to.UTC().Format("2006-01-02 15:04:05"))
+}
+
+// query runs a select over skillColumns and scans every row
+func (r *skillRepository) query(query string, args ...interface{}) ([]models.CaregiverSkill, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query caregiver skills: %w", err)
+	}
+	defer rows.Close()
+
+	var skills []models.CaregiverSkill
+	for rows.Next() {
+		s, err := scanSkill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan caregiver skill: %w", err)
+		}
+		skills = append(skills, *s)
+	}
+
+	return skills, nil
+}
+
+// Upsert records a caregiver's skill, replacing the certification details of
+// a skill they already hold
+func (r *skillRepository) Upsert(skill *models.CaregiverSkill) error {
+	query := `
+		INSERT INTO caregiver_skills (caregiver_id, skill, certification_number, issued_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (caregiver_id, skill) DO UPDATE SET
+		    certification_number = excluded.certification_number,
+		    issued_at = excluded.issued_at,
+		    expires_at = excluded.expires_at,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING id`
+
+	if err := r.db.QueryRow(query, skill.CaregiverID, skill.Skill, skill.CertificationNumber,
+		nullableTime(skill.IssuedAt), nullableTime(skill.ExpiresAt)).Scan(&skill.ID); err != nil {
+		return fmt.Errorf("failed to save caregiver skill: %w", err)
+	}
+
+	now := time.Now()
+	if skill.CreatedAt.IsZero() {
+		skill.CreatedAt = now
+	}
+	skill.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a skill from a caregiver
+func (r *skillRepository) Delete(caregiverID int, skill string) error {
+	if _, err := r.db.Exec("DELETE FROM caregiver_skills WHERE caregiver_id = ? AND skill = ?", caregiverID, skill); err != nil {
+		return fmt.Errorf("failed to delete caregiver skill: %w", err)
+	}
+	return nil
+}
+
+// nullableTime formats an optional time for storage, or NULL when it is nil
+func nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
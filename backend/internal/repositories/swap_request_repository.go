@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type swapRequestRepository struct {
+	db *sql.DB
+}
+
+// NewSwapRequestRepository creates a new shift swap request repository
+func NewSwapRequestRepository(db *sql.DB) SwapRequestRepository {
+	return &swapRequestRepository{db: db}
+}
+
+// swapRequestColumns lists the columns read by scanSwapRequest
+const swapRequestColumns = `id, schedule_id, requester_id, target_caregiver_id, target_schedule_id, notes, status,
+		response_notes, responded_at, version, created_at, updated_at`
+
+// scanSwapRequest reads a row selected with swapRequestColumns
+func scanSwapRequest(row rowScanner) (*models.SwapRequest, error) {
+	var s models.SwapRequest
+	var notes, responseNotes sql.NullString
+	var targetScheduleID sql.NullInt64
+	if err := row.Scan(
+		&s.ID, &s.ScheduleID, &s.RequesterID, &s.TargetCaregiverID, &targetScheduleID, &notes, &s.Status,
+		&responseNotes, &s.RespondedAt, &s.Version, &s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	s.Notes = notes.String
+	s.ResponseNotes = responseNotes.String
+	if targetScheduleID.Valid {
+		id := int(targetScheduleID.Int64)
+		s.TargetScheduleID = &id
+	}
+	return &s, nil
+}
+
+// GetByID retrieves a swap request by ID
+func (r *swapRequestRepository) GetByID(id int) (*models.SwapRequest, error) {
+	query := "SELECT " + swapRequestColumns + " FROM swap_requests WHERE id = ?"
+
+	s, err := scanSwapRequest(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get swap request: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetByCaregiverID retrieves the swap requests a caregiver made or received, latest first
+func (r *swapRequestRepository) GetByCaregiverID(caregiverID int) ([]models.SwapRequest, error) {
+	query := "SELECT " + swapRequestColumns + `
+		FROM swap_requests
+		WHERE requester_id = ? OR target_caregiver_id = ?
+		ORDER BY created_at DESC, id DESC`
+
+	return r.query(query, caregiverID, caregiverID)
+}
+
+// GetPendingByScheduleID retrieves the pending swap requests that would move a
+// schedule, whether offered or asked for in exchange
+func (r *swapRequestRepository) GetPendingByScheduleID(scheduleID int) ([]models.SwapRequest, error) {
+	query := "SELECT " + swapRequestColumns + `
+		FROM swap_requests
+		WHERE status = 'pending' AND (schedule_id = ? OR target_schedule_id = ?)
+		ORDER BY id ASC`
+
+	return r.query(query, scheduleID, scheduleID)
+}
+
+// query runs a select over swapRequestColumns and scans every row
+func (r *swapRequestRepository) query(query string, args ...interface{}) ([]models.SwapRequest, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query swap requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.SwapRequest
+	for rows.Next() {
+		s, err := scanSwapRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan swap request: %w", err)
+		}
+		requests = append(requests, *s)
+	}
+
+	return requests, nil
+}
+
+// Create creates a new swap request
+func (r *swapRequestRepository) Create(request *models.SwapRequest) error {
+	query := `
+		INSERT INTO swap_requests (schedule_id, requester_id, target_caregiver_id, target_schedule_id, notes, status)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, request.ScheduleID, request.RequesterID, request.TargetCaregiverID,
+		request.TargetScheduleID, request.Notes, request.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create swap request: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	now := time.Now()
+	request.ID = int(id)
+	request.Version = 1
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	return nil
+}
+
+// Update stores the response to a swap request if it is still at the version the caller read
+func (r *swapRequestRepository) Update(request *models.SwapRequest) error {
+	query := `
+		UPDATE swap_requests
+		SET status = ?, response_notes = ?, responded_at = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	result, err := r.db.Exec(query, request.Status, request.ResponseNotes, nullableTime(request.RespondedAt), request.ID, request.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update swap request: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update swap request %d: %w", request.ID, err)
+	}
+
+	request.Version++
+	request.UpdatedAt = time.Now()
+	return nil
+}
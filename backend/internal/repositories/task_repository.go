@@ -19,7 +19,7 @@ func NewTaskRepository(db *sql.DB) TaskRepository {
 // GetByScheduleID retrieves all tasks for a schedule
 func (r *taskRepository) GetByScheduleID(scheduleID int) ([]models.Task, error) {
 	query := `
-		SELECT id, schedule_id, title, description, status, reason, completed_at, created_at, updated_at
+		SELECT id, schedule_id, caregiver_id, template_id, title, description, status, reason, required, completed_at, version, created_at, updated_at
 		FROM tasks 
 		WHERE schedule_id = ?
 		ORDER BY created_at ASC`
@@ -34,8 +34,8 @@ func (r *taskRepository) GetByScheduleID(scheduleID int) ([]models.Task, error)
 	for rows.Next() {
 		var t models.Task
 		var reason sql.NullString
-		err := rows.Scan(&t.ID, &t.ScheduleID, &t.Title, &t.Description, &t.Status,
-			&reason, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt)
+		err := rows.Scan(&t.ID, &t.ScheduleID, &t.CaregiverID, &t.TemplateID, &t.Title, &t.Description, &t.Status,
+			&reason, &t.Required, &t.CompletedAt, &t.Version, &t.CreatedAt, &t.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
 		}
@@ -51,14 +51,14 @@ func (r *taskRepository) GetByScheduleID(scheduleID int) ([]models.Task, error)
 // GetByID retrieves a task by ID
 func (r *taskRepository) GetByID(id int) (*models.Task, error) {
 	query := `
-		SELECT id, schedule_id, title, description, status, reason, completed_at, created_at, updated_at
+		SELECT id, schedule_id, caregiver_id, template_id, title, description, status, reason, required, completed_at, version, created_at, updated_at
 		FROM tasks 
 		WHERE id = ?`
 
 	var t models.Task
 	var reason sql.NullString
-	err := r.db.QueryRow(query, id).Scan(&t.ID, &t.ScheduleID, &t.Title, &t.Description,
-		&t.Status, &reason, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt)
+	err := r.db.QueryRow(query, id).Scan(&t.ID, &t.ScheduleID, &t.CaregiverID, &t.TemplateID, &t.Title, &t.Description,
+		&t.Status, &reason, &t.Required, &t.CompletedAt, &t.Version, &t.CreatedAt, &t.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -75,12 +75,17 @@ func (r *taskRepository) GetByID(id int) (*models.Task, error) {
 
 // Create creates a new task
 func (r *taskRepository) Create(task *models.Task) error {
+	return insertTask(r.db, task)
+}
+
+// insertTask inserts a task, also within a schedule's transaction
+func insertTask(db execer, task *models.Task) error {
 	query := `
-		INSERT INTO tasks (schedule_id, title, description, status, reason, completed_at)
-		VALUES (?, ?, ?, ?, ?, ?)`
+		INSERT INTO tasks (schedule_id, caregiver_id, template_id, title, description, status, reason, required, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query, task.ScheduleID, task.Title, task.Description,
-		task.Status, task.Reason, task.CompletedAt)
+	result, err := db.Exec(query, task.ScheduleID, task.CaregiverID, task.TemplateID, task.Title, task.Description,
+		task.Status, task.Reason, task.Required, task.CompletedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
@@ -91,6 +96,7 @@ func (r *taskRepository) Create(task *models.Task) error {
 	}
 
 	task.ID = int(id)
+	task.Version = 1
 	return nil
 }
 
@@ -98,36 +104,43 @@ func (r *taskRepository) Create(task *models.Task) error {
 func (r *taskRepository) Update(task *models.Task) error {
 	query := `
 	UPDATE tasks
-	SET title = ?, description = ?, status = ?, reason = ?, completed_at = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`
+	SET caregiver_id = ?, title = ?, description = ?, status = ?, reason = ?, required = ?, completed_at = ?,
+	    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
 
-	_, err := r.db.Exec(query, task.Title, task.Description, task.Status,
-		task.Reason, task.CompletedAt, task.ID)
+	result, err := r.db.Exec(query, task.CaregiverID, task.Title, task.Description, task.Status,
+		task.Reason, task.Required, task.CompletedAt, task.ID, task.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
 
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update task %d: %w", task.ID, err)
+	}
+
+	task.Version++
 	return nil
 }
 
-// UpdateStatus updates the status of a task
-func (r *taskRepository) UpdateStatus(id int, status, reason string) error {
+// UpdateStatus updates the status of a task if it is still at the given version
+func (r *taskRepository) UpdateStatus(id int, status, reason string, version int) error {
 	var completedAt *time.Time
 	if status == "completed" {
-		now := time.Now()
+		now := time.Now().UTC()
 		completedAt = &now
 	}
 
 	query := `
 		UPDATE tasks
-	SET status = ?, reason = ?, completed_at = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`
+	SET status = ?, reason = ?, completed_at = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
 
 	// Retry mechanism for database locking issues
 	maxRetries := 3
+	var result sql.Result
 	var err error
 	for i := 0; i < maxRetries; i++ {
-		_, err = r.db.Exec(query, status, reason, completedAt, id)
+		result, err = r.db.Exec(query, status, reason, completedAt, id, version)
 		if err == nil {
 			// Success, exit the retry loop
 			break
@@ -149,6 +162,10 @@ func (r *taskRepository) UpdateStatus(id int, status, reason string) error {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
 
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update task %d status: %w", id, err)
+	}
+
 	return nil
 }
 
@@ -162,6 +179,18 @@ func (r *taskRepository) Delete(id int) error {
 	return nil
 }
 
+// checkVersionedUpdate reports a version conflict when an optimistic update matched no rows
+func checkVersionedUpdate(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
 // isDatabaseLockedError checks if an error is a SQLite database locked error
 func isDatabaseLockedError(err error) bool {
 	if err == nil {
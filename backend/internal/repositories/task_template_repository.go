@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type taskTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewTaskTemplateRepository creates a new task template repository
+func NewTaskTemplateRepository(db *sql.DB) TaskTemplateRepository {
+	return &taskTemplateRepository{db: db}
+}
+
+// GetAll retrieves all task templates by title
+func (r *taskTemplateRepository) GetAll() ([]models.TaskTemplate, error) {
+	rows, err := r.db.Query("SELECT id, title, instructions, is_active, created_at, updated_at FROM task_templates ORDER BY title ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []models.TaskTemplate
+	for rows.Next() {
+		var t models.TaskTemplate
+		if err := rows.Scan(&t.ID, &t.Title, &t.Instructions, &t.IsActive, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+// GetByID retrieves a task template by ID
+func (r *taskTemplateRepository) GetByID(id int) (*models.TaskTemplate, error) {
+	return r.get("SELECT id, title, instructions, is_active, created_at, updated_at FROM task_templates WHERE id = ?", id)
+}
+
+// GetByTitle retrieves a task template by title, ignoring case
+func (r *taskTemplateRepository) GetByTitle(title string) (*models.TaskTemplate, error) {
+	return r.get("SELECT id, title, instructions, is_active, created_at, updated_at FROM task_templates WHERE title = ?", title)
+}
+
+// get retrieves a single task template
+func (r *taskTemplateRepository) get(query string, arg interface{}) (*models.TaskTemplate, error) {
+	var t models.TaskTemplate
+	err := r.db.QueryRow(query, arg).Scan(&t.ID, &t.Title, &t.Instructions, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get task template: %w", err)
+	}
+	return &t, nil
+}
+
+// Create creates a new task template
+func (r *taskTemplateRepository) Create(template *models.TaskTemplate) error {
+	result, err := r.db.Exec("INSERT INTO task_templates (title, instructions, is_active) VALUES (?, ?, ?)",
+		template.Title, template.Instructions, template.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to create task template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	now := time.Now()
+	template.ID = int(id)
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	return nil
+}
+
+// Update updates a task template
+func (r *taskTemplateRepository) Update(template *models.TaskTemplate) error {
+	_, err := r.db.Exec("UPDATE task_templates SET title = ?, instructions = ?, is_active = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		template.Title, template.Instructions, template.IsActive, template.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update task template: %w", err)
+	}
+
+	template.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type telephonyPINRepository struct {
+	db *sql.DB
+}
+
+// NewTelephonyPINRepository creates a new caregiver telephony PIN repository
+func NewTelephonyPINRepository(db *sql.DB) TelephonyPINRepository {
+	return &telephonyPINRepository{db: db}
+}
+
+// GetByCaregiverID retrieves a caregiver's telephony PIN hash
+func (r *telephonyPINRepository) GetByCaregiverID(caregiverID int) (*models.CaregiverTelephonyPIN, error) {
+	query := `
+		SELECT caregiver_id, pin_hash, salt, updated_at
+		FROM caregiver_telephony_pins
+		WHERE caregiver_id = ?`
+
+	var p models.CaregiverTelephonyPIN
+	err := r.db.QueryRow(query, caregiverID).Scan(&p.CaregiverID, &p.PINHash, &p.Salt, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get telephony PIN: %w", err)
+	}
+
+	return &p, nil
+}
+
+// Replace stores a caregiver's telephony PIN hash, overwriting any earlier one
+func (r *telephonyPINRepository) Replace(pin *models.CaregiverTelephonyPIN) error {
+	_, err := r.db.Exec(`
+		INSERT INTO caregiver_telephony_pins (caregiver_id, pin_hash, salt, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (caregiver_id) DO UPDATE SET
+		    pin_hash = excluded.pin_hash,
+		    salt = excluded.salt,
+		    updated_at = excluded.updated_at`,
+		pin.CaregiverID, pin.PINHash, pin.Salt)
+	if err != nil {
+		return fmt.Errorf("failed to save telephony PIN: %w", err)
+	}
+
+	pin.UpdatedAt = time.Now()
+	return nil
+}
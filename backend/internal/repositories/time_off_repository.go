@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type timeOffRepository struct {
+	db *sql.DB
+}
+
+// NewTimeOffRepository creates a new time-off request repository
+func NewTimeOffRepository(db *sql.DB) TimeOffRepository {
+	return &timeOffRepository{db: db}
+}
+
+// timeOffColumns lists the columns read by scanTimeOff
+const timeOffColumns = `id, caregiver_id, start_time, end_time, reason, status, review_notes, reviewed_at,
+		version, created_at, updated_at`
+
+// scanTimeOff reads a row selected with timeOffColumns
+func scanTimeOff(row rowScanner) (*models.TimeOffRequest, error) {
+	var t models.TimeOffRequest
+	var reason, notes sql.NullString
+	if err := row.Scan(
+		&t.ID, &t.CaregiverID, &t.StartTime, &t.EndTime, &reason, &t.Status, &notes, &t.ReviewedAt,
+		&t.Version, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	t.Reason = reason.String
+	t.ReviewNotes = notes.String
+	return &t, nil
+}
+
+// GetByID retrieves a time-off request by ID
+func (r *timeOffRepository) GetByID(id int) (*models.TimeOffRequest, error) {
+	query := "SELECT " + timeOffColumns + " FROM time_off_requests WHERE id = ?"
+
+	t, err := scanTimeOff(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get time-off request: %w", err)
+	}
+
+	return t, nil
+}
+
+// GetByCaregiverID retrieves all time-off requests of a caregiver, latest first
+func (r *timeOffRepository) GetByCaregiverID(caregiverID int) ([]models.TimeOffRequest, error) {
+	query := "SELECT " + timeOffColumns + `
+		FROM time_off_requests
+		WHERE caregiver_id = ?
+		ORDER BY start_time DESC, id DESC`
+
+	return r.query(query, caregiverID)
+}
+
+// GetOverlapping retrieves a caregiver's time-off requests in a status that
+// overlap the range from start to end
+func (r *timeOffRepository) GetOverlapping(caregiverID int, start, end time.Time, status string) ([]models.TimeOffRequest, error) {
+	query := "SELECT " + timeOffColumns + `
+		FROM time_off_requests
+		WHERE caregiver_id = ? AND status = ? AND start_time < ? AND end_time > ?
+		ORDER BY start_time ASC`
+
+	return r.query(query, caregiverID, status,
+		end.UTC().Format("2006-01-02 15:04:05"),
+		start.UTC().Format("2006-01-02 15:04:05"))
+}
+
+// query runs a select over timeOffColumns and scans every row
+func (r *timeOffRepository) query(query string, args ...interface{}) ([]models.TimeOffRequest, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time-off requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.TimeOffRequest
+	for rows.Next() {
+		t, err := scanTimeOff(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan time-off request: %w", err)
+		}
+		requests = append(requests, *t)
+	}
+
+	return requests, nil
+}
+
+// Create creates a new time-off request
+func (r *timeOffRepository) Create(request *models.TimeOffRequest) error {
+	query := `
+		INSERT INTO time_off_requests (caregiver_id, start_time, end_time, reason, status)
+		VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, request.CaregiverID,
+		request.StartTime.UTC().Format("2006-01-02 15:04:05"),
+		request.EndTime.UTC().Format("2006-01-02 15:04:05"),
+		request.Reason, request.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create time-off request: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	now := time.Now()
+	request.ID = int(id)
+	request.Version = 1
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	return nil
+}
+
+// Update stores the review of a time-off request if it is still at the version the caller read
+func (r *timeOffRepository) Update(request *models.TimeOffRequest) error {
+	query := `
+		UPDATE time_off_requests
+		SET status = ?, review_notes = ?, reviewed_at = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	var reviewedAt interface{}
+	if request.ReviewedAt != nil {
+		reviewedAt = request.ReviewedAt.UTC().Format("2006-01-02 15:04:05")
+	}
+
+	result, err := r.db.Exec(query, request.Status, request.ReviewNotes, reviewedAt, request.ID, request.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update time-off request: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update time-off request %d: %w", request.ID, err)
+	}
+
+	request.Version++
+	request.UpdatedAt = time.Now()
+	return nil
+}
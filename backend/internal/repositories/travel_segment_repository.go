@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type travelSegmentRepository struct {
+	db *sql.DB
+}
+
+// NewTravelSegmentRepository creates a new travel segment repository
+func NewTravelSegmentRepository(db *sql.DB) TravelSegmentRepository {
+	return &travelSegmentRepository{db: db}
+}
+
+// travelSegmentColumns lists the columns read by scanTravelSegment
+const travelSegmentColumns = `id, caregiver_id, date, from_schedule_id, to_schedule_id,
+		from_latitude, from_longitude, to_latitude, to_longitude, departed_at, arrived_at,
+		distance_km, travel_minutes, adjusted_km, adjustment_reason, adjusted_at, version, created_at, updated_at`
+
+// scanTravelSegment reads a row selected with travelSegmentColumns
+func scanTravelSegment(row rowScanner) (*models.TravelSegment, error) {
+	var s models.TravelSegment
+	var adjustedKm sql.NullFloat64
+	var reason sql.NullString
+	if err := row.Scan(
+		&s.ID, &s.CaregiverID, &s.Date, &s.FromScheduleID, &s.ToScheduleID,
+		&s.FromLatitude, &s.FromLongitude, &s.ToLatitude, &s.ToLongitude, &s.DepartedAt, &s.ArrivedAt,
+		&s.DistanceKm, &s.TravelMinutes, &adjustedKm, &reason, &s.AdjustedAt, &s.Version, &s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	s.AdjustmentReason = reason.String
+	if adjustedKm.Valid {
+		km := adjustedKm.Float64
+		s.AdjustedKm = &km
+	}
+	return &s, nil
+}
+
+// GetByID retrieves a travel segment by ID
+func (r *travelSegmentRepository) GetByID(id int) (*models.TravelSegment, error) {
+	query := "SELECT " + travelSegmentColumns + " FROM travel_segments WHERE id = ?"
+
+	s, err := scanTravelSegment(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get travel segment: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetByCaregiver retrieves a caregiver's travel segments dated from and to
+// (YYYY-MM-DD, inclusive) in the order they were driven
+func (r *travelSegmentRepository) GetByCaregiver(caregiverID int, from, to string) ([]models.TravelSegment, error) {
+	query := "SELECT " + travelSegmentColumns + `
+		FROM travel_segments
+		WHERE caregiver_id = ? AND date >= ? AND date <= ?
+		ORDER BY departed_at ASC, id ASC`
+
+	rows, err := r.db.Query(query, caregiverID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query travel segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []models.TravelSegment
+	for rows.Next() {
+		s, err := scanTravelSegment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan travel segment: %w", err)
+		}
+		segments = append(segments, *s)
+	}
+
+	return segments, nil
+}
+
+// Upsert stores a measured travel segment, keyed by the caregiver and the two
+// schedules. A coordinator's adjustment of an existing segment is kept, and
+// its version only changes when the measurement does.
+func (r *travelSegmentRepository) Upsert(segment *models.TravelSegment) error {
+	query := `
+		INSERT INTO travel_segments (caregiver_id, date, from_schedule_id, to_schedule_id,
+		    from_latitude, from_longitude, to_latitude, to_longitude, departed_at, arrived_at, distance_km, travel_minutes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (caregiver_id, from_schedule_id, to_schedule_id) DO UPDATE SET
+		    date = excluded.date,
+		    from_latitude = excluded.from_latitude,
+		    from_longitude = excluded.from_longitude,
+		    to_latitude = excluded.to_latitude,
+		    to_longitude = excluded.to_longitude,
+		    departed_at = excluded.departed_at,
+		    arrived_at = excluded.arrived_at,
+		    distance_km = excluded.distance_km,
+		    travel_minutes = excluded.travel_minutes,
+		    version = travel_segments.version + 1,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE travel_segments.date != excluded.date
+		   OR travel_segments.departed_at != excluded.departed_at
+		   OR travel_segments.arrived_at != excluded.arrived_at
+		   OR travel_segments.distance_km != excluded.distance_km`
+
+	_, err := r.db.Exec(query, segment.CaregiverID, segment.Date, segment.FromScheduleID, segment.ToScheduleID,
+		segment.FromLatitude, segment.FromLongitude, segment.ToLatitude, segment.ToLongitude,
+		segment.DepartedAt.UTC().Format("2006-01-02 15:04:05"), segment.ArrivedAt.UTC().Format("2006-01-02 15:04:05"),
+		segment.DistanceKm, segment.TravelMinutes)
+	if err != nil {
+		return fmt.Errorf("failed to save travel segment: %w", err)
+	}
+
+	stored, err := scanTravelSegment(r.db.QueryRow("SELECT "+travelSegmentColumns+`
+		FROM travel_segments
+		WHERE caregiver_id = ? AND from_schedule_id = ? AND to_schedule_id = ?`,
+		segment.CaregiverID, segment.FromScheduleID, segment.ToScheduleID))
+	if err != nil {
+		return fmt.Errorf("failed to get saved travel segment: %w", err)
+	}
+
+	*segment = *stored
+	return nil
+}
+
+// Update stores the adjustment of a travel segment if it is still at the version the caller read
+func (r *travelSegmentRepository) Update(segment *models.TravelSegment) error {
+	query := `
+		UPDATE travel_segments
+		SET adjusted_km = ?, adjustment_reason = ?, adjusted_at = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	result, err := r.db.Exec(query, segment.AdjustedKm, segment.AdjustmentReason, nullableTime(segment.AdjustedAt),
+		segment.ID, segment.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update travel segment: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update travel segment %d: %w", segment.ID, err)
+	}
+
+	segment.Version++
+	segment.UpdatedAt = time.Now()
+	return nil
+}
+
+// Delete deletes a travel segment
+func (r *travelSegmentRepository) Delete(id int) error {
+	if _, err := r.db.Exec("DELETE FROM travel_segments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete travel segment: %w", err)
+	}
+	return nil
+}
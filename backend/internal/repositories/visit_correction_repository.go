@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type visitCorrectionRepository struct {
+	db *sql.DB
+}
+
+// NewVisitCorrectionRepository creates a new visit correction repository
+func NewVisitCorrectionRepository(db *sql.DB) VisitCorrectionRepository {
+	return &visitCorrectionRepository{db: db}
+}
+
+// visitCorrectionColumns lists the columns read by scanVisitCorrection
+const visitCorrectionColumns = `id, schedule_id, caregiver_id, visit_id, proposed_start_time, proposed_end_time,
+		recorded_start_time, recorded_end_time, reason_code, notes, status, review_notes, reviewed_at,
+		version, created_at, updated_at`
+
+// scanVisitCorrection reads a row selected with visitCorrectionColumns
+func scanVisitCorrection(row rowScanner) (*models.VisitCorrection, error) {
+	var c models.VisitCorrection
+	var notes, reviewNotes sql.NullString
+	if err := row.Scan(
+		&c.ID, &c.ScheduleID, &c.CaregiverID, &c.VisitID, &c.ProposedStartTime, &c.ProposedEndTime,
+		&c.RecordedStartTime, &c.RecordedEndTime, &c.ReasonCode, &notes, &c.Status, &reviewNotes, &c.ReviewedAt,
+		&c.Version, &c.CreatedAt, &c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	c.Notes = notes.String
+	c.ReviewNotes = reviewNotes.String
+	return &c, nil
+}
+
+// GetByID retrieves a visit correction by ID
+func (r *visitCorrectionRepository) GetByID(id int) (*models.VisitCorrection, error) {
+	query := "SELECT " + visitCorrectionColumns + " FROM visit_corrections WHERE id = ?"
+
+	c, err := scanVisitCorrection(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get visit correction: %w", err)
+	}
+
+	return c, nil
+}
+
+// GetByStatus retrieves the visit corrections in a status, oldest first
+func (r *visitCorrectionRepository) GetByStatus(status string) ([]models.VisitCorrection, error) {
+	query := "SELECT " + visitCorrectionColumns + `
+		FROM visit_corrections
+		WHERE status = ?
+		ORDER BY created_at ASC, id ASC`
+
+	return r.query(query, status)
+}
+
+// GetByScheduleID retrieves the visit corrections of every caregiver on a schedule, oldest first
+func (r *visitCorrectionRepository) GetByScheduleID(scheduleID int) ([]models.VisitCorrection, error) {
+	query := "SELECT " + visitCorrectionColumns + `
+		FROM visit_corrections
+		WHERE schedule_id = ?
+		ORDER BY created_at ASC, id ASC`
+
+	return r.query(query, scheduleID)
+}
+
+// query runs a select over visitCorrectionColumns and scans every row
+func (r *visitCorrectionRepository) query(query string, args ...interface{}) ([]models.VisitCorrection, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit corrections: %w", err)
+	}
+	defer rows.Close()
+
+	var corrections []models.VisitCorrection
+	for rows.Next() {
+		c, err := scanVisitCorrection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan visit correction: %w", err)
+		}
+		corrections = append(corrections, *c)
+	}
+
+	return corrections, nil
+}
+
+// Create creates a new visit correction
+func (r *visitCorrectionRepository) Create(correction *models.VisitCorrection) error {
+	query := `
+		INSERT INTO visit_corrections (schedule_id, caregiver_id, visit_id, proposed_start_time, proposed_end_time,
+		                               recorded_start_time, recorded_end_time, reason_code, notes, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, correction.ScheduleID, correction.CaregiverID, correction.VisitID,
+		nullableTime(correction.ProposedStartTime), nullableTime(correction.ProposedEndTime),
+		nullableTime(correction.RecordedStartTime), nullableTime(correction.RecordedEndTime),
+		correction.ReasonCode, correction.Notes, correction.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create visit correction: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	now := time.Now()
+	correction.ID = int(id)
+	correction.Version = 1
+	correction.CreatedAt = now
+	correction.UpdatedAt = now
+	return nil
+}
+
+// Update stores the review of a visit correction if it is still at the version the caller read
+func (r *visitCorrectionRepository) Update(correction *models.VisitCorrection) error {
+	query := `
+		UPDATE visit_corrections
+		SET status = ?, review_notes = ?, reviewed_at = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	result, err := r.db.Exec(query, correction.Status, correction.ReviewNotes, nullableTime(correction.ReviewedAt),
+		correction.ID, correction.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update visit correction: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update visit correction %d: %w", correction.ID, err)
+	}
+
+	correction.Version++
+	correction.UpdatedAt = time.Now()
+	return nil
+}
@@ -16,20 +16,53 @@ func NewVisitRepository(db *sql.DB) VisitRepository {
 	return &visitRepository{db: db}
 }
 
-// GetByScheduleID retrieves a visit by schedule ID
-func (r *visitRepository) GetByScheduleID(scheduleID int) (*models.Visit, error) {
-	query := `
-		SELECT id, schedule_id, start_time, end_time, start_latitude, start_longitude,
-		       end_latitude, end_longitude, location_status, status, notes, created_at, updated_at
-		FROM visits
-		WHERE schedule_id = ?`
+// visitColumns lists the visit columns in the order scanVisit reads them
+const visitColumns = `v.id, v.schedule_id, v.caregiver_id, v.start_time, v.end_time, v.start_latitude, v.start_longitude,
+		       v.end_latitude, v.end_longitude, v.location_status, v.status, v.notes, v.version, v.created_at, v.updated_at,
+		       v.start_verification_method, v.end_verification_method, v.original_start_time, v.original_end_time,
+		       v.amended_at, v.amendment_reason, v.start_address, v.end_address`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+func scanVisit(row rowScanner) (*models.Visit, error) {
 	var v models.Visit
-	var notes sql.NullString
-	err := r.db.QueryRow(query, scheduleID).Scan(
-		&v.ID, &v.ScheduleID, &v.StartTime, &v.EndTime, &v.StartLatitude, &v.StartLongitude,
-		&v.EndLatitude, &v.EndLongitude, &v.LocationStatus, &v.Status, &notes, &v.CreatedAt, &v.UpdatedAt,
-	)
+	var notes, startMethod, endMethod, amendmentReason, startAddress, endAddress sql.NullString
+	if err := row.Scan(
+		&v.ID, &v.ScheduleID, &v.CaregiverID, &v.StartTime, &v.EndTime, &v.StartLatitude, &v.StartLongitude,
+		&v.EndLatitude, &v.EndLongitude, &v.LocationStatus, &v.Status, &notes, &v.Version, &v.CreatedAt, &v.UpdatedAt,
+		&startMethod, &endMethod, &v.OriginalStartTime, &v.OriginalEndTime,
+		&v.AmendedAt, &amendmentReason, &startAddress, &endAddress,
+	); err != nil {
+		return nil, err
+	}
+
+	if notes.Valid {
+		v.Notes = notes.String
+	}
+	v.StartVerificationMethod = startMethod.String
+	v.EndVerificationMethod = endMethod.String
+	v.AmendmentReason = amendmentReason.String
+	v.StartAddress = startAddress.String
+	v.EndAddress = endAddress.String
+
+	return &v, nil
+}
+
+// GetByScheduleID retrieves the visit of a schedule's lead caregiver, or the
+// first visit recorded on the schedule when the lead has none
+func (r *visitRepository) GetByScheduleID(scheduleID int) (*models.Visit, error) {
+	query := `
+		SELECT ` + visitColumns + `
+		FROM visits v
+		JOIN schedules s ON s.id = v.schedule_id
+		WHERE v.schedule_id = ?
+		ORDER BY v.caregiver_id = s.caregiver_id DESC, v.id ASC
+		LIMIT 1`
+
+	v, err := scanVisit(r.db.QueryRow(query, scheduleID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -37,11 +70,51 @@ func (r *visitRepository) GetByScheduleID(scheduleID int) (*models.Visit, error)
 		return nil, fmt.Errorf("failed to get visit: %w", err)
 	}
 
-	if notes.Valid {
-		v.Notes = notes.String
+	return v, nil
+}
+
+// GetAllByScheduleID retrieves the visits of every caregiver on a schedule
+func (r *visitRepository) GetAllByScheduleID(scheduleID int) ([]models.Visit, error) {
+	query := `
+		SELECT ` + visitColumns + `
+		FROM visits v
+		WHERE v.schedule_id = ?
+		ORDER BY v.id ASC`
+
+	rows, err := r.db.Query(query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visits: %w", err)
 	}
+	defer rows.Close()
 
-	return &v, nil
+	var visits []models.Visit
+	for rows.Next() {
+		v, err := scanVisit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan visit: %w", err)
+		}
+		visits = append(visits, *v)
+	}
+
+	return visits, nil
+}
+
+// GetByScheduleAndCaregiver retrieves one caregiver's visit on a schedule
+func (r *visitRepository) GetByScheduleAndCaregiver(scheduleID, caregiverID int) (*models.Visit, error) {
+	query := `
+		SELECT ` + visitColumns + `
+		FROM visits v
+		WHERE v.schedule_id = ? AND v.caregiver_id = ?`
+
+	v, err := scanVisit(r.db.QueryRow(query, scheduleID, caregiverID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get visit: %w", err)
+	}
+
+	return v, nil
 }
 
 // Create creates a new visit
@@ -60,24 +133,24 @@ func (r *visitRepository) Create(visit *models.Visit) error {
 		endTimeFormatted = nil
 	}
 
+	// Visits without a caregiver belong to the schedule's lead
 	query := `
-	INSERT INTO visits (schedule_id, start_time, end_time, start_latitude, start_longitude,
-		                   end_latitude, end_longitude, location_status, status, notes, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
-
-	result, err := r.db.Exec(query, visit.ScheduleID, startTimeFormatted, endTimeFormatted,
+	INSERT INTO visits (schedule_id, caregiver_id, start_time, end_time, start_latitude, start_longitude,
+		                   end_latitude, end_longitude, location_status, status, notes,
+		                   start_verification_method, end_verification_method, created_at, updated_at)
+		VALUES (?, COALESCE(NULLIF(?, 0), (SELECT caregiver_id FROM schedules WHERE id = ?)),
+		        ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id, caregiver_id`
+
+	err := r.db.QueryRow(query, visit.ScheduleID, visit.CaregiverID, visit.ScheduleID, startTimeFormatted, endTimeFormatted,
 		visit.StartLatitude, visit.StartLongitude, visit.EndLatitude, visit.EndLongitude,
-		visit.LocationStatus, visit.Status, visit.Notes)
+		visit.LocationStatus, visit.Status, visit.Notes, visit.StartVerificationMethod, visit.EndVerificationMethod).
+		Scan(&visit.ID, &visit.CaregiverID)
 	if err != nil {
 		return fmt.Errorf("failed to create visit: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
-	}
-
-	visit.ID = int(id)
+	visit.Version = 1
 	return nil
 }
 
@@ -100,24 +173,37 @@ func (r *visitRepository) Update(visit *models.Visit) error {
 	query := `
 	UPDATE visits
 	SET start_time = ?, end_time = ?, start_latitude = ?, start_longitude = ?,
-		    end_latitude = ?, end_longitude = ?, location_status = ?, status = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`
-
-	_, err := r.db.Exec(query, startTimeFormatted, endTimeFormatted, visit.StartLatitude, visit.StartLongitude,
-		visit.EndLatitude, visit.EndLongitude, visit.LocationStatus, visit.Status, visit.Notes, visit.ID)
+		    end_latitude = ?, end_longitude = ?, location_status = ?, status = ?, notes = ?,
+		    start_verification_method = NULLIF(?, ''), end_verification_method = NULLIF(?, ''),
+		    original_start_time = ?, original_end_time = ?, amended_at = ?, amendment_reason = NULLIF(?, ''),
+		    start_address = NULLIF(?, ''), end_address = NULLIF(?, ''),
+		    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	result, err := r.db.Exec(query, startTimeFormatted, endTimeFormatted, visit.StartLatitude, visit.StartLongitude,
+		visit.EndLatitude, visit.EndLongitude, visit.LocationStatus, visit.Status, visit.Notes,
+		visit.StartVerificationMethod, visit.EndVerificationMethod,
+		nullableTime(visit.OriginalStartTime), nullableTime(visit.OriginalEndTime),
+		nullableTime(visit.AmendedAt), visit.AmendmentReason, visit.StartAddress, visit.EndAddress, visit.ID, visit.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update visit: %w", err)
 	}
 
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update visit %d: %w", visit.ID, err)
+	}
+
+	visit.Version++
 	return nil
 }
 
-// StartVisit starts a visit with timestamp and geolocation
-func (r *visitRepository) StartVisit(scheduleID int, latitude, longitude float64) error {
+// StartVisit starts a caregiver's visit with timestamp, geolocation and the
+// way the caregiver's presence was verified
+func (r *visitRepository) StartVisit(scheduleID, caregiverID int, latitude, longitude float64, method string) error {
 	now := time.Now()
 
 	// First, check if visit exists
-	visit, err := r.GetByScheduleID(scheduleID)
+	visit, err := r.GetByScheduleAndCaregiver(scheduleID, caregiverID)
 	if err != nil {
 		return fmt.Errorf("failed to check existing visit: %w", err)
 	}
@@ -126,11 +212,14 @@ func (r *visitRepository) StartVisit(scheduleID int, latitude, longitude float64
 		// Create new visit
 		visit = &models.Visit{
 			ScheduleID:     scheduleID,
+			CaregiverID:    caregiverID,
 			StartTime:      &now,
 			StartLatitude:  &latitude,
 			StartLongitude: &longitude,
 			LocationStatus: "confirmed",
 			Status:         "in_progress",
+
+			StartVerificationMethod: method,
 		}
 		return r.Create(visit)
 	} else {
@@ -140,21 +229,25 @@ func (r *visitRepository) StartVisit(scheduleID int, latitude, longitude float64
 		visit.StartLongitude = &longitude
 		visit.LocationStatus = "confirmed"
 		visit.Status = "in_progress"
+		visit.StartVerificationMethod = method
+		visit.StartAddress = ""
+		visit.EndAddress = ""
 		return r.Update(visit)
 	}
 }
 
-// EndVisit ends a visit with timestamp and geolocation
-func (r *visitRepository) EndVisit(scheduleID int, latitude, longitude float64, notes string) error {
+// EndVisit ends a caregiver's visit with timestamp, geolocation and the way
+// the caregiver's presence was verified
+func (r *visitRepository) EndVisit(scheduleID, caregiverID int, latitude, longitude float64, method, notes string) error {
 	now := time.Now()
 
-	visit, err := r.GetByScheduleID(scheduleID)
+	visit, err := r.GetByScheduleAndCaregiver(scheduleID, caregiverID)
 	if err != nil {
 		return fmt.Errorf("failed to get visit: %w", err)
 	}
 
 	if visit == nil {
-		return fmt.Errorf("visit not found for schedule %d", scheduleID)
+		return fmt.Errorf("visit not found for schedule %d and caregiver %d", scheduleID, caregiverID)
 	}
 
 	if visit.StartTime == nil {
@@ -165,6 +258,8 @@ func (r *visitRepository) EndVisit(scheduleID int, latitude, longitude float64,
 	visit.EndLatitude = &latitude
 	visit.EndLongitude = &longitude
 	visit.Status = "completed"
+	visit.EndVerificationMethod = method
+	visit.EndAddress = ""
 	if notes != "" {
 		visit.Notes = notes
 	}
@@ -172,15 +267,16 @@ func (r *visitRepository) EndVisit(scheduleID int, latitude, longitude float64,
 	return r.Update(visit)
 }
 
-// CancelVisit cancels an in-progress visit by resetting it to not_started status
-func (r *visitRepository) CancelVisit(scheduleID int) error {
-	visit, err := r.GetByScheduleID(scheduleID)
+// CancelVisit resets a caregiver's in-progress visit on a schedule to
+// not_started status, leaving the visits of the rest of the team untouched
+func (r *visitRepository) CancelVisit(scheduleID, caregiverID int) error {
+	visit, err := r.GetByScheduleAndCaregiver(scheduleID, caregiverID)
 	if err != nil {
 		return fmt.Errorf("failed to get visit: %w", err)
 	}
 
 	if visit == nil {
-		return fmt.Errorf("visit not found for schedule %d", scheduleID)
+		return fmt.Errorf("visit not found for schedule %d and caregiver %d", scheduleID, caregiverID)
 	}
 
 	if visit.Status != "in_progress" {
@@ -196,6 +292,38 @@ func (r *visitRepository) CancelVisit(scheduleID int) error {
 	visit.EndLongitude = nil
 	visit.Status = "not_started"
 	visit.Notes = ""
+	visit.StartVerificationMethod = ""
+	visit.EndVerificationMethod = ""
+	visit.OriginalStartTime = nil
+	visit.OriginalEndTime = nil
+	visit.AmendedAt = nil
+	visit.AmendmentReason = ""
+	visit.StartAddress = ""
+	visit.EndAddress = ""
 
 	return r.Update(visit)
 }
+
+// SetClockAddress stores the address resolved for a caregiver's clock-in or
+// clock-out location without changing the visit's version
+func (r *visitRepository) SetClockAddress(scheduleID, caregiverID int, event, address string) error {
+	column := "start_address"
+	if event == models.ClockEventOut {
+		column = "end_address"
+	}
+
+	result, err := r.db.Exec(`UPDATE visits SET `+column+` = NULLIF(?, '') WHERE schedule_id = ? AND caregiver_id = ?`,
+		address, scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to set visit address: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set visit address: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("visit not found for schedule %d and caregiver %d", scheduleID, caregiverID)
+	}
+	return nil
+}
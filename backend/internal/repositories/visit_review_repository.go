@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type visitReviewRepository struct {
+	db *sql.DB
+}
+
+// NewVisitReviewRepository creates a new visit review repository
+func NewVisitReviewRepository(db *sql.DB) VisitReviewRepository {
+	return &visitReviewRepository{db: db}
+}
+
+// visitReviewColumns lists the columns read by scanVisitReview
+const visitReviewColumns = `id, schedule_id, caregiver_id, risk_score, status, resolution_notes, reviewed_at,
+		version, created_at, updated_at`
+
+// scanVisitReview reads a row selected with visitReviewColumns
+func scanVisitReview(row rowScanner) (*models.VisitReview, error) {
+	var v models.VisitReview
+	var notes sql.NullString
+	if err := row.Scan(
+		&v.ID, &v.ScheduleID, &v.CaregiverID, &v.RiskScore, &v.Status, &notes, &v.ReviewedAt,
+		&v.Version, &v.CreatedAt, &v.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	v.ResolutionNotes = notes.String
+	return &v, nil
+}
+
+// GetByID retrieves a visit review by ID
+func (r *visitReviewRepository) GetByID(id int) (*models.VisitReview, error) {
+	query := "SELECT " + visitReviewColumns + " FROM visit_reviews WHERE id = ?"
+
+	v, err := scanVisitReview(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get visit review: %w", err)
+	}
+
+	return v, nil
+}
+
+// GetByStatus retrieves the visit reviews in a status, riskiest first
+func (r *visitReviewRepository) GetByStatus(status string) ([]models.VisitReview, error) {
+	query := "SELECT " + visitReviewColumns + `
+		FROM visit_reviews
+		WHERE status = ?
+		ORDER BY risk_score DESC, updated_at ASC, id ASC`
+
+	return r.query(query, status)
+}
+
+// GetByScheduleID retrieves the visit reviews of every caregiver on a schedule
+func (r *visitReviewRepository) GetByScheduleID(scheduleID int) ([]models.VisitReview, error) {
+	query := "SELECT " + visitReviewColumns + `
+		FROM visit_reviews
+		WHERE schedule_id = ?
+		ORDER BY caregiver_id ASC`
+
+	return r.query(query, scheduleID)
+}
+
+// query runs a select over visitReviewColumns and scans every row
+func (r *visitReviewRepository) query(query string, args ...interface{}) ([]models.VisitReview, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []models.VisitReview
+	for rows.Next() {
+		v, err := scanVisitReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan visit review: %w", err)
+		}
+		reviews = append(reviews, *v)
+	}
+
+	return reviews, nil
+}
+
+// Queue queues a caregiver's visit for review, keyed by the schedule and the
+// caregiver. A resolved review is queued again, and a pending one keeps the
+// higher risk score; its version only changes when either happens.
+func (r *visitReviewRepository) Queue(review *models.VisitReview) error {
+	query := `
+		INSERT INTO visit_reviews (schedule_id, caregiver_id, risk_score, status)
+		VALUES (?, ?, ?, 'pending')
+		ON CONFLICT (schedule_id, caregiver_id) DO UPDATE SET
+		    risk_score = MAX(visit_reviews.risk_score, excluded.risk_score),
+		    status = 'pending',
+		    reviewed_at = NULL,
+		    version = visit_reviews.version + 1,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE visit_reviews.status != 'pending'
+		   OR visit_reviews.risk_score < excluded.risk_score`
+
+	if _, err := r.db.Exec(query, review.ScheduleID, review.CaregiverID, review.RiskScore); err != nil {
+		return fmt.Errorf("failed to queue visit review: %w", err)
+	}
+
+	stored, err := scanVisitReview(r.db.QueryRow("SELECT "+visitReviewColumns+`
+		FROM visit_reviews
+		WHERE schedule_id = ? AND caregiver_id = ?`, review.ScheduleID, review.CaregiverID))
+	if err != nil {
+		return fmt.Errorf("failed to get queued visit review: %w", err)
+	}
+
+	*review = *stored
+	return nil
+}
+
+// Update stores the resolution of a visit review if it is still at the version the caller read
+func (r *visitReviewRepository) Update(review *models.VisitReview) error {
+	query := `
+		UPDATE visit_reviews
+		SET status = ?, resolution_notes = ?, reviewed_at = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND version = ?`
+
+	result, err := r.db.Exec(query, review.Status, review.ResolutionNotes, nullableTime(review.ReviewedAt),
+		review.ID, review.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update visit review: %w", err)
+	}
+	if err := checkVersionedUpdate(result); err != nil {
+		return fmt.Errorf("failed to update visit review %d: %w", review.ID, err)
+	}
+
+	review.Version++
+	review.UpdatedAt = time.Now()
+	return nil
+}
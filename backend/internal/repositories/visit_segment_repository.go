@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type visitSegmentRepository struct {
+	db *sql.DB
+}
+
+// NewVisitSegmentRepository creates a new visit segment repository
+func NewVisitSegmentRepository(db *sql.DB) VisitSegmentRepository {
+	return &visitSegmentRepository{db: db}
+}
+
+// GetByScheduleID retrieves the recorded segments of every caregiver on a
+// schedule in chronological order
+func (r *visitSegmentRepository) GetByScheduleID(scheduleID int) ([]models.VisitSegment, error) {
+	query := `
+		SELECT id, schedule_id, caregiver_id, type, start_time, end_time, start_latitude, start_longitude,
+		       end_latitude, end_longitude, notes, created_at
+		FROM visit_segments
+		WHERE schedule_id = ?
+		ORDER BY start_time ASC, id ASC`
+
+	rows, err := r.db.Query(query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []models.VisitSegment
+	for rows.Next() {
+		var seg models.VisitSegment
+		var notes sql.NullString
+		if err := rows.Scan(&seg.ID, &seg.ScheduleID, &seg.CaregiverID, &seg.Type, &seg.StartTime, &seg.EndTime,
+			&seg.StartLatitude, &seg.StartLongitude, &seg.EndLatitude, &seg.EndLongitude,
+			&notes, &seg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan visit segment: %w", err)
+		}
+		if notes.Valid {
+			seg.Notes = notes.String
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+// Create records a new visit segment
+func (r *visitSegmentRepository) Create(segment *models.VisitSegment) error {
+	return r.create(r.db, segment)
+}
+
+// Switch closes the running segment of a caregiver's visit and opens next in
+// its place, both at the same instant and location
+func (r *visitSegmentRepository) Switch(scheduleID, caregiverID int, at time.Time, latitude, longitude float64, next *models.VisitSegment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.close(tx, scheduleID, caregiverID, at, latitude, longitude); err != nil {
+		return err
+	}
+
+	next.ScheduleID = scheduleID
+	next.CaregiverID = caregiverID
+	next.StartTime = at
+	next.StartLatitude = &latitude
+	next.StartLongitude = &longitude
+	if err := r.create(tx, next); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit visit segment switch: %w", err)
+	}
+	return nil
+}
+
+// Close ends the running segment of a caregiver's visit, if there is one
+func (r *visitSegmentRepository) Close(scheduleID, caregiverID int, at time.Time, latitude, longitude float64) error {
+	return r.close(r.db, scheduleID, caregiverID, at, latitude, longitude)
+}
+
+// DeleteByScheduleAndCaregiver removes the recorded segments of a caregiver's visit on a schedule
+func (r *visitSegmentRepository) DeleteByScheduleAndCaregiver(scheduleID, caregiverID int) error {
+	if _, err := r.db.Exec("DELETE FROM visit_segments WHERE schedule_id = ? AND caregiver_id = ?", scheduleID, caregiverID); err != nil {
+		return fmt.Errorf("failed to delete visit segments: %w", err)
+	}
+	return nil
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func (r *visitSegmentRepository) create(db execer, segment *models.VisitSegment) error {
+	var endTimeFormatted interface{}
+	if segment.EndTime != nil {
+		endTimeFormatted = segment.EndTime.UTC().Format("2006-01-02 15:04:05")
+	}
+
+	query := `
+		INSERT INTO visit_segments (schedule_id, caregiver_id, type, start_time, end_time, start_latitude, start_longitude,
+		                            end_latitude, end_longitude, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := db.Exec(query, segment.ScheduleID, segment.CaregiverID, segment.Type,
+		segment.StartTime.UTC().Format("2006-01-02 15:04:05"), endTimeFormatted,
+		segment.StartLatitude, segment.StartLongitude, segment.EndLatitude, segment.EndLongitude,
+		segment.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to create visit segment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	segment.ID = int(id)
+	segment.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *visitSegmentRepository) close(db execer, scheduleID, caregiverID int, at time.Time, latitude, longitude float64) error {
+	query := `
+		UPDATE visit_segments
+		SET end_time = ?, end_latitude = ?, end_longitude = ?
+		WHERE schedule_id = ? AND caregiver_id = ? AND end_time IS NULL`
+
+	if _, err := db.Exec(query, at.UTC().Format("2006-01-02 15:04:05"), latitude, longitude, scheduleID, caregiverID); err != nil {
+		return fmt.Errorf("failed to close visit segment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// AddressGeocoder places a postal address on the map. It returns ErrNotFound
+// when it cannot place the address.
+type AddressGeocoder interface {
+	Geocode(address models.PostalAddress) (*models.GeocodeResult, error)
+}
+
+// Confidence of each geocode match
+var geocodeConfidence = map[string]float64{
+	models.GeocodeMatchManual:   1,
+	models.GeocodeMatchAddress:  1,
+	models.GeocodeMatchStreet:   0.7,
+	models.GeocodeMatchPostcode: 0.5,
+	models.GeocodeMatchLocality: 0.3,
+}
+
+// StubGeocoder geocodes a fixed list of addresses, for development and tests
+// without a place dataset. Addresses are compared once normalized.
+type StubGeocoder struct {
+	Results []models.GeocodeResult
+}
+
+// Geocode implements AddressGeocoder
+func (g StubGeocoder) Geocode(address models.PostalAddress) (*models.GeocodeResult, error) {
+	key := addressKey(address)
+	for _, result := range g.Results {
+		if addressKey(result.Address) == key {
+			result.Address = normalizeAddress(result.Address)
+			return &result, nil
+		}
+	}
+	return nil, fmt.Errorf("address %q: %w", key, ErrNotFound)
+}
+
+// streetSuffixes maps street type abbreviations to the names they stand for
+var streetSuffixes = map[string]string{
+	"st": "street", "ave": "avenue", "av": "avenue", "rd": "road", "dr": "drive",
+	"ln": "lane", "blvd": "boulevard", "ct": "court", "pl": "place", "ter": "terrace",
+	"cir": "circle", "hwy": "highway", "pkwy": "parkway", "sq": "square", "trl": "trail",
+}
+
+// normalizeAddress tidies an address as entered: spaces are collapsed, a
+// trailing street type abbreviation is spelled out and the state and zip code
+// are upper-cased
+func normalizeAddress(address models.PostalAddress) models.PostalAddress {
+	street := strings.Fields(strings.TrimRight(strings.TrimSpace(address.Street), ",."))
+	if n := len(street); n > 1 {
+		last := strings.TrimSuffix(street[n-1], ".")
+		if full, ok := streetSuffixes[strings.ToLower(last)]; ok {
+			street[n-1] = strings.ToUpper(full[:1]) + full[1:]
+		}
+	}
+
+	return models.PostalAddress{
+		Street:  strings.Join(street, " "),
+		City:    strings.Join(strings.Fields(address.City), " "),
+		State:   strings.ToUpper(strings.Join(strings.Fields(address.State), " ")),
+		ZipCode: strings.ToUpper(strings.Join(strings.Fields(address.ZipCode), " ")),
+	}
+}
+
+// streetKey reduces a street to lower-case words without punctuation and
+// with the street type spelled out, so that "12 Main St." and "12 main
+// street" compare equal
+func streetKey(street string) string {
+	words := strings.FieldsFunc(strings.ToLower(street), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if n := len(words); n > 1 {
+		if full, ok := streetSuffixes[words[n-1]]; ok {
+			words[n-1] = full
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// splitHouseNumber splits a street key into its leading house number, if
+// any, and the street name
+func splitHouseNumber(key string) (string, string) {
+	number, name, found := strings.Cut(key, " ")
+	if !found || !unicode.IsDigit(rune(number[0])) {
+		return "", key
+	}
+	return number, name
+}
+
+// addressKey is the comparable form of a whole address
+func addressKey(address models.PostalAddress) string {
+	return strings.Join([]string{
+		streetKey(address.Street),
+		strings.ToLower(strings.Join(strings.Fields(address.City), " ")),
+		strings.ToLower(strings.TrimSpace(address.State)),
+		strings.ToLower(strings.TrimSpace(address.ZipCode)),
+	}, "|")
+}
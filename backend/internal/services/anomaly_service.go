@@ -0,0 +1,364 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Points each risk signal adds to a clock event's risk score, out of 100
+const (
+	mockLocationPoints           = 60
+	impossibleTravelPoints       = 50
+	repeatedCoordinatesPoints    = 40
+	exactClientCoordinatesPoints = 30
+	roundedCoordinatesPoints     = 20
+	implausibleAccuracyPoints    = 20
+	poorAccuracyPoints           = 15
+)
+
+const (
+	// defaultMaxTravelSpeedKmh is the fastest a caregiver can plausibly travel
+	// between two clock events
+	defaultMaxTravelSpeedKmh = 120.0
+
+	// defaultReviewThreshold is the risk score from which a visit is queued for review
+	defaultReviewThreshold = 50
+
+	// minImpossibleTravelKm ignores GPS jitter between clock events close together
+	minImpossibleTravelKm = 1.0
+
+	// maxRoundedDecimals is the most decimals both coordinates may have to count
+	// as rounded; device fixes have at least five
+	maxRoundedDecimals = 4
+
+	// Device accuracies outside these bounds, in metres, are suspicious: mock
+	// providers often report zero, and a poor fix cannot place the caregiver
+	minPlausibleAccuracy = 1.0
+	maxUsableAccuracy    = 500.0
+
+	// repeatedCoordinatesLookback is how far back clock events at other
+	// clients are compared with
+	repeatedCoordinatesLookback = 90 * 24 * time.Hour
+)
+
+// AnomalyService scores clock events for the risk that the caregiver spoofed
+// their location and queues high-risk visits for review
+type AnomalyService struct {
+	eventRepo       repositories.ClockEventRepository
+	reviewRepo      repositories.VisitReviewRepository
+	scheduleRepo    repositories.ScheduleRepository
+	maxSpeedKmh     float64
+	reviewThreshold int
+	now             func() time.Time
+	logger          *logrus.Logger
+}
+
+// NewAnomalyService creates a new anomaly service; maxSpeedKmh and
+// reviewThreshold fall back to their defaults when not positive
+func NewAnomalyService(
+	eventRepo repositories.ClockEventRepository,
+	reviewRepo repositories.VisitReviewRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	maxSpeedKmh float64,
+	reviewThreshold int,
+	logger *logrus.Logger,
+) *AnomalyService {
+	if maxSpeedKmh <= 0 {
+		maxSpeedKmh = defaultMaxTravelSpeedKmh
+	}
+	if reviewThreshold <= 0 {
+		reviewThreshold = defaultReviewThreshold
+	}
+
+	return &AnomalyService{
+		eventRepo:       eventRepo,
+		reviewRepo:      reviewRepo,
+		scheduleRepo:    scheduleRepo,
+		maxSpeedKmh:     maxSpeedKmh,
+		reviewThreshold: reviewThreshold,
+		now:             time.Now,
+		logger:          logger,
+	}
+}
+
+// EvaluateClockEvent scores a clock-in or clock-out, stores it and queues the
+// visit for review when the score reaches the review threshold. It is
+// registered as a clock event hook. Events verified by a client token alone
+// or by telephony have no location to score and are stored with no risk.
+func (s *AnomalyService) EvaluateClockEvent(event *models.ClockEvent) error {
+	if event.VerificationMethod == models.VerificationMethodToken || event.VerificationMethod == models.VerificationMethodTelephony {
+		event.RiskScore, event.Signals = 0, []models.RiskSignal{}
+		if err := s.eventRepo.Create(event); err != nil {
+			s.logger.WithError(err).WithField("schedule_id", event.ScheduleID).Error("Failed to save clock event")
+			return fmt.Errorf("failed to save clock event: %w", err)
+		}
+		return nil
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(event.ScheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return fmt.Errorf("schedule %d: %w", event.ScheduleID, ErrNotFound)
+	}
+
+	previous, err := s.eventRepo.GetLastByCaregiver(event.CaregiverID, event.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("failed to get previous clock event: %w", err)
+	}
+	otherClients, err := s.eventRepo.CountClientsAt(event.Latitude, event.Longitude, event.ClientID,
+		event.RecordedAt.Add(-repeatedCoordinatesLookback))
+	if err != nil {
+		return fmt.Errorf("failed to check repeated coordinates: %w", err)
+	}
+
+	event.RiskScore, event.Signals = assessClockEvent(event, schedule.Client, previous, otherClients, s.maxSpeedKmh)
+	if err := s.eventRepo.Create(event); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", event.ScheduleID).Error("Failed to save clock event")
+		return fmt.Errorf("failed to save clock event: %w", err)
+	}
+
+	if event.RiskScore < s.reviewThreshold {
+		return nil
+	}
+
+	review := &models.VisitReview{ScheduleID: event.ScheduleID, CaregiverID: event.CaregiverID, RiskScore: event.RiskScore}
+	if err := s.reviewRepo.Queue(review); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", event.ScheduleID).Error("Failed to queue visit review")
+		return fmt.Errorf("failed to queue visit review: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  event.ScheduleID,
+		"caregiver_id": event.CaregiverID,
+		"event":        event.Event,
+		"risk_score":   event.RiskScore,
+		"review_id":    review.ID,
+	}).Warn("High-risk clock event queued for review")
+	return nil
+}
+
+// GetVisitRisks returns the spoofing risk of every caregiver's visit to a
+// schedule that has clock events
+func (s *AnomalyService) GetVisitRisks(scheduleID int) ([]models.VisitRisk, error) {
+	events, err := s.eventRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clock events: %w", err)
+	}
+	reviews, err := s.reviewRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit reviews: %w", err)
+	}
+
+	index := map[int]int{}
+	var risks []models.VisitRisk
+	for _, event := range events {
+		i, ok := index[event.CaregiverID]
+		if !ok {
+			i = len(risks)
+			index[event.CaregiverID] = i
+			risks = append(risks, models.VisitRisk{ScheduleID: scheduleID, CaregiverID: event.CaregiverID, Signals: []models.RiskSignal{}})
+		}
+		if event.RiskScore > risks[i].Score {
+			risks[i].Score = event.RiskScore
+		}
+		risks[i].Signals = append(risks[i].Signals, event.Signals...)
+	}
+	for _, review := range reviews {
+		if i, ok := index[review.CaregiverID]; ok {
+			id := review.ID
+			risks[i].ReviewID = &id
+			risks[i].ReviewStatus = review.Status
+		}
+	}
+
+	return risks, nil
+}
+
+// GetReviews returns the visit reviews in a status, riskiest first
+func (s *AnomalyService) GetReviews(status string) ([]models.VisitReview, error) {
+	switch status {
+	case models.VisitReviewPending, models.VisitReviewCleared, models.VisitReviewConfirmed:
+	default:
+		return nil, fmt.Errorf("%w: unknown review status %q", ErrValidation, status)
+	}
+
+	reviews, err := s.reviewRepo.GetByStatus(status)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get visit reviews")
+		return nil, fmt.Errorf("failed to get visit reviews: %w", err)
+	}
+	if reviews == nil {
+		reviews = []models.VisitReview{}
+	}
+
+	for i := range reviews {
+		if err := s.attachSignals(&reviews[i]); err != nil {
+			return nil, err
+		}
+	}
+	return reviews, nil
+}
+
+// GetReview returns a visit review with the risk signals of its visit
+func (s *AnomalyService) GetReview(id int) (*models.VisitReview, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit review: %w", err)
+	}
+	if review == nil {
+		return nil, fmt.Errorf("visit review %d: %w", id, ErrNotFound)
+	}
+
+	if err := s.attachSignals(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// ResolveReview records a coordinator's decision on a pending visit review:
+// cleared when the caregiver was at the client, confirmed when they were not
+func (s *AnomalyService) ResolveReview(id int, req *models.VisitReviewResolveRequest) (*models.VisitReview, error) {
+	s.logger.WithFields(logrus.Fields{
+		"visit_review_id": id,
+		"status":          req.Status,
+	}).Info("Resolving visit review")
+
+	notes := strings.TrimSpace(req.Notes)
+	switch req.Status {
+	case models.VisitReviewCleared:
+	case models.VisitReviewConfirmed:
+		if notes == "" {
+			return nil, fmt.Errorf("%w: notes are required to confirm a spoofed location", ErrValidation)
+		}
+	default:
+		return nil, fmt.Errorf("%w: status must be 'cleared' or 'confirmed'", ErrValidation)
+	}
+
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit review: %w", err)
+	}
+	if review == nil {
+		return nil, fmt.Errorf("visit review %d: %w", id, ErrNotFound)
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != review.Version {
+		return nil, fmt.Errorf("visit review %d is at version %d: %w", id, review.Version, ErrVersionConflict)
+	}
+	if review.Status != models.VisitReviewPending {
+		return nil, fmt.Errorf("%w: visit review %d is already %s", ErrInvalidTransition, id, review.Status)
+	}
+
+	now := s.now().UTC()
+	review.Status = req.Status
+	review.ResolutionNotes = notes
+	review.ReviewedAt = &now
+	if err := s.reviewRepo.Update(review); err != nil {
+		s.logger.WithError(err).WithField("visit_review_id", id).Error("Failed to resolve visit review")
+		return nil, fmt.Errorf("failed to resolve visit review: %w", err)
+	}
+
+	if err := s.attachSignals(review); err != nil {
+		return nil, err
+	}
+	s.logger.WithField("visit_review_id", id).Info("Successfully resolved visit review")
+	return review, nil
+}
+
+// attachSignals adds the risk signals of the reviewed visit's clock events to a review
+func (s *AnomalyService) attachSignals(review *models.VisitReview) error {
+	events, err := s.eventRepo.GetByScheduleID(review.ScheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get clock events: %w", err)
+	}
+
+	review.Signals = []models.RiskSignal{}
+	for _, event := range events {
+		if event.CaregiverID == review.CaregiverID {
+			review.Signals = append(review.Signals, event.Signals...)
+		}
+	}
+	return nil
+}
+
+// assessClockEvent scores a clock event from 0 to 100 for the risk that its
+// location was spoofed. previous is the caregiver's clock event before it, if
+// any, and otherClients the number of other clients with a clock event at the
+// same coordinates.
+func assessClockEvent(event *models.ClockEvent, client *models.Client, previous *models.ClockEvent, otherClients int, maxSpeedKmh float64) (int, []models.RiskSignal) {
+	signals := []models.RiskSignal{}
+	raise := func(code string, points int, detail string) {
+		signals = append(signals, models.RiskSignal{Code: code, Event: event.Event, Points: points, Detail: detail})
+	}
+
+	if event.MockLocation {
+		raise(models.RiskSignalMockLocation, mockLocationPoints, "The device reported a mock location provider")
+	}
+
+	if previous != nil {
+		km := distanceKm(previous.Latitude, previous.Longitude, event.Latitude, event.Longitude)
+		elapsed := event.RecordedAt.Sub(previous.RecordedAt)
+		if km > minImpossibleTravelKm && (elapsed <= 0 || km/elapsed.Hours() > maxSpeedKmh) {
+			raise(models.RiskSignalImpossibleTravel, impossibleTravelPoints, fmt.Sprintf(
+				"%.1f km from the %s of schedule %d in %.0f minutes", km, strings.ReplaceAll(previous.Event, "_", "-"),
+				previous.ScheduleID, math.Max(elapsed.Minutes(), 0)))
+		}
+	}
+
+	if otherClients > 0 {
+		raise(models.RiskSignalRepeatedCoordinates, repeatedCoordinatesPoints, fmt.Sprintf(
+			"The same coordinates were reported at %d other client(s)", otherClients))
+	}
+
+	if hasCoordinates(client) && sameCoordinates(event.Latitude, client.Latitude) && sameCoordinates(event.Longitude, client.Longitude) {
+		raise(models.RiskSignalExactClientCoordinates, exactClientCoordinatesPoints,
+			"The coordinates are exactly the client's address coordinates")
+	}
+
+	if decimalPlaces(event.Latitude) <= maxRoundedDecimals && decimalPlaces(event.Longitude) <= maxRoundedDecimals {
+		raise(models.RiskSignalRoundedCoordinates, roundedCoordinatesPoints, fmt.Sprintf(
+			"The coordinates have at most %d decimals", maxRoundedDecimals))
+	}
+
+	if event.Accuracy != nil {
+		switch {
+		case *event.Accuracy < minPlausibleAccuracy:
+			raise(models.RiskSignalImplausibleAccuracy, implausibleAccuracyPoints, fmt.Sprintf(
+				"The device reported an accuracy of %.1f m", *event.Accuracy))
+		case *event.Accuracy > maxUsableAccuracy:
+			raise(models.RiskSignalPoorAccuracy, poorAccuracyPoints, fmt.Sprintf(
+				"The device reported an accuracy of %.0f m", *event.Accuracy))
+		}
+	}
+
+	score := 0
+	for _, signal := range signals {
+		score += signal.Points
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, signals
+}
+
+// sameCoordinates reports whether two coordinates are equal to six decimals, about 10 cm
+func sameCoordinates(a, b float64) bool {
+	return math.Round(a*1e6) == math.Round(b*1e6)
+}
+
+// decimalPlaces counts the decimals of the shortest representation of v
+func decimalPlaces(v float64) int {
+	str := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		return len(str) - i - 1
+	}
+	return 0
+}
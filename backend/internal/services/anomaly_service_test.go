@@ -0,0 +1,223 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockClockEventRepository is a mock implementation of ClockEventRepository
+type MockClockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockClockEventRepository) GetByScheduleID(scheduleID int) ([]models.ClockEvent, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.ClockEvent), args.Error(1)
+}
+
+func (m *MockClockEventRepository) GetLastByCaregiver(caregiverID int, before time.Time) (*models.ClockEvent, error) {
+	args := m.Called(caregiverID, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClockEvent), args.Error(1)
+}
+
+func (m *MockClockEventRepository) CountClientsAt(latitude, longitude float64, excludeClientID int, since time.Time) (int, error) {
+	args := m.Called(latitude, longitude, excludeClientID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockClockEventRepository) Create(event *models.ClockEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+// MockVisitReviewRepository is a mock implementation of VisitReviewRepository
+type MockVisitReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockVisitReviewRepository) GetByID(id int) (*models.VisitReview, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitReview), args.Error(1)
+}
+
+func (m *MockVisitReviewRepository) GetByStatus(status string) ([]models.VisitReview, error) {
+	args := m.Called(status)
+	return args.Get(0).([]models.VisitReview), args.Error(1)
+}
+
+func (m *MockVisitReviewRepository) GetByScheduleID(scheduleID int) ([]models.VisitReview, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.VisitReview), args.Error(1)
+}
+
+func (m *MockVisitReviewRepository) Queue(review *models.VisitReview) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockVisitReviewRepository) Update(review *models.VisitReview) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+// signalCodes lists the codes of risk signals in order
+func signalCodes(signals []models.RiskSignal) []string {
+	codes := []string{}
+	for _, signal := range signals {
+		codes = append(codes, signal.Code)
+	}
+	return codes
+}
+
+func TestAssessClockEvent(t *testing.T) {
+	client := &models.Client{Latitude: 39.7817, Longitude: -89.6501}
+	at := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	good := 12.0
+	clockIn := func(lat, lng float64) *models.ClockEvent {
+		return &models.ClockEvent{Event: models.ClockEventIn, Latitude: lat, Longitude: lng, Accuracy: &good, RecordedAt: at}
+	}
+
+	// A device fix a few metres from the client raises nothing
+	score, signals := assessClockEvent(clockIn(39.781734, -89.650153), client, nil, 0, 120)
+	assert.Equal(t, 0, score)
+	assert.Empty(t, signals)
+
+	// The client's own address coordinates are too precise and too round
+	score, signals = assessClockEvent(clockIn(39.7817, -89.6501), client, nil, 0, 120)
+	assert.Equal(t, 50, score)
+	assert.Equal(t, []string{models.RiskSignalExactClientCoordinates, models.RiskSignalRoundedCoordinates}, signalCodes(signals))
+	assert.Equal(t, models.ClockEventIn, signals[0].Event)
+
+	// 44 km from the previous clock-out in 10 minutes, at coordinates used at
+	// another client, from a mock provider claiming perfect accuracy
+	previous := &models.ClockEvent{ScheduleID: 7, Event: models.ClockEventOut, Latitude: 39.381734, Longitude: -89.650153,
+		RecordedAt: at.Add(-10 * time.Minute)}
+	event := clockIn(39.781734, -89.650153)
+	zero := 0.0
+	event.Accuracy = &zero
+	event.MockLocation = true
+	score, signals = assessClockEvent(event, client, previous, 2, 120)
+	assert.Equal(t, 100, score)
+	assert.Equal(t, []string{models.RiskSignalMockLocation, models.RiskSignalImpossibleTravel,
+		models.RiskSignalRepeatedCoordinates, models.RiskSignalImplausibleAccuracy}, signalCodes(signals))
+	assert.Equal(t, "44.5 km from the clock-out of schedule 7 in 10 minutes", signals[1].Detail)
+
+	// The same trip over an hour is plausible; a poor fix is still flagged
+	previous.RecordedAt = at.Add(-time.Hour)
+	poor := 900.0
+	event = clockIn(39.781734, -89.650153)
+	event.Accuracy = &poor
+	score, signals = assessClockEvent(event, client, previous, 0, 120)
+	assert.Equal(t, poorAccuracyPoints, score)
+	assert.Equal(t, []string{models.RiskSignalPoorAccuracy}, signalCodes(signals))
+}
+
+func TestAnomalyService_EvaluateClockEvent(t *testing.T) {
+	eventRepo := new(MockClockEventRepository)
+	reviewRepo := new(MockVisitReviewRepository)
+	scheduleRepo := new(MockScheduleRepository)
+	service := NewAnomalyService(eventRepo, reviewRepo, scheduleRepo, 0, 0, logrus.New())
+
+	at := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	scheduleRepo.On("GetByID", 3).Return(&models.Schedule{ID: 3, ClientID: 101, Client: &models.Client{ID: 101}}, nil)
+	eventRepo.On("GetLastByCaregiver", 1, at).Return(nil, nil)
+	eventRepo.On("CountClientsAt", 39.781734, -89.650153, 101, at.Add(-repeatedCoordinatesLookback)).Return(0, nil).Once()
+	eventRepo.On("CountClientsAt", 39.781734, -89.650153, 101, at.Add(-repeatedCoordinatesLookback)).Return(1, nil).Once()
+	eventRepo.On("Create", mock.Anything).Return(nil)
+
+	// A low-risk clock-in is stored without a review
+	event := &models.ClockEvent{ScheduleID: 3, CaregiverID: 1, ClientID: 101, Event: models.ClockEventIn,
+		Latitude: 39.781734, Longitude: -89.650153, RecordedAt: at}
+	assert.NoError(t, service.EvaluateClockEvent(event))
+	assert.Equal(t, 0, event.RiskScore)
+
+	// A mocked location at coordinates used at another client is queued
+	reviewRepo.On("Queue", mock.MatchedBy(func(review *models.VisitReview) bool {
+		return review.ScheduleID == 3 && review.CaregiverID == 1 && review.RiskScore == 100
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.VisitReview).ID = 5
+	}).Return(nil).Once()
+
+	event = &models.ClockEvent{ScheduleID: 3, CaregiverID: 1, ClientID: 101, Event: models.ClockEventIn,
+		Latitude: 39.781734, Longitude: -89.650153, MockLocation: true, RecordedAt: at}
+	assert.NoError(t, service.EvaluateClockEvent(event))
+	assert.Equal(t, 100, event.RiskScore)
+	eventRepo.AssertNumberOfCalls(t, "Create", 2)
+	reviewRepo.AssertExpectations(t)
+}
+
+func TestAnomalyService_GetVisitRisks(t *testing.T) {
+	eventRepo := new(MockClockEventRepository)
+	reviewRepo := new(MockVisitReviewRepository)
+	service := NewAnomalyService(eventRepo, reviewRepo, new(MockScheduleRepository), 0, 0, logrus.New())
+
+	rounded := models.RiskSignal{Code: models.RiskSignalRoundedCoordinates, Event: models.ClockEventIn, Points: 20}
+	mocked := models.RiskSignal{Code: models.RiskSignalMockLocation, Event: models.ClockEventOut, Points: 60}
+	eventRepo.On("GetByScheduleID", 3).Return([]models.ClockEvent{
+		{CaregiverID: 2, Event: models.ClockEventIn, Signals: []models.RiskSignal{}},
+		{CaregiverID: 1, Event: models.ClockEventIn, RiskScore: 20, Signals: []models.RiskSignal{rounded}},
+		{CaregiverID: 1, Event: models.ClockEventOut, RiskScore: 60, Signals: []models.RiskSignal{mocked}},
+	}, nil)
+	reviewRepo.On("GetByScheduleID", 3).Return([]models.VisitReview{{ID: 5, CaregiverID: 1, Status: models.VisitReviewPending}}, nil)
+
+	risks, err := service.GetVisitRisks(3)
+	assert.NoError(t, err)
+	if assert.Len(t, risks, 2) {
+		assert.Equal(t, 2, risks[0].CaregiverID)
+		assert.Zero(t, risks[0].Score)
+		assert.Nil(t, risks[0].ReviewID)
+		assert.Equal(t, 60, risks[1].Score)
+		assert.Equal(t, []models.RiskSignal{rounded, mocked}, risks[1].Signals)
+		assert.Equal(t, 5, *risks[1].ReviewID)
+		assert.Equal(t, models.VisitReviewPending, risks[1].ReviewStatus)
+	}
+}
+
+func TestAnomalyService_ResolveReview(t *testing.T) {
+	eventRepo := new(MockClockEventRepository)
+	reviewRepo := new(MockVisitReviewRepository)
+	service := NewAnomalyService(eventRepo, reviewRepo, new(MockScheduleRepository), 0, 0, logrus.New())
+	now := time.Date(2025, 1, 7, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	_, err := service.ResolveReview(5, &models.VisitReviewResolveRequest{Status: models.VisitReviewConfirmed, Notes: " "})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = service.ResolveReview(5, &models.VisitReviewResolveRequest{Status: models.VisitReviewPending})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	reviewRepo.On("GetByID", 5).Return(&models.VisitReview{ID: 5, ScheduleID: 3, CaregiverID: 1, Status: models.VisitReviewPending, Version: 2}, nil).Twice()
+	stale := 1
+	_, err = service.ResolveReview(5, &models.VisitReviewResolveRequest{Status: models.VisitReviewCleared, ExpectedVersion: &stale})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	reviewRepo.On("Update", mock.MatchedBy(func(review *models.VisitReview) bool {
+		return review.Status == models.VisitReviewCleared && review.ResolutionNotes == "Called the client" && review.ReviewedAt.Equal(now)
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.VisitReview).Version++
+	}).Return(nil).Once()
+	eventRepo.On("GetByScheduleID", 3).Return([]models.ClockEvent{
+		{CaregiverID: 1, Signals: []models.RiskSignal{{Code: models.RiskSignalMockLocation}}},
+		{CaregiverID: 2, Signals: []models.RiskSignal{{Code: models.RiskSignalPoorAccuracy}}},
+	}, nil)
+
+	review, err := service.ResolveReview(5, &models.VisitReviewResolveRequest{Status: models.VisitReviewCleared, Notes: " Called the client "})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, review.Version)
+	assert.Equal(t, []string{models.RiskSignalMockLocation}, signalCodes(review.Signals))
+
+	reviewRepo.On("GetByID", 6).Return(&models.VisitReview{ID: 6, Status: models.VisitReviewCleared, Version: 3}, nil)
+	_, err = service.ResolveReview(6, &models.VisitReviewResolveRequest{Status: models.VisitReviewConfirmed, Notes: "Was at home"})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	reviewRepo.AssertExpectations(t)
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// hoursPerWeek bounds the weekly hour limit a caregiver can declare
+const hoursPerWeek = 7 * 24
+
+// AvailabilityService handles caregiver availability, working-hour
+// preferences and time-off requests, and decides whether a caregiver is free
+// to take a schedule
+type AvailabilityService struct {
+	availabilityRepo repositories.AvailabilityRepository
+	timeOffRepo      repositories.TimeOffRepository
+	scheduleRepo     repositories.ScheduleRepository
+	states           *StateMachine[*models.TimeOffRequest]
+	location         *time.Location // Agency default for caregivers without a timezone
+	now              func() time.Time
+	logger           *logrus.Logger
+}
+
+// NewAvailabilityService creates a new availability service; location is the
+// agency default timezone, UTC when nil
+func NewAvailabilityService(
+	availabilityRepo repositories.AvailabilityRepository,
+	timeOffRepo repositories.TimeOffRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	location *time.Location,
+	logger *logrus.Logger,
+) *AvailabilityService {
+	if location == nil {
+		location = time.UTC
+	}
+
+	s := &AvailabilityService{
+		availabilityRepo: availabilityRepo,
+		timeOffRepo:      timeOffRepo,
+		scheduleRepo:     scheduleRepo,
+		states:           NewTimeOffStateMachine(),
+		location:         location,
+		now:              time.Now,
+		logger:           logger,
+	}
+	s.states.now = func() time.Time { return s.now() }
+
+	return s
+}
+
+// GetAvailability retrieves a caregiver's availability windows and preferences.
+// A caregiver who never declared any is returned with no windows.
+func (s *AvailabilityService) GetAvailability(caregiverID int) (*models.CaregiverAvailability, error) {
+	availability, err := s.availabilityRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get availability")
+		return nil, fmt.Errorf("failed to get availability: %w", err)
+	}
+	if availability == nil {
+		availability = &models.CaregiverAvailability{CaregiverID: caregiverID}
+	}
+	if availability.Windows == nil {
+		availability.Windows = []models.AvailabilityWindow{}
+	}
+
+	return availability, nil
+}
+
+// SetAvailability replaces a caregiver's availability windows and preferences
+func (s *AvailabilityService) SetAvailability(caregiverID int, req *models.CaregiverAvailabilityRequest) (*models.CaregiverAvailability, error) {
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"windows":      len(req.Windows),
+	}).Info("Setting caregiver availability")
+
+	if caregiverID <= 0 {
+		return nil, fmt.Errorf("%w: invalid caregiver ID", ErrValidation)
+	}
+	if err := validateTimezone(req.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if req.MaxWeeklyHours != nil && (*req.MaxWeeklyHours <= 0 || *req.MaxWeeklyHours > hoursPerWeek) {
+		return nil, fmt.Errorf("%w: max_weekly_hours must be between 0 and %d", ErrValidation, hoursPerWeek)
+	}
+	for i, w := range req.Windows {
+		if w.Weekday < 0 || w.Weekday > 6 {
+			return nil, fmt.Errorf("%w: window %d: weekday must be between 0 (Sunday) and 6", ErrValidation, i)
+		}
+		if _, err := clockMinutes(w.StartTime); err != nil {
+			return nil, fmt.Errorf("%w: window %d: %v", ErrValidation, i, err)
+		}
+		if _, err := clockMinutes(w.EndTime); err != nil {
+			return nil, fmt.Errorf("%w: window %d: %v", ErrValidation, i, err)
+		}
+	}
+
+	availability := &models.CaregiverAvailability{
+		CaregiverID:    caregiverID,
+		Timezone:       req.Timezone,
+		MaxWeeklyHours: req.MaxWeeklyHours,
+		Windows:        req.Windows,
+	}
+	if availability.Windows == nil {
+		availability.Windows = []models.AvailabilityWindow{}
+	}
+	if err := s.availabilityRepo.Replace(availability); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to set availability")
+		return nil, fmt.Errorf("failed to set availability: %w", err)
+	}
+
+	s.logger.WithField("caregiver_id", caregiverID).Info("Successfully set caregiver availability")
+	return availability, nil
+}
+
+// GetTimeOff lists a caregiver's time-off requests, latest first
+func (s *AvailabilityService) GetTimeOff(caregiverID int) ([]models.TimeOffRequest, error) {
+	requests, err := s.timeOffRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get time off")
+		return nil, fmt.Errorf("failed to get time off: %w", err)
+	}
+	if requests == nil {
+		requests = []models.TimeOffRequest{}
+	}
+
+	return requests, nil
+}
+
+// RequestTimeOff records a pending time-off request for a caregiver
+func (s *AvailabilityService) RequestTimeOff(caregiverID int, req *models.TimeOffCreateRequest) (*models.TimeOffRequest, error) {
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"start_time":   req.StartTime,
+		"end_time":     req.EndTime,
+	}).Info("Requesting time off")
+
+	if caregiverID <= 0 {
+		return nil, fmt.Errorf("%w: invalid caregiver ID", ErrValidation)
+	}
+	if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		return nil, fmt.Errorf("%w: start_time and end_time are required", ErrValidation)
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrValidation)
+	}
+
+	request := &models.TimeOffRequest{
+		CaregiverID: caregiverID,
+		StartTime:   req.StartTime.UTC(),
+		EndTime:     req.EndTime.UTC(),
+		Reason:      req.Reason,
+		Status:      models.TimeOffStatusPending,
+	}
+	if err := s.timeOffRepo.Create(request); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to create time-off request")
+		return nil, fmt.Errorf("failed to create time-off request: %w", err)
+	}
+
+	s.logger.WithField("time_off_id", request.ID).Info("Successfully requested time off")
+	return request, nil
+}
+
+// ApproveTimeOff approves a pending time-off request; the caregiver can no
+// longer be booked during it
+func (s *AvailabilityService) ApproveTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
+	return s.reviewTimeOff(id, TimeOffEventApprove, req)
+}
+
+// RejectTimeOff rejects a pending time-off request
+func (s *AvailabilityService) RejectTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
+	return s.reviewTimeOff(id, TimeOffEventReject, req)
+}
+
+// CancelTimeOff withdraws a pending or approved time-off request
+func (s *AvailabilityService) CancelTimeOff(id int, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
+	return s.reviewTimeOff(id, TimeOffEventCancel, req)
+}
+
+// reviewTimeOff applies a review event to a time-off request
+func (s *AvailabilityService) reviewTimeOff(id int, event string, req *models.TimeOffReviewRequest) (*models.TimeOffRequest, error) {
+	s.logger.WithFields(logrus.Fields{
+		"time_off_id": id,
+		"event":       event,
+	}).Info("Reviewing time-off request")
+
+	request, err := s.timeOffRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time-off request: %w", err)
+	}
+	if request == nil {
+		return nil, fmt.Errorf("time-off request %d: %w", id, ErrNotFound)
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != request.Version {
+		return nil, fmt.Errorf("time-off request %d is at version %d: %w", id, request.Version, ErrVersionConflict)
+	}
+
+	transition, err := s.states.Fire(request, event)
+	if err != nil {
+		s.logger.WithError(err).WithField("time_off_id", id).Warn("Time-off request cannot be reviewed")
+		return nil, err
+	}
+
+	request.Status = transition.To
+	request.ReviewNotes = req.Notes
+	request.ReviewedAt = &transition.At
+	if err := s.timeOffRepo.Update(request); err != nil {
+		s.logger.WithError(err).WithField("time_off_id", id).Error("Failed to update time-off request")
+		return nil, fmt.Errorf("failed to update time-off request: %w", err)
+	}
+	if err := s.states.Complete(request, transition); err != nil {
+		s.logger.WithError(err).WithField("time_off_id", id).Warn("Time-off transition hook failed")
+	}
+
+	return request, nil
+}
+
+// ValidateAssignment checks that a caregiver is available for the whole of a
+// schedule. It is registered with the schedule service through OnAssign.
+func (s *AvailabilityService) ValidateAssignment(schedule *models.Schedule, caregiverID int) error {
+	return s.CheckAvailability(caregiverID, schedule.StartTime, schedule.EndTime, schedule.ID)
+}
+
+// CheckAvailability returns ErrCaregiverUnavailable when a caregiver cannot
+// work from start to end: outside their availability windows, during approved
+// time off, while booked on another schedule, or beyond their weekly hour
+// limit. excludeScheduleID is left out of the booking checks, e.g. the
+// schedule being reassigned.
+func (s *AvailabilityService) CheckAvailability(caregiverID int, start, end time.Time, excludeScheduleID int) error {
+	availability, err := s.availabilityRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get availability: %w", err)
+	}
+
+	loc := s.location
+	if availability != nil {
+		loc = timezoneLocation(availability.Timezone, s.location)
+		if !withinWindows(availability.Windows, loc, start, end) {
+			return fmt.Errorf("%w: caregiver %d is not available from %s to %s", ErrCaregiverUnavailable,
+				caregiverID, start.In(loc).Format("Mon 15:04"), end.In(loc).Format("Mon 15:04"))
+		}
+	}
+
+	timeOff, err := s.timeOffRepo.GetOverlapping(caregiverID, start, end, models.TimeOffStatusApproved)
+	if err != nil {
+		return fmt.Errorf("failed to get time off: %w", err)
+	}
+	if len(timeOff) > 0 {
+		return fmt.Errorf("%w: caregiver %d is on approved time off from %s to %s", ErrCaregiverUnavailable,
+			caregiverID, timeOff[0].StartTime.In(loc).Format("2006-01-02 15:04"), timeOff[0].EndTime.In(loc).Format("2006-01-02 15:04"))
+	}
+
+	booked, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		ActiveFrom:  &start,
+		ActiveTo:    &end,
+		Statuses:    []string{models.ScheduleStatusScheduled, models.ScheduleStatusInProgress},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get schedules: %w", err)
+	}
+	for _, schedule := range booked {
+		if schedule.ID != excludeScheduleID {
+			return fmt.Errorf("%w: caregiver %d is already booked on schedule %d", ErrCaregiverUnavailable, caregiverID, schedule.ID)
+		}
+	}
+
+	if availability != nil && availability.MaxWeeklyHours != nil {
+		if err := s.checkWeeklyHours(caregiverID, *availability.MaxWeeklyHours, loc, start, end, excludeScheduleID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkWeeklyHours checks that adding the shift from start to end keeps the
+// caregiver within their weekly hour limit in every week the shift touches.
+// Weeks run from Monday to Sunday in the caregiver's timezone.
+func (s *AvailabilityService) checkWeeklyHours(caregiverID int, maxHours float64, loc *time.Location, start, end time.Time, excludeScheduleID int) error {
+	for weekStart := weekStartOf(start, loc); weekStart.Before(end); weekStart = weekStart.AddDate(0, 0, 7) {
+		weekEnd := weekStart.AddDate(0, 0, 7)
+
+		booked, err := s.bookedTime(caregiverID, weekStart, weekEnd, excludeScheduleID)
+		if err != nil {
+			return err
+		}
+
+		if hours := (booked + overlap(start, end, weekStart, weekEnd)).Hours(); hours > maxHours {
+			return fmt.Errorf("%w: caregiver %d would work %.1f hours in the week of %s, over their limit of %.1f",
+				ErrCaregiverUnavailable, caregiverID, hours, weekStart.Format("2006-01-02"), maxHours)
+		}
+	}
+
+	return nil
+}
+
+// bookedTime returns how long a caregiver is booked between weekStart and
+// weekEnd, leaving out excludeScheduleID
+func (s *AvailabilityService) bookedTime(caregiverID int, weekStart, weekEnd time.Time, excludeScheduleID int) (time.Duration, error) {
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		ActiveFrom:  &weekStart,
+		ActiveTo:    &weekEnd,
+		Statuses:    []string{models.ScheduleStatusScheduled, models.ScheduleStatusInProgress, models.ScheduleStatusCompleted},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	var total time.Duration
+	for _, schedule := range schedules {
+		if schedule.ID != excludeScheduleID {
+			total += overlap(schedule.StartTime, schedule.EndTime, weekStart, weekEnd)
+		}
+	}
+	return total, nil
+}
+
+// WeeklyHours returns the hours a caregiver is booked in the week containing
+// at, leaving out excludeScheduleID, and their weekly hour limit, nil when
+// they have none
+func (s *AvailabilityService) WeeklyHours(caregiverID int, at time.Time, excludeScheduleID int) (float64, *float64, error) {
+	availability, err := s.availabilityRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get availability: %w", err)
+	}
+
+	loc := s.location
+	var limit *float64
+	if availability != nil {
+		loc = timezoneLocation(availability.Timezone, s.location)
+		limit = availability.MaxWeeklyHours
+	}
+
+	weekStart := weekStartOf(at, loc)
+	booked, err := s.bookedTime(caregiverID, weekStart, weekStart.AddDate(0, 0, 7), excludeScheduleID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return roundHours(booked), limit, nil
+}
+
+// GetAvailableCaregivers lists the caregivers who are free to work from start to end
+func (s *AvailabilityService) GetAvailableCaregivers(start, end time.Time) ([]int, error) {
+	if start.IsZero() || end.IsZero() {
+		return nil, fmt.Errorf("%w: start and end are required", ErrValidation)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrValidation)
+	}
+
+	caregiverIDs, err := s.availabilityRepo.GetCaregiverIDs()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get caregivers")
+		return nil, fmt.Errorf("failed to get caregivers: %w", err)
+	}
+
+	available := []int{}
+	for _, caregiverID := range caregiverIDs {
+		err := s.CheckAvailability(caregiverID, start, end, 0)
+		if errors.Is(err, ErrCaregiverUnavailable) {
+			continue
+		}
+		if err != nil {
+			s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to check availability")
+			return nil, err
+		}
+		available = append(available, caregiverID)
+	}
+
+	return available, nil
+}
+
+// clockMinutes parses an HH:MM time of day into minutes after midnight
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// withinWindows reports whether the recurring windows cover the whole range
+// from start to end. No windows means no restriction.
+func withinWindows(windows []models.AvailabilityWindow, loc *time.Location, start, end time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+
+	// Lay the windows out on every day the range touches, starting the day
+	// before so that overnight windows reaching into the first day count
+	type interval struct{ start, end time.Time }
+	var intervals []interval
+	first := start.In(loc)
+	for day := time.Date(first.Year(), first.Month(), first.Day()-1, 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		for _, w := range windows {
+			if int(day.Weekday()) != w.Weekday {
+				continue
+			}
+			from, err := clockMinutes(w.StartTime)
+			if err != nil {
+				continue
+			}
+			to, err := clockMinutes(w.EndTime)
+			if err != nil {
+				continue
+			}
+			nextDay := 0
+			if to <= from {
+				nextDay = 1
+			}
+			intervals = append(intervals, interval{
+				start: time.Date(day.Year(), day.Month(), day.Day(), 0, from, 0, 0, loc),
+				end:   time.Date(day.Year(), day.Month(), day.Day()+nextDay, 0, to, 0, 0, loc),
+			})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+
+	// Walk the windows in order, extending the covered range until it reaches
+	// the end or a gap is found
+	covered := start
+	for _, iv := range intervals {
+		if iv.start.After(covered) {
+			break
+		}
+		if iv.end.After(covered) {
+			covered = iv.end
+		}
+	}
+	return !covered.Before(end)
+}
+
+// weekStartOf returns midnight on the Monday of the week containing t
+func weekStartOf(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	offset := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, loc)
+}
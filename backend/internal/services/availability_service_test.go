@@ -0,0 +1,282 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAvailabilityRepository is a mock implementation of AvailabilityRepository
+type MockAvailabilityRepository struct {
+	mock.Mock
+}
+
+func (m *MockAvailabilityRepository) GetByCaregiverID(caregiverID int) (*models.CaregiverAvailability, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverAvailability), args.Error(1)
+}
+
+func (m *MockAvailabilityRepository) Replace(availability *models.CaregiverAvailability) error {
+	args := m.Called(availability)
+	return args.Error(0)
+}
+
+func (m *MockAvailabilityRepository) GetCaregiverIDs() ([]int, error) {
+	args := m.Called()
+	return args.Get(0).([]int), args.Error(1)
+}
+
+// MockTimeOffRepository is a mock implementation of TimeOffRepository
+type MockTimeOffRepository struct {
+	mock.Mock
+}
+
+func (m *MockTimeOffRepository) GetByID(id int) (*models.TimeOffRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeOffRequest), args.Error(1)
+}
+
+func (m *MockTimeOffRepository) GetByCaregiverID(caregiverID int) ([]models.TimeOffRequest, error) {
+	args := m.Called(caregiverID)
+	return args.Get(0).([]models.TimeOffRequest), args.Error(1)
+}
+
+func (m *MockTimeOffRepository) GetOverlapping(caregiverID int, start, end time.Time, status string) ([]models.TimeOffRequest, error) {
+	args := m.Called(caregiverID, start, end, status)
+	return args.Get(0).([]models.TimeOffRequest), args.Error(1)
+}
+
+func (m *MockTimeOffRepository) Create(request *models.TimeOffRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockTimeOffRepository) Update(request *models.TimeOffRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func newAvailabilityTestService() (*AvailabilityService, *MockAvailabilityRepository, *MockTimeOffRepository, *MockScheduleRepository) {
+	availabilityRepo := new(MockAvailabilityRepository)
+	timeOffRepo := new(MockTimeOffRepository)
+	scheduleRepo := new(MockScheduleRepository)
+	service := NewAvailabilityService(availabilityRepo, timeOffRepo, scheduleRepo, nil, logrus.New())
+	return service, availabilityRepo, timeOffRepo, scheduleRepo
+}
+
+func TestWithinWindows(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	weekdayMornings := []models.AvailabilityWindow{
+		{Weekday: 1, StartTime: "08:00", EndTime: "12:00"},
+		{Weekday: 2, StartTime: "08:00", EndTime: "12:00"},
+	}
+	nights := []models.AvailabilityWindow{
+		{Weekday: 5, StartTime: "22:00", EndTime: "06:00"},
+		{Weekday: 6, StartTime: "06:00", EndTime: "10:00"},
+	}
+
+	// 2025-01-06 is a Monday
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 1, day, hour, minute, 0, 0, jakarta) }
+
+	cases := []struct {
+		name    string
+		windows []models.AvailabilityWindow
+		start   time.Time
+		end     time.Time
+		want    bool
+	}{
+		{name: "no windows", windows: nil, start: at(6, 3, 0), end: at(6, 4, 0), want: true},
+		{name: "inside a morning", windows: weekdayMornings, start: at(6, 9, 0), end: at(6, 11, 0), want: true},
+		{name: "runs past the window", windows: weekdayMornings, start: at(6, 11, 0), end: at(6, 13, 0), want: false},
+		{name: "wrong weekday", windows: weekdayMornings, start: at(8, 9, 0), end: at(8, 11, 0), want: false},
+		{name: "overnight window", windows: nights, start: at(10, 23, 0), end: at(11, 5, 0), want: true},
+		{name: "adjoining windows", windows: nights, start: at(11, 4, 0), end: at(11, 9, 0), want: true},
+		{name: "after the adjoining windows", windows: nights, start: at(11, 9, 0), end: at(11, 11, 0), want: false},
+		{name: "whole day", windows: []models.AvailabilityWindow{{Weekday: 1, StartTime: "00:00", EndTime: "00:00"}}, start: at(6, 0, 0), end: at(7, 0, 0), want: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Times are given in UTC to check that windows are read in the caregiver's timezone
+			assert.Equal(t, tc.want, withinWindows(tc.windows, jakarta, tc.start.UTC(), tc.end.UTC()))
+		})
+	}
+}
+
+func TestAvailabilityService_CheckAvailability(t *testing.T) {
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	mornings := &models.CaregiverAvailability{CaregiverID: 3, Windows: []models.AvailabilityWindow{
+		{Weekday: 1, StartTime: "08:00", EndTime: "12:00"},
+	}}
+	limit := 10.0
+
+	cases := []struct {
+		name         string
+		availability *models.CaregiverAvailability
+		timeOff      []models.TimeOffRequest
+		booked       []models.Schedule
+		week         []models.Schedule
+		exclude      int
+		wantErr      bool
+	}{
+		{name: "available", availability: mornings},
+		{name: "no declared availability", availability: nil},
+		{name: "outside windows", availability: &models.CaregiverAvailability{CaregiverID: 3, Windows: []models.AvailabilityWindow{
+			{Weekday: 1, StartTime: "13:00", EndTime: "17:00"},
+		}}, wantErr: true},
+		{name: "on approved time off", availability: mornings, timeOff: []models.TimeOffRequest{
+			{ID: 1, CaregiverID: 3, StartTime: start.Add(-24 * time.Hour), EndTime: end, Status: models.TimeOffStatusApproved},
+		}, wantErr: true},
+		{name: "booked elsewhere", availability: mornings, booked: []models.Schedule{{ID: 8, StartTime: start, EndTime: end}}, wantErr: true},
+		{name: "booked on the excluded schedule", availability: mornings, booked: []models.Schedule{{ID: 8, StartTime: start, EndTime: end}}, exclude: 8},
+		{name: "over weekly hours", availability: &models.CaregiverAvailability{CaregiverID: 3, MaxWeeklyHours: &limit},
+			week: []models.Schedule{{ID: 5, StartTime: start.Add(24 * time.Hour), EndTime: start.Add(33 * time.Hour)}}, wantErr: true},
+		{name: "within weekly hours", availability: &models.CaregiverAvailability{CaregiverID: 3, MaxWeeklyHours: &limit},
+			week: []models.Schedule{{ID: 5, StartTime: start.Add(24 * time.Hour), EndTime: start.Add(31 * time.Hour)}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, availabilityRepo, timeOffRepo, scheduleRepo := newAvailabilityTestService()
+
+			// Mock expectations
+			availabilityRepo.On("GetByCaregiverID", 3).Return(tc.availability, nil)
+			timeOffRepo.On("GetOverlapping", 3, start, end, models.TimeOffStatusApproved).Return(tc.timeOff, nil)
+			scheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+				return len(f.Statuses) == 2
+			})).Return(tc.booked, &models.PageInfo{}, nil)
+			scheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+				return len(f.Statuses) == 3
+			})).Return(tc.week, &models.PageInfo{}, nil)
+
+			// Execute
+			err := service.CheckAvailability(3, start, end, tc.exclude)
+
+			// Assert
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrCaregiverUnavailable)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAvailabilityService_ReviewTimeOff(t *testing.T) {
+	service, _, timeOffRepo, _ := newAvailabilityTestService()
+	reviewedAt := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return reviewedAt }
+
+	// Mock expectations
+	timeOffRepo.On("GetByID", 1).Return(&models.TimeOffRequest{ID: 1, CaregiverID: 3, Status: models.TimeOffStatusPending, Version: 1}, nil)
+	timeOffRepo.On("GetByID", 2).Return(&models.TimeOffRequest{ID: 2, CaregiverID: 3, Status: models.TimeOffStatusRejected, Version: 2}, nil)
+	timeOffRepo.On("Update", mock.MatchedBy(func(r *models.TimeOffRequest) bool {
+		return r.ID == 1 && r.Status == models.TimeOffStatusApproved && r.ReviewedAt.Equal(reviewedAt)
+	})).Return(nil)
+
+	// Execute
+	approved, err := service.ApproveTimeOff(1, &models.TimeOffReviewRequest{Notes: "Enjoy"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, models.TimeOffStatusApproved, approved.Status)
+	assert.Equal(t, "Enjoy", approved.ReviewNotes)
+
+	// A rejected request cannot be approved or cancelled, and a stale version is refused
+	_, err = service.ApproveTimeOff(2, &models.TimeOffReviewRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	_, err = service.CancelTimeOff(2, &models.TimeOffReviewRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	stale := 1
+	_, err = service.CancelTimeOff(2, &models.TimeOffReviewRequest{ExpectedVersion: &stale})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	timeOffRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestAvailabilityService_SetAvailability_Validation(t *testing.T) {
+	service, availabilityRepo, _, _ := newAvailabilityTestService()
+	tooMany := 200.0
+
+	cases := []struct {
+		name string
+		req  *models.CaregiverAvailabilityRequest
+	}{
+		{name: "weekday", req: &models.CaregiverAvailabilityRequest{Windows: []models.AvailabilityWindow{{Weekday: 7, StartTime: "08:00", EndTime: "12:00"}}}},
+		{name: "time of day", req: &models.CaregiverAvailabilityRequest{Windows: []models.AvailabilityWindow{{Weekday: 1, StartTime: "8am", EndTime: "12:00"}}}},
+		{name: "timezone", req: &models.CaregiverAvailabilityRequest{Timezone: "Mars/Olympus"}},
+		{name: "weekly hours", req: &models.CaregiverAvailabilityRequest{MaxWeeklyHours: &tooMany}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.SetAvailability(3, tc.req)
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+	availabilityRepo.AssertNotCalled(t, "Replace", mock.Anything)
+}
+
+func TestScheduleService_CreateSchedule_ValidatesAssignment(t *testing.T) {
+	service, m := newTeamTestService()
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	req := &models.ScheduleCreateRequest{ClientID: 101, CaregiverID: 3, StartTime: start, EndTime: start.Add(2 * time.Hour)}
+
+	// The caregiver is on leave
+	service.OnAssign(func(schedule *models.Schedule, caregiverID int) error {
+		return ErrCaregiverUnavailable
+	})
+
+	// Execute
+	_, err := service.CreateSchedule(req)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrCaregiverUnavailable)
+	m.scheduleRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestScheduleService_ReassignSchedule(t *testing.T) {
+	service, m := newTeamTestService()
+	previous := 1
+	var validated int
+	service.OnAssign(func(schedule *models.Schedule, caregiverID int) error {
+		validated = caregiverID
+		return nil
+	})
+
+	// Mock expectations
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusScheduled, Version: 2}, nil)
+	m.scheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.CaregiverID == 3
+	})).Return(nil)
+	m.taskRepo.On("GetByScheduleID", 1).Return([]models.Task{{ID: 1, ScheduleID: 1, CaregiverID: &previous}}, nil)
+	m.taskRepo.On("Update", mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 1 && task.CaregiverID == nil
+	})).Return(nil)
+	m.caregiverRepo.On("Remove", 1, 1).Return(nil)
+
+	// A stale version is refused
+	stale := 1
+	_, err := service.ReassignSchedule(1, &models.ScheduleReassignRequest{CaregiverID: 3, ExpectedVersion: &stale})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	// Execute
+	schedule, err := service.ReassignSchedule(1, &models.ScheduleReassignRequest{CaregiverID: 3})
+
+	// Assert: the previous lead leaves the team with their tasks unassigned
+	assert.NoError(t, err)
+	assert.Equal(t, 3, schedule.CaregiverID)
+	assert.Equal(t, 3, validated)
+	m.taskRepo.AssertExpectations(t)
+	m.caregiverRepo.AssertExpectations(t)
+}
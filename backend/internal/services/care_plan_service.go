@@ -0,0 +1,404 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CarePlanService manages the task template library and client care plans,
+// and sets up the tasks of new schedules from them
+type CarePlanService struct {
+	templateRepo repositories.TaskTemplateRepository
+	planRepo     repositories.CarePlanRepository
+	clientRepo   repositories.ClientRepository
+	location     *time.Location // Agency default for clients without a timezone
+	logger       *logrus.Logger
+}
+
+// NewCarePlanService creates a new care plan service; location is the agency
+// default timezone, UTC when nil
+func NewCarePlanService(
+	templateRepo repositories.TaskTemplateRepository,
+	planRepo repositories.CarePlanRepository,
+	clientRepo repositories.ClientRepository,
+	location *time.Location,
+	logger *logrus.Logger,
+) *CarePlanService {
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &CarePlanService{
+		templateRepo: templateRepo,
+		planRepo:     planRepo,
+		clientRepo:   clientRepo,
+		location:     location,
+		logger:       logger,
+	}
+}
+
+// GetTaskTemplates lists the task templates by title
+func (s *CarePlanService) GetTaskTemplates() ([]models.TaskTemplate, error) {
+	templates, err := s.templateRepo.GetAll()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get task templates")
+		return nil, fmt.Errorf("failed to get task templates: %w", err)
+	}
+	if templates == nil {
+		templates = []models.TaskTemplate{}
+	}
+
+	return templates, nil
+}
+
+// CreateTaskTemplate adds a task template to the library
+func (s *CarePlanService) CreateTaskTemplate(req *models.TaskTemplateRequest) (*models.TaskTemplate, error) {
+	s.logger.WithField("title", req.Title).Info("Creating task template")
+
+	template, err := s.buildTaskTemplate(0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Create(template); err != nil {
+		s.logger.WithError(err).WithField("title", req.Title).Error("Failed to create task template")
+		return nil, fmt.Errorf("failed to create task template: %w", err)
+	}
+
+	s.logger.WithField("template_id", template.ID).Info("Successfully created task template")
+	return template, nil
+}
+
+// UpdateTaskTemplate renames, re-words or deactivates a task template. Care
+// plans keep the title they were saved with.
+func (s *CarePlanService) UpdateTaskTemplate(id int, req *models.TaskTemplateRequest) (*models.TaskTemplate, error) {
+	s.logger.WithField("template_id", id).Info("Updating task template")
+
+	existing, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task template: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("task template %d: %w", id, ErrNotFound)
+	}
+
+	template, err := s.buildTaskTemplate(id, req)
+	if err != nil {
+		return nil, err
+	}
+	template.CreatedAt = existing.CreatedAt
+
+	if err := s.templateRepo.Update(template); err != nil {
+		s.logger.WithError(err).WithField("template_id", id).Error("Failed to update task template")
+		return nil, fmt.Errorf("failed to update task template: %w", err)
+	}
+
+	s.logger.WithField("template_id", id).Info("Successfully updated task template")
+	return template, nil
+}
+
+// buildTaskTemplate validates a task template request; id is 0 for a new template
+func (s *CarePlanService) buildTaskTemplate(id int, req *models.TaskTemplateRequest) (*models.TaskTemplate, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrValidation)
+	}
+
+	// Titles are unique regardless of case so that tasks are not named twice
+	other, err := s.templateRepo.GetByTitle(title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task template: %w", err)
+	}
+	if other != nil && other.ID != id {
+		return nil, fmt.Errorf("%w: task template %q already exists", ErrValidation, other.Title)
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return &models.TaskTemplate{
+		ID:           id,
+		Title:        title,
+		Instructions: strings.TrimSpace(req.Instructions),
+		IsActive:     isActive,
+	}, nil
+}
+
+// GetCarePlan retrieves the current version of a client's care plan
+func (s *CarePlanService) GetCarePlan(clientID int) (*models.CarePlan, error) {
+	if err := s.checkClient(clientID); err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planRepo.GetCurrent(clientID)
+	if err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to get care plan")
+		return nil, fmt.Errorf("failed to get care plan: %w", err)
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("care plan of client %d: %w", clientID, ErrNotFound)
+	}
+
+	return plan, nil
+}
+
+// GetCarePlanVersions lists every version of a client's care plan, newest first
+func (s *CarePlanService) GetCarePlanVersions(clientID int) ([]models.CarePlan, error) {
+	if err := s.checkClient(clientID); err != nil {
+		return nil, err
+	}
+
+	plans, err := s.planRepo.GetVersions(clientID)
+	if err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to get care plan versions")
+		return nil, fmt.Errorf("failed to get care plan versions: %w", err)
+	}
+	if plans == nil {
+		plans = []models.CarePlan{}
+	}
+
+	return plans, nil
+}
+
+// GetCarePlanVersion retrieves a care plan version, such as the one a past
+// visit's tasks were created from
+func (s *CarePlanService) GetCarePlanVersion(id int) (*models.CarePlan, error) {
+	plan, err := s.planRepo.GetByID(id)
+	if err != nil {
+		s.logger.WithError(err).WithField("care_plan_id", id).Error("Failed to get care plan")
+		return nil, fmt.Errorf("failed to get care plan: %w", err)
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("care plan %d: %w", id, ErrNotFound)
+	}
+
+	return plan, nil
+}
+
+// ReviseCarePlan saves a new version of a client's care plan. Schedules
+// created afterwards take their tasks from it; existing schedules keep theirs.
+func (s *CarePlanService) ReviseCarePlan(clientID int, req *models.CarePlanRequest) (*models.CarePlan, error) {
+	s.logger.WithField("client_id", clientID).Info("Revising care plan")
+
+	if err := s.checkClient(clientID); err != nil {
+		return nil, err
+	}
+
+	current, err := s.planRepo.GetCurrent(clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get care plan: %w", err)
+	}
+	version := 0
+	if current != nil {
+		version = current.Version
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != version {
+		return nil, fmt.Errorf("care plan of client %d is at version %d: %w", clientID, version, ErrVersionConflict)
+	}
+
+	items, err := s.buildCarePlanItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.CarePlan{
+		ClientID: clientID,
+		Version:  version + 1,
+		Notes:    strings.TrimSpace(req.Notes),
+		Items:    items,
+	}
+	if err := s.planRepo.Create(plan); err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to save care plan")
+		return nil, fmt.Errorf("failed to save care plan: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"client_id":    clientID,
+		"care_plan_id": plan.ID,
+		"version":      plan.Version,
+	}).Info("Successfully revised care plan")
+	return plan, nil
+}
+
+// buildCarePlanItems validates the items of a care plan revision, copying
+// the title and default instructions of their templates
+func (s *CarePlanService) buildCarePlanItems(reqs []models.CarePlanItemRequest) ([]models.CarePlanItem, error) {
+	items := []models.CarePlanItem{}
+	seen := map[int]bool{}
+	for _, req := range reqs {
+		template, err := s.templateRepo.GetByID(req.TemplateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task template: %w", err)
+		}
+		if template == nil {
+			return nil, fmt.Errorf("%w: task template %d does not exist", ErrValidation, req.TemplateID)
+		}
+		if !template.IsActive {
+			return nil, fmt.Errorf("%w: task template %q is inactive", ErrValidation, template.Title)
+		}
+		if seen[template.ID] {
+			return nil, fmt.Errorf("%w: task template %q is in the plan twice", ErrValidation, template.Title)
+		}
+		seen[template.ID] = true
+
+		weekdays, err := carePlanWeekdays(req.Frequency, req.Weekdays)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrValidation, template.Title, err)
+		}
+
+		instructions := strings.TrimSpace(req.Instructions)
+		if instructions == "" {
+			instructions = template.Instructions
+		}
+
+		items = append(items, models.CarePlanItem{
+			TemplateID:   template.ID,
+			Title:        template.Title,
+			Frequency:    req.Frequency,
+			Weekdays:     weekdays,
+			Instructions: instructions,
+			Required:     req.Required,
+		})
+	}
+
+	return items, nil
+}
+
+// carePlanWeekdays checks the weekdays of a care plan item against its
+// frequency, returning them sorted without repeats
+func carePlanWeekdays(frequency string, weekdays []int) ([]int, error) {
+	switch frequency {
+	case models.CarePlanFrequencyEveryVisit, models.CarePlanFrequencyWeekly:
+		if len(weekdays) > 0 {
+			return nil, fmt.Errorf("weekdays are only allowed with the %s frequency", models.CarePlanFrequencyDays)
+		}
+		return nil, nil
+	case models.CarePlanFrequencyDays:
+	default:
+		return nil, fmt.Errorf("frequency must be %s, %s or %s", models.CarePlanFrequencyEveryVisit,
+			models.CarePlanFrequencyWeekly, models.CarePlanFrequencyDays)
+	}
+
+	if len(weekdays) == 0 {
+		return nil, fmt.Errorf("weekdays are required with the %s frequency", models.CarePlanFrequencyDays)
+	}
+	seen := map[int]bool{}
+	days := []int{}
+	for _, weekday := range weekdays {
+		if weekday < 0 || weekday > 6 {
+			return nil, fmt.Errorf("weekday %d must be between 0 (Sunday) and 6", weekday)
+		}
+		if !seen[weekday] {
+			seen[weekday] = true
+			days = append(days, weekday)
+		}
+	}
+	sort.Ints(days)
+	return days, nil
+}
+
+// checkClient returns ErrNotFound when a client does not exist
+func (s *CarePlanService) checkClient(clientID int) error {
+	client, err := s.clientRepo.GetByID(clientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if client == nil {
+		return fmt.Errorf("client %d: %w", clientID, ErrNotFound)
+	}
+	return nil
+}
+
+// PlanTasks returns the tasks a new schedule takes from the current version
+// of its client's care plan, and that version; both are nil for clients
+// without a plan. Days are those of the schedule's start in the client's
+// timezone, and weekly items are left out when another schedule of the
+// client that week already has them. It is registered with the schedule
+// service through UseTaskPlanner.
+func (s *CarePlanService) PlanTasks(schedule *models.Schedule) (*models.CarePlan, []models.Task, error) {
+	plan, err := s.planRepo.GetCurrent(schedule.ClientID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get care plan: %w", err)
+	}
+	if plan == nil {
+		return nil, nil, nil
+	}
+
+	client, err := s.clientRepo.GetByID(schedule.ClientID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	start := schedule.StartTime.In(clientLocation(client, s.location))
+
+	var plannedThisWeek map[int]bool
+	tasks := []models.Task{}
+	for _, item := range plan.Items {
+		switch item.Frequency {
+		case models.CarePlanFrequencyDays:
+			if !containsWeekday(item.Weekdays, start.Weekday()) {
+				continue
+			}
+		case models.CarePlanFrequencyWeekly:
+			if plannedThisWeek == nil {
+				if plannedThisWeek, err = s.plannedInWeek(schedule.ClientID, start); err != nil {
+					return nil, nil, err
+				}
+			}
+			if plannedThisWeek[item.TemplateID] {
+				continue
+			}
+		}
+
+		templateID := item.TemplateID
+		tasks = append(tasks, models.Task{
+			TemplateID:  &templateID,
+			Title:       item.Title,
+			Description: item.Instructions,
+			Status:      "pending",
+			Required:    item.Required,
+		})
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"client_id":    schedule.ClientID,
+		"care_plan_id": plan.ID,
+		"tasks":        len(tasks),
+	}).Debug("Planned schedule tasks")
+	return plan, tasks, nil
+}
+
+// plannedInWeek returns the templates already on the client's schedules in
+// the Monday to Sunday week of start, in start's timezone
+func (s *CarePlanService) plannedInWeek(clientID int, start time.Time) (map[int]bool, error) {
+	daysSinceMonday := (int(start.Weekday()) + 6) % 7
+	weekStart := time.Date(start.Year(), start.Month(), start.Day()-daysSinceMonday, 0, 0, 0, 0, start.Location())
+
+	templateIDs, err := s.planRepo.PlannedTemplates(clientID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned tasks: %w", err)
+	}
+
+	planned := map[int]bool{}
+	for _, id := range templateIDs {
+		planned[id] = true
+	}
+	return planned, nil
+}
+
+// containsWeekday reports whether weekday is one of days, 0 being Sunday
+func containsWeekday(days []int, weekday time.Weekday) bool {
+	for _, day := range days {
+		if day == int(weekday) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTaskTemplateRepository is a mock implementation of TaskTemplateRepository
+type MockTaskTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaskTemplateRepository) GetAll() ([]models.TaskTemplate, error) {
+	args := m.Called()
+	return args.Get(0).([]models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateRepository) GetByID(id int) (*models.TaskTemplate, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateRepository) GetByTitle(title string) (*models.TaskTemplate, error) {
+	args := m.Called(title)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateRepository) Create(template *models.TaskTemplate) error {
+	args := m.Called(template)
+	return args.Error(0)
+}
+
+func (m *MockTaskTemplateRepository) Update(template *models.TaskTemplate) error {
+	args := m.Called(template)
+	return args.Error(0)
+}
+
+// MockCarePlanRepository is a mock implementation of CarePlanRepository
+type MockCarePlanRepository struct {
+	mock.Mock
+}
+
+func (m *MockCarePlanRepository) GetByID(id int) (*models.CarePlan, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CarePlan), args.Error(1)
+}
+
+func (m *MockCarePlanRepository) GetCurrent(clientID int) (*models.CarePlan, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CarePlan), args.Error(1)
+}
+
+func (m *MockCarePlanRepository) GetVersions(clientID int) ([]models.CarePlan, error) {
+	args := m.Called(clientID)
+	return args.Get(0).([]models.CarePlan), args.Error(1)
+}
+
+func (m *MockCarePlanRepository) Create(plan *models.CarePlan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+func (m *MockCarePlanRepository) PlannedTemplates(clientID int, from, to time.Time) ([]int, error) {
+	args := m.Called(clientID, from, to)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+type carePlanTestMocks struct {
+	templateRepo *MockTaskTemplateRepository
+	planRepo     *MockCarePlanRepository
+	clientRepo   *MockClientRepository
+}
+
+func newCarePlanTestService() (*CarePlanService, *carePlanTestMocks) {
+	m := &carePlanTestMocks{
+		templateRepo: new(MockTaskTemplateRepository),
+		planRepo:     new(MockCarePlanRepository),
+		clientRepo:   new(MockClientRepository),
+	}
+	service := NewCarePlanService(m.templateRepo, m.planRepo, m.clientRepo, time.UTC, logrus.New())
+	return service, m
+}
+
+var (
+	bathingTemplate  = &models.TaskTemplate{ID: 1, Title: "Bathing", Instructions: "Assist with shower", IsActive: true}
+	laundryTemplate  = &models.TaskTemplate{ID: 5, Title: "Laundry", IsActive: true}
+	retiredTemplate  = &models.TaskTemplate{ID: 9, Title: "Ironing", IsActive: false}
+	existingCarePlan = &models.CarePlan{ID: 3, ClientID: 101, Version: 2}
+)
+
+func TestCarePlanService_CreateTaskTemplate(t *testing.T) {
+	service, m := newCarePlanTestService()
+	m.templateRepo.On("GetByTitle", "Bathing").Return(bathingTemplate, nil)
+	m.templateRepo.On("GetByTitle", "Medication reminder").Return(nil, nil)
+	m.templateRepo.On("Create", mock.AnythingOfType("*models.TaskTemplate")).Return(nil)
+
+	_, err := service.CreateTaskTemplate(&models.TaskTemplateRequest{Title: "Bathing"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.CreateTaskTemplate(&models.TaskTemplateRequest{Title: "  "})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	template, err := service.CreateTaskTemplate(&models.TaskTemplateRequest{Title: " Medication reminder "})
+	assert.NoError(t, err)
+	assert.Equal(t, "Medication reminder", template.Title)
+	assert.True(t, template.IsActive)
+}
+
+func TestCarePlanService_ReviseCarePlan(t *testing.T) {
+	service, m := newCarePlanTestService()
+	m.clientRepo.On("GetByID", 101).Return(&models.Client{ID: 101}, nil)
+	m.planRepo.On("GetCurrent", 101).Return(existingCarePlan, nil)
+	m.templateRepo.On("GetByID", 1).Return(bathingTemplate, nil)
+	m.templateRepo.On("GetByID", 5).Return(laundryTemplate, nil)
+	m.planRepo.On("Create", mock.AnythingOfType("*models.CarePlan")).Return(nil)
+
+	expected := 2
+	plan, err := service.ReviseCarePlan(101, &models.CarePlanRequest{
+		ExpectedVersion: &expected,
+		Items: []models.CarePlanItemRequest{
+			{TemplateID: 1, Frequency: models.CarePlanFrequencyDays, Weekdays: []int{5, 1, 5}},
+			{TemplateID: 5, Frequency: models.CarePlanFrequencyWeekly, Instructions: "Bedding too"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, plan.Version)
+	assert.Len(t, plan.Items, 2)
+	assert.Equal(t, []int{1, 5}, plan.Items[0].Weekdays)
+	assert.Equal(t, "Assist with shower", plan.Items[0].Instructions)
+	assert.Equal(t, "Bedding too", plan.Items[1].Instructions)
+}
+
+func TestCarePlanService_ReviseCarePlan_VersionConflict(t *testing.T) {
+	service, m := newCarePlanTestService()
+	m.clientRepo.On("GetByID", 101).Return(&models.Client{ID: 101}, nil)
+	m.planRepo.On("GetCurrent", 101).Return(existingCarePlan, nil)
+
+	stale := 1
+	_, err := service.ReviseCarePlan(101, &models.CarePlanRequest{ExpectedVersion: &stale})
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	m.planRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCarePlanService_ReviseCarePlan_InvalidItems(t *testing.T) {
+	service, m := newCarePlanTestService()
+	m.clientRepo.On("GetByID", 101).Return(&models.Client{ID: 101}, nil)
+	m.clientRepo.On("GetByID", 999).Return(nil, nil)
+	m.planRepo.On("GetCurrent", 101).Return(nil, nil)
+	m.templateRepo.On("GetByID", 1).Return(bathingTemplate, nil)
+	m.templateRepo.On("GetByID", 9).Return(retiredTemplate, nil)
+	m.templateRepo.On("GetByID", 42).Return(nil, nil)
+
+	tests := []struct {
+		name  string
+		items []models.CarePlanItemRequest
+	}{
+		{"unknown template", []models.CarePlanItemRequest{{TemplateID: 42, Frequency: models.CarePlanFrequencyEveryVisit}}},
+		{"inactive template", []models.CarePlanItemRequest{{TemplateID: 9, Frequency: models.CarePlanFrequencyEveryVisit}}},
+		{"repeated template", []models.CarePlanItemRequest{
+			{TemplateID: 1, Frequency: models.CarePlanFrequencyEveryVisit},
+			{TemplateID: 1, Frequency: models.CarePlanFrequencyWeekly},
+		}},
+		{"unknown frequency", []models.CarePlanItemRequest{{TemplateID: 1, Frequency: "monthly"}}},
+		{"days without weekdays", []models.CarePlanItemRequest{{TemplateID: 1, Frequency: models.CarePlanFrequencyDays}}},
+		{"weekday out of range", []models.CarePlanItemRequest{{TemplateID: 1, Frequency: models.CarePlanFrequencyDays, Weekdays: []int{7}}}},
+		{"weekdays on weekly item", []models.CarePlanItemRequest{{TemplateID: 1, Frequency: models.CarePlanFrequencyWeekly, Weekdays: []int{1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ReviseCarePlan(101, &models.CarePlanRequest{Items: tt.items})
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+
+	_, err := service.ReviseCarePlan(999, &models.CarePlanRequest{})
+	assert.ErrorIs(t, err, ErrNotFound)
+	m.planRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCarePlanService_PlanTasks(t *testing.T) {
+	service, m := newCarePlanTestService()
+	newYork, _ := time.LoadLocation("America/New_York")
+	m.clientRepo.On("GetByID", 101).Return(&models.Client{ID: 101, Timezone: "America/New_York"}, nil)
+	m.planRepo.On("GetCurrent", 101).Return(&models.CarePlan{ID: 3, ClientID: 101, Version: 2, Items: []models.CarePlanItem{
+		{TemplateID: 1, Title: "Bathing", Frequency: models.CarePlanFrequencyEveryVisit, Instructions: "Assist with shower", Required: true},
+		{TemplateID: 2, Title: "Meal Preparation", Frequency: models.CarePlanFrequencyDays, Weekdays: []int{1}},
+		{TemplateID: 3, Title: "Medication", Frequency: models.CarePlanFrequencyDays, Weekdays: []int{2}},
+		{TemplateID: 5, Title: "Laundry", Frequency: models.CarePlanFrequencyWeekly},
+		{TemplateID: 6, Title: "Light Housekeeping", Frequency: models.CarePlanFrequencyWeekly},
+	}}, nil)
+	weekStart := time.Date(2025, 1, 6, 0, 0, 0, 0, newYork)
+	m.planRepo.On("PlannedTemplates", 101,
+		mock.MatchedBy(func(from time.Time) bool { return from.Equal(weekStart) }),
+		mock.MatchedBy(func(to time.Time) bool { return to.Equal(weekStart.AddDate(0, 0, 7)) }),
+	).Return([]int{1, 5}, nil)
+
+	// Tuesday 03:00 UTC is Monday evening for the client
+	plan, tasks, err := service.PlanTasks(&models.Schedule{ClientID: 101, StartTime: time.Date(2025, 1, 7, 3, 0, 0, 0, time.UTC)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, plan.ID)
+	titles := []string{}
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+		assert.Equal(t, "pending", task.Status)
+	}
+	assert.Equal(t, []string{"Bathing", "Meal Preparation", "Light Housekeeping"}, titles)
+	assert.Equal(t, 1, *tasks[0].TemplateID)
+	assert.Equal(t, "Assist with shower", tasks[0].Description)
+	assert.True(t, tasks[0].Required)
+	assert.False(t, tasks[1].Required)
+}
+
+func TestCarePlanService_PlanTasks_NoPlan(t *testing.T) {
+	service, m := newCarePlanTestService()
+	m.planRepo.On("GetCurrent", 102).Return(nil, nil)
+
+	plan, tasks, err := service.PlanTasks(&models.Schedule{ClientID: 102, StartTime: time.Now()})
+
+	assert.NoError(t, err)
+	assert.Nil(t, plan)
+	assert.Empty(t, tasks)
+}
+
+func TestScheduleService_CreateSchedule_CarePlanTasks(t *testing.T) {
+	service, m := newTeamTestService()
+	planner, pm := newCarePlanTestService()
+	service.UseTaskPlanner(planner)
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	pm.clientRepo.On("GetByID", 101).Return(&models.Client{ID: 101}, nil)
+	pm.planRepo.On("GetCurrent", 101).Return(&models.CarePlan{ID: 3, ClientID: 101, Version: 2, Items: []models.CarePlanItem{
+		{TemplateID: 1, Title: "Bathing", Frequency: models.CarePlanFrequencyEveryVisit},
+	}}, nil)
+	expectScheduleReadBack(m, 7, nil)
+
+	schedule, err := service.CreateSchedule(&models.ScheduleCreateRequest{ClientID: 101, CaregiverID: 1,
+		StartTime: start, EndTime: start.Add(time.Hour)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *schedule.CarePlanID)
+	m.scheduleRepo.AssertCalled(t, "Create", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.CarePlanID != nil && *s.CarePlanID == 3 && len(s.Tasks) == 1 && s.Tasks[0].Title == "Bathing"
+	}))
+}
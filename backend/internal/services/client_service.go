@@ -3,8 +3,10 @@ package services
 import (
 	"caregiver-shift-tracker/internal/models"
 	"caregiver-shift-tracker/internal/repositories"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -12,29 +14,44 @@ import (
 // ClientService handles business logic for clients
 type ClientService struct {
 	clientRepo repositories.ClientRepository
+	geocoder   AddressGeocoder
+	now        func() time.Time
 	logger     *logrus.Logger
 }
 
-// NewClientService creates a new client service
-func NewClientService(clientRepo repositories.ClientRepository, logger *logrus.Logger) *ClientService {
+// NewClientService creates a new client service; without a geocoder clients
+// must be created with their coordinates
+func NewClientService(clientRepo repositories.ClientRepository, geocoder AddressGeocoder, logger *logrus.Logger) *ClientService {
 	return &ClientService{
 		clientRepo: clientRepo,
+		geocoder:   geocoder,
+		now:        time.Now,
 		logger:     logger,
 	}
 }
 
-// GetAllClients retrieves all clients with optional filtering
-func (s *ClientService) GetAllClients(filter *models.ClientFilter) ([]models.Client, error) {
+// GetAllClients retrieves a page of clients with optional filtering and sorting
+func (s *ClientService) GetAllClients(filter *models.ClientFilter) ([]models.Client, *models.PageInfo, error) {
 	s.logger.Debug("Getting all clients")
 
-	clients, err := s.clientRepo.GetAll(filter)
+	if filter == nil {
+		filter = &models.ClientFilter{}
+	}
+	limit := filter.PageSize()
+	filter.Limit = &limit
+
+	if err := validateNearFilter(filter); err != nil {
+		return nil, nil, err
+	}
+
+	clients, page, err := s.clientRepo.GetAll(filter)
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to get clients")
-		return nil, fmt.Errorf("failed to get clients: %w", err)
+		return nil, nil, fmt.Errorf("failed to get clients: %w", err)
 	}
 
 	s.logger.WithField("count", len(clients)).Debug("Successfully retrieved clients")
-	return clients, nil
+	return clients, page, nil
 }
 
 // GetClientByID retrieves a client by ID
@@ -80,9 +97,16 @@ func (s *ClientService) CreateClient(req *models.ClientCreateRequest) (*models.C
 		Latitude:  req.Latitude,
 		Longitude: req.Longitude,
 		Notes:     req.Notes,
+		Timezone:  req.Timezone,
 		IsActive:  true, // New clients are active by default
 	}
 
+	// Clients created without coordinates are placed by their address
+	if err := s.locateClient(client, req.Latitude == 0 && req.Longitude == 0); err != nil {
+		s.logger.WithError(err).WithField("client_name", req.Name).Warn("Client could not be located")
+		return nil, err
+	}
+
 	if err := s.clientRepo.Create(client); err != nil {
 		s.logger.WithError(err).WithField("client_name", req.Name).Error("Failed to create client")
 		return nil, fmt.Errorf("failed to create client: %w", err)
@@ -112,6 +136,18 @@ func (s *ClientService) UpdateClient(id int, req *models.ClientUpdateRequest) (*
 		return nil, nil
 	}
 
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != client.Version {
+		s.logger.WithFields(logrus.Fields{
+			"client_id":        id,
+			"expected_version": *req.ExpectedVersion,
+			"current_version":  client.Version,
+		}).Warn("Client version mismatch")
+		return nil, fmt.Errorf("client %d is at version %d: %w", id, client.Version, ErrVersionConflict)
+	}
+
+	// Moving the client without new coordinates places it by its new address
+	addressBefore := addressKey(clientAddress(client))
+
 	// Update fields if provided
 	if req.Name != nil {
 		client.Name = *req.Name
@@ -143,6 +179,9 @@ func (s *ClientService) UpdateClient(id int, req *models.ClientUpdateRequest) (*
 	if req.Notes != nil {
 		client.Notes = *req.Notes
 	}
+	if req.Timezone != nil {
+		client.Timezone = *req.Timezone
+	}
 	if req.IsActive != nil {
 		client.IsActive = *req.IsActive
 	}
@@ -152,6 +191,17 @@ func (s *ClientService) UpdateClient(id int, req *models.ClientUpdateRequest) (*
 		return nil, fmt.Errorf("client validation failed: %w", err)
 	}
 
+	switch {
+	case req.Latitude != nil || req.Longitude != nil:
+		err = s.locateClient(client, false)
+	case addressKey(clientAddress(client)) != addressBefore:
+		err = s.locateClient(client, true)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("client_id", id).Warn("Client could not be located")
+		return nil, err
+	}
+
 	if err := s.clientRepo.Update(client); err != nil {
 		s.logger.WithError(err).WithField("client_id", id).Error("Failed to update client")
 		return nil, fmt.Errorf("failed to update client: %w", err)
@@ -161,6 +211,102 @@ func (s *ClientService) UpdateClient(id int, req *models.ClientUpdateRequest) (*
 	return client, nil
 }
 
+// GeocodeClient places a client again by its address, replacing coordinates
+// given by hand
+func (s *ClientService) GeocodeClient(id int, expectedVersion *int) (*models.Client, error) {
+	s.logger.WithField("client_id", id).Debug("Geocoding client")
+
+	client, err := s.clientRepo.GetByID(id)
+	if err != nil {
+		s.logger.WithError(err).WithField("client_id", id).Error("Failed to get client for geocoding")
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	if client == nil {
+		return nil, fmt.Errorf("client %d: %w", id, ErrNotFound)
+	}
+
+	if expectedVersion != nil && *expectedVersion != client.Version {
+		return nil, fmt.Errorf("client %d is at version %d: %w", id, client.Version, ErrVersionConflict)
+	}
+
+	if err := s.locateClient(client, true); err != nil {
+		s.logger.WithError(err).WithField("client_id", id).Warn("Client could not be geocoded")
+		return nil, err
+	}
+
+	if err := s.clientRepo.Update(client); err != nil {
+		s.logger.WithError(err).WithField("client_id", id).Error("Failed to update client")
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"client_id":  id,
+		"match":      client.GeocodeMatch,
+		"confidence": client.GeocodeConfidence,
+	}).Info("Successfully geocoded client")
+	return client, nil
+}
+
+// locateClient normalizes a client's address and sets where the client is.
+// With geocode the address is geocoded, in the dataset's spelling when the
+// house was found; otherwise the client's coordinates were given by hand and
+// are checked. Clients are never placed at 0,0.
+func (s *ClientService) locateClient(client *models.Client, geocode bool) error {
+	address := normalizeAddress(clientAddress(client))
+	now := s.now().UTC()
+
+	if !geocode {
+		if client.Latitude == 0 && client.Longitude == 0 {
+			return fmt.Errorf("%w: latitude and longitude 0,0 are not a client location; omit them to geocode the address", ErrValidation)
+		}
+		if client.Latitude < -90 || client.Latitude > 90 || client.Longitude < -180 || client.Longitude > 180 {
+			return fmt.Errorf("%w: latitude must be within ±90 and longitude within ±180", ErrValidation)
+		}
+		setClientAddress(client, address)
+		client.GeocodeMatch = models.GeocodeMatchManual
+		client.GeocodeConfidence = geocodeConfidence[models.GeocodeMatchManual]
+		client.GeocodedAt = &now
+		return nil
+	}
+
+	if s.geocoder == nil {
+		return fmt.Errorf("%w: latitude and longitude are required as addresses cannot be geocoded", ErrValidation)
+	}
+	result, err := s.geocoder.Geocode(address)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: the address could not be geocoded; check it or give latitude and longitude", ErrValidation)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to geocode address: %w", err)
+	}
+
+	setClientAddress(client, result.Address)
+	client.Latitude = result.Latitude
+	client.Longitude = result.Longitude
+	client.GeocodeMatch = result.Match
+	client.GeocodeConfidence = result.Confidence
+	client.GeocodedAt = &now
+	return nil
+}
+
+// clientAddress returns the postal address of a client
+func clientAddress(client *models.Client) models.PostalAddress {
+	return models.PostalAddress{
+		Street:  client.Address,
+		City:    client.City,
+		State:   client.State,
+		ZipCode: client.ZipCode,
+	}
+}
+
+// setClientAddress sets the postal address of a client
+func setClientAddress(client *models.Client, address models.PostalAddress) {
+	client.Address = address.Street
+	client.City = address.City
+	client.State = address.State
+	client.ZipCode = address.ZipCode
+}
+
 // DeleteClient deletes a client
 func (s *ClientService) DeleteClient(id int) error {
 	s.logger.WithField("client_id", id).Debug("Deleting client")
@@ -208,52 +354,81 @@ func (s *ClientService) SearchClients(query string) ([]models.Client, error) {
 	return clients, nil
 }
 
+// validateNearFilter checks the point and radius of a nearby-client search,
+// defaulting the radius
+func validateNearFilter(filter *models.ClientFilter) error {
+	if filter.Near == nil {
+		if filter.RadiusKm != 0 {
+			return fmt.Errorf("%w: radius_km requires near", ErrValidation)
+		}
+		return nil
+	}
+
+	near := filter.Near
+	if near.Latitude < -90 || near.Latitude > 90 || near.Longitude < -180 || near.Longitude > 180 {
+		return fmt.Errorf("%w: near latitude must be within ±90 and longitude within ±180", ErrValidation)
+	}
+	if filter.RadiusKm == 0 {
+		filter.RadiusKm = models.DefaultNearRadiusKm
+	}
+	if filter.RadiusKm < 0 || filter.RadiusKm > models.MaxNearRadiusKm {
+		return fmt.Errorf("%w: radius_km must be positive and at most %g", ErrValidation, models.MaxNearRadiusKm)
+	}
+	return nil
+}
+
 // validateClientCreateRequest validates a client create request
 func (s *ClientService) validateClientCreateRequest(req *models.ClientCreateRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
-		return fmt.Errorf("client name is required")
+		return fmt.Errorf("%w: client name is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(req.Address) == "" {
-		return fmt.Errorf("address is required")
+		return fmt.Errorf("%w: address is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(req.City) == "" {
-		return fmt.Errorf("city is required")
+		return fmt.Errorf("%w: city is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(req.State) == "" {
-		return fmt.Errorf("state is required")
+		return fmt.Errorf("%w: state is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(req.ZipCode) == "" {
-		return fmt.Errorf("zip code is required")
+		return fmt.Errorf("%w: zip code is required", ErrValidation)
 	}
 
+	if err := validateTimezone(req.Timezone); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
 	return nil
 }
 
 // validateClient validates a client
 func (s *ClientService) validateClient(client *models.Client) error {
 	if strings.TrimSpace(client.Name) == "" {
-		return fmt.Errorf("client name is required")
+		return fmt.Errorf("%w: client name is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(client.Address) == "" {
-		return fmt.Errorf("address is required")
+		return fmt.Errorf("%w: address is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(client.City) == "" {
-		return fmt.Errorf("city is required")
+		return fmt.Errorf("%w: city is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(client.State) == "" {
-		return fmt.Errorf("state is required")
+		return fmt.Errorf("%w: state is required", ErrValidation)
 	}
 
 	if strings.TrimSpace(client.ZipCode) == "" {
-		return fmt.Errorf("zip code is required")
+		return fmt.Errorf("%w: zip code is required", ErrValidation)
 	}
 
+	if err := validateTimezone(client.Timezone); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
 	return nil
 }
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var springfieldGeocoder = StubGeocoder{Results: []models.GeocodeResult{
+	{Address: models.PostalAddress{Street: "742 Evergreen Terrace", City: "Springfield", State: "IL", ZipCode: "62704"},
+		Latitude: 39.7751, Longitude: -89.6720, Match: models.GeocodeMatchAddress, Confidence: 1},
+	{Address: models.PostalAddress{Street: "12 Oak Avenue", City: "Springfield", State: "IL", ZipCode: "62702"},
+		Latitude: 39.7990, Longitude: -89.6440, Match: models.GeocodeMatchStreet, Confidence: 0.7},
+}}
+
+func newClientTestService() (*ClientService, *MockClientRepository) {
+	clientRepo := new(MockClientRepository)
+	service := NewClientService(clientRepo, springfieldGeocoder, logrus.New())
+	service.now = func() time.Time { return time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC) }
+	return service, clientRepo
+}
+
+func TestClientService_CreateClient_Geocoded(t *testing.T) {
+	service, clientRepo := newClientTestService()
+
+	// Mock expectations
+	clientRepo.On("Create", mock.AnythingOfType("*models.Client")).Return(nil)
+
+	// Execute
+	client, err := service.CreateClient(&models.ClientCreateRequest{
+		Name: "Jane Doe", Address: " 742  evergreen ter.", City: "Springfield", State: "il", ZipCode: "62704",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "742 Evergreen Terrace", client.Address)
+	assert.Equal(t, "IL", client.State)
+	assert.Equal(t, 39.7751, client.Latitude)
+	assert.Equal(t, -89.6720, client.Longitude)
+	assert.Equal(t, models.GeocodeMatchAddress, client.GeocodeMatch)
+	assert.Equal(t, 1.0, client.GeocodeConfidence)
+	assert.NotNil(t, client.GeocodedAt)
+	clientRepo.AssertExpectations(t)
+}
+
+func TestClientService_CreateClient_Manual(t *testing.T) {
+	service, clientRepo := newClientTestService()
+
+	// Mock expectations
+	clientRepo.On("Create", mock.AnythingOfType("*models.Client")).Return(nil)
+
+	// Execute
+	client, err := service.CreateClient(&models.ClientCreateRequest{
+		Name: "Jane Doe", Address: "1 Farm Rd", City: "Rochester", State: "IL", ZipCode: "62563",
+		Latitude: 39.75, Longitude: -89.53,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "1 Farm Road", client.Address)
+	assert.Equal(t, 39.75, client.Latitude)
+	assert.Equal(t, models.GeocodeMatchManual, client.GeocodeMatch)
+	clientRepo.AssertExpectations(t)
+}
+
+func TestClientService_CreateClient_NotGeocoded(t *testing.T) {
+	service, clientRepo := newClientTestService()
+
+	// Execute
+	_, err := service.CreateClient(&models.ClientCreateRequest{
+		Name: "Jane Doe", Address: "1 Nowhere Rd", City: "Springfield", State: "IL", ZipCode: "62799",
+	})
+
+	// Assert: the client is not stored at 0,0
+	assert.True(t, errors.Is(err, ErrValidation), "got %v", err)
+	clientRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	// Without a geocoder coordinates are required
+	service.geocoder = nil
+	_, err = service.CreateClient(&models.ClientCreateRequest{
+		Name: "Jane Doe", Address: "742 Evergreen Terrace", City: "Springfield", State: "IL", ZipCode: "62704",
+	})
+	assert.True(t, errors.Is(err, ErrValidation), "got %v", err)
+}
+
+func TestClientService_UpdateClient_Geocoding(t *testing.T) {
+	zero := 0.0
+	street := "12 Oak Ave"
+	notes := "Ring twice"
+	zip := "62702"
+
+	tests := []struct {
+		name      string
+		req       models.ClientUpdateRequest
+		wantMatch string
+		wantLat   float64
+		wantErr   error
+	}{
+		{"address change is geocoded", models.ClientUpdateRequest{Address: &street, ZipCode: &zip},
+			models.GeocodeMatchStreet, 39.7990, nil},
+		{"other changes keep the location", models.ClientUpdateRequest{Notes: &notes},
+			models.GeocodeMatchManual, 39.75, nil},
+		{"0,0 is rejected", models.ClientUpdateRequest{Latitude: &zero, Longitude: &zero},
+			"", 0, ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, clientRepo := newClientTestService()
+
+			// Mock expectations
+			clientRepo.On("GetByID", 5).Return(&models.Client{ID: 5, Name: "Jane Doe", Address: "1 Farm Road", City: "Springfield",
+				State: "IL", ZipCode: "62704", Latitude: 39.75, Longitude: -89.53, GeocodeMatch: models.GeocodeMatchManual, Version: 1}, nil)
+			clientRepo.On("Update", mock.AnythingOfType("*models.Client")).Return(nil)
+
+			// Execute
+			client, err := service.UpdateClient(5, &tt.req)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				clientRepo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMatch, client.GeocodeMatch)
+			assert.Equal(t, tt.wantLat, client.Latitude)
+		})
+	}
+}
+
+func TestClientService_GeocodeClient(t *testing.T) {
+	service, clientRepo := newClientTestService()
+	stale := 1
+
+	// Mock expectations
+	clientRepo.On("GetByID", 5).Return(&models.Client{ID: 5, Name: "Jane Doe", Address: "742 Evergreen Ter", City: "Springfield",
+		State: "IL", ZipCode: "62704", Latitude: 39.70, Longitude: -89.60, GeocodeMatch: models.GeocodeMatchManual, Version: 2}, nil)
+	clientRepo.On("GetByID", 6).Return(nil, nil)
+	clientRepo.On("Update", mock.AnythingOfType("*models.Client")).Return(nil)
+
+	// Execute
+	client, err := service.GeocodeClient(5, nil)
+
+	// Assert: coordinates given by hand are replaced
+	assert.NoError(t, err)
+	assert.Equal(t, 39.7751, client.Latitude)
+	assert.Equal(t, models.GeocodeMatchAddress, client.GeocodeMatch)
+
+	_, err = service.GeocodeClient(5, &stale)
+	assert.True(t, errors.Is(err, ErrVersionConflict), "got %v", err)
+
+	_, err = service.GeocodeClient(6, nil)
+	assert.True(t, errors.Is(err, ErrNotFound), "got %v", err)
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	address := normalizeAddress(models.PostalAddress{Street: "  12  main st., ", City: " New  York ", State: "ny", ZipCode: " 10001 "})
+
+	assert.Equal(t, models.PostalAddress{Street: "12 main Street", City: "New York", State: "NY", ZipCode: "10001"}, address)
+	assert.Equal(t, streetKey("12 Main St."), streetKey("12 main street"))
+}
+
+func TestClientService_GetAllClients_Near(t *testing.T) {
+	service, clientRepo := newClientTestService()
+	distance := 1.25
+
+	// Mock expectations: the radius is defaulted
+	clientRepo.On("GetAll", mock.MatchedBy(func(filter *models.ClientFilter) bool {
+		return filter.Near != nil && filter.RadiusKm == models.DefaultNearRadiusKm
+	})).Return([]models.Client{{ID: 5, DistanceKm: &distance}}, &models.PageInfo{Limit: models.DefaultPageSize}, nil)
+
+	// Execute
+	clients, _, err := service.GetAllClients(&models.ClientFilter{Near: &models.GeoPoint{Latitude: 39.78, Longitude: -89.65}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1.25, *clients[0].DistanceKm)
+	clientRepo.AssertExpectations(t)
+
+	invalid := []*models.ClientFilter{
+		{Near: &models.GeoPoint{Latitude: 91}},
+		{Near: &models.GeoPoint{Latitude: 39.78, Longitude: -89.65}, RadiusKm: models.MaxNearRadiusKm + 1},
+		{Near: &models.GeoPoint{Latitude: 39.78, Longitude: -89.65}, RadiusKm: -1},
+		{RadiusKm: 5},
+	}
+	for _, filter := range invalid {
+		_, _, err := service.GetAllClients(filter)
+		assert.True(t, errors.Is(err, ErrValidation), "got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/repositories"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTransition is returned when an event is not allowed from the
+// current status, or when one of the transition guards rejects it
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// ErrVersionConflict is returned when an update was based on a stale version
+// of the entity, either from an If-Match header or a concurrent write
+var ErrVersionConflict = repositories.ErrVersionConflict
+
+// ErrIdempotencyKeyReused is returned when an Idempotency-Key is sent again
+// with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+
+// ErrIdempotencyInProgress is returned when a request with the same
+// Idempotency-Key is still being processed
+var ErrIdempotencyInProgress = errors.New("request with this idempotency key is still in progress")
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or was
+// issued for a different sort order
+var ErrInvalidCursor = repositories.ErrInvalidCursor
+
+// ErrInvalidSort is returned when a list is sorted by a field that is not allowed
+var ErrInvalidSort = repositories.ErrInvalidSort
+
+// ErrInvalidFilter is returned when a list filter has an unknown value
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// ErrNotFound is returned when the requested entity does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrValidation is returned when a request is rejected by business rules
+var ErrValidation = errors.New("validation failed")
+
+// ErrCaregiverUnavailable is returned when a caregiver cannot take a schedule
+// because of their availability windows, time off, other bookings or weekly hours
+var ErrCaregiverUnavailable = errors.New("caregiver unavailable")
+
+// ErrCaregiverUnqualified is returned when a caregiver lacks a valid
+// certification for a skill the schedule's service type requires
+var ErrCaregiverUnqualified = errors.New("caregiver not qualified")
+
+// ErrVerificationFailed is returned when a caregiver clocking in or out sends
+// neither a location nor a valid client verification code
+var ErrVerificationFailed = errors.New("presence not verified")
+
+// ErrTasksIncomplete is returned when a caregiver clocks out before every
+// required task is completed, or not completed with an accepted reason code
+var ErrTasksIncomplete = errors.New("required tasks incomplete")
+
+// IncompleteTasksError lists the required tasks that keep a caregiver from
+// clocking out; it matches ErrTasksIncomplete with errors.Is
+type IncompleteTasksError struct {
+	TaskIDs []int
+}
+
+func (e *IncompleteTasksError) Error() string {
+	ids := make([]string, len(e.TaskIDs))
+	for i, id := range e.TaskIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	return fmt.Sprintf("%v: tasks %s", ErrTasksIncomplete, strings.Join(ids, ", "))
+}
+
+func (e *IncompleteTasksError) Unwrap() error {
+	return ErrTasksIncomplete
+}
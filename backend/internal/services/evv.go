@@ -0,0 +1,100 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExportEVV returns every caregiver visit started between the from and to
+// dates (YYYY-MM-DD, inclusive, in the client's timezone) for electronic
+// visit verification. Visits amended by an approved correction list both the
+// clock times as recorded and the corrected ones.
+func (s *ScheduleService) ExportEVV(from, to string) (*models.EVVExport, error) {
+	s.logger.WithFields(logrus.Fields{
+		"from": from,
+		"to":   to,
+	}).Info("Exporting EVV visits")
+
+	fromDate, toDate, err := parseDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Widen the range so that days in every client timezone are covered
+	activeFrom := fromDate.Add(-dayWindow)
+	activeTo := toDate.Add(24*time.Hour + dayWindow)
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		ActiveFrom: &activeFrom,
+		ActiveTo:   &activeTo,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get schedules for EVV export")
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	export := &models.EVVExport{
+		From:   from,
+		To:     to,
+		Visits: []models.EVVVisit{},
+	}
+	for _, schedule := range schedules {
+		visits, err := s.visitRepo.GetAllByScheduleID(schedule.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get visits: %w", err)
+		}
+
+		loc := clientLocation(schedule.Client, s.location)
+		for _, visit := range visits {
+			if visit.StartTime == nil {
+				continue
+			}
+			date := visit.StartTime.In(loc).Format(dateLayout)
+			if date < from || date > to {
+				continue
+			}
+			export.Visits = append(export.Visits, evvVisit(&schedule, &visit, date))
+		}
+	}
+	sort.SliceStable(export.Visits, func(i, j int) bool {
+		return export.Visits[i].StartTime.Before(*export.Visits[j].StartTime)
+	})
+
+	s.logger.WithField("visits", len(export.Visits)).Info("Exported EVV visits")
+	return export, nil
+}
+
+// evvVisit describes a started visit for the EVV export
+func evvVisit(schedule *models.Schedule, visit *models.Visit, date string) models.EVVVisit {
+	row := models.EVVVisit{
+		VisitID:                 visit.ID,
+		ScheduleID:              schedule.ID,
+		CaregiverID:             visit.CaregiverID,
+		ClientID:                schedule.ClientID,
+		ServiceName:             schedule.ServiceName,
+		Date:                    date,
+		ScheduledStartTime:      schedule.StartTime,
+		ScheduledEndTime:        schedule.EndTime,
+		RecordedStartTime:       visit.StartTime,
+		RecordedEndTime:         visit.EndTime,
+		StartTime:               visit.StartTime,
+		EndTime:                 visit.EndTime,
+		StartLatitude:           visit.StartLatitude,
+		StartLongitude:          visit.StartLongitude,
+		EndLatitude:             visit.EndLatitude,
+		EndLongitude:            visit.EndLongitude,
+		StartVerificationMethod: visit.StartVerificationMethod,
+		EndVerificationMethod:   visit.EndVerificationMethod,
+	}
+	if visit.AmendedAt != nil {
+		row.RecordedStartTime = visit.OriginalStartTime
+		row.RecordedEndTime = visit.OriginalEndTime
+		row.Amended = true
+		row.AmendmentReason = visit.AmendmentReason
+		row.AmendedAt = visit.AmendedAt
+	}
+	return row
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"math"
+)
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// distanceKm returns the great-circle distance between two coordinates in
+// kilometres using the haversine formula
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// hasCoordinates reports whether a client's location is known; clients
+// created without coordinates are stored at 0,0
+func hasCoordinates(client *models.Client) bool {
+	return client != nil && (client.Latitude != 0 || client.Longitude != 0)
+}
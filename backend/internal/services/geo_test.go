@@ -0,0 +1,15 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceKm(t *testing.T) {
+	// Sample clients 101 and 102 in Springfield are about 2 km apart
+	assert.InDelta(t, 2.0, distanceKm(39.7817, -89.6501, 39.7990, -89.6440), 0.1)
+	// London to Paris
+	assert.InDelta(t, 344, distanceKm(51.5074, -0.1278, 48.8566, 2.3522), 1)
+	assert.Zero(t, distanceKm(39.7817, -89.6501, 39.7817, -89.6501))
+}
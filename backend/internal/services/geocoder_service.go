@@ -0,0 +1,529 @@
+package services
+
+import (
+	"bufio"
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultGeocodeMaxDistanceMeters is how far from a location the nearest
+// place may be and still describe where it is
+const defaultGeocodeMaxDistanceMeters = 5000.0
+
+// geocodeCandidates is how many places near a location are measured exactly
+const geocodeCandidates = 20
+
+// geocodeAreaLimit bounds the places of a postcode or city an address is
+// matched against
+const geocodeAreaLimit = 2000
+
+// geoPlaceBatch is how many imported places are stored per transaction
+const geoPlaceBatch = 1000
+
+// GeoNames dump columns, see https://download.geonames.org/export/dump/readme.txt
+const (
+	geoNamesID           = 0
+	geoNamesName         = 1
+	geoNamesLatitude     = 4
+	geoNamesLongitude    = 5
+	geoNamesFeatureClass = 6
+	geoNamesCountryCode  = 8
+	geoNamesAdmin1       = 10
+	geoNamesMinColumns   = 11
+)
+
+// GeocoderService resolves locations to addresses and addresses to locations
+// from a locally imported place dataset, without calling an online geocoder
+type GeocoderService struct {
+	placeRepo         repositories.GeoPlaceRepository
+	clientRepo        repositories.ClientRepository
+	visitRepo         repositories.VisitRepository
+	radiusMeters      float64
+	maxDistanceMeters float64
+	now               func() time.Time
+	logger            *logrus.Logger
+}
+
+// NewGeocoderService creates a new geocoder service; radiusMeters is the
+// client geofence and maxDistanceMeters how far away a place may be, each
+// falling back to its default when not positive
+func NewGeocoderService(
+	placeRepo repositories.GeoPlaceRepository,
+	clientRepo repositories.ClientRepository,
+	visitRepo repositories.VisitRepository,
+	radiusMeters float64,
+	maxDistanceMeters float64,
+	logger *logrus.Logger,
+) *GeocoderService {
+	if radiusMeters <= 0 {
+		radiusMeters = defaultGeofenceRadiusMeters
+	}
+	if maxDistanceMeters <= 0 {
+		maxDistanceMeters = defaultGeocodeMaxDistanceMeters
+	}
+
+	return &GeocoderService{
+		placeRepo:         placeRepo,
+		clientRepo:        clientRepo,
+		visitRepo:         visitRepo,
+		radiusMeters:      radiusMeters,
+		maxDistanceMeters: maxDistanceMeters,
+		now:               time.Now,
+		logger:            logger,
+	}
+}
+
+// ImportFile loads a place dataset file unless the same file was already
+// imported. Its places are tagged with the file name as their source, so that
+// datasets can be imported side by side and a changed file updates its
+// places. The format is csv for .csv files and geonames otherwise when empty.
+func (s *GeocoderService) ImportFile(path, format string) (*models.GeoPlaceSource, error) {
+	if format == "" {
+		format = models.GeoPlaceFormatGeoNames
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			format = models.GeoPlaceFormatCSV
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open place dataset: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read place dataset: %w", err)
+	}
+
+	src := &models.GeoPlaceSource{
+		Source:         filepath.Base(path),
+		Format:         format,
+		FileSize:       info.Size(),
+		FileModifiedAt: info.ModTime().UTC().Truncate(time.Second),
+	}
+	previous, err := s.placeRepo.GetSource(src.Source)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Format == src.Format && previous.FileSize == src.FileSize &&
+		previous.FileModifiedAt.Equal(src.FileModifiedAt) {
+		s.logger.WithField("source", src.Source).Info("Place dataset already imported")
+		return previous, nil
+	}
+
+	if err := s.importPlaces(src, file); err != nil {
+		return nil, err
+	}
+	src.ImportedAt = s.now().UTC()
+	if err := s.placeRepo.SaveSource(src); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// importPlaces reads the places of a dataset in src's format, counting them
+// on src. Lines without a usable location are skipped.
+func (s *GeocoderService) importPlaces(src *models.GeoPlaceSource, r io.Reader) error {
+	s.logger.WithFields(logrus.Fields{
+		"format": src.Format,
+		"source": src.Source,
+	}).Info("Importing geographic places")
+
+	var read func(io.Reader, string, func(models.GeoPlace) error) (int, error)
+	switch src.Format {
+	case models.GeoPlaceFormatGeoNames:
+		read = readGeoNames
+	case models.GeoPlaceFormatCSV:
+		read = readPlaceCSV
+	default:
+		return fmt.Errorf("%w: format must be %q or %q", ErrValidation, models.GeoPlaceFormatGeoNames, models.GeoPlaceFormatCSV)
+	}
+
+	batch := make([]models.GeoPlace, 0, geoPlaceBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.placeRepo.Upsert(batch); err != nil {
+			return err
+		}
+		src.Imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	skipped, err := read(r, src.Source, func(place models.GeoPlace) error {
+		batch = append(batch, place)
+		if len(batch) == geoPlaceBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("imported", src.Imported).Error("Failed to import geographic places")
+		return err
+	}
+	src.Skipped = skipped
+
+	s.logger.WithFields(logrus.Fields{
+		"source":   src.Source,
+		"imported": src.Imported,
+		"skipped":  src.Skipped,
+	}).Info("Imported geographic places")
+	return nil
+}
+
+// readGeoNames reads a GeoNames dump, one tab-separated place per line.
+// Populated places (feature class P) are their own locality.
+func readGeoNames(r io.Reader, source string, emit func(models.GeoPlace) error) (int, error) {
+	scanner := bufio.NewScanner(r)
+	// Alternate names make some lines far longer than the default buffer
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	skipped := 0
+	for scanner.Scan() {
+		record := strings.Split(scanner.Text(), "\t")
+		if len(record) < geoNamesMinColumns {
+			skipped++
+			continue
+		}
+
+		latitude, longitude, ok := parseCoordinates(record[geoNamesLatitude], record[geoNamesLongitude])
+		if !ok || record[geoNamesID] == "" {
+			skipped++
+			continue
+		}
+		place := models.GeoPlace{
+			Source:      source,
+			SourceID:    record[geoNamesID],
+			Name:        record[geoNamesName],
+			Region:      record[geoNamesAdmin1],
+			CountryCode: record[geoNamesCountryCode],
+			Latitude:    latitude,
+			Longitude:   longitude,
+		}
+		if record[geoNamesFeatureClass] == "P" {
+			place.Locality = place.Name
+		}
+		if err := emit(place); err != nil {
+			return skipped, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return skipped, fmt.Errorf("%w: invalid GeoNames line: %v", ErrValidation, err)
+	}
+	return skipped, nil
+}
+
+// placeCSVColumns maps the header names accepted in place CSV files to the
+// field they fill; OpenStreetMap addr:* tag names are accepted too
+var placeCSVColumns = map[string]string{
+	"id":               "id",
+	"source_id":        "id",
+	"osm_id":           "id",
+	"name":             "name",
+	"housenumber":      "housenumber",
+	"addr:housenumber": "housenumber",
+	"street":           "street",
+	"addr:street":      "street",
+	"locality":         "locality",
+	"city":             "locality",
+	"addr:city":        "locality",
+	"region":           "region",
+	"state":            "region",
+	"addr:state":       "region",
+	"postcode":         "postcode",
+	"zip_code":         "postcode",
+	"addr:postcode":    "postcode",
+	"country_code":     "country_code",
+	"addr:country":     "country_code",
+	"latitude":         "latitude",
+	"lat":              "latitude",
+	"longitude":        "longitude",
+	"lon":              "longitude",
+}
+
+// readPlaceCSV reads a CSV file of places whose header names the columns.
+// Places without an ID are keyed by their coordinates.
+func readPlaceCSV(r io.Reader, source string, emit func(models.GeoPlace) error) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: a CSV header is required: %v", ErrValidation, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if field, ok := placeCSVColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["latitude"]; !ok {
+		return 0, fmt.Errorf("%w: the CSV header needs latitude and longitude columns", ErrValidation)
+	}
+	if _, ok := columns["longitude"]; !ok {
+		return 0, fmt.Errorf("%w: the CSV header needs latitude and longitude columns", ErrValidation)
+	}
+
+	skipped := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, fmt.Errorf("%w: invalid CSV line: %v", ErrValidation, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		latitude, longitude, ok := parseCoordinates(field("latitude"), field("longitude"))
+		if !ok {
+			skipped++
+			continue
+		}
+		place := models.GeoPlace{
+			Source:      source,
+			SourceID:    field("id"),
+			Name:        field("name"),
+			Street:      strings.TrimSpace(field("housenumber") + " " + field("street")),
+			Locality:    field("locality"),
+			Region:      field("region"),
+			Postcode:    field("postcode"),
+			CountryCode: strings.ToUpper(field("country_code")),
+			Latitude:    latitude,
+			Longitude:   longitude,
+		}
+		if place.SourceID == "" {
+			place.SourceID = fmt.Sprintf("%.6f,%.6f", latitude, longitude)
+		}
+		if err := emit(place); err != nil {
+			return skipped, err
+		}
+	}
+}
+
+// parseCoordinates parses a latitude and longitude, rejecting values out of range
+func parseCoordinates(lat, lon string) (float64, float64, bool) {
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return 0, 0, false
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return 0, 0, false
+	}
+	return latitude, longitude, true
+}
+
+// ReverseGeocode returns the address of the place nearest a location, or
+// ErrNotFound when no imported place is within the maximum distance
+func (s *GeocoderService) ReverseGeocode(latitude, longitude float64) (*models.ReverseGeocode, error) {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, fmt.Errorf("%w: latitude must be within ±90 and longitude within ±180", ErrValidation)
+	}
+
+	places, err := s.placeRepo.FindNearest(latitude, longitude, s.maxDistanceMeters/1000, geocodeCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find places: %w", err)
+	}
+
+	var nearest *models.ReverseGeocode
+	for _, place := range places {
+		meters := distanceKm(latitude, longitude, place.Latitude, place.Longitude) * 1000
+		if meters > s.maxDistanceMeters || (nearest != nil && meters >= nearest.DistanceMeters) {
+			continue
+		}
+		nearest = &models.ReverseGeocode{
+			Latitude:       latitude,
+			Longitude:      longitude,
+			Address:        formatAddress(place),
+			DistanceMeters: math.Round(meters),
+			Place:          place,
+		}
+	}
+	if nearest == nil {
+		return nil, fmt.Errorf("no place within %.0f m of %.6f,%.6f: %w", s.maxDistanceMeters, latitude, longitude, ErrNotFound)
+	}
+
+	return nearest, nil
+}
+
+// formatAddress joins the parts of a place's address, leaving out empty and
+// repeated parts
+func formatAddress(place models.GeoPlace) string {
+	region := strings.TrimSpace(place.Region + " " + place.Postcode)
+	var parts []string
+	for _, part := range []string{place.Name, place.Street, place.Locality, region, place.CountryCode} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ResolveClockEvent stores on the visit the address of a clock-in or
+// clock-out location outside the client geofence, or anywhere when the
+// client's location is unknown. It is registered as a clock event hook.
+// Events verified without a location are ignored.
+func (s *GeocoderService) ResolveClockEvent(event *models.ClockEvent) error {
+	if event.VerificationMethod == models.VerificationMethodToken || event.VerificationMethod == models.VerificationMethodTelephony {
+		return nil
+	}
+
+	client, err := s.clientRepo.GetByID(event.ClientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if hasCoordinates(client) &&
+		distanceKm(client.Latitude, client.Longitude, event.Latitude, event.Longitude)*1000 <= s.radiusMeters {
+		return nil
+	}
+
+	geocode, err := s.ReverseGeocode(event.Latitude, event.Longitude)
+	if errors.Is(err, ErrNotFound) {
+		s.logger.WithFields(logrus.Fields{
+			"schedule_id":  event.ScheduleID,
+			"caregiver_id": event.CaregiverID,
+			"event":        event.Event,
+		}).Debug("No known address near clock location")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.visitRepo.SetClockAddress(event.ScheduleID, event.CaregiverID, event.Event, geocode.Address); err != nil {
+		return fmt.Errorf("failed to save clock address: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":     event.ScheduleID,
+		"caregiver_id":    event.CaregiverID,
+		"event":           event.Event,
+		"address":         geocode.Address,
+		"distance_meters": geocode.DistanceMeters,
+	}).Info("Clock location outside client geofence resolved to address")
+	return nil
+}
+
+// Geocode places an address from the imported place dataset. It implements
+// AddressGeocoder, preferring the house on the street, then the middle of the
+// street, of the postcode area and of the city.
+func (s *GeocoderService) Geocode(address models.PostalAddress) (*models.GeocodeResult, error) {
+	address = normalizeAddress(address)
+	number, street := splitHouseNumber(streetKey(address.Street))
+	inArea := func(place models.GeoPlace) bool {
+		if address.ZipCode != "" && strings.EqualFold(place.Postcode, address.ZipCode) {
+			return true
+		}
+		return strings.EqualFold(place.Locality, address.City) &&
+			(place.Region == "" || address.State == "" || strings.EqualFold(place.Region, address.State))
+	}
+
+	// Narrow the street search down by its longest word, which is the least
+	// likely to be abbreviated differently
+	word := ""
+	for _, w := range strings.Fields(street) {
+		if len(w) > len(word) {
+			word = w
+		}
+	}
+	if word != "" {
+		places, err := s.placeRepo.FindInArea(address.City, address.ZipCode, word, geocodeAreaLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find places: %w", err)
+		}
+		var onStreet []models.GeoPlace
+		for _, place := range places {
+			placeNumber, placeStreet := splitHouseNumber(streetKey(place.Street))
+			if placeStreet != street || !inArea(place) {
+				continue
+			}
+			if number != "" && placeNumber == number {
+				return geocodeResult(models.PostalAddress{
+					Street:  place.Street,
+					City:    firstNonEmpty(place.Locality, address.City),
+					State:   address.State,
+					ZipCode: firstNonEmpty(place.Postcode, address.ZipCode),
+				}, []models.GeoPlace{place}, models.GeocodeMatchAddress), nil
+			}
+			onStreet = append(onStreet, place)
+		}
+		if len(onStreet) > 0 {
+			return geocodeResult(address, onStreet, models.GeocodeMatchStreet), nil
+		}
+	}
+
+	places, err := s.placeRepo.FindInArea(address.City, address.ZipCode, "", geocodeAreaLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find places: %w", err)
+	}
+	var inPostcode, inLocality, localities []models.GeoPlace
+	for _, place := range places {
+		switch {
+		case address.ZipCode != "" && strings.EqualFold(place.Postcode, address.ZipCode):
+			inPostcode = append(inPostcode, place)
+		case inArea(place) && place.Street == "":
+			// The populated place itself, e.g. from GeoNames
+			localities = append(localities, place)
+		case inArea(place):
+			inLocality = append(inLocality, place)
+		}
+	}
+	switch {
+	case len(inPostcode) > 0:
+		return geocodeResult(address, inPostcode, models.GeocodeMatchPostcode), nil
+	case len(localities) > 0:
+		return geocodeResult(address, localities[:1], models.GeocodeMatchLocality), nil
+	case len(inLocality) > 0:
+		return geocodeResult(address, inLocality, models.GeocodeMatchLocality), nil
+	}
+
+	return nil, fmt.Errorf("address %q, %q: %w", address.Street, address.City, ErrNotFound)
+}
+
+// geocodeResult places an address at the middle of the places it matched
+func geocodeResult(address models.PostalAddress, places []models.GeoPlace, match string) *models.GeocodeResult {
+	result := &models.GeocodeResult{
+		Address:    address,
+		Match:      match,
+		Confidence: geocodeConfidence[match],
+	}
+	for _, place := range places {
+		result.Latitude += place.Latitude / float64(len(places))
+		result.Longitude += place.Longitude / float64(len(places))
+	}
+	return result
+}
+
+// firstNonEmpty returns the first of its arguments that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGeoPlaceRepository is a mock implementation of GeoPlaceRepository
+type MockGeoPlaceRepository struct {
+	mock.Mock
+}
+
+func (m *MockGeoPlaceRepository) Upsert(places []models.GeoPlace) error {
+	// The importer reuses its batch, so the places are copied
+	args := m.Called(append([]models.GeoPlace(nil), places...))
+	return args.Error(0)
+}
+
+func (m *MockGeoPlaceRepository) FindNearest(latitude, longitude, radiusKm float64, limit int) ([]models.GeoPlace, error) {
+	args := m.Called(latitude, longitude, radiusKm, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.GeoPlace), args.Error(1)
+}
+
+func (m *MockGeoPlaceRepository) FindInArea(locality, postcode, street string, limit int) ([]models.GeoPlace, error) {
+	args := m.Called(locality, postcode, street, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.GeoPlace), args.Error(1)
+}
+
+func (m *MockGeoPlaceRepository) GetSource(source string) (*models.GeoPlaceSource, error) {
+	args := m.Called(source)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GeoPlaceSource), args.Error(1)
+}
+
+func (m *MockGeoPlaceRepository) SaveSource(source *models.GeoPlaceSource) error {
+	args := m.Called(source)
+	return args.Error(0)
+}
+
+type geocoderTestMocks struct {
+	placeRepo  *MockGeoPlaceRepository
+	clientRepo *MockClientRepository
+	visitRepo  *MockVisitRepository
+}
+
+func newGeocoderTestService() (*GeocoderService, *geocoderTestMocks) {
+	m := &geocoderTestMocks{
+		placeRepo:  new(MockGeoPlaceRepository),
+		clientRepo: new(MockClientRepository),
+		visitRepo:  new(MockVisitRepository),
+	}
+	service := NewGeocoderService(m.placeRepo, m.clientRepo, m.visitRepo, 150, 1000, logrus.New())
+	return service, m
+}
+
+// writeDataset writes a place dataset to a temporary file
+func writeDataset(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGeocoderService_ImportFile_GeoNames(t *testing.T) {
+	service, m := newGeocoderTestService()
+	path := writeDataset(t, "US.txt",
+		"4250542\tSpringfield\tSpringfield\tSpringfeld\t39.80172\t-89.64371\tP\tPPLA\tUS\t\tIL\t167\t\t\t114394\t184\t182\tAmerica/Chicago\t2019-09-05\n"+
+			"4237246\tLincoln Park\tLincoln Park\t\t39.7931\t-89.6623\tL\tPRK\tUS\t\tIL\t167\t\t\t0\t\t181\tAmerica/Chicago\t2010-02-15\n"+
+			"9999999\tNowhere\tNowhere\t\tnot-a-latitude\t-89.6\tP\tPPL\tUS\t\tIL\n"+
+			"truncated line\n")
+
+	// Mock expectations
+	m.placeRepo.On("GetSource", "US.txt").Return(nil, nil)
+	m.placeRepo.On("Upsert", []models.GeoPlace{
+		{Source: "US.txt", SourceID: "4250542", Name: "Springfield", Locality: "Springfield", Region: "IL", CountryCode: "US",
+			Latitude: 39.80172, Longitude: -89.64371},
+		{Source: "US.txt", SourceID: "4237246", Name: "Lincoln Park", Region: "IL", CountryCode: "US",
+			Latitude: 39.7931, Longitude: -89.6623},
+	}).Return(nil)
+	m.placeRepo.On("SaveSource", mock.AnythingOfType("*models.GeoPlaceSource")).Return(nil)
+
+	// Execute
+	src, err := service.ImportFile(path, "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, models.GeoPlaceFormatGeoNames, src.Format)
+	assert.Equal(t, 2, src.Imported)
+	assert.Equal(t, 2, src.Skipped)
+	m.placeRepo.AssertExpectations(t)
+}
+
+func TestGeocoderService_ImportFile_CSV(t *testing.T) {
+	service, m := newGeocoderTestService()
+	path := writeDataset(t, "springfield-addresses.csv",
+		"osm_id,addr:housenumber,addr:street,addr:city,addr:state,addr:postcode,lat,lon\n"+
+			"node/101,742,Evergreen Terrace,Springfield,IL,62704,39.7751,-89.6720\n"+
+			",12,Oak Ave,Springfield,IL,62702,39.7990,-89.6440\n"+
+			"node/102,1,Nowhere Rd,Springfield,IL,,,\n")
+
+	// Mock expectations
+	m.placeRepo.On("GetSource", "springfield-addresses.csv").Return(nil, nil)
+	m.placeRepo.On("Upsert", []models.GeoPlace{
+		{Source: "springfield-addresses.csv", SourceID: "node/101", Street: "742 Evergreen Terrace", Locality: "Springfield",
+			Region: "IL", Postcode: "62704", Latitude: 39.7751, Longitude: -89.6720},
+		{Source: "springfield-addresses.csv", SourceID: "39.799000,-89.644000", Street: "12 Oak Ave", Locality: "Springfield",
+			Region: "IL", Postcode: "62702", Latitude: 39.7990, Longitude: -89.6440},
+	}).Return(nil)
+	m.placeRepo.On("SaveSource", mock.MatchedBy(func(src *models.GeoPlaceSource) bool {
+		return src.Format == models.GeoPlaceFormatCSV && src.Imported == 2 && src.Skipped == 1
+	})).Return(nil)
+
+	// Execute
+	_, err := service.ImportFile(path, "")
+
+	// Assert
+	assert.NoError(t, err)
+	m.placeRepo.AssertExpectations(t)
+}
+
+func TestGeocoderService_ImportFile_Unchanged(t *testing.T) {
+	service, m := newGeocoderTestService()
+	path := writeDataset(t, "US.txt", "4250542\tSpringfield\tSpringfield\t\t39.80172\t-89.64371\tP\tPPLA\tUS\t\tIL\n")
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	// Mock expectations: the same file was imported before
+	m.placeRepo.On("GetSource", "US.txt").Return(&models.GeoPlaceSource{Source: "US.txt", Format: models.GeoPlaceFormatGeoNames,
+		FileSize: info.Size(), FileModifiedAt: info.ModTime().UTC().Truncate(time.Second), Imported: 1}, nil)
+
+	// Execute
+	src, err := service.ImportFile(path, "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, src.Imported)
+	m.placeRepo.AssertNotCalled(t, "Upsert", mock.Anything)
+	m.placeRepo.AssertNotCalled(t, "SaveSource", mock.Anything)
+}
+
+func TestGeocoderService_ImportFile_CSVWithoutCoordinates(t *testing.T) {
+	service, m := newGeocoderTestService()
+	path := writeDataset(t, "streets.csv", "street,city\nOak Ave,Springfield\n")
+
+	m.placeRepo.On("GetSource", "streets.csv").Return(nil, nil)
+
+	_, err := service.ImportFile(path, "")
+
+	assert.True(t, errors.Is(err, ErrValidation), "got %v", err)
+	m.placeRepo.AssertNotCalled(t, "SaveSource", mock.Anything)
+}
+
+func TestGeocoderService_ReverseGeocode(t *testing.T) {
+	service, m := newGeocoderTestService()
+
+	// Mock expectations: candidates come roughly ordered, the exact nearest
+	// is the second
+	m.placeRepo.On("FindNearest", 39.7752, -89.6721, 1.0, geocodeCandidates).Return([]models.GeoPlace{
+		{ID: 1, Street: "12 Oak Ave", Locality: "Springfield", Region: "IL", Postcode: "62702", Latitude: 39.7760, Longitude: -89.6721},
+		{ID: 2, Street: "742 Evergreen Terrace", Locality: "Springfield", Region: "IL", Postcode: "62704", CountryCode: "US",
+			Latitude: 39.7751, Longitude: -89.6720},
+	}, nil)
+	m.placeRepo.On("FindNearest", 40.0, -89.0, 1.0, geocodeCandidates).Return([]models.GeoPlace{
+		{ID: 3, Name: "Far Away", Latitude: 40.0, Longitude: -89.02},
+	}, nil)
+
+	// Execute
+	geocode, err := service.ReverseGeocode(39.7752, -89.6721)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, geocode.Place.ID)
+	assert.Equal(t, "742 Evergreen Terrace, Springfield, IL 62704, US", geocode.Address)
+	assert.Equal(t, 14.0, geocode.DistanceMeters)
+
+	// The only candidate is beyond the maximum distance
+	_, err = service.ReverseGeocode(40.0, -89.0)
+	assert.True(t, errors.Is(err, ErrNotFound), "got %v", err)
+
+	_, err = service.ReverseGeocode(91, 0)
+	assert.True(t, errors.Is(err, ErrValidation), "got %v", err)
+}
+
+func TestGeocoderService_ResolveClockEvent(t *testing.T) {
+	client := &models.Client{ID: 101, Latitude: 39.7817, Longitude: -89.6501}
+	nearby := []models.GeoPlace{{ID: 2, Street: "742 Evergreen Terrace", Locality: "Springfield", Latitude: 39.7751, Longitude: -89.6720}}
+
+	tests := []struct {
+		name      string
+		event     *models.ClockEvent
+		client    *models.Client
+		wantSaved bool
+	}{
+		{"outside the geofence", &models.ClockEvent{ScheduleID: 1, CaregiverID: 1, ClientID: 101, Event: models.ClockEventOut,
+			Latitude: 39.7752, Longitude: -89.6721, VerificationMethod: models.VerificationMethodGPS}, client, true},
+		{"at the client", &models.ClockEvent{ScheduleID: 1, CaregiverID: 1, ClientID: 101, Event: models.ClockEventIn,
+			Latitude: 39.7818, Longitude: -89.6502, VerificationMethod: models.VerificationMethodGPS}, client, false},
+		{"client location unknown", &models.ClockEvent{ScheduleID: 1, CaregiverID: 1, ClientID: 102, Event: models.ClockEventOut,
+			Latitude: 39.7752, Longitude: -89.6721, VerificationMethod: models.VerificationMethodGPS}, &models.Client{ID: 102}, true},
+		{"verified by token", &models.ClockEvent{ScheduleID: 1, CaregiverID: 1, ClientID: 101, Event: models.ClockEventIn,
+			VerificationMethod: models.VerificationMethodToken}, client, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := newGeocoderTestService()
+
+			// Mock expectations
+			m.clientRepo.On("GetByID", tt.event.ClientID).Return(tt.client, nil)
+			m.placeRepo.On("FindNearest", tt.event.Latitude, tt.event.Longitude, 1.0, geocodeCandidates).Return(nearby, nil)
+			m.visitRepo.On("SetClockAddress", 1, 1, tt.event.Event, "742 Evergreen Terrace, Springfield").Return(nil)
+
+			// Execute
+			err := service.ResolveClockEvent(tt.event)
+
+			// Assert
+			assert.NoError(t, err)
+			if tt.wantSaved {
+				m.visitRepo.AssertExpectations(t)
+			} else {
+				m.visitRepo.AssertNotCalled(t, "SetClockAddress", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestFormatAddress(t *testing.T) {
+	// A populated place is its own locality
+	place := models.GeoPlace{Name: "Springfield", Locality: "Springfield", Region: "IL", CountryCode: "US"}
+	assert.Equal(t, "Springfield, IL, US", formatAddress(place))
+	assert.False(t, strings.Contains(formatAddress(models.GeoPlace{Street: "Oak Ave"}), ","))
+}
+
+func TestGeocoderService_Geocode(t *testing.T) {
+	springfield := []models.GeoPlace{
+		{ID: 1, Street: "742 Evergreen Ter", Locality: "Springfield", Region: "IL", Postcode: "62704", Latitude: 39.7751, Longitude: -89.6720},
+		{ID: 2, Street: "744 Evergreen Ter", Locality: "Springfield", Region: "IL", Postcode: "62704", Latitude: 39.7753, Longitude: -89.6724},
+		{ID: 3, Street: "740 Evergreen Terrace", Locality: "Shelbyville", Region: "IL", Postcode: "62565", Latitude: 39.4, Longitude: -88.8},
+	}
+	area := []models.GeoPlace{
+		{ID: 4, Street: "12 Oak Ave", Locality: "Springfield", Region: "IL", Postcode: "62702", Latitude: 39.7990, Longitude: -89.6440},
+		{ID: 5, Name: "Springfield", Locality: "Springfield", Region: "IL", CountryCode: "US", Latitude: 39.8017, Longitude: -89.6437},
+	}
+
+	tests := []struct {
+		name      string
+		address   models.PostalAddress
+		street    string
+		wantMatch string
+		wantLat   float64
+		wantStr   string
+		wantErr   error
+	}{
+		{"house on the street", models.PostalAddress{Street: "742  evergreen terrace", City: "springfield", State: "il", ZipCode: "62704"},
+			"evergreen", models.GeocodeMatchAddress, 39.7751, "742 Evergreen Ter", nil},
+		{"house not in the dataset", models.PostalAddress{Street: "750 Evergreen Terrace", City: "Springfield", State: "IL", ZipCode: "62704"},
+			"evergreen", models.GeocodeMatchStreet, 39.7752, "750 Evergreen Terrace", nil},
+		{"street not in the dataset", models.PostalAddress{Street: "9 Elm St", City: "Springfield", State: "IL", ZipCode: "62702"},
+			"street", models.GeocodeMatchPostcode, 39.7990, "9 Elm Street", nil},
+		{"only the city is known", models.PostalAddress{Street: "9 Elm St", City: "Springfield", State: "IL", ZipCode: "62799"},
+			"street", models.GeocodeMatchLocality, 39.8017, "9 Elm Street", nil},
+		{"unknown city", models.PostalAddress{Street: "1 Main St", City: "Capital City", State: "IL", ZipCode: "60000"},
+			"street", "", 0, "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := newGeocoderTestService()
+
+			// Mock expectations: the repository filters by area loosely
+			m.placeRepo.On("FindInArea", "Capital City", "60000", mock.Anything, geocodeAreaLimit).Return(nil, nil)
+			m.placeRepo.On("FindInArea", mock.Anything, mock.Anything, "evergreen", geocodeAreaLimit).Return(springfield, nil)
+			m.placeRepo.On("FindInArea", mock.Anything, mock.Anything, "street", geocodeAreaLimit).Return(nil, nil)
+			m.placeRepo.On("FindInArea", mock.Anything, mock.Anything, "", geocodeAreaLimit).Return(area, nil)
+
+			// Execute
+			result, err := service.Geocode(tt.address)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMatch, result.Match)
+			assert.InDelta(t, tt.wantLat, result.Latitude, 1e-9)
+			assert.Equal(t, tt.wantStr, result.Address.Street)
+			assert.Equal(t, "Springfield", result.Address.City)
+			assert.Equal(t, "IL", result.Address.State)
+			assert.Equal(t, geocodeConfidence[tt.wantMatch], result.Confidence)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IdempotencyService handles business logic for idempotency keys
+type IdempotencyService struct {
+	idempotencyRepo repositories.IdempotencyRepository
+	ttl             time.Duration
+	logger          *logrus.Logger
+	now             func() time.Time
+}
+
+// NewIdempotencyService creates a new idempotency service; keys are kept for ttl
+func NewIdempotencyService(idempotencyRepo repositories.IdempotencyRepository, ttl time.Duration, logger *logrus.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		logger:          logger,
+		now:             time.Now,
+	}
+}
+
+// Begin reserves the key for a new request, identified by its method, path
+// with query string and body. When the key was already used for the same
+// request and has a stored response, that record is returned with replay set
+// to true.
+func (s *IdempotencyService) Begin(key, method, path string, body []byte) (*models.IdempotencyRecord, bool, error) {
+	hash := hashRequest(method, path, body)
+	now := s.now()
+
+	// A second attempt covers the race where another request reserves the key
+	// between our lookup and insert
+	for attempt := 0; attempt < 2; attempt++ {
+		existing, err := s.idempotencyRepo.Get(key)
+		if err != nil {
+			s.logger.WithError(err).WithField("idempotency_key", key).Error("Failed to get idempotency key")
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+
+		if existing != nil && !existing.ExpiresAt.After(now) {
+			if err := s.idempotencyRepo.Delete(key); err != nil {
+				return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+			}
+			existing = nil
+		}
+
+		if existing != nil {
+			if existing.RequestHash != hash {
+				s.logger.WithField("idempotency_key", key).Warn("Idempotency key reused with a different request")
+				return nil, false, ErrIdempotencyKeyReused
+			}
+			if existing.StatusCode == nil {
+				return nil, false, ErrIdempotencyInProgress
+			}
+
+			s.logger.WithField("idempotency_key", key).Info("Replaying stored response")
+			return existing, true, nil
+		}
+
+		record := &models.IdempotencyRecord{
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.ttl),
+		}
+
+		reserved, err := s.idempotencyRepo.Reserve(record)
+		if err != nil {
+			s.logger.WithError(err).WithField("idempotency_key", key).Error("Failed to reserve idempotency key")
+			return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+		if reserved {
+			return record, false, nil
+		}
+	}
+
+	return nil, false, ErrIdempotencyInProgress
+}
+
+// Complete stores the response and its replayed headers for a reserved key so replays can return them
+func (s *IdempotencyService) Complete(key string, statusCode int, headers map[string]string, body []byte) error {
+	if err := s.idempotencyRepo.Complete(key, statusCode, headers, body); err != nil {
+		s.logger.WithError(err).WithField("idempotency_key", key).Error("Failed to store idempotent response")
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release frees a reserved key so the request can be retried, used when it failed unexpectedly
+func (s *IdempotencyService) Release(key string) error {
+	if err := s.idempotencyRepo.Delete(key); err != nil {
+		s.logger.WithError(err).WithField("idempotency_key", key).Error("Failed to release idempotency key")
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpired deletes keys whose TTL has passed
+func (s *IdempotencyService) CleanupExpired() (int64, error) {
+	deleted, err := s.idempotencyRepo.DeleteExpired(s.now())
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to clean up expired idempotency keys")
+		return 0, fmt.Errorf("failed to clean up expired idempotency keys: %w", err)
+	}
+
+	s.logger.WithField("count", deleted).Debug("Cleaned up expired idempotency keys")
+	return deleted, nil
+}
+
+// RunCleanup periodically deletes expired keys until the context is cancelled
+func (s *IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.CleanupExpired()
+		}
+	}
+}
+
+// hashRequest fingerprints a request so a reused key with a different payload can be detected
+func hashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
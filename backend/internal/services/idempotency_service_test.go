@@ -0,0 +1,204 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyRepository is a mock implementation of IdempotencyRepository
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) Get(key string) (*models.IdempotencyRecord, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Reserve(record *models.IdempotencyRecord) (bool, error) {
+	args := m.Called(record)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Complete(key string, statusCode int, headers map[string]string, body []byte) error {
+	args := m.Called(key, statusCode, headers, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) Delete(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestIdempotencyService_Begin_NewKey(t *testing.T) {
+	// Setup
+	mockRepo := new(MockIdempotencyRepository)
+	service := NewIdempotencyService(mockRepo, time.Hour, logrus.New())
+
+	// Mock expectations
+	mockRepo.On("Get", "key-1").Return(nil, nil)
+	mockRepo.On("Reserve", mock.AnythingOfType("*models.IdempotencyRecord")).Return(true, nil)
+
+	// Execute
+	record, replay, err := service.Begin("key-1", "POST", "/api/v1/schedules/1/start", []byte(`{}`))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, replay)
+	assert.Equal(t, "key-1", record.Key)
+	assert.Equal(t, hashRequest("POST", "/api/v1/schedules/1/start", []byte(`{}`)), record.RequestHash)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), record.ExpiresAt, time.Minute)
+
+	// Verify mock expectations
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyService_Begin_Replay(t *testing.T) {
+	// Setup
+	mockRepo := new(MockIdempotencyRepository)
+	service := NewIdempotencyService(mockRepo, time.Hour, logrus.New())
+
+	// Test data
+	status := http.StatusCreated
+	stored := &models.IdempotencyRecord{
+		Key:          "key-1",
+		RequestHash:  hashRequest("POST", "/api/v1/clients", []byte(`{"name":"Jane"}`)),
+		StatusCode:   &status,
+		ResponseBody: []byte(`{"success":true}`),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	// Mock expectations
+	mockRepo.On("Get", "key-1").Return(stored, nil)
+
+	// Execute
+	record, replay, err := service.Begin("key-1", "POST", "/api/v1/clients", []byte(`{"name":"Jane"}`))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, replay)
+	assert.Equal(t, http.StatusCreated, *record.StatusCode)
+	assert.Equal(t, stored.ResponseBody, record.ResponseBody)
+
+	// Verify mock expectations
+	mockRepo.AssertNotCalled(t, "Reserve", mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyService_Begin_DifferentPayload(t *testing.T) {
+	// Setup
+	mockRepo := new(MockIdempotencyRepository)
+	service := NewIdempotencyService(mockRepo, time.Hour, logrus.New())
+
+	// Test data
+	status := http.StatusCreated
+	stored := &models.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: hashRequest("POST", "/api/v1/clients", []byte(`{"name":"Jane"}`)),
+		StatusCode:  &status,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	// Mock expectations
+	mockRepo.On("Get", "key-1").Return(stored, nil)
+
+	// Execute
+	record, replay, err := service.Begin("key-1", "POST", "/api/v1/clients", []byte(`{"name":"John"}`))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	assert.False(t, replay)
+	assert.Nil(t, record)
+
+	// Verify mock expectations
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyService_Begin_InProgress(t *testing.T) {
+	// Setup
+	mockRepo := new(MockIdempotencyRepository)
+	service := NewIdempotencyService(mockRepo, time.Hour, logrus.New())
+
+	// Test data - reserved but no response stored yet
+	stored := &models.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: hashRequest("DELETE", "/api/v1/tasks/1", nil),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	// Mock expectations
+	mockRepo.On("Get", "key-1").Return(stored, nil)
+
+	// Execute
+	_, _, err := service.Begin("key-1", "DELETE", "/api/v1/tasks/1", nil)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrIdempotencyInProgress)
+
+	// Verify mock expectations
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyService_Begin_ExpiredKey(t *testing.T) {
+	// Setup
+	mockRepo := new(MockIdempotencyRepository)
+	service := NewIdempotencyService(mockRepo, time.Hour, logrus.New())
+
+	// Test data - an expired key is treated as unused
+	status := http.StatusOK
+	stored := &models.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: "stale",
+		StatusCode:  &status,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+
+	// Mock expectations
+	mockRepo.On("Get", "key-1").Return(stored, nil)
+	mockRepo.On("Delete", "key-1").Return(nil)
+	mockRepo.On("Reserve", mock.AnythingOfType("*models.IdempotencyRecord")).Return(true, nil)
+
+	// Execute
+	record, replay, err := service.Begin("key-1", "PUT", "/api/v1/tasks/1", []byte(`{}`))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, replay)
+	assert.NotNil(t, record)
+
+	// Verify mock expectations
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyService_CleanupExpired(t *testing.T) {
+	// Setup
+	mockRepo := new(MockIdempotencyRepository)
+	service := NewIdempotencyService(mockRepo, time.Hour, logrus.New())
+
+	// Mock expectations
+	mockRepo.On("DeleteExpired", mock.AnythingOfType("time.Time")).Return(int64(3), nil)
+
+	// Execute
+	deleted, err := service.CleanupExpired()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	// Verify mock expectations
+	mockRepo.AssertExpectations(t)
+}
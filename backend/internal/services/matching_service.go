@@ -0,0 +1,526 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Points each match factor contributes to a candidate's score, out of 100
+const (
+	weightContinuity = 30.0
+	weightDistance   = 20.0
+	weightLanguage   = 20.0
+	weightGender     = 15.0
+	weightWorkload   = 15.0
+)
+
+// continuityVisits is the number of earlier visits to a client that earns the
+// full continuity of care score
+const continuityVisits = 5
+
+// maxTravelKm is the travel distance from the previous visit that earns no
+// distance score
+const maxTravelKm = 30.0
+
+// standardWeeklyHours is the workload caregivers without a weekly hour limit
+// are measured against
+const standardWeeklyHours = 40.0
+
+// languagePattern matches ISO 639-1 and 639-2 language codes
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// MatchingService handles caregiver profiles and client preferences, and
+// ranks the caregivers who could take a schedule
+type MatchingService struct {
+	scheduleRepo     repositories.ScheduleRepository
+	caregiverRepo    repositories.ScheduleCaregiverRepository
+	clientRepo       repositories.ClientRepository
+	profileRepo      repositories.CaregiverProfileRepository
+	preferenceRepo   repositories.ClientPreferenceRepository
+	availabilityRepo repositories.AvailabilityRepository
+	availability     *AvailabilityService
+	skills           *SkillService
+	location         *time.Location
+	logger           *logrus.Logger
+}
+
+// NewMatchingService creates a new matching service; location is the agency
+// default timezone, UTC when nil
+func NewMatchingService(
+	scheduleRepo repositories.ScheduleRepository,
+	caregiverRepo repositories.ScheduleCaregiverRepository,
+	clientRepo repositories.ClientRepository,
+	profileRepo repositories.CaregiverProfileRepository,
+	preferenceRepo repositories.ClientPreferenceRepository,
+	availabilityRepo repositories.AvailabilityRepository,
+	availability *AvailabilityService,
+	skills *SkillService,
+	location *time.Location,
+	logger *logrus.Logger,
+) *MatchingService {
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &MatchingService{
+		scheduleRepo:     scheduleRepo,
+		caregiverRepo:    caregiverRepo,
+		clientRepo:       clientRepo,
+		profileRepo:      profileRepo,
+		preferenceRepo:   preferenceRepo,
+		availabilityRepo: availabilityRepo,
+		availability:     availability,
+		skills:           skills,
+		location:         location,
+		logger:           logger,
+	}
+}
+
+// GetCaregiverProfile retrieves a caregiver's profile. A caregiver without one
+// is returned with no gender and no languages.
+func (s *MatchingService) GetCaregiverProfile(caregiverID int) (*models.CaregiverProfile, error) {
+	profile, err := s.profileRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get caregiver profile")
+		return nil, fmt.Errorf("failed to get caregiver profile: %w", err)
+	}
+	if profile == nil {
+		profile = &models.CaregiverProfile{CaregiverID: caregiverID, Languages: []string{}}
+	}
+
+	return profile, nil
+}
+
+// SetCaregiverProfile replaces a caregiver's profile
+func (s *MatchingService) SetCaregiverProfile(caregiverID int, req *models.CaregiverProfileRequest) (*models.CaregiverProfile, error) {
+	s.logger.WithField("caregiver_id", caregiverID).Info("Setting caregiver profile")
+
+	if caregiverID <= 0 {
+		return nil, fmt.Errorf("%w: invalid caregiver ID", ErrValidation)
+	}
+	if err := validateGender(req.Gender); err != nil {
+		return nil, err
+	}
+	languages, err := normalizeLanguages(req.Languages)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.CaregiverProfile{
+		CaregiverID: caregiverID,
+		Gender:      req.Gender,
+		Languages:   languages,
+	}
+	if err := s.profileRepo.Replace(profile); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to set caregiver profile")
+		return nil, fmt.Errorf("failed to set caregiver profile: %w", err)
+	}
+
+	s.logger.WithField("caregiver_id", caregiverID).Info("Successfully set caregiver profile")
+	return profile, nil
+}
+
+// GetClientPreferences retrieves a client's caregiver preferences. A client
+// without any is returned with no preferences and no blocked caregivers.
+func (s *MatchingService) GetClientPreferences(clientID int) (*models.ClientPreferences, error) {
+	if err := s.requireClient(clientID); err != nil {
+		return nil, err
+	}
+
+	preferences, err := s.preferenceRepo.GetByClientID(clientID)
+	if err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to get client preferences")
+		return nil, fmt.Errorf("failed to get client preferences: %w", err)
+	}
+	if preferences == nil {
+		preferences = &models.ClientPreferences{
+			ClientID:          clientID,
+			Languages:         []string{},
+			BlockedCaregivers: []models.BlockedCaregiver{},
+		}
+	}
+
+	return preferences, nil
+}
+
+// SetClientPreferences replaces a client's caregiver preferences and blocked caregivers
+func (s *MatchingService) SetClientPreferences(clientID int, req *models.ClientPreferencesRequest) (*models.ClientPreferences, error) {
+	s.logger.WithFields(logrus.Fields{
+		"client_id": clientID,
+		"blocked":   len(req.BlockedCaregivers),
+	}).Info("Setting client preferences")
+
+	if err := s.requireClient(clientID); err != nil {
+		return nil, err
+	}
+	if err := validateGender(req.PreferredGender); err != nil {
+		return nil, err
+	}
+	languages, err := normalizeLanguages(req.Languages)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := []models.BlockedCaregiver{}
+	seen := map[int]bool{}
+	for _, b := range req.BlockedCaregivers {
+		if b.CaregiverID <= 0 {
+			return nil, fmt.Errorf("%w: invalid blocked caregiver ID %d", ErrValidation, b.CaregiverID)
+		}
+		if seen[b.CaregiverID] {
+			return nil, fmt.Errorf("%w: caregiver %d is blocked more than once", ErrValidation, b.CaregiverID)
+		}
+		seen[b.CaregiverID] = true
+		blocked = append(blocked, models.BlockedCaregiver{CaregiverID: b.CaregiverID, Reason: strings.TrimSpace(b.Reason)})
+	}
+
+	preferences := &models.ClientPreferences{
+		ClientID:          clientID,
+		PreferredGender:   req.PreferredGender,
+		Languages:         languages,
+		BlockedCaregivers: blocked,
+	}
+	if err := s.preferenceRepo.Replace(preferences); err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to set client preferences")
+		return nil, fmt.Errorf("failed to set client preferences: %w", err)
+	}
+
+	s.logger.WithField("client_id", clientID).Info("Successfully set client preferences")
+	return preferences, nil
+}
+
+// requireClient returns ErrNotFound when the client does not exist
+func (s *MatchingService) requireClient(clientID int) error {
+	client, err := s.clientRepo.GetByID(clientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if client == nil {
+		return fmt.Errorf("client %d: %w", clientID, ErrNotFound)
+	}
+	return nil
+}
+
+// ValidateAssignment rejects caregivers the client has blocked. It is
+// registered with the schedule service through OnAssign.
+func (s *MatchingService) ValidateAssignment(schedule *models.Schedule, caregiverID int) error {
+	preferences, err := s.preferenceRepo.GetByClientID(schedule.ClientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client preferences: %w", err)
+	}
+	if preferences == nil {
+		return nil
+	}
+
+	for _, b := range preferences.BlockedCaregivers {
+		if b.CaregiverID == caregiverID {
+			return fmt.Errorf("%w: caregiver %d is blocked by client %d", ErrCaregiverUnavailable, caregiverID, schedule.ClientID)
+		}
+	}
+	return nil
+}
+
+// GetCandidates ranks the caregivers who could take a scheduled visit.
+// Caregivers who are unavailable, lack a required certification or were
+// blocked by the client are not eligible; the others are scored on continuity
+// of care, travel from their previous visit, the client's language and gender
+// preferences and how much of their week is already booked. Caregivers
+// already on the schedule's team are not candidates.
+func (s *MatchingService) GetCandidates(scheduleID int, opts models.CandidateOptions) ([]models.MatchCandidate, error) {
+	s.logger.WithField("schedule_id", scheduleID).Info("Ranking schedule candidates")
+
+	if opts.Limit != nil && *opts.Limit <= 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrValidation)
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+	if schedule.Status != models.ScheduleStatusScheduled {
+		return nil, fmt.Errorf("%w: cannot match caregivers to a %s schedule", ErrInvalidTransition, schedule.Status)
+	}
+
+	team, err := s.caregiverRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule caregivers: %w", err)
+	}
+	onTeam := map[int]bool{schedule.CaregiverID: true}
+	for _, member := range team {
+		onTeam[member.CaregiverID] = true
+	}
+
+	preferences, err := s.preferenceRepo.GetByClientID(schedule.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client preferences: %w", err)
+	}
+
+	caregiverIDs, err := s.availabilityRepo.GetCaregiverIDs()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get caregivers")
+		return nil, fmt.Errorf("failed to get caregivers: %w", err)
+	}
+
+	candidates := []models.MatchCandidate{}
+	for _, caregiverID := range caregiverIDs {
+		if onTeam[caregiverID] {
+			continue
+		}
+
+		candidate, err := s.scoreCandidate(schedule, caregiverID, preferences)
+		if err != nil {
+			s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to score candidate")
+			return nil, err
+		}
+		if candidate.Eligible || opts.IncludeIneligible {
+			candidates = append(candidates, *candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.CaregiverID < b.CaregiverID
+	})
+	if opts.Limit != nil && len(candidates) > *opts.Limit {
+		candidates = candidates[:*opts.Limit]
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id": scheduleID,
+		"count":       len(candidates),
+	}).Info("Ranked schedule candidates")
+	return candidates, nil
+}
+
+// scoreCandidate checks whether a caregiver can take a schedule and scores
+// how good a match they are
+func (s *MatchingService) scoreCandidate(schedule *models.Schedule, caregiverID int, preferences *models.ClientPreferences) (*models.MatchCandidate, error) {
+	candidate := &models.MatchCandidate{CaregiverID: caregiverID}
+
+	if preferences != nil {
+		for _, b := range preferences.BlockedCaregivers {
+			if b.CaregiverID == caregiverID {
+				exclusion := "blocked by the client"
+				if b.Reason != "" {
+					exclusion += ": " + b.Reason
+				}
+				candidate.Exclusions = append(candidate.Exclusions, exclusion)
+			}
+		}
+	}
+
+	err := s.availability.CheckAvailability(caregiverID, schedule.StartTime, schedule.EndTime, schedule.ID)
+	if errors.Is(err, ErrCaregiverUnavailable) {
+		candidate.Exclusions = append(candidate.Exclusions, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+
+	missing, err := s.skills.MissingSkills(caregiverID, schedule.ServiceName, schedule.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		candidate.Exclusions = append(candidate.Exclusions, "no valid certification for "+strings.Join(missing, ", "))
+	}
+
+	profile, err := s.profileRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caregiver profile: %w", err)
+	}
+
+	continuity, err := s.continuityFactor(schedule, caregiverID)
+	if err != nil {
+		return nil, err
+	}
+	distance, err := s.distanceFactor(schedule, caregiverID)
+	if err != nil {
+		return nil, err
+	}
+	workload, err := s.workloadFactor(schedule, caregiverID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate.Factors = []models.MatchFactor{
+		continuity,
+		distance,
+		languageFactor(profile, preferences),
+		genderFactor(profile, preferences),
+		workload,
+	}
+	for _, factor := range candidate.Factors {
+		candidate.Score += factor.Points
+	}
+	candidate.Score = math.Round(candidate.Score*10) / 10
+	candidate.Eligible = len(candidate.Exclusions) == 0
+
+	return candidate, nil
+}
+
+// continuityFactor scores a caregiver on the visits they completed for the
+// schedule's client before it
+func (s *MatchingService) continuityFactor(schedule *models.Schedule, caregiverID int) (models.MatchFactor, error) {
+	visits, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		ClientID:    &schedule.ClientID,
+		To:          &schedule.StartTime,
+		Statuses:    []string{models.ScheduleStatusCompleted},
+	})
+	if err != nil {
+		return models.MatchFactor{}, fmt.Errorf("failed to get visit history: %w", err)
+	}
+
+	count := len(visits)
+	detail := fmt.Sprintf("%d earlier visits to this client", count)
+	if count == 0 {
+		detail = "has not visited this client before"
+	}
+	return newMatchFactor("continuity", weightContinuity, math.Min(float64(count), continuityVisits)/continuityVisits, detail), nil
+}
+
+// distanceFactor scores a caregiver on how far they travel from the visit
+// before the schedule on the same day. Caregivers with no earlier visit that
+// day score half, since their starting point is unknown.
+func (s *MatchingService) distanceFactor(schedule *models.Schedule, caregiverID int) (models.MatchFactor, error) {
+	dayStart, _ := dayBounds(schedule.StartTime, clientLocation(schedule.Client, s.location))
+	earlier, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		From:        &dayStart,
+		To:          &schedule.StartTime,
+		Statuses:    []string{models.ScheduleStatusScheduled, models.ScheduleStatusInProgress, models.ScheduleStatusCompleted},
+	})
+	if err != nil {
+		return models.MatchFactor{}, fmt.Errorf("failed to get earlier schedules: %w", err)
+	}
+
+	// Schedules are listed by start time, so the previous visit is the last one
+	var previous *models.Schedule
+	for i := range earlier {
+		if earlier[i].ID != schedule.ID {
+			previous = &earlier[i]
+		}
+	}
+
+	if previous == nil {
+		return newMatchFactor("distance", weightDistance, 0.5, "no earlier visit that day"), nil
+	}
+	if !hasCoordinates(previous.Client) || !hasCoordinates(schedule.Client) {
+		return newMatchFactor("distance", weightDistance, 0.5,
+			fmt.Sprintf("location unknown for previous visit (schedule %d)", previous.ID)), nil
+	}
+
+	km := distanceKm(previous.Client.Latitude, previous.Client.Longitude, schedule.Client.Latitude, schedule.Client.Longitude)
+	return newMatchFactor("distance", weightDistance, math.Max(0, 1-km/maxTravelKm),
+		fmt.Sprintf("%.1f km from previous visit (schedule %d)", km, previous.ID)), nil
+}
+
+// workloadFactor favours caregivers with more of their week free
+func (s *MatchingService) workloadFactor(schedule *models.Schedule, caregiverID int) (models.MatchFactor, error) {
+	booked, limit, err := s.availability.WeeklyHours(caregiverID, schedule.StartTime, schedule.ID)
+	if err != nil {
+		return models.MatchFactor{}, err
+	}
+
+	capacity := standardWeeklyHours
+	detail := fmt.Sprintf("%.1f hours booked this week", booked)
+	if limit != nil {
+		capacity = *limit
+		detail = fmt.Sprintf("%.1f of %.1f weekly hours booked", booked, *limit)
+	}
+
+	score := 0.0
+	if capacity > 0 {
+		score = math.Max(0, 1-booked/capacity)
+	}
+	return newMatchFactor("weekly_hours", weightWorkload, score, detail), nil
+}
+
+// languageFactor scores whether a caregiver speaks one of the client's languages
+func languageFactor(profile *models.CaregiverProfile, preferences *models.ClientPreferences) models.MatchFactor {
+	if preferences == nil || len(preferences.Languages) == 0 {
+		return newMatchFactor("language", weightLanguage, 1, "client has no language preference")
+	}
+
+	if profile != nil {
+		for _, language := range preferences.Languages {
+			for _, spoken := range profile.Languages {
+				if spoken == language {
+					return newMatchFactor("language", weightLanguage, 1, "speaks "+language)
+				}
+			}
+		}
+	}
+	return newMatchFactor("language", weightLanguage, 0, "speaks none of "+strings.Join(preferences.Languages, ", "))
+}
+
+// genderFactor scores a caregiver against the client's preferred gender.
+// Caregivers whose gender is not recorded score half.
+func genderFactor(profile *models.CaregiverProfile, preferences *models.ClientPreferences) models.MatchFactor {
+	if preferences == nil || preferences.PreferredGender == "" {
+		return newMatchFactor("gender", weightGender, 1, "client has no gender preference")
+	}
+	if profile == nil || profile.Gender == "" {
+		return newMatchFactor("gender", weightGender, 0.5, "gender not recorded")
+	}
+	if profile.Gender == preferences.PreferredGender {
+		return newMatchFactor("gender", weightGender, 1, "matches preferred gender "+preferences.PreferredGender)
+	}
+	return newMatchFactor("gender", weightGender, 0, "client prefers "+preferences.PreferredGender)
+}
+
+// newMatchFactor builds a factor worth weight points of which the candidate earns score
+func newMatchFactor(name string, weight, score float64, detail string) models.MatchFactor {
+	score = round2(score)
+	return models.MatchFactor{
+		Name:   name,
+		Weight: weight,
+		Score:  score,
+		Points: round2(weight * score),
+		Detail: detail,
+	}
+}
+
+// validateGender checks that gender is empty or one of the known genders
+func validateGender(gender string) error {
+	switch gender {
+	case "", models.GenderFemale, models.GenderMale, models.GenderNonBinary:
+		return nil
+	}
+	return fmt.Errorf("%w: invalid gender %q", ErrValidation, gender)
+}
+
+// normalizeLanguages lower-cases language codes and drops duplicates
+func normalizeLanguages(languages []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, language := range languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if !languagePattern.MatchString(language) {
+			return nil, fmt.Errorf("%w: invalid language code %q", ErrValidation, language)
+		}
+		if !seen[language] {
+			seen[language] = true
+			normalized = append(normalized, language)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockClientRepository is a mock implementation of ClientRepository
+type MockClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockClientRepository) GetAll(filter *models.ClientFilter) ([]models.Client, *models.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Client), nil, args.Error(2)
+}
+
+func (m *MockClientRepository) GetByID(id int) (*models.Client, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+func (m *MockClientRepository) Create(client *models.Client) error {
+	args := m.Called(client)
+	return args.Error(0)
+}
+
+func (m *MockClientRepository) Update(client *models.Client) error {
+	args := m.Called(client)
+	return args.Error(0)
+}
+
+func (m *MockClientRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockClientRepository) Search(query string) ([]models.Client, error) {
+	args := m.Called(query)
+	return args.Get(0).([]models.Client), args.Error(1)
+}
+
+func (m *MockClientRepository) GetByPhone(phone string) (*models.Client, error) {
+	args := m.Called(phone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+// MockCaregiverProfileRepository is a mock implementation of CaregiverProfileRepository
+type MockCaregiverProfileRepository struct {
+	mock.Mock
+}
+
+func (m *MockCaregiverProfileRepository) GetByCaregiverID(caregiverID int) (*models.CaregiverProfile, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverProfile), args.Error(1)
+}
+
+func (m *MockCaregiverProfileRepository) Replace(profile *models.CaregiverProfile) error {
+	args := m.Called(profile)
+	return args.Error(0)
+}
+
+// MockClientPreferenceRepository is a mock implementation of ClientPreferenceRepository
+type MockClientPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockClientPreferenceRepository) GetByClientID(clientID int) (*models.ClientPreferences, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientPreferences), args.Error(1)
+}
+
+func (m *MockClientPreferenceRepository) Replace(preferences *models.ClientPreferences) error {
+	args := m.Called(preferences)
+	return args.Error(0)
+}
+
+// matchingTestMocks holds the repositories behind a matching service under test
+type matchingTestMocks struct {
+	scheduleRepo     *MockScheduleRepository
+	caregiverRepo    *MockScheduleCaregiverRepository
+	clientRepo       *MockClientRepository
+	profileRepo      *MockCaregiverProfileRepository
+	preferenceRepo   *MockClientPreferenceRepository
+	availabilityRepo *MockAvailabilityRepository
+	timeOffRepo      *MockTimeOffRepository
+	skillRepo        *MockSkillRepository
+	serviceTypeRepo  *MockServiceTypeRepository
+}
+
+func newMatchingTestService() (*MatchingService, *matchingTestMocks) {
+	m := &matchingTestMocks{
+		scheduleRepo:     new(MockScheduleRepository),
+		caregiverRepo:    new(MockScheduleCaregiverRepository),
+		clientRepo:       new(MockClientRepository),
+		profileRepo:      new(MockCaregiverProfileRepository),
+		preferenceRepo:   new(MockClientPreferenceRepository),
+		availabilityRepo: new(MockAvailabilityRepository),
+		timeOffRepo:      new(MockTimeOffRepository),
+		skillRepo:        new(MockSkillRepository),
+		serviceTypeRepo:  new(MockServiceTypeRepository),
+	}
+	logger := logrus.New()
+	availability := NewAvailabilityService(m.availabilityRepo, m.timeOffRepo, m.scheduleRepo, nil, logger)
+	skills := NewSkillService(m.skillRepo, m.serviceTypeRepo, logger)
+	service := NewMatchingService(m.scheduleRepo, m.caregiverRepo, m.clientRepo, m.profileRepo, m.preferenceRepo,
+		m.availabilityRepo, availability, skills, nil, logger)
+	return service, m
+}
+
+// scheduleQuery matches the schedule lookups the matching service makes for a caregiver
+func scheduleQuery(caregiverID int, match func(*models.ScheduleFilter) bool) interface{} {
+	return mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return f.CaregiverID != nil && *f.CaregiverID == caregiverID && match(f)
+	})
+}
+
+func isContinuityQuery(f *models.ScheduleFilter) bool { return f.ClientID != nil }
+func isPreviousVisitQuery(f *models.ScheduleFilter) bool {
+	return f.From != nil && f.ClientID == nil
+}
+func isWeeklyHoursQuery(f *models.ScheduleFilter) bool {
+	return f.ActiveFrom != nil && len(f.Statuses) == 3
+}
+func anyQuery(*models.ScheduleFilter) bool { return true }
+
+func TestMatchingService_GetCandidates(t *testing.T) {
+	service, m := newMatchingTestService()
+
+	// Monday 2025-01-06, 14:00 to 16:00 at client 102
+	start := time.Date(2025, 1, 6, 14, 0, 0, 0, time.UTC)
+	maryJohnson := &models.Client{ID: 102, Latitude: 39.7990, Longitude: -89.6440}
+	johnSmith := &models.Client{ID: 101, Latitude: 39.7817, Longitude: -89.6501}
+	schedule := &models.Schedule{ID: 10, ClientID: 102, ServiceName: "Personal Care Service", CaregiverID: 1,
+		StartTime: start, EndTime: start.Add(2 * time.Hour), Status: models.ScheduleStatusScheduled, Client: maryJohnson}
+
+	m.scheduleRepo.On("GetByID", 10).Return(schedule, nil)
+	m.caregiverRepo.On("GetByScheduleID", 10).Return([]models.ScheduleCaregiver{{ScheduleID: 10, CaregiverID: 1, Role: models.CaregiverRoleLead}}, nil)
+	m.preferenceRepo.On("GetByClientID", 102).Return(&models.ClientPreferences{
+		ClientID:          102,
+		PreferredGender:   models.GenderFemale,
+		Languages:         []string{"es"},
+		BlockedCaregivers: []models.BlockedCaregiver{{CaregiverID: 3, Reason: "repeatedly late"}},
+	}, nil)
+	m.availabilityRepo.On("GetCaregiverIDs").Return([]int{1, 2, 3, 4}, nil)
+	m.availabilityRepo.On("GetByCaregiverID", mock.Anything).Return(nil, nil)
+	m.timeOffRepo.On("GetOverlapping", mock.Anything, mock.Anything, mock.Anything, models.TimeOffStatusApproved).Return([]models.TimeOffRequest{}, nil)
+	m.serviceTypeRepo.On("GetByName", "Personal Care Service").Return(nil, nil)
+
+	m.profileRepo.On("GetByCaregiverID", 2).Return(&models.CaregiverProfile{CaregiverID: 2, Gender: models.GenderFemale, Languages: []string{"en", "es"}}, nil)
+	m.profileRepo.On("GetByCaregiverID", 3).Return(nil, nil)
+	m.profileRepo.On("GetByCaregiverID", 4).Return(&models.CaregiverProfile{CaregiverID: 4, Gender: models.GenderMale, Languages: []string{"en"}}, nil)
+
+	// Caregiver 2 visited the client three times and comes from client 101 that morning
+	m.scheduleRepo.On("GetAll", scheduleQuery(2, isContinuityQuery)).Return([]models.Schedule{{ID: 5}, {ID: 6}, {ID: 7}}, nil, nil)
+	m.scheduleRepo.On("GetAll", scheduleQuery(2, isPreviousVisitQuery)).Return([]models.Schedule{
+		{ID: 8, ClientID: 101, StartTime: start.Add(-3 * time.Hour), EndTime: start.Add(-time.Hour), Client: johnSmith},
+	}, nil, nil)
+	// Caregiver 4 already works 20 hours that week
+	m.scheduleRepo.On("GetAll", scheduleQuery(4, isWeeklyHoursQuery)).Return([]models.Schedule{
+		{ID: 9, StartTime: start.AddDate(0, 0, 1), EndTime: start.AddDate(0, 0, 1).Add(20 * time.Hour)},
+	}, nil, nil)
+	for _, caregiverID := range []int{2, 3, 4} {
+		m.scheduleRepo.On("GetAll", scheduleQuery(caregiverID, anyQuery)).Return([]models.Schedule{}, nil, nil)
+	}
+
+	candidates, err := service.GetCandidates(10, models.CandidateOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 2) {
+		// Continuity, a short trip, a shared language and the preferred gender
+		assert.Equal(t, 2, candidates[0].CaregiverID)
+		assert.True(t, candidates[0].Eligible)
+		assert.InDelta(t, 18+18.7+20+15+15, candidates[0].Score, 0.2)
+		assert.Len(t, candidates[0].Factors, 5)
+
+		// No history, unknown starting point, no shared language, half their week booked
+		assert.Equal(t, 4, candidates[1].CaregiverID)
+		assert.Equal(t, 17.5, candidates[1].Score)
+	}
+
+	// The blocked caregiver is listed last, with the reason
+	candidates, err = service.GetCandidates(10, models.CandidateOptions{IncludeIneligible: true})
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 3) {
+		assert.Equal(t, 3, candidates[2].CaregiverID)
+		assert.False(t, candidates[2].Eligible)
+		assert.Equal(t, []string{"blocked by the client: repeatedly late"}, candidates[2].Exclusions)
+	}
+
+	limit := 1
+	candidates, err = service.GetCandidates(10, models.CandidateOptions{Limit: &limit})
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 1)
+}
+
+func TestMatchingService_GetCandidates_NotScheduled(t *testing.T) {
+	service, m := newMatchingTestService()
+	m.scheduleRepo.On("GetByID", 3).Return(&models.Schedule{ID: 3, Status: models.ScheduleStatusMissed}, nil)
+	m.scheduleRepo.On("GetByID", 99).Return(nil, nil)
+
+	_, err := service.GetCandidates(3, models.CandidateOptions{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = service.GetCandidates(99, models.CandidateOptions{})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMatchingService_SetClientPreferences(t *testing.T) {
+	service, m := newMatchingTestService()
+	m.clientRepo.On("GetByID", 102).Return(&models.Client{ID: 102}, nil)
+	m.clientRepo.On("GetByID", 999).Return(nil, nil)
+	m.preferenceRepo.On("Replace", mock.AnythingOfType("*models.ClientPreferences")).Return(nil)
+
+	_, err := service.SetClientPreferences(999, &models.ClientPreferencesRequest{})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = service.SetClientPreferences(102, &models.ClientPreferencesRequest{PreferredGender: "any"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.SetClientPreferences(102, &models.ClientPreferencesRequest{Languages: []string{"English"}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.SetClientPreferences(102, &models.ClientPreferencesRequest{
+		BlockedCaregivers: []models.BlockedCaregiver{{CaregiverID: 3}, {CaregiverID: 3}},
+	})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	preferences, err := service.SetClientPreferences(102, &models.ClientPreferencesRequest{
+		PreferredGender:   models.GenderFemale,
+		Languages:         []string{"EN", " es", "en"},
+		BlockedCaregivers: []models.BlockedCaregiver{{CaregiverID: 3, Reason: " late "}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"en", "es"}, preferences.Languages)
+	assert.Equal(t, "late", preferences.BlockedCaregivers[0].Reason)
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MileageService measures and records the travel of caregivers between visits
+type MileageService struct {
+	scheduleRepo repositories.ScheduleRepository
+	visitRepo    repositories.VisitRepository
+	travelRepo   repositories.TravelSegmentRepository
+	location     *time.Location // Agency default for clients without a timezone
+	now          func() time.Time
+	logger       *logrus.Logger
+}
+
+// NewMileageService creates a new mileage service; location is the agency
+// default timezone, UTC when nil
+func NewMileageService(
+	scheduleRepo repositories.ScheduleRepository,
+	visitRepo repositories.VisitRepository,
+	travelRepo repositories.TravelSegmentRepository,
+	location *time.Location,
+	logger *logrus.Logger,
+) *MileageService {
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &MileageService{
+		scheduleRepo: scheduleRepo,
+		visitRepo:    visitRepo,
+		travelRepo:   travelRepo,
+		location:     location,
+		now:          time.Now,
+		logger:       logger,
+	}
+}
+
+// clockedVisit is a caregiver's visit with the timezone of its client
+type clockedVisit struct {
+	schedule *models.Schedule
+	visit    *models.Visit
+	loc      *time.Location
+}
+
+// RecordClockIn measures the travel to a visit the caregiver just clocked in
+// to, recalculating their day. It is registered as a clock-in hook.
+func (s *MileageService) RecordClockIn(scheduleID, caregiverID int) error {
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.StartTime == nil {
+		return nil
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil
+	}
+
+	date := visit.StartTime.In(clientLocation(schedule.Client, s.location)).Format(dateLayout)
+	_, err = s.RecalculateDay(caregiverID, date)
+	return err
+}
+
+// RecalculateDay measures a caregiver's travel on a date (YYYY-MM-DD) from the
+// clock-out of each visit to the clock-in of the next, and stores it.
+// Adjustments of segments that are measured again are kept; segments that no
+// longer join consecutive visits are removed.
+func (s *MileageService) RecalculateDay(caregiverID int, date string) (*models.TravelLog, error) {
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"date":         date,
+	}).Info("Recalculating caregiver travel")
+
+	day, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", ErrValidation, date)
+	}
+
+	measured, err := s.measureDay(caregiverID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.travelRepo.GetByCaregiver(caregiverID, date, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get travel segments: %w", err)
+	}
+
+	kept := map[[2]int]bool{}
+	for i := range measured {
+		if err := s.travelRepo.Upsert(&measured[i]); err != nil {
+			s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to save travel segment")
+			return nil, fmt.Errorf("failed to save travel segment: %w", err)
+		}
+		kept[[2]int{measured[i].FromScheduleID, measured[i].ToScheduleID}] = true
+	}
+	for _, segment := range existing {
+		if kept[[2]int{segment.FromScheduleID, segment.ToScheduleID}] {
+			continue
+		}
+		if err := s.travelRepo.Delete(segment.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete travel segment: %w", err)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"date":         date,
+		"segments":     len(measured),
+	}).Info("Recalculated caregiver travel")
+	return s.GetTravelLog(caregiverID, date, date)
+}
+
+// measureDay returns the travel between the caregiver's consecutive visits
+// that clocked in on day in their client's timezone. Travel is only measured
+// when the previous visit clocked out earlier the same day and both ends were
+// recorded with a location.
+func (s *MileageService) measureDay(caregiverID int, day time.Time) ([]models.TravelSegment, error) {
+	date := day.Format(dateLayout)
+
+	// Widen the range so that days in every client timezone are covered
+	activeFrom := day.Add(-dayWindow)
+	activeTo := day.Add(24*time.Hour + dayWindow)
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		ActiveFrom:  &activeFrom,
+		ActiveTo:    &activeTo,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get schedules for travel")
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	var visits []clockedVisit
+	for i := range schedules {
+		schedule := &schedules[i]
+		visit, err := s.visitRepo.GetByScheduleAndCaregiver(schedule.ID, caregiverID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get visit: %w", err)
+		}
+		if visit == nil || visit.StartTime == nil {
+			continue
+		}
+		visits = append(visits, clockedVisit{
+			schedule: schedule,
+			visit:    visit,
+			loc:      clientLocation(schedule.Client, s.location),
+		})
+	}
+	sort.SliceStable(visits, func(i, j int) bool {
+		return visits[i].visit.StartTime.Before(*visits[j].visit.StartTime)
+	})
+
+	var segments []models.TravelSegment
+	for i := 1; i < len(visits); i++ {
+		prev, next := visits[i-1].visit, visits[i].visit
+		loc := visits[i].loc
+		if next.StartTime.In(loc).Format(dateLayout) != date {
+			continue
+		}
+		if prev.EndTime == nil || prev.EndTime.After(*next.StartTime) || prev.EndTime.In(loc).Format(dateLayout) != date {
+			continue
+		}
+		if !locationRecorded(prev.EndLatitude, prev.EndLongitude) || !locationRecorded(next.StartLatitude, next.StartLongitude) {
+			s.logger.WithFields(logrus.Fields{
+				"caregiver_id":     caregiverID,
+				"from_schedule_id": prev.ScheduleID,
+				"to_schedule_id":   next.ScheduleID,
+			}).Warn("Travel not measured, clock location missing")
+			continue
+		}
+
+		segments = append(segments, models.TravelSegment{
+			CaregiverID:    caregiverID,
+			Date:           date,
+			FromScheduleID: prev.ScheduleID,
+			ToScheduleID:   next.ScheduleID,
+			FromLatitude:   *prev.EndLatitude,
+			FromLongitude:  *prev.EndLongitude,
+			ToLatitude:     *next.StartLatitude,
+			ToLongitude:    *next.StartLongitude,
+			DepartedAt:     prev.EndTime.UTC(),
+			ArrivedAt:      next.StartTime.UTC(),
+			DistanceKm:     round2(distanceKm(*prev.EndLatitude, *prev.EndLongitude, *next.StartLatitude, *next.StartLongitude)),
+			TravelMinutes:  math.Round(next.StartTime.Sub(*prev.EndTime).Minutes()*10) / 10,
+		})
+	}
+
+	return segments, nil
+}
+
+// locationRecorded reports whether a clock-in or clock-out location was
+// recorded; a missing location may be stored as 0,0
+func locationRecorded(latitude, longitude *float64) bool {
+	return latitude != nil && longitude != nil && (*latitude != 0 || *longitude != 0)
+}
+
+// GetTravelLog returns a caregiver's recorded travel between the from and to
+// dates (YYYY-MM-DD, inclusive)
+func (s *MileageService) GetTravelLog(caregiverID int, from, to string) (*models.TravelLog, error) {
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"from":         from,
+		"to":           to,
+	}).Debug("Getting travel log")
+
+	if _, _, err := parseDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	segments, err := s.travelRepo.GetByCaregiver(caregiverID, from, to)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get travel segments")
+		return nil, fmt.Errorf("failed to get travel segments: %w", err)
+	}
+
+	log := &models.TravelLog{
+		CaregiverID: caregiverID,
+		From:        from,
+		To:          to,
+		Segments:    []models.TravelSegment{},
+	}
+	for i := range segments {
+		summarizeTravel(&segments[i])
+		log.DistanceKm += segments[i].DistanceKm
+		log.MileageKm += segments[i].MileageKm
+		log.TotalTravelMinutes += segments[i].TravelMinutes
+		log.Segments = append(log.Segments, segments[i])
+	}
+	log.DistanceKm = round2(log.DistanceKm)
+	log.MileageKm = round2(log.MileageKm)
+	log.TotalTravelMinutes = math.Round(log.TotalTravelMinutes*10) / 10
+
+	return log, nil
+}
+
+// DailyMileage implements MileageSource
+func (s *MileageService) DailyMileage(caregiverID int, from, to string) (map[string]float64, error) {
+	segments, err := s.travelRepo.GetByCaregiver(caregiverID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get travel segments: %w", err)
+	}
+
+	mileage := map[string]float64{}
+	for i := range segments {
+		summarizeTravel(&segments[i])
+		mileage[segments[i].Date] += segments[i].MileageKm
+	}
+	return mileage, nil
+}
+
+// AdjustTravelSegment overrides the measured distance of a travel segment,
+// e.g. for a detour the straight line between clock locations misses. A
+// reason is required; a nil adjusted_km restores the measured distance.
+func (s *MileageService) AdjustTravelSegment(id int, req *models.TravelAdjustmentRequest) (*models.TravelSegment, error) {
+	s.logger.WithFields(logrus.Fields{
+		"travel_segment_id": id,
+		"adjusted_km":       req.AdjustedKm,
+	}).Info("Adjusting travel segment")
+
+	reason := strings.TrimSpace(req.Reason)
+	if req.AdjustedKm != nil {
+		if *req.AdjustedKm < 0 {
+			return nil, fmt.Errorf("%w: adjusted_km must not be negative", ErrValidation)
+		}
+		if reason == "" {
+			return nil, fmt.Errorf("%w: a reason is required to adjust mileage", ErrValidation)
+		}
+	}
+
+	segment, err := s.travelRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get travel segment: %w", err)
+	}
+	if segment == nil {
+		return nil, fmt.Errorf("travel segment %d: %w", id, ErrNotFound)
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != segment.Version {
+		return nil, fmt.Errorf("travel segment %d is at version %d: %w", id, segment.Version, ErrVersionConflict)
+	}
+
+	segment.AdjustedKm = nil
+	segment.AdjustmentReason = ""
+	segment.AdjustedAt = nil
+	if req.AdjustedKm != nil {
+		km := round2(*req.AdjustedKm)
+		now := s.now().UTC()
+		segment.AdjustedKm = &km
+		segment.AdjustmentReason = reason
+		segment.AdjustedAt = &now
+	}
+
+	if err := s.travelRepo.Update(segment); err != nil {
+		s.logger.WithError(err).WithField("travel_segment_id", id).Error("Failed to adjust travel segment")
+		return nil, fmt.Errorf("failed to adjust travel segment: %w", err)
+	}
+
+	summarizeTravel(segment)
+	s.logger.WithField("travel_segment_id", id).Info("Successfully adjusted travel segment")
+	return segment, nil
+}
+
+// summarizeTravel sets the mileage paid for a segment: the adjusted distance
+// when a coordinator gave one, otherwise the measured distance
+func summarizeTravel(segment *models.TravelSegment) {
+	segment.MileageKm = segment.DistanceKm
+	if segment.AdjustedKm != nil {
+		segment.MileageKm = *segment.AdjustedKm
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTravelSegmentRepository is a mock implementation of TravelSegmentRepository
+type MockTravelSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockTravelSegmentRepository) GetByID(id int) (*models.TravelSegment, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TravelSegment), args.Error(1)
+}
+
+func (m *MockTravelSegmentRepository) GetByCaregiver(caregiverID int, from, to string) ([]models.TravelSegment, error) {
+	args := m.Called(caregiverID, from, to)
+	return args.Get(0).([]models.TravelSegment), args.Error(1)
+}
+
+func (m *MockTravelSegmentRepository) Upsert(segment *models.TravelSegment) error {
+	args := m.Called(segment)
+	return args.Error(0)
+}
+
+func (m *MockTravelSegmentRepository) Update(segment *models.TravelSegment) error {
+	args := m.Called(segment)
+	return args.Error(0)
+}
+
+func (m *MockTravelSegmentRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// mileageFunc adapts a function to MileageSource
+type mileageFunc func(caregiverID int, from, to string) (map[string]float64, error)
+
+func (f mileageFunc) DailyMileage(caregiverID int, from, to string) (map[string]float64, error) {
+	return f(caregiverID, from, to)
+}
+
+func clockedAt(scheduleID int, start, end time.Time, startLat, startLng, endLat, endLng float64) *models.Visit {
+	return &models.Visit{ScheduleID: scheduleID, CaregiverID: 1, StartTime: &start, EndTime: &end,
+		StartLatitude: &startLat, StartLongitude: &startLng, EndLatitude: &endLat, EndLongitude: &endLng}
+}
+
+func TestMileageService_RecalculateDay(t *testing.T) {
+	scheduleRepo := new(MockScheduleRepository)
+	visitRepo := new(MockVisitRepository)
+	travelRepo := new(MockTravelSegmentRepository)
+	service := NewMileageService(scheduleRepo, visitRepo, travelRepo, nil, logrus.New())
+
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	scheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return *f.CaregiverID == 1 && f.ActiveFrom.Before(day) && f.ActiveTo.After(day.AddDate(0, 0, 1))
+	})).Return([]models.Schedule{{ID: 3}, {ID: 1}, {ID: 2}, {ID: 4}}, nil, nil)
+
+	// 20 km east between the first two visits; the third clock-in has no location
+	visitRepo.On("GetByScheduleAndCaregiver", 1, 1).Return(clockedAt(1, at(9, 0), at(10, 0), 1, 0.01, 1, 0.01), nil)
+	visitRepo.On("GetByScheduleAndCaregiver", 2, 1).Return(clockedAt(2, at(10, 30), at(11, 30), 1, 0.19, 1, 0.19), nil)
+	noLocation := clockedAt(3, at(13, 0), at(14, 0), 0, 0, 1, 0.19)
+	visitRepo.On("GetByScheduleAndCaregiver", 3, 1).Return(noLocation, nil)
+	visitRepo.On("GetByScheduleAndCaregiver", 4, 1).Return(&models.Visit{ScheduleID: 4, CaregiverID: 1}, nil)
+
+	stale := models.TravelSegment{ID: 9, CaregiverID: 1, Date: "2025-01-06", FromScheduleID: 2, ToScheduleID: 3}
+	travelRepo.On("GetByCaregiver", 1, "2025-01-06", "2025-01-06").Return([]models.TravelSegment{stale}, nil).Once()
+	travelRepo.On("Upsert", mock.MatchedBy(func(s *models.TravelSegment) bool {
+		return s.FromScheduleID == 1 && s.ToScheduleID == 2 && s.Date == "2025-01-06" && s.TravelMinutes == 30 &&
+			s.DistanceKm > 19.9 && s.DistanceKm < 20.1
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.TravelSegment).ID = 10
+	}).Return(nil).Once()
+	travelRepo.On("Delete", 9).Return(nil).Once()
+
+	adjusted := 24.0
+	travelRepo.On("GetByCaregiver", 1, "2025-01-06", "2025-01-06").Return([]models.TravelSegment{
+		{ID: 10, CaregiverID: 1, Date: "2025-01-06", FromScheduleID: 1, ToScheduleID: 2, DistanceKm: 20.01, TravelMinutes: 30,
+			AdjustedKm: &adjusted, AdjustmentReason: "Detour"},
+	}, nil).Once()
+
+	log, err := service.RecalculateDay(1, "2025-01-06")
+	assert.NoError(t, err)
+	assert.Len(t, log.Segments, 1)
+	assert.Equal(t, 20.01, log.DistanceKm)
+	assert.Equal(t, 24.0, log.MileageKm)
+	assert.Equal(t, 30.0, log.TotalTravelMinutes)
+	travelRepo.AssertExpectations(t)
+
+	_, err = service.RecalculateDay(1, "06/01/2025")
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestMileageService_AdjustTravelSegment(t *testing.T) {
+	travelRepo := new(MockTravelSegmentRepository)
+	service := NewMileageService(new(MockScheduleRepository), new(MockVisitRepository), travelRepo, nil, logrus.New())
+	now := time.Date(2025, 1, 7, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	km := 12.345
+	_, err := service.AdjustTravelSegment(7, &models.TravelAdjustmentRequest{AdjustedKm: &km, Reason: "  "})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	travelRepo.On("GetByID", 7).Return(&models.TravelSegment{ID: 7, DistanceKm: 9.8, Version: 2}, nil)
+	stale := 1
+	_, err = service.AdjustTravelSegment(7, &models.TravelAdjustmentRequest{AdjustedKm: &km, Reason: "Detour", ExpectedVersion: &stale})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	travelRepo.On("Update", mock.MatchedBy(func(s *models.TravelSegment) bool {
+		return *s.AdjustedKm == 12.35 && s.AdjustmentReason == "Road closed" && s.AdjustedAt.Equal(now)
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.TravelSegment).Version++
+	}).Return(nil).Once()
+
+	segment, err := service.AdjustTravelSegment(7, &models.TravelAdjustmentRequest{AdjustedKm: &km, Reason: " Road closed "})
+	assert.NoError(t, err)
+	assert.Equal(t, 12.35, segment.MileageKm)
+	assert.Equal(t, 3, segment.Version)
+
+	travelRepo.On("GetByID", 8).Return(nil, nil)
+	_, err = service.AdjustTravelSegment(8, &models.TravelAdjustmentRequest{})
+	assert.ErrorIs(t, err, ErrNotFound)
+	travelRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_GetTimesheet_Mileage(t *testing.T) {
+	mockScheduleRepo := new(MockScheduleRepository)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        new(MockVisitRepository),
+		Tasks:         new(MockTaskRepository),
+		Segments:      new(MockScheduleSegmentRepository),
+		VisitSegments: new(MockVisitSegmentRepository),
+		Caregivers:    new(MockScheduleCaregiverRepository),
+	}, nil, logrus.New())
+	service.UseMileage(mileageFunc(func(caregiverID int, from, to string) (map[string]float64, error) {
+		return map[string]float64{"2025-01-06": 18.404, "2025-01-07": 6.2}, nil
+	}))
+	mockScheduleRepo.On("GetAll", mock.Anything).Return([]models.Schedule{}, nil, nil)
+
+	timesheet, err := service.GetTimesheet(1, "2025-01-06", "2025-01-07")
+	assert.NoError(t, err)
+	if assert.Len(t, timesheet.Days, 2) {
+		assert.Equal(t, 18.4, timesheet.Days[0].MileageKm)
+		assert.Equal(t, "2025-01-07", timesheet.Days[1].Date)
+	}
+	assert.Equal(t, 24.6, timesheet.MileageKm)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExportPayroll returns the hours and mileage of every caregiver on a
+// schedule's team between the from and to dates (YYYY-MM-DD, inclusive), one
+// row per caregiver and day they worked or drove
+func (s *ScheduleService) ExportPayroll(from, to string) (*models.PayrollExport, error) {
+	s.logger.WithFields(logrus.Fields{
+		"from": from,
+		"to":   to,
+	}).Info("Exporting payroll")
+
+	fromDate, toDate, err := parseDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Widen the range so that days in every client timezone are covered
+	activeFrom := fromDate.Add(-dayWindow)
+	activeTo := toDate.Add(24*time.Hour + dayWindow)
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		ActiveFrom: &activeFrom,
+		ActiveTo:   &activeTo,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get schedules for payroll")
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	seen := map[int]bool{}
+	var caregiverIDs []int
+	for _, schedule := range schedules {
+		team, err := s.caregiverRepo.GetByScheduleID(schedule.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schedule caregivers: %w", err)
+		}
+		for _, member := range team {
+			if !seen[member.CaregiverID] {
+				seen[member.CaregiverID] = true
+				caregiverIDs = append(caregiverIDs, member.CaregiverID)
+			}
+		}
+	}
+	sort.Ints(caregiverIDs)
+
+	export := &models.PayrollExport{
+		From: from,
+		To:   to,
+		Rows: []models.PayrollRow{},
+	}
+	for _, caregiverID := range caregiverIDs {
+		timesheet, err := s.GetTimesheet(caregiverID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range timesheet.Days {
+			export.Rows = append(export.Rows, models.PayrollRow{
+				CaregiverID: caregiverID,
+				Date:        day.Date,
+				TotalHours:  day.TotalHours,
+				SleepHours:  day.SleepHours,
+				BreakHours:  day.BreakHours,
+				WorkedHours: day.WorkedHours,
+				MileageKm:   day.MileageKm,
+				Amended:     day.Amended,
+			})
+		}
+		export.WorkedHours += timesheet.WorkedHours
+		export.MileageKm += timesheet.MileageKm
+	}
+	export.WorkedHours = round2(export.WorkedHours)
+	export.MileageKm = round2(export.MileageKm)
+
+	s.logger.WithFields(logrus.Fields{
+		"caregivers": len(caregiverIDs),
+		"rows":       len(export.Rows),
+	}).Info("Exported payroll")
+	return export, nil
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxFlexMinutes is the furthest a flexible visit may move from its booked start
+const maxFlexMinutes = 240
+
+// defaultSpeedKmh is the average driving speed used to turn straight-line
+// distances into travel times
+const defaultSpeedKmh = 40.0
+
+// maxRouteRounds bounds the local search of the route planner
+const maxRouteRounds = 50
+
+// DistanceMatrix estimates the travel between two client locations. The
+// straight-line estimate can be swapped for one backed by a routing service.
+type DistanceMatrix interface {
+	Travel(from, to *models.Client) (km float64, duration time.Duration, err error)
+}
+
+// StraightLineMatrix estimates travel from the great-circle distance driven
+// at a constant average speed
+type StraightLineMatrix struct {
+	SpeedKmh float64
+}
+
+// Travel implements DistanceMatrix
+func (m StraightLineMatrix) Travel(from, to *models.Client) (float64, time.Duration, error) {
+	speed := m.SpeedKmh
+	if speed <= 0 {
+		speed = defaultSpeedKmh
+	}
+
+	km := distanceKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	return km, time.Duration(km / speed * float64(time.Hour)).Round(time.Second), nil
+}
+
+// RouteService plans the order a caregiver drives their visits in
+type RouteService struct {
+	scheduleRepo repositories.ScheduleRepository
+	distances    DistanceMatrix
+	location     *time.Location // Agency default timezone for route dates
+	now          func() time.Time
+	logger       *logrus.Logger
+}
+
+// NewRouteService creates a new route service; distances defaults to
+// straight-line estimates and location to UTC when nil
+func NewRouteService(scheduleRepo repositories.ScheduleRepository, distances DistanceMatrix, location *time.Location, logger *logrus.Logger) *RouteService {
+	if distances == nil {
+		distances = StraightLineMatrix{SpeedKmh: defaultSpeedKmh}
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &RouteService{
+		scheduleRepo: scheduleRepo,
+		distances:    distances,
+		location:     location,
+		now:          time.Now,
+		logger:       logger,
+	}
+}
+
+// routeVisit is a visit to place on a route with the window its start may fall in
+type routeVisit struct {
+	schedule *models.Schedule
+	earliest time.Time
+	latest   time.Time
+	duration time.Duration
+}
+
+// routeLeg is the estimated travel between two visits, zero when either
+// client has no coordinates
+type routeLeg struct {
+	km       float64
+	duration time.Duration
+}
+
+// routeCost is what driving visits in an order costs. Lateness is minimised
+// before distance, so a feasible order always beats an infeasible one.
+type routeCost struct {
+	late time.Duration
+	km   float64
+}
+
+func (c routeCost) better(other routeCost) bool {
+	if c.late != other.late {
+		return c.late < other.late
+	}
+	return c.km < other.km-1e-9
+}
+
+// PlanRoute orders a caregiver's scheduled visits on a date (YYYY-MM-DD in the
+// agency timezone, today when empty) to minimise travel. Visits with
+// flex_minutes may start that much before or after their booked start and
+// are reordered; the others keep their booked start. Each visit begins as
+// early as its window and the travel from the previous visit allow.
+func (s *RouteService) PlanRoute(caregiverID int, date string) (*models.RoutePlan, error) {
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"date":         date,
+	}).Info("Planning caregiver route")
+
+	day := s.now().In(s.location)
+	if date != "" {
+		parsed, err := time.ParseInLocation(dateLayout, date, s.location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", ErrValidation, date)
+		}
+		day = parsed
+	}
+	dayStart, dayEnd := dayBounds(day, s.location)
+
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		From:        &dayStart,
+		To:          &dayEnd,
+		Statuses:    []string{models.ScheduleStatusScheduled, models.ScheduleStatusInProgress},
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get schedules for route")
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	plan := &models.RoutePlan{
+		CaregiverID: caregiverID,
+		Date:        dayStart.Format(dateLayout),
+		Feasible:    true,
+		Stops:       []models.RouteStop{},
+		Issues:      []string{},
+	}
+	if len(schedules) == 0 {
+		return plan, nil
+	}
+
+	visits := make([]routeVisit, len(schedules))
+	for i := range schedules {
+		schedule := &schedules[i]
+		flex := time.Duration(schedule.FlexMinutes) * time.Minute
+		if schedule.Status == models.ScheduleStatusInProgress {
+			flex = 0
+		}
+		visits[i] = routeVisit{
+			schedule: schedule,
+			earliest: schedule.StartTime.Add(-flex),
+			latest:   schedule.StartTime.Add(flex),
+			duration: schedule.EndTime.Sub(schedule.StartTime),
+		}
+		if !hasCoordinates(schedule.Client) {
+			plan.Issues = append(plan.Issues, fmt.Sprintf("%s has no coordinates, travel to and from schedule %d is not estimated",
+				clientName(schedule), schedule.ID))
+		}
+	}
+
+	legs, err := s.travelLegs(visits)
+	if err != nil {
+		return nil, err
+	}
+
+	booked := make([]int, len(visits))
+	for i := range booked {
+		booked[i] = i
+	}
+	sort.SliceStable(booked, func(a, b int) bool {
+		return visits[booked[a]].schedule.StartTime.Before(visits[booked[b]].schedule.StartTime)
+	})
+	bookedCost, _ := simulateRoute(visits, legs, booked)
+	plan.BookedDistanceKm = round2(bookedCost.km)
+
+	order, cost := improveRoute(visits, legs, booked)
+	_, plan.Stops = simulateRoute(visits, legs, order)
+
+	for _, stop := range plan.Stops {
+		plan.TotalDistanceKm += stop.TravelKm
+		plan.TotalTravelMinutes += stop.TravelMinutes
+		if stop.LateMinutes > 0 {
+			plan.Issues = append(plan.Issues, fmt.Sprintf("schedule %d cannot start by %s, %.0f minutes late",
+				stop.ScheduleID, stop.WindowEnd.In(s.location).Format("15:04"), stop.LateMinutes))
+		}
+	}
+	plan.Feasible = cost.late == 0
+	plan.TotalDistanceKm = round2(plan.TotalDistanceKm)
+	plan.TotalTravelMinutes = math.Round(plan.TotalTravelMinutes*10) / 10
+
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"date":         plan.Date,
+		"stops":        len(plan.Stops),
+		"feasible":     plan.Feasible,
+	}).Info("Planned caregiver route")
+	return plan, nil
+}
+
+// travelLegs estimates the travel between every pair of visits
+func (s *RouteService) travelLegs(visits []routeVisit) ([][]routeLeg, error) {
+	legs := make([][]routeLeg, len(visits))
+	for i := range visits {
+		legs[i] = make([]routeLeg, len(visits))
+		for j := range visits {
+			from, to := visits[i].schedule.Client, visits[j].schedule.Client
+			if i == j || !hasCoordinates(from) || !hasCoordinates(to) {
+				continue
+			}
+			km, duration, err := s.distances.Travel(from, to)
+			if err != nil {
+				return nil, fmt.Errorf("failed to estimate travel: %w", err)
+			}
+			legs[i][j] = routeLeg{km: km, duration: duration}
+		}
+	}
+	return legs, nil
+}
+
+// improveRoute searches for a cheaper order by moving one visit at a time to
+// another position, starting from the better of the booked order and the
+// order of the visits' latest starts
+func improveRoute(visits []routeVisit, legs [][]routeLeg, booked []int) ([]int, routeCost) {
+	order := append([]int(nil), booked...)
+	best, _ := simulateRoute(visits, legs, order)
+
+	byDeadline := append([]int(nil), booked...)
+	sort.SliceStable(byDeadline, func(a, b int) bool {
+		return visits[byDeadline[a]].latest.Before(visits[byDeadline[b]].latest)
+	})
+	if cost, _ := simulateRoute(visits, legs, byDeadline); cost.better(best) {
+		order, best = byDeadline, cost
+	}
+
+	for round := 0; round < maxRouteRounds; round++ {
+		improved := false
+		for i := range order {
+			for j := range order {
+				if i == j {
+					continue
+				}
+				candidate := relocate(order, i, j)
+				if cost, _ := simulateRoute(visits, legs, candidate); cost.better(best) {
+					order, best, improved = candidate, cost, true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return order, best
+}
+
+// relocate returns a copy of order with the element at from moved to position to
+func relocate(order []int, from, to int) []int {
+	moved := order[from]
+	result := make([]int, 0, len(order))
+	result = append(result, order[:from]...)
+	result = append(result, order[from+1:]...)
+	result = append(result[:to], append([]int{moved}, result[to:]...)...)
+	return result
+}
+
+// simulateRoute drives the visits in order, starting each as early as its
+// window and the travel from the previous visit allow
+func simulateRoute(visits []routeVisit, legs [][]routeLeg, order []int) (routeCost, []models.RouteStop) {
+	var cost routeCost
+	stops := make([]models.RouteStop, 0, len(order))
+
+	var depart time.Time
+	for k, index := range order {
+		visit := visits[index]
+		schedule := visit.schedule
+
+		stop := models.RouteStop{
+			Sequence:    k + 1,
+			ScheduleID:  schedule.ID,
+			ClientID:    schedule.ClientID,
+			ClientName:  clientName(schedule),
+			Flexible:    visit.latest.After(visit.earliest),
+			BookedStart: schedule.StartTime,
+			WindowStart: visit.earliest,
+			WindowEnd:   visit.latest,
+			Arrival:     visit.earliest,
+		}
+		if schedule.Client != nil {
+			stop.Latitude = schedule.Client.Latitude
+			stop.Longitude = schedule.Client.Longitude
+		}
+
+		if k > 0 {
+			leg := legs[order[k-1]][index]
+			stop.Arrival = depart.Add(leg.duration)
+			stop.TravelKm = round2(leg.km)
+			stop.TravelMinutes = math.Round(leg.duration.Minutes()*10) / 10
+			cost.km += leg.km
+		}
+
+		start := stop.Arrival
+		if start.Before(visit.earliest) {
+			start = visit.earliest
+			stop.WaitMinutes = math.Round(visit.earliest.Sub(stop.Arrival).Minutes()*10) / 10
+		}
+		if start.After(visit.latest) {
+			late := start.Sub(visit.latest)
+			cost.late += late
+			stop.LateMinutes = math.Round(late.Minutes()*10) / 10
+		}
+		stop.PlannedStart = start
+		stop.PlannedEnd = start.Add(visit.duration)
+		depart = stop.PlannedEnd
+
+		stops = append(stops, stop)
+	}
+
+	return cost, stops
+}
+
+// clientName describes a schedule's client for route issues
+func clientName(schedule *models.Schedule) string {
+	if schedule.Client != nil && schedule.Client.Name != "" {
+		return schedule.Client.Name
+	}
+	return fmt.Sprintf("client %d", schedule.ClientID)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fixedMatrix estimates every trip at the same distance and duration
+type fixedMatrix struct {
+	km       float64
+	duration time.Duration
+}
+
+func (m fixedMatrix) Travel(from, to *models.Client) (float64, time.Duration, error) {
+	return m.km, m.duration, nil
+}
+
+func routeVisitAt(id int, client *models.Client, start time.Time, flexMinutes int) models.Schedule {
+	return models.Schedule{ID: id, ClientID: client.ID, Client: client, CaregiverID: 1, Status: models.ScheduleStatusScheduled,
+		StartTime: start, EndTime: start.Add(time.Hour), FlexMinutes: flexMinutes}
+}
+
+func TestStraightLineMatrix_Travel(t *testing.T) {
+	from := &models.Client{Latitude: 1, Longitude: 0}
+	to := &models.Client{Latitude: 1, Longitude: 0.18}
+
+	km, duration, err := StraightLineMatrix{SpeedKmh: 40}.Travel(from, to)
+	assert.NoError(t, err)
+	assert.InDelta(t, 20.0, km, 0.1)
+	assert.InDelta(t, 30.0, duration.Minutes(), 0.2)
+}
+
+func TestRouteService_PlanRoute_ReordersFlexibleVisits(t *testing.T) {
+	scheduleRepo := new(MockScheduleRepository)
+	service := NewRouteService(scheduleRepo, nil, nil, logrus.New())
+
+	// Clients on an east-west line: B is 20 km from A, C halfway between
+	a := &models.Client{ID: 101, Name: "A", Latitude: 1, Longitude: 0.01}
+	b := &models.Client{ID: 102, Name: "B", Latitude: 1, Longitude: 0.19}
+	c := &models.Client{ID: 103, Name: "C", Latitude: 1, Longitude: 0.10}
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	scheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return *f.CaregiverID == 1 && f.From.Equal(day) && f.To.Equal(day.AddDate(0, 0, 1)) && len(f.Statuses) == 2
+	})).Return([]models.Schedule{
+		routeVisitAt(1, a, day.Add(9*time.Hour), 0),
+		routeVisitAt(2, b, day.Add(10*time.Hour+30*time.Minute), 120),
+		routeVisitAt(3, c, day.Add(12*time.Hour+30*time.Minute), 120),
+	}, nil, nil)
+
+	plan, err := service.PlanRoute(1, "2025-01-06")
+	assert.NoError(t, err)
+	assert.True(t, plan.Feasible)
+	assert.Empty(t, plan.Issues)
+	assert.InDelta(t, 30.0, plan.BookedDistanceKm, 0.2)
+	assert.InDelta(t, 20.0, plan.TotalDistanceKm, 0.2)
+	assert.InDelta(t, 30.0, plan.TotalTravelMinutes, 0.5)
+
+	// A, then C on the way out to B
+	if assert.Len(t, plan.Stops, 3) {
+		assert.Equal(t, []int{1, 3, 2}, []int{plan.Stops[0].ScheduleID, plan.Stops[1].ScheduleID, plan.Stops[2].ScheduleID})
+		assert.False(t, plan.Stops[0].Flexible)
+		assert.Equal(t, day.Add(10*time.Hour+15*time.Minute), plan.Stops[1].Arrival.Round(time.Minute))
+		assert.Equal(t, day.Add(10*time.Hour+30*time.Minute), plan.Stops[1].PlannedStart)
+		assert.InDelta(t, 15.0, plan.Stops[1].WaitMinutes, 0.5)
+		assert.Equal(t, 0.0, plan.Stops[2].LateMinutes)
+	}
+}
+
+func TestRouteService_PlanRoute_FlagsInfeasibleDay(t *testing.T) {
+	scheduleRepo := new(MockScheduleRepository)
+	service := NewRouteService(scheduleRepo, fixedMatrix{km: 20, duration: 30 * time.Minute}, nil, logrus.New())
+
+	a := &models.Client{ID: 101, Name: "A", Latitude: 1, Longitude: 1}
+	b := &models.Client{ID: 102, Name: "B", Latitude: 2, Longitude: 2}
+	nowhere := &models.Client{ID: 103, Name: "C"}
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	// Back-to-back fixed visits leave no time to drive between them
+	scheduleRepo.On("GetAll", mock.Anything).Return([]models.Schedule{
+		routeVisitAt(1, a, day.Add(9*time.Hour), 0),
+		routeVisitAt(2, b, day.Add(10*time.Hour), 0),
+		routeVisitAt(3, nowhere, day.Add(15*time.Hour), 0),
+	}, nil, nil)
+
+	plan, err := service.PlanRoute(1, "2025-01-06")
+	assert.NoError(t, err)
+	assert.False(t, plan.Feasible)
+	if assert.Len(t, plan.Stops, 3) {
+		assert.Equal(t, 30.0, plan.Stops[1].LateMinutes)
+		assert.Equal(t, 0.0, plan.Stops[2].TravelKm)
+	}
+	assert.Equal(t, []string{
+		"C has no coordinates, travel to and from schedule 3 is not estimated",
+		"schedule 2 cannot start by 10:00, 30 minutes late",
+	}, plan.Issues)
+}
+
+func TestRouteService_PlanRoute_Validation(t *testing.T) {
+	scheduleRepo := new(MockScheduleRepository)
+	service := NewRouteService(scheduleRepo, nil, nil, logrus.New())
+	service.now = func() time.Time { return time.Date(2025, 1, 6, 15, 0, 0, 0, time.UTC) }
+	scheduleRepo.On("GetAll", mock.Anything).Return([]models.Schedule{}, nil, nil)
+
+	_, err := service.PlanRoute(1, "06/01/2025")
+	assert.ErrorIs(t, err, ErrValidation)
+
+	plan, err := service.PlanRoute(1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-06", plan.Date)
+	assert.True(t, plan.Feasible)
+	assert.Empty(t, plan.Stops)
+}
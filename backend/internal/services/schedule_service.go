@@ -3,7 +3,10 @@ package services
 import (
 	"caregiver-shift-tracker/internal/models"
 	"caregiver-shift-tracker/internal/repositories"
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -11,43 +14,203 @@ import (
 
 // ScheduleService handles business logic for schedules
 type ScheduleService struct {
-	scheduleRepo repositories.ScheduleRepository
-	visitRepo    repositories.VisitRepository
-	taskRepo     repositories.TaskRepository
-	logger       *logrus.Logger
+	scheduleRepo     repositories.ScheduleRepository
+	visitRepo        repositories.VisitRepository
+	taskRepo         repositories.TaskRepository
+	segmentRepo      repositories.ScheduleSegmentRepository
+	visitSegmentRepo repositories.VisitSegmentRepository
+	caregiverRepo    repositories.ScheduleCaregiverRepository
+	states           *StateMachine[*models.Schedule]
+	validators       []AssignmentValidator
+	clockInHooks     []ClockInHook
+	clockEventHooks  []ClockEventHook
+	mileage          MileageSource
+	tracks           VisitTrackSource
+	risks            VisitRiskSource
+	verifier         VisitVerifier
+	planner          TaskPlanner
+	reasonCodes      ReasonCodeSource
+	location         *time.Location // Agency default for clients without a timezone
+	now              func() time.Time
+	logger           *logrus.Logger
 }
 
-// NewScheduleService creates a new schedule service
-func NewScheduleService(
-	scheduleRepo repositories.ScheduleRepository,
-	visitRepo repositories.VisitRepository,
-	taskRepo repositories.TaskRepository,
-	logger *logrus.Logger,
-) *ScheduleService {
-	return &ScheduleService{
-		scheduleRepo: scheduleRepo,
-		visitRepo:    visitRepo,
-		taskRepo:     taskRepo,
-		logger:       logger,
+// ScheduleRepositories are the stores the schedule service reads and writes
+type ScheduleRepositories struct {
+	Schedules     repositories.ScheduleRepository
+	Visits        repositories.VisitRepository
+	Tasks         repositories.TaskRepository
+	Segments      repositories.ScheduleSegmentRepository
+	VisitSegments repositories.VisitSegmentRepository
+	Caregivers    repositories.ScheduleCaregiverRepository
+}
+
+// NewScheduleService creates a new schedule service; location is the agency
+// default timezone, UTC when nil. Optional collaborators are added with the
+// On* and Use* methods.
+func NewScheduleService(repos ScheduleRepositories, location *time.Location, logger *logrus.Logger) *ScheduleService {
+	if location == nil {
+		location = time.UTC
+	}
+
+	s := &ScheduleService{
+		scheduleRepo:     repos.Schedules,
+		visitRepo:        repos.Visits,
+		taskRepo:         repos.Tasks,
+		segmentRepo:      repos.Segments,
+		visitSegmentRepo: repos.VisitSegments,
+		caregiverRepo:    repos.Caregivers,
+		states:           NewScheduleStateMachine(),
+		location:         location,
+		now:              time.Now,
+		logger:           logger,
+	}
+	s.states.now = func() time.Time { return s.now() }
+
+	s.states.OnTransition(func(schedule *models.Schedule, t Transition) error {
+		s.logger.WithFields(logrus.Fields{
+			"schedule_id": schedule.ID,
+			"event":       t.Event,
+			"from":        t.From,
+			"to":          t.To,
+		}).Info("Schedule status changed")
+		return nil
+	})
+
+	return s
+}
+
+// OnTransition registers a hook that runs after every schedule status change
+func (s *ScheduleService) OnTransition(hook TransitionHook[*models.Schedule]) {
+	s.states.OnTransition(hook)
+}
+
+// ClockInHook runs after a caregiver clocks in to a schedule. A failing hook
+// is logged and does not undo the clock-in.
+type ClockInHook func(scheduleID, caregiverID int) error
+
+// OnClockIn registers a hook that runs after every clock-in
+func (s *ScheduleService) OnClockIn(hook ClockInHook) {
+	s.clockInHooks = append(s.clockInHooks, hook)
+}
+
+// ClockEventHook runs after a caregiver clocks in to or out of a schedule
+// with the location and device details they reported. A failing hook is
+// logged and does not undo the clock event.
+type ClockEventHook func(event *models.ClockEvent) error
+
+// OnClockEvent registers a hook that runs after every clock-in and clock-out
+func (s *ScheduleService) OnClockEvent(hook ClockEventHook) {
+	s.clockEventHooks = append(s.clockEventHooks, hook)
+}
+
+// runClockEventHooks runs the clock event hooks, logging the ones that fail
+func (s *ScheduleService) runClockEventHooks(event *models.ClockEvent) {
+	for _, hook := range s.clockEventHooks {
+		if err := hook(event); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"schedule_id":  event.ScheduleID,
+				"caregiver_id": event.CaregiverID,
+				"event":        event.Event,
+			}).Warn("Clock event hook failed")
+		}
 	}
 }
 
-// ensureLocalTime ensures time values are in the local timezone
-func ensureLocalTime(t time.Time) time.Time {
-	// Use the system's local timezone
-	return t.In(time.Local)
+// VisitTrackSource reports the location track summaries of the visits to a schedule
+type VisitTrackSource interface {
+	GetVisitTracks(scheduleID int) ([]models.VisitTrack, error)
+}
+
+// UseVisitTracks sets where a schedule's visits take their location tracks from
+func (s *ScheduleService) UseVisitTracks(source VisitTrackSource) {
+	s.tracks = source
 }
 
-// GetAllSchedules retrieves all schedules with optional filtering
-func (s *ScheduleService) GetAllSchedules(filter *models.ScheduleFilter) ([]models.Schedule, error) {
+// VisitRiskSource reports the spoofing risk of the visits to a schedule
+type VisitRiskSource interface {
+	GetVisitRisks(scheduleID int) ([]models.VisitRisk, error)
+}
+
+// UseVisitRisks sets where a schedule's visits take their spoofing risk from
+func (s *ScheduleService) UseVisitRisks(source VisitRiskSource) {
+	s.risks = source
+}
+
+// VisitVerifier checks the verification code a caregiver read from the token
+// in the client's home
+type VisitVerifier interface {
+	VerifyCode(clientID int, code string, at time.Time) error
+}
+
+// UseVisitVerifier sets what checks the verification codes sent when clocking
+// in and out; without one only locations are accepted
+func (s *ScheduleService) UseVisitVerifier(verifier VisitVerifier) {
+	s.verifier = verifier
+}
+
+// TaskPlanner sets up the tasks of a new schedule from its client's care
+// plan, returning the plan version they come from
+type TaskPlanner interface {
+	PlanTasks(schedule *models.Schedule) (*models.CarePlan, []models.Task, error)
+}
+
+// UseTaskPlanner sets what sets up the tasks of new schedules; without one
+// schedules are created without tasks
+func (s *ScheduleService) UseTaskPlanner(planner TaskPlanner) {
+	s.planner = planner
+}
+
+// ReasonCodeSource provides the accepted reasons for not completing a
+// required task of a service
+type ReasonCodeSource interface {
+	ReasonCodes(serviceName string) ([]string, error)
+}
+
+// UseReasonCodes sets where the reason codes excusing required tasks at
+// clock-out come from; without one the default reason codes apply
+func (s *ScheduleService) UseReasonCodes(source ReasonCodeSource) {
+	s.reasonCodes = source
+}
+
+// validateFilter checks the enumerated values and the date range of a schedule filter
+func (s *ScheduleService) validateFilter(filter *models.ScheduleFilter) error {
+	for _, status := range filter.Statuses {
+		if !s.states.IsValidState(status) {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, status)
+		}
+	}
+
+	if filter.LocationStatus != nil && *filter.LocationStatus != "pending" && *filter.LocationStatus != "confirmed" {
+		return fmt.Errorf("%w: location_status must be 'pending' or 'confirmed'", ErrInvalidFilter)
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+
+	return nil
+}
+
+// GetAllSchedules retrieves a page of schedules with optional filtering and sorting
+func (s *ScheduleService) GetAllSchedules(filter *models.ScheduleFilter) ([]models.Schedule, *models.PageInfo, error) {
+	if filter == nil {
+		filter = &models.ScheduleFilter{}
+	}
+	if err := s.validateFilter(filter); err != nil {
+		return nil, nil, err
+	}
+	limit := filter.PageSize()
+	filter.Limit = &limit
+
 	s.logger.WithFields(logrus.Fields{
 		"filter": filter,
 	}).Debug("Getting all schedules")
 
-	schedules, err := s.scheduleRepo.GetAll(filter)
+	schedules, page, err := s.scheduleRepo.GetAll(filter)
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to get schedules")
-		return nil, fmt.Errorf("failed to get schedules: %w", err)
+		return nil, nil, fmt.Errorf("failed to get schedules: %w", err)
 	}
 
 	// Enrich schedules with visit and task data
@@ -58,7 +221,7 @@ func (s *ScheduleService) GetAllSchedules(filter *models.ScheduleFilter) ([]mode
 	}
 
 	s.logger.WithField("count", len(schedules)).Debug("Successfully retrieved schedules")
-	return schedules, nil
+	return schedules, page, nil
 }
 
 // GetScheduleByID retrieves a schedule by ID with full details
@@ -80,16 +243,94 @@ func (s *ScheduleService) GetScheduleByID(id int) (*models.Schedule, error) {
 	if err := s.enrichSchedule(schedule); err != nil {
 		s.logger.WithError(err).WithField("schedule_id", id).Warn("Failed to enrich schedule")
 	}
+	if err := s.attachTracks(schedule); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", id).Warn("Failed to get visit tracks")
+	}
+	if err := s.attachRisks(schedule); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", id).Warn("Failed to get visit risks")
+	}
 
 	s.logger.WithField("schedule_id", id).Debug("Successfully retrieved schedule")
 	return schedule, nil
 }
 
-// GetTodaySchedules retrieves today's schedules for a caregiver
+// CreateSchedule books a caregiver for a client visit. The caregiver leads the
+// schedule's team and must pass the assignment validators. The visit's tasks
+// are set up from the client's care plan.
+func (s *ScheduleService) CreateSchedule(req *models.ScheduleCreateRequest) (*models.Schedule, error) {
+	s.logger.WithFields(logrus.Fields{
+		"client_id":    req.ClientID,
+		"caregiver_id": req.CaregiverID,
+		"start_time":   req.StartTime,
+		"end_time":     req.EndTime,
+	}).Info("Creating schedule")
+
+	if req.ClientID <= 0 || req.CaregiverID <= 0 {
+		return nil, fmt.Errorf("%w: client_id and caregiver_id are required", ErrValidation)
+	}
+	if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		return nil, fmt.Errorf("%w: start_time and end_time are required", ErrValidation)
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrValidation)
+	}
+	if req.FlexMinutes < 0 || req.FlexMinutes > maxFlexMinutes {
+		return nil, fmt.Errorf("%w: flex_minutes must be between 0 and %d", ErrValidation, maxFlexMinutes)
+	}
+
+	schedule := &models.Schedule{
+		ClientID:    req.ClientID,
+		ServiceName: req.ServiceName,
+		CaregiverID: req.CaregiverID,
+		StartTime:   req.StartTime.UTC(),
+		EndTime:     req.EndTime.UTC(),
+		Status:      models.ScheduleStatusScheduled,
+		Notes:       req.Notes,
+		FlexMinutes: req.FlexMinutes,
+	}
+	if err := s.validateAssignment(schedule, req.CaregiverID); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", req.CaregiverID).Warn("Caregiver cannot be booked")
+		return nil, err
+	}
+
+	// The tasks come from the client's care plan and are created with the schedule
+	if s.planner != nil {
+		plan, tasks, err := s.planner.PlanTasks(schedule)
+		if err != nil {
+			s.logger.WithError(err).WithField("client_id", req.ClientID).Error("Failed to plan schedule tasks")
+			return nil, fmt.Errorf("failed to plan schedule tasks: %w", err)
+		}
+		if plan != nil {
+			schedule.CarePlanID = &plan.ID
+		}
+		schedule.Tasks = tasks
+	}
+
+	if err := s.scheduleRepo.Create(schedule); err != nil {
+		s.logger.WithError(err).Error("Failed to create schedule")
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	// Get the created schedule to return it as GET shows it, in the client's timezone
+	created, err := s.GetScheduleByID(schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, fmt.Errorf("schedule %d: %w", schedule.ID, ErrNotFound)
+	}
+
+	s.logger.WithField("schedule_id", schedule.ID).Info("Successfully created schedule")
+	return created, nil
+}
+
+// GetTodaySchedules retrieves today's schedules for a caregiver. "Today" is
+// the current calendar day in each schedule's client timezone; overnight and
+// multi-day shifts are included on every day they overlap.
 func (s *ScheduleService) GetTodaySchedules(caregiverID int) ([]models.Schedule, error) {
 	s.logger.WithField("caregiver_id", caregiverID).Debug("Getting today's schedules")
 
-	schedules, err := s.scheduleRepo.GetToday(caregiverID)
+	schedules, err := s.todaySchedules(caregiverID)
 	if err != nil {
 		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get today's schedules")
 		return nil, fmt.Errorf("failed to get today's schedules: %w", err)
@@ -102,9 +343,9 @@ func (s *ScheduleService) GetTodaySchedules(caregiverID int) ([]models.Schedule,
 		}
 	}
 
-	// Update schedule status based on visit status and time
+	// Mark schedules whose time window has passed without a clock-in as missed
 	for i := range schedules {
-		s.updateScheduleStatus(&schedules[i])
+		s.markMissed(&schedules[i])
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -115,7 +356,8 @@ func (s *ScheduleService) GetTodaySchedules(caregiverID int) ([]models.Schedule,
 	return schedules, nil
 }
 
-// GetScheduleStats retrieves schedule statistics for a caregiver
+// GetScheduleStats retrieves schedule statistics for a caregiver. Upcoming and
+// completed counts cover today in each schedule's client timezone.
 func (s *ScheduleService) GetScheduleStats(caregiverID int) (*models.ScheduleStats, error) {
 	s.logger.WithField("caregiver_id", caregiverID).Debug("Getting schedule stats")
 
@@ -125,6 +367,21 @@ func (s *ScheduleService) GetScheduleStats(caregiverID int) (*models.ScheduleSta
 		return nil, fmt.Errorf("failed to get schedule stats: %w", err)
 	}
 
+	today, err := s.todaySchedules(caregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get today's schedules for stats")
+		return nil, fmt.Errorf("failed to get schedule stats: %w", err)
+	}
+
+	for _, schedule := range today {
+		switch schedule.Status {
+		case models.ScheduleStatusScheduled:
+			stats.Upcoming++
+		case models.ScheduleStatusCompleted:
+			stats.Completed++
+		}
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"caregiver_id": caregiverID,
 		"stats":        stats,
@@ -133,12 +390,40 @@ func (s *ScheduleService) GetScheduleStats(caregiverID int) (*models.ScheduleSta
 	return stats, nil
 }
 
-// StartVisit starts a visit for a schedule
+// todaySchedules returns a caregiver's schedules that overlap the current day
+// in their client's timezone
+func (s *ScheduleService) todaySchedules(caregiverID int) ([]models.Schedule, error) {
+	now := s.now()
+	from, to := now.Add(-dayWindow), now.Add(dayWindow)
+
+	candidates, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		ActiveFrom:  &from,
+		ActiveTo:    &to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []models.Schedule{}
+	for _, schedule := range candidates {
+		dayStart, dayEnd := dayBounds(now, clientLocation(schedule.Client, s.location))
+		if schedule.StartTime.Before(dayEnd) && schedule.EndTime.After(dayStart) {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules, nil
+}
+
+// StartVisit clocks a caregiver in to a schedule. The first caregiver of the
+// team to clock in starts the schedule; the others join it.
 func (s *ScheduleService) StartVisit(scheduleID int, req *models.VisitStartRequest) error {
 	s.logger.WithFields(logrus.Fields{
-		"schedule_id": scheduleID,
-		"latitude":    req.Latitude,
-		"longitude":   req.Longitude,
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+		"latitude":     req.Latitude,
+		"longitude":    req.Longitude,
 	}).Info("Starting visit")
 
 	// Validate schedule exists
@@ -153,35 +438,92 @@ func (s *ScheduleService) StartVisit(scheduleID int, req *models.VisitStartReque
 		return fmt.Errorf("schedule not found")
 	}
 
-	// Check if visit can be started (not too early, not already completed)
-	now := time.Now()
-	if now.Before(schedule.StartTime.Add(-30 * time.Minute)) {
-		return fmt.Errorf("cannot start visit more than 30 minutes before scheduled time")
+	caregiverID, err := s.resolveCaregiver(schedule, req.CaregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Caregiver cannot clock in")
+		return err
+	}
+
+	method, err := s.verifyPresence(schedule, req.Latitude, req.Longitude, req.VerificationCode, req.CallerClientID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Clock-in could not be verified")
+		return err
+	}
+
+	joining := schedule.Status == models.ScheduleStatusInProgress
+	var transition Transition
+	if joining {
+		// A team member joins a schedule another caregiver already started
+		visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+		if err != nil {
+			return fmt.Errorf("failed to get visit: %w", err)
+		}
+		if visit != nil && visit.Status != models.VisitStatusNotStarted {
+			return fmt.Errorf("%w: caregiver %d has already clocked in", ErrInvalidTransition, caregiverID)
+		}
+	} else {
+		// Check if visit can be started (not too early, not already started or finished)
+		transition, err = s.states.Fire(schedule, ScheduleEventStart)
+		if err != nil {
+			s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Visit cannot be started")
+			return err
+		}
 	}
 
 	// Start the visit
-	if err := s.visitRepo.StartVisit(scheduleID, req.Latitude, req.Longitude); err != nil {
+	if err := s.visitRepo.StartVisit(scheduleID, caregiverID, req.Latitude, req.Longitude, method); err != nil {
 		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to start visit")
 		return fmt.Errorf("failed to start visit: %w", err)
 	}
 
+	// Work is recorded in segments so that breaks can be taken later
+	if err := s.startWorkSegment(scheduleID, caregiverID); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to start visit segment")
+		return fmt.Errorf("failed to start visit segment: %w", err)
+	}
+
 	// Update schedule status
-	schedule.Status = "in_progress"
-	if err := s.scheduleRepo.Update(schedule); err != nil {
-		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to update schedule status")
-		return fmt.Errorf("failed to update schedule status: %w", err)
+	if !joining {
+		if err := s.applyTransition(schedule, transition); err != nil {
+			return err
+		}
+	}
+
+	for _, hook := range s.clockInHooks {
+		if err := hook(scheduleID, caregiverID); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"schedule_id":  scheduleID,
+				"caregiver_id": caregiverID,
+			}).Warn("Clock-in hook failed")
+		}
 	}
+	s.runClockEventHooks(&models.ClockEvent{
+		ScheduleID:   scheduleID,
+		CaregiverID:  caregiverID,
+		ClientID:     schedule.ClientID,
+		Event:        models.ClockEventIn,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Accuracy:     req.Accuracy,
+		MockLocation: req.MockLocation,
+		RecordedAt:   s.now().UTC(),
+
+		VerificationMethod: method,
+	})
 
 	s.logger.WithField("schedule_id", scheduleID).Info("Successfully started visit")
 	return nil
 }
 
-// EndVisit ends a visit for a schedule
+// EndVisit clocks a caregiver out of a schedule once their required tasks are
+// done or excused. The schedule completes once no caregiver of the team is
+// still clocked in.
 func (s *ScheduleService) EndVisit(scheduleID int, req *models.VisitEndRequest) error {
 	s.logger.WithFields(logrus.Fields{
-		"schedule_id": scheduleID,
-		"latitude":    req.Latitude,
-		"longitude":   req.Longitude,
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+		"latitude":     req.Latitude,
+		"longitude":    req.Longitude,
 	}).Info("Ending visit")
 
 	// Validate schedule exists
@@ -196,25 +538,100 @@ func (s *ScheduleService) EndVisit(scheduleID int, req *models.VisitEndRequest)
 		return fmt.Errorf("schedule not found")
 	}
 
+	// Check if visit can be ended
+	transition, err := s.states.Fire(schedule, ScheduleEventEnd)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Visit cannot be ended")
+		return err
+	}
+
+	caregiverID, err := s.resolveCaregiver(schedule, req.CaregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Caregiver cannot clock out")
+		return err
+	}
+
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.Status != models.VisitStatusInProgress {
+		return fmt.Errorf("%w: caregiver %d is not clocked in", ErrInvalidTransition, caregiverID)
+	}
+
+	if err := s.checkRequiredTasks(schedule, caregiverID); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Required tasks are not done")
+		return err
+	}
+
+	method, err := s.verifyPresence(schedule, req.Latitude, req.Longitude, req.VerificationCode, req.CallerClientID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Clock-out could not be verified")
+		return err
+	}
+
 	// End the visit
-	if err := s.visitRepo.EndVisit(scheduleID, req.Latitude, req.Longitude, req.Notes); err != nil {
+	if err := s.visitRepo.EndVisit(scheduleID, caregiverID, req.Latitude, req.Longitude, method, req.Notes); err != nil {
 		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to end visit")
 		return fmt.Errorf("failed to end visit: %w", err)
 	}
 
+	// Close the running work segment or break at the clock-out
+	visit, err = s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	clockedOut := s.now().UTC()
+	if visit != nil && visit.EndTime != nil {
+		if err := s.visitSegmentRepo.Close(scheduleID, caregiverID, *visit.EndTime, req.Latitude, req.Longitude); err != nil {
+			s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to close visit segment")
+			return fmt.Errorf("failed to close visit segment: %w", err)
+		}
+		clockedOut = *visit.EndTime
+	}
+	s.runClockEventHooks(&models.ClockEvent{
+		ScheduleID:   scheduleID,
+		CaregiverID:  caregiverID,
+		ClientID:     schedule.ClientID,
+		Event:        models.ClockEventOut,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Accuracy:     req.Accuracy,
+		MockLocation: req.MockLocation,
+		RecordedAt:   clockedOut,
+
+		VerificationMethod: method,
+	})
+
+	// The schedule stays in progress while other team members are clocked in
+	visits, err := s.visitRepo.GetAllByScheduleID(scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get visits: %w", err)
+	}
+	for _, v := range visits {
+		if v.Status == models.VisitStatusInProgress {
+			s.logger.WithFields(logrus.Fields{
+				"schedule_id":  scheduleID,
+				"caregiver_id": caregiverID,
+			}).Info("Caregiver clocked out, team visit continues")
+			return nil
+		}
+	}
+
 	// Update schedule status
-	schedule.Status = "completed"
-	if err := s.scheduleRepo.Update(schedule); err != nil {
-		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to update schedule status")
-		return fmt.Errorf("failed to update schedule status: %w", err)
+	if err := s.applyTransition(schedule, transition); err != nil {
+		return err
 	}
 
 	s.logger.WithField("schedule_id", scheduleID).Info("Successfully ended visit")
 	return nil
 }
 
-// CancelVisit cancels an in-progress visit for a schedule
-func (s *ScheduleService) CancelVisit(scheduleID int) error {
+// CancelVisit cancels a visit for a schedule. A schedule that has not
+// started is cancelled altogether. On one in progress only the caregiver's own
+// clock-in is undone; the schedule goes back to scheduled once nobody of the
+// team is left clocked in, or ends when the others already clocked out.
+func (s *ScheduleService) CancelVisit(scheduleID int, req *models.VisitCancelRequest) error {
 	s.logger.WithField("schedule_id", scheduleID).Info("Cancelling visit")
 
 	// Validate schedule exists
@@ -229,42 +646,288 @@ func (s *ScheduleService) CancelVisit(scheduleID int) error {
 		return fmt.Errorf("schedule not found")
 	}
 
-	// Check if visit can be cancelled
-	// Allow cancellation for scheduled visits (not started yet) and in_progress visits
-	if schedule.Status != "scheduled" && schedule.Status != "in_progress" {
-		s.logger.WithField("schedule_id", scheduleID).Warn("Visit cannot be cancelled in this status")
-		return fmt.Errorf("visit cannot be cancelled in status: %s", schedule.Status)
+	if schedule.Status != models.ScheduleStatusInProgress {
+		transition, err := s.states.Fire(schedule, ScheduleEventCancel)
+		if err != nil {
+			s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Visit cannot be cancelled in this status")
+			return err
+		}
+		if err := s.applyTransition(schedule, transition); err != nil {
+			return err
+		}
+
+		s.logger.WithField("schedule_id", scheduleID).Info("Successfully cancelled visit")
+		return nil
+	}
+
+	var requested *int
+	if req != nil {
+		requested = req.CaregiverID
+	}
+	caregiverID, err := s.resolveCaregiver(schedule, requested)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Caregiver cannot abort visit")
+		return err
 	}
 
-	// For "scheduled" status, we don't need to update the visit table since it hasn't started
-	// For "in_progress" status, we need to cancel the visit and update its status
-	if schedule.Status == "in_progress" {
-		// Cancel the visit (reset to not_started status)
-		if err := s.visitRepo.CancelVisit(scheduleID); err != nil {
-			s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to cancel visit")
-			return fmt.Errorf("failed to cancel visit: %w", err)
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.Status != models.VisitStatusInProgress {
+		return fmt.Errorf("%w: caregiver %d is not clocked in", ErrInvalidTransition, caregiverID)
+	}
+
+	// Reset the caregiver's visit to not_started status
+	if err := s.visitRepo.CancelVisit(scheduleID, caregiverID); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to cancel visit")
+		return fmt.Errorf("failed to cancel visit: %w", err)
+	}
+	if err := s.visitSegmentRepo.DeleteByScheduleAndCaregiver(scheduleID, caregiverID); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to delete visit segments")
+		return fmt.Errorf("failed to delete visit segments: %w", err)
+	}
+
+	// The schedule stays in progress while other team members are clocked in
+	visits, err := s.visitRepo.GetAllByScheduleID(scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get visits: %w", err)
+	}
+	event := ScheduleEventAbort
+	for _, v := range visits {
+		switch v.Status {
+		case models.VisitStatusInProgress:
+			s.logger.WithFields(logrus.Fields{
+				"schedule_id":  scheduleID,
+				"caregiver_id": caregiverID,
+			}).Info("Caregiver visit cancelled, team visit continues")
+			return nil
+		case models.VisitStatusCompleted:
+			event = ScheduleEventEnd
 		}
 	}
 
-	// Update schedule status back to scheduled (or keep as scheduled if it was scheduled)
-	schedule.Status = "scheduled"
-	if err := s.scheduleRepo.Update(schedule); err != nil {
-		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to update schedule status")
-		return fmt.Errorf("failed to update schedule status: %w", err)
+	transition, err := s.states.Fire(schedule, event)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Warn("Visit cannot be cancelled in this status")
+		return err
+	}
+
+	// Update schedule status
+	if err := s.applyTransition(schedule, transition); err != nil {
+		return err
 	}
 
 	s.logger.WithField("schedule_id", scheduleID).Info("Successfully cancelled visit")
 	return nil
 }
 
-// enrichSchedule adds visit and task data to a schedule
+// GetScheduleSegments lists the sleep and break segments of a schedule
+func (s *ScheduleService) GetScheduleSegments(scheduleID int) ([]models.ScheduleSegment, error) {
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	segments, err := s.segmentRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to get segments")
+		return nil, fmt.Errorf("failed to get segments: %w", err)
+	}
+
+	loc := clientLocation(schedule.Client, s.location)
+	for i := range segments {
+		segments[i].StartTime = segments[i].StartTime.In(loc)
+		segments[i].EndTime = segments[i].EndTime.In(loc)
+	}
+
+	return segments, nil
+}
+
+// AddScheduleSegment adds a sleep or break segment to a schedule. Segments
+// must lie within the scheduled shift and must not overlap each other.
+func (s *ScheduleService) AddScheduleSegment(scheduleID int, req *models.ScheduleSegmentRequest) (*models.ScheduleSegment, error) {
+	s.logger.WithField("schedule_id", scheduleID).Debug("Adding schedule segment")
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	if req.Type != models.SegmentTypeSleep && req.Type != models.SegmentTypeBreak {
+		return nil, fmt.Errorf("%w: type must be 'sleep' or 'break'", ErrValidation)
+	}
+	if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		return nil, fmt.Errorf("%w: start_time and end_time are required", ErrValidation)
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrValidation)
+	}
+	if req.StartTime.Before(schedule.StartTime) || req.EndTime.After(schedule.EndTime) {
+		return nil, fmt.Errorf("%w: segment must lie within the scheduled shift", ErrValidation)
+	}
+
+	existing, err := s.segmentRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segments: %w", err)
+	}
+	for _, other := range existing {
+		if overlap(req.StartTime, req.EndTime, other.StartTime, other.EndTime) > 0 {
+			return nil, fmt.Errorf("%w: segment overlaps %s segment %d", ErrValidation, other.Type, other.ID)
+		}
+	}
+
+	segment := &models.ScheduleSegment{
+		ScheduleID: scheduleID,
+		Type:       req.Type,
+		StartTime:  req.StartTime.UTC(),
+		EndTime:    req.EndTime.UTC(),
+		Notes:      req.Notes,
+	}
+	if err := s.segmentRepo.Create(segment); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to create segment")
+		return nil, fmt.Errorf("failed to create segment: %w", err)
+	}
+
+	loc := clientLocation(schedule.Client, s.location)
+	segment.StartTime = segment.StartTime.In(loc)
+	segment.EndTime = segment.EndTime.In(loc)
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id": scheduleID,
+		"segment_id":  segment.ID,
+		"type":        segment.Type,
+	}).Info("Successfully added schedule segment")
+	return segment, nil
+}
+
+// DeleteScheduleSegment removes a segment from a schedule
+func (s *ScheduleService) DeleteScheduleSegment(scheduleID, segmentID int) error {
+	segment, err := s.segmentRepo.GetByID(segmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get segment: %w", err)
+	}
+	if segment == nil || segment.ScheduleID != scheduleID {
+		return fmt.Errorf("segment %d of schedule %d: %w", segmentID, scheduleID, ErrNotFound)
+	}
+
+	if err := s.segmentRepo.Delete(segmentID); err != nil {
+		s.logger.WithError(err).WithField("segment_id", segmentID).Error("Failed to delete segment")
+		return fmt.Errorf("failed to delete segment: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id": scheduleID,
+		"segment_id":  segmentID,
+	}).Info("Successfully deleted schedule segment")
+	return nil
+}
+
+// attachTracks adds each caregiver's location track to their visit
+func (s *ScheduleService) attachTracks(schedule *models.Schedule) error {
+	if s.tracks == nil || len(schedule.Visits) == 0 {
+		return nil
+	}
+
+	tracks, err := s.tracks.GetVisitTracks(schedule.ID)
+	if err != nil {
+		return err
+	}
+	for i := range tracks {
+		if visit := caregiverVisit(schedule, tracks[i].CaregiverID); visit != nil {
+			visit.Track = &tracks[i]
+		}
+	}
+	return nil
+}
+
+// verifyPresence checks how a caregiver clocking in or out showed they are at
+// the client: by a location, by the code on the client's verification token,
+// by both, or by calling from the client's phone. It returns the verification
+// method to record on the visit.
+func (s *ScheduleService) verifyPresence(schedule *models.Schedule, latitude, longitude float64, code string, callerClientID *int) (string, error) {
+	if callerClientID != nil {
+		if *callerClientID != schedule.ClientID {
+			return "", fmt.Errorf("%w: the call did not come from the client's phone", ErrVerificationFailed)
+		}
+		return models.VerificationMethodTelephony, nil
+	}
+
+	hasLocation := latitude != 0 || longitude != 0
+	if strings.TrimSpace(code) == "" {
+		if !hasLocation {
+			return "", fmt.Errorf("%w: a location or a verification code is required", ErrVerificationFailed)
+		}
+		return models.VerificationMethodGPS, nil
+	}
+
+	if s.verifier == nil {
+		return "", fmt.Errorf("%w: verification codes are not accepted", ErrVerificationFailed)
+	}
+	if err := s.verifier.VerifyCode(schedule.ClientID, code, s.now()); err != nil {
+		return "", err
+	}
+
+	if hasLocation {
+		return models.VerificationMethodGPSAndToken, nil
+	}
+	return models.VerificationMethodToken, nil
+}
+
+// attachRisks adds each caregiver's spoofing risk to their visit
+func (s *ScheduleService) attachRisks(schedule *models.Schedule) error {
+	if s.risks == nil || len(schedule.Visits) == 0 {
+		return nil
+	}
+
+	risks, err := s.risks.GetVisitRisks(schedule.ID)
+	if err != nil {
+		return err
+	}
+	for i := range risks {
+		if visit := caregiverVisit(schedule, risks[i].CaregiverID); visit != nil {
+			visit.Risk = &risks[i]
+		}
+	}
+	return nil
+}
+
+// enrichSchedule adds visit, task and segment data to a schedule and shows its times in the client's timezone
 func (s *ScheduleService) enrichSchedule(schedule *models.Schedule) error {
-	// Get visit data
-	visit, err := s.visitRepo.GetByScheduleID(schedule.ID)
+	// Times are stored in UTC and shown in the client's timezone
+	loc := clientLocation(schedule.Client, s.location)
+	schedule.Timezone = loc.String()
+	schedule.StartTime = schedule.StartTime.In(loc)
+	schedule.EndTime = schedule.EndTime.In(loc)
+
+	// Get the team and the visit of each caregiver on it
+	caregivers, err := s.caregiverRepo.GetByScheduleID(schedule.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get visit: %w", err)
+		return fmt.Errorf("failed to get schedule caregivers: %w", err)
+	}
+	schedule.Caregivers = caregivers
+
+	visits, err := s.visitRepo.GetAllByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get visits: %w", err)
+	}
+	if len(visits) > 0 {
+		segments, err := s.visitSegmentRepo.GetByScheduleID(schedule.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get visit segments: %w", err)
+		}
+		for i := range visits {
+			summarizeVisit(&visits[i], caregiverSegments(segments, visits[i].CaregiverID), s.now())
+		}
 	}
-	schedule.Visit = visit
+	schedule.Visits = visits
+	schedule.Visit = leadVisit(schedule)
 
 	// Get task data
 	tasks, err := s.taskRepo.GetByScheduleID(schedule.ID)
@@ -273,31 +936,178 @@ func (s *ScheduleService) enrichSchedule(schedule *models.Schedule) error {
 	}
 	schedule.Tasks = tasks
 
+	// Get sleep and break segments
+	segments, err := s.segmentRepo.GetByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get segments: %w", err)
+	}
+	for i := range segments {
+		segments[i].StartTime = segments[i].StartTime.In(loc)
+		segments[i].EndTime = segments[i].EndTime.In(loc)
+	}
+	schedule.Segments = segments
+
 	return nil
 }
 
-// updateScheduleStatus updates schedule status based on current time and visit status
-func (s *ScheduleService) updateScheduleStatus(schedule *models.Schedule) {
-	now := time.Now()
+// applyTransition persists a schedule status change and runs the transition hooks
+func (s *ScheduleService) applyTransition(schedule *models.Schedule, transition Transition) error {
+	schedule.Status = transition.To
+	if err := s.scheduleRepo.Update(schedule); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", schedule.ID).Error("Failed to update schedule status")
+		return fmt.Errorf("failed to update schedule status: %w", err)
+	}
 
-	// If visit is completed, schedule is completed
-	if schedule.Visit != nil && schedule.Visit.Status == "completed" {
-		schedule.Status = "completed"
-		return
+	if err := s.states.Complete(schedule, transition); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", schedule.ID).Warn("Schedule transition hook failed")
 	}
 
-	// If visit is in progress, schedule is in progress
-	if schedule.Visit != nil && schedule.Visit.Status == "in_progress" {
-		schedule.Status = "in_progress"
+	return nil
+}
+
+// markMissed shows a schedule as missed once its end time has passed without
+// any caregiver of the team clocking in. The status is only set on the returned
+// schedule so reading never writes; MarkMissedSchedules stores it.
+func (s *ScheduleService) markMissed(schedule *models.Schedule) {
+	if !s.states.Can(schedule.Status, ScheduleEventMiss) || anyVisitStarted(schedule.Visits) {
 		return
 	}
 
-	// If current time is past end time and no visit started, mark as missed
-	if now.After(schedule.EndTime) && (schedule.Visit == nil || schedule.Visit.StartTime == nil) {
-		schedule.Status = "missed"
+	transition, err := s.states.Fire(schedule, ScheduleEventMiss)
+	if err != nil {
+		// Schedule has not ended yet
 		return
 	}
 
-	// Otherwise, keep as scheduled
-	schedule.Status = "scheduled"
+	schedule.Status = transition.To
+}
+
+// MarkMissedSchedules stores the missed status of every scheduled schedule
+// whose end time has passed without any caregiver of the team clocking in,
+// and returns how many were marked
+func (s *ScheduleService) MarkMissedSchedules() (int, error) {
+	now := s.now()
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		To:       &now,
+		Statuses: []string{models.ScheduleStatusScheduled},
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get schedules to mark missed")
+		return 0, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	marked := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		if !schedule.EndTime.Before(now) {
+			continue
+		}
+
+		visits, err := s.visitRepo.GetAllByScheduleID(schedule.ID)
+		if err != nil {
+			s.logger.WithError(err).WithField("schedule_id", schedule.ID).Warn("Failed to get visits of ended schedule")
+			continue
+		}
+		if anyVisitStarted(visits) {
+			continue
+		}
+
+		transition, err := s.states.Fire(schedule, ScheduleEventMiss)
+		if err != nil {
+			continue
+		}
+		// A schedule changed meanwhile fails its version check and is retried
+		// on the next run
+		if err := s.applyTransition(schedule, transition); err != nil {
+			continue
+		}
+		marked++
+	}
+
+	s.logger.WithField("count", marked).Debug("Marked missed schedules")
+	return marked, nil
+}
+
+// RunMissedSweep periodically marks ended schedules nobody clocked in to as
+// missed until the context is cancelled
+func (s *ScheduleService) RunMissedSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.MarkMissedSchedules()
+		}
+	}
+}
+
+// anyVisitStarted reports whether any caregiver clocked in to one of the visits
+func anyVisitStarted(visits []models.Visit) bool {
+	for _, visit := range visits {
+		if visit.StartTime != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequiredTasks returns an IncompleteTasksError listing the required
+// tasks a caregiver cannot clock out with: those assigned to them, and the
+// unassigned ones when nobody else on the team is still clocked in, that are
+// neither completed nor not completed with one of the service's reason codes
+func (s *ScheduleService) checkRequiredTasks(schedule *models.Schedule, caregiverID int) error {
+	tasks, err := s.taskRepo.GetByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	var open []models.Task
+	for _, task := range tasks {
+		if task.Required && task.Status != "completed" {
+			open = append(open, task)
+		}
+	}
+	if len(open) == 0 {
+		return nil
+	}
+
+	codes := models.DefaultTaskReasonCodes
+	if s.reasonCodes != nil {
+		if codes, err = s.reasonCodes.ReasonCodes(schedule.ServiceName); err != nil {
+			return fmt.Errorf("failed to get reason codes: %w", err)
+		}
+	}
+
+	// Unassigned tasks can still be done by a team member who stays
+	visits, err := s.visitRepo.GetAllByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get visits: %w", err)
+	}
+	lastOut := true
+	for _, v := range visits {
+		if v.CaregiverID != caregiverID && v.Status == models.VisitStatusInProgress {
+			lastOut = false
+		}
+	}
+
+	var taskIDs []int
+	for _, task := range open {
+		if task.CaregiverID != nil && *task.CaregiverID != caregiverID {
+			continue
+		}
+		if task.CaregiverID == nil && !lastOut {
+			continue
+		}
+		if task.Status == "not_completed" && slices.Contains(codes, normalizeSkill(task.Reason)) {
+			continue
+		}
+		taskIDs = append(taskIDs, task.ID)
+	}
+	if len(taskIDs) > 0 {
+		return &IncompleteTasksError{TaskIDs: taskIDs}
+	}
+	return nil
 }
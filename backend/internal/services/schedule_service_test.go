@@ -15,9 +15,12 @@ type MockScheduleRepository struct {
 	mock.Mock
 }
 
-func (m *MockScheduleRepository) GetAll(filter *models.ScheduleFilter) ([]models.Schedule, error) {
+func (m *MockScheduleRepository) GetAll(filter *models.ScheduleFilter) ([]models.Schedule, *models.PageInfo, error) {
 	args := m.Called(filter)
-	return args.Get(0).([]models.Schedule), args.Error(1)
+	if args.Get(1) == nil {
+		return args.Get(0).([]models.Schedule), nil, args.Error(2)
+	}
+	return args.Get(0).([]models.Schedule), args.Get(1).(*models.PageInfo), args.Error(2)
 }
 
 func (m *MockScheduleRepository) GetByID(id int) (*models.Schedule, error) {
@@ -28,11 +31,6 @@ func (m *MockScheduleRepository) GetByID(id int) (*models.Schedule, error) {
 	return args.Get(0).(*models.Schedule), args.Error(1)
 }
 
-func (m *MockScheduleRepository) GetToday(caregiverID int) ([]models.Schedule, error) {
-	args := m.Called(caregiverID)
-	return args.Get(0).([]models.Schedule), args.Error(1)
-}
-
 func (m *MockScheduleRepository) GetStats(caregiverID int) (*models.ScheduleStats, error) {
 	args := m.Called(caregiverID)
 	if args.Get(0) == nil {
@@ -79,18 +77,36 @@ func (m *MockVisitRepository) Update(visit *models.Visit) error {
 	return args.Error(0)
 }
 
-func (m *MockVisitRepository) StartVisit(scheduleID int, latitude, longitude float64) error {
-	args := m.Called(scheduleID, latitude, longitude)
+func (m *MockVisitRepository) GetAllByScheduleID(scheduleID int) ([]models.Visit, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.Visit), args.Error(1)
+}
+
+func (m *MockVisitRepository) GetByScheduleAndCaregiver(scheduleID, caregiverID int) (*models.Visit, error) {
+	args := m.Called(scheduleID, caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Visit), args.Error(1)
+}
+
+func (m *MockVisitRepository) StartVisit(scheduleID, caregiverID int, latitude, longitude float64, method string) error {
+	args := m.Called(scheduleID, caregiverID, latitude, longitude, method)
 	return args.Error(0)
 }
 
-func (m *MockVisitRepository) EndVisit(scheduleID int, latitude, longitude float64, notes string) error {
-	args := m.Called(scheduleID, latitude, longitude, notes)
+func (m *MockVisitRepository) EndVisit(scheduleID, caregiverID int, latitude, longitude float64, method, notes string) error {
+	args := m.Called(scheduleID, caregiverID, latitude, longitude, method, notes)
 	return args.Error(0)
 }
 
-func (m *MockVisitRepository) CancelVisit(scheduleID int) error {
-	args := m.Called(scheduleID)
+func (m *MockVisitRepository) CancelVisit(scheduleID, caregiverID int) error {
+	args := m.Called(scheduleID, caregiverID)
+	return args.Error(0)
+}
+
+func (m *MockVisitRepository) SetClockAddress(scheduleID, caregiverID int, event, address string) error {
+	args := m.Called(scheduleID, caregiverID, event, address)
 	return args.Error(0)
 }
 
@@ -122,8 +138,8 @@ func (m *MockTaskRepository) Update(task *models.Task) error {
 	return args.Error(0)
 }
 
-func (m *MockTaskRepository) UpdateStatus(id int, status, reason string) error {
-	args := m.Called(id, status, reason)
+func (m *MockTaskRepository) UpdateStatus(id int, status, reason string, version int) error {
+	args := m.Called(id, status, reason, version)
 	return args.Error(0)
 }
 
@@ -132,13 +148,101 @@ func (m *MockTaskRepository) Delete(id int) error {
 	return args.Error(0)
 }
 
+// MockScheduleSegmentRepository is a mock implementation of ScheduleSegmentRepository
+type MockScheduleSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduleSegmentRepository) GetByScheduleID(scheduleID int) ([]models.ScheduleSegment, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.ScheduleSegment), args.Error(1)
+}
+
+func (m *MockScheduleSegmentRepository) GetByID(id int) (*models.ScheduleSegment, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduleSegment), args.Error(1)
+}
+
+func (m *MockScheduleSegmentRepository) Create(segment *models.ScheduleSegment) error {
+	args := m.Called(segment)
+	return args.Error(0)
+}
+
+func (m *MockScheduleSegmentRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockVisitSegmentRepository is a mock implementation of VisitSegmentRepository
+type MockVisitSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockVisitSegmentRepository) GetByScheduleID(scheduleID int) ([]models.VisitSegment, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.VisitSegment), args.Error(1)
+}
+
+func (m *MockVisitSegmentRepository) Create(segment *models.VisitSegment) error {
+	args := m.Called(segment)
+	return args.Error(0)
+}
+
+func (m *MockVisitSegmentRepository) Switch(scheduleID, caregiverID int, at time.Time, latitude, longitude float64, next *models.VisitSegment) error {
+	args := m.Called(scheduleID, caregiverID, at, latitude, longitude, next)
+	return args.Error(0)
+}
+
+func (m *MockVisitSegmentRepository) Close(scheduleID, caregiverID int, at time.Time, latitude, longitude float64) error {
+	args := m.Called(scheduleID, caregiverID, at, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockVisitSegmentRepository) DeleteByScheduleAndCaregiver(scheduleID, caregiverID int) error {
+	args := m.Called(scheduleID, caregiverID)
+	return args.Error(0)
+}
+
+// MockScheduleCaregiverRepository is a mock implementation of ScheduleCaregiverRepository
+type MockScheduleCaregiverRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduleCaregiverRepository) GetByScheduleID(scheduleID int) ([]models.ScheduleCaregiver, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.ScheduleCaregiver), args.Error(1)
+}
+
+func (m *MockScheduleCaregiverRepository) Add(caregiver *models.ScheduleCaregiver) error {
+	args := m.Called(caregiver)
+	return args.Error(0)
+}
+
+func (m *MockScheduleCaregiverRepository) Remove(scheduleID, caregiverID int) error {
+	args := m.Called(scheduleID, caregiverID)
+	return args.Error(0)
+}
+
 func TestScheduleService_GetAllSchedules(t *testing.T) {
 	// Setup
 	mockScheduleRepo := new(MockScheduleRepository)
 	mockVisitRepo := new(MockVisitRepository)
 	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
 	logger := logrus.New()
-	service := NewScheduleService(mockScheduleRepo, mockVisitRepo, mockTaskRepo, logger)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
 
 	// Test data
 	expectedSchedules := []models.Schedule{
@@ -165,18 +269,24 @@ func TestScheduleService_GetAllSchedules(t *testing.T) {
 	filter := &models.ScheduleFilter{CaregiverID: &[]int{1}[0]}
 
 	// Mock expectations
-	mockScheduleRepo.On("GetAll", filter).Return(expectedSchedules, nil)
-	mockVisitRepo.On("GetByScheduleID", 1).Return(nil, nil)
+	mockScheduleRepo.On("GetAll", filter).Return(expectedSchedules, &models.PageInfo{Limit: models.DefaultPageSize}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", 1).Return([]models.ScheduleCaregiver{}, nil)
 	mockTaskRepo.On("GetByScheduleID", 1).Return([]models.Task{}, nil)
-	mockVisitRepo.On("GetByScheduleID", 2).Return(nil, nil)
+	mockSegmentRepo.On("GetByScheduleID", 1).Return([]models.ScheduleSegment{}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 2).Return([]models.Visit{}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", 2).Return([]models.ScheduleCaregiver{}, nil)
 	mockTaskRepo.On("GetByScheduleID", 2).Return([]models.Task{}, nil)
+	mockSegmentRepo.On("GetByScheduleID", 2).Return([]models.ScheduleSegment{}, nil)
 
 	// Execute
-	result, err := service.GetAllSchedules(filter)
+	result, page, err := service.GetAllSchedules(filter)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Len(t, result, 2)
+	assert.False(t, page.HasMore)
+	assert.Equal(t, models.DefaultPageSize, *filter.Limit)
 	assert.Equal(t, "John Doe", result[0].Client.Name)
 	assert.Equal(t, "Jane Smith", result[1].Client.Name)
 
@@ -186,13 +296,298 @@ func TestScheduleService_GetAllSchedules(t *testing.T) {
 	mockTaskRepo.AssertExpectations(t)
 }
 
+func TestScheduleService_GetAllSchedules_MaxPageSize(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	limit := 10000
+	filter := &models.ScheduleFilter{ListOptions: models.ListOptions{Limit: &limit}}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return *f.Limit == models.MaxPageSize
+	})).Return([]models.Schedule{}, &models.PageInfo{Limit: models.MaxPageSize}, nil)
+
+	// Execute
+	_, page, err := service.GetAllSchedules(filter)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, models.MaxPageSize, page.Limit)
+
+	// Verify mock expectations
+	mockScheduleRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_GetAllSchedules_InvalidFilter(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	filters := []*models.ScheduleFilter{
+		{Statuses: []string{"missed", "unknown"}},
+		{From: &from, To: &to},
+	}
+
+	for _, filter := range filters {
+		// Execute
+		_, _, err := service.GetAllSchedules(filter)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidFilter)
+	}
+
+	// Verify the repository was never queried
+	mockScheduleRepo.AssertNotCalled(t, "GetAll", mock.Anything)
+}
+
+func TestScheduleService_GetTodaySchedules_ClientTimezones(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	// 00:30 on March 9th in New York, which is still March 8th in Los Angeles
+	newYork, _ := time.LoadLocation("America/New_York")
+	now := time.Date(2025, 3, 9, 0, 30, 0, 0, newYork)
+	service.now = func() time.Time { return now }
+
+	nyClient := &models.Client{ID: 1, Timezone: "America/New_York"}
+	laClient := &models.Client{ID: 2, Timezone: "America/Los_Angeles"}
+
+	candidates := []models.Schedule{
+		// 23:30 the previous evening in New York, before midnight local time
+		{ID: 1, Client: nyClient, Status: "completed", StartTime: now.Add(-time.Hour), EndTime: now.Add(-30 * time.Minute)},
+		// 01:00 in New York, after midnight local time but still March 9th 06:00 UTC
+		{ID: 2, Client: nyClient, Status: "scheduled", StartTime: now.Add(30 * time.Minute), EndTime: now.Add(2 * time.Hour)},
+		// 22:00 March 8th in Los Angeles, today there even though it is March 9th in UTC
+		{ID: 3, Client: laClient, Status: "scheduled", StartTime: now.Add(30 * time.Minute), EndTime: now.Add(time.Hour)},
+		// 00:30 March 9th in Los Angeles, tomorrow there
+		{ID: 4, Client: laClient, Status: "scheduled", StartTime: now.Add(3 * time.Hour), EndTime: now.Add(4 * time.Hour)},
+		// Overnight shift from 22:00 the previous evening in New York, still running
+		{ID: 5, Client: nyClient, Status: "in_progress", StartTime: now.Add(-150 * time.Minute), EndTime: now.Add(330 * time.Minute)},
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return *f.CaregiverID == 1 && f.ActiveFrom.Before(now) && f.ActiveTo.After(now)
+	})).Return(candidates, &models.PageInfo{}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", mock.Anything).Return([]models.Visit{}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", mock.Anything).Return([]models.ScheduleCaregiver{}, nil)
+	mockTaskRepo.On("GetByScheduleID", mock.Anything).Return([]models.Task{}, nil)
+	mockSegmentRepo.On("GetByScheduleID", mock.Anything).Return([]models.ScheduleSegment{}, nil)
+
+	// Execute
+	result, err := service.GetTodaySchedules(1)
+
+	// Assert
+	assert.NoError(t, err)
+	var ids []int
+	for _, schedule := range result {
+		ids = append(ids, schedule.ID)
+	}
+	assert.Equal(t, []int{2, 3, 5}, ids)
+	assert.Equal(t, "America/New_York", result[0].Timezone)
+	assert.Equal(t, 1, result[0].StartTime.Hour())
+	assert.Equal(t, 22, result[1].StartTime.Hour())
+}
+
+func TestScheduleService_GetTodaySchedules_MissedNotPersisted(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	now := time.Date(2025, 3, 9, 15, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	client := &models.Client{ID: 1, Timezone: "UTC"}
+	candidates := []models.Schedule{
+		{ID: 1, Client: client, Status: "scheduled", Version: 3, StartTime: now.Add(-3 * time.Hour), EndTime: now.Add(-2 * time.Hour)},
+		{ID: 2, Client: client, Status: "scheduled", Version: 1, StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)},
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetAll", mock.AnythingOfType("*models.ScheduleFilter")).Return(candidates, &models.PageInfo{}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", mock.Anything).Return([]models.Visit{}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", mock.Anything).Return([]models.ScheduleCaregiver{}, nil)
+	mockTaskRepo.On("GetByScheduleID", mock.Anything).Return([]models.Task{}, nil)
+	mockSegmentRepo.On("GetByScheduleID", mock.Anything).Return([]models.ScheduleSegment{}, nil)
+
+	// Execute
+	result, err := service.GetTodaySchedules(1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "missed", result[0].Status)
+	assert.Equal(t, 3, result[0].Version)
+	assert.Equal(t, "scheduled", result[1].Status)
+	mockScheduleRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestScheduleService_MarkMissedSchedules(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         new(MockTaskRepository),
+		Segments:      new(MockScheduleSegmentRepository),
+		VisitSegments: new(MockVisitSegmentRepository),
+		Caregivers:    new(MockScheduleCaregiverRepository),
+	}, nil, logger)
+
+	now := time.Date(2025, 3, 9, 15, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	clockIn := now.Add(-150 * time.Minute)
+	candidates := []models.Schedule{
+		// Ended without anybody clocking in
+		{ID: 1, Status: "scheduled", StartTime: now.Add(-3 * time.Hour), EndTime: now.Add(-2 * time.Hour)},
+		// Ended, but a caregiver clocked in before the visit was aborted
+		{ID: 2, Status: "scheduled", StartTime: now.Add(-3 * time.Hour), EndTime: now.Add(-time.Hour)},
+		// Started but not ended yet
+		{ID: 3, Status: "scheduled", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)},
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return f.To.Equal(now) && len(f.Statuses) == 1 && f.Statuses[0] == "scheduled"
+	})).Return(candidates, &models.PageInfo{}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 2).Return([]models.Visit{{ScheduleID: 2, StartTime: &clockIn}}, nil)
+	mockScheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.ID == 1 && s.Status == "missed"
+	})).Return(nil).Once()
+
+	// Execute
+	marked, err := service.MarkMissedSchedules()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, marked)
+	mockScheduleRepo.AssertExpectations(t)
+	mockVisitRepo.AssertNotCalled(t, "GetAllByScheduleID", 3)
+}
+
+func TestScheduleService_GetScheduleStats_ClientTimezone(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+
+	// Agency default is Tokyo, where it is already the next day in UTC terms
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, tokyo, logger)
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, tokyo) // 23:00 May 31st UTC
+	service.now = func() time.Time { return now }
+
+	candidates := []models.Schedule{
+		{ID: 1, Client: &models.Client{ID: 1}, Status: "completed", StartTime: now.Add(-7 * time.Hour), EndTime: now.Add(-6 * time.Hour)},  // 01:00 Tokyo
+		{ID: 2, Client: &models.Client{ID: 1}, Status: "scheduled", StartTime: now.Add(2 * time.Hour), EndTime: now.Add(3 * time.Hour)},    // 10:00 Tokyo
+		{ID: 3, Client: &models.Client{ID: 1}, Status: "completed", StartTime: now.Add(-10 * time.Hour), EndTime: now.Add(-9 * time.Hour)}, // 22:00 the day before
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetStats", 1).Return(&models.ScheduleStats{Total: 10, Missed: 2}, nil)
+	mockScheduleRepo.On("GetAll", mock.AnythingOfType("*models.ScheduleFilter")).Return(candidates, &models.PageInfo{}, nil)
+
+	// Execute
+	stats, err := service.GetScheduleStats(1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 10, stats.Total)
+	assert.Equal(t, 2, stats.Missed)
+	assert.Equal(t, 1, stats.Upcoming)
+	assert.Equal(t, 1, stats.Completed)
+}
+
 func TestScheduleService_GetScheduleByID(t *testing.T) {
 	// Setup
 	mockScheduleRepo := new(MockScheduleRepository)
 	mockVisitRepo := new(MockVisitRepository)
 	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
 	logger := logrus.New()
-	service := NewScheduleService(mockScheduleRepo, mockVisitRepo, mockTaskRepo, logger)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
 
 	// Test data
 	expectedSchedule := &models.Schedule{
@@ -207,8 +602,10 @@ func TestScheduleService_GetScheduleByID(t *testing.T) {
 
 	// Mock expectations
 	mockScheduleRepo.On("GetByID", 1).Return(expectedSchedule, nil)
-	mockVisitRepo.On("GetByScheduleID", 1).Return(nil, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", 1).Return([]models.ScheduleCaregiver{}, nil)
 	mockTaskRepo.On("GetByScheduleID", 1).Return([]models.Task{}, nil)
+	mockSegmentRepo.On("GetByScheduleID", 1).Return([]models.ScheduleSegment{}, nil)
 
 	// Execute
 	result, err := service.GetScheduleByID(1)
@@ -229,8 +626,18 @@ func TestScheduleService_GetScheduleByID_NotFound(t *testing.T) {
 	mockScheduleRepo := new(MockScheduleRepository)
 	mockVisitRepo := new(MockVisitRepository)
 	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
 	logger := logrus.New()
-	service := NewScheduleService(mockScheduleRepo, mockVisitRepo, mockTaskRepo, logger)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
 
 	// Mock expectations
 	mockScheduleRepo.On("GetByID", 999).Return(nil, nil)
@@ -251,23 +658,47 @@ func TestScheduleService_StartVisit(t *testing.T) {
 	mockScheduleRepo := new(MockScheduleRepository)
 	mockVisitRepo := new(MockVisitRepository)
 	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
 	logger := logrus.New()
-	service := NewScheduleService(mockScheduleRepo, mockVisitRepo, mockTaskRepo, logger)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
 
 	// Test data
 	schedule := &models.Schedule{
 		ID:        1,
 		StartTime: time.Now().Add(15 * time.Minute), // Within 30 minutes
+		EndTime:   time.Now().Add(75 * time.Minute),
 		Status:    "scheduled",
 	}
+	accuracy := 8.5
 	req := &models.VisitStartRequest{
-		Latitude:  40.7128,
-		Longitude: -74.0060,
+		Latitude:     40.7128,
+		Longitude:    -74.0060,
+		Accuracy:     &accuracy,
+		MockLocation: true,
 	}
+	var events []*models.ClockEvent
+	service.OnClockEvent(func(event *models.ClockEvent) error {
+		events = append(events, event)
+		return nil
+	})
 
 	// Mock expectations
 	mockScheduleRepo.On("GetByID", 1).Return(schedule, nil)
-	mockVisitRepo.On("StartVisit", 1, req.Latitude, req.Longitude).Return(nil)
+	mockVisitRepo.On("StartVisit", 1, 0, req.Latitude, req.Longitude, models.VerificationMethodGPS).Return(nil)
+	clockIn := time.Now()
+	mockVisitRepo.On("GetByScheduleAndCaregiver", 1, 0).Return(&models.Visit{ScheduleID: 1, StartTime: &clockIn, StartLatitude: &req.Latitude, StartLongitude: &req.Longitude}, nil)
+	mockVisitSegmentRepo.On("Create", mock.MatchedBy(func(seg *models.VisitSegment) bool {
+		return seg.Type == models.VisitSegmentTypeWork && seg.StartTime.Equal(clockIn) && seg.EndTime == nil
+	})).Return(nil)
 	mockScheduleRepo.On("Update", mock.AnythingOfType("*models.Schedule")).Return(nil)
 
 	// Execute
@@ -275,10 +706,17 @@ func TestScheduleService_StartVisit(t *testing.T) {
 
 	// Assert
 	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, models.ClockEventIn, events[0].Event)
+		assert.Equal(t, req.Latitude, events[0].Latitude)
+		assert.Equal(t, &accuracy, events[0].Accuracy)
+		assert.True(t, events[0].MockLocation)
+	}
 
 	// Verify mock expectations
 	mockScheduleRepo.AssertExpectations(t)
 	mockVisitRepo.AssertExpectations(t)
+	mockVisitSegmentRepo.AssertExpectations(t)
 }
 
 func TestScheduleService_StartVisit_ScheduleNotFound(t *testing.T) {
@@ -286,8 +724,18 @@ func TestScheduleService_StartVisit_ScheduleNotFound(t *testing.T) {
 	mockScheduleRepo := new(MockScheduleRepository)
 	mockVisitRepo := new(MockVisitRepository)
 	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
 	logger := logrus.New()
-	service := NewScheduleService(mockScheduleRepo, mockVisitRepo, mockTaskRepo, logger)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
 
 	req := &models.VisitStartRequest{
 		Latitude:  40.7128,
@@ -313,8 +761,18 @@ func TestScheduleService_StartVisit_TooEarly(t *testing.T) {
 	mockScheduleRepo := new(MockScheduleRepository)
 	mockVisitRepo := new(MockVisitRepository)
 	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
 	logger := logrus.New()
-	service := NewScheduleService(mockScheduleRepo, mockVisitRepo, mockTaskRepo, logger)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
 
 	// Test data - schedule starts in 2 hours (more than 30 minutes early)
 	schedule := &models.Schedule{
@@ -340,3 +798,179 @@ func TestScheduleService_StartVisit_TooEarly(t *testing.T) {
 	// Verify mock expectations
 	mockScheduleRepo.AssertExpectations(t)
 }
+
+func TestScheduleService_StartVisit_AlreadyCompleted(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	// Test data - completed schedule must not be restarted
+	schedule := &models.Schedule{
+		ID:        1,
+		StartTime: time.Now().Add(-time.Hour),
+		Status:    "completed",
+	}
+	req := &models.VisitStartRequest{
+		Latitude:  40.7128,
+		Longitude: -74.0060,
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(schedule, nil)
+
+	// Execute
+	err := service.StartVisit(1, req)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	mockVisitRepo.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockScheduleRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_EndVisit_Missed(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	// Test data
+	schedule := &models.Schedule{
+		ID:        1,
+		StartTime: time.Now().Add(-3 * time.Hour),
+		EndTime:   time.Now().Add(-time.Hour),
+		Status:    "missed",
+	}
+	req := &models.VisitEndRequest{
+		Latitude:  40.7128,
+		Longitude: -74.0060,
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(schedule, nil)
+
+	// Execute
+	err := service.EndVisit(1, req)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	mockVisitRepo.AssertNotCalled(t, "EndVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockScheduleRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_CancelVisit_Scheduled(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+
+	// Test data
+	schedule := &models.Schedule{
+		ID:        1,
+		StartTime: time.Now().Add(time.Hour),
+		EndTime:   time.Now().Add(2 * time.Hour),
+		Status:    "scheduled",
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(schedule, nil)
+	mockScheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.Status == "cancelled"
+	})).Return(nil)
+
+	// Execute
+	err := service.CancelVisit(1, nil)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVisitRepo.AssertNotCalled(t, "CancelVisit", mock.Anything)
+	mockScheduleRepo.AssertExpectations(t)
+}
+
+// expectScheduleReadBack stores schedules created through m as id and serves
+// them back from GetByID for client, with the created_at the database sets
+func expectScheduleReadBack(m *teamTestMocks, id int, client *models.Client) {
+	stored := &models.Schedule{}
+	m.scheduleRepo.On("Create", mock.AnythingOfType("*models.Schedule")).Run(func(args mock.Arguments) {
+		schedule := args.Get(0).(*models.Schedule)
+		schedule.ID = id
+		*stored = *schedule
+		stored.Client = client
+		stored.Version = 1
+		stored.CreatedAt = time.Now().UTC()
+		stored.UpdatedAt = stored.CreatedAt
+	}).Return(nil)
+	m.scheduleRepo.On("GetByID", id).Return(stored, nil)
+	m.caregiverRepo.On("GetByScheduleID", id).Return([]models.ScheduleCaregiver{}, nil)
+	m.visitRepo.On("GetAllByScheduleID", id).Return([]models.Visit{}, nil)
+	m.taskRepo.On("GetByScheduleID", id).Return([]models.Task{}, nil)
+	m.segmentRepo.On("GetByScheduleID", id).Return([]models.ScheduleSegment{}, nil)
+}
+
+func TestScheduleService_CreateSchedule_ReturnsStoredSchedule(t *testing.T) {
+	service, m := newTeamTestService()
+	start := time.Date(2025, 1, 6, 2, 0, 0, 0, time.UTC)
+	expectScheduleReadBack(m, 7, &models.Client{ID: 101, Timezone: "Asia/Jakarta"})
+
+	schedule, err := service.CreateSchedule(&models.ScheduleCreateRequest{ClientID: 101, CaregiverID: 1,
+		StartTime: start, EndTime: start.Add(time.Hour)})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 7, schedule.ID)
+		assert.Equal(t, "Asia/Jakarta", schedule.Timezone)
+		assert.Equal(t, "2025-01-06T09:00:00+07:00", schedule.StartTime.Format(time.RFC3339))
+		assert.False(t, schedule.CreatedAt.IsZero())
+		assert.Equal(t, 1, schedule.Version)
+	}
+}
+
+func TestScheduleService_CreateSchedule_FlexMinutes(t *testing.T) {
+	service, m := newTeamTestService()
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	expectScheduleReadBack(m, 7, nil)
+
+	_, err := service.CreateSchedule(&models.ScheduleCreateRequest{ClientID: 101, CaregiverID: 1,
+		StartTime: start, EndTime: start.Add(time.Hour), FlexMinutes: maxFlexMinutes + 1})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	schedule, err := service.CreateSchedule(&models.ScheduleCreateRequest{ClientID: 101, CaregiverID: 1,
+		StartTime: start, EndTime: start.Add(time.Hour), FlexMinutes: 60})
+	assert.NoError(t, err)
+	assert.Equal(t, 60, schedule.FlexMinutes)
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AssignmentValidator checks whether a caregiver may be assigned to a schedule,
+// returning an error that wraps ErrCaregiverUnavailable or ErrValidation if not
+type AssignmentValidator func(schedule *models.Schedule, caregiverID int) error
+
+// OnAssign registers a validator that runs whenever a caregiver is booked on a
+// schedule: on creation, on reassignment and when joining a team
+func (s *ScheduleService) OnAssign(validator AssignmentValidator) {
+	s.validators = append(s.validators, validator)
+}
+
+// validateAssignment runs the assignment validators, stopping at the first failure
+func (s *ScheduleService) validateAssignment(schedule *models.Schedule, caregiverID int) error {
+	for _, validator := range s.validators {
+		if err := validator(schedule, caregiverID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckAssignment runs the assignment validators without booking the
+// caregiver, e.g. before a caregiver claims an open shift
+func (s *ScheduleService) CheckAssignment(schedule *models.Schedule, caregiverID int) error {
+	return s.validateAssignment(schedule, caregiverID)
+}
+
+// leadVisit returns the lead caregiver's visit of an enriched schedule, or
+// the first visit recorded when the lead has none
+func leadVisit(schedule *models.Schedule) *models.Visit {
+	if visit := caregiverVisit(schedule, schedule.CaregiverID); visit != nil {
+		return visit
+	}
+	if len(schedule.Visits) > 0 {
+		return &schedule.Visits[0]
+	}
+	return nil
+}
+
+// caregiverVisit returns one caregiver's visit of an enriched schedule
+func caregiverVisit(schedule *models.Schedule, caregiverID int) *models.Visit {
+	for i := range schedule.Visits {
+		if schedule.Visits[i].CaregiverID == caregiverID {
+			return &schedule.Visits[i]
+		}
+	}
+	return nil
+}
+
+// resolveCaregiver returns the caregiver a visit request acts for: the
+// requested team member, or the schedule's lead when none is given
+func (s *ScheduleService) resolveCaregiver(schedule *models.Schedule, requested *int) (int, error) {
+	if requested == nil || *requested == schedule.CaregiverID {
+		return schedule.CaregiverID, nil
+	}
+
+	onTeam, err := s.isOnTeam(schedule.ID, *requested)
+	if err != nil {
+		return 0, err
+	}
+	if !onTeam {
+		return 0, fmt.Errorf("%w: caregiver %d is not assigned to schedule %d", ErrValidation, *requested, schedule.ID)
+	}
+
+	return *requested, nil
+}
+
+// isOnTeam reports whether a caregiver is assigned to a schedule
+func (s *ScheduleService) isOnTeam(scheduleID, caregiverID int) (bool, error) {
+	caregivers, err := s.caregiverRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get schedule caregivers: %w", err)
+	}
+	for _, caregiver := range caregivers {
+		if caregiver.CaregiverID == caregiverID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetScheduleCaregivers lists the team of a schedule, lead first
+func (s *ScheduleService) GetScheduleCaregivers(scheduleID int) ([]models.ScheduleCaregiver, error) {
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	caregivers, err := s.caregiverRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to get schedule caregivers")
+		return nil, fmt.Errorf("failed to get schedule caregivers: %w", err)
+	}
+	if caregivers == nil {
+		caregivers = []models.ScheduleCaregiver{}
+	}
+
+	return caregivers, nil
+}
+
+// AddScheduleCaregiver adds a caregiver to a schedule's team as a member, e.g.
+// the second caregiver of a two-person transfer
+func (s *ScheduleService) AddScheduleCaregiver(scheduleID int, req *models.ScheduleCaregiverRequest) (*models.ScheduleCaregiver, error) {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+	}).Info("Adding caregiver to schedule")
+
+	if req.CaregiverID <= 0 {
+		return nil, fmt.Errorf("%w: caregiver_id is required", ErrValidation)
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+	switch schedule.Status {
+	case models.ScheduleStatusCompleted, models.ScheduleStatusMissed, models.ScheduleStatusCancelled:
+		return nil, fmt.Errorf("%w: cannot change the team of a %s schedule", ErrInvalidTransition, schedule.Status)
+	}
+
+	onTeam, err := s.isOnTeam(scheduleID, req.CaregiverID)
+	if err != nil {
+		return nil, err
+	}
+	if onTeam {
+		return nil, fmt.Errorf("%w: caregiver %d is already assigned to schedule %d", ErrValidation, req.CaregiverID, scheduleID)
+	}
+
+	if err := s.validateAssignment(schedule, req.CaregiverID); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", req.CaregiverID).Warn("Caregiver cannot join schedule")
+		return nil, err
+	}
+
+	caregiver := &models.ScheduleCaregiver{
+		ScheduleID:  scheduleID,
+		CaregiverID: req.CaregiverID,
+		Role:        models.CaregiverRoleMember,
+	}
+	if err := s.caregiverRepo.Add(caregiver); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to add schedule caregiver")
+		return nil, fmt.Errorf("failed to add schedule caregiver: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+	}).Info("Successfully added caregiver to schedule")
+	return caregiver, nil
+}
+
+// RemoveScheduleCaregiver removes a member from a schedule's team and
+// unassigns their tasks. The lead and caregivers who already clocked in stay.
+func (s *ScheduleService) RemoveScheduleCaregiver(scheduleID, caregiverID int) error {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": caregiverID,
+	}).Info("Removing caregiver from schedule")
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	onTeam, err := s.isOnTeam(scheduleID, caregiverID)
+	if err != nil {
+		return err
+	}
+	if !onTeam {
+		return fmt.Errorf("caregiver %d on schedule %d: %w", caregiverID, scheduleID, ErrNotFound)
+	}
+	if caregiverID == schedule.CaregiverID {
+		return fmt.Errorf("%w: the lead caregiver cannot be removed", ErrValidation)
+	}
+
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit != nil && visit.StartTime != nil {
+		return fmt.Errorf("%w: caregiver %d has already clocked in", ErrInvalidTransition, caregiverID)
+	}
+
+	if err := s.unassignTasks(scheduleID, caregiverID); err != nil {
+		return err
+	}
+
+	if err := s.caregiverRepo.Remove(scheduleID, caregiverID); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to remove schedule caregiver")
+		return fmt.Errorf("failed to remove schedule caregiver: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": caregiverID,
+	}).Info("Successfully removed caregiver from schedule")
+	return nil
+}
+
+// ReassignSchedule hands a schedule that has not started to another lead
+// caregiver. The previous lead leaves the team and their tasks are unassigned.
+func (s *ScheduleService) ReassignSchedule(scheduleID int, req *models.ScheduleReassignRequest) (*models.Schedule, error) {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+	}).Info("Reassigning schedule")
+
+	if req.CaregiverID <= 0 {
+		return nil, fmt.Errorf("%w: caregiver_id is required", ErrValidation)
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != schedule.Version {
+		return nil, fmt.Errorf("schedule %d is at version %d: %w", scheduleID, schedule.Version, ErrVersionConflict)
+	}
+	if schedule.Status != models.ScheduleStatusScheduled {
+		return nil, fmt.Errorf("%w: cannot reassign a %s schedule", ErrInvalidTransition, schedule.Status)
+	}
+
+	previous := schedule.CaregiverID
+	if req.CaregiverID == previous {
+		return schedule, nil
+	}
+	if err := s.validateAssignment(schedule, req.CaregiverID); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", req.CaregiverID).Warn("Caregiver cannot take schedule")
+		return nil, err
+	}
+
+	schedule.CaregiverID = req.CaregiverID
+	if err := s.scheduleRepo.Update(schedule); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to reassign schedule")
+		return nil, fmt.Errorf("failed to reassign schedule: %w", err)
+	}
+
+	if err := s.unassignTasks(scheduleID, previous); err != nil {
+		return nil, err
+	}
+	if err := s.caregiverRepo.Remove(scheduleID, previous); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to remove previous lead")
+		return nil, fmt.Errorf("failed to remove schedule caregiver: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id": scheduleID,
+		"from":        previous,
+		"to":          req.CaregiverID,
+	}).Info("Successfully reassigned schedule")
+	return schedule, nil
+}
+
+// unassignTasks clears the assignee of a schedule's tasks assigned to a caregiver
+func (s *ScheduleService) unassignTasks(scheduleID, caregiverID int) error {
+	tasks, err := s.taskRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get tasks: %w", err)
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if task.CaregiverID == nil || *task.CaregiverID != caregiverID {
+			continue
+		}
+		task.CaregiverID = nil
+		if err := s.taskRepo.Update(task); err != nil {
+			s.logger.WithError(err).WithField("task_id", task.ID).Error("Failed to unassign task")
+			return fmt.Errorf("failed to unassign task: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type teamTestMocks struct {
+	scheduleRepo     *MockScheduleRepository
+	visitRepo        *MockVisitRepository
+	taskRepo         *MockTaskRepository
+	visitSegmentRepo *MockVisitSegmentRepository
+	caregiverRepo    *MockScheduleCaregiverRepository
+	segmentRepo      *MockScheduleSegmentRepository
+}
+
+func newTeamTestService() (*ScheduleService, *teamTestMocks) {
+	m := &teamTestMocks{
+		scheduleRepo:     new(MockScheduleRepository),
+		visitRepo:        new(MockVisitRepository),
+		taskRepo:         new(MockTaskRepository),
+		visitSegmentRepo: new(MockVisitSegmentRepository),
+		caregiverRepo:    new(MockScheduleCaregiverRepository),
+		segmentRepo:      new(MockScheduleSegmentRepository),
+	}
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     m.scheduleRepo,
+		Visits:        m.visitRepo,
+		Tasks:         m.taskRepo,
+		Segments:      m.segmentRepo,
+		VisitSegments: m.visitSegmentRepo,
+		Caregivers:    m.caregiverRepo,
+	}, nil, logrus.New())
+	return service, m
+}
+
+// transferTeam is the team of a two-person transfer led by caregiver 1
+var transferTeam = []models.ScheduleCaregiver{
+	{ScheduleID: 1, CaregiverID: 1, Role: models.CaregiverRoleLead},
+	{ScheduleID: 1, CaregiverID: 2, Role: models.CaregiverRoleMember},
+}
+
+func TestScheduleService_StartVisit_TeamMemberJoins(t *testing.T) {
+	service, m := newTeamTestService()
+	clockIn := time.Now()
+	member := 2
+	req := &models.VisitStartRequest{Latitude: 40.7128, Longitude: -74.0060, CaregiverID: &member}
+
+	// Mock expectations: the lead already started the schedule
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+	m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(nil, nil).Once()
+	m.visitRepo.On("StartVisit", 1, 2, req.Latitude, req.Longitude, models.VerificationMethodGPS).Return(nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(&models.Visit{ScheduleID: 1, CaregiverID: 2, StartTime: &clockIn}, nil)
+	m.visitSegmentRepo.On("Create", mock.MatchedBy(func(seg *models.VisitSegment) bool {
+		return seg.CaregiverID == 2 && seg.Type == models.VisitSegmentTypeWork
+	})).Return(nil)
+
+	// Execute
+	err := service.StartVisit(1, req)
+
+	// Assert: the schedule status is left alone
+	assert.NoError(t, err)
+	m.visitRepo.AssertExpectations(t)
+	m.visitSegmentRepo.AssertExpectations(t)
+	m.scheduleRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestScheduleService_StartVisit_CaregiverNotOnTeam(t *testing.T) {
+	service, m := newTeamTestService()
+	stranger := 9
+
+	// Mock expectations
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusScheduled, StartTime: time.Now()}, nil)
+	m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+
+	// Execute
+	err := service.StartVisit(1, &models.VisitStartRequest{Latitude: 1, Longitude: 1, CaregiverID: &stranger})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrValidation)
+	m.visitRepo.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduleService_EndVisit_TeamCompletesWithLastCaregiver(t *testing.T) {
+	clockIn := time.Now().Add(-time.Hour)
+	clockOut := time.Now()
+	member := 2
+
+	cases := []struct {
+		name      string
+		leadState string
+		completes bool
+	}{
+		{name: "lead still clocked in", leadState: models.VisitStatusInProgress, completes: false},
+		{name: "lead already clocked out", leadState: models.VisitStatusCompleted, completes: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := newTeamTestService()
+			req := &models.VisitEndRequest{Latitude: 40.7128, Longitude: -74.0060, CaregiverID: &member}
+
+			// Mock expectations
+			m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+			m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(&models.Visit{ScheduleID: 1, CaregiverID: 2, Status: models.VisitStatusInProgress, StartTime: &clockIn}, nil).Once()
+			m.taskRepo.On("GetByScheduleID", 1).Return([]models.Task{}, nil)
+			m.visitRepo.On("EndVisit", 1, 2, req.Latitude, req.Longitude, models.VerificationMethodGPS, "").Return(nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(&models.Visit{ScheduleID: 1, CaregiverID: 2, Status: models.VisitStatusCompleted, StartTime: &clockIn, EndTime: &clockOut}, nil)
+			m.visitSegmentRepo.On("Close", 1, 2, clockOut, req.Latitude, req.Longitude).Return(nil)
+			m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{
+				{ScheduleID: 1, CaregiverID: 1, Status: tc.leadState},
+				{ScheduleID: 1, CaregiverID: 2, Status: models.VisitStatusCompleted},
+			}, nil)
+			m.scheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+				return s.Status == models.ScheduleStatusCompleted
+			})).Return(nil)
+
+			// Execute
+			err := service.EndVisit(1, req)
+
+			// Assert
+			assert.NoError(t, err)
+			if tc.completes {
+				m.scheduleRepo.AssertCalled(t, "Update", mock.Anything)
+			} else {
+				m.scheduleRepo.AssertNotCalled(t, "Update", mock.Anything)
+			}
+		})
+	}
+}
+
+// stubReasonCodes accepts the same reason codes for every service
+type stubReasonCodes []string
+
+func (c stubReasonCodes) ReasonCodes(serviceName string) ([]string, error) {
+	return c, nil
+}
+
+func TestScheduleService_CancelVisit_TeamMemberAborts(t *testing.T) {
+	service, m := newTeamTestService()
+	clockIn := time.Now().Add(-time.Hour)
+	member := 2
+
+	// Mock expectations: both caregivers are clocked in
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+	m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(&models.Visit{ScheduleID: 1, CaregiverID: 2, StartTime: &clockIn, Status: models.VisitStatusInProgress}, nil)
+	m.visitRepo.On("CancelVisit", 1, 2).Return(nil)
+	m.visitSegmentRepo.On("DeleteByScheduleAndCaregiver", 1, 2).Return(nil)
+	m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{
+		{ScheduleID: 1, CaregiverID: 1, StartTime: &clockIn, Status: models.VisitStatusInProgress},
+		{ScheduleID: 1, CaregiverID: 2, Status: models.VisitStatusNotStarted},
+	}, nil)
+
+	// Execute
+	err := service.CancelVisit(1, &models.VisitCancelRequest{CaregiverID: &member})
+
+	// Assert: the lead's visit and the schedule are left alone
+	assert.NoError(t, err)
+	m.visitRepo.AssertNotCalled(t, "CancelVisit", 1, 1)
+	m.visitSegmentRepo.AssertNotCalled(t, "DeleteByScheduleAndCaregiver", 1, 1)
+	m.scheduleRepo.AssertNotCalled(t, "Update", mock.Anything)
+	m.visitSegmentRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_CancelVisit_LastCaregiverAfterOthersClockedOut(t *testing.T) {
+	service, m := newTeamTestService()
+	clockIn := time.Now().Add(-time.Hour)
+
+	// Mock expectations: the member already clocked out, the lead aborts
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 1).Return(&models.Visit{ScheduleID: 1, CaregiverID: 1, StartTime: &clockIn, Status: models.VisitStatusInProgress}, nil)
+	m.visitRepo.On("CancelVisit", 1, 1).Return(nil)
+	m.visitSegmentRepo.On("DeleteByScheduleAndCaregiver", 1, 1).Return(nil)
+	m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{
+		{ScheduleID: 1, CaregiverID: 1, Status: models.VisitStatusNotStarted},
+		{ScheduleID: 1, CaregiverID: 2, StartTime: &clockIn, Status: models.VisitStatusCompleted},
+	}, nil)
+	m.scheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.Status == models.ScheduleStatusCompleted
+	})).Return(nil)
+
+	// Execute
+	err := service.CancelVisit(1, nil)
+
+	// Assert
+	assert.NoError(t, err)
+	m.scheduleRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_EndVisit_RequiredTasks(t *testing.T) {
+	clockIn := time.Now().Add(-time.Hour)
+	lead, member := 1, 2
+
+	// Tasks of a two-person visit that caregiver 2 clocks out of
+	tasks := []models.Task{
+		{ID: 1, ScheduleID: 1, Required: true, Status: "completed"},
+		{ID: 2, ScheduleID: 1, Required: true, Status: "not_completed", Reason: "Client refused"},
+		{ID: 3, ScheduleID: 1, Required: true, Status: "not_completed", Reason: "forgot"},
+		{ID: 4, ScheduleID: 1, Required: true, Status: "pending", CaregiverID: &member},
+		{ID: 5, ScheduleID: 1, Required: true, Status: "pending", CaregiverID: &lead},
+		{ID: 6, ScheduleID: 1, Required: true, Status: "pending"},
+		{ID: 7, ScheduleID: 1, Status: "pending", CaregiverID: &member},
+	}
+
+	cases := []struct {
+		name      string
+		leadState string
+		taskIDs   []int
+	}{
+		// Unassigned tasks wait for the lead who is still clocked in
+		{name: "lead still clocked in", leadState: models.VisitStatusInProgress, taskIDs: []int{4}},
+		{name: "last to clock out", leadState: models.VisitStatusCompleted, taskIDs: []int{3, 4, 6}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := newTeamTestService()
+			service.UseReasonCodes(stubReasonCodes{"client_refused"})
+			req := &models.VisitEndRequest{Latitude: 40.7128, Longitude: -74.0060, CaregiverID: &member}
+
+			// Mock expectations
+			m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+			m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(&models.Visit{ScheduleID: 1, CaregiverID: 2, Status: models.VisitStatusInProgress, StartTime: &clockIn}, nil)
+			m.taskRepo.On("GetByScheduleID", 1).Return(tasks, nil)
+			m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{
+				{ScheduleID: 1, CaregiverID: 1, Status: tc.leadState},
+				{ScheduleID: 1, CaregiverID: 2, Status: models.VisitStatusInProgress},
+			}, nil)
+
+			// Execute
+			err := service.EndVisit(1, req)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrTasksIncomplete)
+			var incomplete *IncompleteTasksError
+			if assert.ErrorAs(t, err, &incomplete) {
+				assert.Equal(t, tc.taskIDs, incomplete.TaskIDs)
+			}
+			m.visitRepo.AssertNotCalled(t, "EndVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestScheduleService_RemoveScheduleCaregiver(t *testing.T) {
+	service, m := newTeamTestService()
+	member := 2
+
+	// Mock expectations
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusScheduled}, nil)
+	m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(nil, nil)
+	m.taskRepo.On("GetByScheduleID", 1).Return([]models.Task{
+		{ID: 1, ScheduleID: 1, CaregiverID: &member},
+		{ID: 2, ScheduleID: 1},
+	}, nil)
+	m.taskRepo.On("Update", mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 1 && task.CaregiverID == nil
+	})).Return(nil)
+	m.caregiverRepo.On("Remove", 1, 2).Return(nil)
+
+	// The lead cannot be removed
+	assert.ErrorIs(t, service.RemoveScheduleCaregiver(1, 1), ErrValidation)
+
+	// Execute
+	err := service.RemoveScheduleCaregiver(1, 2)
+
+	// Assert: the member's task is unassigned
+	assert.NoError(t, err)
+	m.taskRepo.AssertNumberOfCalls(t, "Update", 1)
+	m.caregiverRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_RemoveScheduleCaregiver_ClockedIn(t *testing.T) {
+	service, m := newTeamTestService()
+	clockIn := time.Now()
+
+	// Mock expectations
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+	m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(&models.Visit{CaregiverID: 2, StartTime: &clockIn}, nil)
+
+	// Execute
+	err := service.RemoveScheduleCaregiver(1, 2)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	m.caregiverRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+}
+
+func TestScheduleService_GetTimesheet_TeamMember(t *testing.T) {
+	service, m := newTeamTestService()
+	segmentRepo := new(MockScheduleSegmentRepository)
+	service.segmentRepo = segmentRepo
+	service.now = func() time.Time { return time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC) }
+
+	leadIn := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	leadOut := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	memberIn := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	memberOut := time.Date(2025, 1, 2, 11, 0, 0, 0, time.UTC)
+
+	// Mock expectations: the member only helped with the transfer
+	m.scheduleRepo.On("GetAll", mock.AnythingOfType("*models.ScheduleFilter")).Return([]models.Schedule{
+		{ID: 1, CaregiverID: 1, Status: "completed", StartTime: leadIn, EndTime: leadOut},
+	}, &models.PageInfo{}, nil)
+	m.caregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+	m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{
+		{ScheduleID: 1, CaregiverID: 1, StartTime: &leadIn, EndTime: &leadOut},
+		{ScheduleID: 1, CaregiverID: 2, StartTime: &memberIn, EndTime: &memberOut},
+	}, nil)
+	m.visitSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{}, nil)
+	m.taskRepo.On("GetByScheduleID", 1).Return([]models.Task{}, nil)
+	segmentRepo.On("GetByScheduleID", 1).Return([]models.ScheduleSegment{}, nil)
+
+	// Execute
+	timesheet, err := service.GetTimesheet(2, "2025-01-02", "2025-01-02")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, timesheet.WorkedHours)
+}
+
+func TestTaskService_AssignTask(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	service := NewTaskService(mockTaskRepo, mockCaregiverRepo, logrus.New())
+	member, stranger := 2, 9
+
+	// Mock expectations
+	mockTaskRepo.On("GetByID", 1).Return(&models.Task{ID: 1, ScheduleID: 1, Title: "Hoyer lift transfer", Version: 1}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", 1).Return(transferTeam, nil)
+	mockTaskRepo.On("Update", mock.MatchedBy(func(task *models.Task) bool {
+		return task.CaregiverID != nil && *task.CaregiverID == member
+	})).Return(nil)
+
+	// A caregiver who is not on the team is rejected
+	_, err := service.AssignTask(1, &models.TaskAssignRequest{CaregiverID: &stranger})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Execute
+	task, err := service.AssignTask(1, &models.TaskAssignRequest{CaregiverID: &member})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, member, *task.CaregiverID)
+	mockTaskRepo.AssertNumberOfCalls(t, "Update", 1)
+}
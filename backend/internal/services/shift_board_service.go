@@ -0,0 +1,680 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ShiftBoardService handles the open shift board, where caregivers release
+// schedules for others to claim, and shift swap requests between caregivers.
+// A schedule only changes hands through the schedule service, so availability,
+// skill and client checks apply before any reassignment takes effect.
+type ShiftBoardService struct {
+	openShiftRepo   repositories.OpenShiftRepository
+	swapRepo        repositories.SwapRequestRepository
+	transitionRepo  repositories.ShiftTransitionRepository
+	scheduleRepo    repositories.ScheduleRepository
+	schedules       *ScheduleService
+	openShiftStates *StateMachine[*models.OpenShift]
+	swapStates      *StateMachine[*models.SwapRequest]
+	now             func() time.Time
+	logger          *logrus.Logger
+}
+
+// NewShiftBoardService creates a new shift board service
+func NewShiftBoardService(
+	openShiftRepo repositories.OpenShiftRepository,
+	swapRepo repositories.SwapRequestRepository,
+	transitionRepo repositories.ShiftTransitionRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	schedules *ScheduleService,
+	logger *logrus.Logger,
+) *ShiftBoardService {
+	s := &ShiftBoardService{
+		openShiftRepo:   openShiftRepo,
+		swapRepo:        swapRepo,
+		transitionRepo:  transitionRepo,
+		scheduleRepo:    scheduleRepo,
+		schedules:       schedules,
+		openShiftStates: NewOpenShiftStateMachine(),
+		swapStates:      NewSwapRequestStateMachine(),
+		now:             time.Now,
+		logger:          logger,
+	}
+	s.openShiftStates.now = func() time.Time { return s.now() }
+	s.swapStates.now = func() time.Time { return s.now() }
+
+	s.openShiftStates.OnTransition(func(shift *models.OpenShift, t Transition) error {
+		var caregiverID *int
+		notes := shift.ReviewNotes
+		switch t.Event {
+		case OpenShiftEventRelease:
+			caregiverID = &shift.ReleasedBy
+			notes = shift.Reason
+		case OpenShiftEventClaim:
+			caregiverID = shift.ClaimedBy
+			notes = ""
+		}
+		return s.record(models.ShiftEntityOpenShift, shift.ID, t, caregiverID, notes)
+	})
+	s.swapStates.OnTransition(func(request *models.SwapRequest, t Transition) error {
+		switch t.Event {
+		case SwapEventRequest:
+			return s.record(models.ShiftEntitySwapRequest, request.ID, t, &request.RequesterID, request.Notes)
+		case SwapEventCancel:
+			return s.record(models.ShiftEntitySwapRequest, request.ID, t, &request.RequesterID, request.ResponseNotes)
+		default:
+			return s.record(models.ShiftEntitySwapRequest, request.ID, t, &request.TargetCaregiverID, request.ResponseNotes)
+		}
+	})
+
+	return s
+}
+
+// record appends a transition to the shift transition log
+func (s *ShiftBoardService) record(entity string, entityID int, t Transition, caregiverID *int, notes string) error {
+	entry := &models.ShiftTransition{
+		Entity:      entity,
+		EntityID:    entityID,
+		Event:       t.Event,
+		FromStatus:  t.From,
+		ToStatus:    t.To,
+		CaregiverID: caregiverID,
+		Notes:       notes,
+		CreatedAt:   t.At,
+	}
+	if err := s.transitionRepo.Create(entry); err != nil {
+		return fmt.Errorf("failed to record %s transition: %w", entity, err)
+	}
+	return nil
+}
+
+// getSchedule loads a schedule, returning ErrNotFound when it does not exist
+func (s *ShiftBoardService) getSchedule(scheduleID int) (*models.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+	return schedule, nil
+}
+
+// requireMovable checks that a scheduled visit is not already on the board or
+// part of a pending swap
+func (s *ShiftBoardService) requireMovable(schedule *models.Schedule) error {
+	if schedule.Status != models.ScheduleStatusScheduled {
+		return fmt.Errorf("%w: cannot hand over a %s schedule", ErrInvalidTransition, schedule.Status)
+	}
+
+	active, err := s.openShiftRepo.GetActiveByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get open shift: %w", err)
+	}
+	if active != nil {
+		return fmt.Errorf("%w: schedule %d is already on the open shift board", ErrInvalidTransition, schedule.ID)
+	}
+
+	pending, err := s.swapRepo.GetPendingByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get swap requests: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: schedule %d has a pending swap request", ErrInvalidTransition, schedule.ID)
+	}
+	return nil
+}
+
+// ReleaseSchedule puts a scheduled visit on the open shift board. The lead
+// caregiver keeps the visit until another caregiver's claim fills the shift.
+func (s *ShiftBoardService) ReleaseSchedule(scheduleID int, req *models.ScheduleReleaseRequest) (*models.OpenShift, error) {
+	s.logger.WithField("schedule_id", scheduleID).Info("Releasing schedule to the open shift board")
+
+	claimMode := req.ClaimMode
+	if claimMode == "" {
+		claimMode = models.ClaimModeFirstCome
+	}
+	if claimMode != models.ClaimModeFirstCome && claimMode != models.ClaimModeApproval {
+		return nil, fmt.Errorf("%w: claim_mode must be first_come or approval", ErrValidation)
+	}
+
+	schedule, err := s.getSchedule(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireMovable(schedule); err != nil {
+		return nil, err
+	}
+
+	shift := &models.OpenShift{
+		ScheduleID: scheduleID,
+		ReleasedBy: schedule.CaregiverID,
+		Reason:     req.Reason,
+		ClaimMode:  claimMode,
+		Status:     models.OpenShiftStatusOpen,
+	}
+	if err := s.openShiftRepo.Create(shift); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to create open shift")
+		return nil, fmt.Errorf("failed to create open shift: %w", err)
+	}
+
+	transition := Transition{Event: OpenShiftEventRelease, To: shift.Status, At: s.now()}
+	if err := s.openShiftStates.Complete(shift, transition); err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", shift.ID).Warn("Open shift transition hook failed")
+	}
+
+	shift.Schedule = schedule
+	s.logger.WithField("open_shift_id", shift.ID).Info("Successfully released schedule")
+	return shift, nil
+}
+
+// GetOpenShifts lists the shifts on the board, oldest first. With a caregiver,
+// only open shifts that caregiver did not release and could take are listed.
+func (s *ShiftBoardService) GetOpenShifts(filter *models.OpenShiftFilter) ([]models.OpenShift, error) {
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{models.OpenShiftStatusOpen, models.OpenShiftStatusClaimed}
+	}
+	for _, status := range statuses {
+		if !s.openShiftStates.IsValidState(status) {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, status)
+		}
+	}
+
+	shifts, err := s.openShiftRepo.GetByStatuses(statuses)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get open shifts")
+		return nil, fmt.Errorf("failed to get open shifts: %w", err)
+	}
+
+	result := []models.OpenShift{}
+	for i := range shifts {
+		shift := &shifts[i]
+		schedule, err := s.getSchedule(shift.ScheduleID)
+		if err != nil {
+			return nil, err
+		}
+		shift.Schedule = schedule
+
+		if filter.CaregiverID != nil {
+			if shift.Status != models.OpenShiftStatusOpen || shift.ReleasedBy == *filter.CaregiverID {
+				continue
+			}
+			err := s.schedules.CheckAssignment(schedule, *filter.CaregiverID)
+			if errors.Is(err, ErrCaregiverUnavailable) || errors.Is(err, ErrCaregiverUnqualified) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, *shift)
+	}
+
+	return result, nil
+}
+
+// GetOpenShift retrieves an open shift with its schedule and history
+func (s *ShiftBoardService) GetOpenShift(id int) (*models.OpenShift, error) {
+	shift, err := s.openShiftRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open shift: %w", err)
+	}
+	if shift == nil {
+		return nil, fmt.Errorf("open shift %d: %w", id, ErrNotFound)
+	}
+
+	if shift.Schedule, err = s.getSchedule(shift.ScheduleID); err != nil {
+		return nil, err
+	}
+	if shift.History, err = s.transitionRepo.GetByEntity(models.ShiftEntityOpenShift, id); err != nil {
+		return nil, fmt.Errorf("failed to get open shift history: %w", err)
+	}
+	return shift, nil
+}
+
+// loadOpenShift loads an open shift for a change, checking the expected version
+func (s *ShiftBoardService) loadOpenShift(id int, expectedVersion *int) (*models.OpenShift, error) {
+	shift, err := s.openShiftRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open shift: %w", err)
+	}
+	if shift == nil {
+		return nil, fmt.Errorf("open shift %d: %w", id, ErrNotFound)
+	}
+	if expectedVersion != nil && *expectedVersion != shift.Version {
+		return nil, fmt.Errorf("open shift %d is at version %d: %w", id, shift.Version, ErrVersionConflict)
+	}
+	return shift, nil
+}
+
+// ClaimOpenShift claims an open shift for a caregiver. A first-come shift is
+// reassigned to the caregiver straight away; an approval shift waits for a
+// coordinator to approve the claim.
+func (s *ShiftBoardService) ClaimOpenShift(id int, req *models.OpenShiftClaimRequest) (*models.OpenShift, error) {
+	s.logger.WithFields(logrus.Fields{
+		"open_shift_id": id,
+		"caregiver_id":  req.CaregiverID,
+	}).Info("Claiming open shift")
+
+	if req.CaregiverID <= 0 {
+		return nil, fmt.Errorf("%w: caregiver_id is required", ErrValidation)
+	}
+
+	shift, err := s.loadOpenShift(id, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if req.CaregiverID == shift.ReleasedBy {
+		return nil, fmt.Errorf("%w: caregiver %d released this shift", ErrValidation, req.CaregiverID)
+	}
+
+	claim, err := s.openShiftStates.Fire(shift, OpenShiftEventClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.getSchedule(shift.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.schedules.CheckAssignment(schedule, req.CaregiverID); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", req.CaregiverID).Warn("Caregiver cannot claim open shift")
+		return nil, err
+	}
+
+	original := *shift
+	shift.Status = claim.To
+	shift.ClaimedBy = &req.CaregiverID
+	shift.ClaimedAt = &claim.At
+	transitions := []Transition{claim}
+
+	if shift.ClaimMode == models.ClaimModeFirstCome {
+		approve, err := s.openShiftStates.Fire(shift, OpenShiftEventApprove)
+		if err != nil {
+			return nil, err
+		}
+		shift.ReviewNotes = "filled on a first-come basis"
+		if err := s.fill(shift, original, schedule, approve); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, approve)
+	} else if err := s.openShiftRepo.Update(shift); err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", id).Error("Failed to claim open shift")
+		return nil, fmt.Errorf("failed to claim open shift: %w", err)
+	}
+
+	for _, t := range transitions {
+		if err := s.openShiftStates.Complete(shift, t); err != nil {
+			s.logger.WithError(err).WithField("open_shift_id", id).Warn("Open shift transition hook failed")
+		}
+	}
+
+	shift.Schedule = schedule
+	s.logger.WithFields(logrus.Fields{
+		"open_shift_id": id,
+		"status":        shift.Status,
+	}).Info("Successfully claimed open shift")
+	return shift, nil
+}
+
+// fill marks a claimed shift filled and reassigns its schedule to the
+// claiming caregiver. The shift is saved first so that only one claim wins;
+// it is put back as it was before when the reassignment fails.
+func (s *ShiftBoardService) fill(shift *models.OpenShift, previous models.OpenShift, schedule *models.Schedule, approve Transition) error {
+	shift.Status = approve.To
+	if err := s.openShiftRepo.Update(shift); err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", shift.ID).Error("Failed to fill open shift")
+		return fmt.Errorf("failed to fill open shift: %w", err)
+	}
+
+	version := schedule.Version
+	updated, err := s.schedules.ReassignSchedule(schedule.ID, &models.ScheduleReassignRequest{
+		CaregiverID:     *shift.ClaimedBy,
+		ExpectedVersion: &version,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", shift.ID).Warn("Failed to reassign open shift, reverting")
+		previous.Version = shift.Version
+		if rerr := s.openShiftRepo.Update(&previous); rerr != nil {
+			s.logger.WithError(rerr).WithField("open_shift_id", shift.ID).Error("Failed to revert open shift")
+		}
+		*shift = previous
+		return err
+	}
+
+	*schedule = *updated
+	return nil
+}
+
+// ApproveOpenShift approves a pending claim and reassigns the schedule to
+// the claiming caregiver
+func (s *ShiftBoardService) ApproveOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	s.logger.WithField("open_shift_id", id).Info("Approving open shift claim")
+
+	shift, err := s.loadOpenShift(id, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := s.openShiftStates.Fire(shift, OpenShiftEventApprove)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.getSchedule(shift.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	original := *shift
+	shift.ReviewNotes = req.Notes
+	if err := s.fill(shift, original, schedule, transition); err != nil {
+		return nil, err
+	}
+	if err := s.openShiftStates.Complete(shift, transition); err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", id).Warn("Open shift transition hook failed")
+	}
+
+	shift.Schedule = schedule
+	s.logger.WithField("open_shift_id", id).Info("Successfully approved open shift claim")
+	return shift, nil
+}
+
+// RejectOpenShift rejects a pending claim and puts the shift back on the board
+func (s *ShiftBoardService) RejectOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	return s.reviewOpenShift(id, OpenShiftEventReject, req)
+}
+
+// CancelOpenShift takes a shift off the board; the releasing caregiver keeps the schedule
+func (s *ShiftBoardService) CancelOpenShift(id int, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	return s.reviewOpenShift(id, OpenShiftEventCancel, req)
+}
+
+// reviewOpenShift applies an event that does not move the schedule to an open shift
+func (s *ShiftBoardService) reviewOpenShift(id int, event string, req *models.ShiftReviewRequest) (*models.OpenShift, error) {
+	s.logger.WithFields(logrus.Fields{
+		"open_shift_id": id,
+		"event":         event,
+	}).Info("Reviewing open shift")
+
+	shift, err := s.loadOpenShift(id, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := s.openShiftStates.Fire(shift, event)
+	if err != nil {
+		return nil, err
+	}
+
+	shift.Status = transition.To
+	shift.ReviewNotes = req.Notes
+	if event == OpenShiftEventReject {
+		shift.ClaimedBy = nil
+		shift.ClaimedAt = nil
+	}
+	if err := s.openShiftRepo.Update(shift); err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", id).Error("Failed to update open shift")
+		return nil, fmt.Errorf("failed to update open shift: %w", err)
+	}
+	if err := s.openShiftStates.Complete(shift, transition); err != nil {
+		s.logger.WithError(err).WithField("open_shift_id", id).Warn("Open shift transition hook failed")
+	}
+
+	return shift, nil
+}
+
+// RequestSwap asks another caregiver to take over a schedule led by the
+// requester, optionally in exchange for a schedule the target caregiver
+// leads. Both caregivers must be able to take the other's schedule.
+func (s *ShiftBoardService) RequestSwap(req *models.SwapRequestCreateRequest) (*models.SwapRequest, error) {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":         req.ScheduleID,
+		"target_caregiver_id": req.TargetCaregiverID,
+	}).Info("Requesting shift swap")
+
+	if req.ScheduleID <= 0 || req.TargetCaregiverID <= 0 {
+		return nil, fmt.Errorf("%w: schedule_id and target_caregiver_id are required", ErrValidation)
+	}
+
+	schedule, err := s.getSchedule(req.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.CaregiverID == req.TargetCaregiverID {
+		return nil, fmt.Errorf("%w: caregiver %d already leads schedule %d", ErrValidation, req.TargetCaregiverID, schedule.ID)
+	}
+	if err := s.requireMovable(schedule); err != nil {
+		return nil, err
+	}
+	if err := s.schedules.CheckAssignment(schedule, req.TargetCaregiverID); err != nil {
+		return nil, err
+	}
+
+	if req.TargetScheduleID != nil {
+		target, err := s.targetSchedule(*req.TargetScheduleID, req.TargetCaregiverID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.requireMovable(target); err != nil {
+			return nil, err
+		}
+		if err := s.schedules.CheckAssignment(target, schedule.CaregiverID); err != nil {
+			return nil, err
+		}
+	}
+
+	request := &models.SwapRequest{
+		ScheduleID:        schedule.ID,
+		RequesterID:       schedule.CaregiverID,
+		TargetCaregiverID: req.TargetCaregiverID,
+		TargetScheduleID:  req.TargetScheduleID,
+		Notes:             req.Notes,
+		Status:            models.SwapStatusPending,
+	}
+	if err := s.swapRepo.Create(request); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", schedule.ID).Error("Failed to create swap request")
+		return nil, fmt.Errorf("failed to create swap request: %w", err)
+	}
+
+	transition := Transition{Event: SwapEventRequest, To: request.Status, At: s.now()}
+	if err := s.swapStates.Complete(request, transition); err != nil {
+		s.logger.WithError(err).WithField("swap_request_id", request.ID).Warn("Swap request transition hook failed")
+	}
+
+	s.logger.WithField("swap_request_id", request.ID).Info("Successfully requested shift swap")
+	return request, nil
+}
+
+// targetSchedule loads the schedule offered in exchange, which the target
+// caregiver must lead
+func (s *ShiftBoardService) targetSchedule(scheduleID, caregiverID int) (*models.Schedule, error) {
+	target, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: target schedule %d does not exist", ErrValidation, scheduleID)
+	}
+	if target.CaregiverID != caregiverID {
+		return nil, fmt.Errorf("%w: schedule %d is not led by caregiver %d", ErrValidation, scheduleID, caregiverID)
+	}
+	return target, nil
+}
+
+// GetSwapRequests lists the swap requests a caregiver made or received, latest first
+func (s *ShiftBoardService) GetSwapRequests(caregiverID int) ([]models.SwapRequest, error) {
+	requests, err := s.swapRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get swap requests")
+		return nil, fmt.Errorf("failed to get swap requests: %w", err)
+	}
+	if requests == nil {
+		requests = []models.SwapRequest{}
+	}
+
+	return requests, nil
+}
+
+// GetSwapRequest retrieves a swap request with its history
+func (s *ShiftBoardService) GetSwapRequest(id int) (*models.SwapRequest, error) {
+	request, err := s.loadSwapRequest(id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if request.History, err = s.transitionRepo.GetByEntity(models.ShiftEntitySwapRequest, id); err != nil {
+		return nil, fmt.Errorf("failed to get swap request history: %w", err)
+	}
+	return request, nil
+}
+
+// loadSwapRequest loads a swap request, checking the expected version
+func (s *ShiftBoardService) loadSwapRequest(id int, expectedVersion *int) (*models.SwapRequest, error) {
+	request, err := s.swapRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get swap request: %w", err)
+	}
+	if request == nil {
+		return nil, fmt.Errorf("swap request %d: %w", id, ErrNotFound)
+	}
+	if expectedVersion != nil && *expectedVersion != request.Version {
+		return nil, fmt.Errorf("swap request %d is at version %d: %w", id, request.Version, ErrVersionConflict)
+	}
+	return request, nil
+}
+
+// AcceptSwap accepts a swap request on behalf of the target caregiver and
+// reassigns the schedules. Both reassignments are checked before either takes
+// effect; since each caregiver is still booked on their own schedule while
+// the other is checked, schedules that overlap in time cannot be exchanged.
+func (s *ShiftBoardService) AcceptSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	s.logger.WithField("swap_request_id", id).Info("Accepting shift swap")
+
+	request, err := s.loadSwapRequest(id, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := s.swapStates.Fire(request, SwapEventAccept)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.getSchedule(request.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.CaregiverID != request.RequesterID {
+		return nil, fmt.Errorf("%w: schedule %d is no longer led by caregiver %d", ErrInvalidTransition, schedule.ID, request.RequesterID)
+	}
+	if err := s.schedules.CheckAssignment(schedule, request.TargetCaregiverID); err != nil {
+		return nil, err
+	}
+
+	var target *models.Schedule
+	if request.TargetScheduleID != nil {
+		if target, err = s.targetSchedule(*request.TargetScheduleID, request.TargetCaregiverID); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+		}
+		if err := s.schedules.CheckAssignment(target, request.RequesterID); err != nil {
+			return nil, err
+		}
+	}
+
+	previous := *request
+	request.Status = transition.To
+	request.ResponseNotes = req.Notes
+	request.RespondedAt = &transition.At
+	if err := s.swapRepo.Update(request); err != nil {
+		s.logger.WithError(err).WithField("swap_request_id", id).Error("Failed to accept swap request")
+		return nil, fmt.Errorf("failed to accept swap request: %w", err)
+	}
+
+	if err := s.swapSchedules(request, schedule, target); err != nil {
+		previous.Version = request.Version
+		if rerr := s.swapRepo.Update(&previous); rerr != nil {
+			s.logger.WithError(rerr).WithField("swap_request_id", id).Error("Failed to revert swap request")
+		}
+		return nil, err
+	}
+
+	if err := s.swapStates.Complete(request, transition); err != nil {
+		s.logger.WithError(err).WithField("swap_request_id", id).Warn("Swap request transition hook failed")
+	}
+
+	s.logger.WithField("swap_request_id", id).Info("Successfully accepted shift swap")
+	return request, nil
+}
+
+// swapSchedules hands the requester's schedule to the target caregiver and
+// the target's schedule, if any, to the requester. The first reassignment is
+// undone when the second fails.
+func (s *ShiftBoardService) swapSchedules(request *models.SwapRequest, schedule, target *models.Schedule) error {
+	version := schedule.Version
+	moved, err := s.schedules.ReassignSchedule(schedule.ID, &models.ScheduleReassignRequest{
+		CaregiverID:     request.TargetCaregiverID,
+		ExpectedVersion: &version,
+	})
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return nil
+	}
+
+	version = target.Version
+	if _, err := s.schedules.ReassignSchedule(target.ID, &models.ScheduleReassignRequest{
+		CaregiverID:     request.RequesterID,
+		ExpectedVersion: &version,
+	}); err != nil {
+		s.logger.WithError(err).WithField("swap_request_id", request.ID).Warn("Failed to reassign target schedule, reverting")
+		if _, rerr := s.schedules.ReassignSchedule(moved.ID, &models.ScheduleReassignRequest{CaregiverID: request.RequesterID}); rerr != nil {
+			s.logger.WithError(rerr).WithField("schedule_id", moved.ID).Error("Failed to revert schedule reassignment")
+		}
+		return err
+	}
+	return nil
+}
+
+// RejectSwap declines a swap request on behalf of the target caregiver
+func (s *ShiftBoardService) RejectSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	return s.respondToSwap(id, SwapEventReject, req)
+}
+
+// CancelSwap withdraws a swap request on behalf of the requester
+func (s *ShiftBoardService) CancelSwap(id int, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	return s.respondToSwap(id, SwapEventCancel, req)
+}
+
+// respondToSwap applies an event that does not move any schedule to a swap request
+func (s *ShiftBoardService) respondToSwap(id int, event string, req *models.ShiftReviewRequest) (*models.SwapRequest, error) {
+	s.logger.WithFields(logrus.Fields{
+		"swap_request_id": id,
+		"event":           event,
+	}).Info("Responding to swap request")
+
+	request, err := s.loadSwapRequest(id, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := s.swapStates.Fire(request, event)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = transition.To
+	request.ResponseNotes = req.Notes
+	request.RespondedAt = &transition.At
+	if err := s.swapRepo.Update(request); err != nil {
+		s.logger.WithError(err).WithField("swap_request_id", id).Error("Failed to update swap request")
+		return nil, fmt.Errorf("failed to update swap request: %w", err)
+	}
+	if err := s.swapStates.Complete(request, transition); err != nil {
+		s.logger.WithError(err).WithField("swap_request_id", id).Warn("Swap request transition hook failed")
+	}
+
+	return request, nil
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOpenShiftRepository is a mock implementation of OpenShiftRepository
+type MockOpenShiftRepository struct {
+	mock.Mock
+}
+
+func (m *MockOpenShiftRepository) GetByID(id int) (*models.OpenShift, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
+
+func (m *MockOpenShiftRepository) GetByStatuses(statuses []string) ([]models.OpenShift, error) {
+	args := m.Called(statuses)
+	return args.Get(0).([]models.OpenShift), args.Error(1)
+}
+
+func (m *MockOpenShiftRepository) GetActiveByScheduleID(scheduleID int) (*models.OpenShift, error) {
+	args := m.Called(scheduleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OpenShift), args.Error(1)
+}
+
+func (m *MockOpenShiftRepository) Create(shift *models.OpenShift) error {
+	args := m.Called(shift)
+	return args.Error(0)
+}
+
+func (m *MockOpenShiftRepository) Update(shift *models.OpenShift) error {
+	args := m.Called(shift)
+	return args.Error(0)
+}
+
+// MockSwapRequestRepository is a mock implementation of SwapRequestRepository
+type MockSwapRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockSwapRequestRepository) GetByID(id int) (*models.SwapRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapRequest), args.Error(1)
+}
+
+func (m *MockSwapRequestRepository) GetByCaregiverID(caregiverID int) ([]models.SwapRequest, error) {
+	args := m.Called(caregiverID)
+	return args.Get(0).([]models.SwapRequest), args.Error(1)
+}
+
+func (m *MockSwapRequestRepository) GetPendingByScheduleID(scheduleID int) ([]models.SwapRequest, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.SwapRequest), args.Error(1)
+}
+
+func (m *MockSwapRequestRepository) Create(request *models.SwapRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockSwapRequestRepository) Update(request *models.SwapRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+// MockShiftTransitionRepository is a mock implementation of ShiftTransitionRepository
+type MockShiftTransitionRepository struct {
+	mock.Mock
+}
+
+func (m *MockShiftTransitionRepository) GetByEntity(entity string, entityID int) ([]models.ShiftTransition, error) {
+	args := m.Called(entity, entityID)
+	return args.Get(0).([]models.ShiftTransition), args.Error(1)
+}
+
+func (m *MockShiftTransitionRepository) Create(transition *models.ShiftTransition) error {
+	args := m.Called(transition)
+	return args.Error(0)
+}
+
+// shiftBoardTestMocks holds the repositories behind a shift board service under test
+type shiftBoardTestMocks struct {
+	*teamTestMocks
+	openShiftRepo  *MockOpenShiftRepository
+	swapRepo       *MockSwapRequestRepository
+	transitionRepo *MockShiftTransitionRepository
+	transitions    []models.ShiftTransition
+}
+
+func newShiftBoardTestService() (*ShiftBoardService, *ScheduleService, *shiftBoardTestMocks) {
+	schedules, team := newTeamTestService()
+	m := &shiftBoardTestMocks{
+		teamTestMocks:  team,
+		openShiftRepo:  new(MockOpenShiftRepository),
+		swapRepo:       new(MockSwapRequestRepository),
+		transitionRepo: new(MockShiftTransitionRepository),
+	}
+	m.transitionRepo.On("Create", mock.AnythingOfType("*models.ShiftTransition")).Run(func(args mock.Arguments) {
+		m.transitions = append(m.transitions, *args.Get(0).(*models.ShiftTransition))
+	}).Return(nil)
+
+	service := NewShiftBoardService(m.openShiftRepo, m.swapRepo, m.transitionRepo, m.scheduleRepo, schedules, logrus.New())
+	service.now = func() time.Time { return time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC) }
+	return service, schedules, m
+}
+
+// events lists the recorded transitions as event:from>to
+func (m *shiftBoardTestMocks) events() []string {
+	var events []string
+	for _, t := range m.transitions {
+		events = append(events, fmt.Sprintf("%s:%s>%s", t.Event, t.FromStatus, t.ToStatus))
+	}
+	return events
+}
+
+// expectReassign stubs the repository calls of reassigning a schedule led by previous
+func (m *shiftBoardTestMocks) expectReassign(scheduleID, previous int) {
+	m.taskRepo.On("GetByScheduleID", scheduleID).Return([]models.Task{}, nil)
+	m.caregiverRepo.On("Remove", scheduleID, previous).Return(nil)
+}
+
+// bumpVersion simulates a versioned update
+func bumpVersion(args mock.Arguments) {
+	switch v := args.Get(0).(type) {
+	case *models.OpenShift:
+		v.Version++
+	case *models.SwapRequest:
+		v.Version++
+	case *models.Schedule:
+		v.Version++
+	}
+}
+
+func newScheduledVisit(id, caregiverID int) *models.Schedule {
+	start := time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC)
+	return &models.Schedule{ID: id, ClientID: 101, CaregiverID: caregiverID, Status: models.ScheduleStatusScheduled,
+		StartTime: start, EndTime: start.Add(2 * time.Hour), Version: 1}
+}
+
+func TestShiftBoardService_ReleaseSchedule(t *testing.T) {
+	service, _, m := newShiftBoardTestService()
+	m.scheduleRepo.On("GetByID", 1).Return(newScheduledVisit(1, 1), nil)
+	m.scheduleRepo.On("GetByID", 2).Return(&models.Schedule{ID: 2, Status: models.ScheduleStatusInProgress}, nil)
+	m.scheduleRepo.On("GetByID", 3).Return(newScheduledVisit(3, 1), nil)
+	m.openShiftRepo.On("GetActiveByScheduleID", 1).Return(nil, nil)
+	m.openShiftRepo.On("GetActiveByScheduleID", 3).Return(&models.OpenShift{ID: 7, ScheduleID: 3}, nil)
+	m.swapRepo.On("GetPendingByScheduleID", 1).Return([]models.SwapRequest{}, nil)
+	m.openShiftRepo.On("Create", mock.AnythingOfType("*models.OpenShift")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.OpenShift).ID = 5
+	}).Return(nil)
+
+	_, err := service.ReleaseSchedule(1, &models.ScheduleReleaseRequest{ClaimMode: "auction"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.ReleaseSchedule(2, &models.ScheduleReleaseRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = service.ReleaseSchedule(3, &models.ScheduleReleaseRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	shift, err := service.ReleaseSchedule(1, &models.ScheduleReleaseRequest{Reason: "family emergency"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ClaimModeFirstCome, shift.ClaimMode)
+	assert.Equal(t, models.OpenShiftStatusOpen, shift.Status)
+	assert.Equal(t, 1, shift.ReleasedBy)
+	assert.Equal(t, []string{"release:>open"}, m.events())
+	assert.Equal(t, "family emergency", m.transitions[0].Notes)
+}
+
+func TestShiftBoardService_ClaimOpenShift_FirstCome(t *testing.T) {
+	service, schedules, m := newShiftBoardTestService()
+	schedule := newScheduledVisit(1, 1)
+	shift := &models.OpenShift{ID: 5, ScheduleID: 1, ReleasedBy: 1, ClaimMode: models.ClaimModeFirstCome,
+		Status: models.OpenShiftStatusOpen, Version: 1}
+
+	m.openShiftRepo.On("GetByID", 5).Return(shift, nil)
+	m.openShiftRepo.On("Update", shift).Run(bumpVersion).Return(nil)
+	m.scheduleRepo.On("GetByID", 1).Return(schedule, nil)
+	m.scheduleRepo.On("Update", schedule).Run(bumpVersion).Return(nil)
+	m.expectReassign(1, 1)
+
+	// Caregiver 3 is not available, so only caregiver 2 can claim
+	schedules.OnAssign(func(_ *models.Schedule, caregiverID int) error {
+		if caregiverID == 3 {
+			return fmt.Errorf("%w: booked elsewhere", ErrCaregiverUnavailable)
+		}
+		return nil
+	})
+
+	_, err := service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 1})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 3})
+	assert.ErrorIs(t, err, ErrCaregiverUnavailable)
+	m.openShiftRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	stale := 4
+	_, err = service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 2, ExpectedVersion: &stale})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	claimed, err := service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, models.OpenShiftStatusFilled, claimed.Status)
+	assert.Equal(t, 2, *claimed.ClaimedBy)
+	assert.Equal(t, 2, claimed.Schedule.CaregiverID)
+	assert.Equal(t, []string{"claim:open>claimed", "approve:claimed>filled"}, m.events())
+	assert.Equal(t, 2, *m.transitions[0].CaregiverID)
+
+	_, err = service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 4})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestShiftBoardService_ClaimOpenShift_RevertsWhenReassignFails(t *testing.T) {
+	service, _, m := newShiftBoardTestService()
+	schedule := newScheduledVisit(1, 1)
+	shift := &models.OpenShift{ID: 5, ScheduleID: 1, ReleasedBy: 1, ClaimMode: models.ClaimModeFirstCome,
+		Status: models.OpenShiftStatusOpen, Version: 1}
+
+	var saved []string
+	m.openShiftRepo.On("GetByID", 5).Return(shift, nil)
+	m.openShiftRepo.On("Update", mock.AnythingOfType("*models.OpenShift")).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(0).(*models.OpenShift).Status)
+		bumpVersion(args)
+	}).Return(nil)
+	m.scheduleRepo.On("GetByID", 1).Return(schedule, nil)
+	m.scheduleRepo.On("Update", schedule).Return(ErrVersionConflict)
+
+	_, err := service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 2})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, []string{models.OpenShiftStatusFilled, models.OpenShiftStatusOpen}, saved)
+	assert.Nil(t, shift.ClaimedBy)
+	assert.Empty(t, m.transitions)
+}
+
+func TestShiftBoardService_ApprovalFlow(t *testing.T) {
+	service, _, m := newShiftBoardTestService()
+	schedule := newScheduledVisit(1, 1)
+	shift := &models.OpenShift{ID: 5, ScheduleID: 1, ReleasedBy: 1, ClaimMode: models.ClaimModeApproval,
+		Status: models.OpenShiftStatusOpen, Version: 1}
+
+	m.openShiftRepo.On("GetByID", 5).Return(shift, nil)
+	m.openShiftRepo.On("Update", shift).Run(bumpVersion).Return(nil)
+	m.scheduleRepo.On("GetByID", 1).Return(schedule, nil)
+	m.scheduleRepo.On("Update", schedule).Run(bumpVersion).Return(nil)
+	m.expectReassign(1, 1)
+
+	claimed, err := service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, models.OpenShiftStatusClaimed, claimed.Status)
+	assert.Equal(t, 1, schedule.CaregiverID)
+
+	rejected, err := service.RejectOpenShift(5, &models.ShiftReviewRequest{Notes: "needs continuity"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.OpenShiftStatusOpen, rejected.Status)
+	assert.Nil(t, rejected.ClaimedBy)
+
+	_, err = service.ApproveOpenShift(5, &models.ShiftReviewRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = service.ClaimOpenShift(5, &models.OpenShiftClaimRequest{CaregiverID: 3})
+	assert.NoError(t, err)
+	approved, err := service.ApproveOpenShift(5, &models.ShiftReviewRequest{Notes: "ok"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.OpenShiftStatusFilled, approved.Status)
+	assert.Equal(t, 3, schedule.CaregiverID)
+	assert.Equal(t, []string{"claim:open>claimed", "reject:claimed>open", "claim:open>claimed", "approve:claimed>filled"}, m.events())
+
+	_, err = service.CancelOpenShift(5, &models.ShiftReviewRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestShiftBoardService_GetOpenShifts_ForCaregiver(t *testing.T) {
+	service, schedules, m := newShiftBoardTestService()
+	m.openShiftRepo.On("GetByStatuses", []string{models.OpenShiftStatusOpen, models.OpenShiftStatusClaimed}).Return([]models.OpenShift{
+		{ID: 5, ScheduleID: 1, ReleasedBy: 1, Status: models.OpenShiftStatusOpen},
+		{ID: 6, ScheduleID: 2, ReleasedBy: 2, Status: models.OpenShiftStatusOpen},
+		{ID: 7, ScheduleID: 3, ReleasedBy: 1, Status: models.OpenShiftStatusOpen},
+		{ID: 8, ScheduleID: 4, ReleasedBy: 1, Status: models.OpenShiftStatusClaimed},
+	}, nil)
+	for id := 1; id <= 4; id++ {
+		m.scheduleRepo.On("GetByID", id).Return(newScheduledVisit(id, 1), nil)
+	}
+	schedules.OnAssign(func(schedule *models.Schedule, _ int) error {
+		if schedule.ID == 3 {
+			return fmt.Errorf("%w: missing wound_care", ErrCaregiverUnqualified)
+		}
+		return nil
+	})
+
+	_, err := service.GetOpenShifts(&models.OpenShiftFilter{Statuses: []string{"taken"}})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	all, err := service.GetOpenShifts(&models.OpenShiftFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	// Caregiver 2 released shift 6, is not qualified for shift 7 and shift 8 is already claimed
+	caregiverID := 2
+	eligible, err := service.GetOpenShifts(&models.OpenShiftFilter{CaregiverID: &caregiverID})
+	assert.NoError(t, err)
+	if assert.Len(t, eligible, 1) {
+		assert.Equal(t, 5, eligible[0].ID)
+	}
+}
+
+func TestShiftBoardService_RequestSwap(t *testing.T) {
+	service, _, m := newShiftBoardTestService()
+	m.scheduleRepo.On("GetByID", 1).Return(newScheduledVisit(1, 1), nil)
+	m.scheduleRepo.On("GetByID", 2).Return(newScheduledVisit(2, 2), nil)
+	m.scheduleRepo.On("GetByID", 3).Return(newScheduledVisit(3, 3), nil)
+	m.openShiftRepo.On("GetActiveByScheduleID", mock.Anything).Return(nil, nil)
+	m.swapRepo.On("GetPendingByScheduleID", mock.Anything).Return([]models.SwapRequest{}, nil)
+	m.swapRepo.On("Create", mock.AnythingOfType("*models.SwapRequest")).Return(nil)
+
+	_, err := service.RequestSwap(&models.SwapRequestCreateRequest{ScheduleID: 1, TargetCaregiverID: 1})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Schedule 3 is led by caregiver 3, not the target
+	targetScheduleID := 3
+	_, err = service.RequestSwap(&models.SwapRequestCreateRequest{ScheduleID: 1, TargetCaregiverID: 2, TargetScheduleID: &targetScheduleID})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	targetScheduleID = 2
+	request, err := service.RequestSwap(&models.SwapRequestCreateRequest{ScheduleID: 1, TargetCaregiverID: 2,
+		TargetScheduleID: &targetScheduleID, Notes: "doctor's appointment"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, request.RequesterID)
+	assert.Equal(t, models.SwapStatusPending, request.Status)
+	assert.Equal(t, []string{"request:>pending"}, m.events())
+}
+
+func TestShiftBoardService_AcceptSwap(t *testing.T) {
+	service, _, m := newShiftBoardTestService()
+	mine, theirs := newScheduledVisit(1, 1), newScheduledVisit(2, 2)
+	targetScheduleID := 2
+	request := &models.SwapRequest{ID: 9, ScheduleID: 1, RequesterID: 1, TargetCaregiverID: 2,
+		TargetScheduleID: &targetScheduleID, Status: models.SwapStatusPending, Version: 1}
+
+	m.swapRepo.On("GetByID", 9).Return(request, nil)
+	m.swapRepo.On("Update", request).Run(bumpVersion).Return(nil)
+	m.scheduleRepo.On("GetByID", 1).Return(mine, nil)
+	m.scheduleRepo.On("GetByID", 2).Return(theirs, nil)
+	m.scheduleRepo.On("Update", mock.AnythingOfType("*models.Schedule")).Run(bumpVersion).Return(nil)
+	m.expectReassign(1, 1)
+	m.expectReassign(2, 2)
+
+	accepted, err := service.AcceptSwap(9, &models.ShiftReviewRequest{Notes: "happy to"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.SwapStatusAccepted, accepted.Status)
+	assert.NotNil(t, accepted.RespondedAt)
+	assert.Equal(t, 2, mine.CaregiverID)
+	assert.Equal(t, 1, theirs.CaregiverID)
+	assert.Equal(t, []string{"accept:pending>accepted"}, m.events())
+	assert.Equal(t, 2, *m.transitions[0].CaregiverID)
+
+	_, err = service.CancelSwap(9, &models.ShiftReviewRequest{})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestShiftBoardService_AcceptSwap_RevertsWhenSecondReassignFails(t *testing.T) {
+	service, _, m := newShiftBoardTestService()
+	mine, theirs := newScheduledVisit(1, 1), newScheduledVisit(2, 2)
+	targetScheduleID := 2
+	request := &models.SwapRequest{ID: 9, ScheduleID: 1, RequesterID: 1, TargetCaregiverID: 2,
+		TargetScheduleID: &targetScheduleID, Status: models.SwapStatusPending, Version: 1}
+
+	var saved []string
+	m.swapRepo.On("GetByID", 9).Return(request, nil)
+	m.swapRepo.On("Update", mock.AnythingOfType("*models.SwapRequest")).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(0).(*models.SwapRequest).Status)
+		bumpVersion(args)
+	}).Return(nil)
+	m.scheduleRepo.On("GetByID", 1).Return(mine, nil)
+	m.scheduleRepo.On("GetByID", 2).Return(theirs, nil)
+	m.scheduleRepo.On("Update", mine).Run(bumpVersion).Return(nil)
+	m.scheduleRepo.On("Update", theirs).Return(ErrVersionConflict)
+	m.expectReassign(1, 1)
+	m.expectReassign(1, 2)
+
+	_, err := service.AcceptSwap(9, &models.ShiftReviewRequest{})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, 1, mine.CaregiverID)
+	assert.Equal(t, []string{models.SwapStatusAccepted, models.SwapStatusPending}, saved)
+	assert.Empty(t, m.transitions)
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dateLayout is the calendar date format used for timesheet days
+const dateLayout = "2006-01-02"
+
+// maxTimesheetDays bounds the date range of a single timesheet request
+const maxTimesheetDays = 62
+
+// MileageSource reports the paid mileage a caregiver drove between visits on
+// each day from and to (YYYY-MM-DD, inclusive), keyed by date
+type MileageSource interface {
+	DailyMileage(caregiverID int, from, to string) (map[string]float64, error)
+}
+
+// UseMileage sets where timesheets take the mileage driven between visits from
+func (s *ScheduleService) UseMileage(source MileageSource) {
+	s.mileage = source
+}
+
+// dayPortion is the part of an interval that falls on one local calendar day
+type dayPortion struct {
+	date  string
+	start time.Time
+	end   time.Time
+}
+
+// splitByDay cuts [start, end) at each local midnight in loc
+func splitByDay(start, end time.Time, loc *time.Location) []dayPortion {
+	var portions []dayPortion
+	for cur := start; cur.Before(end); {
+		_, next := dayBounds(cur, loc)
+		if next.After(end) {
+			next = end
+		}
+		portions = append(portions, dayPortion{
+			date:  cur.In(loc).Format(dateLayout),
+			start: cur,
+			end:   next,
+		})
+		cur = next
+	}
+	return portions
+}
+
+// overlap returns how long [aStart, aEnd) and [bStart, bEnd) overlap
+func overlap(aStart, aEnd, bStart, bEnd time.Time) time.Duration {
+	start, end := aStart, aEnd
+	if bStart.After(start) {
+		start = bStart
+	}
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// coveredDuration returns how much of [start, end) the segments cover,
+// counting time covered by several overlapping segments once
+func coveredDuration(start, end time.Time, segments []models.ScheduleSegment) time.Duration {
+	type span struct{ start, end time.Time }
+	var spans []span
+	for _, seg := range segments {
+		if overlap(start, end, seg.StartTime, seg.EndTime) == 0 {
+			continue
+		}
+		clipped := span{start: seg.StartTime, end: seg.EndTime}
+		if clipped.start.Before(start) {
+			clipped.start = start
+		}
+		if clipped.end.After(end) {
+			clipped.end = end
+		}
+		spans = append(spans, clipped)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+
+	var covered time.Duration
+	var cur span
+	for i, sp := range spans {
+		if i > 0 && !sp.start.After(cur.end) {
+			if sp.end.After(cur.end) {
+				cur.end = sp.end
+			}
+			continue
+		}
+		covered += cur.end.Sub(cur.start)
+		cur = sp
+	}
+	return covered + cur.end.Sub(cur.start)
+}
+
+// roundHours converts a duration to hours rounded to two decimals
+func roundHours(d time.Duration) float64 {
+	return round2(d.Hours())
+}
+
+// round2 rounds hours to two decimals so that sums do not drift
+func round2(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
+
+// shiftHours splits the time worked between start and end into hours per
+// calendar day in loc, less any sleep and break segments. Time covered by
+// overlapping segments is excluded once, counting as sleep when a break
+// overlaps sleep.
+func shiftHours(scheduleID int, start, end time.Time, segments []models.ScheduleSegment, loc *time.Location) []models.DayHours {
+	var sleepSegments, excludedSegments []models.ScheduleSegment
+	for _, seg := range segments {
+		switch seg.Type {
+		case models.SegmentTypeSleep:
+			sleepSegments = append(sleepSegments, seg)
+			excludedSegments = append(excludedSegments, seg)
+		case models.SegmentTypeBreak:
+			excludedSegments = append(excludedSegments, seg)
+		}
+	}
+
+	var days []models.DayHours
+	for _, portion := range splitByDay(start, end, loc) {
+		sleep := coveredDuration(portion.start, portion.end, sleepSegments)
+		breaks := coveredDuration(portion.start, portion.end, excludedSegments) - sleep
+
+		total := portion.end.Sub(portion.start)
+		days = append(days, models.DayHours{
+			Date:        portion.date,
+			ScheduleID:  scheduleID,
+			TotalHours:  roundHours(total),
+			SleepHours:  roundHours(sleep),
+			BreakHours:  roundHours(breaks),
+			WorkedHours: roundHours(total - sleep - breaks),
+		})
+	}
+	return days
+}
+
+// workedInterval returns the period a schedule counts for on a caregiver's
+// timesheet: their clock-in and clock-out once their visit has started,
+// otherwise the scheduled times. Missed and cancelled schedules do not count,
+// nor do completed ones the caregiver never clocked in to.
+func workedInterval(schedule *models.Schedule, visit *models.Visit, now time.Time) (time.Time, time.Time, bool) {
+	started := visit != nil && visit.StartTime != nil
+	switch schedule.Status {
+	case models.ScheduleStatusMissed, models.ScheduleStatusCancelled:
+		return time.Time{}, time.Time{}, false
+	case models.ScheduleStatusCompleted:
+		if !started {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	start, end := schedule.StartTime, schedule.EndTime
+	if started {
+		start = *visit.StartTime
+		if visit.EndTime != nil {
+			end = *visit.EndTime
+		} else {
+			// Still clocked in
+			end = now
+		}
+	}
+
+	return start, end, end.After(start)
+}
+
+// parseDateRange parses an inclusive range of calendar dates (YYYY-MM-DD) of
+// at most maxTimesheetDays
+func parseDateRange(from, to string) (time.Time, time.Time, error) {
+	fromDate, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid from date %q, expected YYYY-MM-DD", ErrValidation, from)
+	}
+	toDate, err := time.Parse(dateLayout, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid to date %q, expected YYYY-MM-DD", ErrValidation, to)
+	}
+	if toDate.Before(fromDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to must not be before from", ErrValidation)
+	}
+	if toDate.Sub(fromDate) >= maxTimesheetDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: date range must not exceed %d days", ErrValidation, maxTimesheetDays)
+	}
+	return fromDate, toDate, nil
+}
+
+// GetTimesheet returns a caregiver's hours per calendar day between the from
+// and to dates (YYYY-MM-DD, inclusive). Shifts spanning midnight are split
+// across the days they cover in the client's timezone. The mileage driven
+// between visits is added when a mileage source is set.
+func (s *ScheduleService) GetTimesheet(caregiverID int, from, to string) (*models.Timesheet, error) {
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"from":         from,
+		"to":           to,
+	}).Debug("Getting timesheet")
+
+	fromDate, toDate, err := parseDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Widen the range so that days in every client timezone are covered
+	activeFrom := fromDate.Add(-dayWindow)
+	activeTo := toDate.Add(24*time.Hour + dayWindow)
+	schedules, _, err := s.scheduleRepo.GetAll(&models.ScheduleFilter{
+		CaregiverID: &caregiverID,
+		ActiveFrom:  &activeFrom,
+		ActiveTo:    &activeTo,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get schedules for timesheet")
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	now := s.now()
+	byDate := map[string]*models.TimesheetDay{}
+	for i := range schedules {
+		schedule := &schedules[i]
+		if err := s.enrichSchedule(schedule); err != nil {
+			s.logger.WithError(err).WithField("schedule_id", schedule.ID).Warn("Failed to enrich schedule")
+		}
+
+		visit := caregiverVisit(schedule, caregiverID)
+		start, end, ok := workedInterval(schedule, visit, now)
+		if !ok {
+			continue
+		}
+
+		loc := clientLocation(schedule.Client, s.location)
+		for _, hours := range shiftHours(schedule.ID, start, end, timesheetSegments(schedule, visit, now), loc) {
+			if hours.Date < from || hours.Date > to {
+				continue
+			}
+			hours.Amended = visit != nil && visit.AmendedAt != nil
+			day, ok := byDate[hours.Date]
+			if !ok {
+				day = &models.TimesheetDay{Date: hours.Date, Shifts: []models.DayHours{}}
+				byDate[hours.Date] = day
+			}
+			day.Shifts = append(day.Shifts, hours)
+		}
+	}
+
+	if s.mileage != nil {
+		mileage, err := s.mileage.DailyMileage(caregiverID, from, to)
+		if err != nil {
+			s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get mileage for timesheet")
+			return nil, fmt.Errorf("failed to get mileage: %w", err)
+		}
+		for date, km := range mileage {
+			day, ok := byDate[date]
+			if !ok {
+				day = &models.TimesheetDay{Date: date, Shifts: []models.DayHours{}}
+				byDate[date] = day
+			}
+			day.MileageKm = round2(km)
+		}
+	}
+
+	timesheet := &models.Timesheet{
+		CaregiverID: caregiverID,
+		From:        from,
+		To:          to,
+		Days:        []models.TimesheetDay{},
+	}
+	for _, day := range byDate {
+		for _, shift := range day.Shifts {
+			day.TotalHours += shift.TotalHours
+			day.SleepHours += shift.SleepHours
+			day.BreakHours += shift.BreakHours
+			day.WorkedHours += shift.WorkedHours
+			day.Amended = day.Amended || shift.Amended
+		}
+		day.TotalHours = round2(day.TotalHours)
+		day.SleepHours = round2(day.SleepHours)
+		day.BreakHours = round2(day.BreakHours)
+		day.WorkedHours = round2(day.WorkedHours)
+		timesheet.Days = append(timesheet.Days, *day)
+	}
+	sort.Slice(timesheet.Days, func(i, j int) bool {
+		return timesheet.Days[i].Date < timesheet.Days[j].Date
+	})
+
+	for _, day := range timesheet.Days {
+		timesheet.TotalHours += day.TotalHours
+		timesheet.SleepHours += day.SleepHours
+		timesheet.BreakHours += day.BreakHours
+		timesheet.WorkedHours += day.WorkedHours
+		timesheet.MileageKm += day.MileageKm
+	}
+	timesheet.TotalHours = round2(timesheet.TotalHours)
+	timesheet.SleepHours = round2(timesheet.SleepHours)
+	timesheet.BreakHours = round2(timesheet.BreakHours)
+	timesheet.WorkedHours = round2(timesheet.WorkedHours)
+	timesheet.MileageKm = round2(timesheet.MileageKm)
+
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"days":         len(timesheet.Days),
+	}).Debug("Successfully built timesheet")
+
+	return timesheet, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestShiftHours_OvernightWithSleep(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	// 20:00 to 08:00 the next morning with a sleep period from 23:00 to 06:00
+	start := time.Date(2025, 1, 1, 20, 0, 0, 0, jakarta)
+	end := time.Date(2025, 1, 2, 8, 0, 0, 0, jakarta)
+	segments := []models.ScheduleSegment{
+		{Type: models.SegmentTypeSleep, StartTime: time.Date(2025, 1, 1, 23, 0, 0, 0, jakarta), EndTime: time.Date(2025, 1, 2, 6, 0, 0, 0, jakarta)},
+		{Type: models.SegmentTypeBreak, StartTime: time.Date(2025, 1, 2, 7, 0, 0, 0, jakarta), EndTime: time.Date(2025, 1, 2, 7, 30, 0, 0, jakarta)},
+	}
+
+	days := shiftHours(7, start, end, segments, jakarta)
+
+	assert.Equal(t, []models.DayHours{
+		{Date: "2025-01-01", ScheduleID: 7, TotalHours: 4, SleepHours: 1, BreakHours: 0, WorkedHours: 3},
+		{Date: "2025-01-02", ScheduleID: 7, TotalHours: 8, SleepHours: 6, BreakHours: 0.5, WorkedHours: 1.5},
+	}, days)
+}
+
+func TestShiftHours_BreakDuringSleep(t *testing.T) {
+	// 22:00 to 07:00 with sleep from 23:00 to 05:00 and a recorded break from
+	// 04:00 to 05:30 that started while sleep was planned
+	start := time.Date(2025, 1, 1, 22, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC)
+	segments := []models.ScheduleSegment{
+		{Type: models.SegmentTypeSleep, StartTime: time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 2, 5, 0, 0, 0, time.UTC)},
+		{Type: models.SegmentTypeBreak, StartTime: time.Date(2025, 1, 2, 4, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 2, 5, 30, 0, 0, time.UTC)},
+		// A second break entirely inside sleep excludes nothing more
+		{Type: models.SegmentTypeBreak, StartTime: time.Date(2025, 1, 2, 1, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC)},
+	}
+
+	days := shiftHours(7, start, end, segments, time.UTC)
+
+	assert.Equal(t, []models.DayHours{
+		{Date: "2025-01-01", ScheduleID: 7, TotalHours: 2, SleepHours: 1, BreakHours: 0, WorkedHours: 1},
+		{Date: "2025-01-02", ScheduleID: 7, TotalHours: 7, SleepHours: 5, BreakHours: 0.5, WorkedHours: 1.5},
+	}, days)
+}
+
+func TestShiftHours_DSTNight(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	// Clocks fall back at 02:00 on November 2nd, so the night is an hour longer
+	start := time.Date(2025, 11, 1, 22, 0, 0, 0, newYork)
+	end := time.Date(2025, 11, 2, 6, 0, 0, 0, newYork)
+
+	days := shiftHours(1, start, end, nil, newYork)
+
+	assert.Len(t, days, 2)
+	assert.Equal(t, 2.0, days[0].TotalHours)
+	assert.Equal(t, 7.0, days[1].TotalHours)
+	assert.Equal(t, "2025-11-02", days[1].Date)
+}
+
+func TestShiftHours_MultiDay(t *testing.T) {
+	// Live-in shift from Monday 09:00 to Wednesday 09:00
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 6, 4, 9, 0, 0, 0, time.UTC)
+
+	days := shiftHours(1, start, end, nil, time.UTC)
+
+	var dates []string
+	var hours []float64
+	for _, day := range days {
+		dates = append(dates, day.Date)
+		hours = append(hours, day.TotalHours)
+	}
+	assert.Equal(t, []string{"2025-06-02", "2025-06-03", "2025-06-04"}, dates)
+	assert.Equal(t, []float64{15, 24, 9}, hours)
+}
+
+func TestScheduleService_GetTimesheet(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockTaskRepo := new(MockTaskRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	mockCaregiverRepo := new(MockScheduleCaregiverRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         mockTaskRepo,
+		Segments:      mockSegmentRepo,
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    mockCaregiverRepo,
+	}, nil, logger)
+	service.now = func() time.Time { return time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC) }
+
+	clockIn := time.Date(2025, 1, 1, 22, 0, 0, 0, time.UTC)
+	clockOut := time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC)
+	schedules := []models.Schedule{
+		// Overnight shift, actually worked 22:00 to 07:00
+		{ID: 1, Status: "completed", StartTime: time.Date(2025, 1, 1, 21, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC)},
+		// Missed shifts are not paid
+		{ID: 2, Status: "missed", StartTime: time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)},
+	}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetAll", mock.MatchedBy(func(f *models.ScheduleFilter) bool {
+		return *f.CaregiverID == 3 && f.ActiveFrom != nil && f.ActiveTo != nil
+	})).Return(schedules, &models.PageInfo{}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{{CaregiverID: 3, StartTime: &clockIn, EndTime: &clockOut}}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", 1).Return([]models.ScheduleCaregiver{{ScheduleID: 1, CaregiverID: 3, Role: models.CaregiverRoleLead}}, nil)
+	mockVisitRepo.On("GetAllByScheduleID", 2).Return([]models.Visit{}, nil)
+	mockCaregiverRepo.On("GetByScheduleID", 2).Return([]models.ScheduleCaregiver{}, nil)
+	mockTaskRepo.On("GetByScheduleID", mock.Anything).Return([]models.Task{}, nil)
+	mockSegmentRepo.On("GetByScheduleID", 1).Return([]models.ScheduleSegment{
+		{Type: models.SegmentTypeSleep, StartTime: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 2, 5, 0, 0, 0, time.UTC)},
+	}, nil)
+	mockSegmentRepo.On("GetByScheduleID", 2).Return([]models.ScheduleSegment{}, nil)
+	mockVisitSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{}, nil)
+
+	// Execute
+	timesheet, err := service.GetTimesheet(3, "2025-01-02", "2025-01-03")
+
+	// Assert: only the part of the night after midnight is on the requested days
+	assert.NoError(t, err)
+	assert.Len(t, timesheet.Days, 1)
+	assert.Equal(t, "2025-01-02", timesheet.Days[0].Date)
+	assert.Equal(t, 7.0, timesheet.TotalHours)
+	assert.Equal(t, 5.0, timesheet.SleepHours)
+	assert.Equal(t, 2.0, timesheet.WorkedHours)
+}
+
+func TestScheduleService_GetTimesheet_InvalidRange(t *testing.T) {
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     new(MockScheduleRepository),
+		Visits:        new(MockVisitRepository),
+		Tasks:         new(MockTaskRepository),
+		Segments:      new(MockScheduleSegmentRepository),
+		VisitSegments: new(MockVisitSegmentRepository),
+		Caregivers:    new(MockScheduleCaregiverRepository),
+	}, nil, logrus.New())
+
+	for _, r := range [][2]string{{"2025-01-02", "2025-01-01"}, {"01/02/2025", "2025-01-03"}, {"2025-01-01", "2025-06-01"}} {
+		_, err := service.GetTimesheet(1, r[0], r[1])
+		assert.ErrorIs(t, err, ErrValidation, r)
+	}
+}
+
+func TestScheduleService_AddScheduleSegment(t *testing.T) {
+	// Setup
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	logger := logrus.New()
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        new(MockVisitRepository),
+		Tasks:         new(MockTaskRepository),
+		Segments:      mockSegmentRepo,
+		VisitSegments: new(MockVisitSegmentRepository),
+		Caregivers:    new(MockScheduleCaregiverRepository),
+	}, nil, logger)
+
+	shiftStart := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	shiftEnd := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+	existing := models.ScheduleSegment{ID: 4, ScheduleID: 1, Type: models.SegmentTypeBreak, StartTime: shiftStart.Add(time.Hour), EndTime: shiftStart.Add(90 * time.Minute)}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, StartTime: shiftStart, EndTime: shiftEnd}, nil)
+	mockSegmentRepo.On("GetByScheduleID", 1).Return([]models.ScheduleSegment{existing}, nil)
+	mockSegmentRepo.On("Create", mock.AnythingOfType("*models.ScheduleSegment")).Return(nil)
+
+	// Rejected requests
+	invalid := []models.ScheduleSegmentRequest{
+		{Type: "nap", StartTime: shiftStart.Add(3 * time.Hour), EndTime: shiftStart.Add(4 * time.Hour)},
+		{Type: models.SegmentTypeSleep, StartTime: shiftStart.Add(4 * time.Hour), EndTime: shiftStart.Add(3 * time.Hour)},
+		{Type: models.SegmentTypeSleep, StartTime: shiftStart.Add(-time.Hour), EndTime: shiftStart.Add(time.Hour)},
+		{Type: models.SegmentTypeSleep, StartTime: shiftStart.Add(80 * time.Minute), EndTime: shiftStart.Add(3 * time.Hour)},
+	}
+	for _, req := range invalid {
+		_, err := service.AddScheduleSegment(1, &req)
+		assert.ErrorIs(t, err, ErrValidation, req)
+	}
+
+	// Accepted request
+	segment, err := service.AddScheduleSegment(1, &models.ScheduleSegmentRequest{
+		Type:      models.SegmentTypeSleep,
+		StartTime: shiftStart.Add(3 * time.Hour),
+		EndTime:   shiftEnd.Add(-2 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.SegmentTypeSleep, segment.Type)
+	mockSegmentRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestScheduleService_DeleteScheduleSegment_WrongSchedule(t *testing.T) {
+	mockSegmentRepo := new(MockScheduleSegmentRepository)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     new(MockScheduleRepository),
+		Visits:        new(MockVisitRepository),
+		Tasks:         new(MockTaskRepository),
+		Segments:      mockSegmentRepo,
+		VisitSegments: new(MockVisitSegmentRepository),
+		Caregivers:    new(MockScheduleCaregiverRepository),
+	}, nil, logrus.New())
+
+	mockSegmentRepo.On("GetByID", 4).Return(&models.ScheduleSegment{ID: 4, ScheduleID: 2}, nil)
+
+	err := service.DeleteScheduleSegment(1, 4)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	mockSegmentRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SkillService handles caregiver skills and certifications and the skills
+// service types require
+type SkillService struct {
+	skillRepo       repositories.SkillRepository
+	serviceTypeRepo repositories.ServiceTypeRepository
+	now             func() time.Time
+	logger          *logrus.Logger
+}
+
+// NewSkillService creates a new skill service
+func NewSkillService(skillRepo repositories.SkillRepository, serviceTypeRepo repositories.ServiceTypeRepository, logger *logrus.Logger) *SkillService {
+	return &SkillService{
+		skillRepo:       skillRepo,
+		serviceTypeRepo: serviceTypeRepo,
+		now:             time.Now,
+		logger:          logger,
+	}
+}
+
+// normalizeSkill turns a skill name into its stored form, e.g.
+// "Wound Care" into "wound_care"
+func normalizeSkill(skill string) string {
+	return strings.Join(strings.Fields(strings.ToLower(skill)), "_")
+}
+
+// GetCaregiverSkills lists a caregiver's skills and certifications
+func (s *SkillService) GetCaregiverSkills(caregiverID int) ([]models.CaregiverSkill, error) {
+	skills, err := s.skillRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to get caregiver skills")
+		return nil, fmt.Errorf("failed to get caregiver skills: %w", err)
+	}
+	if skills == nil {
+		skills = []models.CaregiverSkill{}
+	}
+
+	return skills, nil
+}
+
+// SetCaregiverSkill records a skill or certification for a caregiver, renewing
+// it when the caregiver already holds the skill
+func (s *SkillService) SetCaregiverSkill(caregiverID int, skill string, req *models.CaregiverSkillRequest) (*models.CaregiverSkill, error) {
+	skill = normalizeSkill(skill)
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"skill":        skill,
+		"expires_at":   req.ExpiresAt,
+	}).Info("Setting caregiver skill")
+
+	if caregiverID <= 0 {
+		return nil, fmt.Errorf("%w: invalid caregiver ID", ErrValidation)
+	}
+	if skill == "" {
+		return nil, fmt.Errorf("%w: skill is required", ErrValidation)
+	}
+	if req.IssuedAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.IssuedAt) {
+		return nil, fmt.Errorf("%w: expires_at must be after issued_at", ErrValidation)
+	}
+
+	caregiverSkill := &models.CaregiverSkill{
+		CaregiverID:         caregiverID,
+		Skill:               skill,
+		CertificationNumber: req.CertificationNumber,
+		IssuedAt:            req.IssuedAt,
+		ExpiresAt:           req.ExpiresAt,
+	}
+	if err := s.skillRepo.Upsert(caregiverSkill); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to set caregiver skill")
+		return nil, fmt.Errorf("failed to set caregiver skill: %w", err)
+	}
+
+	s.logger.WithField("caregiver_id", caregiverID).Info("Successfully set caregiver skill")
+	return caregiverSkill, nil
+}
+
+// DeleteCaregiverSkill removes a skill from a caregiver
+func (s *SkillService) DeleteCaregiverSkill(caregiverID int, skill string) error {
+	skill = normalizeSkill(skill)
+	s.logger.WithFields(logrus.Fields{
+		"caregiver_id": caregiverID,
+		"skill":        skill,
+	}).Info("Deleting caregiver skill")
+
+	skills, err := s.skillRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get caregiver skills: %w", err)
+	}
+	if findSkill(skills, skill) == nil {
+		return fmt.Errorf("skill %s of caregiver %d: %w", skill, caregiverID, ErrNotFound)
+	}
+
+	if err := s.skillRepo.Delete(caregiverID, skill); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to delete caregiver skill")
+		return fmt.Errorf("failed to delete caregiver skill: %w", err)
+	}
+
+	return nil
+}
+
+// GetServiceTypes lists all service types with their required skills
+func (s *SkillService) GetServiceTypes() ([]models.ServiceType, error) {
+	serviceTypes, err := s.serviceTypeRepo.GetAll()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get service types")
+		return nil, fmt.Errorf("failed to get service types: %w", err)
+	}
+	if serviceTypes == nil {
+		serviceTypes = []models.ServiceType{}
+	}
+
+	return serviceTypes, nil
+}
+
+// CreateServiceType creates a service type
+func (s *SkillService) CreateServiceType(req *models.ServiceTypeRequest) (*models.ServiceType, error) {
+	s.logger.WithField("name", req.Name).Info("Creating service type")
+
+	serviceType, err := s.buildServiceType(0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.serviceTypeRepo.Create(serviceType); err != nil {
+		s.logger.WithError(err).WithField("name", req.Name).Error("Failed to create service type")
+		return nil, fmt.Errorf("failed to create service type: %w", err)
+	}
+
+	s.logger.WithField("service_type_id", serviceType.ID).Info("Successfully created service type")
+	return serviceType, nil
+}
+
+// UpdateServiceType renames a service type and replaces its required skills
+func (s *SkillService) UpdateServiceType(id int, req *models.ServiceTypeRequest) (*models.ServiceType, error) {
+	s.logger.WithField("service_type_id", id).Info("Updating service type")
+
+	existing, err := s.serviceTypeRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service type: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("service type %d: %w", id, ErrNotFound)
+	}
+
+	serviceType, err := s.buildServiceType(id, req)
+	if err != nil {
+		return nil, err
+	}
+	serviceType.CreatedAt = existing.CreatedAt
+
+	if err := s.serviceTypeRepo.Update(serviceType); err != nil {
+		s.logger.WithError(err).WithField("service_type_id", id).Error("Failed to update service type")
+		return nil, fmt.Errorf("failed to update service type: %w", err)
+	}
+
+	s.logger.WithField("service_type_id", id).Info("Successfully updated service type")
+	return serviceType, nil
+}
+
+// buildServiceType validates a service type request; id is 0 for a new service type
+func (s *SkillService) buildServiceType(id int, req *models.ServiceTypeRequest) (*models.ServiceType, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+
+	// Names are unique regardless of case since schedules match them that way
+	other, err := s.serviceTypeRepo.GetByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service type: %w", err)
+	}
+	if other != nil && other.ID != id {
+		return nil, fmt.Errorf("%w: service type %q already exists", ErrValidation, other.Name)
+	}
+
+	skills := []string{}
+	seen := map[string]bool{}
+	for _, skill := range req.RequiredSkills {
+		skill = normalizeSkill(skill)
+		if skill == "" {
+			return nil, fmt.Errorf("%w: required skills must not be empty", ErrValidation)
+		}
+		if !seen[skill] {
+			seen[skill] = true
+			skills = append(skills, skill)
+		}
+	}
+	sort.Strings(skills)
+
+	// Reason codes are stored like skills and keep the order they are shown in
+	reasonCodes := []string{}
+	seen = map[string]bool{}
+	for _, code := range req.ReasonCodes {
+		code = normalizeSkill(code)
+		if code == "" || strings.Contains(code, ",") {
+			return nil, fmt.Errorf("%w: reason codes must not be empty or contain commas", ErrValidation)
+		}
+		if !seen[code] {
+			seen[code] = true
+			reasonCodes = append(reasonCodes, code)
+		}
+	}
+
+	return &models.ServiceType{
+		ID:             id,
+		Name:           name,
+		Description:    req.Description,
+		RequiredSkills: skills,
+		ReasonCodes:    reasonCodes,
+	}, nil
+}
+
+// RequiredSkills returns the skills needed to deliver a service. Services
+// without a matching service type require none.
+func (s *SkillService) RequiredSkills(serviceName string) ([]string, error) {
+	if strings.TrimSpace(serviceName) == "" {
+		return nil, nil
+	}
+
+	serviceType, err := s.serviceTypeRepo.GetByName(strings.TrimSpace(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service type: %w", err)
+	}
+	if serviceType == nil {
+		return nil, nil
+	}
+
+	return serviceType.RequiredSkills, nil
+}
+
+// ReasonCodes returns the accepted reasons for not completing a required task
+// of a service: those of its service type, or the defaults when the service
+// has no service type or the service type has none. It is registered with the
+// schedule service through UseReasonCodes.
+func (s *SkillService) ReasonCodes(serviceName string) ([]string, error) {
+	if strings.TrimSpace(serviceName) == "" {
+		return models.DefaultTaskReasonCodes, nil
+	}
+
+	serviceType, err := s.serviceTypeRepo.GetByName(strings.TrimSpace(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service type: %w", err)
+	}
+	if serviceType == nil || len(serviceType.ReasonCodes) == 0 {
+		return models.DefaultTaskReasonCodes, nil
+	}
+
+	return serviceType.ReasonCodes, nil
+}
+
+// MissingSkills returns the skills a service requires that the caregiver does
+// not hold, or holds with a certification that has expired by at
+func (s *SkillService) MissingSkills(caregiverID int, serviceName string, at time.Time) ([]string, error) {
+	required, err := s.RequiredSkills(serviceName)
+	if err != nil || len(required) == 0 {
+		return nil, err
+	}
+
+	skills, err := s.skillRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caregiver skills: %w", err)
+	}
+
+	var missing []string
+	for _, skill := range required {
+		held := findSkill(skills, skill)
+		if held == nil || !held.ValidAt(at) {
+			missing = append(missing, skill)
+		}
+	}
+
+	return missing, nil
+}
+
+// ValidateAssignment checks that a caregiver holds every skill the schedule's
+// service requires, certified until the end of the schedule. It is registered
+// with the schedule service through OnAssign.
+func (s *SkillService) ValidateAssignment(schedule *models.Schedule, caregiverID int) error {
+	missing, err := s.MissingSkills(caregiverID, schedule.ServiceName, schedule.EndTime)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: caregiver %d has no valid certification for %s required by %s",
+			ErrCaregiverUnqualified, caregiverID, strings.Join(missing, ", "), schedule.ServiceName)
+	}
+	return nil
+}
+
+// GetExpiringCertifications lists the certifications expiring within the next days
+func (s *SkillService) GetExpiringCertifications(days int) ([]models.CaregiverSkill, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("%w: days must be positive", ErrValidation)
+	}
+
+	now := s.now()
+	skills, err := s.skillRepo.GetExpiring(now, now.AddDate(0, 0, days))
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get expiring certifications")
+		return nil, fmt.Errorf("failed to get expiring certifications: %w", err)
+	}
+	if skills == nil {
+		skills = []models.CaregiverSkill{}
+	}
+
+	return skills, nil
+}
+
+// ReportExpiringCertifications logs a warning for every certification expiring
+// within the next days so that coordinators can arrange renewals
+func (s *SkillService) ReportExpiringCertifications(days int) ([]models.CaregiverSkill, error) {
+	skills, err := s.GetExpiringCertifications(days)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, skill := range skills {
+		s.logger.WithFields(logrus.Fields{
+			"caregiver_id":         skill.CaregiverID,
+			"skill":                skill.Skill,
+			"certification_number": skill.CertificationNumber,
+			"expires_at":           skill.ExpiresAt,
+		}).Warn("Certification expiring soon")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"days":  days,
+		"count": len(skills),
+	}).Info("Reported expiring certifications")
+	return skills, nil
+}
+
+// RunExpiryReport reports expiring certifications once at startup and then at
+// every interval until the context is cancelled
+func (s *SkillService) RunExpiryReport(ctx context.Context, interval time.Duration, days int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	_, _ = s.ReportExpiringCertifications(days)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.ReportExpiringCertifications(days)
+		}
+	}
+}
+
+// findSkill returns the caregiver skill with the given name
+func findSkill(skills []models.CaregiverSkill, skill string) *models.CaregiverSkill {
+	for i := range skills {
+		if skills[i].Skill == skill {
+			return &skills[i]
+		}
+	}
+	return nil
+}
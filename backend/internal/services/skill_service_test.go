@@ -0,0 +1,204 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSkillRepository is a mock implementation of SkillRepository
+type MockSkillRepository struct {
+	mock.Mock
+}
+
+func (m *MockSkillRepository) GetByCaregiverID(caregiverID int) ([]models.CaregiverSkill, error) {
+	args := m.Called(caregiverID)
+	return args.Get(0).([]models.CaregiverSkill), args.Error(1)
+}
+
+func (m *MockSkillRepository) Upsert(skill *models.CaregiverSkill) error {
+	args := m.Called(skill)
+	return args.Error(0)
+}
+
+func (m *MockSkillRepository) Delete(caregiverID int, skill string) error {
+	args := m.Called(caregiverID, skill)
+	return args.Error(0)
+}
+
+func (m *MockSkillRepository) GetExpiring(from, to time.Time) ([]models.CaregiverSkill, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]models.CaregiverSkill), args.Error(1)
+}
+
+// MockServiceTypeRepository is a mock implementation of ServiceTypeRepository
+type MockServiceTypeRepository struct {
+	mock.Mock
+}
+
+func (m *MockServiceTypeRepository) GetAll() ([]models.ServiceType, error) {
+	args := m.Called()
+	return args.Get(0).([]models.ServiceType), args.Error(1)
+}
+
+func (m *MockServiceTypeRepository) GetByID(id int) (*models.ServiceType, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ServiceType), args.Error(1)
+}
+
+func (m *MockServiceTypeRepository) GetByName(name string) (*models.ServiceType, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ServiceType), args.Error(1)
+}
+
+func (m *MockServiceTypeRepository) Create(serviceType *models.ServiceType) error {
+	args := m.Called(serviceType)
+	return args.Error(0)
+}
+
+func (m *MockServiceTypeRepository) Update(serviceType *models.ServiceType) error {
+	args := m.Called(serviceType)
+	return args.Error(0)
+}
+
+func newSkillTestService() (*SkillService, *MockSkillRepository, *MockServiceTypeRepository) {
+	skillRepo := new(MockSkillRepository)
+	serviceTypeRepo := new(MockServiceTypeRepository)
+	service := NewSkillService(skillRepo, serviceTypeRepo, logrus.New())
+	return service, skillRepo, serviceTypeRepo
+}
+
+func TestSkillService_ValidateAssignment(t *testing.T) {
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	schedule := &models.Schedule{ID: 1, ServiceName: "Wound Care", StartTime: start, EndTime: start.Add(2 * time.Hour)}
+	woundCare := &models.ServiceType{ID: 4, Name: "Wound Care", RequiredSkills: []string{"wound_care"}}
+	expiresAt := func(t time.Time) *time.Time { return &t }
+
+	cases := []struct {
+		name        string
+		serviceType *models.ServiceType
+		skills      []models.CaregiverSkill
+		wantErr     bool
+	}{
+		{name: "no matching service type", serviceType: nil},
+		{name: "no required skills", serviceType: &models.ServiceType{ID: 1, Name: "Wound Care", RequiredSkills: []string{}}},
+		{name: "certified", serviceType: woundCare, skills: []models.CaregiverSkill{
+			{CaregiverID: 2, Skill: "wound_care", ExpiresAt: expiresAt(start.AddDate(1, 0, 0))},
+		}},
+		{name: "skill without expiry", serviceType: woundCare, skills: []models.CaregiverSkill{
+			{CaregiverID: 2, Skill: "wound_care"},
+		}},
+		{name: "missing skill", serviceType: woundCare, skills: []models.CaregiverSkill{
+			{CaregiverID: 2, Skill: "medication_management"},
+		}, wantErr: true},
+		{name: "expires during the schedule", serviceType: woundCare, skills: []models.CaregiverSkill{
+			{CaregiverID: 2, Skill: "wound_care", ExpiresAt: expiresAt(start.Add(time.Hour))},
+		}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, skillRepo, serviceTypeRepo := newSkillTestService()
+			serviceTypeRepo.On("GetByName", "Wound Care").Return(tc.serviceType, nil)
+			skillRepo.On("GetByCaregiverID", 2).Return(tc.skills, nil)
+
+			err := service.ValidateAssignment(schedule, 2)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrCaregiverUnqualified)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSkillService_SetCaregiverSkill(t *testing.T) {
+	service, skillRepo, _ := newSkillTestService()
+	issued := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := issued.AddDate(0, 0, -1)
+
+	// Expiry must come after issue
+	_, err := service.SetCaregiverSkill(2, "wound_care", &models.CaregiverSkillRequest{IssuedAt: &issued, ExpiresAt: &expired})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Skill names are normalized
+	skillRepo.On("Upsert", mock.MatchedBy(func(s *models.CaregiverSkill) bool {
+		return s.CaregiverID == 2 && s.Skill == "wound_care"
+	})).Return(nil)
+	skill, err := service.SetCaregiverSkill(2, " Wound  Care ", &models.CaregiverSkillRequest{IssuedAt: &issued})
+	assert.NoError(t, err)
+	assert.Equal(t, "wound_care", skill.Skill)
+	skillRepo.AssertExpectations(t)
+}
+
+func TestSkillService_CreateServiceType(t *testing.T) {
+	service, _, serviceTypeRepo := newSkillTestService()
+	serviceTypeRepo.On("GetByName", "Wound Care").Return(&models.ServiceType{ID: 4, Name: "Wound Care"}, nil)
+	serviceTypeRepo.On("GetByName", "Dementia Care").Return(nil, nil)
+	serviceTypeRepo.On("Create", mock.AnythingOfType("*models.ServiceType")).Return(nil)
+
+	// Names are unique
+	_, err := service.CreateServiceType(&models.ServiceTypeRequest{Name: "Wound Care"})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	serviceType, err := service.CreateServiceType(&models.ServiceTypeRequest{
+		Name:           "Dementia Care",
+		RequiredSkills: []string{"Dementia Care", "first_aid", "dementia_care"},
+		ReasonCodes:    []string{"Client refused", "wandering", "client_refused"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dementia_care", "first_aid"}, serviceType.RequiredSkills)
+	assert.Equal(t, []string{"client_refused", "wandering"}, serviceType.ReasonCodes)
+
+	// Reason codes are stored comma-separated
+	_, err = service.CreateServiceType(&models.ServiceTypeRequest{Name: "Dementia Care", ReasonCodes: []string{"refused, asleep"}})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestSkillService_ReasonCodes(t *testing.T) {
+	service, _, serviceTypeRepo := newSkillTestService()
+	serviceTypeRepo.On("GetByName", "Medication Management").
+		Return(&models.ServiceType{ID: 2, Name: "Medication Management", ReasonCodes: []string{"held_by_physician"}}, nil)
+	serviceTypeRepo.On("GetByName", "Wound Care").Return(&models.ServiceType{ID: 4, Name: "Wound Care", ReasonCodes: []string{}}, nil)
+	serviceTypeRepo.On("GetByName", "Respite").Return(nil, nil)
+
+	codes, err := service.ReasonCodes("Medication Management")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"held_by_physician"}, codes)
+
+	// Service types without reason codes, unknown services and schedules
+	// without a service use the defaults
+	for _, name := range []string{"Wound Care", "Respite", ""} {
+		codes, err = service.ReasonCodes(name)
+		assert.NoError(t, err)
+		assert.Equal(t, models.DefaultTaskReasonCodes, codes)
+	}
+}
+
+func TestSkillService_GetExpiringCertifications(t *testing.T) {
+	service, skillRepo, _ := newSkillTestService()
+	now := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	expires := now.AddDate(0, 0, 20)
+	skillRepo.On("GetExpiring", now, now.AddDate(0, 0, 30)).
+		Return([]models.CaregiverSkill{{CaregiverID: 2, Skill: "wound_care", ExpiresAt: &expires}}, nil)
+
+	skills, err := service.ReportExpiringCertifications(30)
+	assert.NoError(t, err)
+	assert.Len(t, skills, 1)
+
+	_, err = service.GetExpiringCertifications(0)
+	assert.ErrorIs(t, err, ErrValidation)
+	skillRepo.AssertExpectations(t)
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Schedule events
+const (
+	ScheduleEventStart  = "start"
+	ScheduleEventEnd    = "end"
+	ScheduleEventAbort  = "abort"
+	ScheduleEventMiss   = "miss"
+	ScheduleEventCancel = "cancel"
+)
+
+// Visit events
+const (
+	VisitEventStart = "start"
+	VisitEventEnd   = "end"
+	VisitEventReset = "reset"
+)
+
+// Time-off request events
+const (
+	TimeOffEventApprove = "approve"
+	TimeOffEventReject  = "reject"
+	TimeOffEventCancel  = "cancel"
+)
+
+// Visit correction events
+const (
+	CorrectionEventApprove = "approve"
+	CorrectionEventReject  = "reject"
+	CorrectionEventCancel  = "cancel"
+)
+
+// Open shift events
+const (
+	OpenShiftEventRelease = "release"
+	OpenShiftEventClaim   = "claim"
+	OpenShiftEventApprove = "approve"
+	OpenShiftEventReject  = "reject"
+	OpenShiftEventCancel  = "cancel"
+)
+
+// Swap request events
+const (
+	SwapEventRequest = "request"
+	SwapEventAccept  = "accept"
+	SwapEventReject  = "reject"
+	SwapEventCancel  = "cancel"
+)
+
+// earlyStartWindow is how long before the scheduled start a visit may begin
+const earlyStartWindow = 30 * time.Minute
+
+// Transition describes a single status change of an entity
+type Transition struct {
+	Event string
+	From  string
+	To    string
+	At    time.Time
+}
+
+// TransitionGuard checks whether a transition may happen for the given subject
+type TransitionGuard[T any] func(subject T, transition Transition) error
+
+// TransitionHook is run after a transition has been persisted
+type TransitionHook[T any] func(subject T, transition Transition) error
+
+// StateMachine defines the allowed status transitions for an entity type
+type StateMachine[T any] struct {
+	name        string
+	status      func(T) string
+	states      map[string]bool
+	transitions map[string]map[string]string // event -> from -> to
+	guards      map[string][]TransitionGuard[T]
+	hooks       []TransitionHook[T]
+	now         func() time.Time
+}
+
+// NewStateMachine creates an empty state machine; status reads the current status of a subject
+func NewStateMachine[T any](name string, status func(T) string, states ...string) *StateMachine[T] {
+	m := &StateMachine[T]{
+		name:        name,
+		status:      status,
+		states:      make(map[string]bool),
+		transitions: make(map[string]map[string]string),
+		guards:      make(map[string][]TransitionGuard[T]),
+		now:         time.Now,
+	}
+	for _, state := range states {
+		m.states[state] = true
+	}
+	return m
+}
+
+// Permit allows the event to move a subject from any of the given statuses to the target status
+func (m *StateMachine[T]) Permit(event, to string, from ...string) *StateMachine[T] {
+	if m.transitions[event] == nil {
+		m.transitions[event] = make(map[string]string)
+	}
+	for _, f := range from {
+		m.transitions[event][f] = to
+	}
+	return m
+}
+
+// Guard registers a guard that must pass before the event is applied
+func (m *StateMachine[T]) Guard(event string, guard TransitionGuard[T]) *StateMachine[T] {
+	m.guards[event] = append(m.guards[event], guard)
+	return m
+}
+
+// OnTransition registers a hook that runs after every completed transition
+func (m *StateMachine[T]) OnTransition(hook TransitionHook[T]) *StateMachine[T] {
+	m.hooks = append(m.hooks, hook)
+	return m
+}
+
+// IsValidState reports whether the status is known to the state machine
+func (m *StateMachine[T]) IsValidState(status string) bool {
+	return m.states[status]
+}
+
+// Can reports whether the event is allowed from the given status, ignoring guards
+func (m *StateMachine[T]) Can(from, event string) bool {
+	_, ok := m.transitions[event][from]
+	return ok
+}
+
+// Fire validates the event against the subject's current status and guards,
+// returning the transition to apply. The caller is responsible for persisting it.
+func (m *StateMachine[T]) Fire(subject T, event string) (Transition, error) {
+	from := m.status(subject)
+	to, ok := m.transitions[event][from]
+	if !ok {
+		return Transition{}, fmt.Errorf("%w: cannot %s %s in status %s", ErrInvalidTransition, event, m.name, from)
+	}
+
+	transition := Transition{Event: event, From: from, To: to, At: m.now()}
+	for _, guard := range m.guards[event] {
+		if err := guard(subject, transition); err != nil {
+			return Transition{}, fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+		}
+	}
+
+	return transition, nil
+}
+
+// Complete runs the transition hooks once a transition has been persisted
+func (m *StateMachine[T]) Complete(subject T, transition Transition) error {
+	var errs []error
+	for _, hook := range m.hooks {
+		if err := hook(subject, transition); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewScheduleStateMachine creates the state machine governing schedule statuses
+func NewScheduleStateMachine() *StateMachine[*models.Schedule] {
+	m := NewStateMachine("schedule",
+		func(s *models.Schedule) string { return s.Status },
+		models.ScheduleStatusScheduled,
+		models.ScheduleStatusInProgress,
+		models.ScheduleStatusCompleted,
+		models.ScheduleStatusMissed,
+		models.ScheduleStatusCancelled,
+	)
+
+	m.Permit(ScheduleEventStart, models.ScheduleStatusInProgress, models.ScheduleStatusScheduled).
+		Permit(ScheduleEventEnd, models.ScheduleStatusCompleted, models.ScheduleStatusInProgress).
+		Permit(ScheduleEventAbort, models.ScheduleStatusScheduled, models.ScheduleStatusInProgress).
+		Permit(ScheduleEventMiss, models.ScheduleStatusMissed, models.ScheduleStatusScheduled).
+		Permit(ScheduleEventCancel, models.ScheduleStatusCancelled, models.ScheduleStatusScheduled)
+
+	m.Guard(ScheduleEventStart, func(s *models.Schedule, t Transition) error {
+		if t.At.Before(s.StartTime.Add(-earlyStartWindow)) {
+			return fmt.Errorf("cannot start visit more than 30 minutes before scheduled time")
+		}
+		if t.At.After(s.EndTime) {
+			return fmt.Errorf("cannot start visit after scheduled end time")
+		}
+		return nil
+	})
+	m.Guard(ScheduleEventMiss, func(s *models.Schedule, t Transition) error {
+		if !t.At.After(s.EndTime) {
+			return fmt.Errorf("schedule has not ended yet")
+		}
+		return nil
+	})
+
+	return m
+}
+
+// NewVisitStateMachine creates the state machine governing visit statuses
+func NewVisitStateMachine() *StateMachine[*models.Visit] {
+	m := NewStateMachine("visit",
+		func(v *models.Visit) string { return v.Status },
+		models.VisitStatusNotStarted,
+		models.VisitStatusInProgress,
+		models.VisitStatusCompleted,
+	)
+
+	m.Permit(VisitEventStart, models.VisitStatusInProgress, models.VisitStatusNotStarted).
+		Permit(VisitEventEnd, models.VisitStatusCompleted, models.VisitStatusInProgress).
+		Permit(VisitEventReset, models.VisitStatusNotStarted, models.VisitStatusInProgress)
+
+	m.Guard(VisitEventEnd, func(v *models.Visit, t Transition) error {
+		if v.StartTime == nil {
+			return fmt.Errorf("cannot end visit that hasn't been started")
+		}
+		return nil
+	})
+
+	return m
+}
+
+// NewTimeOffStateMachine creates the state machine governing time-off request
+// statuses. Approved time off can still be cancelled, e.g. when plans change.
+func NewTimeOffStateMachine() *StateMachine[*models.TimeOffRequest] {
+	m := NewStateMachine("time off",
+		func(t *models.TimeOffRequest) string { return t.Status },
+		models.TimeOffStatusPending,
+		models.TimeOffStatusApproved,
+		models.TimeOffStatusRejected,
+		models.TimeOffStatusCancelled,
+	)
+
+	m.Permit(TimeOffEventApprove, models.TimeOffStatusApproved, models.TimeOffStatusPending).
+		Permit(TimeOffEventReject, models.TimeOffStatusRejected, models.TimeOffStatusPending).
+		Permit(TimeOffEventCancel, models.TimeOffStatusCancelled, models.TimeOffStatusPending, models.TimeOffStatusApproved)
+
+	return m
+}
+
+// NewVisitCorrectionStateMachine creates the state machine governing visit
+// correction statuses. Only pending corrections can be reviewed or withdrawn;
+// an approved correction has already amended the visit.
+func NewVisitCorrectionStateMachine() *StateMachine[*models.VisitCorrection] {
+	m := NewStateMachine("visit correction",
+		func(c *models.VisitCorrection) string { return c.Status },
+		models.CorrectionStatusPending,
+		models.CorrectionStatusApproved,
+		models.CorrectionStatusRejected,
+		models.CorrectionStatusCancelled,
+	)
+
+	m.Permit(CorrectionEventApprove, models.CorrectionStatusApproved, models.CorrectionStatusPending).
+		Permit(CorrectionEventReject, models.CorrectionStatusRejected, models.CorrectionStatusPending).
+		Permit(CorrectionEventCancel, models.CorrectionStatusCancelled, models.CorrectionStatusPending)
+
+	return m
+}
+
+// NewOpenShiftStateMachine creates the state machine governing open shift
+// statuses. A rejected claim puts the shift back on the board.
+func NewOpenShiftStateMachine() *StateMachine[*models.OpenShift] {
+	m := NewStateMachine("open shift",
+		func(o *models.OpenShift) string { return o.Status },
+		models.OpenShiftStatusOpen,
+		models.OpenShiftStatusClaimed,
+		models.OpenShiftStatusFilled,
+		models.OpenShiftStatusCancelled,
+	)
+
+	m.Permit(OpenShiftEventClaim, models.OpenShiftStatusClaimed, models.OpenShiftStatusOpen).
+		Permit(OpenShiftEventApprove, models.OpenShiftStatusFilled, models.OpenShiftStatusClaimed).
+		Permit(OpenShiftEventReject, models.OpenShiftStatusOpen, models.OpenShiftStatusClaimed).
+		Permit(OpenShiftEventCancel, models.OpenShiftStatusCancelled, models.OpenShiftStatusOpen, models.OpenShiftStatusClaimed)
+
+	return m
+}
+
+// NewSwapRequestStateMachine creates the state machine governing shift swap request statuses
+func NewSwapRequestStateMachine() *StateMachine[*models.SwapRequest] {
+	m := NewStateMachine("swap request",
+		func(r *models.SwapRequest) string { return r.Status },
+		models.SwapStatusPending,
+		models.SwapStatusAccepted,
+		models.SwapStatusRejected,
+		models.SwapStatusCancelled,
+	)
+
+	m.Permit(SwapEventAccept, models.SwapStatusAccepted, models.SwapStatusPending).
+		Permit(SwapEventReject, models.SwapStatusRejected, models.SwapStatusPending).
+		Permit(SwapEventCancel, models.SwapStatusCancelled, models.SwapStatusPending)
+
+	return m
+}
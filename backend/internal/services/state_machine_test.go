@@ -0,0 +1,126 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleStateMachine_AllowedTransitions(t *testing.T) {
+	machine := NewScheduleStateMachine()
+
+	tests := []struct {
+		from  string
+		event string
+		to    string
+	}{
+		{models.ScheduleStatusScheduled, ScheduleEventStart, models.ScheduleStatusInProgress},
+		{models.ScheduleStatusInProgress, ScheduleEventEnd, models.ScheduleStatusCompleted},
+		{models.ScheduleStatusInProgress, ScheduleEventAbort, models.ScheduleStatusScheduled},
+		{models.ScheduleStatusScheduled, ScheduleEventCancel, models.ScheduleStatusCancelled},
+	}
+
+	for _, tt := range tests {
+		schedule := &models.Schedule{
+			ID:        1,
+			StartTime: time.Now(),
+			EndTime:   time.Now().Add(time.Hour),
+			Status:    tt.from,
+		}
+
+		transition, err := machine.Fire(schedule, tt.event)
+
+		assert.NoError(t, err, "%s from %s", tt.event, tt.from)
+		assert.Equal(t, tt.from, transition.From)
+		assert.Equal(t, tt.to, transition.To)
+	}
+}
+
+func TestScheduleStateMachine_IllegalTransitions(t *testing.T) {
+	machine := NewScheduleStateMachine()
+
+	tests := []struct {
+		from  string
+		event string
+	}{
+		{models.ScheduleStatusMissed, ScheduleEventEnd},
+		{models.ScheduleStatusCompleted, ScheduleEventStart},
+		{models.ScheduleStatusInProgress, ScheduleEventStart},
+		{models.ScheduleStatusScheduled, ScheduleEventEnd},
+		{models.ScheduleStatusCancelled, ScheduleEventStart},
+		{models.ScheduleStatusCompleted, ScheduleEventCancel},
+	}
+
+	for _, tt := range tests {
+		schedule := &models.Schedule{ID: 1, StartTime: time.Now(), Status: tt.from}
+
+		_, err := machine.Fire(schedule, tt.event)
+
+		assert.Error(t, err, "%s from %s", tt.event, tt.from)
+		assert.True(t, errors.Is(err, ErrInvalidTransition))
+	}
+}
+
+func TestScheduleStateMachine_MissGuard(t *testing.T) {
+	machine := NewScheduleStateMachine()
+
+	upcoming := &models.Schedule{
+		StartTime: time.Now().Add(time.Hour),
+		EndTime:   time.Now().Add(2 * time.Hour),
+		Status:    models.ScheduleStatusScheduled,
+	}
+	_, err := machine.Fire(upcoming, ScheduleEventMiss)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	past := &models.Schedule{
+		StartTime: time.Now().Add(-2 * time.Hour),
+		EndTime:   time.Now().Add(-time.Hour),
+		Status:    models.ScheduleStatusScheduled,
+	}
+	transition, err := machine.Fire(past, ScheduleEventMiss)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ScheduleStatusMissed, transition.To)
+}
+
+func TestScheduleStateMachine_StartAfterEnd(t *testing.T) {
+	machine := NewScheduleStateMachine()
+
+	ended := &models.Schedule{
+		StartTime: time.Now().Add(-2 * time.Hour),
+		EndTime:   time.Now().Add(-time.Hour),
+		Status:    models.ScheduleStatusScheduled,
+	}
+	_, err := machine.Fire(ended, ScheduleEventStart)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	late := &models.Schedule{
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+		Status:    models.ScheduleStatusScheduled,
+	}
+	_, err = machine.Fire(late, ScheduleEventStart)
+	assert.NoError(t, err)
+}
+
+func TestStateMachine_Hooks(t *testing.T) {
+	machine := NewVisitStateMachine()
+
+	var seen []Transition
+	machine.OnTransition(func(v *models.Visit, transition Transition) error {
+		seen = append(seen, transition)
+		return nil
+	})
+
+	now := time.Now()
+	visit := &models.Visit{StartTime: &now, Status: models.VisitStatusInProgress}
+
+	transition, err := machine.Fire(visit, VisitEventEnd)
+	assert.NoError(t, err)
+	assert.NoError(t, machine.Complete(visit, transition))
+
+	assert.Len(t, seen, 1)
+	assert.Equal(t, models.VisitStatusCompleted, seen[0].To)
+}
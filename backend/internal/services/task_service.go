@@ -10,15 +10,17 @@ import (
 
 // TaskService handles business logic for tasks
 type TaskService struct {
-	taskRepo repositories.TaskRepository
-	logger   *logrus.Logger
+	taskRepo      repositories.TaskRepository
+	caregiverRepo repositories.ScheduleCaregiverRepository
+	logger        *logrus.Logger
 }
 
 // NewTaskService creates a new task service
-func NewTaskService(taskRepo repositories.TaskRepository, logger *logrus.Logger) *TaskService {
+func NewTaskService(taskRepo repositories.TaskRepository, caregiverRepo repositories.ScheduleCaregiverRepository, logger *logrus.Logger) *TaskService {
 	return &TaskService{
-		taskRepo: taskRepo,
-		logger:   logger,
+		taskRepo:      taskRepo,
+		caregiverRepo: caregiverRepo,
+		logger:        logger,
 	}
 }
 
@@ -85,8 +87,17 @@ func (s *TaskService) UpdateTaskStatus(id int, req *models.TaskUpdateRequest) (*
 		return nil, fmt.Errorf("task not found")
 	}
 
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != task.Version {
+		s.logger.WithFields(logrus.Fields{
+			"task_id":          id,
+			"expected_version": *req.ExpectedVersion,
+			"current_version":  task.Version,
+		}).Warn("Task version mismatch")
+		return nil, fmt.Errorf("task %d is at version %d: %w", id, task.Version, ErrVersionConflict)
+	}
+
 	// Update the task status
-	if err := s.taskRepo.UpdateStatus(id, req.Status, req.Reason); err != nil {
+	if err := s.taskRepo.UpdateStatus(id, req.Status, req.Reason, task.Version); err != nil {
 		s.logger.WithError(err).WithField("task_id", id).Error("Failed to update task status")
 		return nil, fmt.Errorf("failed to update task status: %w", err)
 	}
@@ -102,6 +113,55 @@ func (s *TaskService) UpdateTaskStatus(id int, req *models.TaskUpdateRequest) (*
 	return updatedTask, nil
 }
 
+// AssignTask assigns a task to a caregiver on the schedule's team, or
+// unassigns it when no caregiver is given
+func (s *TaskService) AssignTask(id int, req *models.TaskAssignRequest) (*models.Task, error) {
+	s.logger.WithFields(logrus.Fields{
+		"task_id":      id,
+		"caregiver_id": req.CaregiverID,
+	}).Info("Assigning task")
+
+	task, err := s.taskRepo.GetByID(id)
+	if err != nil {
+		s.logger.WithError(err).WithField("task_id", id).Error("Failed to get task")
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if task == nil {
+		s.logger.WithField("task_id", id).Warn("Task not found")
+		return nil, fmt.Errorf("task %d: %w", id, ErrNotFound)
+	}
+
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != task.Version {
+		return nil, fmt.Errorf("task %d is at version %d: %w", id, task.Version, ErrVersionConflict)
+	}
+
+	if req.CaregiverID != nil {
+		caregivers, err := s.caregiverRepo.GetByScheduleID(task.ScheduleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schedule caregivers: %w", err)
+		}
+		onTeam := false
+		for _, caregiver := range caregivers {
+			if caregiver.CaregiverID == *req.CaregiverID {
+				onTeam = true
+			}
+		}
+		if !onTeam {
+			return nil, fmt.Errorf("%w: caregiver %d is not assigned to schedule %d", ErrValidation, *req.CaregiverID, task.ScheduleID)
+		}
+	}
+
+	task.CaregiverID = req.CaregiverID
+	if err := s.taskRepo.Update(task); err != nil {
+		s.logger.WithError(err).WithField("task_id", id).Error("Failed to assign task")
+		return nil, fmt.Errorf("failed to assign task: %w", err)
+	}
+
+	s.logger.WithField("task_id", id).Info("Successfully assigned task")
+	return task, nil
+}
+
 // CreateTask creates a new task
 func (s *TaskService) CreateTask(task *models.Task) error {
 	s.logger.WithField("schedule_id", task.ScheduleID).Debug("Creating task")
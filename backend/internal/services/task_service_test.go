@@ -6,13 +6,14 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestTaskService_GetTasksByScheduleID(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data
 	expectedTasks := []models.Task{
@@ -40,7 +41,7 @@ func TestTaskService_GetTaskByID(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data
 	expectedTask := &models.Task{
@@ -71,7 +72,7 @@ func TestTaskService_GetTaskByID_NotFound(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Mock expectations
 	mockTaskRepo.On("GetByID", 999).Return(nil, nil)
@@ -91,7 +92,7 @@ func TestTaskService_UpdateTaskStatus_Completed(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data
 	task := &models.Task{
@@ -99,6 +100,7 @@ func TestTaskService_UpdateTaskStatus_Completed(t *testing.T) {
 		ScheduleID: 1,
 		Title:      "Give medication",
 		Status:     "pending",
+		Version:    1,
 	}
 	req := &models.TaskUpdateRequest{
 		Status: "completed",
@@ -113,7 +115,7 @@ func TestTaskService_UpdateTaskStatus_Completed(t *testing.T) {
 		Description: "Administer morning medications",
 	}
 	mockTaskRepo.On("GetByID", 1).Return(task, nil).Once() // First call returns original task
-	mockTaskRepo.On("UpdateStatus", 1, "completed", "").Return(nil)
+	mockTaskRepo.On("UpdateStatus", 1, "completed", "", 1).Return(nil)
 	mockTaskRepo.On("GetByID", 1).Return(updatedTask, nil).Once() // Second call returns updated task
 
 	// Execute
@@ -132,7 +134,7 @@ func TestTaskService_UpdateTaskStatus_NotCompleted(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data
 	task := &models.Task{
@@ -140,6 +142,7 @@ func TestTaskService_UpdateTaskStatus_NotCompleted(t *testing.T) {
 		ScheduleID: 1,
 		Title:      "Give medication",
 		Status:     "pending",
+		Version:    1,
 	}
 	req := &models.TaskUpdateRequest{
 		Status: "not_completed",
@@ -156,7 +159,7 @@ func TestTaskService_UpdateTaskStatus_NotCompleted(t *testing.T) {
 		Reason:      "Client refused medication",
 	}
 	mockTaskRepo.On("GetByID", 1).Return(task, nil).Once() // First call returns original task
-	mockTaskRepo.On("UpdateStatus", 1, "not_completed", "Client refused medication").Return(nil)
+	mockTaskRepo.On("UpdateStatus", 1, "not_completed", "Client refused medication", 1).Return(nil)
 	mockTaskRepo.On("GetByID", 1).Return(updatedTask2, nil).Once() // Second call returns updated task
 
 	// Execute
@@ -172,11 +175,47 @@ func TestTaskService_UpdateTaskStatus_NotCompleted(t *testing.T) {
 	mockTaskRepo.AssertExpectations(t)
 }
 
+func TestTaskService_UpdateTaskStatus_VersionMismatch(t *testing.T) {
+	// Setup
+	mockTaskRepo := new(MockTaskRepository)
+	logger := logrus.New()
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
+
+	// Test data - the task was updated by another session in the meantime
+	task := &models.Task{
+		ID:         1,
+		ScheduleID: 1,
+		Title:      "Give medication",
+		Status:     "completed",
+		Version:    3,
+	}
+	expectedVersion := 2
+	req := &models.TaskUpdateRequest{
+		Status:          "not_completed",
+		Reason:          "Client refused medication",
+		ExpectedVersion: &expectedVersion,
+	}
+
+	// Mock expectations
+	mockTaskRepo.On("GetByID", 1).Return(task, nil)
+
+	// Execute
+	updatedTask, err := service.UpdateTaskStatus(1, req)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Nil(t, updatedTask)
+
+	// Verify mock expectations
+	mockTaskRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockTaskRepo.AssertExpectations(t)
+}
+
 func TestTaskService_UpdateTaskStatus_TaskNotFound(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	req := &models.TaskUpdateRequest{
 		Status: "completed",
@@ -201,7 +240,7 @@ func TestTaskService_UpdateTaskStatus_ValidationError_MissingReason(t *testing.T
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	req := &models.TaskUpdateRequest{
 		Status: "not_completed",
@@ -221,7 +260,7 @@ func TestTaskService_UpdateTaskStatus_ValidationError_InvalidStatus(t *testing.T
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	req := &models.TaskUpdateRequest{
 		Status: "invalid_status",
@@ -240,7 +279,7 @@ func TestTaskService_CreateTask(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data
 	task := &models.Task{
@@ -267,7 +306,7 @@ func TestTaskService_CreateTask_ValidationError(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data with missing title
 	task := &models.Task{
@@ -289,7 +328,7 @@ func TestTaskService_DeleteTask(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Test data
 	task := &models.Task{
@@ -317,7 +356,7 @@ func TestTaskService_DeleteTask_NotFound(t *testing.T) {
 	// Setup
 	mockTaskRepo := new(MockTaskRepository)
 	logger := logrus.New()
-	service := NewTaskService(mockTaskRepo, logger)
+	service := NewTaskService(mockTaskRepo, new(MockScheduleCaregiverRepository), logger)
 
 	// Mock expectations
 	mockTaskRepo.On("GetByID", 999).Return(nil, nil)
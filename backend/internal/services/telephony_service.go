@@ -0,0 +1,245 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// minPINLength and maxPINLength bound the digits of a telephony PIN
+	minPINLength = 4
+	maxPINLength = 8
+
+	// pinSaltBytes is the length of the random salt hashed with each PIN
+	pinSaltBytes = 16
+)
+
+// Telephony call states, returned with a prompt and sent back with the
+// caller's answer to it
+const (
+	telephonyStateCaregiver = "caregiver"
+	telephonyStatePIN       = "pin:" // Followed by the caregiver number
+)
+
+// Telephony prompts
+const (
+	promptWelcome          = "Welcome to the caregiver line. Enter your caregiver number, then press pound."
+	promptInvalidCaregiver = "That caregiver number is not valid. Enter your caregiver number, then press pound."
+	promptPIN              = "Enter your PIN, then press pound."
+	promptWrongPIN         = "The PIN is not correct. Goodbye."
+	promptUnknownCaller    = "This phone number is not registered with the agency. Please call from your client's phone. Goodbye."
+	promptNoVisit          = "You have no visit with this client today that can be clocked in or out. Please contact your coordinator. Goodbye."
+	promptFailed           = "Your visit could not be recorded. Please contact your coordinator. Goodbye."
+	promptTasksIncomplete  = "Required tasks of this visit are not done. Record them in the app before clocking out. Goodbye."
+)
+
+// TelephonyClock lists and clocks in and out of a caregiver's schedules; it
+// is implemented by ScheduleService so that calls follow the same rules as
+// clock events from the app
+type TelephonyClock interface {
+	GetTodaySchedules(caregiverID int) ([]models.Schedule, error)
+	StartVisit(scheduleID int, req *models.VisitStartRequest) error
+	EndVisit(scheduleID int, req *models.VisitEndRequest) error
+}
+
+// TelephonyService runs the telephony line caregivers without a smartphone
+// call from the client's landline to clock in and out. The caller ID
+// identifies the client and the caregiver enters their number and PIN.
+type TelephonyService struct {
+	pinRepo    repositories.TelephonyPINRepository
+	clientRepo repositories.ClientRepository
+	clock      TelephonyClock
+	logger     *logrus.Logger
+}
+
+// NewTelephonyService creates a new telephony service
+func NewTelephonyService(
+	pinRepo repositories.TelephonyPINRepository,
+	clientRepo repositories.ClientRepository,
+	clock TelephonyClock,
+	logger *logrus.Logger,
+) *TelephonyService {
+	return &TelephonyService{
+		pinRepo:    pinRepo,
+		clientRepo: clientRepo,
+		clock:      clock,
+		logger:     logger,
+	}
+}
+
+// SetPIN sets the PIN a caregiver enters when calling the telephony line
+func (s *TelephonyService) SetPIN(caregiverID int, req *models.TelephonyPINRequest) (*models.CaregiverTelephonyPIN, error) {
+	s.logger.WithField("caregiver_id", caregiverID).Info("Setting telephony PIN")
+
+	if len(req.PIN) < minPINLength || len(req.PIN) > maxPINLength || !isDigits(req.PIN) {
+		return nil, fmt.Errorf("%w: PIN must be %d to %d digits", ErrValidation, minPINLength, maxPINLength)
+	}
+
+	salt := make([]byte, pinSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate PIN salt: %w", err)
+	}
+
+	pin := &models.CaregiverTelephonyPIN{CaregiverID: caregiverID, Salt: hex.EncodeToString(salt)}
+	pin.PINHash = hashPIN(pin.Salt, req.PIN)
+	if err := s.pinRepo.Replace(pin); err != nil {
+		s.logger.WithError(err).WithField("caregiver_id", caregiverID).Error("Failed to save telephony PIN")
+		return nil, fmt.Errorf("failed to save telephony PIN: %w", err)
+	}
+
+	return pin, nil
+}
+
+// HandleCall answers one step of a call to the telephony line. A call asks
+// for the caregiver number, then the PIN, and then clocks the caregiver out
+// of the visit to the calling client they are clocked in to, or else in to
+// their next visit to the client today.
+func (s *TelephonyService) HandleCall(call *models.TelephonyCall) (*models.TelephonyPrompt, error) {
+	logger := s.logger.WithFields(logrus.Fields{"call_id": call.CallID, "state": call.State})
+
+	client, err := s.clientRepo.GetByPhone(call.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client by phone: %w", err)
+	}
+	if client == nil {
+		logger.WithField("from", call.From).Warn("Telephony call from an unknown number")
+		return &models.TelephonyPrompt{Message: promptUnknownCaller}, nil
+	}
+
+	switch {
+	case call.State == "":
+		return &models.TelephonyPrompt{Message: promptWelcome, Gather: true, State: telephonyStateCaregiver}, nil
+
+	case call.State == telephonyStateCaregiver:
+		caregiverID, err := strconv.Atoi(strings.TrimSpace(call.Digits))
+		if err != nil || caregiverID <= 0 {
+			return &models.TelephonyPrompt{Message: promptInvalidCaregiver, Gather: true, State: telephonyStateCaregiver}, nil
+		}
+		return &models.TelephonyPrompt{Message: promptPIN, Gather: true, State: telephonyStatePIN + strconv.Itoa(caregiverID)}, nil
+
+	case strings.HasPrefix(call.State, telephonyStatePIN):
+		caregiverID, err := strconv.Atoi(strings.TrimPrefix(call.State, telephonyStatePIN))
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown call state %q", ErrValidation, call.State)
+		}
+
+		ok, err := s.checkPIN(caregiverID, strings.TrimSpace(call.Digits))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			logger.WithField("caregiver_id", caregiverID).Warn("Wrong telephony PIN")
+			return &models.TelephonyPrompt{Message: promptWrongPIN}, nil
+		}
+
+		return s.clockByPhone(client, caregiverID)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown call state %q", ErrValidation, call.State)
+	}
+}
+
+// checkPIN reports whether a caregiver entered their telephony PIN
+func (s *TelephonyService) checkPIN(caregiverID int, entered string) (bool, error) {
+	pin, err := s.pinRepo.GetByCaregiverID(caregiverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get telephony PIN: %w", err)
+	}
+	if pin == nil || entered == "" {
+		return false, nil
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashPIN(pin.Salt, entered)), []byte(pin.PINHash)) == 1, nil
+}
+
+// clockByPhone clocks a caregiver out of the calling client's visit they are
+// clocked in to, or in to their next visit to the client today
+func (s *TelephonyService) clockByPhone(client *models.Client, caregiverID int) (*models.TelephonyPrompt, error) {
+	schedules, err := s.clock.GetTodaySchedules(caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get today's schedules: %w", err)
+	}
+
+	fields := logrus.Fields{"client_id": client.ID, "caregiver_id": caregiverID}
+	var next *models.Schedule
+	for i := range schedules {
+		schedule := &schedules[i]
+		if schedule.ClientID != client.ID {
+			continue
+		}
+
+		visit := caregiverVisit(schedule, caregiverID)
+		if visit != nil && visit.Status == models.VisitStatusInProgress {
+			err := s.clock.EndVisit(schedule.ID, &models.VisitEndRequest{CaregiverID: &caregiverID, CallerClientID: &client.ID})
+			return s.clockPrompt(schedule, models.ClockEventOut, err, fields)
+		}
+
+		// A team member can still join a schedule another caregiver started
+		startable := schedule.Status == models.ScheduleStatusScheduled ||
+			(schedule.Status == models.ScheduleStatusInProgress && (visit == nil || visit.Status == models.VisitStatusNotStarted))
+		if next == nil && startable {
+			next = schedule
+		}
+	}
+
+	if next == nil {
+		s.logger.WithFields(fields).Warn("No visit to clock by phone")
+		return &models.TelephonyPrompt{Message: promptNoVisit}, nil
+	}
+
+	err = s.clock.StartVisit(next.ID, &models.VisitStartRequest{CaregiverID: &caregiverID, CallerClientID: &client.ID})
+	return s.clockPrompt(next, models.ClockEventIn, err, fields)
+}
+
+// clockPrompt tells the caller whether their clock-in or clock-out was recorded
+func (s *TelephonyService) clockPrompt(schedule *models.Schedule, event string, err error, fields logrus.Fields) (*models.TelephonyPrompt, error) {
+	fields["schedule_id"] = schedule.ID
+	fields["event"] = event
+
+	if err != nil {
+		if errors.Is(err, ErrTasksIncomplete) {
+			s.logger.WithError(err).WithFields(fields).Warn("Clock-out by phone with required tasks open")
+			return &models.TelephonyPrompt{Message: promptTasksIncomplete}, nil
+		}
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrValidation) || errors.Is(err, ErrVerificationFailed) {
+			s.logger.WithError(err).WithFields(fields).Warn("Clock event by phone rejected")
+			return &models.TelephonyPrompt{Message: promptFailed}, nil
+		}
+		return nil, err
+	}
+
+	message := "You are clocked in. Goodbye."
+	if event == models.ClockEventOut {
+		message = "You are clocked out. Goodbye."
+	}
+	s.logger.WithFields(fields).Info("Clock event recorded by phone")
+
+	id := schedule.ID
+	return &models.TelephonyPrompt{Message: message, ScheduleID: &id, Event: event}, nil
+}
+
+// hashPIN hashes a PIN with its salt
+func hashPIN(salt, pin string) string {
+	sum := sha256.Sum256([]byte(salt + pin))
+	return hex.EncodeToString(sum[:])
+}
+
+// isDigits reports whether s consists of ASCII digits only
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
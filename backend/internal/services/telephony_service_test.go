@@ -0,0 +1,255 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTelephonyPINRepository is a mock implementation of TelephonyPINRepository
+type MockTelephonyPINRepository struct {
+	mock.Mock
+}
+
+func (m *MockTelephonyPINRepository) GetByCaregiverID(caregiverID int) (*models.CaregiverTelephonyPIN, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CaregiverTelephonyPIN), args.Error(1)
+}
+
+func (m *MockTelephonyPINRepository) Replace(pin *models.CaregiverTelephonyPIN) error {
+	args := m.Called(pin)
+	return args.Error(0)
+}
+
+// MockTelephonyClock is a mock implementation of TelephonyClock
+type MockTelephonyClock struct {
+	mock.Mock
+}
+
+func (m *MockTelephonyClock) GetTodaySchedules(caregiverID int) ([]models.Schedule, error) {
+	args := m.Called(caregiverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Schedule), args.Error(1)
+}
+
+func (m *MockTelephonyClock) StartVisit(scheduleID int, req *models.VisitStartRequest) error {
+	args := m.Called(scheduleID, req)
+	return args.Error(0)
+}
+
+func (m *MockTelephonyClock) EndVisit(scheduleID int, req *models.VisitEndRequest) error {
+	args := m.Called(scheduleID, req)
+	return args.Error(0)
+}
+
+// newTelephonyTestService returns a service where caller +62 21 555 0100 is
+// client 3 and caregiver 2 has PIN 4321
+func newTelephonyTestService() (*TelephonyService, *MockTelephonyClock) {
+	pinRepo := new(MockTelephonyPINRepository)
+	clientRepo := new(MockClientRepository)
+	clock := new(MockTelephonyClock)
+
+	clientRepo.On("GetByPhone", "+62 21 555 0100").Return(&models.Client{ID: 3, Name: "Ani"}, nil)
+	clientRepo.On("GetByPhone", mock.Anything).Return(nil, nil)
+	pinRepo.On("GetByCaregiverID", 2).Return(&models.CaregiverTelephonyPIN{CaregiverID: 2, Salt: "ab", PINHash: hashPIN("ab", "4321")}, nil)
+	pinRepo.On("GetByCaregiverID", mock.Anything).Return(nil, nil)
+
+	return NewTelephonyService(pinRepo, clientRepo, clock, logrus.New()), clock
+}
+
+func TestTelephonyService_HandleCall_Prompts(t *testing.T) {
+	service, clock := newTelephonyTestService()
+
+	tests := []struct {
+		name   string
+		call   *models.TelephonyCall
+		gather bool
+		state  string
+		prompt string
+	}{
+		{"incoming call", &models.TelephonyCall{From: "+62 21 555 0100"}, true, telephonyStateCaregiver, promptWelcome},
+		{"caregiver number", &models.TelephonyCall{From: "+62 21 555 0100", State: telephonyStateCaregiver, Digits: "2"}, true, "pin:2", promptPIN},
+		{"invalid caregiver number", &models.TelephonyCall{From: "+62 21 555 0100", State: telephonyStateCaregiver, Digits: "*"}, true, telephonyStateCaregiver, promptInvalidCaregiver},
+		{"wrong PIN", &models.TelephonyCall{From: "+62 21 555 0100", State: "pin:2", Digits: "1234"}, false, "", promptWrongPIN},
+		{"caregiver without PIN", &models.TelephonyCall{From: "+62 21 555 0100", State: "pin:5", Digits: "4321"}, false, "", promptWrongPIN},
+		{"unknown caller", &models.TelephonyCall{From: "+1 555 0199"}, false, "", promptUnknownCaller},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := service.HandleCall(tt.call)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.prompt, prompt.Message)
+			assert.Equal(t, tt.gather, prompt.Gather)
+			assert.Equal(t, tt.state, prompt.State)
+		})
+	}
+
+	_, err := service.HandleCall(&models.TelephonyCall{From: "+62 21 555 0100", State: "pin:x"})
+	assert.True(t, errors.Is(err, ErrValidation))
+	clock.AssertNotCalled(t, "GetTodaySchedules", mock.Anything)
+}
+
+func TestTelephonyService_HandleCall_ClocksInAndOut(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules []models.Schedule
+		event     string
+		schedule  int
+		prompt    string
+	}{
+		{
+			name: "clocks in to the client's next visit",
+			schedules: []models.Schedule{
+				{ID: 10, ClientID: 4, Status: models.ScheduleStatusScheduled},
+				{ID: 11, ClientID: 3, Status: models.ScheduleStatusScheduled},
+				{ID: 12, ClientID: 3, Status: models.ScheduleStatusScheduled},
+			},
+			event: models.ClockEventIn, schedule: 11, prompt: "You are clocked in. Goodbye.",
+		},
+		{
+			name: "clocks out of the visit in progress",
+			schedules: []models.Schedule{
+				{ID: 11, ClientID: 3, Status: models.ScheduleStatusCompleted},
+				{ID: 12, ClientID: 3, Status: models.ScheduleStatusInProgress,
+					Visits: []models.Visit{{ScheduleID: 12, CaregiverID: 2, Status: models.VisitStatusInProgress}}},
+				{ID: 13, ClientID: 3, Status: models.ScheduleStatusScheduled},
+			},
+			event: models.ClockEventOut, schedule: 12, prompt: "You are clocked out. Goodbye.",
+		},
+		{
+			name: "no visit with the client",
+			schedules: []models.Schedule{
+				{ID: 10, ClientID: 4, Status: models.ScheduleStatusScheduled},
+				{ID: 11, ClientID: 3, Status: models.ScheduleStatusCancelled},
+			},
+			prompt: promptNoVisit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, clock := newTelephonyTestService()
+			clock.On("GetTodaySchedules", 2).Return(tt.schedules, nil)
+			clock.On("StartVisit", tt.schedule, mock.MatchedBy(func(req *models.VisitStartRequest) bool {
+				return *req.CaregiverID == 2 && *req.CallerClientID == 3
+			})).Return(nil)
+			clock.On("EndVisit", tt.schedule, mock.MatchedBy(func(req *models.VisitEndRequest) bool {
+				return *req.CaregiverID == 2 && *req.CallerClientID == 3
+			})).Return(nil)
+
+			// Execute
+			prompt, err := service.HandleCall(&models.TelephonyCall{From: "+62 21 555 0100", State: "pin:2", Digits: "4321"})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.prompt, prompt.Message)
+			assert.False(t, prompt.Gather)
+			assert.Equal(t, tt.event, prompt.Event)
+			if tt.event == "" {
+				assert.Nil(t, prompt.ScheduleID)
+				clock.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything)
+				clock.AssertNotCalled(t, "EndVisit", mock.Anything, mock.Anything)
+			} else {
+				if assert.NotNil(t, prompt.ScheduleID) {
+					assert.Equal(t, tt.schedule, *prompt.ScheduleID)
+				}
+			}
+		})
+	}
+}
+
+func TestTelephonyService_HandleCall_ClockRejected(t *testing.T) {
+	service, clock := newTelephonyTestService()
+	clock.On("GetTodaySchedules", 2).Return([]models.Schedule{{ID: 11, ClientID: 3, Status: models.ScheduleStatusScheduled}}, nil)
+	clock.On("StartVisit", 11, mock.Anything).Return(ErrInvalidTransition)
+
+	prompt, err := service.HandleCall(&models.TelephonyCall{From: "+62 21 555 0100", State: "pin:2", Digits: "4321"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, promptFailed, prompt.Message)
+}
+
+func TestTelephonyService_HandleCall_RequiredTasksOpen(t *testing.T) {
+	service, clock := newTelephonyTestService()
+	clock.On("GetTodaySchedules", 2).Return([]models.Schedule{{ID: 12, ClientID: 3, Status: models.ScheduleStatusInProgress,
+		Visits: []models.Visit{{ScheduleID: 12, CaregiverID: 2, Status: models.VisitStatusInProgress}}}}, nil)
+	clock.On("EndVisit", 12, mock.Anything).Return(&IncompleteTasksError{TaskIDs: []int{40}})
+
+	prompt, err := service.HandleCall(&models.TelephonyCall{From: "+62 21 555 0100", State: "pin:2", Digits: "4321"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, promptTasksIncomplete, prompt.Message)
+	assert.Nil(t, prompt.ScheduleID)
+}
+
+func TestTelephonyService_SetPIN(t *testing.T) {
+	pinRepo := new(MockTelephonyPINRepository)
+	service := NewTelephonyService(pinRepo, new(MockClientRepository), new(MockTelephonyClock), logrus.New())
+	pinRepo.On("Replace", mock.AnythingOfType("*models.CaregiverTelephonyPIN")).Return(nil)
+
+	for _, pin := range []string{"123", "123456789", "12a4", ""} {
+		_, err := service.SetPIN(2, &models.TelephonyPINRequest{PIN: pin})
+		assert.True(t, errors.Is(err, ErrValidation), "PIN %q", pin)
+	}
+
+	saved, err := service.SetPIN(2, &models.TelephonyPINRequest{PIN: "4321"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, hashPIN(saved.Salt, "4321"), saved.PINHash)
+		assert.NotEqual(t, hashPIN(saved.Salt, "1234"), saved.PINHash)
+	}
+	pinRepo.AssertNumberOfCalls(t, "Replace", 1)
+}
+
+func TestScheduleService_StartVisit_Telephony(t *testing.T) {
+	tests := []struct {
+		name     string
+		callerID int
+		method   string
+	}{
+		{"call from the client's phone", 3, models.VerificationMethodTelephony},
+		{"call from another client's phone", 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := newTeamTestService()
+			req := &models.VisitStartRequest{CallerClientID: &tt.callerID}
+			var events []*models.ClockEvent
+			service.OnClockEvent(func(event *models.ClockEvent) error {
+				events = append(events, event)
+				return nil
+			})
+
+			// Mock expectations
+			m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, ClientID: 3, StartTime: time.Now(), EndTime: time.Now().Add(time.Hour),
+				Status: models.ScheduleStatusScheduled}, nil)
+			m.visitRepo.On("StartVisit", 1, 0, 0.0, 0.0, models.VerificationMethodTelephony).Return(nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 0).Return(&models.Visit{ScheduleID: 1}, nil)
+			m.visitSegmentRepo.On("Create", mock.AnythingOfType("*models.VisitSegment")).Return(nil)
+			m.scheduleRepo.On("Update", mock.AnythingOfType("*models.Schedule")).Return(nil)
+
+			// Execute
+			err := service.StartVisit(1, req)
+
+			// Assert
+			if tt.method == "" {
+				assert.True(t, errors.Is(err, ErrVerificationFailed), "got %v", err)
+				m.visitRepo.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, tt.method, events[0].VerificationMethod)
+			}
+		})
+	}
+}
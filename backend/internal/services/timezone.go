@@ -0,0 +1,56 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"time"
+)
+
+// dayWindow is wide enough to hold the local calendar day around an instant in
+// any timezone, including days lengthened by a DST change
+const dayWindow = 26 * time.Hour
+
+// dayBounds returns the start of the local day containing t and the start of
+// the following day. Across DST changes a day is 23 or 25 hours long.
+func dayBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := t.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+	return start, end
+}
+
+// clientLocation returns the timezone of a client, or fallback when the client
+// has none or it cannot be loaded
+func clientLocation(client *models.Client, fallback *time.Location) *time.Location {
+	if client == nil {
+		return fallback
+	}
+	return timezoneLocation(client.Timezone, fallback)
+}
+
+// timezoneLocation loads an IANA timezone, or returns fallback when name is
+// empty or cannot be loaded
+func timezoneLocation(name string, fallback *time.Location) *time.Location {
+	if name == "" {
+		return fallback
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// validateTimezone checks that name is an IANA timezone; empty means the agency default
+func validateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "Local" {
+		return fmt.Errorf("invalid timezone: %s", name)
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid timezone: %s", name)
+	}
+	return nil
+}
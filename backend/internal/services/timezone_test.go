@@ -0,0 +1,58 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDayBounds_DSTTransitions(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		length time.Duration
+	}{
+		{"regular day", time.Date(2025, 3, 5, 12, 0, 0, 0, newYork), 24 * time.Hour},
+		{"spring forward", time.Date(2025, 3, 9, 12, 0, 0, 0, newYork), 23 * time.Hour},
+		{"fall back", time.Date(2025, 11, 2, 12, 0, 0, 0, newYork), 25 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		start, end := dayBounds(tt.at, newYork)
+
+		assert.Equal(t, 0, start.In(newYork).Hour(), tt.name)
+		assert.Equal(t, tt.at.Day(), start.In(newYork).Day(), tt.name)
+		assert.Equal(t, tt.length, end.Sub(start), tt.name)
+	}
+}
+
+func TestDayBounds_CrossesUTCMidnight(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	// 23:30 UTC on January 1st is already 06:30 on January 2nd in Jakarta
+	at := time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC)
+	start, end := dayBounds(at, jakarta)
+
+	assert.Equal(t, time.Date(2025, 1, 1, 17, 0, 0, 0, time.UTC), start.UTC())
+	assert.Equal(t, time.Date(2025, 1, 2, 17, 0, 0, 0, time.UTC), end.UTC())
+}
+
+func TestClientLocation(t *testing.T) {
+	fallback, _ := time.LoadLocation("Europe/London")
+
+	assert.Equal(t, fallback, clientLocation(nil, fallback))
+	assert.Equal(t, fallback, clientLocation(&models.Client{}, fallback))
+	assert.Equal(t, fallback, clientLocation(&models.Client{Timezone: "Not/AZone"}, fallback))
+	assert.Equal(t, "Asia/Tokyo", clientLocation(&models.Client{Timezone: "Asia/Tokyo"}, fallback).String())
+}
+
+func TestValidateTimezone(t *testing.T) {
+	assert.NoError(t, validateTimezone(""))
+	assert.NoError(t, validateTimezone("America/Chicago"))
+	assert.Error(t, validateTimezone("Local"))
+	assert.Error(t, validateTimezone("Mars/Olympus"))
+}
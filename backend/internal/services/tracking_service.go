@@ -0,0 +1,253 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultGeofenceRadiusMeters is how far from the client a caregiver may be
+// and still count as at the visit
+const defaultGeofenceRadiusMeters = 150.0
+
+// maxPingSkew is how far a ping's device clock may run ahead of the server,
+// or before the clock-in, before the ping is rejected
+const maxPingSkew = 2 * time.Minute
+
+// maxLocationBatch bounds the number of pings posted in one request
+const maxLocationBatch = 500
+
+// TrackingService records location pings during visits and summarizes them
+// against the client's geofence
+type TrackingService struct {
+	trackRepo    repositories.LocationTrackRepository
+	scheduleRepo repositories.ScheduleRepository
+	visitRepo    repositories.VisitRepository
+	radiusMeters float64
+	now          func() time.Time
+	logger       *logrus.Logger
+}
+
+// NewTrackingService creates a new tracking service; radiusMeters defaults to
+// defaultGeofenceRadiusMeters when not positive
+func NewTrackingService(
+	trackRepo repositories.LocationTrackRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	visitRepo repositories.VisitRepository,
+	radiusMeters float64,
+	logger *logrus.Logger,
+) *TrackingService {
+	if radiusMeters <= 0 {
+		radiusMeters = defaultGeofenceRadiusMeters
+	}
+
+	return &TrackingService{
+		trackRepo:    trackRepo,
+		scheduleRepo: scheduleRepo,
+		visitRepo:    visitRepo,
+		radiusMeters: radiusMeters,
+		now:          time.Now,
+		logger:       logger,
+	}
+}
+
+// RecordLocations stores a batch of location pings from a caregiver's
+// in-progress visit and returns the updated track summary
+func (s *TrackingService) RecordLocations(scheduleID int, req *models.VisitLocationRequest) (*models.VisitTrack, error) {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+		"points":       len(req.Points),
+	}).Debug("Recording visit locations")
+
+	if len(req.Points) == 0 || len(req.Points) > maxLocationBatch {
+		return nil, fmt.Errorf("%w: between 1 and %d points are required", ErrValidation, maxLocationBatch)
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	caregiverID := schedule.CaregiverID
+	if req.CaregiverID != nil {
+		caregiverID = *req.CaregiverID
+	}
+
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.Status != models.VisitStatusInProgress || visit.StartTime == nil {
+		return nil, fmt.Errorf("%w: caregiver %d is not clocked in", ErrInvalidTransition, caregiverID)
+	}
+
+	earliest := visit.StartTime.Add(-maxPingSkew)
+	latest := s.now().Add(maxPingSkew)
+	for i, p := range req.Points {
+		if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+			return nil, fmt.Errorf("%w: point %d has invalid coordinates", ErrValidation, i)
+		}
+		if p.RecordedAt.Before(earliest) || p.RecordedAt.After(latest) {
+			return nil, fmt.Errorf("%w: point %d was not recorded during the visit", ErrValidation, i)
+		}
+		if p.Accuracy != nil && *p.Accuracy < 0 {
+			return nil, fmt.Errorf("%w: point %d has a negative accuracy", ErrValidation, i)
+		}
+	}
+
+	if err := s.trackRepo.AddPoints(scheduleID, caregiverID, req.Points); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to save location pings")
+		return nil, fmt.Errorf("failed to save location pings: %w", err)
+	}
+
+	points, err := s.trackRepo.GetPoints(scheduleID, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location pings: %w", err)
+	}
+
+	track := summarizeTrack(scheduleID, caregiverID, points, schedule.Client, s.radiusMeters)
+	if err := s.trackRepo.SaveTrack(track); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to save visit track")
+		return nil, fmt.Errorf("failed to save visit track: %w", err)
+	}
+
+	if track.PointsOutside > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"schedule_id":         scheduleID,
+			"caregiver_id":        caregiverID,
+			"max_distance_meters": track.MaxDistanceMeters,
+		}).Warn("Caregiver left the client geofence during visit")
+	}
+	return track, nil
+}
+
+// GetVisitTracks returns the track summaries of every caregiver's visit to a
+// schedule, without the raw pings
+func (s *TrackingService) GetVisitTracks(scheduleID int) ([]models.VisitTrack, error) {
+	tracks, err := s.trackRepo.GetTracks(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit tracks: %w", err)
+	}
+	return tracks, nil
+}
+
+// GetVisitTrack returns the track of a caregiver's visit, the lead's when
+// caregiverID is nil, with the raw pings that have not been purged
+func (s *TrackingService) GetVisitTrack(scheduleID int, caregiverID *int) (*models.VisitTrack, error) {
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	id := schedule.CaregiverID
+	if caregiverID != nil {
+		id = *caregiverID
+	}
+
+	tracks, err := s.GetVisitTracks(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tracks {
+		if tracks[i].CaregiverID != id {
+			continue
+		}
+		if tracks[i].Points, err = s.trackRepo.GetPoints(scheduleID, id); err != nil {
+			return nil, fmt.Errorf("failed to get location pings: %w", err)
+		}
+		return &tracks[i], nil
+	}
+
+	return nil, fmt.Errorf("no track for caregiver %d on schedule %d: %w", id, scheduleID, ErrNotFound)
+}
+
+// PurgeExpired deletes the raw location pings recorded longer ago than
+// retention; the track summaries are kept
+func (s *TrackingService) PurgeExpired(retention time.Duration) (int64, error) {
+	deleted, err := s.trackRepo.DeletePointsBefore(s.now().Add(-retention))
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to purge location pings")
+		return 0, fmt.Errorf("failed to purge location pings: %w", err)
+	}
+
+	s.logger.WithField("count", deleted).Debug("Purged expired location pings")
+	return deleted, nil
+}
+
+// RunRetention periodically purges expired location pings until the context is cancelled
+func (s *TrackingService) RunRetention(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.PurgeExpired(retention)
+		}
+	}
+}
+
+// summarizeTrack measures pings against a geofence of radiusMeters around the
+// client. The time between two pings counts as inside or outside by where the
+// first of them was. Without client coordinates only the pings are counted.
+func summarizeTrack(scheduleID, caregiverID int, points []models.LocationPing, client *models.Client, radiusMeters float64) *models.VisitTrack {
+	track := &models.VisitTrack{
+		ScheduleID:           scheduleID,
+		CaregiverID:          caregiverID,
+		PointCount:           len(points),
+		GeofenceKnown:        hasCoordinates(client),
+		GeofenceRadiusMeters: radiusMeters,
+	}
+	if len(points) == 0 {
+		return track
+	}
+
+	sorted := append([]models.LocationPing(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].RecordedAt.Before(sorted[j].RecordedAt)
+	})
+	first, last := sorted[0].RecordedAt, sorted[len(sorted)-1].RecordedAt
+	track.FirstRecordedAt, track.LastRecordedAt = &first, &last
+	if !track.GeofenceKnown {
+		return track
+	}
+
+	var inside, outside time.Duration
+	for i, p := range sorted {
+		meters := distanceKm(client.Latitude, client.Longitude, p.Latitude, p.Longitude) * 1000
+		track.MaxDistanceMeters = math.Max(track.MaxDistanceMeters, meters)
+		within := meters <= radiusMeters
+		if !within {
+			track.PointsOutside++
+		}
+
+		if i+1 < len(sorted) {
+			gap := sorted[i+1].RecordedAt.Sub(p.RecordedAt)
+			if within {
+				inside += gap
+			} else {
+				outside += gap
+			}
+		}
+	}
+
+	track.InsideMinutes = math.Round(inside.Minutes()*10) / 10
+	track.OutsideMinutes = math.Round(outside.Minutes()*10) / 10
+	track.MaxDistanceMeters = math.Round(track.MaxDistanceMeters)
+	return track
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLocationTrackRepository is a mock implementation of LocationTrackRepository
+type MockLocationTrackRepository struct {
+	mock.Mock
+}
+
+func (m *MockLocationTrackRepository) AddPoints(scheduleID, caregiverID int, points []models.LocationPing) error {
+	args := m.Called(scheduleID, caregiverID, points)
+	return args.Error(0)
+}
+
+func (m *MockLocationTrackRepository) GetPoints(scheduleID, caregiverID int) ([]models.LocationPing, error) {
+	args := m.Called(scheduleID, caregiverID)
+	return args.Get(0).([]models.LocationPing), args.Error(1)
+}
+
+func (m *MockLocationTrackRepository) DeletePointsBefore(cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLocationTrackRepository) GetTracks(scheduleID int) ([]models.VisitTrack, error) {
+	args := m.Called(scheduleID)
+	return args.Get(0).([]models.VisitTrack), args.Error(1)
+}
+
+func (m *MockLocationTrackRepository) SaveTrack(track *models.VisitTrack) error {
+	args := m.Called(track)
+	return args.Error(0)
+}
+
+func TestSummarizeTrack(t *testing.T) {
+	client := &models.Client{Latitude: 1, Longitude: 0}
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	ping := func(minutes int, lng float64) models.LocationPing {
+		return models.LocationPing{Latitude: 1, Longitude: lng, RecordedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	// About 110 m east is inside, about 1.1 km east is outside; pings arrive out of order
+	points := []models.LocationPing{ping(10, 0.01), ping(0, 0), ping(5, 0.001), ping(25, 0)}
+	track := summarizeTrack(3, 1, points, client, 150)
+	assert.True(t, track.GeofenceKnown)
+	assert.Equal(t, 4, track.PointCount)
+	assert.Equal(t, 1, track.PointsOutside)
+	assert.Equal(t, 10.0, track.InsideMinutes)
+	assert.Equal(t, 15.0, track.OutsideMinutes)
+	assert.InDelta(t, 1112, track.MaxDistanceMeters, 1)
+	assert.True(t, track.FirstRecordedAt.Equal(start))
+	assert.True(t, track.LastRecordedAt.Equal(start.Add(25*time.Minute)))
+
+	unknown := summarizeTrack(3, 1, points, &models.Client{}, 150)
+	assert.False(t, unknown.GeofenceKnown)
+	assert.Equal(t, 4, unknown.PointCount)
+	assert.Zero(t, unknown.InsideMinutes+unknown.OutsideMinutes)
+}
+
+func TestTrackingService_RecordLocations(t *testing.T) {
+	trackRepo := new(MockLocationTrackRepository)
+	scheduleRepo := new(MockScheduleRepository)
+	visitRepo := new(MockVisitRepository)
+	service := NewTrackingService(trackRepo, scheduleRepo, visitRepo, 0, logrus.New())
+	now := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	clockIn := now.Add(-time.Hour)
+	scheduleRepo.On("GetByID", 3).Return(&models.Schedule{ID: 3, CaregiverID: 1, Client: &models.Client{Latitude: 1, Longitude: 0}}, nil)
+	scheduleRepo.On("GetByID", 4).Return(nil, nil)
+	visitRepo.On("GetByScheduleAndCaregiver", 3, 1).Return(&models.Visit{ScheduleID: 3, CaregiverID: 1,
+		Status: models.VisitStatusInProgress, StartTime: &clockIn}, nil)
+	visitRepo.On("GetByScheduleAndCaregiver", 3, 2).Return(nil, nil)
+
+	points := []models.LocationPing{{Latitude: 1, Longitude: 0.01, RecordedAt: now.Add(-10 * time.Minute)}}
+	stored := []models.LocationPing{{Latitude: 1, Longitude: 0, RecordedAt: clockIn}, points[0]}
+	trackRepo.On("AddPoints", 3, 1, points).Return(nil).Once()
+	trackRepo.On("GetPoints", 3, 1).Return(stored, nil).Once()
+	trackRepo.On("SaveTrack", mock.MatchedBy(func(track *models.VisitTrack) bool {
+		return track.GeofenceRadiusMeters == defaultGeofenceRadiusMeters && track.PointCount == 2 && track.InsideMinutes == 50
+	})).Return(nil).Once()
+
+	track, err := service.RecordLocations(3, &models.VisitLocationRequest{Points: points})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, track.PointsOutside)
+
+	_, err = service.RecordLocations(3, &models.VisitLocationRequest{Points: []models.LocationPing{
+		{Latitude: 1, Longitude: 0, RecordedAt: clockIn.Add(-time.Hour)},
+	}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.RecordLocations(3, &models.VisitLocationRequest{Points: []models.LocationPing{
+		{Latitude: 91, Longitude: 0, RecordedAt: now},
+	}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	other := 2
+	_, err = service.RecordLocations(3, &models.VisitLocationRequest{Points: points, CaregiverID: &other})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = service.RecordLocations(4, &models.VisitLocationRequest{Points: points})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = service.RecordLocations(3, &models.VisitLocationRequest{})
+	assert.ErrorIs(t, err, ErrValidation)
+	trackRepo.AssertExpectations(t)
+}
+
+func TestTrackingService_PurgeExpired(t *testing.T) {
+	trackRepo := new(MockLocationTrackRepository)
+	service := NewTrackingService(trackRepo, new(MockScheduleRepository), new(MockVisitRepository), 150, logrus.New())
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	trackRepo.On("DeletePointsBefore", now.AddDate(0, 0, -30)).Return(int64(42), nil)
+
+	deleted, err := service.PurgeExpired(30 * 24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), deleted)
+	trackRepo.AssertExpectations(t)
+}
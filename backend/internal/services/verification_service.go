@@ -0,0 +1,211 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// verificationCodeDigits and verificationCodePeriod are the TOTP parameters
+	// of client verification codes, the defaults of authenticator apps
+	verificationCodeDigits = 6
+	verificationCodePeriod = 30 * time.Second
+
+	// verificationSecretBytes is the length of a verification token secret,
+	// the HMAC-SHA1 block size recommended by RFC 4226
+	verificationSecretBytes = 20
+
+	// defaultVerificationSkewSteps is how many periods a code may be behind or
+	// ahead of the server clock, covering drift on the card or tag and the time
+	// taken to read and send it
+	defaultVerificationSkewSteps = 1
+
+	// verificationIssuer labels the tokens in authenticator apps
+	verificationIssuer = "Caregiver Shift Tracker"
+)
+
+// secretEncoding is the unpadded base32 used for TOTP secrets
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// VerificationService issues the verification tokens placed in clients' homes
+// and checks the codes caregivers read from them when clocking in or out
+type VerificationService struct {
+	tokenRepo  repositories.ClientVerificationTokenRepository
+	clientRepo repositories.ClientRepository
+	skewSteps  int
+	now        func() time.Time
+	logger     *logrus.Logger
+}
+
+// NewVerificationService creates a new verification service; skewSteps falls
+// back to its default when negative
+func NewVerificationService(
+	tokenRepo repositories.ClientVerificationTokenRepository,
+	clientRepo repositories.ClientRepository,
+	skewSteps int,
+	logger *logrus.Logger,
+) *VerificationService {
+	if skewSteps < 0 {
+		skewSteps = defaultVerificationSkewSteps
+	}
+
+	return &VerificationService{
+		tokenRepo:  tokenRepo,
+		clientRepo: clientRepo,
+		skewSteps:  skewSteps,
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+// IssueToken creates a new verification token for a client, replacing any
+// earlier one so that lost or copied cards stop working. The secret is only
+// returned here, to be written to the client's QR card or NFC tag.
+func (s *VerificationService) IssueToken(clientID int) (*models.ClientVerificationToken, error) {
+	s.logger.WithField("client_id", clientID).Info("Issuing client verification token")
+
+	client, err := s.clientRepo.GetByID(clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	if client == nil {
+		return nil, fmt.Errorf("client %d: %w", clientID, ErrNotFound)
+	}
+
+	secret := make([]byte, verificationSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate verification secret: %w", err)
+	}
+
+	token := &models.ClientVerificationToken{ClientID: clientID, Secret: secretEncoding.EncodeToString(secret)}
+	if err := s.tokenRepo.Replace(token); err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to save client verification token")
+		return nil, fmt.Errorf("failed to save client verification token: %w", err)
+	}
+
+	describeToken(token)
+	token.ProvisioningURI = provisioningURI(client, token.Secret)
+
+	s.logger.WithField("client_id", clientID).Info("Successfully issued client verification token")
+	return token, nil
+}
+
+// GetToken returns a client's verification token without its secret
+func (s *VerificationService) GetToken(clientID int) (*models.ClientVerificationToken, error) {
+	token, err := s.tokenRepo.GetByClientID(clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client verification token: %w", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("verification token of client %d: %w", clientID, ErrNotFound)
+	}
+
+	token.Secret = ""
+	describeToken(token)
+	return token, nil
+}
+
+// RevokeToken removes a client's verification token; caregivers then have to
+// verify their visits to the client by location
+func (s *VerificationService) RevokeToken(clientID int) error {
+	s.logger.WithField("client_id", clientID).Info("Revoking client verification token")
+
+	token, err := s.tokenRepo.GetByClientID(clientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client verification token: %w", err)
+	}
+	if token == nil {
+		return fmt.Errorf("verification token of client %d: %w", clientID, ErrNotFound)
+	}
+
+	if err := s.tokenRepo.Delete(clientID); err != nil {
+		s.logger.WithError(err).WithField("client_id", clientID).Error("Failed to revoke client verification token")
+		return fmt.Errorf("failed to revoke client verification token: %w", err)
+	}
+	return nil
+}
+
+// VerifyCode checks a code read from a client's verification token at a
+// time, accepting the codes of skewSteps periods before and after it. Codes
+// are not single use: every caregiver of a team visit reads the same one.
+func (s *VerificationService) VerifyCode(clientID int, code string, at time.Time) error {
+	code = strings.TrimSpace(code)
+	if len(code) != verificationCodeDigits || strings.Trim(code, "0123456789") != "" {
+		return fmt.Errorf("%w: verification code must be %d digits", ErrVerificationFailed, verificationCodeDigits)
+	}
+
+	token, err := s.tokenRepo.GetByClientID(clientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client verification token: %w", err)
+	}
+	if token == nil {
+		return fmt.Errorf("%w: client %d has no verification token", ErrVerificationFailed, clientID)
+	}
+	secret, err := secretEncoding.DecodeString(token.Secret)
+	if err != nil {
+		return fmt.Errorf("invalid verification secret of client %d: %w", clientID, err)
+	}
+
+	step := at.Unix() / int64(verificationCodePeriod/time.Second)
+	for offset := -s.skewSteps; offset <= s.skewSteps; offset++ {
+		if step+int64(offset) < 0 {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(secret, uint64(step+int64(offset)))), []byte(code)) {
+			return nil
+		}
+	}
+
+	s.logger.WithField("client_id", clientID).Warn("Invalid client verification code")
+	return fmt.Errorf("%w: verification code is invalid or expired", ErrVerificationFailed)
+}
+
+// describeToken fills in the TOTP parameters shown with a token
+func describeToken(token *models.ClientVerificationToken) {
+	token.Digits = verificationCodeDigits
+	token.PeriodSeconds = int(verificationCodePeriod / time.Second)
+}
+
+// provisioningURI returns the otpauth URI encoded in a client's QR card or
+// NFC tag, in the Key URI Format understood by authenticator apps
+func provisioningURI(client *models.Client, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", verificationIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(verificationCodeDigits))
+	query.Set("period", fmt.Sprint(int(verificationCodePeriod/time.Second)))
+
+	label := url.PathEscape(fmt.Sprintf("%s:client-%d", verificationIssuer, client.ID))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// totpCode computes the code of a time step with HOTP dynamic truncation (RFC 4226)
+func totpCode(secret []byte, step uint64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], step)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < verificationCodeDigits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", verificationCodeDigits, value%modulus)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockClientVerificationTokenRepository is a mock implementation of ClientVerificationTokenRepository
+type MockClientVerificationTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockClientVerificationTokenRepository) GetByClientID(clientID int) (*models.ClientVerificationToken, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClientVerificationToken), args.Error(1)
+}
+
+func (m *MockClientVerificationTokenRepository) Replace(token *models.ClientVerificationToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockClientVerificationTokenRepository) Delete(clientID int) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+
+// MockVisitVerifier is a mock implementation of VisitVerifier
+type MockVisitVerifier struct {
+	mock.Mock
+}
+
+func (m *MockVisitVerifier) VerifyCode(clientID int, code string, at time.Time) error {
+	args := m.Called(clientID, code, at)
+	return args.Error(0)
+}
+
+// rfcSecret is the SHA-1 secret of the RFC 6238 test vectors
+var rfcSecret = []byte("12345678901234567890")
+
+func TestTotpCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes; 6 digit codes are their last six digits
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		assert.Equal(t, want, totpCode(rfcSecret, uint64(unix/30)), "time %d", unix)
+	}
+}
+
+func TestVerificationService_VerifyCode(t *testing.T) {
+	tokenRepo := new(MockClientVerificationTokenRepository)
+	service := NewVerificationService(tokenRepo, new(MockClientRepository), 1, logrus.New())
+	tokenRepo.On("GetByClientID", 7).Return(&models.ClientVerificationToken{ClientID: 7, Secret: secretEncoding.EncodeToString(rfcSecret)}, nil)
+	tokenRepo.On("GetByClientID", 8).Return(nil, nil)
+
+	at := time.Unix(1111111109, 0)
+	step := uint64(at.Unix() / 30)
+
+	tests := []struct {
+		name     string
+		clientID int
+		code     string
+		valid    bool
+	}{
+		{"current code", 7, totpCode(rfcSecret, step), true},
+		{"previous step within skew", 7, totpCode(rfcSecret, step-1), true},
+		{"next step within skew", 7, " " + totpCode(rfcSecret, step+1) + " ", true},
+		{"two steps old", 7, totpCode(rfcSecret, step-2), false},
+		{"not six digits", 7, "12345a", false},
+		{"client without token", 8, "123456", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.VerifyCode(tt.clientID, tt.code, at)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrVerificationFailed), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerificationService_IssueToken(t *testing.T) {
+	tokenRepo := new(MockClientVerificationTokenRepository)
+	clientRepo := new(MockClientRepository)
+	service := NewVerificationService(tokenRepo, clientRepo, 1, logrus.New())
+
+	clientRepo.On("GetByID", 7).Return(&models.Client{ID: 7, Name: "Ani"}, nil)
+	clientRepo.On("GetByID", 9).Return(nil, nil)
+	tokenRepo.On("Replace", mock.AnythingOfType("*models.ClientVerificationToken")).Return(nil)
+
+	token, err := service.IssueToken(7)
+	if assert.NoError(t, err) {
+		secret, err := secretEncoding.DecodeString(token.Secret)
+		assert.NoError(t, err)
+		assert.Len(t, secret, verificationSecretBytes)
+		assert.Equal(t, 6, token.Digits)
+		assert.Equal(t, 30, token.PeriodSeconds)
+		assert.True(t, strings.HasPrefix(token.ProvisioningURI, "otpauth://totp/"))
+		assert.Contains(t, token.ProvisioningURI, "secret="+token.Secret)
+	}
+
+	_, err = service.IssueToken(9)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	tokenRepo.AssertNumberOfCalls(t, "Replace", 1)
+}
+
+func TestScheduleService_StartVisit_VerificationMethods(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *models.VisitStartRequest
+		verifier  bool
+		codeValid bool
+		method    string
+	}{
+		{"location only", &models.VisitStartRequest{Latitude: -6.2, Longitude: 106.8}, true, true, models.VerificationMethodGPS},
+		{"code only", &models.VisitStartRequest{VerificationCode: "123456"}, true, true, models.VerificationMethodToken},
+		{"location and code", &models.VisitStartRequest{Latitude: -6.2, Longitude: 106.8, VerificationCode: "123456"}, true, true, models.VerificationMethodGPSAndToken},
+		{"neither", &models.VisitStartRequest{}, true, true, ""},
+		{"invalid code", &models.VisitStartRequest{Latitude: -6.2, Longitude: 106.8, VerificationCode: "123456"}, true, false, ""},
+		{"code without verifier", &models.VisitStartRequest{VerificationCode: "123456"}, false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := newTeamTestService()
+			clockIn := time.Now()
+			if tt.verifier {
+				verifier := new(MockVisitVerifier)
+				var verifyErr error
+				if !tt.codeValid {
+					verifyErr = ErrVerificationFailed
+				}
+				verifier.On("VerifyCode", 3, "123456", mock.Anything).Return(verifyErr)
+				service.UseVisitVerifier(verifier)
+			}
+			var events []*models.ClockEvent
+			service.OnClockEvent(func(event *models.ClockEvent) error {
+				events = append(events, event)
+				return nil
+			})
+
+			// Mock expectations
+			m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, ClientID: 3, StartTime: clockIn, EndTime: clockIn.Add(time.Hour),
+				Status: models.ScheduleStatusScheduled}, nil)
+			m.visitRepo.On("StartVisit", 1, 0, tt.req.Latitude, tt.req.Longitude, tt.method).Return(nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 0).Return(&models.Visit{ScheduleID: 1, StartTime: &clockIn}, nil)
+			m.visitSegmentRepo.On("Create", mock.AnythingOfType("*models.VisitSegment")).Return(nil)
+			m.scheduleRepo.On("Update", mock.AnythingOfType("*models.Schedule")).Return(nil)
+
+			// Execute
+			err := service.StartVisit(1, tt.req)
+
+			// Assert
+			if tt.method == "" {
+				assert.True(t, errors.Is(err, ErrVerificationFailed), "got %v", err)
+				m.visitRepo.AssertNotCalled(t, "StartVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, tt.method, events[0].VerificationMethod)
+			}
+		})
+	}
+}
+
+func TestAnomalyService_EvaluateClockEvent_TokenOnly(t *testing.T) {
+	eventRepo := new(MockClockEventRepository)
+	service := NewAnomalyService(eventRepo, new(MockVisitReviewRepository), new(MockScheduleRepository), 0, 0, logrus.New())
+	eventRepo.On("Create", mock.AnythingOfType("*models.ClockEvent")).Return(nil)
+
+	// A code-verified clock-in has no coordinates, which would otherwise look rounded
+	event := &models.ClockEvent{ScheduleID: 1, CaregiverID: 2, ClientID: 3, Event: models.ClockEventIn,
+		RecordedAt: time.Now(), VerificationMethod: models.VerificationMethodToken}
+	err := service.EvaluateClockEvent(event)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, event.RiskScore)
+	assert.Empty(t, event.Signals)
+	eventRepo.AssertNotCalled(t, "GetLastByCaregiver", mock.Anything, mock.Anything)
+	eventRepo.AssertExpectations(t)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkCorrectedTimes checks the clock-in and clock-out a visit would have
+// after a correction; the clock-out may still be missing
+func checkCorrectedTimes(start, end *time.Time, now time.Time) error {
+	if start == nil {
+		return fmt.Errorf("%w: the visit has no clock-in", ErrValidation)
+	}
+	if start.After(now) || (end != nil && end.After(now)) {
+		return fmt.Errorf("%w: corrected times must not be in the future", ErrValidation)
+	}
+	if end != nil && !end.After(*start) {
+		return fmt.Errorf("%w: clock-out must be after clock-in", ErrValidation)
+	}
+	return nil
+}
+
+// AmendVisit replaces a caregiver's clock-in, clock-out or both with
+// corrected times; a nil time keeps the current one. The times first recorded
+// are kept on the visit. A corrected clock-out ends a visit still in progress,
+// completing the schedule once no caregiver of the team is clocked in.
+func (s *ScheduleService) AmendVisit(scheduleID, caregiverID int, start, end *time.Time, reason string) (*models.Visit, error) {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": caregiverID,
+		"start_time":   start,
+		"end_time":     end,
+	}).Info("Amending visit")
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.StartTime == nil {
+		return nil, fmt.Errorf("%w: caregiver %d has not clocked in", ErrInvalidTransition, caregiverID)
+	}
+
+	correctedStart, correctedEnd := visit.StartTime, visit.EndTime
+	if start != nil {
+		t := start.UTC()
+		correctedStart = &t
+	}
+	if end != nil {
+		t := end.UTC()
+		correctedEnd = &t
+	}
+	now := s.now().UTC()
+	if err := checkCorrectedTimes(correctedStart, correctedEnd, now); err != nil {
+		return nil, err
+	}
+
+	// Only the first amendment records the original times
+	if visit.AmendedAt == nil {
+		visit.OriginalStartTime, visit.OriginalEndTime = visit.StartTime, visit.EndTime
+	}
+	ending := visit.Status == models.VisitStatusInProgress && correctedEnd != nil
+	visit.StartTime, visit.EndTime = correctedStart, correctedEnd
+	visit.AmendedAt = &now
+	visit.AmendmentReason = reason
+	if ending {
+		visit.Status = models.VisitStatusCompleted
+	}
+	if err := s.visitRepo.Update(visit); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to amend visit")
+		return nil, fmt.Errorf("failed to amend visit: %w", err)
+	}
+
+	if ending {
+		if err := s.visitSegmentRepo.Close(scheduleID, caregiverID, *correctedEnd, 0, 0); err != nil {
+			return nil, fmt.Errorf("failed to close visit segment: %w", err)
+		}
+		if err := s.completeIfNoneClockedIn(schedule); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": caregiverID,
+	}).Info("Successfully amended visit")
+	return visit, nil
+}
+
+// completeIfNoneClockedIn completes an in-progress schedule once no caregiver
+// of its team is still clocked in
+func (s *ScheduleService) completeIfNoneClockedIn(schedule *models.Schedule) error {
+	if schedule.Status != models.ScheduleStatusInProgress {
+		return nil
+	}
+
+	visits, err := s.visitRepo.GetAllByScheduleID(schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get visits: %w", err)
+	}
+	for _, v := range visits {
+		if v.Status == models.VisitStatusInProgress {
+			return nil
+		}
+	}
+
+	transition, err := s.states.Fire(schedule, ScheduleEventEnd)
+	if err != nil {
+		return err
+	}
+	return s.applyTransition(schedule, transition)
+}
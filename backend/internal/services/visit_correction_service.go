@@ -0,0 +1,237 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"caregiver-shift-tracker/internal/repositories"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// VisitAmender applies corrected clock times to a caregiver's visit; it is
+// implemented by ScheduleService
+type VisitAmender interface {
+	AmendVisit(scheduleID, caregiverID int, start, end *time.Time, reason string) (*models.Visit, error)
+}
+
+// VisitCorrectionService handles caregivers' requests to correct the clock-in
+// or clock-out of a visit, e.g. after forgetting to clock out, and their
+// approval by a supervisor
+type VisitCorrectionService struct {
+	correctionRepo repositories.VisitCorrectionRepository
+	scheduleRepo   repositories.ScheduleRepository
+	visitRepo      repositories.VisitRepository
+	amender        VisitAmender
+	states         *StateMachine[*models.VisitCorrection]
+	now            func() time.Time
+	logger         *logrus.Logger
+}
+
+// NewVisitCorrectionService creates a new visit correction service
+func NewVisitCorrectionService(
+	correctionRepo repositories.VisitCorrectionRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	visitRepo repositories.VisitRepository,
+	amender VisitAmender,
+	logger *logrus.Logger,
+) *VisitCorrectionService {
+	s := &VisitCorrectionService{
+		correctionRepo: correctionRepo,
+		scheduleRepo:   scheduleRepo,
+		visitRepo:      visitRepo,
+		amender:        amender,
+		states:         NewVisitCorrectionStateMachine(),
+		now:            time.Now,
+		logger:         logger,
+	}
+	s.states.now = func() time.Time { return s.now() }
+
+	return s
+}
+
+// RequestCorrection records a caregiver's pending correction of their visit
+// on a schedule. The visit must have been clocked in to, and only one
+// correction of a visit can be pending at a time.
+func (s *VisitCorrectionService) RequestCorrection(scheduleID int, req *models.VisitCorrectionRequest) (*models.VisitCorrection, error) {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+		"reason_code":  req.ReasonCode,
+	}).Info("Requesting visit correction")
+
+	switch req.ReasonCode {
+	case models.CorrectionReasonForgotClockIn, models.CorrectionReasonForgotClockOut,
+		models.CorrectionReasonDeviceIssue, models.CorrectionReasonWrongTime, models.CorrectionReasonOther:
+	default:
+		return nil, fmt.Errorf("%w: unknown reason code %q", ErrValidation, req.ReasonCode)
+	}
+	if req.StartTime == nil && req.EndTime == nil {
+		return nil, fmt.Errorf("%w: start_time or end_time is required", ErrValidation)
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+
+	caregiverID := schedule.CaregiverID
+	if req.CaregiverID != nil {
+		caregiverID = *req.CaregiverID
+	}
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.StartTime == nil {
+		return nil, fmt.Errorf("%w: caregiver %d has not clocked in to schedule %d", ErrInvalidTransition, caregiverID, scheduleID)
+	}
+
+	correction := &models.VisitCorrection{
+		ScheduleID:        scheduleID,
+		CaregiverID:       caregiverID,
+		VisitID:           visit.ID,
+		RecordedStartTime: visit.StartTime,
+		RecordedEndTime:   visit.EndTime,
+		ReasonCode:        req.ReasonCode,
+		Notes:             req.Notes,
+		Status:            models.CorrectionStatusPending,
+	}
+	start, end := visit.StartTime, visit.EndTime
+	if req.StartTime != nil {
+		t := req.StartTime.UTC()
+		correction.ProposedStartTime, start = &t, &t
+	}
+	if req.EndTime != nil {
+		t := req.EndTime.UTC()
+		correction.ProposedEndTime, end = &t, &t
+	}
+	if err := checkCorrectedTimes(start, end, s.now()); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.correctionRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit corrections: %w", err)
+	}
+	for _, other := range existing {
+		if other.CaregiverID == caregiverID && other.Status == models.CorrectionStatusPending {
+			return nil, fmt.Errorf("%w: correction %d of this visit is already pending", ErrInvalidTransition, other.ID)
+		}
+	}
+
+	if err := s.correctionRepo.Create(correction); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to create visit correction")
+		return nil, fmt.Errorf("failed to create visit correction: %w", err)
+	}
+
+	s.logger.WithField("correction_id", correction.ID).Info("Successfully requested visit correction")
+	return correction, nil
+}
+
+// GetCorrections lists the visit corrections in a status, oldest first
+func (s *VisitCorrectionService) GetCorrections(status string) ([]models.VisitCorrection, error) {
+	if !s.states.IsValidState(status) {
+		return nil, fmt.Errorf("%w: unknown correction status %q", ErrValidation, status)
+	}
+
+	corrections, err := s.correctionRepo.GetByStatus(status)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get visit corrections")
+		return nil, fmt.Errorf("failed to get visit corrections: %w", err)
+	}
+	if corrections == nil {
+		corrections = []models.VisitCorrection{}
+	}
+
+	return corrections, nil
+}
+
+// GetScheduleCorrections lists the visit corrections requested on a schedule
+func (s *VisitCorrectionService) GetScheduleCorrections(scheduleID int) ([]models.VisitCorrection, error) {
+	corrections, err := s.correctionRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to get visit corrections")
+		return nil, fmt.Errorf("failed to get visit corrections: %w", err)
+	}
+	if corrections == nil {
+		corrections = []models.VisitCorrection{}
+	}
+
+	return corrections, nil
+}
+
+// GetCorrection retrieves a visit correction
+func (s *VisitCorrectionService) GetCorrection(id int) (*models.VisitCorrection, error) {
+	correction, err := s.correctionRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visit correction: %w", err)
+	}
+	if correction == nil {
+		return nil, fmt.Errorf("visit correction %d: %w", id, ErrNotFound)
+	}
+
+	return correction, nil
+}
+
+// ApproveCorrection approves a pending correction and amends the visit with
+// its times, keeping the times first recorded
+func (s *VisitCorrectionService) ApproveCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	return s.reviewCorrection(id, CorrectionEventApprove, req)
+}
+
+// RejectCorrection rejects a pending correction, leaving the visit as recorded
+func (s *VisitCorrectionService) RejectCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	return s.reviewCorrection(id, CorrectionEventReject, req)
+}
+
+// CancelCorrection withdraws a pending correction
+func (s *VisitCorrectionService) CancelCorrection(id int, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	return s.reviewCorrection(id, CorrectionEventCancel, req)
+}
+
+// reviewCorrection applies a review event to a visit correction
+func (s *VisitCorrectionService) reviewCorrection(id int, event string, req *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error) {
+	s.logger.WithFields(logrus.Fields{
+		"correction_id": id,
+		"event":         event,
+	}).Info("Reviewing visit correction")
+
+	correction, err := s.GetCorrection(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != correction.Version {
+		return nil, fmt.Errorf("visit correction %d is at version %d: %w", id, correction.Version, ErrVersionConflict)
+	}
+
+	transition, err := s.states.Fire(correction, event)
+	if err != nil {
+		s.logger.WithError(err).WithField("correction_id", id).Warn("Visit correction cannot be reviewed")
+		return nil, err
+	}
+
+	if event == CorrectionEventApprove {
+		if _, err := s.amender.AmendVisit(correction.ScheduleID, correction.CaregiverID,
+			correction.ProposedStartTime, correction.ProposedEndTime, correction.ReasonCode); err != nil {
+			s.logger.WithError(err).WithField("correction_id", id).Warn("Visit could not be amended")
+			return nil, err
+		}
+	}
+
+	correction.Status = transition.To
+	correction.ReviewNotes = req.Notes
+	correction.ReviewedAt = &transition.At
+	if err := s.correctionRepo.Update(correction); err != nil {
+		s.logger.WithError(err).WithField("correction_id", id).Error("Failed to update visit correction")
+		return nil, fmt.Errorf("failed to update visit correction: %w", err)
+	}
+	if err := s.states.Complete(correction, transition); err != nil {
+		s.logger.WithError(err).WithField("correction_id", id).Warn("Visit correction transition hook failed")
+	}
+
+	return correction, nil
+}
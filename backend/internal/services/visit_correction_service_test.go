@@ -0,0 +1,334 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockVisitCorrectionRepository is a mock implementation of VisitCorrectionRepository
+type MockVisitCorrectionRepository struct {
+	mock.Mock
+}
+
+func (m *MockVisitCorrectionRepository) GetByID(id int) (*models.VisitCorrection, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionRepository) GetByStatus(status string) ([]models.VisitCorrection, error) {
+	args := m.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionRepository) GetByScheduleID(scheduleID int) ([]models.VisitCorrection, error) {
+	args := m.Called(scheduleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.VisitCorrection), args.Error(1)
+}
+
+func (m *MockVisitCorrectionRepository) Create(correction *models.VisitCorrection) error {
+	args := m.Called(correction)
+	return args.Error(0)
+}
+
+func (m *MockVisitCorrectionRepository) Update(correction *models.VisitCorrection) error {
+	args := m.Called(correction)
+	return args.Error(0)
+}
+
+// MockVisitAmender is a mock implementation of VisitAmender
+type MockVisitAmender struct {
+	mock.Mock
+}
+
+func (m *MockVisitAmender) AmendVisit(scheduleID, caregiverID int, start, end *time.Time, reason string) (*models.Visit, error) {
+	args := m.Called(scheduleID, caregiverID, start, end, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Visit), args.Error(1)
+}
+
+type correctionTestMocks struct {
+	correctionRepo *MockVisitCorrectionRepository
+	scheduleRepo   *MockScheduleRepository
+	visitRepo      *MockVisitRepository
+	amender        *MockVisitAmender
+}
+
+func newCorrectionTestService() (*VisitCorrectionService, *correctionTestMocks) {
+	m := &correctionTestMocks{
+		correctionRepo: new(MockVisitCorrectionRepository),
+		scheduleRepo:   new(MockScheduleRepository),
+		visitRepo:      new(MockVisitRepository),
+		amender:        new(MockVisitAmender),
+	}
+	service := NewVisitCorrectionService(m.correctionRepo, m.scheduleRepo, m.visitRepo, m.amender, logrus.New())
+	return service, m
+}
+
+func TestVisitCorrectionService_RequestCorrection(t *testing.T) {
+	clockIn := time.Now().Add(-10 * time.Hour).UTC()
+	leftAt := clockIn.Add(2 * time.Hour)
+	future := time.Now().Add(time.Hour)
+	beforeClockIn := clockIn.Add(-time.Hour)
+	member := 2
+
+	tests := []struct {
+		name    string
+		req     *models.VisitCorrectionRequest
+		pending bool
+		wantErr error
+	}{
+		{"forgot to clock out", &models.VisitCorrectionRequest{EndTime: &leftAt, ReasonCode: models.CorrectionReasonForgotClockOut}, false, nil},
+		{"unknown reason", &models.VisitCorrectionRequest{EndTime: &leftAt, ReasonCode: "overslept"}, false, ErrValidation},
+		{"no times", &models.VisitCorrectionRequest{ReasonCode: models.CorrectionReasonOther}, false, ErrValidation},
+		{"clock-out in the future", &models.VisitCorrectionRequest{EndTime: &future, ReasonCode: models.CorrectionReasonWrongTime}, false, ErrValidation},
+		{"clock-out before clock-in", &models.VisitCorrectionRequest{EndTime: &beforeClockIn, ReasonCode: models.CorrectionReasonWrongTime}, false, ErrValidation},
+		{"caregiver not clocked in", &models.VisitCorrectionRequest{CaregiverID: &member, EndTime: &leftAt, ReasonCode: models.CorrectionReasonForgotClockOut}, false, ErrInvalidTransition},
+		{"correction already pending", &models.VisitCorrectionRequest{EndTime: &leftAt, ReasonCode: models.CorrectionReasonForgotClockOut}, true, ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := newCorrectionTestService()
+			var existing []models.VisitCorrection
+			if tt.pending {
+				existing = []models.VisitCorrection{{ID: 4, ScheduleID: 1, CaregiverID: 1, Status: models.CorrectionStatusPending}}
+			}
+
+			// Mock expectations: the lead is still clocked in
+			m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 1).Return(&models.Visit{ID: 7, ScheduleID: 1, CaregiverID: 1,
+				StartTime: &clockIn, Status: models.VisitStatusInProgress}, nil)
+			m.visitRepo.On("GetByScheduleAndCaregiver", 1, 2).Return(nil, nil)
+			m.correctionRepo.On("GetByScheduleID", 1).Return(existing, nil)
+			m.correctionRepo.On("Create", mock.AnythingOfType("*models.VisitCorrection")).Return(nil)
+
+			// Execute
+			correction, err := service.RequestCorrection(1, tt.req)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				m.correctionRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, models.CorrectionStatusPending, correction.Status)
+			assert.Equal(t, 7, correction.VisitID)
+			assert.Equal(t, clockIn, *correction.RecordedStartTime)
+			assert.Nil(t, correction.RecordedEndTime)
+			assert.Nil(t, correction.ProposedStartTime)
+			assert.Equal(t, leftAt, *correction.ProposedEndTime)
+		})
+	}
+}
+
+func TestVisitCorrectionService_Review(t *testing.T) {
+	leftAt := time.Now().Add(-8 * time.Hour).UTC()
+
+	tests := []struct {
+		name    string
+		status  string
+		review  func(*VisitCorrectionService, int, *models.VisitCorrectionReviewRequest) (*models.VisitCorrection, error)
+		want    string
+		amends  bool
+		wantErr error
+	}{
+		{"approve", models.CorrectionStatusPending, (*VisitCorrectionService).ApproveCorrection, models.CorrectionStatusApproved, true, nil},
+		{"reject", models.CorrectionStatusPending, (*VisitCorrectionService).RejectCorrection, models.CorrectionStatusRejected, false, nil},
+		{"cancel", models.CorrectionStatusPending, (*VisitCorrectionService).CancelCorrection, models.CorrectionStatusCancelled, false, nil},
+		{"approve twice", models.CorrectionStatusApproved, (*VisitCorrectionService).ApproveCorrection, "", false, ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := newCorrectionTestService()
+
+			// Mock expectations
+			m.correctionRepo.On("GetByID", 4).Return(&models.VisitCorrection{ID: 4, ScheduleID: 1, CaregiverID: 2,
+				ProposedEndTime: &leftAt, ReasonCode: models.CorrectionReasonForgotClockOut, Status: tt.status, Version: 1}, nil)
+			m.amender.On("AmendVisit", 1, 2, (*time.Time)(nil), &leftAt, models.CorrectionReasonForgotClockOut).Return(&models.Visit{}, nil)
+			m.correctionRepo.On("Update", mock.AnythingOfType("*models.VisitCorrection")).Return(nil)
+
+			// Execute
+			correction, err := tt.review(service, 4, &models.VisitCorrectionReviewRequest{Notes: "Checked with the client"})
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				m.correctionRepo.AssertNotCalled(t, "Update", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, correction.Status)
+				assert.Equal(t, "Checked with the client", correction.ReviewNotes)
+				assert.NotNil(t, correction.ReviewedAt)
+			}
+			if tt.amends {
+				m.amender.AssertExpectations(t)
+			} else {
+				m.amender.AssertNotCalled(t, "AmendVisit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestVisitCorrectionService_ApproveCorrection_AmendFails(t *testing.T) {
+	service, m := newCorrectionTestService()
+	leftAt := time.Now().Add(-8 * time.Hour).UTC()
+
+	// Mock expectations: the caregiver's visit was reset since the request
+	m.correctionRepo.On("GetByID", 4).Return(&models.VisitCorrection{ID: 4, ScheduleID: 1, CaregiverID: 1,
+		ProposedEndTime: &leftAt, ReasonCode: models.CorrectionReasonForgotClockOut, Status: models.CorrectionStatusPending, Version: 1}, nil)
+	m.amender.On("AmendVisit", 1, 1, (*time.Time)(nil), &leftAt, models.CorrectionReasonForgotClockOut).
+		Return(nil, ErrInvalidTransition)
+
+	// Execute
+	_, err := service.ApproveCorrection(4, &models.VisitCorrectionReviewRequest{})
+
+	// Assert: the correction stays pending
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	m.correctionRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestScheduleService_AmendVisit_ForgotClockOut(t *testing.T) {
+	service, m := newTeamTestService()
+	clockIn := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	leftAt := time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 1, 6, 20, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	// Mock expectations: the caregiver is still clocked in hours after leaving
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusInProgress}, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 1).Return(&models.Visit{ID: 7, ScheduleID: 1, CaregiverID: 1,
+		StartTime: &clockIn, Status: models.VisitStatusInProgress}, nil)
+	m.visitRepo.On("Update", mock.AnythingOfType("*models.Visit")).Return(nil)
+	m.visitSegmentRepo.On("Close", 1, 1, leftAt, 0.0, 0.0).Return(nil)
+	m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{{ScheduleID: 1, CaregiverID: 1, Status: models.VisitStatusCompleted}}, nil)
+	m.scheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.Status == models.ScheduleStatusCompleted
+	})).Return(nil)
+
+	// Execute
+	visit, err := service.AmendVisit(1, 1, nil, &leftAt, models.CorrectionReasonForgotClockOut)
+
+	// Assert: the recorded times are kept
+	assert.NoError(t, err)
+	assert.Equal(t, models.VisitStatusCompleted, visit.Status)
+	assert.Equal(t, clockIn, *visit.StartTime)
+	assert.Equal(t, leftAt, *visit.EndTime)
+	assert.Equal(t, clockIn, *visit.OriginalStartTime)
+	assert.Nil(t, visit.OriginalEndTime)
+	assert.Equal(t, now, *visit.AmendedAt)
+	assert.Equal(t, models.CorrectionReasonForgotClockOut, visit.AmendmentReason)
+	m.visitSegmentRepo.AssertExpectations(t)
+	m.scheduleRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_AmendVisit_KeepsFirstRecordedTimes(t *testing.T) {
+	service, m := newTeamTestService()
+	recordedIn := time.Date(2025, 1, 6, 9, 40, 0, 0, time.UTC)
+	recordedOut := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	firstIn := time.Date(2025, 1, 6, 9, 15, 0, 0, time.UTC)
+	arrived := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	amendedAt := time.Date(2025, 1, 6, 13, 0, 0, 0, time.UTC)
+
+	// Mock expectations: the visit was already amended once
+	m.scheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 1, Status: models.ScheduleStatusCompleted}, nil)
+	m.visitRepo.On("GetByScheduleAndCaregiver", 1, 1).Return(&models.Visit{ID: 7, ScheduleID: 1, CaregiverID: 1,
+		StartTime: &firstIn, EndTime: &recordedOut, OriginalStartTime: &recordedIn, OriginalEndTime: &recordedOut,
+		AmendedAt: &amendedAt, Status: models.VisitStatusCompleted}, nil)
+	m.visitRepo.On("Update", mock.AnythingOfType("*models.Visit")).Return(nil)
+
+	// Execute
+	visit, err := service.AmendVisit(1, 1, &arrived, nil, models.CorrectionReasonForgotClockIn)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, arrived, *visit.StartTime)
+	assert.Equal(t, recordedIn, *visit.OriginalStartTime)
+	assert.Equal(t, recordedOut, *visit.OriginalEndTime)
+	m.visitSegmentRepo.AssertNotCalled(t, "Close", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	m.scheduleRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestSummarizeVisit_Amended(t *testing.T) {
+	arrived := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	clockIn := time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC)
+	breakStart := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	breakEnd := time.Date(2025, 1, 6, 10, 30, 0, 0, time.UTC)
+	left := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC)
+
+	// The segments follow the recorded clock-in and a clock-out that never came
+	visit := &models.Visit{StartTime: &arrived, EndTime: &left, OriginalStartTime: &clockIn, AmendedAt: &now}
+	summarizeVisit(visit, []models.VisitSegment{
+		{Type: models.VisitSegmentTypeWork, StartTime: clockIn, EndTime: &breakStart},
+		{Type: models.VisitSegmentTypeBreak, StartTime: breakStart, EndTime: &breakEnd},
+		{Type: models.VisitSegmentTypeWork, StartTime: breakEnd, EndTime: &now},
+	}, now)
+
+	assert.Equal(t, 2.5, visit.WorkedHours)
+	assert.Equal(t, 0.5, visit.BreakHours)
+	assert.False(t, visit.OnBreak)
+}
+
+func TestScheduleService_ExportEVV(t *testing.T) {
+	service, m := newTeamTestService()
+	recordedIn := time.Date(2025, 1, 6, 9, 5, 0, 0, time.UTC)
+	arrived := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	left := time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC)
+	amendedAt := time.Date(2025, 1, 7, 8, 0, 0, 0, time.UTC)
+	memberIn := time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC)
+	memberOut := time.Date(2025, 1, 6, 10, 30, 0, 0, time.UTC)
+	lastWeek := time.Date(2024, 12, 30, 9, 0, 0, 0, time.UTC)
+
+	// Mock expectations
+	m.scheduleRepo.On("GetAll", mock.AnythingOfType("*models.ScheduleFilter")).Return([]models.Schedule{
+		{ID: 1, ClientID: 3, CaregiverID: 1, ServiceName: "Personal Care Service"},
+		{ID: 2, ClientID: 4, CaregiverID: 1},
+	}, &models.PageInfo{}, nil)
+	m.visitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{
+		{ID: 7, ScheduleID: 1, CaregiverID: 1, StartTime: &arrived, EndTime: &left, OriginalStartTime: &recordedIn,
+			AmendedAt: &amendedAt, AmendmentReason: models.CorrectionReasonForgotClockOut, StartVerificationMethod: models.VerificationMethodGPS},
+		{ID: 8, ScheduleID: 1, CaregiverID: 2, StartTime: &memberIn, EndTime: &memberOut},
+		{ID: 9, ScheduleID: 1, CaregiverID: 5, Status: models.VisitStatusNotStarted},
+	}, nil)
+	m.visitRepo.On("GetAllByScheduleID", 2).Return([]models.Visit{
+		{ID: 10, ScheduleID: 2, CaregiverID: 1, StartTime: &lastWeek},
+	}, nil)
+
+	// Execute
+	export, err := service.ExportEVV("2025-01-06", "2025-01-06")
+
+	// Assert: visits outside the range or never started are left out
+	assert.NoError(t, err)
+	if assert.Len(t, export.Visits, 2) {
+		amended := export.Visits[0]
+		assert.Equal(t, 7, amended.VisitID)
+		assert.True(t, amended.Amended)
+		assert.Equal(t, recordedIn, *amended.RecordedStartTime)
+		assert.Nil(t, amended.RecordedEndTime)
+		assert.Equal(t, arrived, *amended.StartTime)
+		assert.Equal(t, left, *amended.EndTime)
+		assert.Equal(t, models.CorrectionReasonForgotClockOut, amended.AmendmentReason)
+
+		recorded := export.Visits[1]
+		assert.False(t, recorded.Amended)
+		assert.Equal(t, memberIn, *recorded.RecordedStartTime)
+		assert.Equal(t, memberIn, *recorded.StartTime)
+	}
+}
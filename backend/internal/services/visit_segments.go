@@ -0,0 +1,252 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// summarizeVisit attaches the recorded segments to a visit and computes the
+// time worked and spent on breaks, counting running segments up to now
+func summarizeVisit(visit *models.Visit, segments []models.VisitSegment, now time.Time) {
+	visit.Segments = segments
+	visit.WorkedHours, visit.BreakHours, visit.OnBreak = 0, 0, false
+	if visit.StartTime == nil {
+		return
+	}
+
+	if len(segments) == 0 {
+		// Visits recorded before segments were tracked are one period of work
+		end := now
+		if visit.EndTime != nil {
+			end = *visit.EndTime
+		}
+		if end.After(*visit.StartTime) {
+			visit.WorkedHours = roundHours(end.Sub(*visit.StartTime))
+		}
+		return
+	}
+
+	if visit.AmendedAt != nil {
+		summarizeAmendedVisit(visit, segments, now)
+		return
+	}
+
+	var worked, breaks time.Duration
+	for _, seg := range segments {
+		end := now
+		if seg.EndTime != nil {
+			end = *seg.EndTime
+		} else if seg.Type == models.VisitSegmentTypeBreak {
+			visit.OnBreak = true
+		}
+		if !end.After(seg.StartTime) {
+			continue
+		}
+
+		switch seg.Type {
+		case models.VisitSegmentTypeWork:
+			worked += end.Sub(seg.StartTime)
+		case models.VisitSegmentTypeBreak:
+			breaks += end.Sub(seg.StartTime)
+		}
+	}
+
+	visit.WorkedHours = roundHours(worked)
+	visit.BreakHours = roundHours(breaks)
+}
+
+// summarizeAmendedVisit computes the time worked on a visit whose clock times
+// were corrected. Its segments follow the times first recorded, so the visit
+// counts from its corrected clock-in to clock-out less the breaks within them.
+func summarizeAmendedVisit(visit *models.Visit, segments []models.VisitSegment, now time.Time) {
+	end := now
+	if visit.EndTime != nil {
+		end = *visit.EndTime
+	}
+
+	var breaks time.Duration
+	for _, seg := range segments {
+		if seg.Type != models.VisitSegmentTypeBreak {
+			continue
+		}
+		segEnd := now
+		if seg.EndTime != nil {
+			segEnd = *seg.EndTime
+		} else {
+			visit.OnBreak = true
+		}
+		breaks += overlap(seg.StartTime, segEnd, *visit.StartTime, end)
+	}
+
+	if worked := end.Sub(*visit.StartTime) - breaks; worked > 0 {
+		visit.WorkedHours = roundHours(worked)
+	}
+	visit.BreakHours = roundHours(breaks)
+}
+
+// caregiverSegments returns the segments recorded by one caregiver of a team visit
+func caregiverSegments(segments []models.VisitSegment, caregiverID int) []models.VisitSegment {
+	var own []models.VisitSegment
+	for _, seg := range segments {
+		if seg.CaregiverID == caregiverID {
+			own = append(own, seg)
+		}
+	}
+	return own
+}
+
+// timesheetSegments returns the segments excluded from a caregiver's worked
+// hours on a schedule. Once their visit is tracked through segments its
+// recorded breaks replace the planned ones; planned sleep always applies.
+func timesheetSegments(schedule *models.Schedule, visit *models.Visit, now time.Time) []models.ScheduleSegment {
+	if visit == nil || len(visit.Segments) == 0 {
+		return schedule.Segments
+	}
+
+	var segments []models.ScheduleSegment
+	for _, seg := range visit.Segments {
+		if seg.Type != models.VisitSegmentTypeBreak {
+			continue
+		}
+		end := now
+		if seg.EndTime != nil {
+			end = *seg.EndTime
+		}
+		segments = append(segments, models.ScheduleSegment{
+			ScheduleID: schedule.ID,
+			Type:       models.SegmentTypeBreak,
+			StartTime:  seg.StartTime,
+			EndTime:    end,
+		})
+	}
+	for _, seg := range schedule.Segments {
+		if seg.Type == models.SegmentTypeSleep {
+			segments = append(segments, seg)
+		}
+	}
+
+	return segments
+}
+
+// PauseVisit ends the running work segment of a caregiver's in-progress visit
+// and starts a break, e.g. for lunch or a pharmacy run
+func (s *ScheduleService) PauseVisit(scheduleID int, req *models.VisitPauseRequest) error {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+		"latitude":     req.Latitude,
+		"longitude":    req.Longitude,
+	}).Info("Pausing visit")
+
+	return s.switchSegment(scheduleID, req.CaregiverID, req.Latitude, req.Longitude, models.VisitSegmentTypeBreak, req.Reason)
+}
+
+// ResumeVisit ends the running break of a caregiver's paused visit and starts
+// a new work segment
+func (s *ScheduleService) ResumeVisit(scheduleID int, req *models.VisitResumeRequest) error {
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": req.CaregiverID,
+		"latitude":     req.Latitude,
+		"longitude":    req.Longitude,
+	}).Info("Resuming visit")
+
+	return s.switchSegment(scheduleID, req.CaregiverID, req.Latitude, req.Longitude, models.VisitSegmentTypeWork, "")
+}
+
+// switchSegment closes the running segment of a caregiver's in-progress visit
+// and opens one of type next at the given location
+func (s *ScheduleService) switchSegment(scheduleID int, requested *int, latitude, longitude float64, next, notes string) error {
+	if latitude < -90 || latitude > 90 {
+		return fmt.Errorf("%w: latitude must be between -90 and 90", ErrValidation)
+	}
+	if longitude < -180 || longitude > 180 {
+		return fmt.Errorf("%w: longitude must be between -180 and 180", ErrValidation)
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to get schedule")
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule == nil {
+		return fmt.Errorf("schedule %d: %w", scheduleID, ErrNotFound)
+	}
+	if schedule.Status != models.ScheduleStatusInProgress {
+		return fmt.Errorf("%w: visit is not in progress (status %s)", ErrInvalidTransition, schedule.Status)
+	}
+
+	caregiverID, err := s.resolveCaregiver(schedule, requested)
+	if err != nil {
+		return err
+	}
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.Status != models.VisitStatusInProgress {
+		return fmt.Errorf("%w: caregiver %d is not clocked in", ErrInvalidTransition, caregiverID)
+	}
+
+	all, err := s.visitSegmentRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit segments: %w", err)
+	}
+	segments := caregiverSegments(all, caregiverID)
+
+	current := models.VisitSegmentTypeWork
+	if len(segments) > 0 && segments[len(segments)-1].EndTime == nil {
+		current = segments[len(segments)-1].Type
+	}
+	if current == next {
+		if next == models.VisitSegmentTypeBreak {
+			return fmt.Errorf("%w: visit is already paused", ErrInvalidTransition)
+		}
+		return fmt.Errorf("%w: visit is not paused", ErrInvalidTransition)
+	}
+
+	if len(segments) == 0 {
+		// Visit started before segments were tracked, record its work so far
+		if err := s.startWorkSegment(scheduleID, caregiverID); err != nil {
+			s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to backfill visit segment")
+			return fmt.Errorf("failed to record visit segment: %w", err)
+		}
+	}
+
+	segment := &models.VisitSegment{Type: next, Notes: notes}
+	if err := s.visitSegmentRepo.Switch(scheduleID, caregiverID, s.now(), latitude, longitude, segment); err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to switch visit segment")
+		return fmt.Errorf("failed to record visit segment: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"schedule_id":  scheduleID,
+		"caregiver_id": caregiverID,
+		"segment_id":   segment.ID,
+		"type":         next,
+	}).Info("Visit segment started")
+	return nil
+}
+
+// startWorkSegment opens the first work segment of a caregiver's visit at their clock-in
+func (s *ScheduleService) startWorkSegment(scheduleID, caregiverID int) error {
+	visit, err := s.visitRepo.GetByScheduleAndCaregiver(scheduleID, caregiverID)
+	if err != nil {
+		return fmt.Errorf("failed to get visit: %w", err)
+	}
+	if visit == nil || visit.StartTime == nil {
+		return nil
+	}
+
+	return s.visitSegmentRepo.Create(&models.VisitSegment{
+		ScheduleID:     scheduleID,
+		CaregiverID:    caregiverID,
+		Type:           models.VisitSegmentTypeWork,
+		StartTime:      *visit.StartTime,
+		StartLatitude:  visit.StartLatitude,
+		StartLongitude: visit.StartLongitude,
+	})
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"caregiver-shift-tracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newSegmentTestService() (*ScheduleService, *MockScheduleRepository, *MockVisitRepository, *MockVisitSegmentRepository) {
+	mockScheduleRepo := new(MockScheduleRepository)
+	mockVisitRepo := new(MockVisitRepository)
+	mockVisitSegmentRepo := new(MockVisitSegmentRepository)
+	service := NewScheduleService(ScheduleRepositories{
+		Schedules:     mockScheduleRepo,
+		Visits:        mockVisitRepo,
+		Tasks:         new(MockTaskRepository),
+		Segments:      new(MockScheduleSegmentRepository),
+		VisitSegments: mockVisitSegmentRepo,
+		Caregivers:    new(MockScheduleCaregiverRepository),
+	}, nil, logrus.New())
+	return service, mockScheduleRepo, mockVisitRepo, mockVisitSegmentRepo
+}
+
+func TestSummarizeVisit_Segments(t *testing.T) {
+	clockIn := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	lunch := clockIn.Add(2 * time.Hour)
+	back := lunch.Add(30 * time.Minute)
+	now := back.Add(time.Hour)
+
+	visit := &models.Visit{StartTime: &clockIn}
+	segments := []models.VisitSegment{
+		{Type: models.VisitSegmentTypeWork, StartTime: clockIn, EndTime: &lunch},
+		{Type: models.VisitSegmentTypeBreak, StartTime: lunch, EndTime: &back},
+		{Type: models.VisitSegmentTypeWork, StartTime: back},
+	}
+
+	summarizeVisit(visit, segments, now)
+
+	assert.Equal(t, 3.0, visit.WorkedHours)
+	assert.Equal(t, 0.5, visit.BreakHours)
+	assert.False(t, visit.OnBreak)
+	assert.Len(t, visit.Segments, 3)
+}
+
+func TestSummarizeVisit_OnBreak(t *testing.T) {
+	clockIn := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	pause := clockIn.Add(time.Hour)
+
+	visit := &models.Visit{StartTime: &clockIn}
+	segments := []models.VisitSegment{
+		{Type: models.VisitSegmentTypeWork, StartTime: clockIn, EndTime: &pause},
+		{Type: models.VisitSegmentTypeBreak, StartTime: pause},
+	}
+
+	summarizeVisit(visit, segments, pause.Add(15*time.Minute))
+
+	assert.Equal(t, 1.0, visit.WorkedHours)
+	assert.Equal(t, 0.25, visit.BreakHours)
+	assert.True(t, visit.OnBreak)
+}
+
+func TestSummarizeVisit_WithoutSegments(t *testing.T) {
+	clockIn := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	clockOut := clockIn.Add(90 * time.Minute)
+
+	visit := &models.Visit{StartTime: &clockIn, EndTime: &clockOut}
+	summarizeVisit(visit, nil, clockOut.Add(time.Hour))
+
+	assert.Equal(t, 1.5, visit.WorkedHours)
+	assert.Equal(t, 0.0, visit.BreakHours)
+}
+
+func TestTimesheetSegments_RecordedBreaksReplacePlanned(t *testing.T) {
+	start := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	pause := start.Add(4 * time.Hour)
+	resume := pause.Add(45 * time.Minute)
+
+	schedule := &models.Schedule{
+		ID: 1,
+		Segments: []models.ScheduleSegment{
+			{Type: models.SegmentTypeBreak, StartTime: start.Add(time.Hour), EndTime: start.Add(90 * time.Minute)},
+			{Type: models.SegmentTypeSleep, StartTime: start.Add(5 * time.Hour), EndTime: start.Add(10 * time.Hour)},
+		},
+	}
+	visit := &models.Visit{Segments: []models.VisitSegment{
+		{Type: models.VisitSegmentTypeWork, StartTime: start, EndTime: &pause},
+		{Type: models.VisitSegmentTypeBreak, StartTime: pause, EndTime: &resume},
+		{Type: models.VisitSegmentTypeWork, StartTime: resume},
+	}}
+
+	segments := timesheetSegments(schedule, visit, start.Add(12*time.Hour))
+
+	assert.Equal(t, []models.ScheduleSegment{
+		{ScheduleID: 1, Type: models.SegmentTypeBreak, StartTime: pause, EndTime: resume},
+		schedule.Segments[1],
+	}, segments)
+}
+
+func TestScheduleService_PauseVisit(t *testing.T) {
+	service, mockScheduleRepo, mockVisitRepo, mockVisitSegmentRepo := newSegmentTestService()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	clockIn := now.Add(-3 * time.Hour)
+	req := &models.VisitPauseRequest{Latitude: 40.7128, Longitude: -74.0060, Reason: "pharmacy run"}
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 7, Status: models.ScheduleStatusInProgress}, nil)
+	mockVisitRepo.On("GetByScheduleAndCaregiver", 1, 7).Return(&models.Visit{ScheduleID: 1, CaregiverID: 7, Status: models.VisitStatusInProgress}, nil)
+	mockVisitSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{
+		{ID: 1, CaregiverID: 7, Type: models.VisitSegmentTypeWork, StartTime: clockIn},
+	}, nil)
+	mockVisitSegmentRepo.On("Switch", 1, 7, now, req.Latitude, req.Longitude, mock.MatchedBy(func(seg *models.VisitSegment) bool {
+		return seg.Type == models.VisitSegmentTypeBreak && seg.Notes == "pharmacy run"
+	})).Return(nil)
+
+	// Execute
+	err := service.PauseVisit(1, req)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVisitSegmentRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_PauseVisit_AlreadyPaused(t *testing.T) {
+	service, mockScheduleRepo, mockVisitRepo, mockVisitSegmentRepo := newSegmentTestService()
+	clockIn := time.Now().Add(-time.Hour)
+	pause := clockIn.Add(30 * time.Minute)
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 7, Status: models.ScheduleStatusInProgress}, nil)
+	mockVisitRepo.On("GetByScheduleAndCaregiver", 1, 7).Return(&models.Visit{ScheduleID: 1, CaregiverID: 7, Status: models.VisitStatusInProgress}, nil)
+	mockVisitSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{
+		{ID: 1, CaregiverID: 7, Type: models.VisitSegmentTypeWork, StartTime: clockIn, EndTime: &pause},
+		{ID: 2, CaregiverID: 7, Type: models.VisitSegmentTypeBreak, StartTime: pause},
+	}, nil)
+
+	// Execute
+	err := service.PauseVisit(1, &models.VisitPauseRequest{Latitude: 1, Longitude: 1})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	mockVisitSegmentRepo.AssertNotCalled(t, "Switch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduleService_PauseVisit_BackfillsLegacyVisit(t *testing.T) {
+	service, mockScheduleRepo, mockVisitRepo, mockVisitSegmentRepo := newSegmentTestService()
+	clockIn := time.Now().Add(-time.Hour)
+	lat, lng := 40.7128, -74.0060
+
+	// Mock expectations: a visit started before segments were recorded
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 7, Status: models.ScheduleStatusInProgress}, nil)
+	mockVisitSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{}, nil)
+	mockVisitRepo.On("GetByScheduleAndCaregiver", 1, 7).Return(&models.Visit{ScheduleID: 1, CaregiverID: 7, Status: models.VisitStatusInProgress, StartTime: &clockIn, StartLatitude: &lat, StartLongitude: &lng}, nil)
+	mockVisitSegmentRepo.On("Create", mock.MatchedBy(func(seg *models.VisitSegment) bool {
+		return seg.Type == models.VisitSegmentTypeWork && seg.CaregiverID == 7 && seg.StartTime.Equal(clockIn)
+	})).Return(nil)
+	mockVisitSegmentRepo.On("Switch", 1, 7, mock.AnythingOfType("time.Time"), lat, lng, mock.AnythingOfType("*models.VisitSegment")).Return(nil)
+
+	// Execute
+	err := service.PauseVisit(1, &models.VisitPauseRequest{Latitude: lat, Longitude: lng})
+
+	// Assert
+	assert.NoError(t, err)
+	mockVisitSegmentRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_ResumeVisit_NotPaused(t *testing.T) {
+	service, mockScheduleRepo, mockVisitRepo, mockVisitSegmentRepo := newSegmentTestService()
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 7, Status: models.ScheduleStatusInProgress}, nil)
+	mockVisitRepo.On("GetByScheduleAndCaregiver", 1, 7).Return(&models.Visit{ScheduleID: 1, CaregiverID: 7, Status: models.VisitStatusInProgress}, nil)
+	mockVisitSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{
+		{ID: 1, CaregiverID: 7, Type: models.VisitSegmentTypeWork, StartTime: time.Now().Add(-time.Hour)},
+		// The team member's break does not pause the lead
+		{ID: 2, CaregiverID: 8, Type: models.VisitSegmentTypeBreak, StartTime: time.Now().Add(-time.Minute)},
+	}, nil)
+
+	// Execute
+	err := service.ResumeVisit(1, &models.VisitResumeRequest{Latitude: 1, Longitude: 1})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestScheduleService_PauseVisit_NotInProgress(t *testing.T) {
+	service, mockScheduleRepo, _, mockVisitSegmentRepo := newSegmentTestService()
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, Status: models.ScheduleStatusScheduled}, nil)
+
+	// Execute
+	err := service.PauseVisit(1, &models.VisitPauseRequest{Latitude: 1, Longitude: 1})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	mockVisitSegmentRepo.AssertNotCalled(t, "GetByScheduleID", mock.Anything)
+}
+
+func TestScheduleService_CancelVisit_InProgressDeletesSegments(t *testing.T) {
+	service, mockScheduleRepo, mockVisitRepo, mockVisitSegmentRepo := newSegmentTestService()
+
+	// Mock expectations
+	mockScheduleRepo.On("GetByID", 1).Return(&models.Schedule{ID: 1, CaregiverID: 7, Status: models.ScheduleStatusInProgress}, nil)
+	mockVisitRepo.On("GetByScheduleAndCaregiver", 1, 7).Return(&models.Visit{ScheduleID: 1, CaregiverID: 7, Status: models.VisitStatusInProgress}, nil)
+	mockVisitRepo.On("CancelVisit", 1, 7).Return(nil)
+	mockVisitSegmentRepo.On("DeleteByScheduleAndCaregiver", 1, 7).Return(nil)
+	mockVisitRepo.On("GetAllByScheduleID", 1).Return([]models.Visit{{ScheduleID: 1, CaregiverID: 7, Status: models.VisitStatusNotStarted}}, nil)
+	mockScheduleRepo.On("Update", mock.MatchedBy(func(s *models.Schedule) bool {
+		return s.Status == models.ScheduleStatusScheduled
+	})).Return(nil)
+
+	// Execute
+	err := service.CancelVisit(1, &models.VisitCancelRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	mockVisitSegmentRepo.AssertExpectations(t)
+}
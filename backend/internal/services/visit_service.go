@@ -11,15 +11,19 @@ import (
 
 // VisitService handles business logic for visits
 type VisitService struct {
-	visitRepo repositories.VisitRepository
-	logger    *logrus.Logger
+	visitRepo   repositories.VisitRepository
+	segmentRepo repositories.VisitSegmentRepository
+	states      *StateMachine[*models.Visit]
+	logger      *logrus.Logger
 }
 
 // NewVisitService creates a new visit service
-func NewVisitService(visitRepo repositories.VisitRepository, logger *logrus.Logger) *VisitService {
+func NewVisitService(visitRepo repositories.VisitRepository, segmentRepo repositories.VisitSegmentRepository, logger *logrus.Logger) *VisitService {
 	return &VisitService{
-		visitRepo: visitRepo,
-		logger:    logger,
+		visitRepo:   visitRepo,
+		segmentRepo: segmentRepo,
+		states:      NewVisitStateMachine(),
+		logger:      logger,
 	}
 }
 
@@ -38,6 +42,13 @@ func (s *VisitService) GetVisitByScheduleID(scheduleID int) (*models.Visit, erro
 		return nil, nil
 	}
 
+	segments, err := s.segmentRepo.GetByScheduleID(scheduleID)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to get visit segments")
+		return nil, fmt.Errorf("failed to get visit segments: %w", err)
+	}
+	summarizeVisit(visit, caregiverSegments(segments, visit.CaregiverID), time.Now())
+
 	s.logger.WithField("schedule_id", scheduleID).Debug("Successfully retrieved visit")
 	return visit, nil
 }
@@ -119,12 +130,18 @@ func (s *VisitService) EndVisit(scheduleID int, latitude, longitude float64, not
 		return fmt.Errorf("cannot end visit that hasn't been started")
 	}
 
+	transition, err := s.states.Fire(visit, VisitEventEnd)
+	if err != nil {
+		s.logger.WithError(err).WithField("schedule_id", scheduleID).Error("Visit cannot be ended")
+		return err
+	}
+
 	// Update the visit to completed status
-	now := time.Now()
+	now := transition.At
 	visit.EndTime = &now
 	visit.EndLatitude = &latitude
 	visit.EndLongitude = &longitude
-	visit.Status = "completed"
+	visit.Status = transition.To
 	visit.LocationStatus = "confirmed" // End location provided, so confirmed
 	if notes != "" {
 		visit.Notes = notes
@@ -135,6 +152,16 @@ func (s *VisitService) EndVisit(scheduleID int, latitude, longitude float64, not
 		return fmt.Errorf("failed to update visit: %w", err)
 	}
 
+	// Close the running work segment or break at the clock-out
+	if err := s.segmentRepo.Close(scheduleID, visit.CaregiverID, now, latitude, longitude); err != nil {
+		s.logger.WithError(err).WithField("visit_id", visit.ID).Error("Failed to close visit segment")
+		return fmt.Errorf("failed to close visit segment: %w", err)
+	}
+
+	if err := s.states.Complete(visit, transition); err != nil {
+		s.logger.WithError(err).WithField("visit_id", visit.ID).Warn("Visit transition hook failed")
+	}
+
 	s.logger.WithField("visit_id", visit.ID).Info("Successfully ended visit")
 	return nil
 }
@@ -149,13 +176,7 @@ func (s *VisitService) validateVisit(visit *models.Visit) error {
 		return fmt.Errorf("status is required")
 	}
 
-	validStatuses := map[string]bool{
-		"not_started": true,
-		"in_progress": true,
-		"completed":   true,
-	}
-
-	if !validStatuses[visit.Status] {
+	if !s.states.IsValidState(visit.Status) {
 		return fmt.Errorf("invalid status: %s", visit.Status)
 	}
 
@@ -165,7 +186,7 @@ func (s *VisitService) validateVisit(visit *models.Visit) error {
 	}
 
 	// If status is in_progress, start time and location should be set
-	if visit.Status == "in_progress" {
+	if visit.Status == models.VisitStatusInProgress {
 		if visit.StartTime == nil {
 			return fmt.Errorf("start_time is required when status is in_progress")
 		}
@@ -175,7 +196,7 @@ func (s *VisitService) validateVisit(visit *models.Visit) error {
 	}
 
 	// If status is completed, end time and location should be set
-	if visit.Status == "completed" {
+	if visit.Status == models.VisitStatusCompleted {
 		if visit.StartTime == nil {
 			return fmt.Errorf("start_time is required when status is completed")
 		}
@@ -13,8 +13,9 @@ import (
 func TestVisitService_GetVisitByScheduleID(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data
 	now := time.Now()
@@ -27,6 +28,7 @@ func TestVisitService_GetVisitByScheduleID(t *testing.T) {
 
 	// Mock expectations
 	mockVisitRepo.On("GetByScheduleID", 1).Return(expectedVisit, nil)
+	mockSegmentRepo.On("GetByScheduleID", 1).Return([]models.VisitSegment{}, nil)
 
 	// Execute
 	result, err := service.GetVisitByScheduleID(1)
@@ -36,6 +38,7 @@ func TestVisitService_GetVisitByScheduleID(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, 1, result.ScheduleID)
 	assert.Equal(t, "in_progress", result.Status)
+	assert.False(t, result.OnBreak)
 
 	// Verify mock expectations
 	mockVisitRepo.AssertExpectations(t)
@@ -44,8 +47,9 @@ func TestVisitService_GetVisitByScheduleID(t *testing.T) {
 func TestVisitService_GetVisitByScheduleID_NotFound(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Mock expectations
 	mockVisitRepo.On("GetByScheduleID", 999).Return(nil, nil)
@@ -64,8 +68,9 @@ func TestVisitService_GetVisitByScheduleID_NotFound(t *testing.T) {
 func TestVisitService_CreateVisit(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data
 	visit := &models.Visit{
@@ -89,8 +94,9 @@ func TestVisitService_CreateVisit(t *testing.T) {
 func TestVisitService_CreateVisit_ValidationError_MissingScheduleID(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with missing schedule ID
 	visit := &models.Visit{
@@ -108,8 +114,9 @@ func TestVisitService_CreateVisit_ValidationError_MissingScheduleID(t *testing.T
 func TestVisitService_CreateVisit_ValidationError_InvalidStatus(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with invalid status
 	visit := &models.Visit{
@@ -128,8 +135,9 @@ func TestVisitService_CreateVisit_ValidationError_InvalidStatus(t *testing.T) {
 func TestVisitService_CreateVisit_ValidationError_InProgressMissingStartTime(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with in_progress status but missing start time
 	visit := &models.Visit{
@@ -148,8 +156,9 @@ func TestVisitService_CreateVisit_ValidationError_InProgressMissingStartTime(t *
 func TestVisitService_CreateVisit_ValidationError_CompletedMissingEndTime(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with completed status but missing end time
 	now := time.Now()
@@ -175,8 +184,9 @@ func TestVisitService_CreateVisit_ValidationError_CompletedMissingEndTime(t *tes
 func TestVisitService_CreateVisit_ValidationError_InvalidLatitude(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with invalid latitude
 	now := time.Now()
@@ -201,8 +211,9 @@ func TestVisitService_CreateVisit_ValidationError_InvalidLatitude(t *testing.T)
 func TestVisitService_CreateVisit_ValidationError_InvalidLongitude(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with invalid longitude
 	now := time.Now()
@@ -227,8 +238,9 @@ func TestVisitService_CreateVisit_ValidationError_InvalidLongitude(t *testing.T)
 func TestVisitService_CreateVisit_ValidationError_EndTimeBeforeStartTime(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data with end time before start time
 	startTime := time.Now()
@@ -257,8 +269,9 @@ func TestVisitService_CreateVisit_ValidationError_EndTimeBeforeStartTime(t *test
 func TestVisitService_UpdateVisit(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data
 	now := time.Now()
@@ -292,8 +305,9 @@ func TestVisitService_UpdateVisit(t *testing.T) {
 func TestVisitService_EndVisit(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data
 	now := time.Now()
@@ -320,6 +334,7 @@ func TestVisitService_EndVisit(t *testing.T) {
 		updatedVisit.LocationStatus = "confirmed"
 		updatedVisit.Notes = "Test notes"
 	})
+	mockSegmentRepo.On("Close", 1, 0, mock.AnythingOfType("time.Time"), lat, lng).Return(nil)
 
 	// Execute
 	err := service.EndVisit(1, lat, lng, "Test notes")
@@ -342,8 +357,9 @@ func TestVisitService_EndVisit(t *testing.T) {
 func TestVisitService_CreateVisit_LocationStatusPending(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data - no location
 	visit := &models.Visit{
@@ -367,8 +383,9 @@ func TestVisitService_CreateVisit_LocationStatusPending(t *testing.T) {
 func TestVisitService_CreateVisit_LocationStatusConfirmed(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data - with location
 	now := time.Now()
@@ -398,8 +415,9 @@ func TestVisitService_CreateVisit_LocationStatusConfirmed(t *testing.T) {
 func TestVisitService_ValidateVisit_InvalidLocationStatus(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data
 	visit := &models.Visit{
@@ -421,8 +439,9 @@ func TestVisitService_ValidateVisit_InvalidLocationStatus(t *testing.T) {
 func TestVisitService_EndVisit_NotStarted(t *testing.T) {
 	// Setup
 	mockVisitRepo := new(MockVisitRepository)
+	mockSegmentRepo := new(MockVisitSegmentRepository)
 	logger := logrus.New()
-	service := NewVisitService(mockVisitRepo, logger)
+	service := NewVisitService(mockVisitRepo, mockSegmentRepo, logger)
 
 	// Test data - no start time
 	visit := &models.Visit{